@@ -0,0 +1,291 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bytesize
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// Size is a number of bytes.
+//
+// It implements encoding.TextMarshaler and encoding.TextUnmarshaler,
+// so it can be used directly in configuration structs.
+type Size int64
+
+// Binary units.
+const (
+	B Size = 1 << (10 * iota)
+	KiB
+	MiB
+	GiB
+	TiB
+	PiB
+	EiB
+)
+
+// Decimal units.
+const (
+	KB Size = 1000
+	MB      = 1000 * KB
+	GB      = 1000 * MB
+	TB      = 1000 * GB
+	PB      = 1000 * TB
+	EB      = 1000 * PB
+)
+
+// ErrInvalidSize is an error indicating that
+// the byte size string is malformed.
+//
+// The client should use errors.Is to test whether
+// an error is ErrInvalidSize.
+var ErrInvalidSize = errors.AutoNewCustom(
+	"invalid byte size",
+	errors.PrependFullPkgName,
+	0,
+)
+
+// ErrOutOfRange is an error indicating that
+// the byte size is out of the range of int64.
+//
+// The client should use errors.Is to test whether
+// an error is ErrOutOfRange.
+var ErrOutOfRange = errors.AutoNewCustom(
+	"byte size out of range",
+	errors.PrependFullPkgName,
+	0,
+)
+
+// unitMap maps the lowercase unit names to their sizes.
+var unitMap = map[string]Size{
+	"":    B,
+	"b":   B,
+	"k":   KiB,
+	"kb":  KB,
+	"kib": KiB,
+	"m":   MiB,
+	"mb":  MB,
+	"mib": MiB,
+	"g":   GiB,
+	"gb":  GB,
+	"gib": GiB,
+	"t":   TiB,
+	"tb":  TB,
+	"tib": TiB,
+	"p":   PiB,
+	"pb":  PB,
+	"pib": PiB,
+	"e":   EiB,
+	"eb":  EB,
+	"eib": EiB,
+}
+
+// numberRegexp matches a decimal number with optional sign, fractional part,
+// exponent, and underscores between digits.
+var numberRegexp = regexp.MustCompile(
+	`^[+-]?(?:\d(?:_?\d)*(?:\.(?:\d(?:_?\d)*)?)?|\.\d(?:_?\d)*)(?:[eE][+-]?\d(?:_?\d)*)?$`,
+)
+
+// Parse parses a byte size string, such as "10MiB", "1.5 GB", and "4096".
+//
+// The string consists of a decimal number (possibly with a sign,
+// a fractional part, an exponent, and underscores between digits),
+// optionally followed by white space and a unit.
+// The units are case-insensitive:
+//   - "B" or no unit: bytes.
+//   - "KiB", "MiB", "GiB", "TiB", "PiB", "EiB": binary units
+//     (powers of 1024).
+//   - "kB" (or "KB"), "MB", "GB", "TB", "PB", "EB": decimal units
+//     (powers of 1000).
+//   - "K", "M", "G", "T", "P", "E": binary units (same as "KiB", "MiB", etc.).
+//
+// The result is rounded to the nearest integer,
+// with halves rounded away from zero.
+//
+// If s is malformed, Parse reports ErrInvalidSize.
+// If the result is out of the range of int64, Parse reports ErrOutOfRange.
+// (To test whether err is ErrInvalidSize or ErrOutOfRange,
+// use function errors.Is.)
+func Parse(s string) (int64, error) {
+	t := strings.TrimSpace(s)
+	i := strings.LastIndexFunc(t, func(r rune) bool {
+		return r >= '0' && r <= '9' || r == '.'
+	})
+	num, unit := t[:i+1], strings.ToLower(strings.TrimSpace(t[i+1:]))
+	u, ok := unitMap[unit]
+	if !ok || !numberRegexp.MatchString(num) {
+		return 0, errors.AutoWrap(fmt.Errorf("%w: %q", ErrInvalidSize, s))
+	}
+	r, ok := new(big.Rat).SetString(strings.ReplaceAll(num, "_", ""))
+	if !ok {
+		return 0, errors.AutoWrap(fmt.Errorf("%w: %q", ErrInvalidSize, s))
+	}
+	r.Mul(r, new(big.Rat).SetInt64(int64(u)))
+	n, ok := roundRat(r)
+	if !ok {
+		return 0, errors.AutoWrap(fmt.Errorf("%w: %q", ErrOutOfRange, s))
+	}
+	return n, nil
+}
+
+// MustParse is like Parse but panics if s cannot be parsed.
+func MustParse(s string) int64 {
+	n, err := Parse(s)
+	if err != nil {
+		panic(errors.AutoWrap(err))
+	}
+	return n
+}
+
+// roundRat rounds r to the nearest integer,
+// with halves rounded away from zero.
+//
+// It reports false if the result is out of the range of int64.
+func roundRat(r *big.Rat) (n int64, ok bool) {
+	q, m := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	// Round away from zero if 2*|m| >= denominator.
+	if m.Sign() != 0 && new(big.Int).Abs(m.Lsh(m, 1)).Cmp(r.Denom()) >= 0 {
+		if r.Sign() > 0 {
+			q.Add(q, big.NewInt(1))
+		} else {
+			q.Sub(q, big.NewInt(1))
+		}
+	}
+	if !q.IsInt64() {
+		return 0, false
+	}
+	return q.Int64(), true
+}
+
+// binaryUnits and decimalUnits are the units used by Format,
+// in descending order.
+var (
+	binaryUnits = []struct {
+		size Size
+		name string
+	}{
+		{EiB, "EiB"}, {PiB, "PiB"}, {TiB, "TiB"},
+		{GiB, "GiB"}, {MiB, "MiB"}, {KiB, "KiB"},
+	}
+	decimalUnits = []struct {
+		size Size
+		name string
+	}{
+		{EB, "EB"}, {PB, "PB"}, {TB, "TB"},
+		{GB, "GB"}, {MB, "MB"}, {KB, "kB"},
+	}
+)
+
+// FormatBinary formats n bytes with the largest binary unit
+// (KiB, MiB, GiB, ...) not greater than |n|.
+//
+// prec is the maximum number of digits after the decimal point.
+// Trailing zeros after the decimal point are removed.
+// If prec is negative, the value is represented exactly.
+//
+// If |n| is less than 1 KiB, the result is in bytes (e.g., "512B").
+func FormatBinary(n int64, prec int) string {
+	return format(n, prec, binaryUnits)
+}
+
+// FormatDecimal formats n bytes with the largest decimal unit
+// (kB, MB, GB, ...) not greater than |n|.
+//
+// prec is the maximum number of digits after the decimal point.
+// Trailing zeros after the decimal point are removed.
+// If prec is negative, the value is represented exactly.
+//
+// If |n| is less than 1 kB, the result is in bytes (e.g., "512B").
+func FormatDecimal(n int64, prec int) string {
+	return format(n, prec, decimalUnits)
+}
+
+// format is the implementation of FormatBinary and FormatDecimal.
+func format(n int64, prec int, units []struct {
+	size Size
+	name string
+}) string {
+	abs := new(big.Int).Abs(big.NewInt(n))
+	for _, u := range units {
+		if abs.Cmp(big.NewInt(int64(u.size))) < 0 {
+			continue
+		}
+		r := new(big.Rat).SetFrac(big.NewInt(n), big.NewInt(int64(u.size)))
+		p := prec
+		if p < 0 {
+			// The unit size is at most 2^60 or 10^18,
+			// so 60 digits are sufficient to represent the value exactly.
+			p = 60
+		}
+		s := r.FloatString(p)
+		if strings.ContainsRune(s, '.') {
+			s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+		}
+		if s == "-0" {
+			s = "0"
+		}
+		return s + u.name
+	}
+	return strconv.FormatInt(n, 10) + "B"
+}
+
+// String formats the size by FormatBinary with at most 2 digits
+// after the decimal point.
+func (s Size) String() string {
+	return FormatBinary(int64(s), 2)
+}
+
+// MarshalText formats the size by FormatBinary with exact precision,
+// so that the result can be parsed back to the same size.
+//
+// It implements the interface encoding.TextMarshaler.
+func (s Size) MarshalText() ([]byte, error) {
+	return []byte(FormatBinary(int64(s), -1)), nil
+}
+
+// UnmarshalText parses text by Parse.
+//
+// It implements the interface encoding.TextUnmarshaler.
+func (s *Size) UnmarshalText(text []byte) error {
+	n, err := Parse(string(text))
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	*s = Size(n)
+	return nil
+}
+
+// Int returns the size as an int, clamped to the range of int.
+//
+// It is convenient for setting options of type int,
+// such as buffer sizes.
+func (s Size) Int() int {
+	if int64(s) > math.MaxInt {
+		return math.MaxInt
+	} else if int64(s) < math.MinInt {
+		return math.MinInt
+	}
+	return int(s)
+}
@@ -0,0 +1,164 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bytesize_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/donyori/gogo/bytesize"
+	"github.com/donyori/gogo/errors"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		s    string
+		want int64
+	}{
+		{"0", 0},
+		{"4096", 4096},
+		{"512B", 512},
+		{"1 b", 1},
+		{"1KiB", 1024},
+		{"1k", 1024},
+		{"1kB", 1000},
+		{"1KB", 1000},
+		{"10MiB", 10 << 20},
+		{"10 MB", 10_000_000},
+		{"1.5GB", 1_500_000_000},
+		{"1.5GiB", 3 << 29},
+		{"0.5KiB", 512},
+		{"1.1kB", 1100},
+		{"1e3", 1000},
+		{"2.5e-3MB", 2500},
+		{"1_024 KiB", 1 << 20},
+		{"-1MiB", -(1 << 20)},
+		{"+2KiB", 2048},
+		{"0.0004kB", 0},
+		{"0.0005kB", 1},
+		{"-0.0005kB", -1},
+		{"7EiB", 7 << 60},
+		{"  3 TiB  ", 3 << 40},
+	}
+	for _, tc := range testCases {
+		t.Run("s="+tc.s, func(t *testing.T) {
+			got, err := bytesize.Parse(tc.s)
+			if err != nil {
+				t.Fatal(err)
+			} else if got != tc.want {
+				t.Errorf("got %d; want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParse_Error(t *testing.T) {
+	testCases := []struct {
+		s    string
+		want error
+	}{
+		{"", bytesize.ErrInvalidSize},
+		{"MiB", bytesize.ErrInvalidSize},
+		{"1XB", bytesize.ErrInvalidSize},
+		{"1.2.3KB", bytesize.ErrInvalidSize},
+		{"1__0", bytesize.ErrInvalidSize},
+		{"_10", bytesize.ErrInvalidSize},
+		{"0x10", bytesize.ErrInvalidSize},
+		{"0b11", bytesize.ErrInvalidSize},
+		{"0o7", bytesize.ErrInvalidSize},
+		{"1/2KiB", bytesize.ErrInvalidSize},
+		{"+-1", bytesize.ErrInvalidSize},
+		{"8EiB", bytesize.ErrOutOfRange},
+		{"10EB", bytesize.ErrOutOfRange},
+	}
+	for _, tc := range testCases {
+		t.Run("s="+tc.s, func(t *testing.T) {
+			_, err := bytesize.Parse(tc.s)
+			if !errors.Is(err, tc.want) {
+				t.Errorf("got error %v; want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestFormatBinary(t *testing.T) {
+	testCases := []struct {
+		n    int64
+		prec int
+		want string
+	}{
+		{0, 2, "0B"},
+		{1023, 2, "1023B"},
+		{1024, 2, "1KiB"},
+		{1536, 2, "1.5KiB"},
+		{1 << 20, 2, "1MiB"},
+		{1<<20 + 1, 2, "1MiB"},
+		{1<<20 + 1, -1, "1.00000095367431640625MiB"},
+		{10<<30 + 512<<20, 1, "10.5GiB"},
+		{-1536, 0, "-2KiB"},
+		{math.MaxInt64, 3, "8EiB"},
+	}
+	for _, tc := range testCases {
+		got := bytesize.FormatBinary(tc.n, tc.prec)
+		if got != tc.want {
+			t.Errorf("n=%d, prec=%d: got %q; want %q", tc.n, tc.prec, got, tc.want)
+		}
+	}
+}
+
+func TestFormatDecimal(t *testing.T) {
+	testCases := []struct {
+		n    int64
+		prec int
+		want string
+	}{
+		{999, 2, "999B"},
+		{1000, 2, "1kB"},
+		{1_500_000_000, 2, "1.5GB"},
+		{1_234_567, 2, "1.23MB"},
+		{1_234_567, -1, "1.234567MB"},
+		{-2_000_000_000_000, 2, "-2TB"},
+	}
+	for _, tc := range testCases {
+		got := bytesize.FormatDecimal(tc.n, tc.prec)
+		if got != tc.want {
+			t.Errorf("n=%d, prec=%d: got %q; want %q", tc.n, tc.prec, got, tc.want)
+		}
+	}
+}
+
+func TestSize_Text(t *testing.T) {
+	for _, n := range []int64{0, 1, 1023, 1025, 3 << 29, 1<<20 + 1, math.MaxInt64, math.MinInt64} {
+		text, err := bytesize.Size(n).MarshalText()
+		if err != nil {
+			t.Errorf("n=%d: marshal - %v", n, err)
+			continue
+		}
+		var s bytesize.Size
+		err = s.UnmarshalText(text)
+		if err != nil {
+			t.Errorf("n=%d: unmarshal %q - %v", n, text, err)
+		} else if int64(s) != n {
+			t.Errorf("n=%d: round trip %q got %d", n, text, s)
+		}
+	}
+	if got := (bytesize.MiB + 512*bytesize.KiB).String(); got != "1.5MiB" {
+		t.Errorf("got String %q; want 1.5MiB", got)
+	}
+}
@@ -0,0 +1,24 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package bytesize provides functions to parse and format byte sizes
+// in human-readable form, such as "10MiB" and "1.5GB".
+//
+// Both binary units (KiB, MiB, GiB, ..., in powers of 1024)
+// and decimal units (kB, MB, GB, ..., in powers of 1000) are supported.
+package bytesize
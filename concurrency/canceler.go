@@ -20,6 +20,7 @@ package concurrency
 
 import (
 	"context"
+	"time"
 
 	"github.com/donyori/gogo/errors"
 )
@@ -39,6 +40,21 @@ type Canceler interface {
 	// Canceled reports whether the Canceler has broadcast
 	// the cancellation signal (i.e., the method Cancel has been called).
 	Canceled() bool
+
+	// AsContext returns a context.Context that is done
+	// when the Canceler broadcasts the cancellation signal,
+	// so that the cancellation can be passed to APIs
+	// accepting only context.Context (e.g., HTTP and database clients).
+	//
+	// The Done channel of the returned context is the same as
+	// the channel returned by the method C.
+	//
+	// If the Canceler wraps a context.Context,
+	// the returned context is that context,
+	// so its deadline and values are retained.
+	// Otherwise, the returned context has no deadline and no values,
+	// and its method Err returns context.Canceled after cancellation.
+	AsContext() context.Context
 }
 
 // ErrCanceled is an error indicating that
@@ -71,6 +87,37 @@ func (c *onceCanceler) Canceled() bool {
 	return c.o.Done()
 }
 
+func (c *onceCanceler) AsContext() context.Context {
+	return cancelerContext{c: c}
+}
+
+// cancelerContext is an implementation of interface context.Context
+// based on Canceler.
+//
+// It has no deadline and no values.
+type cancelerContext struct {
+	c Canceler
+}
+
+func (ctx cancelerContext) Deadline() (deadline time.Time, ok bool) {
+	return
+}
+
+func (ctx cancelerContext) Done() <-chan struct{} {
+	return ctx.c.C()
+}
+
+func (ctx cancelerContext) Err() error {
+	if ctx.c.Canceled() {
+		return context.Canceled
+	}
+	return nil
+}
+
+func (ctx cancelerContext) Value(any) any {
+	return nil
+}
+
 // NewCancelerFromContext wraps the specified context and
 // cancel function as a Canceler.
 //
@@ -126,6 +173,10 @@ func (c *contextCanceler) Canceled() bool {
 	}
 }
 
+func (c *contextCanceler) AsContext() context.Context {
+	return c.ctx
+}
+
 // NewCancelerFromContextCause wraps the specified context and
 // cancel function as a Canceler.
 //
@@ -180,3 +231,26 @@ func (c *contextCauseCanceler) Canceled() bool {
 		return false
 	}
 }
+
+func (c *contextCauseCanceler) AsContext() context.Context {
+	return c.ctx
+}
+
+// NewCancelerFromParentContext creates a new Canceler
+// that broadcasts the cancellation signal
+// when its method Cancel is called or parent is done.
+//
+// It derives a new context from parent.
+// The method AsContext of the returned Canceler returns the derived context,
+// which retains the deadline and values of parent.
+// When the Canceler cancels the derived context,
+// the cancellation cause is ErrCanceled.
+//
+// NewCancelerFromParentContext panics if parent is nil.
+func NewCancelerFromParentContext(parent context.Context) Canceler {
+	if parent == nil {
+		panic(errors.AutoMsg("the provided context is nil"))
+	}
+	ctx, cancel := context.WithCancelCause(parent)
+	return NewCancelerFromContextCause(ctx, cancel)
+}
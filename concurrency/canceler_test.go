@@ -49,6 +49,66 @@ func TestOnceCanceler(t *testing.T) {
 	wg.Wait()
 }
 
+func TestOnceCanceler_AsContext(t *testing.T) {
+	canceler := concurrency.NewCanceler()
+	ctx := canceler.AsContext()
+	if ctx.Done() != canceler.C() {
+		t.Error("Context.Done is not the same as Canceler.C")
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		t.Errorf("got deadline %v; want no deadline", deadline)
+	}
+	testContextDoneErrAndCause(t, "before calling Cancel, ", ctx, false, nil)
+	canceler.Cancel()
+	testContextDoneErrAndCause(
+		t, "after calling Cancel, ", ctx, true, context.Canceled)
+}
+
+func TestContextCanceler_AsContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	canceler := concurrency.NewCancelerFromContext(ctx, cancel)
+	if got := canceler.AsContext(); got != ctx {
+		t.Errorf("got %v; want %v", got, ctx)
+	}
+	causeCtx, causeCancel := context.WithCancelCause(context.Background())
+	defer causeCancel(concurrency.ErrCanceled)
+	canceler = concurrency.NewCancelerFromContextCause(causeCtx, causeCancel)
+	if got := canceler.AsContext(); got != causeCtx {
+		t.Errorf("got %v; want %v", got, causeCtx)
+	}
+}
+
+func TestNewCancelerFromParentContext_CancelByCanceler(t *testing.T) {
+	type keyType struct{}
+	parent := context.WithValue(context.Background(), keyType{}, 1)
+	canceler := concurrency.NewCancelerFromParentContext(parent)
+	ctx := canceler.AsContext()
+	if v := ctx.Value(keyType{}); v != 1 {
+		t.Errorf("got value %v; want 1", v)
+	}
+	testCancelerCAndCanceled(t, "before calling Cancel, ", canceler, false)
+	testContextDoneErrAndCause(t, "before calling Cancel, ", ctx, false, nil)
+	canceler.Cancel()
+	testCancelerCAndCanceled(t, "after calling Cancel, ", canceler, true)
+	testContextDoneErrAndCause(
+		t, "after calling Cancel, ", ctx, true, concurrency.ErrCanceled)
+	if err := parent.Err(); err != nil {
+		t.Errorf("got parent Context.Err %v; want <nil>", err)
+	}
+}
+
+func TestNewCancelerFromParentContext_CancelByParent(t *testing.T) {
+	parent, cancel := context.WithCancel(context.Background())
+	canceler := concurrency.NewCancelerFromParentContext(parent)
+	testCancelerCAndCanceled(t, "before canceling parent, ", canceler, false)
+	cancel()
+	<-canceler.C()
+	testCancelerCAndCanceled(t, "after canceling parent, ", canceler, true)
+	testContextDoneErrAndCause(t, "after canceling parent, ",
+		canceler.AsContext(), true, context.Canceled)
+}
+
 func TestContextCanceler_CancelByCanceler(t *testing.T) {
 	testContextCancelerFunc(t, 0)
 }
@@ -0,0 +1,179 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package concurrency
+
+import (
+	"container/list"
+	"context"
+	"sync"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// CondWakeReason is the reason why a wait on Cond ends.
+type CondWakeReason int8
+
+const (
+	// CondSignaled indicates that the waiter is woken up
+	// by the method Signal or Broadcast.
+	CondSignaled CondWakeReason = iota
+
+	// CondCanceled indicates that the wait is interrupted
+	// by a cancellation signal (of the Canceler or the context).
+	CondCanceled
+
+	// CondTimeout indicates that the wait is interrupted
+	// because the deadline of the context is exceeded.
+	CondTimeout
+)
+
+// Cond is a condition variable like sync.Cond,
+// except that its waits can be interrupted by a context.Context
+// or a Canceler.
+//
+// It is useful in framework-based code
+// where cancellation must interrupt waiting.
+type Cond interface {
+	// Locker returns the sync.Locker associated with the Cond,
+	// which must be held when calling the methods Wait and WaitCanceler,
+	// and can be held when calling the methods Signal and Broadcast.
+	Locker() sync.Locker
+
+	// Wait atomically unlocks the locker of the Cond and
+	// suspends the execution of the calling goroutine until
+	// it is woken up by the method Signal or Broadcast,
+	// or ctx is done.
+	// It locks the locker again before returning,
+	// regardless of the reason.
+	//
+	// It returns CondSignaled if woken up by Signal or Broadcast,
+	// CondTimeout if the deadline of ctx is exceeded,
+	// and CondCanceled if ctx is canceled otherwise.
+	//
+	// Like sync.Cond, the caller typically cannot assume that
+	// the condition is true when Wait returns,
+	// and should call Wait in a loop.
+	//
+	// Wait panics if ctx is nil.
+	Wait(ctx context.Context) CondWakeReason
+
+	// WaitCanceler is like Wait, but is interrupted by
+	// the cancellation signal of c instead of a context.
+	//
+	// It returns CondSignaled if woken up by Signal or Broadcast,
+	// and CondCanceled if c broadcasts the cancellation signal.
+	//
+	// WaitCanceler panics if c is nil.
+	WaitCanceler(c Canceler) CondWakeReason
+
+	// Signal wakes up one goroutine waiting on the Cond, if there is any.
+	Signal()
+
+	// Broadcast wakes up all goroutines waiting on the Cond.
+	Broadcast()
+}
+
+// NewCond creates a new Cond with the specified locker.
+//
+// NewCond panics if l is nil.
+func NewCond(l sync.Locker) Cond {
+	if l == nil {
+		panic(errors.AutoMsg("locker is nil"))
+	}
+	return &cond{l: l}
+}
+
+// cond is an implementation of interface Cond.
+type cond struct {
+	l       sync.Locker // Locker associated with the Cond.
+	m       sync.Mutex  // Lock for waiters.
+	waiters list.List   // Queue of waiters, with items of type chan struct{}.
+}
+
+func (c *cond) Locker() sync.Locker {
+	return c.l
+}
+
+func (c *cond) Wait(ctx context.Context) CondWakeReason {
+	if ctx == nil {
+		panic(errors.AutoMsg("the provided context is nil"))
+	}
+	if c.wait(ctx.Done()) {
+		return CondSignaled
+	} else if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return CondTimeout
+	}
+	return CondCanceled
+}
+
+func (c *cond) WaitCanceler(canceler Canceler) CondWakeReason {
+	if canceler == nil {
+		panic(errors.AutoMsg("canceler is nil"))
+	}
+	if c.wait(canceler.C()) {
+		return CondSignaled
+	}
+	return CondCanceled
+}
+
+func (c *cond) Signal() {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if front := c.waiters.Front(); front != nil {
+		close(c.waiters.Remove(front).(chan struct{}))
+	}
+}
+
+func (c *cond) Broadcast() {
+	c.m.Lock()
+	defer c.m.Unlock()
+	for e := c.waiters.Front(); e != nil; e = e.Next() {
+		close(e.Value.(chan struct{}))
+	}
+	c.waiters.Init()
+}
+
+// wait unlocks c.l, waits for a signal or doneC to be closed,
+// and then locks c.l again.
+//
+// It reports whether it is woken up by a signal.
+// If a signal and doneC arrive at the same time,
+// the signal takes precedence so that it is not lost.
+func (c *cond) wait(doneC <-chan struct{}) (signaled bool) {
+	ch := make(chan struct{})
+	c.m.Lock()
+	elem := c.waiters.PushBack(ch)
+	c.m.Unlock()
+	c.l.Unlock()
+	defer c.l.Lock()
+	select {
+	case <-ch:
+		return true
+	case <-doneC:
+	}
+	c.m.Lock()
+	defer c.m.Unlock()
+	select {
+	case <-ch:
+		return true // signaled before removal
+	default:
+		c.waiters.Remove(elem)
+		return false
+	}
+}
@@ -0,0 +1,142 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package concurrency_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/concurrency"
+)
+
+func TestCond_Signal(t *testing.T) {
+	var m sync.Mutex
+	c := concurrency.NewCond(&m)
+	if c.Locker() != &m {
+		t.Error("Locker is not the provided locker")
+	}
+	ready := false
+	resultC := make(chan concurrency.CondWakeReason, 1)
+	go func() {
+		m.Lock()
+		defer m.Unlock()
+		r := concurrency.CondSignaled
+		for !ready && r == concurrency.CondSignaled {
+			r = c.Wait(context.Background())
+		}
+		resultC <- r
+	}()
+	time.Sleep(time.Millisecond)
+	m.Lock()
+	ready = true
+	c.Signal()
+	m.Unlock()
+	select {
+	case r := <-resultC:
+		if r != concurrency.CondSignaled {
+			t.Errorf("got %v; want %v", r, concurrency.CondSignaled)
+		}
+	case <-time.After(time.Second):
+		t.Error("waiter is not woken up by Signal")
+	}
+}
+
+func TestCond_Broadcast(t *testing.T) {
+	const N = 8
+	var m sync.Mutex
+	c := concurrency.NewCond(&m)
+	ready := false
+	var wg sync.WaitGroup
+	wg.Add(N)
+	for range N {
+		go func() {
+			defer wg.Done()
+			canceler := concurrency.NewCanceler()
+			m.Lock()
+			defer m.Unlock()
+			for !ready {
+				if r := c.WaitCanceler(canceler); r != concurrency.CondSignaled {
+					t.Errorf("got %v; want %v", r, concurrency.CondSignaled)
+					return
+				}
+			}
+		}()
+	}
+	time.Sleep(time.Millisecond)
+	m.Lock()
+	ready = true
+	c.Broadcast()
+	m.Unlock()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		wg.Wait()
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("waiters are not woken up by Broadcast")
+	}
+}
+
+func TestCond_Wait_Timeout(t *testing.T) {
+	var m sync.Mutex
+	c := concurrency.NewCond(&m)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	m.Lock()
+	r := c.Wait(ctx)
+	if !m.TryLock() {
+		m.Unlock() // the lock is held again after Wait returns
+	} else {
+		t.Error("lock is not held after Wait returns")
+	}
+	if r != concurrency.CondTimeout {
+		t.Errorf("got %v; want %v", r, concurrency.CondTimeout)
+	}
+	c.Signal() // no waiter, no effect
+}
+
+func TestCond_Wait_Canceled(t *testing.T) {
+	var m sync.Mutex
+	c := concurrency.NewCond(&m)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(time.Millisecond, cancel)
+	m.Lock()
+	r := c.Wait(ctx)
+	m.Unlock()
+	if r != concurrency.CondCanceled {
+		t.Errorf("got %v; want %v", r, concurrency.CondCanceled)
+	}
+}
+
+func TestCond_WaitCanceler_Canceled(t *testing.T) {
+	var m sync.Mutex
+	c := concurrency.NewCond(&m)
+	canceler := concurrency.NewCanceler()
+	time.AfterFunc(time.Millisecond, canceler.Cancel)
+	m.Lock()
+	r := c.WaitCanceler(canceler)
+	m.Unlock()
+	if r != concurrency.CondCanceled {
+		t.Errorf("got %v; want %v", r, concurrency.CondCanceled)
+	}
+}
@@ -0,0 +1,156 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package concurrency
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// Debouncer is a wrapper of a function that delays calling the function
+// until a period has elapsed since the last call to the wrapper.
+//
+// It is useful for coalescing bursts of events
+// (e.g., file system notifications) into one handling.
+//
+// The wrapped function is never called concurrently with itself.
+//
+// It is safe for concurrent use by multiple goroutines.
+type Debouncer interface {
+	// Call schedules a call to the wrapped function
+	// after the delay of the Debouncer.
+	//
+	// If there is a scheduled call, Call postpones it instead,
+	// so that the function is called only once
+	// after a burst of calls to Call.
+	//
+	// The wrapped function is called on another goroutine.
+	//
+	// After calling the method Stop, Call does nothing.
+	Call()
+
+	// Flush calls the wrapped function immediately on the current goroutine
+	// if there is a scheduled call, and cancels the scheduled call.
+	//
+	// It reports whether the wrapped function is called.
+	Flush() bool
+
+	// Stop cancels the scheduled call (if any) and
+	// makes subsequent calls to the method Call do nothing.
+	//
+	// It reports whether a scheduled call is canceled.
+	Stop() bool
+}
+
+// Debounce wraps fn as a Debouncer with the delay d.
+//
+// Debounce panics if fn is nil or d is nonpositive.
+func Debounce(fn func(), d time.Duration) Debouncer {
+	if fn == nil {
+		panic(errors.AutoMsg("fn is nil"))
+	} else if d <= 0 {
+		panic(errors.AutoMsg(fmt.Sprintf("d (%v) is nonpositive", d)))
+	}
+	return &debouncer{fn: fn, d: d}
+}
+
+// debouncer is an implementation of interface Debouncer.
+type debouncer struct {
+	fn func()        // The wrapped function.
+	d  time.Duration // The delay.
+	fm sync.Mutex    // Lock to avoid calling fn concurrently.
+
+	m       sync.Mutex  // Lock for the following fields.
+	timer   *time.Timer // Timer for the scheduled call.
+	gen     uint64      // Generation of the scheduled call, to identify outdated timers.
+	pending bool        // An indicator to report whether there is a scheduled call.
+	stopped bool        // An indicator to report whether the method Stop is called.
+}
+
+func (db *debouncer) Call() {
+	db.m.Lock()
+	defer db.m.Unlock()
+	if db.stopped {
+		return
+	}
+	db.cancelTimer()
+	db.pending = true
+	gen := db.gen
+	db.timer = time.AfterFunc(db.d, func() {
+		db.fire(gen)
+	})
+}
+
+func (db *debouncer) Flush() bool {
+	db.m.Lock()
+	if !db.pending {
+		db.m.Unlock()
+		return false
+	}
+	db.cancelTimer()
+	db.pending = false
+	db.m.Unlock()
+	db.callFn()
+	return true
+}
+
+func (db *debouncer) Stop() bool {
+	db.m.Lock()
+	defer db.m.Unlock()
+	db.stopped = true
+	pending := db.pending
+	db.cancelTimer()
+	db.pending = false
+	return pending
+}
+
+// cancelTimer stops the current timer and
+// makes it outdated in case it has fired.
+//
+// The caller must hold db.m.
+func (db *debouncer) cancelTimer() {
+	if db.timer != nil {
+		db.timer.Stop()
+		db.timer = nil
+	}
+	db.gen++
+}
+
+// fire is called by the timer of generation gen.
+func (db *debouncer) fire(gen uint64) {
+	db.m.Lock()
+	if gen != db.gen || !db.pending {
+		db.m.Unlock()
+		return
+	}
+	db.timer = nil
+	db.pending = false
+	db.m.Unlock()
+	db.callFn()
+}
+
+// callFn calls the wrapped function without concurrency.
+func (db *debouncer) callFn() {
+	db.fm.Lock()
+	defer db.fm.Unlock()
+	db.fn()
+}
@@ -0,0 +1,91 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package concurrency_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/concurrency"
+)
+
+func TestDebounce(t *testing.T) {
+	const D = 20 * time.Millisecond
+	var n atomic.Int32
+	called := make(chan struct{}, 10)
+	db := concurrency.Debounce(func() {
+		n.Add(1)
+		called <- struct{}{}
+	}, D)
+	for range 5 {
+		db.Call()
+		time.Sleep(D / 5)
+	}
+	select {
+	case <-called:
+	case <-time.After(time.Second):
+		t.Fatal("wrapped function is not called")
+	}
+	time.Sleep(D * 2)
+	if got := n.Load(); got != 1 {
+		t.Errorf("got %d calls; want 1", got)
+	}
+}
+
+func TestDebouncer_Flush(t *testing.T) {
+	var n atomic.Int32
+	db := concurrency.Debounce(func() {
+		n.Add(1)
+	}, time.Hour)
+	if db.Flush() {
+		t.Error("Flush returned true without a scheduled call")
+	}
+	db.Call()
+	db.Call()
+	if !db.Flush() {
+		t.Error("Flush returned false with a scheduled call")
+	}
+	if got := n.Load(); got != 1 {
+		t.Errorf("got %d calls; want 1", got)
+	}
+	if db.Flush() {
+		t.Error("Flush returned true after flushing")
+	}
+}
+
+func TestDebouncer_Stop(t *testing.T) {
+	const D = 5 * time.Millisecond
+	var n atomic.Int32
+	db := concurrency.Debounce(func() {
+		n.Add(1)
+	}, D)
+	db.Call()
+	if !db.Stop() {
+		t.Error("Stop returned false with a scheduled call")
+	}
+	db.Call()
+	if db.Stop() {
+		t.Error("Stop returned true after stopping")
+	}
+	time.Sleep(D * 4)
+	if got := n.Load(); got != 0 {
+		t.Errorf("got %d calls; want 0", got)
+	}
+}
@@ -0,0 +1,31 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package group provides a simple framework to run a fixed set of tasks
+// on multiple goroutines, in the style of golang.org/x/sync/errgroup.
+//
+// To use this framework, you should start with the function New
+// (or NewWithContext) to create a Group.
+// And then call its method Go to run each task on a new goroutine.
+// Finally, call its method Wait to wait for the tasks to finish,
+// and get the first error and the panic records.
+//
+// Compared with the framework jobsched,
+// it is suitable for the cases where the tasks are known in advance
+// and do not generate new tasks.
+package group
@@ -0,0 +1,173 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package group
+
+import (
+	"context"
+	"runtime/debug"
+	"strconv"
+	"sync"
+
+	"github.com/donyori/gogo/concurrency"
+	"github.com/donyori/gogo/concurrency/framework"
+	"github.com/donyori/gogo/errors"
+)
+
+// Group is a collection of goroutines running tasks
+// for subtasks of a common job.
+//
+// The first task that returns a non-nil error or panics
+// cancels the group.
+type Group interface {
+	// Canceler returns a concurrency.Canceler for the group.
+	//
+	// It is the same as the canceler passed to the tasks.
+	// Calls to Canceler always return the same non-nil value.
+	Canceler() concurrency.Canceler
+
+	// SetLimit limits the number of active tasks in the group to n.
+	// Nonpositive n for no limit.
+	//
+	// SetLimit panics if there is any active task in the group.
+	SetLimit(n int)
+
+	// Go runs the specified task on a new goroutine.
+	//
+	// If the number of active tasks reaches the limit set by SetLimit,
+	// Go blocks until a task finishes.
+	//
+	// The parameter of task is the canceler of the group.
+	// The task should return as soon as possible
+	// after the canceler broadcasts the cancellation signal.
+	//
+	// The first task that returns a non-nil error or panics
+	// cancels the group.
+	// The task is run even if the group has been canceled.
+	//
+	// Go panics if task is nil.
+	Go(task func(canceler concurrency.Canceler) error)
+
+	// Wait waits for all the tasks started by the method Go to finish,
+	// and then cancels the group.
+	//
+	// It returns the panic records of the tasks
+	// and the first non-nil error returned by the tasks (if any).
+	Wait() (panicRecords []framework.PanicRecord, err error)
+}
+
+// New creates a new Group.
+func New() Group {
+	return &group{
+		c:  concurrency.NewCanceler(),
+		pr: concurrency.NewRecorder[framework.PanicRecord](0),
+	}
+}
+
+// NewWithContext creates a new Group bound to ctx.
+//
+// The canceler of the group is derived from ctx.
+// When ctx is canceled (or its deadline is exceeded),
+// the canceler broadcasts the cancellation signal.
+//
+// NewWithContext panics if ctx is nil.
+func NewWithContext(ctx context.Context) Group {
+	if ctx == nil {
+		panic(errors.AutoMsg("the provided context is nil"))
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &group{
+		c:  concurrency.NewCancelerFromContextCause(ctx, cancel),
+		pr: concurrency.NewRecorder[framework.PanicRecord](0),
+	}
+}
+
+// group is an implementation of interface Group.
+type group struct {
+	c   concurrency.Canceler                        // Canceler.
+	pr  concurrency.Recorder[framework.PanicRecord] // Panic recorder.
+	wg  sync.WaitGroup                              // Wait group for the tasks.
+	sem chan struct{}                               // Semaphore to limit the number of active tasks, or nil if there is no limit.
+	nt  int                                         // The number of tasks started by the method Go, used to name the goroutines.
+	m   sync.Mutex                                  // Lock for sem and nt.
+
+	eo  sync.Once // For recording the first error.
+	err error     // The first error returned by the tasks.
+}
+
+func (g *group) Canceler() concurrency.Canceler {
+	return g.c
+}
+
+func (g *group) SetLimit(n int) {
+	g.m.Lock()
+	defer g.m.Unlock()
+	if g.sem != nil && len(g.sem) > 0 {
+		panic(errors.AutoMsg("cannot modify the limit with " +
+			strconv.Itoa(len(g.sem)) + " active tasks"))
+	}
+	if n <= 0 {
+		g.sem = nil
+	} else {
+		g.sem = make(chan struct{}, n)
+	}
+}
+
+func (g *group) Go(task func(canceler concurrency.Canceler) error) {
+	if task == nil {
+		panic(errors.AutoMsg("task is nil"))
+	}
+	g.m.Lock()
+	sem, i := g.sem, g.nt
+	g.nt++
+	g.m.Unlock()
+	if sem != nil {
+		sem <- struct{}{}
+	}
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if sem != nil {
+			defer func() {
+				<-sem
+			}()
+		}
+		defer func() {
+			if e := recover(); e != nil {
+				g.c.Cancel()
+				g.pr.Record(framework.PanicRecord{
+					Name:       "task " + strconv.Itoa(i),
+					Content:    e,
+					StackTrace: string(debug.Stack()),
+				})
+			}
+		}()
+		if err := task(g.c); err != nil {
+			g.eo.Do(func() {
+				g.err = err
+				g.c.Cancel()
+			})
+		}
+	}()
+}
+
+func (g *group) Wait() (panicRecords []framework.PanicRecord, err error) {
+	g.wg.Wait()
+	g.c.Cancel()
+	return g.pr.All(), g.err
+}
@@ -0,0 +1,179 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package group_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/concurrency"
+	"github.com/donyori/gogo/concurrency/framework/group"
+)
+
+func TestGroup_NoError(t *testing.T) {
+	const N = 20
+	var ctr atomic.Int32
+	g := group.New()
+	for range N {
+		g.Go(func(canceler concurrency.Canceler) error {
+			ctr.Add(1)
+			return nil
+		})
+	}
+	prs, err := g.Wait()
+	if len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+	if err != nil {
+		t.Error(err)
+	}
+	if n := ctr.Load(); n != N {
+		t.Errorf("got %d tasks run; want %d", n, N)
+	}
+	if !g.Canceler().Canceled() {
+		t.Error("group not canceled after Wait")
+	}
+}
+
+func TestGroup_FirstError(t *testing.T) {
+	errFirst := errors.New("first error")
+	errSecond := errors.New("second error")
+	g := group.New()
+	g.Go(func(canceler concurrency.Canceler) error {
+		return errFirst
+	})
+	g.Go(func(canceler concurrency.Canceler) error {
+		select {
+		case <-canceler.C():
+		case <-time.After(time.Minute):
+			t.Error("canceler not canceled after the first error")
+		}
+		return errSecond
+	})
+	prs, err := g.Wait()
+	if len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+	if !errors.Is(err, errFirst) {
+		t.Errorf("got error %v; want %v", err, errFirst)
+	}
+}
+
+func TestGroup_Panic(t *testing.T) {
+	const PanicMsg = "test panic"
+	g := group.New()
+	g.Go(func(canceler concurrency.Canceler) error {
+		panic(PanicMsg)
+	})
+	g.Go(func(canceler concurrency.Canceler) error {
+		<-canceler.C()
+		return nil
+	})
+	prs, err := g.Wait()
+	if err != nil {
+		t.Error(err)
+	}
+	if len(prs) != 1 {
+		t.Fatalf("got %d panic records; want 1", len(prs))
+	}
+	if prs[0].Name != "task 0" || prs[0].Content != PanicMsg {
+		t.Errorf("got panic record %+v; want Name %q, Content %q",
+			prs[0], "task 0", PanicMsg)
+	}
+	if !strings.Contains(prs[0].StackTrace, "TestGroup_Panic") {
+		t.Errorf("got stack trace %q; want it to contain %q",
+			prs[0].StackTrace, "TestGroup_Panic")
+	}
+}
+
+func TestGroup_SetLimit(t *testing.T) {
+	const N, Limit = 30, 3
+	var active, maxActive atomic.Int32
+	g := group.New()
+	g.SetLimit(Limit)
+	for range N {
+		g.Go(func(canceler concurrency.Canceler) error {
+			n := active.Add(1)
+			for {
+				m := maxActive.Load()
+				if n <= m || maxActive.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			active.Add(-1)
+			return nil
+		})
+	}
+	prs, err := g.Wait()
+	if len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+	if err != nil {
+		t.Error(err)
+	}
+	if n := maxActive.Load(); n > Limit || n < 1 {
+		t.Errorf("got max active tasks %d; want in [1, %d]", n, Limit)
+	}
+}
+
+func TestGroup_SetLimit_ActiveTasks(t *testing.T) {
+	release := make(chan struct{})
+	g := group.New()
+	g.SetLimit(1)
+	g.Go(func(canceler concurrency.Canceler) error {
+		<-release
+		return nil
+	})
+	func() {
+		defer func() {
+			if e := recover(); e == nil {
+				t.Error("want panic but not")
+			}
+		}()
+		g.SetLimit(2)
+	}()
+	close(release)
+	g.Wait()
+}
+
+func TestNewWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	g := group.NewWithContext(ctx)
+	g.Go(func(canceler concurrency.Canceler) error {
+		select {
+		case <-canceler.C():
+		case <-time.After(time.Minute):
+			t.Error("canceler not canceled after canceling the context")
+		}
+		return nil
+	})
+	cancel()
+	prs, err := g.Wait()
+	if len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+	if err != nil {
+		t.Error(err)
+	}
+}
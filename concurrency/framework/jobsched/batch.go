@@ -0,0 +1,210 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobsched
+
+import (
+	"time"
+
+	"github.com/donyori/gogo/concurrency"
+	"github.com/donyori/gogo/errors"
+)
+
+// BatchJobHandler is a function to process a batch of jobs.
+//
+// The first type parameter Job is the type of jobs.
+// The second type parameter Properties is the type of custom properties
+// in the meta information of jobs.
+// The third type parameter Feedback is the type of feedback on the jobs,
+// which is collected and handled in a dedicated goroutine.
+//
+// Its parameters and return values are the same as those of JobHandler,
+// except that it processes the jobs in the third parameter jobs at once,
+// and returns the new jobs and the feedback on the whole batch.
+// jobs is nonempty and contains at most Options.MaxBatch jobs.
+// jobs is not reused by the framework after the handler returns.
+//
+// If any job in the batch has a deadline
+// (see the fields Deadline and Timeout of Meta),
+// the canceler broadcasts the cancellation signal
+// when the earliest deadline is exceeded.
+type BatchJobHandler[Job, Properties, Feedback any] func(
+	canceler concurrency.Canceler,
+	rank int,
+	jobs []Job,
+) (newJobs []*MetaJob[Job, Properties], feedback Feedback)
+
+// NewBatch is like New but creates a controller that passes
+// the jobs to the batch job handler in batches,
+// which improves throughput for the handlers that perform batched I/O,
+// such as bulk database inserts.
+//
+// The size of batches is controlled by
+// the options MaxBatch and MaxBatchDelay.
+//
+// batchHandler is the batch job handler.
+// NewBatch panics if batchHandler is nil.
+//
+// The option RetryPolicy is ignored.
+// The option OnComplete is called for each job in the batch,
+// with the time spent by the batch job handler as the latency.
+// If the option OrderedFeedback is true,
+// the feedback on a batch is ordered by the first job of the batch.
+//
+// The other parameters are the same as those of function New.
+func NewBatch[Job, Properties, Feedback any](
+	batchHandler BatchJobHandler[Job, Properties, Feedback],
+	feedbackHandler FeedbackHandler[Feedback],
+	opts *Options[Job, Properties, Feedback],
+	metaJob ...*MetaJob[Job, Properties],
+) Controller[Job, Properties, Feedback] {
+	if batchHandler == nil {
+		panic(errors.AutoMsg("batch job handler is nil"))
+	}
+	return newController(nil, batchHandler, feedbackHandler, opts, metaJob...)
+}
+
+// collectBatch collects a batch of up to ctrl.mb jobs
+// from ctrl.dqc, starting with mj.
+//
+// It waits at most ctrl.mbd for the jobs after mj,
+// and stops waiting when the controller is canceled or quit is closed.
+func (ctrl *controller[Job, Properties, Feedback]) collectBatch(
+	mj *MetaJob[Job, Properties],
+	quit <-chan struct{},
+) []*MetaJob[Job, Properties] {
+	batch := []*MetaJob[Job, Properties]{mj}
+	var timerC <-chan time.Time
+	if ctrl.mbd > 0 && ctrl.mb > 1 {
+		timer := time.NewTimer(ctrl.mbd)
+		defer timer.Stop()
+		timerC = timer.C
+	}
+	for len(batch) < ctrl.mb {
+		if timerC == nil {
+			select {
+			case mj, ok := <-ctrl.dqc:
+				if !ok {
+					return batch
+				}
+				batch = append(batch, mj)
+			default:
+				return batch
+			}
+			continue
+		}
+		select {
+		case <-ctrl.c.C():
+			return batch
+		case <-quit:
+			return batch
+		case <-timerC:
+			return batch
+		case mj, ok := <-ctrl.dqc:
+			if !ok {
+				return batch
+			}
+			batch = append(batch, mj)
+		}
+	}
+	return batch
+}
+
+// trackBatch calls ctrl.handleBatch and updates the statistics rs
+// of the worker goroutine.
+//
+// It also calls ctrl.onComplete (if not nil) for each job in batch
+// if ctrl.handleBatch returns normally.
+func (ctrl *controller[Job, Properties, Feedback]) trackBatch(
+	rank int,
+	rs *rankStats,
+	batch []*MetaJob[Job, Properties],
+) (newJobs []*MetaJob[Job, Properties], feedback Feedback) {
+	n := int64(len(batch))
+	rs.nf.Add(n)
+	var ok bool
+	var start time.Time
+	timed := ctrl.ls || ctrl.onComplete != nil
+	if timed {
+		start = time.Now()
+	}
+	defer func() {
+		rs.nf.Add(-n)
+		if !ok {
+			return
+		}
+		rs.nc.Add(n)
+		if !timed {
+			return
+		}
+		latency := time.Since(start)
+		if ctrl.ls {
+			rs.tl.Add(int64(latency) * n)
+		}
+		if ctrl.onComplete != nil {
+			for _, mj := range batch {
+				ctrl.onComplete(ctrl, rank, mj.Job, latency)
+			}
+		}
+	}()
+	newJobs, feedback = ctrl.handleBatch(rank, batch)
+	ok = true
+	return
+}
+
+// handleBatch processes the specified batch of jobs
+// with the batch job handler on the worker goroutine of the specified rank.
+//
+// If any job in the batch has a deadline, it passes a canceler that
+// broadcasts the cancellation signal when the earliest deadline is exceeded
+// to the batch job handler, and reports each job whose deadline is exceeded
+// when the batch job handler returns to ctrl.onTimeout (if not nil).
+func (ctrl *controller[Job, Properties, Feedback]) handleBatch(
+	rank int,
+	batch []*MetaJob[Job, Properties],
+) (newJobs []*MetaJob[Job, Properties], feedback Feedback) {
+	jobs := make([]Job, len(batch))
+	var earliest time.Time
+	start := time.Now()
+	for i, mj := range batch {
+		jobs[i] = mj.Job
+		if d, ok := jobDeadline(&mj.Meta, start); ok &&
+			(earliest.IsZero() || d.Before(earliest)) {
+			earliest = d
+		}
+	}
+	if earliest.IsZero() {
+		return ctrl.bh(ctrl.c, rank, jobs)
+	}
+	jc := newJobCanceler(ctrl.c, earliest)
+	defer jc.stop()
+	newJobs, feedback = ctrl.bh(jc, rank, jobs)
+	if ctrl.onTimeout != nil {
+		now := time.Now()
+		for _, mj := range batch {
+			if d, ok := jobDeadline(&mj.Meta, start); ok && !now.Before(d) {
+				ctrl.onTimeout(ctrl, TimeoutRecord[Job, Properties]{
+					MetaJob:  mj,
+					Deadline: d,
+					Rank:     rank,
+				})
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,155 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobsched_test
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/concurrency"
+	"github.com/donyori/gogo/concurrency/framework/jobsched"
+)
+
+func TestNewBatch(t *testing.T) {
+	const NumJob = 100
+	const MaxBatch = 8
+	var mu sync.Mutex
+	counter := make([]int, NumJob)
+	var maxSize int
+	var sum int
+	ctrl := jobsched.NewBatch(func(canceler concurrency.Canceler, rank int, jobs []int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback int) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, job := range jobs {
+			counter[job]++
+		}
+		maxSize = max(maxSize, len(jobs))
+		return nil, len(jobs)
+	}, func(canceler concurrency.Canceler, feedback int) {
+		sum += feedback // called by only one goroutine
+	}, &jobsched.Options[int, jobsched.NoProperty, int]{
+		NumWorker:     2,
+		MaxBatch:      MaxBatch,
+		MaxBatchDelay: 10 * time.Millisecond,
+	})
+	for i := range NumJob {
+		ctrl.Input(&jobsched.MetaJob[int, jobsched.NoProperty]{Job: i})
+	}
+	ctrl.Run()
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+	for job, ctr := range counter {
+		if ctr != 1 {
+			t.Errorf("got counter[%d] %d; want 1", job, ctr)
+		}
+	}
+	if maxSize > MaxBatch {
+		t.Errorf("got max batch size %d; want at most %d", maxSize, MaxBatch)
+	} else if maxSize < 2 {
+		t.Errorf("got max batch size %d; want at least 2", maxSize)
+	}
+	if sum != NumJob {
+		t.Errorf("got feedback sum %d; want %d", sum, NumJob)
+	}
+	if st := ctrl.Stats(); st.Completed != NumJob {
+		t.Errorf("got Stats().Completed %d; want %d", st.Completed, NumJob)
+	}
+}
+
+func TestNewBatch_NewJobs(t *testing.T) {
+	const NumRound = 5
+	const NumJobPerRound = 20
+	var mu sync.Mutex
+	var processed []int
+	ctrl := jobsched.NewBatch(func(canceler concurrency.Canceler, rank int, jobs []int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback jobsched.NoFeedback) {
+		mu.Lock()
+		processed = append(processed, jobs...)
+		mu.Unlock()
+		for _, job := range jobs {
+			if job+NumJobPerRound < NumRound*NumJobPerRound {
+				newJobs = append(newJobs, &jobsched.MetaJob[int, jobsched.NoProperty]{
+					Job: job + NumJobPerRound,
+				})
+			}
+		}
+		return
+	}, nil, &jobsched.Options[int, jobsched.NoProperty, jobsched.NoFeedback]{
+		NumWorker: 3,
+		MaxBatch:  4,
+	})
+	for i := range NumJobPerRound {
+		ctrl.Input(&jobsched.MetaJob[int, jobsched.NoProperty]{Job: i})
+	}
+	ctrl.Run()
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+	slices.Sort(processed)
+	want := make([]int, NumRound*NumJobPerRound)
+	for i := range want {
+		want[i] = i
+	}
+	if !slices.Equal(processed, want) {
+		t.Errorf("got processed %v; want %v", processed, want)
+	}
+}
+
+func TestNewBatch_OrderedFeedback(t *testing.T) {
+	const NumJob = 50
+	var feedback []int
+	metaJobs := make([]*jobsched.MetaJob[int, jobsched.NoProperty], NumJob)
+	for i := range metaJobs {
+		metaJobs[i] = &jobsched.MetaJob[int, jobsched.NoProperty]{Job: i}
+	}
+	ctrl := jobsched.NewBatch(func(canceler concurrency.Canceler, rank int, jobs []int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback int) {
+		time.Sleep(time.Duration(len(jobs)%3) * time.Millisecond)
+		return nil, jobs[0]
+	}, func(canceler concurrency.Canceler, fb int) {
+		feedback = append(feedback, fb) // called by only one goroutine
+	}, &jobsched.Options[int, jobsched.NoProperty, int]{
+		NumWorker:       4,
+		MaxBatch:        3,
+		MaxBatchDelay:   time.Millisecond,
+		OrderedFeedback: true,
+	}, metaJobs...)
+	ctrl.Run()
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+	if len(feedback) == 0 || feedback[0] != 0 {
+		t.Errorf("got feedback %v; want it to start with 0", feedback)
+	} else if !slices.IsSorted(feedback) {
+		t.Errorf("got feedback %v; want sorted", feedback)
+	}
+}
+
+func TestNewBatch_NilHandler(t *testing.T) {
+	defer func() {
+		if e := recover(); e == nil {
+			t.Error("want panic but not")
+		}
+	}()
+	jobsched.NewBatch[int, jobsched.NoProperty, jobsched.NoFeedback](nil, nil, nil)
+}
@@ -0,0 +1,106 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobsched
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/donyori/gogo/concurrency/framework"
+	"github.com/donyori/gogo/errors"
+)
+
+// NewChild is like New but creates the controller as a child of parent.
+//
+// It is typically called in a job handler to process a subproblem
+// with another controller.
+//
+// The child controller is linked to the canceler of parent:
+// when parent is canceled, the child controller is canceled as well,
+// and so are all its descendants.
+// Canceling the child controller does not affect parent.
+// To also cancel the child controller when the deadline of the job
+// is exceeded, set the option Context to the context
+// returned by the method AsContext of the canceler
+// passed to the job handler.
+//
+// If parent is a Controller created by this package,
+// the panic records of the child controller
+// (including those of its descendants) are aggregated into
+// the panic records of parent, with the name of the goroutine
+// prefixed by "child <i>, ", where <i> is the order in which
+// the child controller is created, starting from 0.
+// The aggregated panic records are also counted in
+// the return value of the method Wait of parent.
+//
+// Note that parent does not wait for its child controllers.
+// The client should wait for the child controller
+// (e.g., call its method Run) in the job handler.
+//
+// NewChild panics if parent or jobHandler is nil.
+func NewChild[Job, Properties, Feedback any](
+	parent framework.Controller,
+	jobHandler JobHandler[Job, Properties, Feedback],
+	feedbackHandler FeedbackHandler[Feedback],
+	opts *Options[Job, Properties, Feedback],
+	metaJob ...*MetaJob[Job, Properties],
+) Controller[Job, Properties, Feedback] {
+	if parent == nil {
+		panic(errors.AutoMsg("parent is nil"))
+	} else if jobHandler == nil {
+		panic(errors.AutoMsg("job handler is nil"))
+	}
+	ctrl := newController(jobHandler, nil, feedbackHandler, opts, metaJob...)
+	ctrl.ul = context.AfterFunc(parent.Canceler().AsContext(), ctrl.c.Cancel)
+	if cr, ok := parent.(childRegistrar); ok {
+		cr.registerChild(ctrl)
+	}
+	return ctrl
+}
+
+// childRegistrar is an interface implemented by the controllers
+// of this package, to register child controllers
+// for aggregating their panic records.
+type childRegistrar interface {
+	// registerChild registers the specified child controller.
+	registerChild(child framework.Controller)
+}
+
+func (ctrl *controller[Job, Properties, Feedback]) registerChild(
+	child framework.Controller) {
+	ctrl.cm.Lock()
+	defer ctrl.cm.Unlock()
+	ctrl.children = append(ctrl.children, child)
+}
+
+// childPanicRecords returns the panic records of the child controllers,
+// with the name of the goroutine prefixed by "child <i>, ".
+func (ctrl *controller[Job, Properties, Feedback]) childPanicRecords() []framework.PanicRecord {
+	ctrl.cm.Lock()
+	children := ctrl.children
+	ctrl.cm.Unlock()
+	var prs []framework.PanicRecord
+	for i, child := range children {
+		for _, pr := range child.PanicRecords() {
+			pr.Name = "child " + strconv.Itoa(i) + ", " + pr.Name
+			prs = append(prs, pr)
+		}
+	}
+	return prs
+}
@@ -0,0 +1,154 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobsched_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/concurrency"
+	"github.com/donyori/gogo/concurrency/framework/jobsched"
+)
+
+func TestNewChild_PanicRecords(t *testing.T) {
+	const PanicMsg = "test panic in child"
+	var parent jobsched.Controller[int, jobsched.NoProperty, jobsched.NoFeedback]
+	parent = jobsched.NewWithoutFeedback(
+		func(
+			canceler concurrency.Canceler,
+			rank int,
+			job int,
+		) (newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], _ jobsched.NoFeedback) {
+			child := jobsched.NewChild(
+				parent,
+				func(
+					canceler concurrency.Canceler,
+					rank int,
+					job int,
+				) (newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], _ jobsched.NoFeedback) {
+					if job == 1 {
+						panic(PanicMsg)
+					}
+					return
+				},
+				nil,
+				&jobsched.Options[int, jobsched.NoProperty, jobsched.NoFeedback]{
+					NumWorker: 1,
+				},
+				&jobsched.MetaJob[int, jobsched.NoProperty]{Job: 0},
+				&jobsched.MetaJob[int, jobsched.NoProperty]{Job: 1},
+			)
+			if n := child.Run(); n != 1 {
+				t.Errorf("child, got %d panics; want 1", n)
+			}
+			return
+		},
+		&jobsched.Options[int, jobsched.NoProperty, jobsched.NoFeedback]{
+			NumWorker: 1,
+		},
+		&jobsched.MetaJob[int, jobsched.NoProperty]{Job: 0},
+	)
+	if n := parent.Run(); n != 1 {
+		t.Errorf("parent, got %d panics; want 1", n)
+	}
+	prs := parent.PanicRecords()
+	if len(prs) != 1 {
+		t.Fatalf("got %d panic records; want 1", len(prs))
+	}
+	if !strings.HasPrefix(prs[0].Name, "child 0, ") ||
+		prs[0].Content != PanicMsg {
+		t.Errorf("got panic record %+v; want Name with prefix %q, Content %q",
+			prs[0], "child 0, ", PanicMsg)
+	}
+}
+
+func TestNewChild_Cancel(t *testing.T) {
+	childStarted := make(chan struct{})
+	childCanceled := make(chan bool, 1)
+	var parent jobsched.Controller[int, jobsched.NoProperty, jobsched.NoFeedback]
+	parent = jobsched.NewWithoutFeedback(
+		func(
+			canceler concurrency.Canceler,
+			rank int,
+			job int,
+		) (newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], _ jobsched.NoFeedback) {
+			child := jobsched.NewChild(
+				parent,
+				func(
+					canceler concurrency.Canceler,
+					rank int,
+					job int,
+				) (newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], _ jobsched.NoFeedback) {
+					close(childStarted)
+					select {
+					case <-canceler.C():
+						childCanceled <- true
+					case <-time.After(time.Second):
+						childCanceled <- false
+					}
+					return
+				},
+				nil,
+				nil,
+				&jobsched.MetaJob[int, jobsched.NoProperty]{Job: 0},
+			)
+			child.Run()
+			return
+		},
+		nil,
+		&jobsched.MetaJob[int, jobsched.NoProperty]{Job: 0},
+	)
+	parent.Launch()
+	<-childStarted
+	parent.Canceler().Cancel()
+	if !<-childCanceled {
+		t.Error("child is not canceled after canceling the parent")
+	}
+	parent.Wait()
+}
+
+func TestNewChild_CancelChild(t *testing.T) {
+	parent := jobsched.NewWithoutFeedback(
+		func(
+			canceler concurrency.Canceler,
+			rank int,
+			job int,
+		) (newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], _ jobsched.NoFeedback) {
+			return
+		},
+		nil,
+	)
+	child := jobsched.NewChild(
+		parent,
+		func(
+			canceler concurrency.Canceler,
+			rank int,
+			job int,
+		) (newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], _ jobsched.NoFeedback) {
+			return
+		},
+		nil,
+		nil,
+	)
+	child.Canceler().Cancel()
+	if parent.Canceler().Canceled() {
+		t.Error("parent is canceled after canceling the child")
+	}
+}
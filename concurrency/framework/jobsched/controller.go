@@ -19,17 +19,38 @@
 package jobsched
 
 import (
+	"context"
+	"iter"
+	"maps"
 	"reflect"
 	"runtime"
+	"runtime/debug"
+	"slices"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/donyori/gogo/concurrency"
 	"github.com/donyori/gogo/concurrency/framework"
+	"github.com/donyori/gogo/container/heap/pqueue"
 	"github.com/donyori/gogo/errors"
 )
 
+// ErrFeedbackUnavailable is an error indicating that
+// the feedback on the jobs cannot be iterated over.
+//
+// It is reported by the iterator returned by the method IterFeedback
+// of Controller.
+//
+// The client should use errors.Is to test whether an error
+// is ErrFeedbackUnavailable.
+var ErrFeedbackUnavailable = errors.AutoNewCustom(
+	"feedback is unavailable for iteration",
+	errors.PrependFullPkgName,
+	0,
+)
+
 // Controller is a controller for this job scheduling framework.
 //
 // It is used to launch, cancel, and wait for the job.
@@ -60,6 +81,141 @@ type Controller[Job, Properties, Feedback any] interface {
 	// After calling the method Wait, Input does nothing and returns 0.
 	// Note that the method Run calls Wait inside.
 	Input(metaJob ...*MetaJob[Job, Properties]) int
+
+	// SetNumWorker sets the number of worker goroutines to n.
+	//
+	// Nonpositive n for using the option NumWorkerPolicy,
+	// the same as the option NumWorker.
+	//
+	// If the controller is not launched, it only sets the number of
+	// worker goroutines to start when the controller is launched.
+	// Otherwise, it starts new worker goroutines
+	// or asks some worker goroutines to exit.
+	// The new worker goroutines call the setup function (if any)
+	// when they start, like the initial ones.
+	// The worker goroutines asked to exit finish their current jobs
+	// (if any), call the cleanup function (if any), and then exit.
+	//
+	// The rank of a worker goroutine is unique among
+	// the running worker goroutines.
+	// After reducing the number of worker goroutines,
+	// the workers with the highest ranks exit.
+	// New worker goroutines take the lowest ranks not in use.
+	// Therefore, while some worker goroutines are exiting,
+	// the rank of a new worker goroutine may be
+	// greater than or equal to ctrl.NumGoroutine().
+	//
+	// It does nothing after the controller is canceled or finished.
+	//
+	// It is safe for concurrent use by multiple goroutines.
+	SetNumWorker(n int)
+
+	// Shutdown stops accepting new jobs from the method Input,
+	// waits for the queued jobs (including the new jobs
+	// generated by the job handlers) to finish, and then returns nil.
+	//
+	// Unlike canceling the controller, Shutdown does not abandon
+	// the queued jobs.
+	// It is equivalent to the method Wait but returns early
+	// when ctx is done.
+	// In this case, it returns an error wrapping ctx.Err(),
+	// and the jobs continue to be processed.
+	// The client can then call the method Stop to abandon them.
+	//
+	// If the controller is not launched, Shutdown does nothing
+	// and returns nil.
+	//
+	// Shutdown panics if ctx is nil.
+	Shutdown(ctx context.Context) error
+
+	// Stop cancels the controller, abandoning the queued jobs,
+	// waits for the running job handlers and the feedback handler
+	// to return, and then returns nil.
+	//
+	// If ctx is done before that, Stop returns
+	// an error wrapping ctx.Err().
+	//
+	// If the controller is not launched, Stop cancels it
+	// and returns nil.
+	//
+	// Stop panics if ctx is nil.
+	Stop(ctx context.Context) error
+
+	// FeedbackSeq returns an iterator over the feedback on the jobs,
+	// as an alternative to the feedback handler.
+	//
+	// Ranging over the iterator launches the controller
+	// and waits for it, like the method Run,
+	// and yields the feedback in the order it is produced.
+	// Once ranging starts, the method Input does nothing and returns 0.
+	// If the loop breaks early, the controller is canceled,
+	// and the remaining feedback is discarded.
+	//
+	// The iterator yields feedback only if the type of feedback
+	// is not NoFeedback, the controller has no feedback handler
+	// (i.e., feedbackHandler passed to New is nil),
+	// the controller is not launched before ranging over the iterator,
+	// and no other iteration over FeedbackSeq has started.
+	// Otherwise, it only launches the controller and waits for it,
+	// without yielding anything.
+	FeedbackSeq() iter.Seq[Feedback]
+
+	// IterFeedback is like FeedbackSeq but also reports the error
+	// of the iteration through pErr, so that it can be used directly
+	// in a for-range statement:
+	//
+	//	var err error
+	//	for fb := range ctrl.IterFeedback(&err) {
+	//		// Deal with fb.
+	//	}
+	//	if err != nil {
+	//		// Deal with err.
+	//	}
+	//
+	// *pErr is set to nil at the start of each iteration
+	// and to the error (if any) after the iteration ends.
+	// If pErr is nil, the error is discarded.
+	//
+	// If the iterator cannot yield feedback
+	// (see FeedbackSeq for the conditions),
+	// the error is ErrFeedbackUnavailable.
+	// Otherwise, if any goroutine panicked,
+	// the error is an error list (see github.com/donyori/gogo/errors.ErrorList)
+	// of the panic records (of type framework.PanicRecord).
+	// Breaking the loop early is not an error.
+	IterFeedback(pErr *error) iter.Seq[Feedback]
+
+	// Stats returns the statistics of the controller.
+	//
+	// It is safe for concurrent use by multiple goroutines.
+	Stats() Stats
+
+	// QueueLen returns the number of jobs waiting to be dispatched
+	// to worker goroutines, including those waiting to be retried.
+	//
+	// It is the same as the field Queued of the result of the method Stats.
+	//
+	// It is safe for concurrent use by multiple goroutines.
+	QueueLen() int
+
+	// SnapshotPending returns copies of up to maxJobs jobs waiting to be
+	// dispatched to worker goroutines, including those waiting to be retried,
+	// for debugging and checkpointing.
+	// Nonpositive maxJobs for all such jobs.
+	//
+	// It neither removes the jobs nor affects the order of scheduling.
+	// The copies are shallow: the field Job of each copy
+	// is assigned from the original one.
+	// The order of the returned jobs is unspecified.
+	//
+	// The jobs in the job queue are included only if
+	// the job queue implements MetaRanger.
+	// A job being dispatched to a worker goroutine may be missing.
+	//
+	// It returns nil after the controller is canceled or finished.
+	//
+	// It is safe for concurrent use by multiple goroutines.
+	SnapshotPending(maxJobs int) []*MetaJob[Job, Properties]
 }
 
 // NoFeedback is a special case of feedback type
@@ -79,6 +235,10 @@ var noFeedbackType = reflect.TypeFor[NoFeedback]()
 // which is collected and handled in a dedicated goroutine.
 //
 // The first parameter is a canceler to interrupt all job processors.
+// If the job has a deadline (see the fields Deadline and Timeout of Meta),
+// the canceler also broadcasts the cancellation signal
+// when the deadline is exceeded,
+// but this signal applies only to the current job.
 // The second parameter is the rank of the worker goroutine
 // (from 0 to ctrl.NumGoroutine()-1) to identify the goroutine uniquely.
 // The third parameter is the job to be processed.
@@ -113,9 +273,37 @@ type FeedbackHandler[Feedback any] func(
 // Options are options for creating Controller.
 type Options[Job, Properties, Feedback any] struct {
 	// The number of worker goroutines to process jobs.
-	// Nonpositive values for using max(1, runtime.NumCPU()-2).
+	// Nonpositive values for using NumWorkerPolicy.
 	NumWorker int
 
+	// The policy to determine the number of worker goroutines
+	// from the number of available CPUs (see NumWorkerPolicy).
+	//
+	// It takes effect only if NumWorker is nonpositive,
+	// including when the method SetNumWorker of Controller
+	// is called with a nonpositive number.
+	// If it is nil, NumCPUMinusPolicy(2) is used,
+	// i.e., max(1, min(runtime.NumCPU(), runtime.GOMAXPROCS(0))-2).
+	NumWorkerPolicy NumWorkerPolicy
+
+	// If true, each worker goroutine is locked to its own OS thread
+	// by runtime.LockOSThread during its whole lifetime,
+	// including the setup function, the job handler,
+	// and the cleanup function.
+	//
+	// It is useful for the job handlers that depend on
+	// thread-local resources, such as some cgo libraries,
+	// which can be initialized in the setup function
+	// and released in the cleanup function.
+	//
+	// The OS thread is unlocked after the worker goroutine
+	// calls the cleanup function.
+	// A new worker goroutine (e.g., started by the method SetNumWorker
+	// of Controller or by RestartOnPanic) is locked to its own OS thread,
+	// which is not necessarily the thread of the previous worker goroutine
+	// of the same rank.
+	LockOSThread bool
+
 	// The maker to create a new job queue.
 	// It enables the client to make custom JobQueue.
 	// If it is nil, an FCFS (first come, first served) job queue is used.
@@ -154,6 +342,157 @@ type Options[Job, Properties, Feedback any] struct {
 	// The client is responsible for guaranteeing that
 	// this function is safe for concurrency.
 	Cleanup func(ctrl Controller[Job, Properties, Feedback], rank int)
+
+	// The context bound to the controller.
+	//
+	// If it is not nil, the canceler of the controller is derived from it.
+	// When the context is canceled (or its deadline is exceeded),
+	// the canceler broadcasts the cancellation signal,
+	// the controller stops dispatching jobs,
+	// and the method Wait returns once the running job handlers return.
+	//
+	// If it is nil, the controller is not bound to any context.
+	Context context.Context
+
+	// The function called when a job exceeds its deadline
+	// (see the fields Deadline and Timeout of Meta).
+	//
+	// If it is nil, such jobs are not reported.
+	//
+	// Its first parameter is the controller.
+	// Its second parameter is the record of the job.
+	//
+	// For a job being processed, it is called by the worker goroutine
+	// after the job handler returns.
+	// The new jobs and feedback returned by the job handler
+	// are still handled as usual.
+	// For a job dropped from the job queue,
+	// it is called by the goroutine that dispatches jobs,
+	// which cannot dispatch other jobs until this function returns.
+	// Therefore, this function should return quickly
+	// and must not call the method Input of the controller.
+	//
+	// The client is responsible for guaranteeing that
+	// this function is safe for concurrency.
+	OnTimeout func(
+		ctrl Controller[Job, Properties, Feedback],
+		record TimeoutRecord[Job, Properties],
+	)
+
+	// The policy for retrying failed jobs.
+	//
+	// If it is nil, failed jobs are not retried.
+	//
+	// See RetryPolicy for details.
+	RetryPolicy *RetryPolicy[Job, Feedback]
+
+	// The limit on the rate of dispatching jobs to worker goroutines.
+	//
+	// If it is nil, the rate is not limited.
+	//
+	// See RateLimit for details.
+	RateLimit *RateLimit
+
+	// The function called each time the job handler returns.
+	//
+	// If it is nil, it is not called.
+	//
+	// Its first parameter is the controller.
+	// Its second parameter is the rank of the worker goroutine
+	// that processed the job.
+	// Its third parameter is the job.
+	// Its fourth parameter is the time spent by the job handler.
+	//
+	// It is called by the worker goroutine after the job handler returns
+	// (even if the job is to be retried),
+	// but not if the job handler panics.
+	//
+	// The client is responsible for guaranteeing that
+	// this function is safe for concurrency.
+	OnComplete func(
+		ctrl Controller[Job, Properties, Feedback],
+		rank int,
+		job Job,
+		latency time.Duration,
+	)
+
+	// If true, the controller measures the time spent by the job handler
+	// and reports the average in the field AvgLatency of
+	// the result of the method Stats.
+	//
+	// Otherwise, AvgLatency is always 0, and the controller reads the clock
+	// for each job only if OnComplete is non-nil.
+	LatencyStats bool
+
+	// If true, a worker goroutine that panics while processing a job
+	// is replaced with a new worker goroutine of the same rank,
+	// instead of canceling the controller.
+	//
+	// The panic is still recorded in the panic records,
+	// and the job is abandoned (unless it is retried by RetryPolicy).
+	// The panicking worker goroutine calls the cleanup function (if any)
+	// before exiting, and the new one calls the setup function (if any)
+	// when it starts.
+	//
+	// A panic in the setup function still cancels the controller,
+	// to avoid restarting worker goroutines endlessly.
+	RestartOnPanic bool
+
+	// If true, feedback is delivered to the feedback handler
+	// (or the iterator returned by the method FeedbackSeq of Controller)
+	// in the order the jobs are submitted,
+	// regardless of the order the jobs are finished.
+	//
+	// The order of jobs is the order they enter the controller,
+	// including the initial jobs, the jobs input by the method Input,
+	// and the new jobs returned by the job handlers.
+	// The feedback on jobs finished out of order is buffered
+	// until the feedback on all the earlier jobs is delivered.
+	// The jobs without feedback (e.g., the jobs dropped due to
+	// their deadlines and the jobs whose handlers panic)
+	// are skipped.
+	// If the controller is canceled, the buffered feedback
+	// is delivered in order when the controller finishes.
+	//
+	// It takes effect only if the type of feedback is not NoFeedback.
+	OrderedFeedback bool
+
+	// The maximum number of jobs passed to the batch job handler at once.
+	// Nonpositive values for 1.
+	//
+	// It takes effect only for the controllers created by NewBatch.
+	// Each worker goroutine collects a batch of up to MaxBatch jobs
+	// before calling the batch job handler.
+	// See MaxBatchDelay for how long it waits for the jobs.
+	MaxBatch int
+
+	// The maximum time a worker goroutine waits for more jobs
+	// to fill a batch after receiving the first job of the batch.
+	// Nonpositive values for no waiting,
+	// i.e., the batch consists of only the jobs available immediately.
+	//
+	// It takes effect only for the controllers created by NewBatch.
+	MaxBatchDelay time.Duration
+
+	// If true, the controller schedules jobs by work stealing
+	// instead of a single shared job queue.
+	//
+	// Each worker goroutine keeps the new jobs returned by its job handler
+	// in its own deque and processes them in LIFO order.
+	// The jobs input by the client are put into a shared deque.
+	// An idle worker goroutine takes jobs from the shared deque first,
+	// and then steals the oldest jobs from the other worker goroutines.
+	// This reduces the contention on the shared job queue
+	// when the jobs are very short and there are many worker goroutines.
+	//
+	// In the work-stealing mode, the options JobQueueMaker, RetryPolicy,
+	// and RateLimit are ignored,
+	// and the jobs are not processed in any specific order.
+	// The jobs that exceed their deadlines before being processed
+	// are still dropped and reported to OnTimeout.
+	//
+	// It does not take effect for the controllers created by NewBatch.
+	WorkStealing bool
 }
 
 // New creates a new Controller with options opts.
@@ -188,38 +527,77 @@ func New[Job, Properties, Feedback any](
 ) Controller[Job, Properties, Feedback] {
 	if jobHandler == nil {
 		panic(errors.AutoMsg("job handler is nil"))
-	} else if opts == nil {
+	}
+	return newController(jobHandler, nil, feedbackHandler, opts, metaJob...)
+}
+
+// newController creates a new controller with
+// either the job handler jh or the batch job handler bh.
+//
+// Exactly one of jh and bh must be non-nil.
+//
+// The other parameters are the same as those of function New.
+func newController[Job, Properties, Feedback any](
+	jh JobHandler[Job, Properties, Feedback],
+	bh BatchJobHandler[Job, Properties, Feedback],
+	feedbackHandler FeedbackHandler[Feedback],
+	opts *Options[Job, Properties, Feedback],
+	metaJob ...*MetaJob[Job, Properties],
+) *controller[Job, Properties, Feedback] {
+	if opts == nil {
 		opts = new(Options[Job, Properties, Feedback])
 	}
-	n := opts.NumWorker
-	if n <= 0 {
-		n = runtime.NumCPU() - 2
-		if n < 1 {
-			n = 1
-		}
+	var c concurrency.Canceler
+	if opts.Context != nil {
+		ctx, cancel := context.WithCancelCause(opts.Context)
+		c = concurrency.NewCancelerFromContextCause(ctx, cancel)
+	} else {
+		c = concurrency.NewCanceler()
 	}
+	n := normalizeNumWorker(opts.NumWorker, opts.NumWorkerPolicy)
+	ws := opts.WorkStealing && bh == nil
 	var jq JobQueue[Job, Properties]
-	if opts.JobQueueMaker != nil {
+	if opts.JobQueueMaker != nil && !ws {
 		jq = opts.JobQueueMaker.New()
 	} else {
 		jq = new(fcfsJobQueue[Job, Properties])
 	}
-	if len(metaJob) > 0 {
-		jq.Enqueue(copyMetaJobs(metaJob)...)
-	}
+	mdq, _ := jq.(MetaDequeuer[Job, Properties])
 	ctrl := &controller[Job, Properties, Feedback]{
-		n:       n,
-		jh:      jobHandler,
-		fh:      feedbackHandler,
-		c:       concurrency.NewCanceler(),
-		jq:      jq,
-		ic:      make(chan []*MetaJob[Job, Properties], 1),
-		eqc:     make(chan []*MetaJob[Job, Properties], n),
-		dqc:     make(chan Job, 1),
-		pr:      concurrency.NewRecorder[framework.PanicRecord](0),
-		wso:     concurrency.NewOnce(nil),
-		setup:   opts.Setup,
-		cleanup: opts.Cleanup,
+		n:          n,
+		jh:         jh,
+		bh:         bh,
+		fh:         feedbackHandler,
+		c:          c,
+		jq:         jq,
+		mdq:        mdq,
+		ic:         make(chan []*MetaJob[Job, Properties], 1),
+		eqc:        make(chan []*MetaJob[Job, Properties], n),
+		dqc:        make(chan *MetaJob[Job, Properties], 1),
+		dc:         make(chan struct{}),
+		adc:        make(chan struct{}),
+		snc:        make(chan snapshotRequest[Job, Properties]),
+		pr:         concurrency.NewRecorder[framework.PanicRecord](0),
+		wso:        concurrency.NewOnce(nil),
+		setup:      opts.Setup,
+		cleanup:    opts.Cleanup,
+		onTimeout:  opts.OnTimeout,
+		onComplete: opts.OnComplete,
+		ls:         opts.LatencyStats,
+		rop:        opts.RestartOnPanic,
+		nwp:        opts.NumWorkerPolicy,
+		lot:        opts.LockOSThread,
+	}
+	if bh != nil {
+		ctrl.mb, ctrl.mbd = max(opts.MaxBatch, 1), opts.MaxBatchDelay
+	} else if ws {
+		ctrl.st = newStealer[Job, Properties]()
+	} else if opts.RetryPolicy != nil && opts.RetryPolicy.MaxAttempts > 1 && mdq != nil {
+		rp := *opts.RetryPolicy
+		ctrl.rp = &rp
+	}
+	if opts.RateLimit != nil && opts.RateLimit.PerSecond > 0 && !ws {
+		ctrl.tb = newTokenBucket(*opts.RateLimit, time.Now())
 	}
 	ctrl.lo = concurrency.NewOnce(ctrl.launchProc)
 	if reflect.TypeFor[Feedback]() != noFeedbackType {
@@ -227,12 +605,46 @@ func New[Job, Properties, Feedback any](
 		if bufSize < 0 {
 			bufSize = 0
 		}
-		ctrl.fc = make(chan Feedback, bufSize)
+		ctrl.fc = make(chan feedbackItem[Feedback], bufSize)
 		ctrl.fhdc = make(chan struct{})
+		ctrl.ofb = opts.OrderedFeedback
+	}
+	if len(metaJob) > 0 {
+		jq.Enqueue(ctrl.copyMetaJobs(metaJob)...)
 	}
 	return ctrl
 }
 
+// NewWithContext is like New but binds the controller to ctx.
+//
+// It is equivalent to New with the option Context set to ctx.
+// If opts are not nil, they are copied before setting the option Context,
+// so the client's options are not modified.
+//
+// When ctx is canceled (or its deadline is exceeded),
+// the canceler of the controller broadcasts the cancellation signal,
+// the controller stops dispatching jobs,
+// and the method Wait returns once the running job handlers return.
+//
+// NewWithContext panics if ctx is nil.
+func NewWithContext[Job, Properties, Feedback any](
+	ctx context.Context,
+	jobHandler JobHandler[Job, Properties, Feedback],
+	feedbackHandler FeedbackHandler[Feedback],
+	opts *Options[Job, Properties, Feedback],
+	metaJob ...*MetaJob[Job, Properties],
+) Controller[Job, Properties, Feedback] {
+	if ctx == nil {
+		panic(errors.AutoMsg("the provided context is nil"))
+	}
+	var o Options[Job, Properties, Feedback]
+	if opts != nil {
+		o = *opts
+	}
+	o.Context = ctx
+	return New(jobHandler, feedbackHandler, &o, metaJob...)
+}
+
 // NewWithoutFeedback is like New but sets the type of feedback to NoFeedback.
 //
 // The parameters are similar to those of function New,
@@ -263,6 +675,27 @@ func Run[Job, Properties, Feedback any](
 	return ctrl.PanicRecords()
 }
 
+// RunCollect creates a Controller with specified arguments,
+// runs it, and collects all the feedback into a slice.
+// It returns the feedback and the panic records of the Controller.
+//
+// The feedback is in the order it is produced,
+// which is not necessarily the order of the jobs.
+//
+// The parameters are the same as those of function New,
+// except that RunCollect does not require feedbackHandler.
+func RunCollect[Job, Properties, Feedback any](
+	jobHandler JobHandler[Job, Properties, Feedback],
+	opts *Options[Job, Properties, Feedback],
+	metaJob ...*MetaJob[Job, Properties],
+) (feedback []Feedback, panicRecords []framework.PanicRecord) {
+	ctrl := New(jobHandler, func(_ concurrency.Canceler, fb Feedback) {
+		feedback = append(feedback, fb) // called by only one goroutine
+	}, opts, metaJob...)
+	ctrl.Run()
+	return feedback, ctrl.PanicRecords()
+}
+
 // RunWithoutFeedback creates a Controller[Job, Properties, NoFeedback]
 // with specified arguments, and then runs it.
 // It returns the panic records of the Controller.
@@ -281,18 +714,27 @@ func RunWithoutFeedback[Job, Properties any](
 
 // controller is an implementation of interface Controller.
 type controller[Job, Properties, Feedback any] struct {
-	n  int                                   // The number of worker goroutines to process jobs.
-	jh JobHandler[Job, Properties, Feedback] // Job handler.
-	fh FeedbackHandler[Feedback]             // Feedback handler.
+	n  int                                        // The number of worker goroutines to process jobs.
+	jh JobHandler[Job, Properties, Feedback]      // Job handler, or nil if bh is used.
+	bh BatchJobHandler[Job, Properties, Feedback] // Batch job handler, or nil if jh is used.
+	fh FeedbackHandler[Feedback]                  // Feedback handler.
+
+	mb  int           // The maximum number of jobs in a batch, used only if bh is not nil.
+	mbd time.Duration // The maximum time to wait for more jobs to fill a batch, used only if bh is not nil.
 
-	c  concurrency.Canceler      // Canceler.
-	jq JobQueue[Job, Properties] // Job queue.
+	c   concurrency.Canceler          // Canceler.
+	jq  JobQueue[Job, Properties]     // Job queue.
+	mdq MetaDequeuer[Job, Properties] // The job queue as a MetaDequeuer, or nil if the job queue does not implement MetaDequeuer.
 
-	ic   chan []*MetaJob[Job, Properties] // Input channel, to input jobs from the client.
-	eqc  chan []*MetaJob[Job, Properties] // Enqueue channel, to input jobs from workers.
-	dqc  chan Job                         // Dequeue channel, to dispatch jobs to workers.
-	fc   chan Feedback                    // Feedback channel, to collect feedback on jobs.
-	fhdc chan struct{}                    // Feedback handler done channel, to broadcast a signal when the feedback handler is finished.
+	ic   chan []*MetaJob[Job, Properties]      // Input channel, to input jobs from the client.
+	eqc  chan []*MetaJob[Job, Properties]      // Enqueue channel, to input jobs from workers.
+	dqc  chan *MetaJob[Job, Properties]        // Dequeue channel, to dispatch jobs to workers.
+	fc   chan feedbackItem[Feedback]           // Feedback channel, to collect feedback on jobs.
+	fhdc chan struct{}                         // Feedback handler done channel, to broadcast a signal when the feedback handler is finished.
+	sfc  chan Feedback                         // Sequence feedback channel, to forward feedback to the iterator returned by FeedbackSeq. It is nil if no iterator is ranging.
+	dc   chan struct{}                         // Done channel, to broadcast a signal when the workers, the job allocator, and the feedback handler are all finished.
+	adc  chan struct{}                         // Allocator done channel, to broadcast a signal when the job allocator is finished.
+	snc  chan snapshotRequest[Job, Properties] // Snapshot channel, to request the job allocator to take a snapshot of the pending jobs.
 
 	pr  concurrency.Recorder[framework.PanicRecord] // Panic recorder.
 	wg  sync.WaitGroup                              // Wait group for the workers and the job allocator, not for the feedback handler.
@@ -304,8 +746,35 @@ type controller[Job, Properties, Feedback any] struct {
 	m   sync.Mutex
 	lsi bool // An indicator to report whether the method Launch is started.
 
+	// Lock to avoid the race condition on n, ws, and ad.
+	wm sync.Mutex
+	ws []*workerState // The states of the worker goroutines, indexed by rank. A nil item indicates no worker goroutine of that rank. It is nil before launching.
+	ad bool           // An indicator to report whether the job allocator is finished.
+
 	setup   func(ctrl Controller[Job, Properties, Feedback], rank int) // Worker setup function.
 	cleanup func(ctrl Controller[Job, Properties, Feedback], rank int) // Worker cleanup function.
+
+	onTimeout func(ctrl Controller[Job, Properties, Feedback], record TimeoutRecord[Job, Properties]) // Timeout handler.
+	rp        *RetryPolicy[Job, Feedback]                                                             // Retry policy, or nil if jobs are never retried.
+	tb        *tokenBucket                                                                            // Token bucket for the rate limit, or nil if the rate is not limited. Used only by the job allocator.
+
+	onComplete func(ctrl Controller[Job, Properties, Feedback], rank int, job Job, latency time.Duration) // Job completion hook.
+	ls         bool                                                                                       // An indicator to report whether to measure the latency for the method Stats.
+	rop        bool                                                                                       // An indicator to report whether to restart the worker goroutines that panic while processing jobs.
+	ofb        bool                                                                                       // An indicator to report whether to deliver feedback in the order of jobs.
+	ns         atomic.Uint64                                                                              // The next sequence number of jobs, used only if ofb is true.
+
+	nwp NumWorkerPolicy // Policy to determine the number of worker goroutines for nonpositive numbers, or nil for the default policy.
+	lot bool            // An indicator to report whether to lock each worker goroutine to its own OS thread.
+
+	ul       func() bool            // Function to unlink from the parent controller, or nil if there is no parent. Set by NewChild.
+	cm       sync.Mutex             // Lock for children.
+	children []framework.Controller // Child controllers created by NewChild.
+
+	st *stealer[Job, Properties] // The work-stealing scheduler, or nil if not in the work-stealing mode. If it is not nil, the job allocator, ic, eqc, and dqc are unused.
+
+	nq atomic.Int64 // The number of queued jobs, updated by the job allocator after launching.
+	rs []*rankStats // The statistics of the worker goroutines, indexed by rank. Protected by wm.
 }
 
 func (ctrl *controller[Job, Properties, Feedback]) Canceler() concurrency.Canceler {
@@ -326,7 +795,43 @@ func (ctrl *controller[Job, Properties, Feedback]) Wait() int {
 	if ctrl.fhdc != nil {
 		<-ctrl.fhdc
 	}
-	return ctrl.pr.Len()
+	if ctrl.ul != nil {
+		ctrl.ul()
+	}
+	return ctrl.pr.Len() + len(ctrl.childPanicRecords())
+}
+
+func (ctrl *controller[Job, Properties, Feedback]) Shutdown(ctx context.Context) error {
+	if ctx == nil {
+		panic(errors.AutoMsg("the provided context is nil"))
+	} else if !ctrl.lo.Done() {
+		return nil
+	}
+	ctrl.wso.Do()
+	select {
+	case <-ctrl.dc:
+		ctrl.c.Cancel() // for cleanup possible daemon goroutines that wait for a cancellation signal to exit
+		return nil
+	case <-ctx.Done():
+		return errors.AutoWrap(ctx.Err())
+	}
+}
+
+func (ctrl *controller[Job, Properties, Feedback]) Stop(ctx context.Context) error {
+	if ctx == nil {
+		panic(errors.AutoMsg("the provided context is nil"))
+	}
+	ctrl.c.Cancel()
+	if !ctrl.lo.Done() {
+		return nil
+	}
+	ctrl.wso.Do()
+	select {
+	case <-ctrl.dc:
+		return nil
+	case <-ctx.Done():
+		return errors.AutoWrap(ctx.Err())
+	}
 }
 
 func (ctrl *controller[Job, Properties, Feedback]) Run() int {
@@ -335,11 +840,17 @@ func (ctrl *controller[Job, Properties, Feedback]) Run() int {
 }
 
 func (ctrl *controller[Job, Properties, Feedback]) NumGoroutine() int {
+	ctrl.wm.Lock()
+	defer ctrl.wm.Unlock()
 	return ctrl.n
 }
 
 func (ctrl *controller[Job, Properties, Feedback]) PanicRecords() []framework.PanicRecord {
-	return ctrl.pr.All()
+	prs := ctrl.pr.All()
+	if cprs := ctrl.childPanicRecords(); len(cprs) > 0 {
+		prs = append(prs, cprs...)
+	}
+	return prs
 }
 
 func (ctrl *controller[Job, Properties, Feedback]) Input(
@@ -347,9 +858,11 @@ func (ctrl *controller[Job, Properties, Feedback]) Input(
 	if ctrl.wso.Done() {
 		return 0
 	}
-	mjs := copyMetaJobs(metaJob)
+	mjs := ctrl.copyMetaJobs(metaJob)
 	if !ctrl.lo.Done() && ctrl.inputBeforeLaunch(mjs) {
 		return len(mjs)
+	} else if ctrl.st != nil {
+		return ctrl.st.input(mjs)
 	}
 	select {
 	case <-ctrl.c.C():
@@ -365,8 +878,10 @@ func (ctrl *controller[Job, Properties, Feedback]) launchProc() {
 	ctrl.m.Lock()
 	defer ctrl.m.Unlock()
 	ctrl.lsi = true
+	ctrl.wm.Lock()
+	defer ctrl.wm.Unlock()
 
-	ctrl.wg.Add(ctrl.n + 1) // n workers + 1 job allocator
+	ctrl.wg.Add(ctrl.n + 1) // n workers + 1 job allocator (or work-stealing monitor)
 	if ctrl.fc != nil {
 		go func() { // goroutine for feedback handler
 			defer close(ctrl.fhdc)
@@ -374,8 +889,9 @@ func (ctrl *controller[Job, Properties, Feedback]) launchProc() {
 				if e := recover(); e != nil {
 					ctrl.c.Cancel()
 					ctrl.pr.Record(framework.PanicRecord{
-						Name:    "feedback handler",
-						Content: e,
+						Name:       "feedback handler",
+						Content:    e,
+						StackTrace: string(debug.Stack()),
 					})
 				}
 			}()
@@ -387,28 +903,32 @@ func (ctrl *controller[Job, Properties, Feedback]) launchProc() {
 				if e := recover(); e != nil {
 					ctrl.c.Cancel()
 					ctrl.pr.Record(framework.PanicRecord{
-						Name:    "feedback channel closer",
-						Content: e,
+						Name:       "feedback channel closer",
+						Content:    e,
+						StackTrace: string(debug.Stack()),
 					})
 				}
 			}()
 			ctrl.wg.Wait()
 		}()
 	}
+	go func() { // goroutine for closing done channel
+		defer close(ctrl.dc)
+		ctrl.wg.Wait()
+		if ctrl.fhdc != nil {
+			<-ctrl.fhdc
+		}
+	}()
+	ctrl.nq.Store(int64(ctrl.jq.Len()))
+	ctrl.ws = make([]*workerState, 0, ctrl.n)
+	if ctrl.st != nil {
+		ctrl.launchStealer()
+	}
 	for i := range ctrl.n {
-		go func(rank int) { // goroutine for worker
-			defer ctrl.wg.Done()
-			defer func() {
-				if e := recover(); e != nil {
-					ctrl.c.Cancel()
-					ctrl.pr.Record(framework.PanicRecord{
-						Name:    "worker " + strconv.Itoa(rank),
-						Content: e,
-					})
-				}
-			}()
-			ctrl.workerProc(rank)
-		}(i)
+		ctrl.startWorker(i)
+	}
+	if ctrl.st != nil {
+		return
 	}
 	go func() { // goroutine for job allocator
 		defer ctrl.wg.Done()
@@ -416,73 +936,455 @@ func (ctrl *controller[Job, Properties, Feedback]) launchProc() {
 			if e := recover(); e != nil {
 				ctrl.c.Cancel()
 				ctrl.pr.Record(framework.PanicRecord{
-					Name:    "job allocator",
-					Content: e,
+					Name:       "job allocator",
+					Content:    e,
+					StackTrace: string(debug.Stack()),
 				})
 			}
 		}()
+		defer func() {
+			ctrl.wm.Lock()
+			defer ctrl.wm.Unlock()
+			ctrl.ad = true
+		}()
+		defer close(ctrl.adc)
 		ctrl.jobAllocatorProc()
 	}()
 }
 
+func (ctrl *controller[Job, Properties, Feedback]) FeedbackSeq() iter.Seq[Feedback] {
+	return func(yield func(Feedback) bool) {
+		ctrl.rangeFeedback(yield)
+	}
+}
+
+func (ctrl *controller[Job, Properties, Feedback]) IterFeedback(
+	pErr *error) iter.Seq[Feedback] {
+	if pErr == nil {
+		pErr = new(error)
+	}
+	return func(yield func(Feedback) bool) {
+		*pErr = nil
+		if !ctrl.rangeFeedback(yield) {
+			*pErr = errors.AutoWrap(ErrFeedbackUnavailable)
+		} else if prs := ctrl.PanicRecords(); len(prs) > 0 {
+			el := errors.NewErrorList(true)
+			for _, pr := range prs {
+				el.Append(pr)
+			}
+			*pErr = errors.AutoWrap(el.ToError())
+		}
+	}
+}
+
+// rangeFeedback launches the controller, yields the feedback
+// in the order it is produced, and waits for the controller.
+//
+// It reports whether the feedback can be yielded
+// (see the method FeedbackSeq for the conditions).
+func (ctrl *controller[Job, Properties, Feedback]) rangeFeedback(
+	yield func(Feedback) bool) (ok bool) {
+	var sfc chan Feedback
+	if ctrl.fc != nil && ctrl.fh == nil {
+		ctrl.m.Lock()
+		if !ctrl.lsi && ctrl.sfc == nil {
+			sfc = make(chan Feedback)
+			ctrl.sfc = sfc
+		}
+		ctrl.m.Unlock()
+	}
+	ctrl.Launch()
+	defer ctrl.Wait()
+	ctrl.wso.Do() // stop accepting new jobs from the client, like the method Wait
+	if sfc == nil {
+		return false
+	}
+	yielding := true
+	for fb := range sfc {
+		if yielding && !yield(fb) {
+			yielding = false
+			ctrl.c.Cancel()
+		}
+		// Continue receiving feedback after the loop breaks
+		// so that the feedback handler goroutine can finish.
+	}
+	return true
+}
+
+func (ctrl *controller[Job, Properties, Feedback]) Stats() Stats {
+	st := Stats{Queued: ctrl.QueueLen()}
+	ctrl.wm.Lock()
+	defer ctrl.wm.Unlock()
+	if len(ctrl.rs) > 0 {
+		st.Processed = make([]int64, len(ctrl.rs))
+	}
+	var tl int64
+	for rank, rs := range ctrl.rs {
+		st.InFlight += int(rs.nf.Load())
+		st.Processed[rank] = rs.nc.Load()
+		st.Completed += st.Processed[rank]
+		tl += rs.tl.Load()
+	}
+	if ctrl.ls && st.Completed > 0 {
+		st.AvgLatency = time.Duration(tl / st.Completed)
+	}
+	return st
+}
+
+func (ctrl *controller[Job, Properties, Feedback]) QueueLen() int {
+	ctrl.m.Lock()
+	defer ctrl.m.Unlock()
+	switch {
+	case !ctrl.lsi:
+		return ctrl.jq.Len()
+	case ctrl.st != nil:
+		return int(ctrl.st.queued.Load())
+	}
+	return int(ctrl.nq.Load()) + len(ctrl.dqc)
+}
+
+func (ctrl *controller[Job, Properties, Feedback]) SnapshotPending(
+	maxJobs int) []*MetaJob[Job, Properties] {
+	ctrl.m.Lock()
+	if !ctrl.lsi {
+		defer ctrl.m.Unlock()
+		return ctrl.snapshotPending(maxJobs, nil, nil)
+	}
+	ctrl.m.Unlock()
+	cancelChan := ctrl.c.C()
+	if ctrl.st != nil {
+		select {
+		case <-cancelChan:
+			return nil
+		case <-ctrl.st.done:
+			return nil
+		default:
+			return ctrl.st.snapshot(maxJobs)
+		}
+	}
+	reply := make(chan []*MetaJob[Job, Properties], 1)
+	select {
+	case <-cancelChan:
+		return nil
+	case <-ctrl.adc:
+		return nil
+	case ctrl.snc <- snapshotRequest[Job, Properties]{maxJobs: maxJobs, reply: reply}:
+	}
+	select {
+	case <-cancelChan:
+		return nil
+	case mjs := <-reply:
+		return mjs
+	}
+}
+
+func (ctrl *controller[Job, Properties, Feedback]) SetNumWorker(n int) {
+	n = normalizeNumWorker(n, ctrl.nwp)
+	ctrl.wm.Lock()
+	defer ctrl.wm.Unlock()
+	if ctrl.ws == nil {
+		// Not launched yet.
+		// Set ctrl.n only, which is used when launching.
+		ctrl.n = n
+		return
+	} else if ctrl.ad || ctrl.c.Canceled() {
+		return
+	}
+	for ; ctrl.n < n; ctrl.n++ {
+		// ctrl.ad is false, so the job allocator is running,
+		// and the counter of ctrl.wg is positive.
+		// Therefore, it is safe to call ctrl.wg.Add here.
+		ctrl.wg.Add(1)
+		ctrl.startWorker(slices.Index(ctrl.ws, nil))
+	}
+	for rank := len(ctrl.ws) - 1; rank >= 0 && ctrl.n > n; rank-- {
+		if w := ctrl.ws[rank]; w != nil && !w.quitting {
+			w.quitting = true
+			close(w.quit)
+			ctrl.n--
+		}
+	}
+}
+
+// workerState is the state of a worker goroutine.
+type workerState struct {
+	quit     chan struct{} // The channel closed to ask the worker to exit.
+	quitting bool          // An indicator to report whether quit is closed.
+}
+
+// startWorker starts a new worker goroutine of the specified rank.
+//
+// If rank is negative, it uses len(ctrl.ws) as the rank.
+//
+// The caller must hold ctrl.wm and
+// have added 1 to ctrl.wg for the new worker goroutine.
+func (ctrl *controller[Job, Properties, Feedback]) startWorker(rank int) {
+	w := &workerState{quit: make(chan struct{})}
+	if rank < 0 || rank >= len(ctrl.ws) {
+		rank = len(ctrl.ws)
+		ctrl.ws = append(ctrl.ws, w)
+	} else {
+		ctrl.ws[rank] = w
+	}
+	for len(ctrl.rs) <= rank {
+		ctrl.rs = append(ctrl.rs, new(rankStats))
+	}
+	rs := ctrl.rs[rank]
+	go func() { // goroutine for worker
+		defer ctrl.wg.Done()
+		if ctrl.lot {
+			runtime.LockOSThread()
+			defer runtime.UnlockOSThread()
+		}
+		defer func() {
+			ctrl.wm.Lock()
+			defer ctrl.wm.Unlock()
+			if ctrl.ws[rank] == w {
+				ctrl.ws[rank] = nil
+			}
+		}()
+		var cur []*MetaJob[Job, Properties]
+		defer func() {
+			if e := recover(); e != nil {
+				ctrl.pr.Record(framework.PanicRecord{
+					Name:       "worker " + strconv.Itoa(rank),
+					Content:    e,
+					StackTrace: string(debug.Stack()),
+				})
+				if !ctrl.rop || cur == nil || !ctrl.restartWorker(rank, w, cur) {
+					ctrl.c.Cancel()
+				}
+			}
+		}()
+		ctrl.workerProc(rank, rs, w.quit, &cur)
+	}()
+}
+
+// restartWorker starts a new worker goroutine of the specified rank
+// to replace the worker goroutine w that panicked while processing
+// the jobs in batch.
+//
+// It reports whether the controller can continue.
+// It returns false if the panicking jobs cannot be reported to
+// the job allocator, and returns true without starting a new worker
+// goroutine if w is asked to exit or the job allocator is finished.
+//
+// The caller must be the goroutine of w
+// and must not hold ctrl.wm.
+func (ctrl *controller[Job, Properties, Feedback]) restartWorker(
+	rank int,
+	w *workerState,
+	batch []*MetaJob[Job, Properties],
+) bool {
+	if ctrl.ofb {
+		// Tell the feedback handler goroutine that the jobs have no feedback.
+		for _, mj := range batch {
+			select {
+			case <-ctrl.c.C():
+				return false
+			case ctrl.fc <- feedbackItem[Feedback]{seq: mj.Meta.seq, skip: true}:
+			}
+		}
+	}
+	// Tell the job allocator that the jobs are finished without new jobs.
+	for range batch {
+		if ctrl.st != nil {
+			ctrl.st.finish(nil, nil)
+			continue
+		}
+		select {
+		case <-ctrl.c.C():
+			return false
+		case ctrl.eqc <- nil:
+		}
+	}
+	ctrl.wm.Lock()
+	defer ctrl.wm.Unlock()
+	if w.quitting || ctrl.ad {
+		return true
+	}
+	// The goroutine of w has not called ctrl.wg.Done,
+	// so the counter of ctrl.wg is positive.
+	// Therefore, it is safe to call ctrl.wg.Add here.
+	ctrl.wg.Add(1)
+	ctrl.startWorker(rank)
+	return true
+}
+
 // feedbackHandlerProc is the feedback handler main process,
 // without panic checking and close(ctrl.fhdc).
 func (ctrl *controller[Job, Properties, Feedback]) feedbackHandlerProc() {
 	// The feedback handler must handle all feedback
 	// returned by the job handlers.
 	// Therefore, this function does not listen to ctrl.c.C().
+	var deliver func(fb Feedback)
 	switch {
 	case ctrl.fc == nil:
 		// This should never happen, but will act as a safeguard for later.
 		return
 	case ctrl.fh != nil:
-		for fb := range ctrl.fc {
+		deliver = func(fb Feedback) {
 			ctrl.fh(ctrl.c, fb)
 		}
+	case ctrl.sfc != nil:
+		defer close(ctrl.sfc)
+		deliver = func(fb Feedback) {
+			ctrl.sfc <- fb
+		}
 	default:
 		for range ctrl.fc {
 		}
+		return
+	}
+	if !ctrl.ofb {
+		for item := range ctrl.fc {
+			deliver(item.fb)
+		}
+		return
+	}
+	pending := make(map[uint64]feedbackItem[Feedback]) // feedback finished out of order
+	var next uint64                                    // the sequence number of the next job to deliver feedback
+	for item := range ctrl.fc {
+		if item.seq != next {
+			pending[item.seq] = item
+			continue
+		}
+		for {
+			if !item.skip {
+				deliver(item.fb)
+			}
+			next++
+			var ok bool
+			item, ok = pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+		}
 	}
+	// The feedback channel is closed.
+	// Deliver the remaining feedback in order.
+	for _, seq := range slices.Sorted(maps.Keys(pending)) {
+		if item := pending[seq]; !item.skip {
+			deliver(item.fb)
+		}
+	}
+}
+
+// feedbackItem is an item sent through the feedback channel.
+type feedbackItem[Feedback any] struct {
+	fb   Feedback // The feedback.
+	seq  uint64   // The sequence number of the job, used only if the feedback is ordered.
+	skip bool     // An indicator to report that the job has no feedback, used only if the feedback is ordered.
+}
+
+// copyMetaJobs calls function copyMetaJobs on metaJobs and
+// assigns sequence numbers to the copies if ctrl.ofb is true.
+func (ctrl *controller[Job, Properties, Feedback]) copyMetaJobs(
+	metaJobs []*MetaJob[Job, Properties]) []*MetaJob[Job, Properties] {
+	mjs := copyMetaJobs(metaJobs)
+	if ctrl.ofb && len(mjs) > 0 {
+		seq := ctrl.ns.Add(uint64(len(mjs))) - uint64(len(mjs))
+		for _, mj := range mjs {
+			mj.Meta.seq, seq = seq, seq+1
+		}
+	}
+	return mjs
 }
 
 // workerProc is the worker main process,
 // without panic checking and ctrl.wg.Done().
-func (ctrl *controller[Job, Properties, Feedback]) workerProc(rank int) {
+//
+// The worker exits when quit is closed,
+// after finishing its current job (if any).
+//
+// It records the statistics in rs, which are shared by
+// the worker goroutines of the same rank.
+//
+// It sets *cur to the jobs being processed, or nil if no job is processing.
+func (ctrl *controller[Job, Properties, Feedback]) workerProc(
+	rank int,
+	rs *rankStats,
+	quit <-chan struct{},
+	cur *[]*MetaJob[Job, Properties],
+) {
 	if ctrl.setup != nil {
 		ctrl.setup(ctrl, rank)
 	}
 	if ctrl.cleanup != nil {
 		defer ctrl.cleanup(ctrl, rank)
 	}
+	if ctrl.st != nil {
+		ctrl.stealWorkerProc(rank, rs, quit, cur)
+		return
+	}
 	cancelChan := ctrl.c.C()
+	one := make([]*MetaJob[Job, Properties], 1) // reused for a single job to avoid allocation
 	for {
-		var mjs []*MetaJob[Job, Properties]
+		var batch, mjs []*MetaJob[Job, Properties]
 		var fb Feedback
+		var retry bool
+		select {
+		case <-quit:
+			return
+		default:
+		}
 		select {
 		case <-cancelChan:
 			return
-		case job, ok := <-ctrl.dqc:
+		case <-quit:
+			return
+		case mj, ok := <-ctrl.dqc:
 			if !ok {
 				return
 			}
-			mjs, fb = ctrl.jh(ctrl.c, rank, job)
-			mjs = copyMetaJobs(mjs)
+			if ctrl.bh != nil {
+				batch = ctrl.collectBatch(mj, quit)
+				*cur = batch
+				mjs, fb = ctrl.trackBatch(rank, rs, batch)
+			} else {
+				one[0] = mj
+				batch, *cur = one, one
+				mjs, fb, retry = ctrl.trackJob(rank, rs, mj)
+			}
+			*cur = nil
+			if retry {
+				mjs = []*MetaJob[Job, Properties]{mj}
+			} else {
+				mjs = ctrl.copyMetaJobs(mjs)
+			}
 		}
-		if ctrl.fc != nil {
+		if ctrl.fc != nil && !retry {
 			// The feedback type is not NoFeedback.
 			// Send feedback first.
+			// The feedback on a batch is attributed to its first job,
+			// and the other jobs in the batch have no feedback.
 			select {
 			case <-cancelChan:
 				return
-			case ctrl.fc <- fb:
+			case ctrl.fc <- feedbackItem[Feedback]{fb: fb, seq: batch[0].Meta.seq}:
+			}
+			for i := 1; ctrl.ofb && i < len(batch); i++ {
+				select {
+				case <-cancelChan:
+					return
+				case ctrl.fc <- feedbackItem[Feedback]{seq: batch[i].Meta.seq, skip: true}:
+				}
 			}
 		}
 		// Always send new jobs to the job allocator,
 		// regardless of whether jobs are empty.
-		select {
-		case <-cancelChan:
-			return
-		case ctrl.eqc <- mjs:
+		// For a batch, send nil for each of the other jobs
+		// so that the job allocator can count the finished jobs.
+		for i := range batch {
+			if i > 0 {
+				mjs = nil
+			}
+			select {
+			case <-cancelChan:
+				return
+			case ctrl.eqc <- mjs:
+			}
 		}
 	}
 }
@@ -491,15 +1393,58 @@ func (ctrl *controller[Job, Properties, Feedback]) workerProc(rank int) {
 // without panic checking and ctrl.wg.Done().
 func (ctrl *controller[Job, Properties, Feedback]) jobAllocatorProc() {
 	defer close(ctrl.dqc)
-	var dqc chan<- Job // disable dqc at the beginning
-	var job Job
-	if ctrl.jq.Len() > 0 {
-		job = ctrl.jq.Dequeue()
-		dqc = ctrl.dqc // enable dqc
+	var dqc chan<- *MetaJob[Job, Properties] // disable dqc at the beginning
+	// Jobs waiting to be retried, and the timer for the earliest one.
+	var delayed pqueue.PriorityQueue[*MetaJob[Job, Properties]] // lazy init
+	var timer *time.Timer
+	var timerC <-chan time.Time // disable timerC at the beginning
+	// The timer for waiting for the rate limit.
+	var rateTimer *time.Timer
+	var rateC <-chan time.Time // disable rateC at the beginning
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+		if rateTimer != nil {
+			rateTimer.Stop()
+		}
+	}()
+	// enableDqc enables dqc if the rate limit allows.
+	// Otherwise, it enables rateC to wait for the rate limit.
+	enableDqc := func() {
+		var d time.Duration
+		if ctrl.tb != nil {
+			d = ctrl.tb.take(time.Now())
+		}
+		if d <= 0 {
+			dqc = ctrl.dqc
+		} else if rateTimer == nil {
+			rateTimer = time.NewTimer(d)
+			rateC = rateTimer.C
+		} else {
+			rateTimer.Reset(d)
+			rateC = rateTimer.C
+		}
+	}
+	mj := ctrl.dequeue()
+	if mj != nil {
+		enableDqc()
 	}
+	// updateNumQueued updates ctrl.nq for the method Stats.
+	updateNumQueued := func() {
+		n := ctrl.jq.Len()
+		if mj != nil {
+			n++
+		}
+		if delayed != nil {
+			n += delayed.Len()
+		}
+		ctrl.nq.Store(int64(n))
+	}
+	updateNumQueued()
 	ctr := 1 // counter for available input sources. 1 at the beginning stands for the client
 	cancelChan, wsoC := ctrl.c.C(), ctrl.wso.C()
-	for ctr > 0 || len(ctrl.ic) > 0 || dqc != nil {
+	for ctr > 0 || len(ctrl.ic) > 0 || mj != nil || timerC != nil {
 		select {
 		case <-cancelChan:
 			return
@@ -512,23 +1457,223 @@ func (ctrl *controller[Job, Properties, Feedback]) jobAllocatorProc() {
 			}
 		case mjs := <-ctrl.eqc:
 			ctr--
-			if len(mjs) > 0 {
+			if len(mjs) == 1 && !mjs[0].Meta.notBefore.IsZero() {
+				// A job to be retried after a delay.
+				if delayed == nil {
+					delayed = newDelayedJobQueue[Job, Properties]()
+				}
+				delayed.Enqueue(mjs[0])
+				timer, timerC = resetDelayedJobTimer(delayed, timer)
+			} else if len(mjs) > 0 {
 				ctrl.jq.Enqueue(mjs...)
 			}
-		case dqc <- job:
+		case <-timerC:
+			now := time.Now()
+			for delayed.Len() > 0 && !delayed.Top().Meta.notBefore.After(now) {
+				ctrl.jq.Enqueue(delayed.Dequeue())
+			}
+			timer, timerC = resetDelayedJobTimer(delayed, timer)
+		case <-rateC:
+			rateC = nil // disable rateC
+		case req := <-ctrl.snc:
+			req.reply <- ctrl.snapshotPending(req.maxJobs, mj, delayed)
+		case dqc <- mj:
 			ctr++
-			if ctrl.jq.Len() > 0 {
-				job = ctrl.jq.Dequeue()
-				continue
-			} else {
-				dqc = nil // disable dqc
+			mj, dqc = nil, nil // disable dqc
+		}
+		if mj == nil {
+			mj = ctrl.dequeue()
+		}
+		if mj != nil && dqc == nil && rateC == nil {
+			enableDqc()
+		}
+		updateNumQueued()
+	}
+}
+
+// trackJob calls ctrl.processJob and updates the statistics rs
+// of the worker goroutine.
+//
+// It also calls ctrl.onComplete (if not nil)
+// if ctrl.processJob returns normally.
+func (ctrl *controller[Job, Properties, Feedback]) trackJob(
+	rank int,
+	rs *rankStats,
+	mj *MetaJob[Job, Properties],
+) (newJobs []*MetaJob[Job, Properties], feedback Feedback, retry bool) {
+	rs.nf.Add(1)
+	var ok bool
+	var start time.Time
+	timed := ctrl.ls || ctrl.onComplete != nil
+	if timed {
+		start = time.Now()
+	}
+	defer func() {
+		rs.nf.Add(-1)
+		if !ok {
+			return
+		}
+		rs.nc.Add(1)
+		if !timed {
+			return
+		}
+		latency := time.Since(start)
+		if ctrl.ls {
+			rs.tl.Add(int64(latency))
+		}
+		if ctrl.onComplete != nil {
+			ctrl.onComplete(ctrl, rank, mj.Job, latency)
+		}
+	}()
+	newJobs, feedback, retry = ctrl.processJob(rank, mj)
+	ok = true
+	return
+}
+
+// processJob processes the specified job with the job handler
+// on the worker goroutine of the specified rank,
+// and reports whether to retry the job according to ctrl.rp.
+//
+// If the job is to be retried, it updates the meta information of mj
+// for the retry, and returns retry as true
+// with newJobs and feedback as their zero values.
+// Otherwise, it returns the results of the job handler,
+// and the panic of the job handler (if any) is propagated.
+func (ctrl *controller[Job, Properties, Feedback]) processJob(
+	rank int,
+	mj *MetaJob[Job, Properties],
+) (newJobs []*MetaJob[Job, Properties], feedback Feedback, retry bool) {
+	if ctrl.rp == nil {
+		newJobs, feedback = ctrl.handleJob(rank, mj)
+		return
+	}
+	attempt := mj.Meta.attempt + 1
+	defer func() {
+		if !retry {
+			return
+		}
+		var zero Feedback
+		newJobs, feedback = nil, zero
+		mj.Meta.attempt, mj.Meta.notBefore = attempt, time.Time{}
+		if ctrl.rp.Backoff != nil {
+			if d := ctrl.rp.Backoff(attempt); d > 0 {
+				mj.Meta.notBefore = time.Now().Add(d)
+			}
+		}
+	}()
+	defer func() {
+		if e := recover(); e != nil {
+			var zero Feedback
+			if !shouldRetry(ctrl.rp, mj, attempt, zero, e) {
+				panic(e)
 			}
+			retry = true
+		}
+	}()
+	newJobs, feedback = ctrl.handleJob(rank, mj)
+	retry = shouldRetry(ctrl.rp, mj, attempt, feedback, nil)
+	return
+}
+
+// resetDelayedJobTimer resets the timer to fire when the earliest job
+// in delayed can be retried, creating a new timer if timer is nil.
+//
+// It returns the timer and its channel,
+// or the timer and a nil channel if delayed is empty.
+func resetDelayedJobTimer[Job, Properties any](
+	delayed pqueue.PriorityQueue[*MetaJob[Job, Properties]],
+	timer *time.Timer,
+) (*time.Timer, <-chan time.Time) {
+	if delayed.Len() == 0 {
+		if timer != nil {
+			timer.Stop()
 		}
-		if dqc == nil && ctrl.jq.Len() > 0 {
-			job = ctrl.jq.Dequeue()
-			dqc = ctrl.dqc // enable dqc
+		return timer, nil
+	}
+	d := time.Until(delayed.Top().Meta.notBefore)
+	if timer == nil {
+		timer = time.NewTimer(d)
+	} else {
+		timer.Reset(d)
+	}
+	return timer, timer.C
+}
+
+// dequeue removes and returns the next job in the job queue
+// that has not exceeded its deadline.
+//
+// The jobs that have exceeded their deadlines are dropped
+// and reported to ctrl.onTimeout (if not nil).
+//
+// It returns nil if there is no such job in the job queue.
+func (ctrl *controller[Job, Properties, Feedback]) dequeue() *MetaJob[Job, Properties] {
+	for ctrl.jq.Len() > 0 {
+		if ctrl.mdq == nil {
+			return &MetaJob[Job, Properties]{Job: ctrl.jq.Dequeue()}
+		}
+		mj := ctrl.mdq.DequeueMeta()
+		if mj.Meta.Deadline.IsZero() || time.Now().Before(mj.Meta.Deadline) {
+			return mj
+		}
+		if ctrl.ofb {
+			ctrl.skipFeedback(mj.Meta.seq)
 		}
+		if ctrl.onTimeout != nil {
+			ctrl.onTimeout(ctrl, TimeoutRecord[Job, Properties]{
+				MetaJob:  mj,
+				Deadline: mj.Meta.Deadline,
+				Rank:     -1,
+			})
+		}
+	}
+	return nil
+}
+
+// skipFeedback tells the feedback handler goroutine that
+// the job of the specified sequence number has no feedback,
+// without blocking the caller.
+//
+// The caller must be the job allocator.
+func (ctrl *controller[Job, Properties, Feedback]) skipFeedback(seq uint64) {
+	// The job allocator has not called ctrl.wg.Done,
+	// so the counter of ctrl.wg is positive.
+	// Therefore, it is safe to call ctrl.wg.Add here.
+	ctrl.wg.Add(1)
+	go func() {
+		defer ctrl.wg.Done()
+		select {
+		case <-ctrl.c.C():
+		case ctrl.fc <- feedbackItem[Feedback]{seq: seq, skip: true}:
+		}
+	}()
+}
+
+// handleJob processes the specified job with the job handler
+// on the worker goroutine of the specified rank.
+//
+// If the job has a deadline, it passes a canceler that broadcasts
+// the cancellation signal when the deadline is exceeded
+// to the job handler, and reports the job to ctrl.onTimeout (if not nil)
+// if the deadline is exceeded when the job handler returns.
+func (ctrl *controller[Job, Properties, Feedback]) handleJob(
+	rank int,
+	mj *MetaJob[Job, Properties],
+) (newJobs []*MetaJob[Job, Properties], feedback Feedback) {
+	deadline, ok := jobDeadline(&mj.Meta, time.Now())
+	if !ok {
+		return ctrl.jh(ctrl.c, rank, mj.Job)
+	}
+	jc := newJobCanceler(ctrl.c, deadline)
+	defer jc.stop()
+	newJobs, feedback = ctrl.jh(jc, rank, mj.Job)
+	if ctrl.onTimeout != nil && !time.Now().Before(deadline) {
+		ctrl.onTimeout(ctrl, TimeoutRecord[Job, Properties]{
+			MetaJob:  mj,
+			Deadline: deadline,
+			Rank:     rank,
+		})
 	}
+	return
 }
 
 // inputBeforeLaunch inputs metaJobs before the first call to the method Launch.
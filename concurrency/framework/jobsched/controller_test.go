@@ -19,8 +19,11 @@
 package jobsched_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -28,6 +31,7 @@ import (
 	"time"
 
 	"github.com/donyori/gogo/concurrency"
+	"github.com/donyori/gogo/concurrency/framework"
 	"github.com/donyori/gogo/concurrency/framework/jobsched"
 )
 
@@ -704,7 +708,7 @@ func TestController_JobHandlerRankUnique(t *testing.T) {
 		t.Run(fmt.Sprintf("n=%d", n), func(t *testing.T) {
 			numWorker := n
 			if numWorker <= 0 {
-				numWorker = runtime.NumCPU() - 2
+				numWorker = min(runtime.NumCPU(), runtime.GOMAXPROCS(0)) - 2
 				if numWorker < 1 {
 					numWorker = 1
 				}
@@ -733,6 +737,797 @@ func TestController_JobHandlerRankUnique(t *testing.T) {
 	}
 }
 
+func TestNewWithContext_Cancel(t *testing.T) {
+	const NumJobBeforeCancel = 10
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var x atomic.Int32
+	ctrl := jobsched.NewWithContext(ctx, func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback int) {
+		if x.Add(1) == NumJobBeforeCancel {
+			cancel()
+		}
+		// Generate new jobs endlessly.
+		// Only the context cancellation can stop the controller.
+		return []*jobsched.MetaJob[int, jobsched.NoProperty]{{Job: job + 1}}, 1
+	}, nil, &jobsched.Options[int, jobsched.NoProperty, int]{
+		NumWorker: 4,
+	}, make([]*jobsched.MetaJob[int, jobsched.NoProperty], 4)...)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ctrl.Run()
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Minute):
+		t.Fatal("timeout - Run did not return after the context was canceled")
+	}
+	if !ctrl.Canceler().Canceled() {
+		t.Error("canceler is not canceled")
+	}
+	if gotX := x.Load(); gotX < NumJobBeforeCancel {
+		t.Errorf("got x %d; want >= %d", gotX, NumJobBeforeCancel)
+	}
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+}
+
+func TestNewWithContext_CanceledBeforeLaunch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var x atomic.Int32
+	ctrl := jobsched.NewWithContext(ctx, func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback jobsched.NoFeedback) {
+		x.Add(1)
+		return
+	}, nil, nil, make([]*jobsched.MetaJob[int, jobsched.NoProperty], 16)...)
+	if !ctrl.Canceler().Canceled() {
+		t.Error("canceler is not canceled before launch")
+	}
+	ctrl.Run()
+	// Workers may have received at most one job each
+	// before noticing the cancellation signal.
+	if gotX := x.Load(); int(gotX) > ctrl.NumGoroutine() {
+		t.Errorf("got x %d; want <= %d", gotX, ctrl.NumGoroutine())
+	}
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+}
+
+func TestNewWithContext_NotCanceled(t *testing.T) {
+	const NumJob = 16
+	var x atomic.Int32
+	var feedbackSum int
+	opts := &jobsched.Options[int, jobsched.NoProperty, int]{NumWorker: 4}
+	ctrl := jobsched.NewWithContext(context.Background(), func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback int) {
+		x.Add(1)
+		return nil, 1
+	}, getSumFeedbackHandler(&feedbackSum), opts,
+		make([]*jobsched.MetaJob[int, jobsched.NoProperty], NumJob)...)
+	if opts.Context != nil {
+		t.Error("the client's options are modified")
+	}
+	ctrl.Run()
+	if gotX := x.Load(); gotX != NumJob {
+		t.Errorf("got x %d; want %d", gotX, NumJob)
+	}
+	if feedbackSum != NumJob {
+		t.Errorf("got sum of feedback %d; want %d", feedbackSum, NumJob)
+	}
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+}
+
+func TestController_JobTimeout(t *testing.T) {
+	const NumWorker = 2
+	var x atomic.Int32
+	var m sync.Mutex
+	var records []jobsched.TimeoutRecord[int, jobsched.NoProperty]
+	ctrl := jobsched.New(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback int) {
+		if job == 1 {
+			// Only job 1 has a timeout.
+			select {
+			case <-canceler.C():
+			case <-time.After(time.Minute):
+				t.Error("timeout - the job canceler did not broadcast the signal")
+			}
+		} else if canceler.Canceled() {
+			t.Errorf("job %d - canceler is canceled", job)
+		}
+		x.Add(1)
+		return
+	}, nil, &jobsched.Options[int, jobsched.NoProperty, int]{
+		NumWorker: NumWorker,
+		OnTimeout: func(
+			ctrl jobsched.Controller[int, jobsched.NoProperty, int],
+			record jobsched.TimeoutRecord[int, jobsched.NoProperty],
+		) {
+			m.Lock()
+			defer m.Unlock()
+			records = append(records, record)
+		},
+	},
+		&jobsched.MetaJob[int, jobsched.NoProperty]{
+			Meta: jobsched.Meta[jobsched.NoProperty]{Timeout: time.Millisecond * 10},
+			Job:  1,
+		},
+		&jobsched.MetaJob[int, jobsched.NoProperty]{Job: 2},
+		&jobsched.MetaJob[int, jobsched.NoProperty]{Job: 3},
+	)
+	ctrl.Run()
+	if gotX := x.Load(); gotX != 3 {
+		t.Errorf("got x %d; want 3", gotX)
+	}
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+	if len(records) != 1 {
+		t.Fatalf("got %d timeout records; want 1", len(records))
+	}
+	if records[0].MetaJob.Job != 1 {
+		t.Errorf("got timeout job %d; want 1", records[0].MetaJob.Job)
+	}
+	if records[0].Rank < 0 || records[0].Rank >= NumWorker {
+		t.Errorf("got rank %d; want in [0, %d)", records[0].Rank, NumWorker)
+	}
+}
+
+func TestController_JobTimeout_ContextValue(t *testing.T) {
+	type ctxKey struct{}
+	const Value = "test value"
+	ctx := context.WithValue(context.Background(), ctxKey{}, Value)
+	var x atomic.Int32
+	jobsched.NewWithContext(ctx, func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback int) {
+		jobCtx := canceler.AsContext()
+		if v := jobCtx.Value(ctxKey{}); v != Value {
+			t.Errorf("job %d - got context value %v; want %q", job, v, Value)
+		}
+		if _, ok := jobCtx.Deadline(); ok != (job == 1) {
+			t.Errorf("job %d - got context deadline set %t; want %t",
+				job, ok, job == 1)
+		}
+		x.Add(1)
+		return
+	}, nil, nil,
+		&jobsched.MetaJob[int, jobsched.NoProperty]{
+			Meta: jobsched.Meta[jobsched.NoProperty]{Timeout: time.Minute},
+			Job:  1,
+		},
+		&jobsched.MetaJob[int, jobsched.NoProperty]{Job: 2},
+	).Run()
+	if gotX := x.Load(); gotX != 2 {
+		t.Errorf("got x %d; want 2", gotX)
+	}
+}
+
+func TestController_JobDeadlineExceededInQueue(t *testing.T) {
+	var processed []int
+	var records []jobsched.TimeoutRecord[int, jobsched.NoProperty]
+	deadline := time.Now().Add(-time.Second)
+	ctrl := jobsched.New(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback int) {
+		processed = append(processed, job) // only one worker; no race condition
+		return
+	}, nil, &jobsched.Options[int, jobsched.NoProperty, int]{
+		NumWorker: 1,
+		OnTimeout: func(
+			ctrl jobsched.Controller[int, jobsched.NoProperty, int],
+			record jobsched.TimeoutRecord[int, jobsched.NoProperty],
+		) {
+			records = append(records, record) // called by the job allocator only
+		},
+	},
+		&jobsched.MetaJob[int, jobsched.NoProperty]{Job: 1},
+		&jobsched.MetaJob[int, jobsched.NoProperty]{
+			Meta: jobsched.Meta[jobsched.NoProperty]{Deadline: deadline},
+			Job:  2,
+		},
+		&jobsched.MetaJob[int, jobsched.NoProperty]{Job: 3},
+	)
+	ctrl.Run()
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+	if len(processed) != 2 || processed[0] != 1 || processed[1] != 3 {
+		t.Errorf("got processed jobs %v; want [1 3]", processed)
+	}
+	if len(records) != 1 {
+		t.Fatalf("got %d timeout records; want 1", len(records))
+	}
+	if records[0].MetaJob.Job != 2 ||
+		!records[0].Deadline.Equal(deadline) ||
+		records[0].Rank != -1 {
+		t.Errorf("got timeout record %+v; want job 2, deadline %v, rank -1",
+			records[0], deadline)
+	}
+}
+
+func TestController_SetNumWorker_BeforeLaunch(t *testing.T) {
+	const NumWorker = 3
+	var rankOutOfRange atomic.Bool
+	ctrl := jobsched.NewWithoutFeedback(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback jobsched.NoFeedback) {
+		if rank < 0 || rank >= NumWorker {
+			rankOutOfRange.Store(true)
+		}
+		return
+	}, &jobsched.Options[int, jobsched.NoProperty, jobsched.NoFeedback]{
+		NumWorker: 1,
+	}, make([]*jobsched.MetaJob[int, jobsched.NoProperty], 16)...)
+	ctrl.SetNumWorker(NumWorker)
+	if n := ctrl.NumGoroutine(); n != NumWorker {
+		t.Errorf("got NumGoroutine %d; want %d", n, NumWorker)
+	}
+	ctrl.Run()
+	if rankOutOfRange.Load() {
+		t.Errorf("got rank out of range [0, %d)", NumWorker)
+	}
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+}
+
+func TestController_SetNumWorker_Grow(t *testing.T) {
+	const NumWorker = 4
+	var m sync.Mutex
+	setupRanks := make(map[int]int)
+	var barrier sync.WaitGroup
+	barrier.Add(NumWorker)
+	var ctrl jobsched.Controller[int, jobsched.NoProperty, jobsched.NoFeedback]
+	ctrl = jobsched.NewWithoutFeedback(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback jobsched.NoFeedback) {
+		if job == 0 {
+			ctrl.SetNumWorker(NumWorker)
+		}
+		barrier.Done()
+		// Block the worker to ensure that
+		// NumWorker workers process jobs simultaneously.
+		done := make(chan struct{})
+		go func() {
+			barrier.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Minute):
+			t.Error("timeout - new workers did not process jobs")
+			canceler.Cancel()
+		}
+		return
+	}, &jobsched.Options[int, jobsched.NoProperty, jobsched.NoFeedback]{
+		NumWorker: 1,
+		Setup: func(
+			ctrl jobsched.Controller[int, jobsched.NoProperty, jobsched.NoFeedback],
+			rank int,
+		) {
+			m.Lock()
+			defer m.Unlock()
+			setupRanks[rank]++
+		},
+	}, &jobsched.MetaJob[int, jobsched.NoProperty]{Job: 0},
+		&jobsched.MetaJob[int, jobsched.NoProperty]{Job: 1},
+		&jobsched.MetaJob[int, jobsched.NoProperty]{Job: 2},
+		&jobsched.MetaJob[int, jobsched.NoProperty]{Job: 3},
+	)
+	ctrl.Run()
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+	if n := ctrl.NumGoroutine(); n != NumWorker {
+		t.Errorf("got NumGoroutine %d; want %d", n, NumWorker)
+	}
+	if len(setupRanks) != NumWorker {
+		t.Errorf("got setup ranks %v; want 0 to %d", setupRanks, NumWorker-1)
+	}
+	for rank := range NumWorker {
+		if setupRanks[rank] != 1 {
+			t.Errorf("setup for rank %d was called %d times; want 1",
+				rank, setupRanks[rank])
+		}
+	}
+}
+
+func TestController_SetNumWorker_Shrink(t *testing.T) {
+	const NumWorker, NewNumWorker = 4, 2
+	var m sync.Mutex
+	var cleanupRanks []int
+	var rankOutOfRange atomic.Bool
+	ctrl := jobsched.NewWithoutFeedback(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback jobsched.NoFeedback) {
+		if rank < 0 || rank >= NewNumWorker {
+			rankOutOfRange.Store(true)
+		}
+		return
+	}, &jobsched.Options[int, jobsched.NoProperty, jobsched.NoFeedback]{
+		NumWorker: NumWorker,
+		Cleanup: func(
+			ctrl jobsched.Controller[int, jobsched.NoProperty, jobsched.NoFeedback],
+			rank int,
+		) {
+			m.Lock()
+			defer m.Unlock()
+			cleanupRanks = append(cleanupRanks, rank)
+		},
+	})
+	ctrl.Launch()
+	ctrl.SetNumWorker(NewNumWorker)
+	if n := ctrl.NumGoroutine(); n != NewNumWorker {
+		t.Errorf("got NumGoroutine %d; want %d", n, NewNumWorker)
+	}
+	timeout := time.After(time.Minute)
+	for {
+		m.Lock()
+		n := len(cleanupRanks)
+		m.Unlock()
+		if n >= NumWorker-NewNumWorker {
+			break
+		}
+		select {
+		case <-timeout:
+			t.Fatal("timeout - workers did not exit")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	m.Lock()
+	slices.Sort(cleanupRanks)
+	if !slices.Equal(cleanupRanks, []int{2, 3}) {
+		t.Errorf("got cleanup ranks %v; want [2 3]", cleanupRanks)
+	}
+	m.Unlock()
+	ctrl.Input(make([]*jobsched.MetaJob[int, jobsched.NoProperty], 16)...)
+	ctrl.Wait()
+	if rankOutOfRange.Load() {
+		t.Errorf("got rank out of range [0, %d)", NewNumWorker)
+	}
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+}
+
+func TestController_Shutdown(t *testing.T) {
+	const NumJob = 8
+	var x atomic.Int32
+	var feedbackSum int
+	ctrl := jobsched.New(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback int) {
+		x.Add(1)
+		time.Sleep(time.Millisecond)
+		if job < NumJob {
+			// Generate a new job to test that
+			// the new jobs are also processed.
+			newJobs = []*jobsched.MetaJob[int, jobsched.NoProperty]{
+				{Job: job + NumJob},
+			}
+		}
+		return newJobs, 1
+	}, getSumFeedbackHandler(&feedbackSum), &jobsched.Options[int, jobsched.NoProperty, int]{
+		NumWorker: 2,
+	})
+	metaJobs := make([]*jobsched.MetaJob[int, jobsched.NoProperty], NumJob)
+	for i := range metaJobs {
+		metaJobs[i] = &jobsched.MetaJob[int, jobsched.NoProperty]{Job: i}
+	}
+	ctrl.Launch()
+	ctrl.Input(metaJobs...)
+	if err := ctrl.Shutdown(context.Background()); err != nil {
+		t.Error("shutdown -", err)
+	}
+	if gotX := x.Load(); gotX != NumJob*2 {
+		t.Errorf("got x %d; want %d", gotX, NumJob*2)
+	}
+	if feedbackSum != NumJob*2 {
+		t.Errorf("got sum of feedback %d; want %d", feedbackSum, NumJob*2)
+	}
+	if n := ctrl.Input(nil); n != 0 {
+		t.Errorf("after shutdown, got Input %d; want 0", n)
+	}
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+}
+
+func TestController_Shutdown_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	var x atomic.Int32
+	ctrl := jobsched.NewWithoutFeedback(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback jobsched.NoFeedback) {
+		<-release // ignore the cancellation signal
+		x.Add(1)
+		return
+	}, &jobsched.Options[int, jobsched.NoProperty, jobsched.NoFeedback]{
+		NumWorker: 2,
+	}, make([]*jobsched.MetaJob[int, jobsched.NoProperty], 4)...)
+	ctrl.Launch()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	err := ctrl.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v; want %v", err, context.DeadlineExceeded)
+	}
+	if ctrl.Canceler().Canceled() {
+		t.Error("canceler is canceled after shutdown timeout")
+	}
+	close(release)
+	if err = ctrl.Shutdown(context.Background()); err != nil {
+		t.Error("shutdown again -", err)
+	}
+	if gotX := x.Load(); gotX != 4 {
+		t.Errorf("got x %d; want 4", gotX)
+	}
+}
+
+func TestController_Stop(t *testing.T) {
+	const NumJob = 100
+	var x atomic.Int32
+	ctrl := jobsched.NewWithoutFeedback(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback jobsched.NoFeedback) {
+		x.Add(1)
+		select {
+		case <-canceler.C():
+		case <-time.After(time.Minute):
+		}
+		return
+	}, &jobsched.Options[int, jobsched.NoProperty, jobsched.NoFeedback]{
+		NumWorker: 2,
+	}, make([]*jobsched.MetaJob[int, jobsched.NoProperty], NumJob)...)
+	ctrl.Launch()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := ctrl.Stop(ctx); err != nil {
+		t.Error("stop -", err)
+	}
+	if !ctrl.Canceler().Canceled() {
+		t.Error("canceler is not canceled after stop")
+	}
+	if gotX := x.Load(); gotX >= NumJob {
+		t.Errorf("got x %d; want < %d", gotX, NumJob)
+	}
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+}
+
+func TestController_Stop_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	var started sync.WaitGroup
+	started.Add(1)
+	ctrl := jobsched.NewWithoutFeedback(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback jobsched.NoFeedback) {
+		started.Done()
+		<-release // ignore the cancellation signal
+		return
+	}, &jobsched.Options[int, jobsched.NoProperty, jobsched.NoFeedback]{
+		NumWorker: 1,
+	}, &jobsched.MetaJob[int, jobsched.NoProperty]{})
+	ctrl.Launch()
+	started.Wait()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	err := ctrl.Stop(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v; want %v", err, context.DeadlineExceeded)
+	}
+	close(release)
+	ctrl.Wait()
+}
+
+func TestController_ShutdownAndStop_BeforeLaunch(t *testing.T) {
+	ctrl := jobsched.NewWithoutFeedback(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback jobsched.NoFeedback) {
+		return
+	}, nil)
+	if err := ctrl.Shutdown(context.Background()); err != nil {
+		t.Error("shutdown -", err)
+	}
+	if ctrl.Canceler().Canceled() {
+		t.Error("canceler is canceled after shutdown before launch")
+	}
+	if err := ctrl.Stop(context.Background()); err != nil {
+		t.Error("stop -", err)
+	}
+	if !ctrl.Canceler().Canceled() {
+		t.Error("canceler is not canceled after stop before launch")
+	}
+}
+
+func TestRunCollect(t *testing.T) {
+	const NumJob = 32
+	metaJobs := make([]*jobsched.MetaJob[int, jobsched.NoProperty], NumJob)
+	for i := range metaJobs {
+		metaJobs[i] = &jobsched.MetaJob[int, jobsched.NoProperty]{Job: i}
+	}
+	feedback, prs := jobsched.RunCollect(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback int) {
+		return nil, job * 2
+	}, &jobsched.Options[int, jobsched.NoProperty, int]{
+		NumWorker: 4,
+	}, metaJobs...)
+	if len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+	slices.Sort(feedback)
+	want := make([]int, NumJob)
+	for i := range want {
+		want[i] = i * 2
+	}
+	if !slices.Equal(feedback, want) {
+		t.Errorf("got (sorted) %v; want %v", feedback, want)
+	}
+}
+
+func TestController_FeedbackSeq(t *testing.T) {
+	const NumJob = 32
+	ctrl := jobsched.New(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback int) {
+		return nil, 1
+	}, nil, &jobsched.Options[int, jobsched.NoProperty, int]{
+		NumWorker: 4,
+	}, make([]*jobsched.MetaJob[int, jobsched.NoProperty], NumJob)...)
+	var sum int
+	for fb := range ctrl.FeedbackSeq() {
+		sum += fb
+	}
+	if sum != NumJob {
+		t.Errorf("got sum of feedback %d; want %d", sum, NumJob)
+	}
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+	for range ctrl.FeedbackSeq() {
+		t.Error("second iteration yielded feedback")
+		break
+	}
+}
+
+func TestController_IterFeedback(t *testing.T) {
+	const NumJob = 32
+	ctrl := jobsched.New(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback int) {
+		return nil, 1
+	}, nil, &jobsched.Options[int, jobsched.NoProperty, int]{
+		NumWorker: 4,
+	}, make([]*jobsched.MetaJob[int, jobsched.NoProperty], NumJob)...)
+	var err error
+	seq := ctrl.IterFeedback(&err)
+	var sum int
+	for fb := range seq {
+		sum += fb
+	}
+	if err != nil {
+		t.Error(err)
+	}
+	if sum != NumJob {
+		t.Errorf("got sum of feedback %d; want %d", sum, NumJob)
+	}
+	for range seq {
+		t.Error("second iteration yielded feedback")
+		break
+	}
+	if !errors.Is(err, jobsched.ErrFeedbackUnavailable) {
+		t.Errorf("second iteration, got error %v; want %v",
+			err, jobsched.ErrFeedbackUnavailable)
+	}
+	for range ctrl.IterFeedback(nil) {
+		t.Error("iteration with nil pErr yielded feedback")
+		break
+	}
+}
+
+func TestController_IterFeedback_Panic(t *testing.T) {
+	const PanicMsg = "test panic"
+	ctrl := jobsched.New(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback int) {
+		if job == 1 {
+			panic(PanicMsg)
+		}
+		return nil, job
+	}, nil, &jobsched.Options[int, jobsched.NoProperty, int]{
+		NumWorker: 1,
+	}, &jobsched.MetaJob[int, jobsched.NoProperty]{Job: 0},
+		&jobsched.MetaJob[int, jobsched.NoProperty]{Job: 1})
+	var err error
+	for range ctrl.IterFeedback(&err) {
+	}
+	var pr framework.PanicRecord
+	if !errors.As(err, &pr) {
+		t.Fatalf("got error %v; want a panic record", err)
+	}
+	if pr.Content != PanicMsg {
+		t.Errorf("got panic content %v; want %q", pr.Content, PanicMsg)
+	}
+}
+
+func TestController_FeedbackSeq_Break(t *testing.T) {
+	const Limit = 5
+	var x atomic.Int32
+	ctrl := jobsched.New(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback int) {
+		x.Add(1)
+		// Generate new jobs endlessly.
+		return []*jobsched.MetaJob[int, jobsched.NoProperty]{{Job: job + 1}}, job
+	}, nil, &jobsched.Options[int, jobsched.NoProperty, int]{
+		NumWorker: 2,
+	}, &jobsched.MetaJob[int, jobsched.NoProperty]{})
+	var n int
+	for range ctrl.FeedbackSeq() {
+		n++
+		if n >= Limit {
+			break
+		}
+	}
+	if n != Limit {
+		t.Errorf("got %d feedback; want %d", n, Limit)
+	}
+	if !ctrl.Canceler().Canceled() {
+		t.Error("canceler is not canceled after breaking the loop")
+	}
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+}
+
+func TestController_FeedbackSeq_AfterLaunch(t *testing.T) {
+	var x atomic.Int32
+	ctrl := jobsched.New(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback int) {
+		x.Add(1)
+		return nil, 1
+	}, nil, nil, make([]*jobsched.MetaJob[int, jobsched.NoProperty], 8)...)
+	ctrl.Launch()
+	for range ctrl.FeedbackSeq() {
+		t.Error("iteration after launch yielded feedback")
+		break
+	}
+	if gotX := x.Load(); gotX != 8 {
+		t.Errorf("got x %d; want 8", gotX)
+	}
+}
+
+func TestController_RestartOnPanic(t *testing.T) {
+	const PanicMsg = "test panic"
+	const NumWorker = 2
+	const NumJob = 20
+	var x atomic.Int32
+	var setupCalls, cleanupCalls atomic.Int32
+	var feedbackSum int
+	metaJobs := make([]*jobsched.MetaJob[int, jobsched.NoProperty], NumJob)
+	for i := range metaJobs {
+		metaJobs[i] = &jobsched.MetaJob[int, jobsched.NoProperty]{Job: i}
+	}
+	prs := jobsched.Run(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback int) {
+		if job%5 == 0 {
+			panic(PanicMsg)
+		}
+		x.Add(1)
+		return nil, 1
+	}, getSumFeedbackHandler(&feedbackSum), &jobsched.Options[int, jobsched.NoProperty, int]{
+		NumWorker:      NumWorker,
+		RestartOnPanic: true,
+		Setup: func(
+			ctrl jobsched.Controller[int, jobsched.NoProperty, int],
+			rank int,
+		) {
+			setupCalls.Add(1)
+		},
+		Cleanup: func(
+			ctrl jobsched.Controller[int, jobsched.NoProperty, int],
+			rank int,
+		) {
+			cleanupCalls.Add(1)
+		},
+	}, metaJobs...)
+	const NumPanic = NumJob / 5
+	if gotX := x.Load(); gotX != NumJob-NumPanic {
+		t.Errorf("got x %d; want %d", gotX, NumJob-NumPanic)
+	}
+	if feedbackSum != NumJob-NumPanic {
+		t.Errorf("got sum of feedback %d; want %d",
+			feedbackSum, NumJob-NumPanic)
+	}
+	if len(prs) != NumPanic {
+		t.Errorf("got len(prs) %d; want %d", len(prs), NumPanic)
+	}
+	for _, pr := range prs {
+		if msg, ok := pr.Content.(string); !ok || msg != PanicMsg {
+			t.Error(pr)
+		}
+	}
+	if n := setupCalls.Load(); n != NumWorker+NumPanic {
+		t.Errorf("got %d calls to Setup; want %d", n, NumWorker+NumPanic)
+	}
+	if n := cleanupCalls.Load(); n != NumWorker+NumPanic {
+		t.Errorf("got %d calls to Cleanup; want %d", n, NumWorker+NumPanic)
+	}
+}
+
+func TestController_RestartOnPanic_SetupPanic(t *testing.T) {
+	const PanicMsg = "test panic"
+	var setupCalls atomic.Int32
+	prs := jobsched.RunWithoutFeedback(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback jobsched.NoFeedback) {
+		return
+	}, &jobsched.Options[int, jobsched.NoProperty, jobsched.NoFeedback]{
+		NumWorker:      1,
+		RestartOnPanic: true,
+		Setup: func(
+			ctrl jobsched.Controller[int, jobsched.NoProperty, jobsched.NoFeedback],
+			rank int,
+		) {
+			setupCalls.Add(1)
+			panic(PanicMsg)
+		},
+	}, make([]*jobsched.MetaJob[int, jobsched.NoProperty], 4)...)
+	if n := setupCalls.Load(); n != 1 {
+		t.Errorf("got %d calls to Setup; want 1", n)
+	}
+	if len(prs) != 1 {
+		t.Errorf("got len(prs) %d; want 1", len(prs))
+	}
+}
+
+func TestController_OrderedFeedback(t *testing.T) {
+	const NumJob = 30
+	const DroppedJob, PanicJob = 10, 20
+	metaJobs := make([]*jobsched.MetaJob[int, jobsched.NoProperty], NumJob)
+	for i := range metaJobs {
+		metaJobs[i] = &jobsched.MetaJob[int, jobsched.NoProperty]{Job: i}
+	}
+	metaJobs[DroppedJob].Meta.Deadline = time.Now().Add(-time.Second)
+	want := make([]int, 0, NumJob-2)
+	for i := range NumJob {
+		if i != DroppedJob && i != PanicJob {
+			want = append(want, i)
+		}
+	}
+
+	for _, useSeq := range []bool{false, true} {
+		t.Run(fmt.Sprintf("useSeq=%t", useSeq), func(t *testing.T) {
+			var got []int
+			jobHandler := func(canceler concurrency.Canceler, rank, job int) (
+				newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback int) {
+				if job == PanicJob {
+					panic("test panic")
+				}
+				// Make the jobs finish out of order.
+				time.Sleep(time.Millisecond * time.Duration((NumJob-job)%7))
+				return nil, job
+			}
+			opts := &jobsched.Options[int, jobsched.NoProperty, int]{
+				NumWorker:       4,
+				RestartOnPanic:  true,
+				OrderedFeedback: true,
+			}
+			var ctrl jobsched.Controller[int, jobsched.NoProperty, int]
+			if useSeq {
+				ctrl = jobsched.New(jobHandler, nil, opts, metaJobs...)
+				for fb := range ctrl.FeedbackSeq() {
+					got = append(got, fb)
+				}
+			} else {
+				ctrl = jobsched.New(jobHandler, func(
+					canceler concurrency.Canceler, feedback int) {
+					got = append(got, feedback)
+				}, opts, metaJobs...)
+				ctrl.Run()
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("got %v;\nwant %v", got, want)
+			}
+			if prs := ctrl.PanicRecords(); len(prs) != 1 {
+				t.Errorf("got %d panic records; want 1", len(prs))
+			}
+		})
+	}
+}
+
 // getSumFeedbackHandler returns a
 // github.com/donyori/gogo/concurrency/framework/jobsched.FeedbackHandler[int]
 // that adds the feedback (of type int) to *ptr.
@@ -0,0 +1,114 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobsched
+
+import (
+	"context"
+	"time"
+
+	"github.com/donyori/gogo/concurrency"
+)
+
+// TimeoutRecord is a record of a job that exceeded its deadline.
+//
+// The deadline of a job is determined by the fields Deadline and Timeout
+// in its meta information.
+type TimeoutRecord[Job, Properties any] struct {
+	// The job that exceeded its deadline, with its meta information.
+	MetaJob *MetaJob[Job, Properties]
+
+	// The effective deadline of the job.
+	Deadline time.Time
+
+	// The rank of the worker goroutine that processed the job,
+	// or -1 if the job was dropped from the job queue
+	// without being processed.
+	Rank int
+}
+
+// jobDeadline returns the effective deadline of the job
+// with the specified meta information,
+// assuming that the job is received by a worker goroutine at time start.
+//
+// ok is false if the job has neither a deadline nor a timeout.
+func jobDeadline[Properties any](
+	meta *Meta[Properties],
+	start time.Time,
+) (deadline time.Time, ok bool) {
+	deadline = meta.Deadline
+	if meta.Timeout > 0 {
+		t := start.Add(meta.Timeout)
+		if deadline.IsZero() || t.Before(deadline) {
+			deadline = t
+		}
+	}
+	return deadline, !deadline.IsZero()
+}
+
+// jobCanceler is the canceler passed to the job handler
+// for a job with a deadline.
+//
+// Its method Cancel cancels the whole controller,
+// the same as the canceler of the controller.
+// Its method C returns a channel that is closed
+// when the controller is canceled or the deadline of the job is exceeded.
+type jobCanceler struct {
+	c      concurrency.Canceler // The canceler of the controller.
+	ctx    context.Context      // The context of c with the deadline of the job.
+	cancel context.CancelFunc   // The cancel function of ctx.
+}
+
+// newJobCanceler creates a new jobCanceler
+// derived from c with the specified deadline.
+//
+// The context returned by its method AsContext is derived from
+// c.AsContext(), so it retains the values of that context.
+//
+// The caller must call the method stop of the returned jobCanceler
+// after the job is processed to release the associated resources.
+func newJobCanceler(c concurrency.Canceler, deadline time.Time) *jobCanceler {
+	ctx, cancel := context.WithDeadline(c.AsContext(), deadline)
+	return &jobCanceler{c: c, ctx: ctx, cancel: cancel}
+}
+
+func (jc *jobCanceler) Cancel() {
+	jc.c.Cancel()
+}
+
+func (jc *jobCanceler) C() <-chan struct{} {
+	return jc.ctx.Done()
+}
+
+func (jc *jobCanceler) Canceled() bool {
+	select {
+	case <-jc.ctx.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+func (jc *jobCanceler) AsContext() context.Context {
+	return jc.ctx
+}
+
+// stop releases the resources associated with jc.
+func (jc *jobCanceler) stop() {
+	jc.cancel()
+}
@@ -46,6 +46,48 @@ type JobQueue[Job, Properties any] interface {
 	Dequeue() Job
 }
 
+// MetaDequeuer is an optional interface for JobQueue.
+//
+// A JobQueue implementing MetaDequeuer returns jobs together with
+// their meta information, which enables the framework to apply
+// the fields Deadline and Timeout in the meta information.
+//
+// All the job queues provided by this framework
+// (including those in the subpackage queue)
+// implement MetaDequeuer.
+type MetaDequeuer[Job, Properties any] interface {
+	// DequeueMeta removes and returns a job in the queue
+	// together with its meta information.
+	//
+	// The returned item must be the same as that passed to
+	// the method Enqueue of the job queue.
+	//
+	// It panics if the queue is nil or empty.
+	DequeueMeta() *MetaJob[Job, Properties]
+}
+
+// MetaRanger is an optional interface for JobQueue.
+//
+// A JobQueue implementing MetaRanger enables the framework to inspect
+// the queued jobs without removing them
+// (see the method SnapshotPending of Controller).
+//
+// All the job queues provided by this framework
+// (including those in the subpackage queue)
+// implement MetaRanger.
+type MetaRanger[Job, Properties any] interface {
+	// RangeMeta accesses the jobs in the queue
+	// together with their meta information.
+	// Each job is accessed once.
+	// The order of access depends on the specific implementation.
+	//
+	// Its parameter handler is a function to deal with the job metaJob
+	// in the queue and report whether to continue to access the next job.
+	//
+	// handler must not modify metaJob.
+	RangeMeta(handler func(metaJob *MetaJob[Job, Properties]) (cont bool))
+}
+
 // JobQueueMaker is a maker for creating job queues.
 //
 // The first type parameter Job is the type of jobs.
@@ -69,7 +111,7 @@ type JobQueueMaker[Job, Properties any] interface {
 const emptyQueuePanicMessage = "job queue is empty"
 
 // fcfsJobQueue is an FCFS (first come, first served) job queue.
-type fcfsJobQueue[Job, Properties any] []Job
+type fcfsJobQueue[Job, Properties any] []*MetaJob[Job, Properties]
 
 func (jq *fcfsJobQueue[Job, Properties]) Len() int {
 	return len(*jq)
@@ -80,18 +122,30 @@ func (jq *fcfsJobQueue[Job, Properties]) Enqueue(
 	if len(metaJob) == 0 {
 		return
 	}
-	i := len(*jq)
-	*jq = append(*jq, make([]Job, len(metaJob))...)
-	for _, mj := range metaJob {
-		(*jq)[i], i = mj.Job, i+1
-	}
+	*jq = append(*jq, metaJob...)
 }
 
 func (jq *fcfsJobQueue[Job, Properties]) Dequeue() Job {
 	if len(*jq) == 0 {
 		panic(errors.AutoMsg(emptyQueuePanicMessage))
 	}
-	var job Job
-	*jq, (*jq)[0], job = (*jq)[1:], job, (*jq)[0] // where (*jq)[0] = job is to avoid memory leak
-	return job
+	return jq.DequeueMeta().Job
+}
+
+func (jq *fcfsJobQueue[Job, Properties]) DequeueMeta() *MetaJob[Job, Properties] {
+	if len(*jq) == 0 {
+		panic(errors.AutoMsg(emptyQueuePanicMessage))
+	}
+	var mj *MetaJob[Job, Properties]
+	*jq, (*jq)[0], mj = (*jq)[1:], nil, (*jq)[0] // where (*jq)[0] = nil is to avoid memory leak
+	return mj
+}
+
+func (jq *fcfsJobQueue[Job, Properties]) RangeMeta(
+	handler func(metaJob *MetaJob[Job, Properties]) (cont bool)) {
+	for _, mj := range *jq {
+		if !handler(mj) {
+			return
+		}
+	}
 }
@@ -42,10 +42,48 @@ type Meta[Properties any] struct {
 	// before adding to a job queue.
 	CreationTime time.Time
 
+	// The deadline of the job.
+	//
+	// A zero-value Deadline means that the job has no deadline.
+	//
+	// If the job is still in the job queue when its deadline is exceeded,
+	// it is dropped without being processed.
+	// If the job is being processed when its deadline is exceeded,
+	// the canceler passed to the job handler broadcasts
+	// the cancellation signal for this job.
+	// In both cases, the job is reported to the option OnTimeout.
+	//
+	// The deadline takes effect only if the job queue
+	// implements MetaDequeuer.
+	Deadline time.Time
+
+	// The maximum duration to process the job,
+	// counted from when the job is received by a worker goroutine.
+	//
+	// Nonpositive values for no timeout.
+	//
+	// If both Deadline and Timeout are set,
+	// the earlier one takes effect.
+	//
+	// The timeout takes effect only if the job queue
+	// implements MetaDequeuer.
+	Timeout time.Duration
+
 	// Custom properties, used to customize job scheduling algorithm.
 	//
 	// If no custom property is required, set the type parameter to NoProperty.
 	Custom Properties
+
+	// The number of attempts made on the job, maintained by the framework.
+	attempt int
+
+	// The time before which the job cannot be retried,
+	// maintained by the framework.
+	notBefore time.Time
+
+	// The sequence number of the job, maintained by the framework
+	// if the option OrderedFeedback is true.
+	seq uint64
 }
 
 // MetaJob combines the job and its meta information.
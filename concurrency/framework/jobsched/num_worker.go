@@ -0,0 +1,91 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobsched
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// NumWorkerPolicy determines the number of worker goroutines
+// from the number of available CPUs.
+//
+// The number of available CPUs is the minimum of runtime.NumCPU()
+// and runtime.GOMAXPROCS(0),
+// evaluated each time the policy is applied.
+//
+// If the policy returns a nonpositive value, 1 is used instead.
+type NumWorkerPolicy func(numCPU int) int
+
+// NumCPUPolicy returns a NumWorkerPolicy that
+// uses one worker goroutine per available CPU.
+func NumCPUPolicy() NumWorkerPolicy {
+	return func(numCPU int) int {
+		return numCPU
+	}
+}
+
+// NumCPUMinusPolicy returns a NumWorkerPolicy that
+// uses k fewer worker goroutines than the available CPUs,
+// leaving them for other goroutines such as the job allocator
+// and the feedback handler.
+//
+// The default policy of the controller is NumCPUMinusPolicy(2).
+func NumCPUMinusPolicy(k int) NumWorkerPolicy {
+	return func(numCPU int) int {
+		return numCPU - k
+	}
+}
+
+// NumCPUFractionPolicy returns a NumWorkerPolicy that
+// uses the fraction f of the available CPUs, rounded to the nearest integer.
+//
+// NumCPUFractionPolicy panics if f is not positive or is NaN or infinity.
+func NumCPUFractionPolicy(f float64) NumWorkerPolicy {
+	if !(f > 0) || math.IsInf(f, 1) {
+		panic(errors.AutoMsg(fmt.Sprintf(
+			"f (%v) must be a positive finite number", f)))
+	}
+	return func(numCPU int) int {
+		return int(math.Round(float64(numCPU) * f))
+	}
+}
+
+// availableCPU returns the minimum of runtime.NumCPU()
+// and runtime.GOMAXPROCS(0).
+func availableCPU() int {
+	return min(runtime.NumCPU(), runtime.GOMAXPROCS(0))
+}
+
+// normalizeNumWorker returns n if n is positive.
+// Otherwise, it applies the policy p to the number of available CPUs
+// (see NumWorkerPolicy), or NumCPUMinusPolicy(2) if p is nil,
+// and returns the result, or 1 if the result is nonpositive.
+func normalizeNumWorker(n int, p NumWorkerPolicy) int {
+	if n > 0 {
+		return n
+	}
+	if p == nil {
+		p = NumCPUMinusPolicy(2)
+	}
+	return max(p(availableCPU()), 1)
+}
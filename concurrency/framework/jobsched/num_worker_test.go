@@ -0,0 +1,129 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobsched_test
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sync/atomic"
+	"testing"
+
+	"github.com/donyori/gogo/concurrency"
+	"github.com/donyori/gogo/concurrency/framework/jobsched"
+)
+
+func TestOptions_NumWorkerPolicy(t *testing.T) {
+	numCPU := min(runtime.NumCPU(), runtime.GOMAXPROCS(0))
+	testCases := []struct {
+		name      string
+		numWorker int
+		policy    jobsched.NumWorkerPolicy
+		want      int
+	}{
+		{"nil", 0, nil, max(numCPU-2, 1)},
+		{"NumCPU", 0, jobsched.NumCPUPolicy(), numCPU},
+		{"NumCPU-1", 0, jobsched.NumCPUMinusPolicy(1), max(numCPU-1, 1)},
+		{"NumCPU-1000", 0, jobsched.NumCPUMinusPolicy(1000), 1},
+		{"fraction 0.5", -1, jobsched.NumCPUFractionPolicy(.5),
+			max(int(math.Round(float64(numCPU)*.5)), 1)},
+		{"fraction 2", 0, jobsched.NumCPUFractionPolicy(2), numCPU * 2},
+		{"positive NumWorker", 3, jobsched.NumCPUPolicy(), 3},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := jobsched.New(func(canceler concurrency.Canceler, rank, job int) (
+				newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback jobsched.NoFeedback) {
+				return // do nothing
+			}, nil, &jobsched.Options[int, jobsched.NoProperty, jobsched.NoFeedback]{
+				NumWorker:       tc.numWorker,
+				NumWorkerPolicy: tc.policy,
+			})
+			if n := ctrl.NumGoroutine(); n != tc.want {
+				t.Errorf("got %d; want %d", n, tc.want)
+			}
+			ctrl.SetNumWorker(7)
+			if n := ctrl.NumGoroutine(); n != 7 {
+				t.Errorf("after SetNumWorker(7), got %d; want 7", n)
+			}
+			ctrl.SetNumWorker(0)
+			want := tc.want
+			if tc.numWorker > 0 {
+				// SetNumWorker(0) uses the policy regardless of NumWorker.
+				want = max(tc.policy(numCPU), 1)
+			}
+			if n := ctrl.NumGoroutine(); n != want {
+				t.Errorf("after SetNumWorker(0), got %d; want %d", n, want)
+			}
+			ctrl.Run()
+			if prs := ctrl.PanicRecords(); len(prs) > 0 {
+				t.Errorf("panic %q", prs)
+			}
+		})
+	}
+}
+
+func TestNumCPUFractionPolicy_Panic(t *testing.T) {
+	for _, f := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		t.Run(fmt.Sprintf("f=%v", f), func(t *testing.T) {
+			defer func() {
+				if e := recover(); e == nil {
+					t.Error("want panic but not")
+				}
+			}()
+			jobsched.NumCPUFractionPolicy(f)
+		})
+	}
+}
+
+func TestOptions_LockOSThread(t *testing.T) {
+	const NumWorker = 3
+	var setupCounter, jobCounter, cleanupCounter atomic.Int32
+	ctrl := jobsched.New(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback jobsched.NoFeedback) {
+		jobCounter.Add(1)
+		if job > 0 {
+			newJobs = []*jobsched.MetaJob[int, jobsched.NoProperty]{{Job: job - 1}}
+		}
+		return
+	}, nil, &jobsched.Options[int, jobsched.NoProperty, jobsched.NoFeedback]{
+		NumWorker:    NumWorker,
+		LockOSThread: true,
+		Setup: func(ctrl jobsched.Controller[int, jobsched.NoProperty, jobsched.NoFeedback], rank int) {
+			setupCounter.Add(1)
+		},
+		Cleanup: func(ctrl jobsched.Controller[int, jobsched.NoProperty, jobsched.NoFeedback], rank int) {
+			cleanupCounter.Add(1)
+		},
+	}, &jobsched.MetaJob[int, jobsched.NoProperty]{Job: 9})
+	ctrl.SetNumWorker(NumWorker + 1)
+	ctrl.Run()
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+	if got := jobCounter.Load(); got != 10 {
+		t.Errorf("got job counter %d; want 10", got)
+	}
+	if got := setupCounter.Load(); got != NumWorker+1 {
+		t.Errorf("got setup counter %d; want %d", got, NumWorker+1)
+	}
+	if got := cleanupCounter.Load(); got != NumWorker+1 {
+		t.Errorf("got cleanup counter %d; want %d", got, NumWorker+1)
+	}
+}
@@ -160,6 +160,69 @@ func testJobQueueFunc(
 			job := jq.Dequeue() // want panic here
 			t.Errorf("dequeued more than %d items, got %d", wantN, job)
 		})
+		t.Run(tc.name+"&DequeueMeta", func(t *testing.T) {
+			jq := m.New()
+			mdq, ok := jq.(jobsched.MetaDequeuer[int, jobsched.NoProperty])
+			if !ok {
+				t.Fatal("job queue does not implement MetaDequeuer")
+			}
+			tc.enqueueFn(jq)
+			got := make([]int, 0, N)
+			for jq.Len() > 0 {
+				mj := mdq.DequeueMeta()
+				if mj != metaJobs[mj.Job] {
+					t.Errorf("got %p for job %d; want %p",
+						mj, mj.Job, metaJobs[mj.Job])
+				}
+				got = append(got, mj.Job)
+			}
+			if len(got) != wantN || gotWrong(got, want) {
+				t.Errorf("got (len=%d) %v;\nwant (len=%d) %v",
+					len(got), got, wantN, want)
+				if len(got) != wantN {
+					return
+				}
+			}
+			defer func() {
+				if e := recover(); !isDequeuePanicMessage(e) {
+					t.Error(e)
+				}
+			}()
+			mj := mdq.DequeueMeta() // want panic here
+			t.Errorf("dequeued more than %d items, got %v", wantN, mj)
+		})
+		t.Run(tc.name+"&RangeMeta", func(t *testing.T) {
+			jq := m.New()
+			mr, ok := jq.(jobsched.MetaRanger[int, jobsched.NoProperty])
+			if !ok {
+				t.Fatal("job queue does not implement MetaRanger")
+			}
+			tc.enqueueFn(jq)
+			counter := make(map[int]int, N)
+			mr.RangeMeta(func(mj *jobsched.MetaJob[int, jobsched.NoProperty]) (cont bool) {
+				if mj != metaJobs[mj.Job] {
+					t.Errorf("got %p for job %d; want %p",
+						mj, mj.Job, metaJobs[mj.Job])
+				}
+				counter[mj.Job]++
+				return true
+			})
+			var n int
+			for _, ctr := range counter {
+				n += ctr
+			}
+			if n != wantN || jq.Len() != wantN {
+				t.Errorf("got %d jobs accessed, Len %d; want %d", n, jq.Len(), wantN)
+			}
+			var calls int
+			mr.RangeMeta(func(*jobsched.MetaJob[int, jobsched.NoProperty]) (cont bool) {
+				calls++
+				return false
+			})
+			if wantN > 0 && calls != 1 {
+				t.Errorf("got %d calls after stopping; want 1", calls)
+			}
+		})
 	}
 }
 
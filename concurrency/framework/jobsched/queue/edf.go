@@ -0,0 +1,84 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package queue
+
+import (
+	"time"
+
+	"github.com/donyori/gogo/concurrency/framework/jobsched"
+	"github.com/donyori/gogo/container/heap/pqueue"
+)
+
+// edfJobQueueMaker is a maker for creating job queues with
+// EDF (earliest deadline first) scheduling algorithm.
+//
+// A job with an earlier deadline is dequeued earlier.
+// The jobs with the same deadline are scheduled according to
+// their priority, and then creation time.
+// The jobs without a deadline (i.e., with a zero-value deadline)
+// are dequeued after all the jobs with a deadline.
+type edfJobQueueMaker[Job, Properties any] struct {
+	// A function to get the deadline of a job from its meta information.
+	deadlineFn func(meta *jobsched.Meta[Properties]) time.Time
+}
+
+// NewEarliestDeadlineFirstJobQueueMaker creates a job queue maker
+// for creating job queues with EDF (earliest deadline first)
+// scheduling algorithm, so that latency-sensitive jobs
+// are scheduled before best-effort ones.
+//
+// deadlineFn gets the deadline of a job from its meta information,
+// such as a deadline stored in the custom properties.
+// If deadlineFn is nil, the field Deadline of the meta information is used.
+// The framework guarantees that arguments passed to deadlineFn are never nil.
+//
+// A job with an earlier deadline is dequeued earlier.
+// The jobs with the same deadline are scheduled according to
+// their priority (the field Priority in the meta information),
+// and then creation time (the field CreationTime in the meta information).
+// The jobs without a deadline (i.e., with a zero-value deadline)
+// are dequeued after all the jobs with a deadline.
+func NewEarliestDeadlineFirstJobQueueMaker[Job, Properties any](
+	deadlineFn func(meta *jobsched.Meta[Properties]) time.Time,
+) jobsched.JobQueueMaker[Job, Properties] {
+	return &edfJobQueueMaker[Job, Properties]{deadlineFn: deadlineFn}
+}
+
+func (m *edfJobQueueMaker[Job, Properties]) New() jobsched.JobQueue[Job, Properties] {
+	deadlineFn := m.deadlineFn
+	if deadlineFn == nil {
+		deadlineFn = func(meta *jobsched.Meta[Properties]) time.Time {
+			return meta.Deadline
+		}
+	}
+	return &priorityJobQueue[Job, Properties]{
+		pq: pqueue.New(func(a, b *jobsched.MetaJob[Job, Properties]) bool {
+			da, db := deadlineFn(&a.Meta), deadlineFn(&b.Meta)
+			switch {
+			case da.IsZero() != db.IsZero():
+				return db.IsZero()
+			case !da.Equal(db):
+				return da.Before(db)
+			case a.Meta.Priority != b.Meta.Priority:
+				return a.Meta.Priority > b.Meta.Priority
+			}
+			return a.Meta.CreationTime.Before(b.Meta.CreationTime)
+		}, nil),
+	}
+}
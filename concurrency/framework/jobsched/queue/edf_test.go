@@ -0,0 +1,92 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package queue_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/concurrency/framework/jobsched"
+	"github.com/donyori/gogo/concurrency/framework/jobsched/queue"
+)
+
+func TestEarliestDeadlineFirstJobQueue(t *testing.T) {
+	base := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	mjs := []*jobsched.MetaJob[int, jobsched.NoProperty]{
+		{Job: 0, Meta: jobsched.Meta[jobsched.NoProperty]{CreationTime: base}},
+		{Job: 1, Meta: jobsched.Meta[jobsched.NoProperty]{
+			CreationTime: base, Deadline: base.Add(time.Hour * 2)}},
+		{Job: 2, Meta: jobsched.Meta[jobsched.NoProperty]{
+			CreationTime: base, Deadline: base.Add(time.Hour)}},
+		{Job: 3, Meta: jobsched.Meta[jobsched.NoProperty]{
+			CreationTime: base, Priority: 9}},
+		{Job: 4, Meta: jobsched.Meta[jobsched.NoProperty]{
+			CreationTime: base, Deadline: base.Add(time.Hour), Priority: 1}},
+		{Job: 5, Meta: jobsched.Meta[jobsched.NoProperty]{
+			CreationTime: base.Add(-time.Second), Deadline: base.Add(time.Hour)}},
+	}
+	want := []int{4, 5, 2, 1, 3, 0}
+	jq := queue.NewEarliestDeadlineFirstJobQueueMaker[int, jobsched.NoProperty](nil).New()
+	jq.Enqueue(mjs...)
+	got := make([]int, 0, len(mjs))
+	for jq.Len() > 0 {
+		got = append(got, jq.Dequeue())
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func TestEarliestDeadlineFirstJobQueue_DeadlineFn(t *testing.T) {
+	base := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	mjs := make([]*jobsched.MetaJob[int, time.Time], 8)
+	for i := range mjs {
+		mjs[i] = &jobsched.MetaJob[int, time.Time]{
+			Meta: jobsched.Meta[time.Time]{
+				CreationTime: base,
+				// Store the deadline in the custom properties,
+				// in reverse order of jobs.
+				Custom: base.Add(time.Minute * time.Duration(len(mjs)-i)),
+			},
+			Job: i,
+		}
+	}
+	jq := queue.NewEarliestDeadlineFirstJobQueueMaker[int](
+		func(meta *jobsched.Meta[time.Time]) time.Time {
+			return meta.Custom
+		},
+	).New()
+	jq.Enqueue(mjs...)
+	mdq, ok := jq.(jobsched.MetaDequeuer[int, time.Time])
+	if !ok {
+		t.Fatal("job queue does not implement MetaDequeuer")
+	}
+	for want := len(mjs) - 1; want >= 0; want-- {
+		if jq.Len() == 0 {
+			t.Fatalf("job queue is empty; want job %d", want)
+		}
+		if mj := mdq.DequeueMeta(); mj != mjs[want] {
+			t.Errorf("got job %d; want %d", mj.Job, want)
+		}
+	}
+	if n := jq.Len(); n != 0 {
+		t.Errorf("got Len %d after dequeuing all jobs; want 0", n)
+	}
+}
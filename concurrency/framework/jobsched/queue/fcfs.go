@@ -58,7 +58,7 @@ func (m fcfsJobQueueMaker[Job, Properties]) New() jobsched.JobQueue[Job, Propert
 }
 
 // fcfsJobQueue is an FCFS (first come, first served) job queue.
-type fcfsJobQueue[Job, Properties any] []Job
+type fcfsJobQueue[Job, Properties any] []*jobsched.MetaJob[Job, Properties]
 
 func (jq *fcfsJobQueue[Job, Properties]) Len() int {
 	return len(*jq)
@@ -69,18 +69,30 @@ func (jq *fcfsJobQueue[Job, Properties]) Enqueue(
 	if len(metaJob) == 0 {
 		return
 	}
-	i := len(*jq)
-	*jq = append(*jq, make([]Job, len(metaJob))...)
-	for _, mj := range metaJob {
-		(*jq)[i], i = mj.Job, i+1
-	}
+	*jq = append(*jq, metaJob...)
 }
 
 func (jq *fcfsJobQueue[Job, Properties]) Dequeue() Job {
 	if len(*jq) == 0 {
 		panic(errors.AutoMsg(emptyQueuePanicMessage))
 	}
-	var job Job
-	*jq, (*jq)[0], job = (*jq)[1:], job, (*jq)[0] // where (*jq)[0] = job is to avoid memory leak
-	return job
+	return jq.DequeueMeta().Job
+}
+
+func (jq *fcfsJobQueue[Job, Properties]) DequeueMeta() *jobsched.MetaJob[Job, Properties] {
+	if len(*jq) == 0 {
+		panic(errors.AutoMsg(emptyQueuePanicMessage))
+	}
+	var mj *jobsched.MetaJob[Job, Properties]
+	*jq, (*jq)[0], mj = (*jq)[1:], nil, (*jq)[0] // where (*jq)[0] = nil is to avoid memory leak
+	return mj
+}
+
+func (jq *fcfsJobQueue[Job, Properties]) RangeMeta(
+	handler func(metaJob *jobsched.MetaJob[Job, Properties]) (cont bool)) {
+	for _, mj := range *jq {
+		if !handler(mj) {
+			return
+		}
+	}
 }
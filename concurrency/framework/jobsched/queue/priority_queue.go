@@ -180,3 +180,15 @@ func (jq *priorityJobQueue[Job, Properties]) Dequeue() Job {
 	}
 	return jq.pq.Dequeue().Job
 }
+
+func (jq *priorityJobQueue[Job, Properties]) DequeueMeta() *jobsched.MetaJob[Job, Properties] {
+	if jq.pq.Len() == 0 {
+		panic(errors.AutoMsg(emptyQueuePanicMessage))
+	}
+	return jq.pq.Dequeue()
+}
+
+func (jq *priorityJobQueue[Job, Properties]) RangeMeta(
+	handler func(metaJob *jobsched.MetaJob[Job, Properties]) (cont bool)) {
+	jq.pq.Range(handler)
+}
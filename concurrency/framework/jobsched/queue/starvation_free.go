@@ -80,7 +80,11 @@ func (m *exponentialJobQueueMaker[Job, Properties]) New() jobsched.JobQueue[Job,
 //
 // The priority of jobs increases exponentially.
 type exponentialJobQueue[Job, Properties any] struct {
-	pq pqueue.PriorityQueue[*jobsched.MetaJob[Job, float64]]
+	// The priority queue holding the jobs.
+	//
+	// The field Job of its items is the original item passed to Enqueue,
+	// and the field Meta.Custom is the value of t at enqueue time.
+	pq pqueue.PriorityQueue[*jobsched.MetaJob[*jobsched.MetaJob[Job, Properties], float64]]
 
 	// The base of the exponent.
 	b float64
@@ -103,32 +107,46 @@ func (jq *exponentialJobQueue[Job, Properties]) Enqueue(
 	if len(metaJob) == 0 {
 		return
 	}
-	a := make([]*jobsched.MetaJob[Job, float64], len(metaJob))
+	a := make([]*jobsched.MetaJob[*jobsched.MetaJob[Job, Properties], float64], len(metaJob))
 	for i, mj := range metaJob {
-		a[i] = &jobsched.MetaJob[Job, float64]{
+		a[i] = &jobsched.MetaJob[*jobsched.MetaJob[Job, Properties], float64]{
 			Meta: jobsched.Meta[float64]{
 				Priority:     mj.Meta.Priority,
 				CreationTime: mj.Meta.CreationTime,
 				Custom:       jq.t,
 			},
-			Job: mj.Job,
+			Job: mj,
 		}
 	}
 	jq.pq.Enqueue(a...)
 }
 
 func (jq *exponentialJobQueue[Job, Properties]) Dequeue() Job {
+	if jq.pq.Len() == 0 {
+		panic(errors.AutoMsg(emptyQueuePanicMessage))
+	}
+	return jq.DequeueMeta().Job
+}
+
+func (jq *exponentialJobQueue[Job, Properties]) DequeueMeta() *jobsched.MetaJob[Job, Properties] {
 	n := jq.pq.Len()
 	if n == 0 {
 		panic(errors.AutoMsg(emptyQueuePanicMessage))
 	}
-	job := jq.pq.Dequeue().Job
+	mj := jq.pq.Dequeue().Job
 	if n > 1 {
 		jq.t += jq.rn
 	} else {
 		jq.t = 0. // the job queue is empty now; reset t to 0
 	}
-	return job
+	return mj
+}
+
+func (jq *exponentialJobQueue[Job, Properties]) RangeMeta(
+	handler func(metaJob *jobsched.MetaJob[Job, Properties]) (cont bool)) {
+	jq.pq.Range(func(x *jobsched.MetaJob[*jobsched.MetaJob[Job, Properties], float64]) (cont bool) {
+		return handler(x.Job)
+	})
 }
 
 // jobLess is a github.com/donyori/gogo/function/compare.LessFunc
@@ -138,7 +156,7 @@ func (jq *exponentialJobQueue[Job, Properties]) Dequeue() Job {
 // If two jobs have nearly the same priority (difference less than 0.001),
 // the earlier its creation time, the "less" the job.
 func (jq *exponentialJobQueue[Job, Properties]) jobLess(
-	a, b *jobsched.MetaJob[Job, float64]) bool {
+	a, b *jobsched.MetaJob[*jobsched.MetaJob[Job, Properties], float64]) bool {
 	pa, pb := jq.calculatePriority(a), jq.calculatePriority(b)
 	if math.Abs(pa-pb) < 1e-3 {
 		return a.Meta.CreationTime.Before(b.Meta.CreationTime)
@@ -161,6 +179,6 @@ func (jq *exponentialJobQueue[Job, Properties]) jobLess(
 // calls to the method Dequeue since the job was added to this queue,
 // and n is the number of goroutines to process jobs.
 func (jq *exponentialJobQueue[Job, Properties]) calculatePriority(
-	mj *jobsched.MetaJob[Job, float64]) float64 {
+	mj *jobsched.MetaJob[*jobsched.MetaJob[Job, Properties], float64]) float64 {
 	return (float64(mj.Meta.Priority) + 1) * math.Pow(jq.b, jq.t-mj.Meta.Custom)
 }
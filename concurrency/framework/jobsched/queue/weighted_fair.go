@@ -0,0 +1,162 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package queue
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/donyori/gogo/concurrency/framework/jobsched"
+	"github.com/donyori/gogo/errors"
+)
+
+// wfqJobQueueMaker is a maker for creating job queues with
+// weighted fair scheduling algorithm across job classes.
+type wfqJobQueueMaker[Job, Properties any, Class comparable] struct {
+	// A function to get the class of a job from its meta information.
+	classFn func(meta *jobsched.Meta[Properties]) Class
+
+	// Weights of the classes.
+	weights map[Class]int
+}
+
+// NewWeightedFairJobQueueMaker creates a job queue maker
+// for creating job queues with weighted fair scheduling algorithm
+// across job classes, so that a flood of jobs of one class
+// cannot starve the jobs of other classes.
+//
+// The third type parameter Class is the type of job classes.
+//
+// classFn gets the class of a job from its meta information,
+// such as a tenant or a job type stored in the custom properties.
+// The framework guarantees that arguments passed to classFn are never nil.
+// NewWeightedFairJobQueueMaker panics if classFn is nil.
+//
+// weights are the weights of the classes.
+// The classes not in weights, or with a nonpositive weight,
+// have a weight of 1.
+// weights are copied, so modifying weights after calling this function
+// does not affect the job queue maker.
+//
+// The job queue serves the classes with queued jobs in
+// weighted round-robin order:
+// it dequeues up to w consecutive jobs from a class of weight w,
+// and then moves to the next class.
+// The classes are served in the order in which they become nonempty.
+// The jobs in the same class are dequeued in the order that they arrive.
+func NewWeightedFairJobQueueMaker[Job, Properties any, Class comparable](
+	classFn func(meta *jobsched.Meta[Properties]) Class,
+	weights map[Class]int,
+) jobsched.JobQueueMaker[Job, Properties] {
+	if classFn == nil {
+		panic(errors.AutoMsg("classFn is nil"))
+	}
+	return &wfqJobQueueMaker[Job, Properties, Class]{
+		classFn: classFn,
+		weights: maps.Clone(weights),
+	}
+}
+
+func (m *wfqJobQueueMaker[Job, Properties, Class]) New() jobsched.JobQueue[Job, Properties] {
+	return &wfqJobQueue[Job, Properties, Class]{
+		m:       m,
+		classes: make(map[Class]*wfqClass[Job, Properties]),
+	}
+}
+
+// wfqClass is a job class in wfqJobQueue.
+type wfqClass[Job, Properties any] struct {
+	weight int                                  // Weight of the class.
+	jobs   []*jobsched.MetaJob[Job, Properties] // Queued jobs of the class, in FCFS order.
+}
+
+// wfqJobQueue is a job queue with weighted fair scheduling algorithm
+// across job classes.
+type wfqJobQueue[Job, Properties any, Class comparable] struct {
+	m       *wfqJobQueueMaker[Job, Properties, Class]
+	classes map[Class]*wfqClass[Job, Properties] // Nonempty classes.
+	ring    []Class                              // Nonempty classes in round-robin order.
+	cur     int                                  // Index of the class being served in ring.
+	credit  int                                  // Remaining number of jobs to be dequeued from the class being served.
+	n       int                                  // The number of queued jobs.
+}
+
+func (jq *wfqJobQueue[Job, Properties, Class]) Len() int {
+	return jq.n
+}
+
+func (jq *wfqJobQueue[Job, Properties, Class]) Enqueue(
+	metaJob ...*jobsched.MetaJob[Job, Properties]) {
+	for _, mj := range metaJob {
+		class := jq.m.classFn(&mj.Meta)
+		c := jq.classes[class]
+		if c == nil {
+			c = &wfqClass[Job, Properties]{weight: max(jq.m.weights[class], 1)}
+			jq.classes[class] = c
+			jq.ring = append(jq.ring, class)
+		}
+		c.jobs = append(c.jobs, mj)
+	}
+	jq.n += len(metaJob)
+}
+
+func (jq *wfqJobQueue[Job, Properties, Class]) Dequeue() Job {
+	if jq.n == 0 {
+		panic(errors.AutoMsg(emptyQueuePanicMessage))
+	}
+	return jq.DequeueMeta().Job
+}
+
+func (jq *wfqJobQueue[Job, Properties, Class]) DequeueMeta() *jobsched.MetaJob[Job, Properties] {
+	if jq.n == 0 {
+		panic(errors.AutoMsg(emptyQueuePanicMessage))
+	}
+	class := jq.ring[jq.cur]
+	c := jq.classes[class]
+	if jq.credit <= 0 {
+		jq.credit = c.weight
+	}
+	mj := c.jobs[0]
+	c.jobs[0] = nil // avoid memory leak
+	c.jobs = c.jobs[1:]
+	jq.credit--
+	jq.n--
+	if len(c.jobs) == 0 {
+		delete(jq.classes, class)
+		jq.ring = slices.Delete(jq.ring, jq.cur, jq.cur+1)
+		jq.credit = 0
+		if jq.cur >= len(jq.ring) {
+			jq.cur = 0
+		}
+	} else if jq.credit == 0 {
+		jq.cur = (jq.cur + 1) % len(jq.ring)
+	}
+	return mj
+}
+
+func (jq *wfqJobQueue[Job, Properties, Class]) RangeMeta(
+	handler func(metaJob *jobsched.MetaJob[Job, Properties]) (cont bool)) {
+	for _, class := range jq.ring {
+		for _, mj := range jq.classes[class].jobs {
+			if !handler(mj) {
+				return
+			}
+		}
+	}
+}
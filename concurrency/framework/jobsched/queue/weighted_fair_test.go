@@ -0,0 +1,99 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package queue_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/concurrency/framework/jobsched"
+	"github.com/donyori/gogo/concurrency/framework/jobsched/queue"
+)
+
+func TestWeightedFairJobQueue_OneClass(t *testing.T) {
+	want := make([][]int, N)
+	for i := range want {
+		want[i] = []int{metaJobs[i].Job}
+	}
+	testJobQueueFunc(t, queue.NewWeightedFairJobQueueMaker[int](
+		func(meta *jobsched.Meta[jobsched.NoProperty]) int {
+			return 0
+		},
+		nil,
+	), want)
+}
+
+func TestWeightedFairJobQueue(t *testing.T) {
+	base := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	newMetaJob := func(job int, class string) *jobsched.MetaJob[int, string] {
+		return &jobsched.MetaJob[int, string]{
+			Meta: jobsched.Meta[string]{CreationTime: base, Custom: class},
+			Job:  job,
+		}
+	}
+	weights := map[string]int{"a": 3, "b": 1, "c": 0}
+	m := queue.NewWeightedFairJobQueueMaker[int](
+		func(meta *jobsched.Meta[string]) string {
+			return meta.Custom
+		},
+		weights,
+	)
+	weights["b"] = 100 // must not affect m
+	jq := m.New()
+	// A flood of class "a", followed by a few jobs of classes "b" and "c".
+	for i := range 10 {
+		jq.Enqueue(newMetaJob(i, "a"))
+	}
+	jq.Enqueue(newMetaJob(10, "b"), newMetaJob(11, "b"), newMetaJob(12, "c"))
+	want := []int{0, 1, 2, 10, 12, 3, 4, 5, 11, 6, 7, 8, 9}
+	if n := jq.Len(); n != len(want) {
+		t.Errorf("got Len %d; want %d", n, len(want))
+	}
+	var ranged []int
+	mr, ok := jq.(jobsched.MetaRanger[int, string])
+	if !ok {
+		t.Fatal("job queue does not implement MetaRanger")
+	}
+	mr.RangeMeta(func(metaJob *jobsched.MetaJob[int, string]) (cont bool) {
+		ranged = append(ranged, metaJob.Job)
+		return true
+	})
+	if len(ranged) != len(want) {
+		t.Errorf("got %d jobs by RangeMeta; want %d", len(ranged), len(want))
+	}
+	got := make([]int, 0, len(want))
+	for jq.Len() > 0 {
+		got = append(got, jq.Dequeue())
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+	// A class becoming nonempty again joins the end of the round.
+	jq.Enqueue(newMetaJob(0, "a"), newMetaJob(1, "a"), newMetaJob(2, "a"),
+		newMetaJob(3, "a"), newMetaJob(4, "b"))
+	want = []int{0, 1, 2, 4, 3}
+	got = got[:0]
+	for jq.Len() > 0 {
+		got = append(got, jq.Dequeue())
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
@@ -0,0 +1,83 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobsched
+
+import "time"
+
+// RateLimit is a limit on the rate of dispatching jobs to worker goroutines.
+//
+// It is enforced by the controller with a token bucket:
+// the bucket holds at most Burst tokens and is refilled
+// at PerSecond tokens per second.
+// The controller takes a token before dispatching each job,
+// and waits for a token if the bucket is empty.
+// The bucket is full when the controller is created.
+type RateLimit struct {
+	// The maximum average number of jobs dispatched per second.
+	//
+	// Nonpositive values for no limit.
+	PerSecond float64
+
+	// The maximum number of jobs that can be dispatched at once
+	// (i.e., the capacity of the token bucket).
+	//
+	// Values less than 1 for 1.
+	Burst int
+}
+
+// tokenBucket is a token bucket to enforce RateLimit.
+//
+// It is not safe for concurrent use.
+type tokenBucket struct {
+	rate   float64   // The number of tokens added per second.
+	burst  float64   // The capacity of the bucket.
+	tokens float64   // The number of tokens in the bucket at time last.
+	last   time.Time // The last time the bucket was updated.
+}
+
+// newTokenBucket creates a full token bucket for rl at time now.
+//
+// The caller must guarantee that rl.PerSecond is positive.
+func newTokenBucket(rl RateLimit, now time.Time) *tokenBucket {
+	burst := float64(max(rl.Burst, 1))
+	return &tokenBucket{
+		rate:   rl.PerSecond,
+		burst:  burst,
+		tokens: burst,
+		last:   now,
+	}
+}
+
+// take takes a token from the bucket at time now.
+//
+// If a token is available, it takes the token and returns 0.
+// Otherwise, it takes nothing and returns
+// the time to wait for the next token.
+func (tb *tokenBucket) take(now time.Time) time.Duration {
+	if elapsed := now.Sub(tb.last); elapsed > 0 {
+		tb.tokens = min(tb.tokens+elapsed.Seconds()*tb.rate, tb.burst)
+		tb.last = now
+	}
+	if tb.tokens >= 1 {
+		tb.tokens--
+		return 0
+	}
+	d := time.Duration((1 - tb.tokens) / tb.rate * float64(time.Second))
+	return max(d, time.Nanosecond)
+}
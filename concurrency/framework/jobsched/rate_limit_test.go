@@ -0,0 +1,80 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobsched_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/concurrency"
+	"github.com/donyori/gogo/concurrency/framework/jobsched"
+)
+
+func TestController_RateLimit(t *testing.T) {
+	const PerSecond = 50
+	const NumJob = 11
+	var x atomic.Int32
+	start := time.Now()
+	prs := jobsched.RunWithoutFeedback(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback jobsched.NoFeedback) {
+		x.Add(1)
+		return
+	}, &jobsched.Options[int, jobsched.NoProperty, jobsched.NoFeedback]{
+		NumWorker: 4,
+		RateLimit: &jobsched.RateLimit{PerSecond: PerSecond},
+	}, make([]*jobsched.MetaJob[int, jobsched.NoProperty], NumJob)...)
+	elapsed := time.Since(start)
+	if len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+	if gotX := x.Load(); gotX != NumJob {
+		t.Errorf("got x %d; want %d", gotX, NumJob)
+	}
+	// The first job is dispatched immediately,
+	// and each of the others waits for 1/PerSecond seconds.
+	if want := time.Second * (NumJob - 1) / PerSecond; elapsed < want {
+		t.Errorf("got elapsed %v; want >= %v", elapsed, want)
+	}
+}
+
+func TestController_RateLimit_Burst(t *testing.T) {
+	const Burst = 8
+	var x atomic.Int32
+	start := time.Now()
+	prs := jobsched.RunWithoutFeedback(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback jobsched.NoFeedback) {
+		x.Add(1)
+		return
+	}, &jobsched.Options[int, jobsched.NoProperty, jobsched.NoFeedback]{
+		NumWorker: 4,
+		RateLimit: &jobsched.RateLimit{PerSecond: 0.1, Burst: Burst},
+	}, make([]*jobsched.MetaJob[int, jobsched.NoProperty], Burst)...)
+	elapsed := time.Since(start)
+	if len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+	if gotX := x.Load(); gotX != Burst {
+		t.Errorf("got x %d; want %d", gotX, Burst)
+	}
+	// All the jobs can be dispatched at once.
+	if elapsed >= time.Second*5 {
+		t.Errorf("got elapsed %v; want < 5s", elapsed)
+	}
+}
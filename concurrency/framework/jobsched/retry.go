@@ -0,0 +1,132 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobsched
+
+import (
+	"github.com/donyori/gogo/container/heap/pqueue"
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/retry"
+)
+
+// ErrRetry is a sentinel error that the job handler can return
+// as its feedback to request a retry of the current job,
+// when the type of feedback can hold an error (e.g., error or any).
+//
+// It takes effect only if the option RetryPolicy is not nil
+// and its field ShouldRetry is nil.
+//
+// The client should use errors.Is to test whether an error is ErrRetry.
+var ErrRetry = errors.AutoNewCustom(
+	"job handler requests a retry",
+	errors.PrependFullPkgName,
+	0,
+)
+
+// RetryPolicy is a policy for retrying failed jobs.
+//
+// The first type parameter Job is the type of jobs.
+// The second type parameter Feedback is the type of feedback on the jobs.
+//
+// A job to be retried is put back into the job queue
+// (after the delay specified by Backoff),
+// with the same meta information.
+// Its feedback and new jobs returned by the job handler are discarded.
+//
+// The retry policy takes effect only if the job queue
+// implements MetaDequeuer.
+type RetryPolicy[Job, Feedback any] struct {
+	// The maximum number of attempts to process a job,
+	// including the first attempt.
+	//
+	// Values less than 2 for no retry.
+	MaxAttempts int
+
+	// A function that returns the delay before retrying a job.
+	//
+	// Its parameter attempt is the number of attempts made on the job,
+	// starting from 1.
+	//
+	// The backoff schedules provided by package
+	// github.com/donyori/gogo/retry (such as retry.ExponentialBackoff)
+	// can be used here.
+	//
+	// If Backoff is nil or returns a nonpositive value,
+	// the job is put back into the job queue immediately.
+	//
+	// The client is responsible for guaranteeing that
+	// this function is safe for concurrency.
+	Backoff retry.Backoff
+
+	// A function that reports whether to retry a job
+	// after an attempt to process it.
+	//
+	// Its parameter job is the job.
+	// Its parameter attempt is the number of attempts made on the job,
+	// starting from 1.
+	// Its parameter feedback is the feedback returned by the job handler,
+	// or the zero value of Feedback if the job handler panicked.
+	// Its parameter panicValue is the value passed to panic
+	// if the job handler panicked, or nil otherwise.
+	//
+	// It is not called if the number of attempts reaches MaxAttempts.
+	//
+	// If ShouldRetry is nil, a job is retried if the job handler panics,
+	// or if its feedback is an error that matches ErrRetry.
+	//
+	// If the job handler panics and the job is not retried,
+	// the panic is handled as usual (i.e., the framework records
+	// the panic and cancels the job).
+	//
+	// The client is responsible for guaranteeing that
+	// this function is safe for concurrency.
+	ShouldRetry func(
+		job Job,
+		attempt int,
+		feedback Feedback,
+		panicValue any,
+	) bool
+}
+
+// shouldRetry reports whether to retry the job mj
+// after the attempt-th attempt according to rp.
+func shouldRetry[Job, Properties, Feedback any](
+	rp *RetryPolicy[Job, Feedback],
+	mj *MetaJob[Job, Properties],
+	attempt int,
+	feedback Feedback,
+	panicValue any,
+) bool {
+	if attempt >= rp.MaxAttempts {
+		return false
+	} else if rp.ShouldRetry != nil {
+		return rp.ShouldRetry(mj.Job, attempt, feedback, panicValue)
+	} else if panicValue != nil {
+		return true
+	}
+	err, ok := any(feedback).(error)
+	return ok && errors.Is(err, ErrRetry)
+}
+
+// newDelayedJobQueue creates a priority queue for the jobs
+// waiting to be retried, ordered by the time they can be retried.
+func newDelayedJobQueue[Job, Properties any]() pqueue.PriorityQueue[*MetaJob[Job, Properties]] {
+	return pqueue.New(func(a, b *MetaJob[Job, Properties]) bool {
+		return a.Meta.notBefore.Before(b.Meta.notBefore)
+	}, nil)
+}
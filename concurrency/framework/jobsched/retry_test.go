@@ -0,0 +1,226 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobsched_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/concurrency"
+	"github.com/donyori/gogo/concurrency/framework/jobsched"
+	"github.com/donyori/gogo/retry"
+)
+
+func TestController_RetryPolicy_Panic(t *testing.T) {
+	const PanicMsg = "test panic"
+	const NumFailure = 2
+	var m sync.Mutex
+	attempts := make(map[int]int)
+	var backoffCalls atomic.Int32
+	prs := jobsched.Run(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback int) {
+		m.Lock()
+		attempts[job]++
+		n := attempts[job]
+		m.Unlock()
+		if n <= NumFailure {
+			panic(PanicMsg)
+		}
+		return
+	}, nil, &jobsched.Options[int, jobsched.NoProperty, int]{
+		NumWorker: 2,
+		RetryPolicy: &jobsched.RetryPolicy[int, int]{
+			MaxAttempts: NumFailure + 1,
+			Backoff: func(attempt int) time.Duration {
+				backoffCalls.Add(1)
+				return time.Millisecond * time.Duration(attempt)
+			},
+		},
+	},
+		&jobsched.MetaJob[int, jobsched.NoProperty]{Job: 1},
+		&jobsched.MetaJob[int, jobsched.NoProperty]{Job: 2},
+	)
+	if len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+	for job := 1; job <= 2; job++ {
+		if attempts[job] != NumFailure+1 {
+			t.Errorf("got %d attempts on job %d; want %d",
+				attempts[job], job, NumFailure+1)
+		}
+	}
+	if n := backoffCalls.Load(); n != NumFailure*2 {
+		t.Errorf("got %d calls to Backoff; want %d", n, NumFailure*2)
+	}
+}
+
+func TestController_RetryPolicy_RetryBackoff(t *testing.T) {
+	const NumFailure = 3
+	const Initial = 10 * time.Millisecond
+	var attempts atomic.Int32
+	start := time.Now()
+	prs := jobsched.Run(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback int) {
+		if attempts.Add(1) <= NumFailure {
+			panic("test panic")
+		}
+		return
+	}, nil, &jobsched.Options[int, jobsched.NoProperty, int]{
+		NumWorker: 1,
+		RetryPolicy: &jobsched.RetryPolicy[int, int]{
+			MaxAttempts: NumFailure + 1,
+			Backoff:     retry.ExponentialBackoff(Initial, 2, Initial*3),
+		},
+	}, &jobsched.MetaJob[int, jobsched.NoProperty]{Job: 1})
+	elapsed := time.Since(start)
+	if len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+	if n := attempts.Load(); n != NumFailure+1 {
+		t.Errorf("got %d attempts; want %d", n, NumFailure+1)
+	}
+	// The delays are Initial, Initial*2, and Initial*3 (capped).
+	if want := Initial * 6; elapsed < want {
+		t.Errorf("got elapsed time %v; want at least %v", elapsed, want)
+	}
+}
+
+func TestController_RetryPolicy_MaxAttempts(t *testing.T) {
+	const PanicMsg = "test panic"
+	const MaxAttempts = 3
+	var x atomic.Int32
+	prs := jobsched.Run(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback int) {
+		x.Add(1)
+		panic(PanicMsg)
+	}, nil, &jobsched.Options[int, jobsched.NoProperty, int]{
+		NumWorker: 1,
+		RetryPolicy: &jobsched.RetryPolicy[int, int]{
+			MaxAttempts: MaxAttempts,
+		},
+	}, &jobsched.MetaJob[int, jobsched.NoProperty]{Job: 1})
+	if gotX := x.Load(); gotX != MaxAttempts {
+		t.Errorf("got %d attempts; want %d", gotX, MaxAttempts)
+	}
+	switch {
+	case len(prs) != 1:
+		t.Errorf("got len(prs) %d; want 1", len(prs))
+	case prs[0].Name != "worker 0":
+		t.Error(prs[0])
+	default:
+		msg, ok := prs[0].Content.(string)
+		if !ok || msg != PanicMsg {
+			t.Error(prs[0])
+		}
+	}
+}
+
+func TestController_RetryPolicy_ErrRetry(t *testing.T) {
+	errFatal := errors.New("fatal error")
+	var m sync.Mutex
+	attempts := make(map[int]int)
+	var feedbacks []error
+	prs := jobsched.Run(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback error) {
+		m.Lock()
+		attempts[job]++
+		n := attempts[job]
+		m.Unlock()
+		switch {
+		case job == 1 && n < 3:
+			return []*jobsched.MetaJob[int, jobsched.NoProperty]{{Job: 100}},
+				fmt.Errorf("attempt %d: %w", n, jobsched.ErrRetry)
+		case job == 2:
+			return nil, errFatal
+		}
+		return
+	}, func(canceler concurrency.Canceler, feedback error) {
+		feedbacks = append(feedbacks, feedback)
+	}, &jobsched.Options[int, jobsched.NoProperty, error]{
+		NumWorker: 2,
+		RetryPolicy: &jobsched.RetryPolicy[int, error]{
+			MaxAttempts: 5,
+		},
+	},
+		&jobsched.MetaJob[int, jobsched.NoProperty]{Job: 1},
+		&jobsched.MetaJob[int, jobsched.NoProperty]{Job: 2},
+	)
+	if len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+	if attempts[1] != 3 {
+		t.Errorf("got %d attempts on job 1; want 3", attempts[1])
+	}
+	if attempts[2] != 1 {
+		t.Errorf("got %d attempts on job 2; want 1", attempts[2])
+	}
+	if attempts[100] != 0 {
+		t.Errorf("new jobs of retried attempts were processed %d times",
+			attempts[100])
+	}
+	var numNil, numFatal int
+	for _, fb := range feedbacks {
+		switch {
+		case fb == nil:
+			numNil++
+		case errors.Is(fb, errFatal):
+			numFatal++
+		default:
+			t.Errorf("unexpected feedback %v", fb)
+		}
+	}
+	if numNil != 1 || numFatal != 1 {
+		t.Errorf("got %d nil feedback and %d fatal feedback; want 1 and 1",
+			numNil, numFatal)
+	}
+}
+
+func TestController_RetryPolicy_ShouldRetry(t *testing.T) {
+	var x atomic.Int32
+	var calls []int
+	prs := jobsched.Run(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback int) {
+		return nil, int(x.Add(1))
+	}, nil, &jobsched.Options[int, jobsched.NoProperty, int]{
+		NumWorker: 1,
+		RetryPolicy: &jobsched.RetryPolicy[int, int]{
+			MaxAttempts: 10,
+			ShouldRetry: func(job, attempt, feedback int, panicValue any) bool {
+				calls = append(calls, attempt) // only one worker; no race condition
+				if panicValue != nil {
+					t.Errorf("got panic value %v; want nil", panicValue)
+				}
+				return feedback < 4
+			},
+		},
+	}, &jobsched.MetaJob[int, jobsched.NoProperty]{Job: 1})
+	if len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+	if gotX := x.Load(); gotX != 4 {
+		t.Errorf("got %d attempts; want 4", gotX)
+	}
+	if fmt.Sprint(calls) != "[1 2 3 4]" {
+		t.Errorf("got attempts passed to ShouldRetry %v; want [1 2 3 4]", calls)
+	}
+}
@@ -0,0 +1,82 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobsched
+
+import "github.com/donyori/gogo/container/heap/pqueue"
+
+// snapshotRequest is a request sent to the job allocator
+// by the method SnapshotPending.
+type snapshotRequest[Job, Properties any] struct {
+	maxJobs int                                // The maximum number of jobs to collect. Nonpositive for all.
+	reply   chan<- []*MetaJob[Job, Properties] // The channel to send the snapshot, with a buffer of size 1.
+}
+
+// pendingSnapshot collects copies of jobs for the method SnapshotPending.
+type pendingSnapshot[Job, Properties any] struct {
+	maxJobs int                         // The maximum number of jobs to collect. Nonpositive for all.
+	mjs     []*MetaJob[Job, Properties] // The copies collected.
+}
+
+// add appends a copy of mj to ps.mjs and
+// reports whether ps can collect more jobs.
+//
+// It does nothing and returns false if ps.mjs is full.
+func (ps *pendingSnapshot[Job, Properties]) add(mj *MetaJob[Job, Properties]) bool {
+	if ps.full() {
+		return false
+	}
+	c := *mj
+	ps.mjs = append(ps.mjs, &c)
+	return !ps.full()
+}
+
+// addQueue calls ps.add on the jobs in jq until ps.mjs is full.
+//
+// It does nothing if jq does not implement MetaRanger.
+func (ps *pendingSnapshot[Job, Properties]) addQueue(jq JobQueue[Job, Properties]) {
+	if mr, ok := jq.(MetaRanger[Job, Properties]); ok && !ps.full() {
+		mr.RangeMeta(ps.add)
+	}
+}
+
+// full reports whether ps.mjs has ps.maxJobs jobs.
+func (ps *pendingSnapshot[Job, Properties]) full() bool {
+	return ps.maxJobs > 0 && len(ps.mjs) >= ps.maxJobs
+}
+
+// snapshotPending returns copies of up to maxJobs jobs waiting to be
+// dispatched, including mj (if not nil), the jobs in ctrl.jq,
+// and the jobs in delayed (if not nil).
+//
+// The caller must be the job allocator.
+func (ctrl *controller[Job, Properties, Feedback]) snapshotPending(
+	maxJobs int,
+	mj *MetaJob[Job, Properties],
+	delayed pqueue.PriorityQueue[*MetaJob[Job, Properties]],
+) []*MetaJob[Job, Properties] {
+	ps := &pendingSnapshot[Job, Properties]{maxJobs: maxJobs}
+	if mj != nil {
+		ps.add(mj)
+	}
+	ps.addQueue(ctrl.jq)
+	if delayed != nil && !ps.full() {
+		delayed.Range(ps.add)
+	}
+	return ps.mjs
+}
@@ -0,0 +1,130 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobsched_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/concurrency"
+	"github.com/donyori/gogo/concurrency/framework/jobsched"
+)
+
+func TestController_SnapshotPending_BeforeLaunch(t *testing.T) {
+	const NumJob = 5
+	ctrl := jobsched.NewWithoutFeedback(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback jobsched.NoFeedback) {
+		return
+	}, nil)
+	metaJobs := make([]*jobsched.MetaJob[int, jobsched.NoProperty], NumJob)
+	for i := range metaJobs {
+		metaJobs[i] = &jobsched.MetaJob[int, jobsched.NoProperty]{Job: i}
+	}
+	ctrl.Input(metaJobs...)
+	if n := ctrl.QueueLen(); n != NumJob {
+		t.Errorf("got QueueLen %d; want %d", n, NumJob)
+	}
+	snapshot := ctrl.SnapshotPending(0)
+	if len(snapshot) != NumJob {
+		t.Fatalf("got len(snapshot) %d; want %d", len(snapshot), NumJob)
+	}
+	for i, mj := range snapshot {
+		if mj.Job != i {
+			t.Errorf("got snapshot[%d].Job %d; want %d", i, mj.Job, i)
+		}
+		mj.Job = -1 // must not affect the controller
+	}
+	if snapshot = ctrl.SnapshotPending(2); len(snapshot) != 2 {
+		t.Errorf("got len(snapshot) %d; want 2", len(snapshot))
+	}
+	ctrl.Run()
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+	if st := ctrl.Stats(); st.Completed != NumJob {
+		t.Errorf("got Stats().Completed %d; want %d", st.Completed, NumJob)
+	}
+}
+
+func TestController_SnapshotPending_Running(t *testing.T) {
+	const NumJob = 10
+	for _, ws := range []bool{false, true} {
+		var processed [NumJob]bool
+		started := make(chan struct{})
+		release := make(chan struct{})
+		ctrl := jobsched.NewWithoutFeedback(func(canceler concurrency.Canceler, rank, job int) (
+			newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback jobsched.NoFeedback) {
+			if job == 0 {
+				close(started)
+				<-release
+			}
+			processed[job] = true // only one worker
+			return
+		}, &jobsched.Options[int, jobsched.NoProperty, jobsched.NoFeedback]{
+			NumWorker:    1,
+			WorkStealing: ws,
+		}, &jobsched.MetaJob[int, jobsched.NoProperty]{Job: 0})
+		ctrl.Launch()
+		<-started
+		for i := 1; i < NumJob; i++ {
+			ctrl.Input(&jobsched.MetaJob[int, jobsched.NoProperty]{Job: i})
+		}
+		// QueueLen may lag behind Input slightly.
+		timeout := time.After(time.Minute)
+		for n := ctrl.QueueLen(); n != NumJob-1; n = ctrl.QueueLen() {
+			select {
+			case <-timeout:
+				t.Fatalf("ws=%t, timeout - got QueueLen %d; want %d", ws, n, NumJob-1)
+			case <-time.After(time.Millisecond):
+			}
+		}
+		snapshot := ctrl.SnapshotPending(0)
+		// The job being dispatched to the worker may be missing.
+		if len(snapshot) < NumJob-2 || len(snapshot) > NumJob-1 {
+			t.Errorf("ws=%t, got len(snapshot) %d; want %d or %d",
+				ws, len(snapshot), NumJob-2, NumJob-1)
+		}
+		seen := make(map[int]bool, len(snapshot))
+		for _, mj := range snapshot {
+			if mj.Job <= 0 || mj.Job >= NumJob || seen[mj.Job] {
+				t.Errorf("ws=%t, got unexpected job %d in snapshot", ws, mj.Job)
+			}
+			seen[mj.Job] = true
+		}
+		if snapshot = ctrl.SnapshotPending(3); len(snapshot) != 3 {
+			t.Errorf("ws=%t, got len(snapshot) %d; want 3", ws, len(snapshot))
+		}
+		close(release)
+		ctrl.Wait()
+		if prs := ctrl.PanicRecords(); len(prs) > 0 {
+			t.Errorf("ws=%t, panic %q", ws, prs)
+		}
+		for job, ok := range processed {
+			if !ok {
+				t.Errorf("ws=%t, job %d not processed", ws, job)
+			}
+		}
+		if snapshot = ctrl.SnapshotPending(0); snapshot != nil {
+			t.Errorf("ws=%t, got snapshot %v after Wait; want nil", ws, snapshot)
+		}
+		if n := ctrl.QueueLen(); n != 0 {
+			t.Errorf("ws=%t, got QueueLen %d after Wait; want 0", ws, n)
+		}
+	}
+}
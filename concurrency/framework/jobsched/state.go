@@ -0,0 +1,156 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobsched
+
+import (
+	"sync"
+
+	"github.com/donyori/gogo/concurrency"
+	"github.com/donyori/gogo/errors"
+)
+
+// StatefulJobHandler is a function to process a job
+// with the state of the worker goroutine.
+//
+// The first type parameter Job is the type of jobs.
+// The second type parameter Properties is the type of custom properties
+// in the meta information of jobs.
+// The third type parameter Feedback is the type of feedback on the jobs,
+// which is collected and handled in a dedicated goroutine.
+// The fourth type parameter State is the type of the per-worker state.
+//
+// Its parameters and return values are the same as those of JobHandler,
+// except for the third parameter state,
+// which is the state created by the setup function
+// passed to NewWithState for the worker goroutine.
+type StatefulJobHandler[Job, Properties, Feedback, State any] func(
+	canceler concurrency.Canceler,
+	rank int,
+	state State,
+	job Job,
+) (newJobs []*MetaJob[Job, Properties], feedback Feedback)
+
+// NewWithState is like New but maintains a state for each worker goroutine,
+// such as a database connection or a reusable buffer.
+//
+// The fourth type parameter State is the type of the per-worker state.
+//
+// setup creates the state for a worker goroutine.
+// Each worker goroutine calls it when the goroutine starts
+// (after the option Setup, if any).
+// If setup is nil, the state is the zero value of State.
+//
+// cleanup releases the state of a worker goroutine.
+// Each worker goroutine calls it before the goroutine ends
+// (before the option Cleanup, if any),
+// even if the goroutine panics,
+// provided that setup has returned successfully.
+// If cleanup is nil, the state is discarded.
+//
+// jobHandler is called with the state of the worker goroutine
+// that processes the job.
+// NewWithState panics if jobHandler is nil.
+//
+// The client is responsible for guaranteeing that
+// setup and cleanup are safe for concurrency.
+//
+// The other parameters are the same as those of function New.
+func NewWithState[Job, Properties, Feedback, State any](
+	setup func(ctrl Controller[Job, Properties, Feedback], rank int) State,
+	cleanup func(ctrl Controller[Job, Properties, Feedback], rank int, state State),
+	jobHandler StatefulJobHandler[Job, Properties, Feedback, State],
+	feedbackHandler FeedbackHandler[Feedback],
+	opts *Options[Job, Properties, Feedback],
+	metaJob ...*MetaJob[Job, Properties],
+) Controller[Job, Properties, Feedback] {
+	if jobHandler == nil {
+		panic(errors.AutoMsg("job handler is nil"))
+	}
+	var o Options[Job, Properties, Feedback]
+	if opts != nil {
+		o = *opts
+	}
+	ws := &workerStates[State]{m: make(map[int]State)}
+	optsSetup, optsCleanup := o.Setup, o.Cleanup
+	o.Setup = func(ctrl Controller[Job, Properties, Feedback], rank int) {
+		if optsSetup != nil {
+			optsSetup(ctrl, rank)
+		}
+		var ok bool
+		if optsCleanup != nil {
+			// Call optsCleanup if setup panics,
+			// as optsSetup has returned successfully.
+			defer func() {
+				if !ok {
+					optsCleanup(ctrl, rank)
+				}
+			}()
+		}
+		var state State
+		if setup != nil {
+			state = setup(ctrl, rank)
+		}
+		ws.set(rank, state)
+		ok = true
+	}
+	o.Cleanup = func(ctrl Controller[Job, Properties, Feedback], rank int) {
+		if optsCleanup != nil {
+			defer optsCleanup(ctrl, rank)
+		}
+		state := ws.remove(rank)
+		if cleanup != nil {
+			cleanup(ctrl, rank, state)
+		}
+	}
+	return New(func(canceler concurrency.Canceler, rank int, job Job) (
+		newJobs []*MetaJob[Job, Properties], feedback Feedback) {
+		return jobHandler(canceler, rank, ws.get(rank), job)
+	}, feedbackHandler, &o, metaJob...)
+}
+
+// workerStates are the states of the running worker goroutines,
+// indexed by their ranks.
+type workerStates[State any] struct {
+	lock sync.RWMutex
+	m    map[int]State
+}
+
+// get returns the state of the worker goroutine of the specified rank.
+func (ws *workerStates[State]) get(rank int) State {
+	ws.lock.RLock()
+	defer ws.lock.RUnlock()
+	return ws.m[rank]
+}
+
+// set sets the state of the worker goroutine of the specified rank.
+func (ws *workerStates[State]) set(rank int, state State) {
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+	ws.m[rank] = state
+}
+
+// remove removes and returns the state of
+// the worker goroutine of the specified rank.
+func (ws *workerStates[State]) remove(rank int) State {
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+	state := ws.m[rank]
+	delete(ws.m, rank)
+	return state
+}
@@ -0,0 +1,169 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobsched_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/donyori/gogo/concurrency"
+	"github.com/donyori/gogo/concurrency/framework/jobsched"
+)
+
+type workerBuffer struct {
+	rank int
+	jobs []int
+}
+
+func TestNewWithState(t *testing.T) {
+	const NumWorker = 3
+	const NumJob = 30
+	var mu sync.Mutex
+	var optsOrder, cleaned []string
+	var bufs []*workerBuffer
+	ctrl := jobsched.NewWithState(
+		func(ctrl jobsched.Controller[int, jobsched.NoProperty, jobsched.NoFeedback], rank int) *workerBuffer {
+			mu.Lock()
+			defer mu.Unlock()
+			optsOrder = append(optsOrder, "setup")
+			return &workerBuffer{rank: rank}
+		},
+		func(ctrl jobsched.Controller[int, jobsched.NoProperty, jobsched.NoFeedback], rank int, state *workerBuffer) {
+			mu.Lock()
+			defer mu.Unlock()
+			if state == nil || state.rank != rank {
+				t.Errorf("got state %v at cleanup of rank %d", state, rank)
+				return
+			}
+			cleaned = append(cleaned, "cleanup")
+			bufs = append(bufs, state)
+		},
+		func(canceler concurrency.Canceler, rank int, state *workerBuffer, job int) (
+			newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback jobsched.NoFeedback) {
+			if state == nil || state.rank != rank {
+				t.Errorf("got state %v for job %d on rank %d", state, job, rank)
+				return
+			}
+			state.jobs = append(state.jobs, job) // no lock: state is per-worker
+			return
+		},
+		nil,
+		&jobsched.Options[int, jobsched.NoProperty, jobsched.NoFeedback]{
+			NumWorker: NumWorker,
+			Setup: func(ctrl jobsched.Controller[int, jobsched.NoProperty, jobsched.NoFeedback], rank int) {
+				mu.Lock()
+				defer mu.Unlock()
+				optsOrder = append(optsOrder, "opts.Setup")
+			},
+			Cleanup: func(ctrl jobsched.Controller[int, jobsched.NoProperty, jobsched.NoFeedback], rank int) {
+				mu.Lock()
+				defer mu.Unlock()
+				cleaned = append(cleaned, "opts.Cleanup")
+			},
+		},
+	)
+	for i := range NumJob {
+		ctrl.Input(&jobsched.MetaJob[int, jobsched.NoProperty]{Job: i})
+	}
+	ctrl.Run()
+	prs := ctrl.PanicRecords()
+	if len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+	if len(bufs) != NumWorker {
+		t.Fatalf("got %d cleaned states; want %d", len(bufs), NumWorker)
+	}
+	seen := make([]bool, NumJob)
+	for _, buf := range bufs {
+		for _, job := range buf.jobs {
+			if seen[job] {
+				t.Errorf("job %d processed more than once", job)
+			}
+			seen[job] = true
+		}
+	}
+	for job := range seen {
+		if !seen[job] {
+			t.Errorf("job %d not processed", job)
+		}
+	}
+	// Each worker calls opts.Setup before setup,
+	// and cleanup before opts.Cleanup.
+	var numOptsSetup, numOptsCleanup int
+	for _, s := range optsOrder {
+		if s == "opts.Setup" {
+			numOptsSetup++
+		} else if numOptsSetup == 0 {
+			t.Error("setup called before any opts.Setup")
+		}
+	}
+	for _, s := range cleaned {
+		if s == "opts.Cleanup" {
+			numOptsCleanup++
+		}
+	}
+	if numOptsSetup != NumWorker || numOptsCleanup != NumWorker {
+		t.Errorf("got %d opts.Setup, %d opts.Cleanup; want %d, %d",
+			numOptsSetup, numOptsCleanup, NumWorker, NumWorker)
+	}
+	if cleaned[0] != "cleanup" {
+		t.Errorf("got first cleanup call %q; want %q", cleaned[0], "cleanup")
+	}
+}
+
+func TestNewWithState_SetupPanic(t *testing.T) {
+	const NumWorker = 2
+	var mu sync.Mutex
+	var numOptsCleanup, numCleanup int
+	ctrl := jobsched.NewWithState(
+		func(ctrl jobsched.Controller[int, jobsched.NoProperty, jobsched.NoFeedback], rank int) int {
+			panic("setup panic")
+		},
+		func(ctrl jobsched.Controller[int, jobsched.NoProperty, jobsched.NoFeedback], rank int, state int) {
+			mu.Lock()
+			defer mu.Unlock()
+			numCleanup++
+		},
+		func(canceler concurrency.Canceler, rank int, state int, job int) (
+			newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback jobsched.NoFeedback) {
+			return
+		},
+		nil,
+		&jobsched.Options[int, jobsched.NoProperty, jobsched.NoFeedback]{
+			NumWorker: NumWorker,
+			Cleanup: func(ctrl jobsched.Controller[int, jobsched.NoProperty, jobsched.NoFeedback], rank int) {
+				mu.Lock()
+				defer mu.Unlock()
+				numOptsCleanup++
+			},
+		},
+		&jobsched.MetaJob[int, jobsched.NoProperty]{Job: 1},
+	)
+	ctrl.Run()
+	prs := ctrl.PanicRecords()
+	if len(prs) == 0 {
+		t.Error("no panic records")
+	}
+	if numCleanup != 0 {
+		t.Errorf("got %d cleanup calls; want 0", numCleanup)
+	}
+	if numOptsCleanup < 1 {
+		t.Errorf("got %d opts.Cleanup calls; want at least 1", numOptsCleanup)
+	}
+}
@@ -0,0 +1,70 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobsched
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats are the statistics of a Controller.
+//
+// It is a snapshot taken by the method Stats of Controller.
+// The fields are collected separately,
+// so they may be slightly inconsistent with each other
+// while the controller is running.
+type Stats struct {
+	// The number of jobs waiting to be dispatched to worker goroutines,
+	// including those waiting to be retried.
+	Queued int
+
+	// The number of jobs being processed by worker goroutines.
+	InFlight int
+
+	// The number of times the job handler has returned
+	// (including the attempts on jobs that are to be retried,
+	// but excluding the calls that panicked).
+	Completed int64
+
+	// The number of times the job handler has returned
+	// on each worker goroutine, indexed by the rank of the worker.
+	//
+	// Its length is the number of ranks of the worker goroutines
+	// that have been started, which is 0 before launching.
+	Processed []int64
+
+	// The average time spent by the job handler
+	// over the Completed calls.
+	//
+	// It is 0 if Completed is 0 or Options.LatencyStats is false.
+	AvgLatency time.Duration
+}
+
+// rankStats are the statistics of the worker goroutines of one rank.
+//
+// They are updated only by the worker goroutine of that rank
+// and merged by the method Stats of the controller,
+// so that the worker goroutines do not contend for a shared lock.
+type rankStats struct {
+	nf atomic.Int64 // The number of in-flight jobs.
+	nc atomic.Int64 // The number of completed jobs.
+	tl atomic.Int64 // The total latency of the completed jobs, in nanoseconds. Updated only if Options.LatencyStats is true.
+
+	_ [40]byte // Padding to keep the counters of different ranks in different cache lines.
+}
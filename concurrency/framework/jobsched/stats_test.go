@@ -0,0 +1,138 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobsched_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/concurrency"
+	"github.com/donyori/gogo/concurrency/framework/jobsched"
+)
+
+func TestController_Stats(t *testing.T) {
+	const NumWorker = 2
+	const NumJob = 10
+	var onCompleteCalls atomic.Int32
+	release := make(chan struct{})
+	var started sync.WaitGroup
+	started.Add(NumWorker)
+	ctrl := jobsched.NewWithoutFeedback(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback jobsched.NoFeedback) {
+		if job < NumWorker {
+			started.Done()
+			<-release
+		}
+		time.Sleep(time.Millisecond)
+		return
+	}, &jobsched.Options[int, jobsched.NoProperty, jobsched.NoFeedback]{
+		NumWorker:    NumWorker,
+		LatencyStats: true,
+		OnComplete: func(
+			ctrl jobsched.Controller[int, jobsched.NoProperty, jobsched.NoFeedback],
+			rank, job int,
+			latency time.Duration,
+		) {
+			onCompleteCalls.Add(1)
+			if latency < time.Millisecond {
+				t.Errorf("job %d - got latency %v; want >= 1ms", job, latency)
+			}
+		},
+	})
+	metaJobs := make([]*jobsched.MetaJob[int, jobsched.NoProperty], NumJob)
+	for i := range metaJobs {
+		metaJobs[i] = &jobsched.MetaJob[int, jobsched.NoProperty]{Job: i}
+	}
+	ctrl.Input(metaJobs...)
+
+	st := ctrl.Stats()
+	if st.Queued != NumJob || st.InFlight != 0 || st.Completed != 0 ||
+		len(st.Processed) != 0 || st.AvgLatency != 0 {
+		t.Errorf("before launch, got %+v; want Queued %d and others zero",
+			st, NumJob)
+	}
+
+	ctrl.Launch()
+	started.Wait()
+	st = ctrl.Stats()
+	if st.InFlight != NumWorker {
+		t.Errorf("during processing, got InFlight %d; want %d",
+			st.InFlight, NumWorker)
+	}
+	// The job allocator may not update the statistics yet.
+	if st.Queued < NumJob-NumWorker || st.Queued > NumJob {
+		t.Errorf("during processing, got Queued %d; want in [%d, %d]",
+			st.Queued, NumJob-NumWorker, NumJob)
+	}
+	if st.Completed != 0 {
+		t.Errorf("during processing, got Completed %d; want 0", st.Completed)
+	}
+	close(release)
+	ctrl.Wait()
+
+	st = ctrl.Stats()
+	if st.Queued != 0 || st.InFlight != 0 || st.Completed != NumJob {
+		t.Errorf("after waiting, got Queued %d, InFlight %d, Completed %d; want 0, 0, %d",
+			st.Queued, st.InFlight, st.Completed, NumJob)
+	}
+	var sum int64
+	for _, n := range st.Processed {
+		sum += n
+	}
+	if sum != NumJob || len(st.Processed) > NumWorker {
+		t.Errorf("after waiting, got Processed %v; want len <= %d and sum %d",
+			st.Processed, NumWorker, NumJob)
+	}
+	if st.AvgLatency < time.Millisecond {
+		t.Errorf("after waiting, got AvgLatency %v; want >= 1ms", st.AvgLatency)
+	}
+	if n := onCompleteCalls.Load(); n != NumJob {
+		t.Errorf("got %d calls to OnComplete; want %d", n, NumJob)
+	}
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+}
+
+func TestController_Stats_NoLatencyStats(t *testing.T) {
+	const NumJob = 10
+	ctrl := jobsched.NewWithoutFeedback(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback jobsched.NoFeedback) {
+		time.Sleep(time.Millisecond)
+		return
+	}, &jobsched.Options[int, jobsched.NoProperty, jobsched.NoFeedback]{
+		NumWorker: 2,
+	})
+	metaJobs := make([]*jobsched.MetaJob[int, jobsched.NoProperty], NumJob)
+	for i := range metaJobs {
+		metaJobs[i] = &jobsched.MetaJob[int, jobsched.NoProperty]{Job: i}
+	}
+	ctrl.Input(metaJobs...)
+	ctrl.Run()
+	st := ctrl.Stats()
+	if st.Completed != NumJob || st.InFlight != 0 || st.AvgLatency != 0 {
+		t.Errorf("got Completed %d, InFlight %d, AvgLatency %v; want %d, 0, 0",
+			st.Completed, st.InFlight, st.AvgLatency, NumJob)
+	}
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+}
@@ -0,0 +1,437 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobsched
+
+import (
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/donyori/gogo/concurrency/framework"
+)
+
+// stealer is the scheduler of the controller in the work-stealing mode
+// (see the option WorkStealing).
+//
+// Each worker goroutine has a local deque.
+// It pushes the new jobs returned by the job handler to the back of
+// its local deque and takes jobs from the back.
+// The jobs input by the client are put into a global deque.
+// When its local deque is empty, a worker goroutine takes jobs
+// from the front of the global deque,
+// and then steals jobs from the front of the other local deques.
+type stealer[Job, Properties any] struct {
+	global stealDeque[Job, Properties] // The global deque, for the jobs from the client and the exited worker goroutines.
+
+	lm     sync.RWMutex                   // Lock for locals.
+	locals []*stealDeque[Job, Properties] // The local deques, indexed by rank. It is replaced (not modified) when adding a deque.
+
+	m      sync.Mutex    // Lock for closed.
+	closed bool          // An indicator to report whether the client input is closed.
+	done   chan struct{} // The channel closed when closed is true and pending is 0.
+
+	pending atomic.Int64 // The number of jobs queued or being processed.
+	queued  atomic.Int64 // The number of jobs queued, for the method Stats.
+
+	sleepers atomic.Int32  // The number of worker goroutines waiting for jobs.
+	wm       sync.Mutex    // Lock for wake.
+	wake     chan struct{} // The channel closed (and then replaced) to wake up the waiting worker goroutines.
+}
+
+// newStealer creates a new stealer.
+func newStealer[Job, Properties any]() *stealer[Job, Properties] {
+	return &stealer[Job, Properties]{
+		done: make(chan struct{}),
+		wake: make(chan struct{}),
+	}
+}
+
+// input puts mjs into the global deque.
+//
+// It returns the number of jobs input successfully,
+// which is 0 if the client input is closed.
+func (s *stealer[Job, Properties]) input(mjs []*MetaJob[Job, Properties]) int {
+	s.m.Lock()
+	if s.closed {
+		s.m.Unlock()
+		return 0
+	}
+	s.pending.Add(int64(len(mjs)))
+	s.queued.Add(int64(len(mjs)))
+	s.global.pushBack(mjs...)
+	s.m.Unlock()
+	s.wakeUp()
+	return len(mjs)
+}
+
+// close closes the client input.
+func (s *stealer[Job, Properties]) close() {
+	s.m.Lock()
+	s.closed = true
+	s.m.Unlock()
+	s.checkDone()
+}
+
+// local returns the local deque of the worker goroutine
+// of the specified rank, creating it if necessary.
+func (s *stealer[Job, Properties]) local(rank int) *stealDeque[Job, Properties] {
+	s.lm.RLock()
+	if rank < len(s.locals) && s.locals[rank] != nil {
+		d := s.locals[rank]
+		s.lm.RUnlock()
+		return d
+	}
+	s.lm.RUnlock()
+	s.lm.Lock()
+	defer s.lm.Unlock()
+	if rank < len(s.locals) && s.locals[rank] != nil {
+		return s.locals[rank]
+	}
+	locals := make([]*stealDeque[Job, Properties], max(len(s.locals), rank+1))
+	copy(locals, s.locals)
+	d := new(stealDeque[Job, Properties])
+	locals[rank] = d
+	s.locals = locals
+	return d
+}
+
+// release moves the jobs in the local deque d to the global deque.
+//
+// It is called when the owner of d exits.
+func (s *stealer[Job, Properties]) release(d *stealDeque[Job, Properties]) {
+	if mjs := d.drain(); len(mjs) > 0 {
+		s.global.pushBack(mjs...)
+		s.wakeUp()
+	}
+}
+
+// snapshot returns copies of up to maxJobs queued jobs,
+// first from the global deque and then from the local deques.
+// Nonpositive maxJobs for all queued jobs.
+func (s *stealer[Job, Properties]) snapshot(maxJobs int) []*MetaJob[Job, Properties] {
+	ps := &pendingSnapshot[Job, Properties]{maxJobs: maxJobs}
+	s.global.rangeMeta(ps.add)
+	s.lm.RLock()
+	locals := s.locals
+	s.lm.RUnlock()
+	for _, d := range locals {
+		if ps.full() {
+			break
+		} else if d != nil {
+			d.rangeMeta(ps.add)
+		}
+	}
+	return ps.mjs
+}
+
+// take removes and returns a job for the worker goroutine
+// of the specified rank, whose local deque is d.
+//
+// It returns nil if there is no job available.
+func (s *stealer[Job, Properties]) take(
+	rank int,
+	d *stealDeque[Job, Properties],
+) *MetaJob[Job, Properties] {
+	mj := d.popBack()
+	if mj == nil {
+		mj = s.global.popFront()
+	}
+	if mj == nil {
+		s.lm.RLock()
+		locals := s.locals
+		s.lm.RUnlock()
+		for i := 1; i < len(locals) && mj == nil; i++ {
+			if v := locals[(rank+i)%len(locals)]; v != nil {
+				mj = v.popFront()
+			}
+		}
+	}
+	if mj != nil {
+		s.queued.Add(-1)
+	}
+	return mj
+}
+
+// finish reports that a job is finished,
+// and pushes its new jobs mjs to the local deque d.
+func (s *stealer[Job, Properties]) finish(
+	d *stealDeque[Job, Properties],
+	mjs []*MetaJob[Job, Properties],
+) {
+	if len(mjs) > 0 {
+		s.pending.Add(int64(len(mjs)))
+		s.queued.Add(int64(len(mjs)))
+		d.pushBack(mjs...)
+		s.wakeUp()
+	}
+	if s.pending.Add(-1) == 0 {
+		s.checkDone()
+	}
+}
+
+// checkDone closes s.done if the client input is closed
+// and there is no pending job.
+func (s *stealer[Job, Properties]) checkDone() {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.closed && s.pending.Load() == 0 {
+		select {
+		case <-s.done:
+		default:
+			close(s.done)
+		}
+	}
+}
+
+// wakeChan returns the channel to wait for new jobs.
+func (s *stealer[Job, Properties]) wakeChan() <-chan struct{} {
+	s.wm.Lock()
+	defer s.wm.Unlock()
+	return s.wake
+}
+
+// wakeUp wakes up the worker goroutines waiting for jobs (if any).
+func (s *stealer[Job, Properties]) wakeUp() {
+	if s.sleepers.Load() == 0 {
+		return
+	}
+	s.wm.Lock()
+	defer s.wm.Unlock()
+	close(s.wake)
+	s.wake = make(chan struct{})
+}
+
+// stealDeque is a double-ended queue of jobs, safe for concurrent use.
+type stealDeque[Job, Properties any] struct {
+	m    sync.Mutex
+	mjs  []*MetaJob[Job, Properties]
+	head int // The index of the front item in mjs.
+}
+
+// pushBack adds mjs to the back of the deque.
+func (d *stealDeque[Job, Properties]) pushBack(mjs ...*MetaJob[Job, Properties]) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	if d.head > 0 && d.head >= len(d.mjs)/2 {
+		// Reclaim the space before head.
+		n := copy(d.mjs, d.mjs[d.head:])
+		clear(d.mjs[n:])
+		d.mjs, d.head = d.mjs[:n], 0
+	}
+	d.mjs = append(d.mjs, mjs...)
+}
+
+// popBack removes and returns the back item of the deque.
+//
+// It returns nil if the deque is empty.
+func (d *stealDeque[Job, Properties]) popBack() *MetaJob[Job, Properties] {
+	d.m.Lock()
+	defer d.m.Unlock()
+	if d.head >= len(d.mjs) {
+		return nil
+	}
+	last := len(d.mjs) - 1
+	mj := d.mjs[last]
+	d.mjs[last] = nil
+	d.mjs = d.mjs[:last]
+	if d.head >= len(d.mjs) {
+		d.mjs, d.head = d.mjs[:0], 0
+	}
+	return mj
+}
+
+// popFront removes and returns the front item of the deque.
+//
+// It returns nil if the deque is empty.
+func (d *stealDeque[Job, Properties]) popFront() *MetaJob[Job, Properties] {
+	d.m.Lock()
+	defer d.m.Unlock()
+	if d.head >= len(d.mjs) {
+		return nil
+	}
+	mj := d.mjs[d.head]
+	d.mjs[d.head] = nil
+	d.head++
+	if d.head >= len(d.mjs) {
+		d.mjs, d.head = d.mjs[:0], 0
+	}
+	return mj
+}
+
+// rangeMeta calls handler on the items in the deque from front to back,
+// until handler returns false.
+func (d *stealDeque[Job, Properties]) rangeMeta(
+	handler func(metaJob *MetaJob[Job, Properties]) (cont bool)) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	for _, mj := range d.mjs[d.head:] {
+		if !handler(mj) {
+			return
+		}
+	}
+}
+
+// drain removes and returns all the items in the deque,
+// from front to back.
+func (d *stealDeque[Job, Properties]) drain() []*MetaJob[Job, Properties] {
+	d.m.Lock()
+	defer d.m.Unlock()
+	if d.head >= len(d.mjs) {
+		return nil
+	}
+	mjs := make([]*MetaJob[Job, Properties], len(d.mjs)-d.head)
+	copy(mjs, d.mjs[d.head:])
+	clear(d.mjs)
+	d.mjs, d.head = d.mjs[:0], 0
+	return mjs
+}
+
+// stealMonitorProc is the main process of the goroutine
+// that replaces the job allocator in the work-stealing mode,
+// without panic checking and ctrl.wg.Done().
+//
+// It closes the client input on the first effective call to
+// the method Wait, and then returns when all jobs are finished.
+func (ctrl *controller[Job, Properties, Feedback]) stealMonitorProc() {
+	cancelChan := ctrl.c.C()
+	select {
+	case <-cancelChan:
+		return
+	case <-ctrl.wso.C():
+	}
+	ctrl.st.close()
+	select {
+	case <-cancelChan:
+	case <-ctrl.st.done:
+	}
+}
+
+// stealWorkerProc is the worker main process in the work-stealing mode,
+// without setup, cleanup, panic checking, and ctrl.wg.Done().
+//
+// The parameters are the same as those of method workerProc.
+func (ctrl *controller[Job, Properties, Feedback]) stealWorkerProc(
+	rank int,
+	rs *rankStats,
+	quit <-chan struct{},
+	cur *[]*MetaJob[Job, Properties],
+) {
+	st := ctrl.st
+	d := st.local(rank)
+	defer st.release(d)
+	cancelChan := ctrl.c.C()
+	one := make([]*MetaJob[Job, Properties], 1) // reused to avoid allocation
+	for {
+		select {
+		case <-cancelChan:
+			return
+		case <-quit:
+			return
+		default:
+		}
+		mj := st.take(rank, d)
+		if mj == nil {
+			// Register as a sleeper before checking again
+			// to avoid missing the wake-up signal.
+			st.sleepers.Add(1)
+			wake := st.wakeChan()
+			mj = st.take(rank, d)
+			if mj == nil {
+				select {
+				case <-cancelChan:
+				case <-quit:
+				case <-st.done:
+					st.sleepers.Add(-1)
+					return
+				case <-wake:
+				}
+			}
+			st.sleepers.Add(-1)
+			if mj == nil {
+				continue
+			}
+		}
+		if !mj.Meta.Deadline.IsZero() && !time.Now().Before(mj.Meta.Deadline) {
+			// Drop the job, like the method dequeue.
+			if ctrl.ofb {
+				select {
+				case <-cancelChan:
+					return
+				case ctrl.fc <- feedbackItem[Feedback]{seq: mj.Meta.seq, skip: true}:
+				}
+			}
+			if ctrl.onTimeout != nil {
+				ctrl.onTimeout(ctrl, TimeoutRecord[Job, Properties]{
+					MetaJob:  mj,
+					Deadline: mj.Meta.Deadline,
+					Rank:     -1,
+				})
+			}
+			st.finish(d, nil)
+			continue
+		}
+		one[0] = mj
+		*cur = one
+		mjs, fb, _ := ctrl.trackJob(rank, rs, mj) // ctrl.rp is nil, so never retry
+		*cur = nil
+		mjs = ctrl.copyMetaJobs(mjs)
+		if ctrl.fc != nil {
+			// The feedback type is not NoFeedback.
+			select {
+			case <-cancelChan:
+				return
+			case ctrl.fc <- feedbackItem[Feedback]{fb: fb, seq: mj.Meta.seq}:
+			}
+		}
+		st.finish(d, mjs)
+	}
+}
+
+// launchStealer starts the goroutine that replaces the job allocator
+// in the work-stealing mode, after moving the jobs in ctrl.jq to ctrl.st.
+//
+// The caller must hold ctrl.m and have added 1 to ctrl.wg
+// for the new goroutine.
+func (ctrl *controller[Job, Properties, Feedback]) launchStealer() {
+	mjs := make([]*MetaJob[Job, Properties], 0, ctrl.jq.Len())
+	for ctrl.jq.Len() > 0 {
+		mjs = append(mjs, ctrl.mdq.DequeueMeta())
+	}
+	ctrl.st.input(mjs)
+	go func() { // goroutine for work-stealing monitor
+		defer ctrl.wg.Done()
+		defer func() {
+			if e := recover(); e != nil {
+				ctrl.c.Cancel()
+				ctrl.pr.Record(framework.PanicRecord{
+					Name:       "work-stealing monitor",
+					Content:    e,
+					StackTrace: string(debug.Stack()),
+				})
+			}
+		}()
+		defer func() {
+			ctrl.wm.Lock()
+			defer ctrl.wm.Unlock()
+			ctrl.ad = true
+		}()
+		ctrl.stealMonitorProc()
+	}()
+}
@@ -0,0 +1,215 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobsched_test
+
+import (
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/concurrency"
+	"github.com/donyori/gogo/concurrency/framework/jobsched"
+)
+
+// binaryTreeJobHandler is a job handler that processes a job d
+// by generating two new jobs d-1 if d is positive,
+// so a job d results in 2^(d+1)-1 jobs in total.
+func binaryTreeJobHandler(
+	counter *atomic.Int64,
+) jobsched.JobHandler[int, jobsched.NoProperty, jobsched.NoFeedback] {
+	return func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback jobsched.NoFeedback) {
+		counter.Add(1)
+		if job > 0 {
+			newJobs = []*jobsched.MetaJob[int, jobsched.NoProperty]{
+				{Job: job - 1},
+				{Job: job - 1},
+			}
+		}
+		return
+	}
+}
+
+func TestController_WorkStealing(t *testing.T) {
+	const Depth = 12
+	const NumRoot = 3
+	for _, numWorker := range []int{1, 2, 8, 32} {
+		var counter atomic.Int64
+		metaJobs := make([]*jobsched.MetaJob[int, jobsched.NoProperty], NumRoot)
+		for i := range metaJobs {
+			metaJobs[i] = &jobsched.MetaJob[int, jobsched.NoProperty]{Job: Depth}
+		}
+		ctrl := jobsched.NewWithoutFeedback(
+			binaryTreeJobHandler(&counter),
+			&jobsched.Options[int, jobsched.NoProperty, jobsched.NoFeedback]{
+				NumWorker:    numWorker,
+				WorkStealing: true,
+			},
+			metaJobs...,
+		)
+		ctrl.Run()
+		if prs := ctrl.PanicRecords(); len(prs) > 0 {
+			t.Errorf("numWorker=%d, panic %q", numWorker, prs)
+		}
+		const Want = NumRoot * (1<<(Depth+1) - 1)
+		if got := counter.Load(); got != Want {
+			t.Errorf("numWorker=%d, got %d jobs processed; want %d",
+				numWorker, got, Want)
+		}
+		st := ctrl.Stats()
+		if st.Completed != Want || st.Queued != 0 || st.InFlight != 0 {
+			t.Errorf("numWorker=%d, got Stats %+v; want Completed %d, Queued 0, InFlight 0",
+				numWorker, st, Want)
+		}
+	}
+}
+
+func TestController_WorkStealing_InputAfterLaunch(t *testing.T) {
+	const Depth = 6
+	const NumInput = 50
+	var counter atomic.Int64
+	ctrl := jobsched.NewWithoutFeedback(
+		binaryTreeJobHandler(&counter),
+		&jobsched.Options[int, jobsched.NoProperty, jobsched.NoFeedback]{
+			NumWorker:    4,
+			WorkStealing: true,
+		},
+	)
+	ctrl.Launch()
+	for i := range NumInput {
+		if n := ctrl.Input(&jobsched.MetaJob[int, jobsched.NoProperty]{Job: Depth}); n != 1 {
+			t.Errorf("Input %d returned %d; want 1", i, n)
+		}
+		if i%10 == 0 {
+			time.Sleep(time.Millisecond) // let workers become idle
+		}
+	}
+	ctrl.Wait()
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+	if n := ctrl.Input(nil); n != 0 {
+		t.Errorf("Input after Wait returned %d; want 0", n)
+	}
+	const Want = NumInput * (1<<(Depth+1) - 1)
+	if got := counter.Load(); got != Want {
+		t.Errorf("got %d jobs processed; want %d", got, Want)
+	}
+}
+
+func TestController_WorkStealing_SetNumWorker(t *testing.T) {
+	const Depth = 10
+	var counter atomic.Int64
+	release := make(chan struct{})
+	jh := binaryTreeJobHandler(&counter)
+	ctrl := jobsched.NewWithoutFeedback(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback jobsched.NoFeedback) {
+		<-release
+		return jh(canceler, rank, job)
+	}, &jobsched.Options[int, jobsched.NoProperty, jobsched.NoFeedback]{
+		NumWorker:    8,
+		WorkStealing: true,
+	}, &jobsched.MetaJob[int, jobsched.NoProperty]{Job: Depth})
+	ctrl.Launch()
+	close(release)
+	ctrl.SetNumWorker(2)
+	ctrl.SetNumWorker(5)
+	ctrl.Wait()
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+	const Want = 1<<(Depth+1) - 1
+	if got := counter.Load(); got != Want {
+		t.Errorf("got %d jobs processed; want %d", got, Want)
+	}
+}
+
+func TestController_WorkStealing_RestartOnPanic(t *testing.T) {
+	const NumJob = 40
+	var counter atomic.Int64
+	metaJobs := make([]*jobsched.MetaJob[int, jobsched.NoProperty], NumJob)
+	for i := range metaJobs {
+		metaJobs[i] = &jobsched.MetaJob[int, jobsched.NoProperty]{Job: i}
+	}
+	prs := jobsched.RunWithoutFeedback(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback jobsched.NoFeedback) {
+		if job%10 == 0 {
+			panic("test panic")
+		}
+		counter.Add(1)
+		return
+	}, &jobsched.Options[int, jobsched.NoProperty, jobsched.NoFeedback]{
+		NumWorker:      3,
+		WorkStealing:   true,
+		RestartOnPanic: true,
+	}, metaJobs...)
+	if len(prs) != NumJob/10 {
+		t.Errorf("got %d panic records; want %d", len(prs), NumJob/10)
+	}
+	if got := counter.Load(); got != NumJob-NumJob/10 {
+		t.Errorf("got %d jobs processed; want %d", got, NumJob-NumJob/10)
+	}
+}
+
+func TestController_WorkStealing_OrderedFeedbackAndDeadline(t *testing.T) {
+	const NumJob = 30
+	var timeoutCounter atomic.Int32
+	metaJobs := make([]*jobsched.MetaJob[int, jobsched.NoProperty], NumJob)
+	past := time.Now().Add(-time.Hour)
+	for i := range metaJobs {
+		metaJobs[i] = &jobsched.MetaJob[int, jobsched.NoProperty]{Job: i}
+		if i%3 == 0 {
+			metaJobs[i].Meta.Deadline = past
+		}
+	}
+	feedback, prs := jobsched.RunCollect(func(canceler concurrency.Canceler, rank, job int) (
+		newJobs []*jobsched.MetaJob[int, jobsched.NoProperty], feedback int) {
+		time.Sleep(time.Duration(job%4) * 100 * time.Microsecond)
+		return nil, job
+	}, &jobsched.Options[int, jobsched.NoProperty, int]{
+		NumWorker:       4,
+		WorkStealing:    true,
+		OrderedFeedback: true,
+		OnTimeout: func(
+			ctrl jobsched.Controller[int, jobsched.NoProperty, int],
+			record jobsched.TimeoutRecord[int, jobsched.NoProperty],
+		) {
+			if record.Rank != -1 {
+				t.Errorf("got rank %d in timeout record; want -1", record.Rank)
+			}
+			timeoutCounter.Add(1)
+		},
+	}, metaJobs...)
+	if len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+	var want []int
+	for i := range NumJob {
+		if i%3 != 0 {
+			want = append(want, i)
+		}
+	}
+	if !slices.Equal(feedback, want) {
+		t.Errorf("got feedback %v; want %v", feedback, want)
+	}
+	if n := timeoutCounter.Load(); n != NumJob/3 {
+		t.Errorf("got %d timeouts; want %d", n, NumJob/3)
+	}
+}
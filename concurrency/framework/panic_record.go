@@ -20,18 +20,44 @@ package framework
 
 import "fmt"
 
-// PanicRecord is a panic record, including the name of the goroutine
-// and the panic content (i.e., the argument passed to function panic).
+// PanicRecord is a panic record, including the name of the goroutine,
+// the panic content (i.e., the argument passed to function panic),
+// and the stack trace of the goroutine at the time the panic was recovered.
 type PanicRecord struct {
-	Name    string // Name of the goroutine.
-	Content any    // The argument passed to function panic.
+	Name       string // Name of the goroutine.
+	Content    any    // The argument passed to function panic.
+	StackTrace string // Stack trace captured at recover time, or empty if unavailable.
 }
 
 // Error formats the panic record into a string
 // and reports it as an error message.
+//
+// The stack trace is not included.
+// To get the message with the stack trace, use method String.
 func (pr PanicRecord) Error() string {
 	if pr.Content == nil {
 		return "no panic"
 	}
 	return fmt.Sprintf("panic on goroutine %s: %v", pr.Name, pr.Content)
 }
+
+// String formats the panic record into a string,
+// including the stack trace if available.
+func (pr PanicRecord) String() string {
+	if pr.StackTrace == "" {
+		return pr.Error()
+	}
+	return pr.Error() + "\n\n" + pr.StackTrace
+}
+
+// Stack returns the stack trace captured at recover time.
+// It returns nil if the stack trace is unavailable.
+//
+// It makes PanicRecord satisfy interface
+// github.com/donyori/gogo/logging.StackError.
+func (pr PanicRecord) Stack() []byte {
+	if pr.StackTrace == "" {
+		return nil
+	}
+	return []byte(pr.StackTrace)
+}
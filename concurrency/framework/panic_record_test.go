@@ -0,0 +1,65 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package framework_test
+
+import (
+	"testing"
+
+	"github.com/donyori/gogo/concurrency/framework"
+)
+
+func TestPanicRecord_String(t *testing.T) {
+	testCases := []struct {
+		pr   framework.PanicRecord
+		want string
+	}{
+		{framework.PanicRecord{}, "no panic"},
+		{
+			framework.PanicRecord{Name: "worker 1", Content: "oops"},
+			"panic on goroutine worker 1: oops",
+		},
+		{
+			framework.PanicRecord{
+				Name:       "worker 1",
+				Content:    "oops",
+				StackTrace: "goroutine 7 [running]:",
+			},
+			"panic on goroutine worker 1: oops\n\ngoroutine 7 [running]:",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.want, func(t *testing.T) {
+			if got := tc.pr.String(); got != tc.want {
+				t.Errorf("got %q; want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPanicRecord_Stack(t *testing.T) {
+	pr := framework.PanicRecord{Name: "worker 1", Content: "oops"}
+	if stack := pr.Stack(); stack != nil {
+		t.Errorf("got %q; want nil", stack)
+	}
+	pr.StackTrace = "goroutine 7 [running]:"
+	if stack := pr.Stack(); string(stack) != pr.StackTrace {
+		t.Errorf("got %q; want %q", stack, pr.StackTrace)
+	}
+}
@@ -0,0 +1,375 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package periodic
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/donyori/gogo/concurrency"
+	"github.com/donyori/gogo/concurrency/framework"
+	"github.com/donyori/gogo/errors"
+)
+
+// Controller is a controller for this periodic scheduler framework.
+//
+// It is used to launch, stop, cancel, and wait for the scheduler.
+// Also, it is used to register new tasks.
+//
+// The scheduler runs until the method Stop is called
+// or the Controller is canceled.
+// Thus, the method Wait (and Run) blocks until then.
+type Controller interface {
+	framework.Controller
+
+	// Add registers the specified task to the scheduler.
+	//
+	// Before launching, the task is buffered and then
+	// scheduled when the scheduler is launched.
+	// After launching, the task is scheduled from now on.
+	//
+	// It reports whether the task is registered.
+	// It returns false if the scheduler has been stopped or canceled.
+	//
+	// It is safe for concurrent use by multiple goroutines.
+	//
+	// Add panics if the schedule or handler of the task is nil.
+	Add(task Task) bool
+
+	// Stop stops the scheduler gracefully.
+	//
+	// After calling Stop, no new run is started,
+	// and the runs waiting for workers are discarded,
+	// but the running handlers are not interrupted.
+	// The method Wait returns after the running handlers finish.
+	//
+	// To interrupt the running handlers,
+	// use the canceler of the Controller instead.
+	//
+	// Stop can be called before launching.
+	// In this case, the scheduler finishes immediately after launching.
+	//
+	// It is safe for concurrent use by multiple goroutines.
+	Stop()
+}
+
+// New creates a new Controller for the periodic scheduler.
+//
+// numWorker is the number of worker goroutines to run the handlers.
+// If numWorker is nonpositive, runtime.NumCPU() is used instead.
+//
+// tasks are the initial tasks.
+//
+// New panics if the schedule or handler of any task is nil.
+func New(numWorker int, tasks ...Task) Controller {
+	if numWorker <= 0 {
+		numWorker = runtime.NumCPU()
+	}
+	ctrl := &controller{
+		nw: numWorker,
+		c:  concurrency.NewCanceler(),
+		pr: concurrency.NewRecorder[framework.PanicRecord](0),
+		sc: make(chan struct{}),
+		ac: make(chan *taskState),
+		rc: make(chan *taskRun),
+		dc: make(chan *taskState),
+	}
+	ctrl.lo = concurrency.NewOnce(ctrl.launchProc)
+	ctrl.so = concurrency.NewOnce(func() {
+		close(ctrl.sc)
+	})
+	for i := range tasks {
+		ctrl.Add(tasks[i])
+	}
+	return ctrl
+}
+
+// taskState is a task with its scheduling state,
+// maintained by the scheduler.
+type taskState struct {
+	Task
+
+	next   time.Time   // Next activation time. Zero for no more activation.
+	active int         // The number of runs waiting for workers or running.
+	queued []time.Time // Scheduled times of the runs queued by OverlapQueue.
+}
+
+// taskRun is a run of a task.
+type taskRun struct {
+	ts        *taskState // The task.
+	scheduled time.Time  // The scheduled time.
+}
+
+// controller is an implementation of interface Controller.
+type controller struct {
+	nw int // Number of workers.
+
+	c  concurrency.Canceler                        // Canceler.
+	pr concurrency.Recorder[framework.PanicRecord] // Panic recorder.
+	wg sync.WaitGroup                              // Wait group for all goroutines.
+	lo concurrency.Once                            // For launching the scheduler.
+	so concurrency.Once                            // For stopping the scheduler.
+	sc chan struct{}                               // Channel closed when the method Stop is called.
+
+	ac chan *taskState // Channel for adding tasks after launching.
+	rc chan *taskRun   // Channel for sending runs to workers.
+	dc chan *taskState // Channel for workers to report finished runs.
+
+	tm      sync.Mutex   // Lock for pending, lsi, and numTask.
+	pending []*taskState // The tasks added before launching.
+	lsi     bool         // An indicator to report whether the method Launch is started.
+	numTask int          // The number of tasks added, used to name tasks.
+}
+
+func (ctrl *controller) Canceler() concurrency.Canceler {
+	return ctrl.c
+}
+
+func (ctrl *controller) Launch() {
+	ctrl.lo.Do()
+}
+
+func (ctrl *controller) Wait() int {
+	if !ctrl.lo.Done() {
+		return -1
+	}
+	defer ctrl.c.Cancel() // for cleanup possible daemon goroutines that wait for a cancellation signal to exit
+	ctrl.wg.Wait()
+	return ctrl.pr.Len()
+}
+
+func (ctrl *controller) Run() int {
+	ctrl.Launch()
+	return ctrl.Wait()
+}
+
+func (ctrl *controller) NumGoroutine() int {
+	return ctrl.nw
+}
+
+func (ctrl *controller) PanicRecords() []framework.PanicRecord {
+	return ctrl.pr.All()
+}
+
+func (ctrl *controller) Add(task Task) bool {
+	if task.Schedule == nil {
+		panic(errors.AutoMsg("task schedule is nil"))
+	} else if task.Handler == nil {
+		panic(errors.AutoMsg("task handler is nil"))
+	}
+	select {
+	case <-ctrl.sc:
+		return false
+	case <-ctrl.c.C():
+		return false
+	default:
+	}
+	ts := &taskState{Task: task}
+	ctrl.tm.Lock()
+	if ts.Name == "" {
+		ts.Name = "task " + strconv.Itoa(ctrl.numTask)
+	}
+	ctrl.numTask++
+	if !ctrl.lsi {
+		ctrl.pending = append(ctrl.pending, ts)
+		ctrl.tm.Unlock()
+		return true
+	}
+	ctrl.tm.Unlock()
+	select {
+	case <-ctrl.sc:
+		return false
+	case <-ctrl.c.C():
+		return false
+	case ctrl.ac <- ts:
+		return true
+	}
+}
+
+func (ctrl *controller) Stop() {
+	ctrl.so.Do()
+}
+
+// launchProc is the process of starting the scheduler.
+// It is invoked by ctrl.lo.Do.
+func (ctrl *controller) launchProc() {
+	ctrl.tm.Lock()
+	ctrl.lsi = true
+	tasks := ctrl.pending
+	ctrl.pending = nil
+	ctrl.tm.Unlock()
+
+	ctrl.wg.Add(ctrl.nw + 1)
+	for i := range ctrl.nw {
+		go ctrl.workerProc(i)
+	}
+	go func() { // goroutine for scheduler
+		defer ctrl.wg.Done()
+		defer func() {
+			if e := recover(); e != nil {
+				ctrl.c.Cancel()
+				ctrl.pr.Record(framework.PanicRecord{
+					Name:       "scheduler",
+					Content:    e,
+					StackTrace: string(debug.Stack()),
+				})
+			}
+		}()
+		ctrl.schedulerProc(tasks)
+	}()
+}
+
+// schedulerProc is the scheduler main process,
+// without panic checking and ctrl.wg.Done().
+func (ctrl *controller) schedulerProc(tasks []*taskState) {
+	defer close(ctrl.rc) // notify workers to exit
+	now := time.Now()
+	for _, ts := range tasks {
+		ts.next = ts.Schedule.Next(now)
+	}
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	var timerC <-chan time.Time
+	resetTimer := func() {
+		var earliest time.Time
+		for _, ts := range tasks {
+			if !ts.next.IsZero() &&
+				(earliest.IsZero() || ts.next.Before(earliest)) {
+				earliest = ts.next
+			}
+		}
+		if earliest.IsZero() {
+			timer.Stop()
+			timerC = nil
+			return
+		}
+		timer.Reset(time.Until(earliest))
+		timerC = timer.C
+	}
+	resetTimer()
+
+	var runs []*taskRun // runs waiting for workers
+	var active int      // the number of runs waiting for workers or running
+	dispatch := func(ts *taskState, scheduled time.Time) {
+		ts.active++
+		active++
+		runs = append(runs, &taskRun{ts: ts, scheduled: scheduled})
+	}
+	activate := func(ts *taskState, scheduled time.Time) {
+		if ts.active > 0 {
+			switch ts.Overlap {
+			case OverlapQueue:
+				ts.queued = append(ts.queued, scheduled)
+				return
+			case OverlapParallel:
+			default: // OverlapSkip
+				return
+			}
+		}
+		dispatch(ts, scheduled)
+	}
+
+	cancelChan, stopC, addC := ctrl.c.C(), ctrl.sc, ctrl.ac
+	for stopC != nil || active > 0 {
+		var rc chan<- *taskRun
+		var run *taskRun
+		if len(runs) > 0 {
+			rc, run = ctrl.rc, runs[0]
+		}
+		select {
+		case <-cancelChan:
+			return
+		case <-stopC:
+			// Stop scheduling and discard the runs waiting for workers.
+			stopC, addC, timerC = nil, nil, nil
+			for _, r := range runs {
+				r.ts.active--
+				active--
+			}
+			runs = nil
+			for _, ts := range tasks {
+				ts.queued = nil
+			}
+		case ts := <-addC:
+			ts.next = ts.Schedule.Next(time.Now())
+			tasks = append(tasks, ts)
+			resetTimer()
+		case now := <-timerC:
+			for _, ts := range tasks {
+				if !ts.next.IsZero() && !ts.next.After(now) {
+					activate(ts, ts.next)
+					// Skip the activations missed during this run.
+					ts.next = ts.Schedule.Next(now)
+				}
+			}
+			resetTimer()
+		case rc <- run:
+			runs[0] = nil // avoid memory leak
+			runs = runs[1:]
+		case ts := <-ctrl.dc:
+			ts.active--
+			active--
+			if len(ts.queued) > 0 {
+				scheduled := ts.queued[0]
+				ts.queued = ts.queued[1:]
+				dispatch(ts, scheduled)
+			}
+		}
+	}
+}
+
+// workerProc is the worker main process.
+func (ctrl *controller) workerProc(rank int) {
+	defer ctrl.wg.Done()
+	cancelChan := ctrl.c.C()
+	for {
+		select {
+		case <-cancelChan:
+			return
+		case run, ok := <-ctrl.rc:
+			if !ok {
+				return
+			}
+			ctrl.runTask(rank, run)
+			select {
+			case <-cancelChan:
+				return
+			case ctrl.dc <- run.ts:
+			}
+		}
+	}
+}
+
+// runTask runs the handler of the task for the specified run,
+// and records the panic (if any).
+func (ctrl *controller) runTask(rank int, run *taskRun) {
+	defer func() {
+		if e := recover(); e != nil {
+			ctrl.pr.Record(framework.PanicRecord{
+				Name:       "worker " + strconv.Itoa(rank) + " (" + run.ts.Name + ")",
+				Content:    e,
+				StackTrace: string(debug.Stack()),
+			})
+		}
+	}()
+	run.ts.Handler(ctrl.c, run.scheduled)
+}
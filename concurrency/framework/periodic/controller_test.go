@@ -0,0 +1,221 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package periodic_test
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/concurrency"
+	"github.com/donyori/gogo/concurrency/framework/periodic"
+)
+
+const (
+	testInterval = 5 * time.Millisecond
+	testDuration = 20 * testInterval
+)
+
+func TestController_Overlap(t *testing.T) {
+	testCases := []struct {
+		name        string
+		overlap     periodic.OverlapPolicy
+		wantOverlap bool
+	}{
+		{"OverlapSkip", periodic.OverlapSkip, false},
+		{"OverlapQueue", periodic.OverlapQueue, false},
+		{"OverlapParallel", periodic.OverlapParallel, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var runs, active, maxActive atomic.Int32
+			ctrl := periodic.New(4, periodic.Task{
+				Schedule: periodic.Every(testInterval),
+				Overlap:  tc.overlap,
+				Handler: func(
+					canceler concurrency.Canceler,
+					scheduled time.Time,
+				) {
+					runs.Add(1)
+					n := active.Add(1)
+					defer active.Add(-1)
+					for {
+						m := maxActive.Load()
+						if n <= m || maxActive.CompareAndSwap(m, n) {
+							break
+						}
+					}
+					time.Sleep(testInterval * 3)
+				},
+			})
+			ctrl.Launch()
+			time.Sleep(testDuration)
+			ctrl.Stop()
+			if n := ctrl.Wait(); n != 0 {
+				t.Errorf("got %d panics; want 0", n)
+			}
+			if runs.Load() == 0 {
+				t.Fatal("handler is never run")
+			}
+			if m := maxActive.Load(); (m > 1) != tc.wantOverlap {
+				t.Errorf("got max active runs %d; want overlap %t",
+					m, tc.wantOverlap)
+			}
+		})
+	}
+}
+
+func TestController_Add(t *testing.T) {
+	var before, after atomic.Int32
+	ctrl := periodic.New(0)
+	if !ctrl.Add(periodic.Task{
+		Schedule: periodic.Every(testInterval),
+		Handler: func(canceler concurrency.Canceler, scheduled time.Time) {
+			before.Add(1)
+		},
+	}) {
+		t.Error("failed to add a task before launching")
+	}
+	ctrl.Launch()
+	if !ctrl.Add(periodic.Task{
+		Schedule: periodic.Every(testInterval),
+		Handler: func(canceler concurrency.Canceler, scheduled time.Time) {
+			after.Add(1)
+		},
+	}) {
+		t.Error("failed to add a task after launching")
+	}
+	time.Sleep(testDuration)
+	ctrl.Stop()
+	ctrl.Wait()
+	if before.Load() == 0 {
+		t.Error("task added before launching is never run")
+	}
+	if after.Load() == 0 {
+		t.Error("task added after launching is never run")
+	}
+	if ctrl.Add(periodic.Task{
+		Schedule: periodic.Every(testInterval),
+		Handler:  func(canceler concurrency.Canceler, scheduled time.Time) {},
+	}) {
+		t.Error("added a task after stopping")
+	}
+}
+
+func TestController_Stop(t *testing.T) {
+	started := make(chan struct{})
+	var finished, canceled atomic.Bool
+	ctrl := periodic.New(1, periodic.Task{
+		Schedule: periodic.Every(testInterval),
+		Handler: func(canceler concurrency.Canceler, scheduled time.Time) {
+			select {
+			case <-started:
+				return
+			default:
+			}
+			close(started)
+			time.Sleep(testInterval * 4)
+			canceled.Store(canceler.Canceled())
+			finished.Store(true)
+		},
+	})
+	ctrl.Launch()
+	<-started
+	ctrl.Stop()
+	ctrl.Wait()
+	if !finished.Load() {
+		t.Error("running handler was not finished before Wait returned")
+	}
+	if canceled.Load() {
+		t.Error("running handler was canceled by Stop")
+	}
+}
+
+func TestController_Cancel(t *testing.T) {
+	started := make(chan struct{}, 1)
+	ctrl := periodic.New(1, periodic.Task{
+		Schedule: periodic.Every(testInterval),
+		Handler: func(canceler concurrency.Canceler, scheduled time.Time) {
+			select {
+			case started <- struct{}{}:
+			default:
+			}
+			<-canceler.C()
+		},
+	})
+	ctrl.Launch()
+	<-started
+	ctrl.Canceler().Cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ctrl.Wait()
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("Wait did not return after cancellation")
+	}
+}
+
+func TestController_Panic(t *testing.T) {
+	const PanicMsg = "test panic"
+	var runs atomic.Int32
+	ctrl := periodic.New(2, periodic.Task{
+		Name:     "panicker",
+		Schedule: periodic.Every(testInterval),
+		Handler: func(canceler concurrency.Canceler, scheduled time.Time) {
+			runs.Add(1)
+			panic(PanicMsg)
+		},
+	})
+	ctrl.Launch()
+	time.Sleep(testDuration)
+	ctrl.Stop()
+	n := ctrl.Wait()
+	if r := runs.Load(); r < 2 {
+		t.Errorf("got %d runs; want at least 2", r)
+	}
+	prs := ctrl.PanicRecords()
+	if n != len(prs) || n != int(runs.Load()) {
+		t.Errorf("got %d panics and %d panic records; want %d",
+			n, len(prs), runs.Load())
+	}
+	for _, pr := range prs {
+		if !strings.Contains(pr.Name, "panicker") || pr.Content != PanicMsg {
+			t.Errorf("got panic record %+v; want Name containing %q, Content %q",
+				pr, "panicker", PanicMsg)
+		}
+	}
+}
+
+func TestController_StopBeforeLaunch(t *testing.T) {
+	ctrl := periodic.New(1, periodic.Task{
+		Schedule: periodic.Every(testInterval),
+		Handler: func(canceler concurrency.Canceler, scheduled time.Time) {
+			t.Error("handler is run after stopping")
+		},
+	})
+	ctrl.Stop()
+	if n := ctrl.Run(); n != 0 {
+		t.Errorf("got %d panics; want 0", n)
+	}
+}
@@ -0,0 +1,38 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package periodic provides a framework to run functions periodically,
+// like a lightweight cron.
+//
+// To use this framework, you should start with the function New
+// to create a Controller with some tasks.
+// Each task specifies a function (its handler),
+// a Schedule (created by the function Every or ParseSpec)
+// to determine when to run the handler,
+// and an OverlapPolicy to determine what to do
+// if the previous run of the handler has not finished.
+// Then, call the method Launch of the Controller to start the scheduler.
+// More tasks can be added through the method Add of the Controller.
+// Finally, call the method Stop of the Controller to stop the scheduler
+// gracefully, or cancel the Controller to interrupt the handlers,
+// and call the method Wait to wait for the handlers to finish.
+//
+// The handlers are run on a pool of worker goroutines.
+// If a handler panics, the panic is recorded and
+// the worker goroutine continues to serve other runs.
+package periodic
@@ -0,0 +1,249 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package periodic
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// Schedule determines when to run the handler of a task.
+type Schedule interface {
+	// Next returns the next time to run the handler after t
+	// (strictly later than t).
+	//
+	// It returns a zero time if there is no next time.
+	Next(t time.Time) time.Time
+}
+
+// Every returns a Schedule that activates every d.
+//
+// The first activation is d after the task is registered
+// to a running scheduler (or after the scheduler is launched).
+//
+// Every panics if d is nonpositive.
+func Every(d time.Duration) Schedule {
+	if d <= 0 {
+		panic(errors.AutoMsg(fmt.Sprintf("d (%v) is nonpositive", d)))
+	}
+	return everySchedule(d)
+}
+
+// everySchedule is an implementation of interface Schedule
+// that activates at a fixed interval.
+type everySchedule time.Duration
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(s))
+}
+
+// ParseSpec parses a cron-like specification into a Schedule.
+//
+// The specification consists of five fields separated by spaces:
+//
+//	minute hour day-of-month month day-of-week
+//
+// with the following allowed values:
+//
+//	minute:       0-59
+//	hour:         0-23
+//	day-of-month: 1-31
+//	month:        1-12
+//	day-of-week:  0-7 (0 and 7 are both Sunday)
+//
+// Each field is a comma-separated list of items.
+// Each item is one of "*" (all values), "a" (a single value),
+// or "a-b" (an inclusive range),
+// optionally followed by "/n" to take every n-th value of the range.
+// For "a/n", the range is from a to the maximum value.
+//
+// As in cron, if both day-of-month and day-of-week are restricted
+// (i.e., not "*"), the schedule activates when either of them matches.
+//
+// The following predefined specifications are also supported:
+//
+//	@yearly (or @annually): 0 0 1 1 *
+//	@monthly:               0 0 1 * *
+//	@weekly:                0 0 * * 0
+//	@daily (or @midnight):  0 0 * * *
+//	@hourly:                0 * * * *
+//	@every <duration>:      same as Every(<duration>), where <duration>
+//	                        is a string accepted by time.ParseDuration
+//
+// The returned Schedule uses the location of the time passed to
+// its method Next.
+func ParseSpec(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "@") {
+		if d, ok := strings.CutPrefix(spec, "@every "); ok {
+			dur, err := time.ParseDuration(strings.TrimSpace(d))
+			if err != nil {
+				return nil, errors.AutoWrap(err)
+			} else if dur <= 0 {
+				return nil, errors.AutoNew(fmt.Sprintf(
+					"nonpositive duration in spec %q", spec))
+			}
+			return everySchedule(dur), nil
+		}
+		switch spec {
+		case "@yearly", "@annually":
+			spec = "0 0 1 1 *"
+		case "@monthly":
+			spec = "0 0 1 * *"
+		case "@weekly":
+			spec = "0 0 * * 0"
+		case "@daily", "@midnight":
+			spec = "0 0 * * *"
+		case "@hourly":
+			spec = "0 * * * *"
+		default:
+			return nil, errors.AutoNew(fmt.Sprintf(
+				"unknown predefined spec %q", spec))
+		}
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, errors.AutoNew(fmt.Sprintf(
+			"spec %q has %d fields; want 5", spec, len(fields)))
+	}
+	s := new(cronSchedule)
+	var err error
+	for i, p := range []struct {
+		bits     *uint64
+		min, max int
+	}{
+		{&s.minute, 0, 59},
+		{&s.hour, 0, 23},
+		{&s.dom, 1, 31},
+		{&s.month, 1, 12},
+		{&s.dow, 0, 7},
+	} {
+		*p.bits, err = parseField(fields[i], p.min, p.max)
+		if err != nil {
+			return nil, errors.AutoWrap(fmt.Errorf("spec %q: %w", spec, err))
+		}
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1 // 7 is also Sunday
+	}
+	s.domStar, s.dowStar = fields[2] == "*", fields[4] == "*"
+	return s, nil
+}
+
+// parseField parses a field of the cron-like specification
+// into a bit set, where the i-th bit indicates whether value i is included.
+func parseField(field string, min, max int) (bits uint64, err error) {
+	for _, item := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			step, err = strconv.Atoi(stepStr)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", item)
+			}
+		}
+		var lo, hi int
+		if rng == "*" {
+			lo, hi = min, max
+		} else {
+			loStr, hiStr, isRange := strings.Cut(rng, "-")
+			lo, err = strconv.Atoi(loStr)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value in %q", item)
+			}
+			switch {
+			case isRange:
+				hi, err = strconv.Atoi(hiStr)
+				if err != nil {
+					return 0, fmt.Errorf("invalid value in %q", item)
+				}
+			case hasStep:
+				hi = max
+			default:
+				hi = lo
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q is out of range [%d, %d]", item, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return
+}
+
+// cronSchedule is an implementation of interface Schedule
+// based on a cron-like specification.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64 // Bit sets of the fields.
+	domStar, dowStar              bool   // Indicators of whether day-of-month and day-of-week are "*".
+}
+
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	// Start from the next whole minute.
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1,
+		0, 0, loc)
+	yearLimit := t.Year() + 5 // give up if there is no match in 5 years
+wrap:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+	for s.month&(1<<uint(t.Month())) == 0 {
+		t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+	for !s.dayMatches(t) {
+		t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+	for s.hour&(1<<uint(t.Hour())) == 0 {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+	for s.minute&(1<<uint(t.Minute())) == 0 {
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+	return t
+}
+
+// dayMatches reports whether the day of t matches
+// the day-of-month and day-of-week fields.
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
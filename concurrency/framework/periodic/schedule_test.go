@@ -0,0 +1,145 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package periodic_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/concurrency/framework/periodic"
+)
+
+func TestEvery(t *testing.T) {
+	s := periodic.Every(time.Minute)
+	start := time.Date(2024, 1, 1, 0, 0, 30, 0, time.UTC)
+	if got, want := s.Next(start), start.Add(time.Minute); !got.Equal(want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func TestParseSpec(t *testing.T) {
+	// 2024-01-01 is a Monday.
+	start := time.Date(2024, 1, 1, 10, 30, 15, 0, time.UTC)
+	testCases := []struct {
+		spec string
+		want []time.Time
+	}{
+		{"* * * * *", []time.Time{
+			time.Date(2024, 1, 1, 10, 31, 0, 0, time.UTC),
+			time.Date(2024, 1, 1, 10, 32, 0, 0, time.UTC),
+		}},
+		{"*/20 * * * *", []time.Time{
+			time.Date(2024, 1, 1, 10, 40, 0, 0, time.UTC),
+			time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC),
+		}},
+		{"0 9-17/4 * * *", []time.Time{
+			time.Date(2024, 1, 1, 13, 0, 0, 0, time.UTC),
+			time.Date(2024, 1, 1, 17, 0, 0, 0, time.UTC),
+			time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC),
+		}},
+		{"15,45 2 * * *", []time.Time{
+			time.Date(2024, 1, 2, 2, 15, 0, 0, time.UTC),
+			time.Date(2024, 1, 2, 2, 45, 0, 0, time.UTC),
+		}},
+		{"0 0 * * 0", []time.Time{
+			time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 1, 14, 0, 0, 0, 0, time.UTC),
+		}},
+		{"0 0 * * 7", []time.Time{
+			time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC),
+		}},
+		{"0 0 29 2 *", []time.Time{
+			time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+			time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		}},
+		{"0 0 13 * 5", []time.Time{ // the 13th or Fridays
+			time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 1, 12, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 1, 13, 0, 0, 0, 0, time.UTC),
+		}},
+		{"0 0 31 * *", []time.Time{
+			time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC),
+		}},
+		{"@hourly", []time.Time{
+			time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC),
+		}},
+		{"@daily", []time.Time{
+			time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		}},
+		{"@weekly", []time.Time{
+			time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC),
+		}},
+		{"@monthly", []time.Time{
+			time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		}},
+		{"@yearly", []time.Time{
+			time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		}},
+		{"@every 90s", []time.Time{
+			time.Date(2024, 1, 1, 10, 31, 45, 0, time.UTC),
+			time.Date(2024, 1, 1, 10, 33, 15, 0, time.UTC),
+		}},
+		{"0 0 30 2 *", []time.Time{{}}}, // never
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("spec=%q", tc.spec), func(t *testing.T) {
+			s, err := periodic.ParseSpec(tc.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cur := start
+			for i, want := range tc.want {
+				cur = s.Next(cur)
+				if !cur.Equal(want) {
+					t.Errorf("activation %d, got %v; want %v", i, cur, want)
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestParseSpec_Error(t *testing.T) {
+	specs := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@every",
+		"@every -1s",
+		"@unknown",
+	}
+	for _, spec := range specs {
+		t.Run(fmt.Sprintf("spec=%q", spec), func(t *testing.T) {
+			if _, err := periodic.ParseSpec(spec); err == nil {
+				t.Error("got nil error")
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package periodic
+
+import (
+	"time"
+
+	"github.com/donyori/gogo/concurrency"
+)
+
+// OverlapPolicy determines what to do when a task is activated
+// while its previous run has not finished.
+type OverlapPolicy int8
+
+const (
+	// OverlapSkip skips the new run if the previous run of the task
+	// has not finished (or is waiting for a worker).
+	OverlapSkip OverlapPolicy = iota
+
+	// OverlapQueue queues the new run and starts it
+	// after the previous run of the task finishes.
+	// Thus, the runs of the task never overlap.
+	OverlapQueue
+
+	// OverlapParallel starts the new run regardless of the previous run,
+	// so the runs of the task can overlap.
+	OverlapParallel
+)
+
+// Task is a function to be run periodically.
+type Task struct {
+	// Name is the name of the task, used in the panic records.
+	//
+	// If Name is empty, a name "task <i>" is assigned,
+	// where <i> is the order in which the task is registered
+	// to the Controller, starting from 0.
+	Name string
+
+	// Schedule determines when to run the handler.
+	//
+	// It must be non-nil.
+	Schedule Schedule
+
+	// Overlap determines what to do when the task is activated
+	// while its previous run has not finished.
+	//
+	// The default (zero value) is OverlapSkip.
+	Overlap OverlapPolicy
+
+	// Handler is the function to be run.
+	//
+	// Its first parameter is the canceler of the Controller.
+	// The handler should return as soon as possible
+	// after the canceler broadcasts the cancellation signal.
+	// Its second parameter is the scheduled time of this run.
+	//
+	// It must be non-nil.
+	Handler func(canceler concurrency.Canceler, scheduled time.Time)
+}
@@ -0,0 +1,265 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pipeline
+
+import (
+	"runtime/debug"
+	"sync"
+
+	"github.com/donyori/gogo/concurrency"
+	"github.com/donyori/gogo/concurrency/framework"
+	"github.com/donyori/gogo/errors"
+)
+
+// Controller is a controller for this pipeline framework.
+//
+// It is used to launch, cancel, and wait for the pipeline.
+// Also, it is used to input items to the first stage.
+//
+// The first type parameter In is the type of input items
+// of the first stage.
+// The second type parameter Out is the type of output items
+// of the last stage.
+type Controller[In, Out any] interface {
+	framework.Controller
+
+	// Input enables the client to input items to the first stage.
+	//
+	// Before launching the pipeline, the items are buffered
+	// and then sent to the first stage when the pipeline is launched.
+	// After launching, Input blocks until all the items are received
+	// by the first stage or the pipeline is canceled.
+	//
+	// It returns the number of items input successfully.
+	//
+	// It is safe for concurrent use by multiple goroutines.
+	//
+	// The client can input items before the first effective call to
+	// the method Wait (i.e., the call after invoking the method Launch).
+	// After calling the method Wait, Input does nothing and returns 0.
+	// Note that the method Run calls Wait inside.
+	Input(x ...In) int
+}
+
+// New creates a new Controller for the pipeline built by b.
+//
+// sink is the function to process the output items of the last stage,
+// which is called in a dedicated goroutine.
+// Its first parameter is a canceler to interrupt the whole pipeline.
+// Its second parameter is the output item.
+// If sink is nil, the output items are discarded silently.
+//
+// x is the initial input items.
+//
+// New panics if b is nil.
+func New[In, Out any](
+	b *Builder[In, Out],
+	sink func(canceler concurrency.Canceler, y Out),
+	x ...In,
+) Controller[In, Out] {
+	if b == nil {
+		panic(errors.AutoMsg("builder is nil"))
+	}
+	ctrl := &controller[In, Out]{
+		b:    b,
+		sink: sink,
+		c:    concurrency.NewCanceler(),
+		pr:   concurrency.NewRecorder[framework.PanicRecord](0),
+		ic:   make(chan In),
+	}
+	if len(x) > 0 {
+		ctrl.pending = append(ctrl.pending, x...)
+	}
+	ctrl.lo = concurrency.NewOnce(ctrl.launchProc)
+	ctrl.wso = concurrency.NewOnce(ctrl.closeInput)
+	return ctrl
+}
+
+// Run creates a Controller with specified arguments, and then runs it.
+// It returns the panic records of the Controller.
+//
+// The parameters are the same as those of function New.
+func Run[In, Out any](
+	b *Builder[In, Out],
+	sink func(canceler concurrency.Canceler, y Out),
+	x ...In,
+) []framework.PanicRecord {
+	ctrl := New(b, sink, x...)
+	ctrl.Run()
+	return ctrl.PanicRecords()
+}
+
+// controller is an implementation of interface Controller.
+type controller[In, Out any] struct {
+	b    *Builder[In, Out]                          // Builder of the pipeline.
+	sink func(canceler concurrency.Canceler, y Out) // Sink function.
+
+	c   concurrency.Canceler                        // Canceler.
+	pr  concurrency.Recorder[framework.PanicRecord] // Panic recorder.
+	wg  sync.WaitGroup                              // Wait group for all goroutines.
+	lo  concurrency.Once                            // For launching the pipeline.
+	wso concurrency.Once                            // For indicating the start of the first effective call to the method Wait, and closing ic.
+
+	ic chan In // Input channel, to input items to the first stage.
+	// Lock to avoid sending to ic after closing it.
+	// The senders hold the read lock, and the closer holds the write lock.
+	m      sync.RWMutex
+	closed bool // An indicator to report whether ic is closed.
+
+	pm      sync.Mutex // Lock for pending and lsi.
+	pending []In       // The items input before launching.
+	lsi     bool       // An indicator to report whether the method Launch is started.
+}
+
+func (ctrl *controller[In, Out]) Canceler() concurrency.Canceler {
+	return ctrl.c
+}
+
+func (ctrl *controller[In, Out]) Launch() {
+	ctrl.lo.Do()
+}
+
+func (ctrl *controller[In, Out]) Wait() int {
+	if !ctrl.lo.Done() {
+		return -1
+	}
+	defer ctrl.c.Cancel() // for cleanup possible daemon goroutines that wait for a cancellation signal to exit
+	ctrl.wso.Do()
+	ctrl.wg.Wait()
+	return ctrl.pr.Len()
+}
+
+func (ctrl *controller[In, Out]) Run() int {
+	ctrl.Launch()
+	return ctrl.Wait()
+}
+
+func (ctrl *controller[In, Out]) NumGoroutine() int {
+	return ctrl.b.numGoroutine
+}
+
+func (ctrl *controller[In, Out]) PanicRecords() []framework.PanicRecord {
+	return ctrl.pr.All()
+}
+
+func (ctrl *controller[In, Out]) Input(x ...In) int {
+	if len(x) == 0 || ctrl.wso.Done() {
+		return 0
+	}
+	ctrl.m.RLock()
+	defer ctrl.m.RUnlock()
+	if ctrl.closed {
+		return 0
+	}
+	ctrl.pm.Lock()
+	if !ctrl.lsi {
+		ctrl.pending = append(ctrl.pending, x...)
+		ctrl.pm.Unlock()
+		return len(x)
+	}
+	ctrl.pm.Unlock()
+	return ctrl.send(x)
+}
+
+// launchProc is the process of starting the pipeline.
+// It is invoked by ctrl.lo.Do.
+func (ctrl *controller[In, Out]) launchProc() {
+	ctrl.pm.Lock()
+	ctrl.lsi = true
+	pending := ctrl.pending
+	ctrl.pending = nil
+	ctrl.pm.Unlock()
+
+	e := &env{c: ctrl.c, wg: &ctrl.wg, pr: ctrl.pr}
+	oc := ctrl.b.connect(e, ctrl.ic)
+	ctrl.wg.Add(1)
+	go func() { // goroutine for sink
+		defer ctrl.wg.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				ctrl.c.Cancel()
+				ctrl.pr.Record(framework.PanicRecord{
+					Name:       "sink",
+					Content:    r,
+					StackTrace: string(debug.Stack()),
+				})
+			}
+		}()
+		ctrl.sinkProc(oc)
+	}()
+	if len(pending) > 0 {
+		// Hold the read lock until all pending items are sent
+		// to prevent ic from being closed.
+		// ctrl.closed must be false here because
+		// the method Wait does nothing before launching.
+		ctrl.m.RLock()
+		ctrl.wg.Add(1)
+		go func() { // goroutine for feeding pending items
+			defer ctrl.wg.Done()
+			defer ctrl.m.RUnlock()
+			ctrl.send(pending)
+		}()
+	}
+}
+
+// closeInput closes the input channel.
+// It is invoked by ctrl.wso.Do.
+func (ctrl *controller[In, Out]) closeInput() {
+	ctrl.m.Lock()
+	defer ctrl.m.Unlock()
+	ctrl.closed = true
+	close(ctrl.ic)
+}
+
+// send sends x to the input channel one by one
+// until the pipeline is canceled.
+//
+// It returns the number of items sent.
+//
+// The caller must hold the read lock of ctrl.m.
+func (ctrl *controller[In, Out]) send(x []In) int {
+	cancelChan := ctrl.c.C()
+	for i := range x {
+		select {
+		case <-cancelChan:
+			return i
+		case ctrl.ic <- x[i]:
+		}
+	}
+	return len(x)
+}
+
+// sinkProc is the sink main process,
+// without panic checking and ctrl.wg.Done().
+func (ctrl *controller[In, Out]) sinkProc(oc <-chan Out) {
+	cancelChan := ctrl.c.C()
+	for {
+		select {
+		case <-cancelChan:
+			return
+		case y, ok := <-oc:
+			if !ok {
+				return
+			}
+			if ctrl.sink != nil {
+				ctrl.sink(ctrl.c, y)
+			}
+		}
+	}
+}
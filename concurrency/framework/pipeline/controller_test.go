@@ -0,0 +1,223 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pipeline_test
+
+import (
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/concurrency"
+	"github.com/donyori/gogo/concurrency/framework/pipeline"
+)
+
+func TestRun(t *testing.T) {
+	const N = 100
+	b := pipeline.Then(
+		pipeline.Then(
+			pipeline.First(pipeline.Stage[int, int]{
+				NumWorker: 3,
+				Handler: func(canceler concurrency.Canceler, rank, x int) []int {
+					if x%2 != 0 {
+						return nil // filter out odd numbers
+					}
+					return []int{x, x + 1}
+				},
+			}),
+			pipeline.Stage[int, int]{
+				NumWorker: 2,
+				BufSize:   4,
+				Handler: func(canceler concurrency.Canceler, rank, x int) []int {
+					return []int{x * 10}
+				},
+			},
+		),
+		pipeline.Stage[int, string]{
+			NumWorker: 4,
+			Handler: func(canceler concurrency.Canceler, rank, x int) []string {
+				return []string{strconv.Itoa(x)}
+			},
+		},
+	)
+	if n := b.NumStage(); n != 3 {
+		t.Errorf("got NumStage %d; want 3", n)
+	}
+	input := make([]int, N)
+	for i := range input {
+		input[i] = i
+	}
+	var got []string
+	prs := pipeline.Run(b, func(canceler concurrency.Canceler, y string) {
+		got = append(got, y) // called by only one goroutine
+	}, input...)
+	if len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+	want := make([]string, 0, N)
+	for i := 0; i < N; i += 2 {
+		want = append(want, strconv.Itoa(i*10), strconv.Itoa((i+1)*10))
+	}
+	slices.Sort(got)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func TestController_Input(t *testing.T) {
+	const N = 50
+	var sum atomic.Int64
+	ctrl := pipeline.New(pipeline.First(pipeline.Stage[int, int]{
+		NumWorker: 2,
+		Handler: func(canceler concurrency.Canceler, rank, x int) []int {
+			return []int{x}
+		},
+	}), func(canceler concurrency.Canceler, y int) {
+		sum.Add(int64(y))
+	})
+	if n := ctrl.NumGoroutine(); n != 2 {
+		t.Errorf("got NumGoroutine %d; want 2", n)
+	}
+	for i := range N / 2 {
+		if n := ctrl.Input(i); n != 1 {
+			t.Errorf("got %d; want 1", n)
+		}
+	}
+	ctrl.Launch()
+	for i := N / 2; i < N; i++ {
+		if n := ctrl.Input(i); n != 1 {
+			t.Errorf("got %d; want 1", n)
+		}
+	}
+	ctrl.Wait()
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+	if n := ctrl.Input(N); n != 0 {
+		t.Errorf("got %d after Wait; want 0", n)
+	}
+	if got := sum.Load(); got != N*(N-1)/2 {
+		t.Errorf("got sum %d; want %d", got, N*(N-1)/2)
+	}
+}
+
+func TestController_Backpressure(t *testing.T) {
+	const BufSize = 2
+	release := make(chan struct{})
+	var produced atomic.Int32
+	ctrl := pipeline.New(pipeline.Then(
+		pipeline.First(pipeline.Stage[int, int]{
+			NumWorker: 1,
+			BufSize:   BufSize,
+			Handler: func(canceler concurrency.Canceler, rank, x int) []int {
+				produced.Add(1)
+				return []int{x}
+			},
+		}),
+		pipeline.Stage[int, int]{
+			NumWorker: 1,
+			Handler: func(canceler concurrency.Canceler, rank, x int) []int {
+				<-release
+				return []int{x}
+			},
+		},
+	), nil, make([]int, 20)...)
+	ctrl.Launch()
+	time.Sleep(20 * time.Millisecond)
+	// The second stage holds 1 item, the channel buffers BufSize items,
+	// and the first stage is blocked sending 1 item.
+	if n := produced.Load(); n > BufSize+2 {
+		t.Errorf("got %d items produced by the first stage; want at most %d",
+			n, BufSize+2)
+	}
+	close(release)
+	ctrl.Wait()
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+	if n := produced.Load(); n != 20 {
+		t.Errorf("got %d items produced; want 20", n)
+	}
+}
+
+func TestController_Panic(t *testing.T) {
+	const PanicMsg = "test panic"
+	ctrl := pipeline.New(pipeline.Then(
+		pipeline.First(pipeline.Stage[int, int]{
+			NumWorker: 2,
+			Handler: func(canceler concurrency.Canceler, rank, x int) []int {
+				return []int{x}
+			},
+		}),
+		pipeline.Stage[int, int]{
+			Name:      "panicker",
+			NumWorker: 1,
+			Handler: func(canceler concurrency.Canceler, rank, x int) []int {
+				if x == 3 {
+					panic(PanicMsg)
+				}
+				return []int{x}
+			},
+		},
+	), nil, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+	ctrl.Launch()
+	// Input blocks until the pipeline is canceled.
+	for ctrl.Input(10) > 0 {
+	}
+	if n := ctrl.Wait(); n != 1 {
+		t.Errorf("got %d panic goroutines; want 1", n)
+	}
+	prs := ctrl.PanicRecords()
+	if len(prs) != 1 {
+		t.Fatalf("got %d panic records; want 1", len(prs))
+	}
+	if prs[0].Name != "panicker worker 0" || prs[0].Content != PanicMsg {
+		t.Errorf("got panic record %+v; want Name %q, Content %q",
+			prs[0], "panicker worker 0", PanicMsg)
+	}
+	if !ctrl.Canceler().Canceled() {
+		t.Error("pipeline not canceled")
+	}
+}
+
+func TestController_Cancel(t *testing.T) {
+	ctrl := pipeline.New(pipeline.First(pipeline.Stage[int, int]{
+		NumWorker: 2,
+		Handler: func(canceler concurrency.Canceler, rank, x int) []int {
+			<-canceler.C()
+			return []int{x}
+		},
+	}), nil, 1, 2, 3, 4)
+	ctrl.Launch()
+	ctrl.Canceler().Cancel()
+	if n := ctrl.Wait(); n != 0 {
+		t.Errorf("got %d panic goroutines; want 0", n)
+	}
+}
+
+func TestFirst_NilHandler(t *testing.T) {
+	defer func() {
+		if e := recover(); e == nil {
+			t.Error("want panic but not")
+		}
+	}()
+	pipeline.First(pipeline.Stage[int, int]{})
+}
@@ -0,0 +1,36 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package pipeline provides a framework for multi-stage pipelines.
+//
+// A pipeline consists of a sequence of stages.
+// Each stage processes items on its own worker goroutines
+// and passes the results to the next stage through a bounded channel,
+// which provides backpressure: a stage blocks when the next stage
+// cannot keep up.
+// The results of the last stage are passed to a sink function.
+//
+// To use this framework, you should start with the function First
+// to declare the first stage and the function Then to append
+// subsequent stages.
+// Then, create a controller through the function New,
+// and call the Launch method to run the pipeline.
+// Finally, call the Wait method to wait for the pipeline to finish.
+// Or simply, you can start with the function Run, which combines New,
+// Launch, and Wait together.
+package pipeline
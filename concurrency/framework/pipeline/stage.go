@@ -0,0 +1,217 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pipeline
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"sync"
+
+	"github.com/donyori/gogo/concurrency"
+	"github.com/donyori/gogo/concurrency/framework"
+	"github.com/donyori/gogo/errors"
+)
+
+// Stage is the declaration of a stage of a pipeline.
+//
+// The first type parameter In is the type of input items of the stage.
+// The second type parameter Out is the type of output items of the stage.
+type Stage[In, Out any] struct {
+	// The name of the stage, used in the panic records.
+	// If it is empty, "stage <i>" is used,
+	// where <i> is the index of the stage in the pipeline (starting from 0).
+	Name string
+
+	// The number of worker goroutines of the stage.
+	// Nonpositive values for using max(1, runtime.NumCPU()-2).
+	NumWorker int
+
+	// The buffer size of the output channel of the stage.
+	// Nonpositive values for no buffer.
+	BufSize int
+
+	// The function to process an input item.
+	//
+	// Its first parameter is a canceler to interrupt the whole pipeline.
+	// Its second parameter is the rank of the worker goroutine
+	// (from 0 to NumWorker-1) to identify the goroutine uniquely
+	// within the stage.
+	// Its third parameter is the input item.
+	//
+	// It returns the output items,
+	// which are sent to the next stage (or the sink) in order.
+	// It can return no output items to filter out the input item,
+	// or multiple output items to split the input item.
+	//
+	// The client is responsible for guaranteeing that
+	// this function is safe for concurrency.
+	// It must not call the method Input of the controller.
+	Handler func(canceler concurrency.Canceler, rank int, x In) []Out
+}
+
+// Builder is a builder for a pipeline whose first stage takes items
+// of type In and last stage produces items of type Out.
+//
+// A Builder is created by the function First
+// and extended by the function Then.
+// A Builder is immutable: Then returns a new Builder
+// and leaves the original one unchanged.
+type Builder[In, Out any] struct {
+	numStage     int // The number of stages.
+	numGoroutine int // The total number of worker goroutines of the stages.
+
+	// connect starts the worker goroutines of the stages
+	// reading from in, and returns the output channel of the last stage.
+	connect func(e *env, in <-chan In) <-chan Out
+}
+
+// First creates a Builder with s as the first stage.
+//
+// It panics if s.Handler is nil.
+func First[In, Out any](s Stage[In, Out]) *Builder[In, Out] {
+	n := checkStage(&s, 0)
+	return &Builder[In, Out]{
+		numStage:     1,
+		numGoroutine: n,
+		connect: func(e *env, in <-chan In) <-chan Out {
+			return startStage(e, &s, n, in)
+		},
+	}
+}
+
+// Then creates a new Builder by appending s to the stages of b.
+//
+// It panics if b is nil or s.Handler is nil.
+func Then[In, Mid, Out any](
+	b *Builder[In, Mid],
+	s Stage[Mid, Out],
+) *Builder[In, Out] {
+	if b == nil {
+		panic(errors.AutoMsg("builder is nil"))
+	}
+	n := checkStage(&s, b.numStage)
+	connect := b.connect
+	return &Builder[In, Out]{
+		numStage:     b.numStage + 1,
+		numGoroutine: b.numGoroutine + n,
+		connect: func(e *env, in <-chan In) <-chan Out {
+			return startStage(e, &s, n, connect(e, in))
+		},
+	}
+}
+
+// NumStage returns the number of stages.
+func (b *Builder[In, Out]) NumStage() int {
+	return b.numStage
+}
+
+// env is the environment for starting the goroutines of a pipeline.
+type env struct {
+	c  concurrency.Canceler                        // Canceler.
+	wg *sync.WaitGroup                             // Wait group for the goroutines.
+	pr concurrency.Recorder[framework.PanicRecord] // Panic recorder.
+}
+
+// checkStage panics if s.Handler is nil, sets s.Name to "stage <idx>"
+// if it is empty, and returns the normalized number of worker goroutines.
+func checkStage[In, Out any](s *Stage[In, Out], idx int) int {
+	if s.Handler == nil {
+		panic(errors.AutoMsg("handler of stage " + strconv.Itoa(idx) + " is nil"))
+	}
+	if s.Name == "" {
+		s.Name = "stage " + strconv.Itoa(idx)
+	}
+	n := s.NumWorker
+	if n <= 0 {
+		n = runtime.NumCPU() - 2
+		if n < 1 {
+			n = 1
+		}
+	}
+	return n
+}
+
+// startStage starts n worker goroutines of stage s reading from in,
+// and returns the output channel of the stage.
+//
+// The output channel is closed after all the worker goroutines exit.
+func startStage[In, Out any](
+	e *env,
+	s *Stage[In, Out],
+	n int,
+	in <-chan In,
+) <-chan Out {
+	out := make(chan Out, max(s.BufSize, 0))
+	var swg sync.WaitGroup // wait group for the worker goroutines of the stage
+	swg.Add(n)
+	e.wg.Add(n + 1) // n workers + 1 output channel closer
+	for rank := range n {
+		go func() { // goroutine for worker
+			defer e.wg.Done()
+			defer swg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					e.c.Cancel()
+					e.pr.Record(framework.PanicRecord{
+						Name:       s.Name + " worker " + strconv.Itoa(rank),
+						Content:    r,
+						StackTrace: string(debug.Stack()),
+					})
+				}
+			}()
+			stageWorkerProc(e.c, s, rank, in, out)
+		}()
+	}
+	go func() { // goroutine for closing output channel
+		defer e.wg.Done()
+		swg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// stageWorkerProc is the main process of a worker goroutine of stage s,
+// without panic checking and wait group operations.
+func stageWorkerProc[In, Out any](
+	c concurrency.Canceler,
+	s *Stage[In, Out],
+	rank int,
+	in <-chan In,
+	out chan<- Out,
+) {
+	cancelChan := c.C()
+	for {
+		select {
+		case <-cancelChan:
+			return
+		case x, ok := <-in:
+			if !ok {
+				return
+			}
+			for _, y := range s.Handler(c, rank, x) {
+				select {
+				case <-cancelChan:
+					return
+				case out <- y:
+				}
+			}
+		}
+	}
+}
@@ -20,9 +20,11 @@ package spmd
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/donyori/gogo/concurrency"
+	"github.com/donyori/gogo/concurrency/framework"
 	"github.com/donyori/gogo/container/sequence"
 	"github.com/donyori/gogo/container/sequence/array"
 	"github.com/donyori/gogo/errors"
@@ -140,6 +142,39 @@ type Communicator[Message any] interface {
 	// the method Send, use the method ReceivePublic instead.
 	ReceiveAny() (src int, msg Message)
 
+	// ISend starts sending the message msg to another goroutine
+	// without blocking, and returns a request handle
+	// to test or wait for the completion of the operation.
+	//
+	// It allows the current goroutine to overlap communication
+	// and computation.
+	// It panics if dst is out of range or
+	// the destination goroutine is the sender itself.
+	//
+	// Messages sent to the same destination through ISend are
+	// received in the order in which ISend is called.
+	// There is no such guarantee between ISend and Send.
+	//
+	// dst is the rank of the destination goroutine.
+	// msg is the message to be sent.
+	ISend(dst int, msg Message) SendRequest
+
+	// IRecv starts receiving a message from another goroutine
+	// without blocking, and returns a request handle
+	// to test or wait for the completion of the operation.
+	//
+	// It allows the current goroutine to overlap communication
+	// and computation.
+	// It panics if src is out of range or
+	// the source goroutine is the receiver itself.
+	//
+	// Receive operations from the same source started through IRecv
+	// get the messages in the order in which IRecv is called.
+	// There is no such guarantee between IRecv and Receive.
+	//
+	// src is the rank of the source goroutine.
+	IRecv(src int) ReceiveRequest[Message]
+
 	// Barrier blocks until all other goroutines in this group call
 	// method Barrier of their own communicators,
 	// or a cancellation signal is detected.
@@ -151,6 +186,32 @@ type Communicator[Message any] interface {
 	// i.e., consistent in the execution progress.
 	Barrier() bool
 
+	// BarrierWithTimeout is like Barrier but gives up waiting
+	// for other goroutines after the duration d,
+	// so that a stuck goroutine cannot block the whole group forever.
+	//
+	// If it gives up, the barrier of this group cannot complete anymore.
+	// Therefore, it cancels the job,
+	// records a panic record with an error wrapping ErrBarrierTimeout
+	// (without panicking), and returns false.
+	// Other goroutines then detect the cancellation signal.
+	//
+	// If d is nonpositive, it is equivalent to Barrier.
+	BarrierWithTimeout(d time.Duration) bool
+
+	// BarrierWithCanceler is like Barrier but gives up waiting
+	// for other goroutines when canceler broadcasts
+	// a cancellation signal.
+	//
+	// If it gives up, the barrier of this group cannot complete anymore.
+	// Therefore, it cancels the job,
+	// records a panic record with an error wrapping ErrBarrierCanceled
+	// (without panicking), and returns false.
+	// Other goroutines then detect the cancellation signal.
+	//
+	// It panics if canceler is nil.
+	BarrierWithCanceler(canceler concurrency.Canceler) bool
+
 	// Broadcast sends the message x from the root to others in this group.
 	//
 	// The method does not wait for all goroutines to finish the broadcast.
@@ -203,6 +264,84 @@ type Communicator[Message any] interface {
 	// For others, x is nil.
 	// ok is false if and only if a cancellation signal is detected.
 	Gather(root int, msg Message) (x []Message, ok bool)
+
+	// AllGather collects messages from all goroutines in this group
+	// to every goroutine in this group.
+	//
+	// The method does not wait for all goroutines to finish the gathering.
+	// To synchronize all goroutines, use method Barrier.
+	//
+	// msg is the message sent to the other goroutines.
+	//
+	// It returns the gathered messages as a list x, and an indicator ok.
+	// x is the list of messages ordered by the ranks of sender goroutines.
+	// ok is false if and only if a cancellation signal is detected.
+	AllGather(msg Message) (x []Message, ok bool)
+
+	// Reduce combines messages from all goroutines (including the root)
+	// in this group into one message on the root.
+	//
+	// The method does not wait for all goroutines to finish the reduction.
+	// To synchronize all goroutines, use method Barrier.
+	//
+	// root is the rank of the receiver goroutine in this group.
+	// It panics if root is out of range.
+	//
+	// msg is the message to be combined.
+	//
+	// combine is a function to combine two messages into one.
+	// The messages are combined in the order of the ranks of
+	// their sender goroutines, i.e.,
+	// combine(...combine(combine(m0, m1), m2)..., m(n-1)),
+	// where mi is the message of the goroutine of rank i,
+	// and n = NumGoroutine().
+	// Therefore, combine needs not be commutative.
+	// It is called only on the root.
+	// Reduce panics if combine is nil.
+	//
+	// It returns the combined message x and an indicator ok.
+	// For others, x is the zero value.
+	// ok is false if and only if a cancellation signal is detected.
+	Reduce(root int, msg Message, combine func(a, b Message) Message) (
+		x Message, ok bool)
+
+	// AllReduce combines messages from all goroutines in this group
+	// into one message, and then sends it to every goroutine in this group.
+	//
+	// The method does not wait for all goroutines to finish the reduction.
+	// To synchronize all goroutines, use method Barrier.
+	//
+	// msg and combine are the same as those of method Reduce.
+	// combine is called only on the goroutine of rank 0.
+	// AllReduce panics if combine is nil.
+	//
+	// It returns the combined message x and an indicator ok.
+	// ok is false if and only if a cancellation signal is detected.
+	AllReduce(msg Message, combine func(a, b Message) Message) (
+		x Message, ok bool)
+
+	// Split divides the goroutines in this group into subgroups
+	// and returns the communicator of the subgroup
+	// containing the current goroutine.
+	//
+	// All goroutines in this group must call Split.
+	// The method blocks until all goroutines in this group call Split,
+	// or a cancellation signal is detected.
+	//
+	// color specifies the subgroup of the current goroutine.
+	// The goroutines with the same color are put into the same subgroup.
+	// If color is negative, the current goroutine is not put into
+	// any subgroup, and Split returns a nil communicator.
+	//
+	// key determines the rank of the current goroutine in the subgroup.
+	// The goroutines in a subgroup are ranked in ascending order of
+	// their keys, and then in ascending order of their ranks in this group.
+	//
+	// The new communicator shares the canceler of the job with this one.
+	//
+	// It returns the new communicator and an indicator ok.
+	// ok is false if and only if a cancellation signal is detected.
+	Split(color, key int) (newComm Communicator[Message], ok bool)
 }
 
 // communicator is an implementation of interface Communicator.
@@ -212,12 +351,16 @@ type communicator[Message any] struct {
 	scdc chan []chan array.Array[Message] // Channel for receiving channel lists from the channel dispatcher for method Scatter.
 	gcdc chan chan *sndrMsg[Message]      // Channel for receiving channels from the channel dispatcher for method Gather.
 
-	rank int                // The rank of current goroutine.
-	pcs  []chan Message     // List of channels for point-to-point communication.
-	bc   chan chan struct{} // Channel for method Barrier.
-	bCtr int64              // Counter to specify a Broadcast communication uniquely.
-	sCtr int64              // Counter to specify a Scatter communication uniquely.
-	gCtr int64              // Counter to specify a Gather communication uniquely.
+	rank  int                // The rank of current goroutine.
+	pcs   []chan Message     // List of channels for point-to-point communication.
+	bc    chan chan struct{} // Channel for method Barrier.
+	bCtr  int64              // Counter to specify a Broadcast communication uniquely.
+	sCtr  int64              // Counter to specify a Scatter communication uniquely.
+	gCtr  int64              // Counter to specify a Gather communication uniquely.
+	spCtr int64              // Counter to specify a Split communication uniquely.
+
+	isCs []<-chan struct{} // Done channels of the last ISend requests to each peer, initially nil.
+	irCs []<-chan struct{} // Done channels of the last IRecv requests from each peer, initially nil.
 }
 
 // sndrMsg is a combination of the sender's rank and message.
@@ -268,7 +411,7 @@ func (comm *communicator[Message]) NumGoroutine() int {
 	return len(comm.ctx.comms)
 }
 
-func (comm *communicator[Message]) Send(dst int, msg Message) bool {
+func (comm *communicator[Message]) Send(dst int, msg Message) (ok bool) {
 	if comm.rank == dst {
 		panic(errors.AutoMsg("dst is the sender itself"))
 	}
@@ -276,6 +419,11 @@ func (comm *communicator[Message]) Send(dst int, msg Message) bool {
 	if idx > dst {
 		idx--
 	}
+	if done := comm.trace(TraceSend, dst); done != nil {
+		defer func() {
+			done(ok)
+		}()
+	}
 	select {
 	case <-comm.ctx.ctrl.c.C():
 		return false
@@ -292,6 +440,11 @@ func (comm *communicator[Message]) Receive(src int) (msg Message, ok bool) {
 	if idx > comm.rank {
 		idx--
 	}
+	if done := comm.trace(TraceReceive, src); done != nil {
+		defer func() {
+			done(ok)
+		}()
+	}
 	select {
 	case <-comm.ctx.ctrl.c.C():
 	case msg = <-comm.pcs[idx]:
@@ -459,10 +612,104 @@ func (comm *communicator[Message]) ReceiveAny() (src int, msg Message) {
 	}
 }
 
+func (comm *communicator[Message]) ISend(dst int, msg Message) SendRequest {
+	idx := comm.peerIndex(dst, "dst")
+	if comm.isCs == nil {
+		comm.isCs = make([]<-chan struct{}, len(comm.pcs))
+	}
+	prev := comm.isCs[idx]
+	r := &sendRequest{doneC: make(chan struct{})}
+	comm.isCs[idx] = r.doneC
+	go func() {
+		defer close(r.doneC)
+		if prev != nil {
+			<-prev // keep the order of messages to the same destination
+		}
+		r.ok = comm.Send(dst, msg)
+	}()
+	return r
+}
+
+func (comm *communicator[Message]) IRecv(src int) ReceiveRequest[Message] {
+	idx := comm.peerIndex(src, "src")
+	if comm.irCs == nil {
+		comm.irCs = make([]<-chan struct{}, len(comm.pcs))
+	}
+	prev := comm.irCs[idx]
+	r := &receiveRequest[Message]{doneC: make(chan struct{})}
+	comm.irCs[idx] = r.doneC
+	go func() {
+		defer close(r.doneC)
+		if prev != nil {
+			<-prev // keep the order of messages from the same source
+		}
+		r.msg, r.ok = comm.Receive(src)
+	}()
+	return r
+}
+
 func (comm *communicator[Message]) Barrier() bool {
+	ok, _ := comm.barrier(nil)
+	return ok
+}
+
+func (comm *communicator[Message]) BarrierWithTimeout(d time.Duration) bool {
+	if d <= 0 {
+		return comm.Barrier()
+	}
+	timeoutC := make(chan struct{})
+	timer := time.AfterFunc(d, func() {
+		close(timeoutC)
+	})
+	defer timer.Stop()
+	return comm.barrierOrAbort(timeoutC, ErrBarrierTimeout)
+}
+
+func (comm *communicator[Message]) BarrierWithCanceler(
+	canceler concurrency.Canceler) bool {
+	if canceler == nil {
+		panic(errors.AutoMsg("canceler is nil"))
+	}
+	return comm.barrierOrAbort(canceler.C(), ErrBarrierCanceled)
+}
+
+// barrierOrAbort calls comm.barrier with giveUpC.
+//
+// If comm.barrier gives up, barrierOrAbort cancels the job and
+// records a panic record with an error wrapping err.
+//
+// It returns the indicator ok returned by comm.barrier.
+func (comm *communicator[Message]) barrierOrAbort(
+	giveUpC <-chan struct{},
+	err error,
+) bool {
+	ok, gaveUp := comm.barrier(giveUpC)
+	if gaveUp {
+		ctrl := comm.ctx.ctrl
+		ctrl.c.Cancel()
+		ctrl.pr.Record(framework.PanicRecord{
+			Name:    strconv.Itoa(comm.ctx.worldRanks[comm.rank]),
+			Content: fmt.Errorf("group %s, rank %d: %w", comm.ctx.id, comm.rank, err),
+		})
+	}
+	return ok
+}
+
+// barrier is the implementation of method Barrier.
+//
+// It gives up waiting for other goroutines when giveUpC is closed
+// (giveUpC can be nil to never give up).
+// In this case, it returns ok as false and gaveUp as true.
+func (comm *communicator[Message]) barrier(giveUpC <-chan struct{}) (
+	ok, gaveUp bool) {
+	if done := comm.trace(TraceBarrier, -1); done != nil {
+		defer func() {
+			done(ok)
+		}()
+	}
 	n := len(comm.ctx.comms)
 	if n <= 1 {
-		return !comm.ctx.ctrl.c.Canceled()
+		return !comm.ctx.ctrl.c.Canceled(), false
 	}
 	var c chan struct{}
 	cancelChan := comm.ctx.ctrl.c.C()
@@ -474,7 +721,9 @@ func (comm *communicator[Message]) Barrier() bool {
 		// from their respective previous goroutines.
 		select {
 		case <-cancelChan:
-			return false
+			return false, false
+		case <-giveUpC:
+			return false, !comm.ctx.ctrl.c.Canceled()
 		case c = <-comm.ctx.comms[comm.rank].bc: // listen on its own channel, not the sender's!
 		}
 	}
@@ -487,22 +736,32 @@ func (comm *communicator[Message]) Barrier() bool {
 		// to their respective next goroutines.
 		select {
 		case <-cancelChan:
-			return false
+			return false, false
+		case <-giveUpC:
+			return false, !comm.ctx.ctrl.c.Canceled()
 		case comm.ctx.comms[comm.rank+1].bc <- c: // send it to the receiver's channel
 		}
 		// Then listen on the signal channel.
 		select {
 		case <-cancelChan:
-			return false
+			return false, false
+		case <-giveUpC:
+			return false, !comm.ctx.ctrl.c.Canceled()
 		case <-c:
 		}
 	}
-	return true
+	return true, false
 }
 
 func (comm *communicator[Message]) Broadcast(root int, x Message) (
 	msg Message, ok bool) {
-	if comm.checkRootAndN(root) {
+	noOther := comm.checkRootAndN(root)
+	if done := comm.trace(TraceBroadcast, root); done != nil {
+		defer func() {
+			done(ok)
+		}()
+	}
+	if noOther {
 		// No other goroutines in this group.
 		ok = !comm.ctx.ctrl.c.Canceled()
 		if ok {
@@ -707,6 +966,124 @@ func (comm *communicator[Message]) Gather(root int, msg Message) (
 	return x, true
 }
 
+func (comm *communicator[Message]) AllGather(msg Message) (
+	x []Message, ok bool) {
+	x, ok = comm.Gather(0, msg)
+	if !ok {
+		return nil, false
+	}
+	n := len(comm.ctx.comms)
+	if comm.rank != 0 {
+		x = make([]Message, n)
+	}
+	for i := range n {
+		// The goroutine of rank 0 broadcasts the gathered messages
+		// one by one.
+		x[i], ok = comm.Broadcast(0, x[i])
+		if !ok {
+			return nil, false
+		}
+	}
+	return x, true
+}
+
+func (comm *communicator[Message]) Reduce(
+	root int,
+	msg Message,
+	combine func(a, b Message) Message,
+) (x Message, ok bool) {
+	if combine == nil {
+		panic(errors.AutoMsg("combine is nil"))
+	}
+	ms, ok := comm.Gather(root, msg)
+	if !ok || comm.rank != root {
+		return
+	}
+	x = ms[0]
+	for i := 1; i < len(ms); i++ {
+		x = combine(x, ms[i])
+	}
+	return x, true
+}
+
+func (comm *communicator[Message]) AllReduce(
+	msg Message,
+	combine func(a, b Message) Message,
+) (x Message, ok bool) {
+	x, ok = comm.Reduce(0, msg, combine)
+	if !ok {
+		return
+	}
+	return comm.Broadcast(0, x)
+}
+
+func (comm *communicator[Message]) Split(color, key int) (
+	newComm Communicator[Message], ok bool) {
+	ctx, n := comm.ctx, len(comm.ctx.comms)
+	ctr := comm.spCtr
+	comm.spCtr++ // update counter before communication
+
+	ctx.sm.Lock()
+	if ctx.splitMap == nil {
+		ctx.splitMap = make(map[int64]*splitState[Message])
+	}
+	st := ctx.splitMap[ctr]
+	if st == nil {
+		st = &splitState[Message]{
+			colors: make([]int, n),
+			keys:   make([]int, n),
+			left:   n,
+			done:   make(chan struct{}),
+		}
+		ctx.splitMap[ctr] = st
+	}
+	st.colors[comm.rank], st.keys[comm.rank] = color, key
+	st.arrived++
+	if st.arrived == n {
+		// The last goroutine creates the new communicators.
+		st.comms = ctx.split(ctr, st.colors, st.keys)
+		close(st.done)
+	}
+	ctx.sm.Unlock()
+
+	select {
+	case <-ctx.ctrl.c.C():
+		return
+	case <-st.done:
+	}
+	ctx.sm.Lock()
+	st.left--
+	if st.left == 0 {
+		delete(ctx.splitMap, ctr)
+	}
+	ctx.sm.Unlock()
+	if c := st.comms[comm.rank]; c != nil {
+		newComm = c
+	}
+	return newComm, true
+}
+
+// peerIndex returns the index of the point-to-point channel
+// corresponding to the goroutine of the specified rank.
+//
+// It panics if rank is out of range or equal to the rank
+// of the current goroutine.
+// name is the parameter name of rank used in the panic message.
+func (comm *communicator[Message]) peerIndex(rank int, name string) int {
+	n := len(comm.ctx.comms)
+	if rank < 0 || rank >= n {
+		panic(errors.AutoMsgCustom(
+			fmt.Sprintf("%s %d is out of range (n: %d)", name, rank, n), -1, 1))
+	} else if rank == comm.rank {
+		panic(errors.AutoMsgCustom(
+			fmt.Sprintf("%s is the current goroutine itself", name), -1, 1))
+	}
+	if rank > comm.rank {
+		return rank - 1
+	}
+	return rank
+}
+
 // checkRootAndN panics if root is out of range.
 // It returns true if and only if comm.NumGoroutine() <= 1.
 func (comm *communicator[Message]) checkRootAndN(root int) bool {
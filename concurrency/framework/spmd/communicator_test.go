@@ -19,9 +19,12 @@
 package spmd_test
 
 import (
+	"errors"
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/donyori/gogo/concurrency"
 	"github.com/donyori/gogo/concurrency/framework/spmd"
 	"github.com/donyori/gogo/container/sequence/array"
 )
@@ -290,6 +293,79 @@ func TestCommunicator_Barrier(t *testing.T) {
 	}
 }
 
+func TestCommunicator_BarrierWithTimeout(t *testing.T) {
+	prs := spmd.Run(4, func(
+		world spmd.Communicator[int],
+		commMap map[string]spmd.Communicator[int],
+	) {
+		r := world.Rank()
+		time.Sleep(time.Millisecond * time.Duration(r))
+		if !world.BarrierWithTimeout(time.Minute) {
+			t.Errorf("goroutine %d, BarrierWithTimeout returned false", r)
+		}
+	}, nil)
+	if len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+}
+
+func TestCommunicator_BarrierWithTimeout_Stuck(t *testing.T) {
+	const StuckRank = 2
+	var results [4]bool
+	prs := spmd.Run(4, func(
+		world spmd.Communicator[int],
+		commMap map[string]spmd.Communicator[int],
+	) {
+		r := world.Rank()
+		if r == StuckRank {
+			<-world.Canceler().C() // stuck until the job is canceled
+			return
+		}
+		results[r] = world.BarrierWithTimeout(10 * time.Millisecond)
+	}, nil)
+	for r, ok := range results {
+		if ok {
+			t.Errorf("goroutine %d, BarrierWithTimeout returned true", r)
+		}
+	}
+	if len(prs) == 0 {
+		t.Fatal("no panic records")
+	}
+	for _, pr := range prs {
+		err, ok := pr.Content.(error)
+		if !ok || !errors.Is(err, spmd.ErrBarrierTimeout) {
+			t.Errorf("got panic record %v; want an error wrapping ErrBarrierTimeout", pr)
+		}
+	}
+}
+
+func TestCommunicator_BarrierWithCanceler(t *testing.T) {
+	c := concurrency.NewCanceler()
+	prs := spmd.Run(3, func(
+		world spmd.Communicator[int],
+		commMap map[string]spmd.Communicator[int],
+	) {
+		r := world.Rank()
+		if r == 0 {
+			c.Cancel()
+			<-world.Canceler().C() // never call the barrier
+			return
+		}
+		if world.BarrierWithCanceler(c) {
+			t.Errorf("goroutine %d, BarrierWithCanceler returned true", r)
+		}
+	}, nil)
+	if len(prs) == 0 {
+		t.Fatal("no panic records")
+	}
+	for _, pr := range prs {
+		err, ok := pr.Content.(error)
+		if !ok || !errors.Is(err, spmd.ErrBarrierCanceled) {
+			t.Errorf("got panic record %v; want an error wrapping ErrBarrierCanceled", pr)
+		}
+	}
+}
+
 func TestCommunicator_Broadcast(t *testing.T) {
 	data := [][4]any{
 		{1},
@@ -431,3 +507,253 @@ func TestCommunicator_Gather(t *testing.T) {
 		t.Errorf("gather channel map is not clean: %d element(s) remained", n)
 	}
 }
+
+func TestCommunicator_AllGather(t *testing.T) {
+	for _, n := range []int{1, 2, 5} {
+		ctrl := spmd.New(n, func(
+			world spmd.Communicator[int],
+			commMap map[string]spmd.Communicator[int],
+		) {
+			r := world.Rank()
+			for round := range 3 {
+				x, ok := world.AllGather(r*10 + round)
+				if !ok {
+					t.Errorf("n=%d, goroutine %d, round %d, detected an unexpected cancellation signal",
+						n, r, round)
+					return
+				}
+				if len(x) != n {
+					t.Errorf("n=%d, goroutine %d, round %d, got x %v; want length %d",
+						n, r, round, x, n)
+					continue
+				}
+				for k := range x {
+					if x[k] != k*10+round {
+						t.Errorf("n=%d, goroutine %d, round %d, got x[%d] %d; want %d",
+							n, r, round, k, x[k], k*10+round)
+					}
+				}
+			}
+		}, nil)
+		ctrl.Run()
+		if prs := ctrl.PanicRecords(); len(prs) > 0 {
+			t.Errorf("n=%d, panic %q", n, prs)
+		}
+	}
+}
+
+func TestCommunicator_Reduce(t *testing.T) {
+	const N = 4
+	concat := func(a, b string) string {
+		return a + b
+	}
+	ctrl := spmd.New(N, func(
+		world spmd.Communicator[string],
+		commMap map[string]spmd.Communicator[string],
+	) {
+		r := world.Rank()
+		for root := range N {
+			x, ok := world.Reduce(root, strconv.Itoa(r), concat)
+			if !ok {
+				t.Errorf("goroutine %d, root %d, detected an unexpected cancellation signal",
+					r, root)
+				return
+			}
+			// Concatenation is not commutative,
+			// so the result shows the order of combination.
+			if r == root && x != "0123" {
+				t.Errorf("goroutine %d, root %d, got x %q; want %q",
+					r, root, x, "0123")
+			} else if r != root && x != "" {
+				t.Errorf("goroutine %d, root %d, got x %q; want empty",
+					r, root, x)
+			}
+		}
+	}, nil)
+	ctrl.Run()
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+}
+
+func TestCommunicator_AllReduce(t *testing.T) {
+	const N = 6
+	ctrl := spmd.New(N, func(
+		world spmd.Communicator[int],
+		commMap map[string]spmd.Communicator[int],
+	) {
+		r := world.Rank()
+		sum, ok := world.AllReduce(r+1, func(a, b int) int {
+			return a + b
+		})
+		if !ok {
+			t.Errorf("goroutine %d, detected an unexpected cancellation signal", r)
+		} else if sum != N*(N+1)/2 {
+			t.Errorf("goroutine %d, got sum %d; want %d", r, sum, N*(N+1)/2)
+		}
+		maxRank, ok := world.AllReduce(r, func(a, b int) int {
+			return max(a, b)
+		})
+		if !ok {
+			t.Errorf("goroutine %d, detected an unexpected cancellation signal", r)
+		} else if maxRank != N-1 {
+			t.Errorf("goroutine %d, got max %d; want %d", r, maxRank, N-1)
+		}
+	}, nil)
+	ctrl.Run()
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+}
+
+func TestCommunicator_ISend_IRecv(t *testing.T) {
+	const N, NumMsg = 5, 20
+	ctrl := spmd.New(N, func(
+		world spmd.Communicator[int],
+		commMap map[string]spmd.Communicator[int],
+	) {
+		r := world.Rank()
+		next, prev := (r+1)%N, (r+N-1)%N
+		sendReqs := make([]spmd.SendRequest, NumMsg)
+		recvReqs := make([]spmd.ReceiveRequest[int], NumMsg)
+		for i := range NumMsg {
+			sendReqs[i] = world.ISend(next, r*NumMsg+i)
+			recvReqs[i] = world.IRecv(prev)
+		}
+		for i, req := range recvReqs {
+			msg, ok := req.Wait()
+			if !ok {
+				t.Errorf("goroutine %d, receive request %d, detected an unexpected cancellation signal", r, i)
+			} else if want := prev*NumMsg + i; msg != want {
+				t.Errorf("goroutine %d, receive request %d, got %d; want %d",
+					r, i, msg, want)
+			}
+			testMsg, done, ok := req.Test()
+			if !done || !ok || testMsg != msg {
+				t.Errorf("goroutine %d, receive request %d, Test got (%d, %t, %t); want (%d, true, true)",
+					r, i, testMsg, done, ok, msg)
+			}
+		}
+		for i, req := range sendReqs {
+			if !req.Wait() {
+				t.Errorf("goroutine %d, send request %d, detected an unexpected cancellation signal", r, i)
+			}
+			if done, ok := req.Test(); !done || !ok {
+				t.Errorf("goroutine %d, send request %d, Test got (%t, %t); want (true, true)",
+					r, i, done, ok)
+			}
+		}
+	}, nil)
+	ctrl.Run()
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+}
+
+func TestCommunicator_ISend_IRecv_Cancel(t *testing.T) {
+	ctrl := spmd.New(2, func(
+		world spmd.Communicator[int],
+		commMap map[string]spmd.Communicator[int],
+	) {
+		if world.Rank() != 0 {
+			return
+		}
+		sendReq, recvReq := world.ISend(1, 1), world.IRecv(1)
+		if done, _ := sendReq.Test(); done {
+			t.Error("send request completed without a receiver")
+		}
+		if _, done, _ := recvReq.Test(); done {
+			t.Error("receive request completed without a sender")
+		}
+		world.Canceler().Cancel()
+		if sendReq.Wait() {
+			t.Error("send request succeeded after cancellation")
+		}
+		if _, ok := recvReq.Wait(); ok {
+			t.Error("receive request succeeded after cancellation")
+		}
+	}, nil)
+	ctrl.Run()
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+}
+
+func TestCommunicator_Split(t *testing.T) {
+	const NumRow, NumCol = 3, 4
+	ctrl := spmd.New(NumRow*NumCol, func(
+		world spmd.Communicator[int],
+		commMap map[string]spmd.Communicator[int],
+	) {
+		r := world.Rank()
+		row, col := r/NumCol, r%NumCol
+		rowComm, ok := world.Split(row, col)
+		if !ok {
+			t.Errorf("goroutine %d, detected an unexpected cancellation signal", r)
+			return
+		} else if rowComm == nil {
+			t.Errorf("goroutine %d, got nil row communicator", r)
+			return
+		}
+		// Reverse the order of goroutines in each column.
+		colComm, ok := world.Split(col, -row)
+		if !ok {
+			t.Errorf("goroutine %d, detected an unexpected cancellation signal", r)
+			return
+		} else if colComm == nil {
+			t.Errorf("goroutine %d, got nil column communicator", r)
+			return
+		}
+		if n := rowComm.NumGoroutine(); n != NumCol {
+			t.Errorf("goroutine %d, got row NumGoroutine %d; want %d",
+				r, n, NumCol)
+		}
+		if rank := rowComm.Rank(); rank != col {
+			t.Errorf("goroutine %d, got row rank %d; want %d", r, rank, col)
+		}
+		if n := colComm.NumGoroutine(); n != NumRow {
+			t.Errorf("goroutine %d, got column NumGoroutine %d; want %d",
+				r, n, NumRow)
+		}
+		if rank := colComm.Rank(); rank != NumRow-1-row {
+			t.Errorf("goroutine %d, got column rank %d; want %d",
+				r, rank, NumRow-1-row)
+		}
+		rowSum, ok := rowComm.AllReduce(r, func(a, b int) int {
+			return a + b
+		})
+		if !ok {
+			t.Errorf("goroutine %d, detected an unexpected cancellation signal", r)
+		} else if want := row*NumCol*NumCol + NumCol*(NumCol-1)/2; rowSum != want {
+			t.Errorf("goroutine %d, got row sum %d; want %d", r, rowSum, want)
+		}
+		colSum, ok := colComm.AllReduce(r, func(a, b int) int {
+			return a + b
+		})
+		if !ok {
+			t.Errorf("goroutine %d, detected an unexpected cancellation signal", r)
+		} else if want := col*NumRow + NumCol*NumRow*(NumRow-1)/2; colSum != want {
+			t.Errorf("goroutine %d, got column sum %d; want %d", r, colSum, want)
+		}
+		color := -1
+		if r%2 == 0 {
+			color = 0
+		}
+		evenComm, ok := world.Split(color, 0)
+		if !ok {
+			t.Errorf("goroutine %d, detected an unexpected cancellation signal", r)
+		} else if r%2 != 0 {
+			if evenComm != nil {
+				t.Errorf("goroutine %d, got non-nil communicator for negative color", r)
+			}
+		} else if evenComm == nil {
+			t.Errorf("goroutine %d, got nil communicator", r)
+		} else if rank := evenComm.Rank(); rank != r/2 {
+			t.Errorf("goroutine %d, got even rank %d; want %d", r, rank, r/2)
+		}
+	}, nil)
+	ctrl.Run()
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Errorf("panic %q", prs)
+	}
+}
@@ -18,7 +18,14 @@
 
 package spmd
 
-import "github.com/donyori/gogo/container/sequence/array"
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/donyori/gogo/container/sequence/array"
+)
 
 // bcastChanCtr is a combination of a channel used in Broadcast and a counter.
 type bcastChanCtr[Message any] struct {
@@ -52,6 +59,19 @@ type context[Message any] struct {
 	bcastMap   map[int64]*bcastChanCtr[Message]   // Channel map for Broadcast, maintained by the channel dispatcher, initially nil.
 	scatterMap map[int64]*scatterChanCtr[Message] // Channel list map for Scatter, maintained by the channel dispatcher, initially nil.
 	gatherMap  map[int64]*gatherChanCtr[Message]  // Channel map for Gather, maintained by the channel dispatcher, initially nil.
+
+	sm       sync.Mutex                     // Lock for splitMap.
+	splitMap map[int64]*splitState[Message] // Map for Split, maintained by the communicators, initially nil.
+}
+
+// splitState is the state of a Split communication.
+type splitState[Message any] struct {
+	colors  []int                    // Colors of the goroutines, indexed by their ranks.
+	keys    []int                    // Keys of the goroutines, indexed by their ranks.
+	arrived int                      // The number of goroutines that have called Split.
+	left    int                      // The number of goroutines that have not taken their results.
+	done    chan struct{}            // Channel closed when comms is ready.
+	comms   []*communicator[Message] // Resulting communicators, indexed by the ranks of the goroutines in the original group. A nil item indicates no communicator for that goroutine.
 }
 
 // newContext creates a new context.
@@ -79,3 +99,43 @@ func newContext[Message any](
 	}
 	return ctx
 }
+
+// split creates new contexts by splitting ctx according to
+// the colors and keys of its goroutines.
+//
+// The goroutines with the same nonnegative color are put in a new context,
+// ordered by their keys and then by their ranks in ctx.
+// The goroutines with a negative color are not in any new context.
+//
+// It returns the communicators of the goroutines in the new contexts,
+// indexed by the ranks of the goroutines in ctx.
+//
+// ctr is the counter of the Split communication, used to name new contexts.
+func (ctx *context[Message]) split(
+	ctr int64,
+	colors []int,
+	keys []int,
+) []*communicator[Message] {
+	groups := make(map[int][]int) // color -> ranks in ctx
+	for r, color := range colors {
+		if color >= 0 {
+			groups[color] = append(groups[color], r)
+		}
+	}
+	comms := make([]*communicator[Message], len(colors))
+	for color, ranks := range groups {
+		slices.SortStableFunc(ranks, func(a, b int) int {
+			return cmp.Compare(keys[a], keys[b])
+		})
+		worldRanks := make([]int, len(ranks))
+		for i, r := range ranks {
+			worldRanks[i] = ctx.worldRanks[r]
+		}
+		newCtx := newContext(ctx.ctrl, ctx.id+"_split"+
+			strconv.FormatInt(ctr, 10)+"_"+strconv.Itoa(color), worldRanks)
+		for i, r := range ranks {
+			comms[r] = newCtx.comms[i]
+		}
+	}
+	return comms
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"regexp"
 	"runtime"
+	"runtime/debug"
 	"strconv"
 	"sync"
 
@@ -76,6 +77,32 @@ func New[Message any](
 	biz BusinessFunc[Message],
 	groupMap map[string][]int,
 ) framework.Controller {
+	return NewWithOptions(n, biz, groupMap, nil)
+}
+
+// Options are options for creating a Controller.
+type Options struct {
+	// Tracer records the communication events of the goroutines
+	// if it is not nil.
+	//
+	// Tracing has overhead on each traced communication,
+	// so it should be enabled only for debugging.
+	Tracer *Tracer
+}
+
+// NewWithOptions is like New but with the specified options.
+//
+// If opts is nil, it uses default options,
+// which is equivalent to New.
+func NewWithOptions[Message any](
+	n int,
+	biz BusinessFunc[Message],
+	groupMap map[string][]int,
+	opts *Options,
+) framework.Controller {
+	if opts == nil {
+		opts = new(Options)
+	}
 	if biz == nil {
 		panic(errors.AutoMsg("biz is nil"))
 	} else if n <= 0 {
@@ -87,6 +114,7 @@ func New[Message any](
 		biz:          biz,
 		pr:           concurrency.NewRecorder[framework.PanicRecord](0),
 		lnchCommMaps: make([]map[string]Communicator[Message], n),
+		tr:           opts.Tracer,
 	}
 	ctrl.lo = concurrency.NewOnce(ctrl.launchProc)
 	ctrl.lcdo = concurrency.NewOnce(ctrl.launchChannelDispatcherProc)
@@ -156,6 +184,8 @@ type controller[Message any] struct {
 	// List of commMap used by method Launch,
 	// will be nil after calling Launch.
 	lnchCommMaps []map[string]Communicator[Message]
+
+	tr *Tracer // Tracer for communication events, or nil if tracing is disabled.
 }
 
 func (ctrl *controller[Message]) Canceler() concurrency.Canceler {
@@ -211,8 +241,9 @@ func (ctrl *controller[Message]) launchProc() {
 				if e := recover(); e != nil {
 					ctrl.c.Cancel()
 					ctrl.pr.Record(framework.PanicRecord{
-						Name:    strconv.Itoa(rank),
-						Content: e,
+						Name:       strconv.Itoa(rank),
+						Content:    e,
+						StackTrace: string(debug.Stack()),
 					})
 				}
 			}()
@@ -232,8 +263,9 @@ func (ctrl *controller[Message]) launchChannelDispatcherProc() {
 			if e := recover(); e != nil {
 				ctrl.c.Cancel()
 				ctrl.pr.Record(framework.PanicRecord{
-					Name:    "channel_dispatcher",
-					Content: e,
+					Name:       "channel_dispatcher",
+					Content:    e,
+					StackTrace: string(debug.Stack()),
 				})
 			}
 		}()
@@ -0,0 +1,51 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package spmd
+
+import "github.com/donyori/gogo/errors"
+
+// ErrBarrierTimeout is an error indicating that a goroutine
+// gave up waiting for the other goroutines in a barrier
+// because the timeout expired.
+//
+// It is recorded in the panic records of the controller
+// by the method BarrierWithTimeout of Communicator.
+//
+// The client should use errors.Is to test whether an error
+// is ErrBarrierTimeout.
+var ErrBarrierTimeout = errors.AutoNewCustom(
+	"barrier timeout",
+	errors.PrependFullPkgName,
+	0,
+)
+
+// ErrBarrierCanceled is an error indicating that a goroutine
+// gave up waiting for the other goroutines in a barrier
+// because the client's canceler broadcast the cancellation signal.
+//
+// It is recorded in the panic records of the controller
+// by the method BarrierWithCanceler of Communicator.
+//
+// The client should use errors.Is to test whether an error
+// is ErrBarrierCanceled.
+var ErrBarrierCanceled = errors.AutoNewCustom(
+	"barrier canceled",
+	errors.PrependFullPkgName,
+	0,
+)
@@ -0,0 +1,101 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package spmd
+
+// SendRequest is a handle of a non-blocking send operation
+// started by the method ISend of Communicator.
+type SendRequest interface {
+	// Test reports whether the send operation has completed,
+	// without blocking.
+	//
+	// done is true if the operation has completed.
+	// ok is true if the operation has completed successfully,
+	// and false if it has not completed or has failed
+	// (e.g., a cancellation signal is detected).
+	Test() (done, ok bool)
+
+	// Wait blocks until the send operation completes.
+	//
+	// It returns true if the operation succeeds,
+	// otherwise (e.g., a cancellation signal is detected), false.
+	Wait() bool
+}
+
+// ReceiveRequest is a handle of a non-blocking receive operation
+// started by the method IRecv of Communicator.
+type ReceiveRequest[Message any] interface {
+	// Test reports whether the receive operation has completed,
+	// without blocking.
+	//
+	// done is true if the operation has completed.
+	// ok is true if the operation has completed successfully,
+	// and false if it has not completed or has failed
+	// (e.g., a cancellation signal is detected).
+	// msg is the received message if ok is true,
+	// and the zero value otherwise.
+	Test() (msg Message, done, ok bool)
+
+	// Wait blocks until the receive operation completes.
+	//
+	// It returns the received message and an indicator ok.
+	// ok is true if the operation succeeds,
+	// otherwise (e.g., a cancellation signal is detected), false.
+	Wait() (msg Message, ok bool)
+}
+
+// sendRequest is an implementation of interface SendRequest.
+type sendRequest struct {
+	doneC chan struct{} // Channel closed when the operation completes.
+	ok    bool          // Result of the operation, valid after doneC is closed.
+}
+
+func (r *sendRequest) Test() (done, ok bool) {
+	select {
+	case <-r.doneC:
+		return true, r.ok
+	default:
+		return
+	}
+}
+
+func (r *sendRequest) Wait() bool {
+	<-r.doneC
+	return r.ok
+}
+
+// receiveRequest is an implementation of interface ReceiveRequest.
+type receiveRequest[Message any] struct {
+	doneC chan struct{} // Channel closed when the operation completes.
+	msg   Message       // Received message, valid after doneC is closed.
+	ok    bool          // Result of the operation, valid after doneC is closed.
+}
+
+func (r *receiveRequest[Message]) Test() (msg Message, done, ok bool) {
+	select {
+	case <-r.doneC:
+		return r.msg, true, r.ok
+	default:
+		return
+	}
+}
+
+func (r *receiveRequest[Message]) Wait() (msg Message, ok bool) {
+	<-r.doneC
+	return r.msg, r.ok
+}
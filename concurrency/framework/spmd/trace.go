@@ -0,0 +1,191 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package spmd
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// TraceEventKind is the kind of communication recorded by Tracer.
+type TraceEventKind string
+
+const (
+	// TraceSend is the kind of events recorded by the method Send
+	// (including that called by the method ISend) of Communicator.
+	TraceSend TraceEventKind = "send"
+
+	// TraceReceive is the kind of events recorded by the method Receive
+	// (including that called by the method IRecv) of Communicator.
+	TraceReceive TraceEventKind = "receive"
+
+	// TraceBarrier is the kind of events recorded by the methods
+	// Barrier, BarrierWithTimeout, and BarrierWithCanceler of Communicator.
+	TraceBarrier TraceEventKind = "barrier"
+
+	// TraceBroadcast is the kind of events recorded by the method Broadcast
+	// (including those called by other collective operations)
+	// of Communicator.
+	TraceBroadcast TraceEventKind = "broadcast"
+)
+
+// TraceEvent is a communication event recorded by Tracer.
+type TraceEvent struct {
+	Kind      TraceEventKind `json:"kind"`       // Kind of the communication.
+	Group     string         `json:"group"`      // ID of the goroutine group, "_world" for the world group.
+	Rank      int            `json:"rank"`       // Rank of the goroutine in the group.
+	WorldRank int            `json:"world_rank"` // Rank of the goroutine in the world group.
+	Peer      int            `json:"peer"`       // Rank in the group of the destination (for send), the source (for receive), or the root (for broadcast); -1 for barrier.
+	Start     time.Time      `json:"start"`      // Time when the communication starts.
+	End       time.Time      `json:"end"`        // Time when the communication ends.
+	OK        bool           `json:"ok"`         // Indicator of whether the communication succeeds.
+}
+
+// Tracer records the communication events of the goroutines
+// for debugging load imbalance and deadlocks.
+//
+// To enable tracing, set the option Tracer when creating the Controller
+// through the function NewWithOptions.
+// A Tracer can be shared by multiple Controllers.
+//
+// It is safe for concurrent use by multiple goroutines.
+type Tracer struct {
+	m      sync.Mutex
+	events []TraceEvent
+}
+
+// NewTracer creates a new Tracer.
+func NewTracer() *Tracer {
+	return new(Tracer)
+}
+
+// Events returns a copy of the recorded events,
+// in the order in which the communications end.
+func (t *Tracer) Events() []TraceEvent {
+	t.m.Lock()
+	defer t.m.Unlock()
+	if len(t.events) == 0 {
+		return nil
+	}
+	events := make([]TraceEvent, len(t.events))
+	copy(events, t.events)
+	return events
+}
+
+// Reset discards all the recorded events.
+func (t *Tracer) Reset() {
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.events = nil
+}
+
+// WriteJSON writes the recorded events to w as a JSON array.
+func (t *Tracer) WriteJSON(w io.Writer) error {
+	events := t.Events()
+	if events == nil {
+		events = []TraceEvent{}
+	}
+	return errors.AutoWrap(json.NewEncoder(w).Encode(events))
+}
+
+// chromeTraceEvent is an event in the Chrome trace event format.
+type chromeTraceEvent struct {
+	Name string         `json:"name"`
+	Cat  string         `json:"cat"`
+	Ph   string         `json:"ph"`
+	Ts   float64        `json:"ts"`
+	Dur  float64        `json:"dur"`
+	Pid  int            `json:"pid"`
+	Tid  int            `json:"tid"`
+	Args map[string]any `json:"args"`
+}
+
+// WriteChromeTrace writes the recorded events to w
+// in the Chrome trace event format,
+// which can be viewed in chrome://tracing or Perfetto.
+//
+// Each goroutine is shown as a thread identified by its world rank.
+// The timestamps are relative to the start of the earliest event.
+func (t *Tracer) WriteChromeTrace(w io.Writer) error {
+	events := t.Events()
+	var base time.Time
+	for i := range events {
+		if base.IsZero() || events[i].Start.Before(base) {
+			base = events[i].Start
+		}
+	}
+	ctes := make([]chromeTraceEvent, len(events))
+	for i := range events {
+		e := &events[i]
+		ctes[i] = chromeTraceEvent{
+			Name: string(e.Kind),
+			Cat:  e.Group,
+			Ph:   "X",
+			Ts:   float64(e.Start.Sub(base).Nanoseconds()) / 1e3,
+			Dur:  float64(e.End.Sub(e.Start).Nanoseconds()) / 1e3,
+			Tid:  e.WorldRank,
+			Args: map[string]any{
+				"group": e.Group,
+				"rank":  e.Rank,
+				"peer":  e.Peer,
+				"ok":    e.OK,
+			},
+		}
+	}
+	return errors.AutoWrap(json.NewEncoder(w).Encode(
+		map[string]any{"traceEvents": ctes}))
+}
+
+// record appends the event e.
+func (t *Tracer) record(e TraceEvent) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.events = append(t.events, e)
+}
+
+// trace starts tracing a communication of the specified kind and peer.
+//
+// It returns a function to be called with the result of the communication
+// when the communication ends,
+// or nil if tracing is disabled.
+func (comm *communicator[Message]) trace(
+	kind TraceEventKind,
+	peer int,
+) func(ok bool) {
+	t := comm.ctx.ctrl.tr
+	if t == nil {
+		return nil
+	}
+	e := TraceEvent{
+		Kind:      kind,
+		Group:     comm.ctx.id,
+		Rank:      comm.rank,
+		WorldRank: comm.ctx.worldRanks[comm.rank],
+		Peer:      peer,
+		Start:     time.Now(),
+	}
+	return func(ok bool) {
+		e.End, e.OK = time.Now(), ok
+		t.record(e)
+	}
+}
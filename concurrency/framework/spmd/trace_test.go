@@ -0,0 +1,129 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package spmd_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/donyori/gogo/concurrency/framework/spmd"
+)
+
+func TestTracer(t *testing.T) {
+	tracer := spmd.NewTracer()
+	ctrl := spmd.NewWithOptions(2, func(
+		world spmd.Communicator[int],
+		commMap map[string]spmd.Communicator[int],
+	) {
+		r := world.Rank()
+		if r == 0 {
+			world.Send(1, 1)
+		} else {
+			world.Receive(0)
+		}
+		world.Barrier()
+		world.Broadcast(1, r)
+	}, nil, &spmd.Options{Tracer: tracer})
+	ctrl.Run()
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		t.Fatalf("panic %q", prs)
+	}
+
+	events := tracer.Events()
+	counts := make(map[spmd.TraceEventKind][2]int)
+	for _, e := range events {
+		if e.Group != "_world" {
+			t.Errorf("got group %q; want %q", e.Group, "_world")
+		}
+		if e.Rank != e.WorldRank {
+			t.Errorf("got rank %d and world rank %d; want equal",
+				e.Rank, e.WorldRank)
+		}
+		if !e.OK {
+			t.Errorf("event %+v, got OK false", e)
+		}
+		if e.End.Before(e.Start) {
+			t.Errorf("event %+v, end is before start", e)
+		}
+		c := counts[e.Kind]
+		c[e.Rank]++
+		counts[e.Kind] = c
+	}
+	wantCounts := map[spmd.TraceEventKind][2]int{
+		spmd.TraceSend:      {1, 0},
+		spmd.TraceReceive:   {0, 1},
+		spmd.TraceBarrier:   {1, 1},
+		spmd.TraceBroadcast: {1, 1},
+	}
+	for kind, want := range wantCounts {
+		if got := counts[kind]; got != want {
+			t.Errorf("kind %q, got per-rank counts %v; want %v",
+				kind, got, want)
+		}
+	}
+	if len(counts) != len(wantCounts) {
+		t.Errorf("got kinds %v; want %v", counts, wantCounts)
+	}
+
+	var buf bytes.Buffer
+	if err := tracer.WriteJSON(&buf); err != nil {
+		t.Fatal("write JSON -", err)
+	}
+	var decoded []spmd.TraceEvent
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal("unmarshal JSON -", err)
+	} else if len(decoded) != len(events) {
+		t.Errorf("got %d JSON events; want %d", len(decoded), len(events))
+	}
+
+	buf.Reset()
+	if err := tracer.WriteChromeTrace(&buf); err != nil {
+		t.Fatal("write Chrome trace -", err)
+	}
+	var chromeTrace struct {
+		TraceEvents []struct {
+			Name string  `json:"name"`
+			Ph   string  `json:"ph"`
+			Ts   float64 `json:"ts"`
+			Dur  float64 `json:"dur"`
+			Tid  int     `json:"tid"`
+		} `json:"traceEvents"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &chromeTrace); err != nil {
+		t.Fatal("unmarshal Chrome trace -", err)
+	} else if len(chromeTrace.TraceEvents) != len(events) {
+		t.Errorf("got %d Chrome trace events; want %d",
+			len(chromeTrace.TraceEvents), len(events))
+	}
+	for i, e := range chromeTrace.TraceEvents {
+		if e.Ph != "X" {
+			t.Errorf("Chrome trace event %d, got ph %q; want %q", i, e.Ph, "X")
+		}
+		if e.Ts < 0 || e.Dur < 0 {
+			t.Errorf("Chrome trace event %d, got ts %v, dur %v; want nonnegative",
+				i, e.Ts, e.Dur)
+		}
+	}
+
+	tracer.Reset()
+	if events = tracer.Events(); events != nil {
+		t.Errorf("got %v after Reset; want <nil>", events)
+	}
+}
@@ -0,0 +1,198 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package concurrency
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// Semaphore is a weighted semaphore that bounds the concurrent access
+// to a shared resource.
+//
+// The waiters are served in first-in-first-out (FIFO) order.
+// A waiter requesting a large weight blocks the subsequent waiters
+// until it acquires the resource, so that it is never starved.
+//
+// Like sync.Mutex, it permits the client to acquire the resource
+// on one goroutine, and release it on another goroutine.
+type Semaphore interface {
+	// Size returns the maximum combined weight of the semaphore.
+	Size() int64
+
+	// Acquire acquires the resource with a weight of n,
+	// blocking until the resource is available or ctx is done.
+	//
+	// On success, it returns nil.
+	// On failure, it returns an error wrapping ctx.Err()
+	// and leaves the semaphore unchanged.
+	//
+	// It panics if ctx is nil, n is negative, or n exceeds the size.
+	Acquire(ctx context.Context, n int64) error
+
+	// TryAcquire acquires the resource with a weight of n without blocking.
+	//
+	// It reports whether the resource is acquired.
+	// On failure, it leaves the semaphore unchanged.
+	//
+	// It panics if n is negative or exceeds the size.
+	TryAcquire(n int64) bool
+
+	// Release releases the resource with a weight of n.
+	//
+	// It panics if n is negative or
+	// greater than the weight currently held.
+	Release(n int64)
+}
+
+// NewSemaphore creates a new Semaphore with the specified size,
+// i.e., the maximum combined weight for concurrent access.
+//
+// It panics if size is nonpositive.
+func NewSemaphore(size int64) Semaphore {
+	if size <= 0 {
+		panic(errors.AutoMsg(fmt.Sprintf("size (%d) is nonpositive", size)))
+	}
+	return &semaphore{size: size}
+}
+
+// semaphoreWaiter is a waiter of semaphore.
+type semaphoreWaiter struct {
+	n     int64         // Requested weight.
+	ready chan struct{} // Channel closed when the resource is acquired.
+}
+
+// semaphore is an implementation of interface Semaphore.
+type semaphore struct {
+	size    int64      // Maximum combined weight.
+	m       sync.Mutex // Lock for cur and waiters.
+	cur     int64      // Weight currently held.
+	waiters list.List  // Queue of waiters, with items of type semaphoreWaiter.
+}
+
+func (s *semaphore) Size() int64 {
+	return s.size
+}
+
+func (s *semaphore) Acquire(ctx context.Context, n int64) error {
+	if ctx == nil {
+		panic(errors.AutoMsg("the provided context is nil"))
+	}
+	s.checkWeight(n)
+	s.m.Lock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		s.m.Unlock()
+		return nil
+	}
+	done := ctx.Done()
+	select {
+	case <-done:
+		s.m.Unlock()
+		return errors.AutoWrap(ctx.Err())
+	default:
+	}
+	ready := make(chan struct{})
+	elem := s.waiters.PushBack(semaphoreWaiter{n: n, ready: ready})
+	s.m.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-done:
+	}
+	s.m.Lock()
+	select {
+	case <-ready:
+		// Acquired after ctx is done. Release it to fail consistently.
+		s.cur -= n
+		s.notifyWaiters()
+	default:
+		isFront := s.waiters.Front() == elem
+		s.waiters.Remove(elem)
+		if isFront {
+			// The next waiters may be able to acquire the resource now.
+			s.notifyWaiters()
+		}
+	}
+	s.m.Unlock()
+	return errors.AutoWrap(ctx.Err())
+}
+
+func (s *semaphore) TryAcquire(n int64) bool {
+	s.checkWeight(n)
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		return true
+	}
+	return false
+}
+
+func (s *semaphore) Release(n int64) {
+	if n < 0 {
+		panic(errors.AutoMsg(fmt.Sprintf("n (%d) is negative", n)))
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	if n > s.cur {
+		panic(errors.AutoMsg(fmt.Sprintf(
+			"release of %d, but only %d is held", n, s.cur)))
+	}
+	s.cur -= n
+	s.notifyWaiters()
+}
+
+// checkWeight panics if n is negative or exceeds the size.
+func (s *semaphore) checkWeight(n int64) {
+	if n < 0 {
+		panic(errors.AutoMsgCustom(
+			fmt.Sprintf("n (%d) is negative", n), -1, 1))
+	} else if n > s.size {
+		panic(errors.AutoMsgCustom(
+			fmt.Sprintf("n (%d) exceeds the size (%d)", n, s.size), -1, 1))
+	}
+}
+
+// notifyWaiters lets the waiters at the front of the queue
+// acquire the resource as long as it is available.
+//
+// The caller must hold s.m.
+func (s *semaphore) notifyWaiters() {
+	for {
+		front := s.waiters.Front()
+		if front == nil {
+			return
+		}
+		w := front.Value.(semaphoreWaiter)
+		if s.size-s.cur < w.n {
+			// Do not let subsequent smaller waiters go first
+			// to avoid starving w.
+			return
+		}
+		s.cur += w.n
+		s.waiters.Remove(front)
+		close(w.ready)
+	}
+}
@@ -0,0 +1,173 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package concurrency_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/concurrency"
+)
+
+func TestSemaphore_TryAcquire(t *testing.T) {
+	s := concurrency.NewSemaphore(3)
+	if size := s.Size(); size != 3 {
+		t.Errorf("got size %d; want 3", size)
+	}
+	if !s.TryAcquire(2) {
+		t.Fatal("failed to acquire 2 on an empty semaphore")
+	}
+	if s.TryAcquire(2) {
+		t.Error("acquired 2 with only 1 available")
+	}
+	if !s.TryAcquire(1) {
+		t.Error("failed to acquire 1 with 1 available")
+	}
+	s.Release(3)
+	if !s.TryAcquire(3) {
+		t.Error("failed to acquire 3 after releasing all")
+	}
+}
+
+func TestSemaphore_Acquire(t *testing.T) {
+	const Size, N = 2, 20
+	s := concurrency.NewSemaphore(Size)
+	var m sync.Mutex
+	var active, maxActive int
+	var wg sync.WaitGroup
+	wg.Add(N)
+	for range N {
+		go func() {
+			defer wg.Done()
+			if err := s.Acquire(context.Background(), 1); err != nil {
+				t.Error(err)
+				return
+			}
+			defer s.Release(1)
+			m.Lock()
+			active++
+			maxActive = max(maxActive, active)
+			m.Unlock()
+			time.Sleep(time.Millisecond)
+			m.Lock()
+			active--
+			m.Unlock()
+		}()
+	}
+	wg.Wait()
+	if maxActive > Size {
+		t.Errorf("got max active %d; want <= %d", maxActive, Size)
+	}
+}
+
+func TestSemaphore_Acquire_FIFO(t *testing.T) {
+	s := concurrency.NewSemaphore(3)
+	if !s.TryAcquire(3) {
+		t.Fatal("failed to acquire 3 on an empty semaphore")
+	}
+	bigC, smallC := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(bigC)
+		if err := s.Acquire(context.Background(), 2); err != nil {
+			t.Error(err)
+		}
+	}()
+	for s.TryAcquire(0) { // wait until the big waiter is queued
+		time.Sleep(time.Microsecond)
+	}
+	go func() {
+		defer close(smallC)
+		if err := s.Acquire(context.Background(), 1); err != nil {
+			t.Error(err)
+		}
+	}()
+	s.Release(1) // 1 available, but the big waiter is at the front
+	select {
+	case <-smallC:
+		t.Fatal("small waiter overtook the big waiter")
+	case <-time.After(10 * time.Millisecond):
+	}
+	s.Release(1) // 2 available, the big waiter goes first
+	<-bigC
+	s.Release(1) // the small waiter goes next
+	<-smallC
+}
+
+func TestSemaphore_Acquire_Canceled(t *testing.T) {
+	s := concurrency.NewSemaphore(2)
+	if !s.TryAcquire(2) {
+		t.Fatal("failed to acquire 2 on an empty semaphore")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	err := s.Acquire(ctx, 1)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v; want %v", err, context.DeadlineExceeded)
+	}
+	s.Release(2)
+	if !s.TryAcquire(2) {
+		t.Error("failed to acquire 2 after a canceled acquisition")
+	}
+}
+
+func TestSemaphore_Acquire_CanceledFrontWaiter(t *testing.T) {
+	s := concurrency.NewSemaphore(2)
+	if !s.TryAcquire(1) {
+		t.Fatal("failed to acquire 1 on an empty semaphore")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	bigC := make(chan error, 1)
+	go func() {
+		bigC <- s.Acquire(ctx, 2)
+	}()
+	for s.TryAcquire(0) { // wait until the big waiter is queued
+		time.Sleep(time.Microsecond)
+	}
+	smallC := make(chan error, 1)
+	go func() {
+		smallC <- s.Acquire(context.Background(), 1)
+	}()
+	cancel()
+	if err := <-bigC; !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v; want %v", err, context.Canceled)
+	}
+	// The small waiter can acquire the resource
+	// after the big waiter at the front gives up.
+	select {
+	case err := <-smallC:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(time.Second):
+		t.Error("small waiter is not notified after the front waiter gave up")
+	}
+}
+
+func TestSemaphore_Release_Panic(t *testing.T) {
+	s := concurrency.NewSemaphore(1)
+	defer func() {
+		if e := recover(); e == nil {
+			t.Error("no panic when releasing more than held")
+		}
+	}()
+	s.Release(1)
+}
@@ -0,0 +1,193 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package concurrency
+
+import (
+	"sync"
+	"time"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// ErrSingleFlightGoexit is an error indicating that the function
+// called by SingleFlight.Do interrupted its goroutine
+// (e.g., called runtime.Goexit).
+//
+// It is returned to the other callers waiting for that function.
+var ErrSingleFlightGoexit = errors.AutoNewCustom(
+	"the function of single flight interrupted its goroutine",
+	errors.PrependFullPkgName,
+	0,
+)
+
+// SingleFlight is a device to deduplicate concurrent calls
+// with the same key.
+//
+// While a call with a key is in flight, subsequent calls with the same key
+// wait for it and share its result and error,
+// instead of calling their functions.
+//
+// Optionally, SingleFlight caches a successful result for a period
+// (time-to-live, TTL), during which calls with the same key
+// get the cached result directly.
+type SingleFlight[K comparable, V any] interface {
+	// Do calls fn and returns its result and error,
+	// making sure that only one call with the same key
+	// is in flight at a time.
+	//
+	// If a call with the same key is in flight, Do waits for it to finish
+	// and returns its result and error, without calling fn.
+	// If a successful result with the same key is cached
+	// and has not expired, Do returns it directly, without calling fn.
+	//
+	// shared reports whether v and err are also given to other callers
+	// or obtained from the cache.
+	//
+	// If fn panics, Do panics with the same value
+	// for the caller calling fn and all waiting callers.
+	// If fn interrupts its goroutine (e.g., calls runtime.Goexit),
+	// the waiting callers get ErrSingleFlightGoexit.
+	//
+	// Do panics if fn is nil.
+	Do(key K, fn func() (V, error)) (v V, err error, shared bool)
+
+	// Forget discards the cached result with the specified key,
+	// and makes the next call to Do with that key call its function
+	// instead of waiting for a call in flight.
+	Forget(key K)
+}
+
+// NewSingleFlight creates a new SingleFlight.
+//
+// ttl is the time-to-live of the cached successful results.
+// If ttl is nonpositive, the results are not cached.
+func NewSingleFlight[K comparable, V any](ttl time.Duration) SingleFlight[K, V] {
+	return &singleFlight[K, V]{
+		ttl:   max(ttl, 0),
+		calls: make(map[K]*singleFlightCall[V]),
+	}
+}
+
+// singleFlightCall is a call (in flight or cached) of singleFlight.
+type singleFlightCall[V any] struct {
+	done       chan struct{} // Channel closed when the call finishes.
+	v          V             // Result of the function.
+	err        error         // Error of the function.
+	panicked   bool          // Indicator of whether the function panicked.
+	panicValue any           // Panic value of the function.
+	dups       int           // The number of callers waiting for this call.
+	expiry     time.Time     // Expiry time of the cached result.
+	timer      *time.Timer   // Timer to discard the cached result.
+}
+
+// singleFlight is an implementation of interface SingleFlight.
+type singleFlight[K comparable, V any] struct {
+	ttl   time.Duration              // Time-to-live of the cached results.
+	m     sync.Mutex                 // Lock for calls.
+	calls map[K]*singleFlightCall[V] // Calls in flight and cached.
+}
+
+func (sf *singleFlight[K, V]) Do(key K, fn func() (V, error)) (
+	v V, err error, shared bool) {
+	if fn == nil {
+		panic(errors.AutoMsg("fn is nil"))
+	}
+	sf.m.Lock()
+	if c := sf.calls[key]; c != nil {
+		select {
+		case <-c.done:
+			// The call in the map has finished, so its result is cached.
+			if time.Now().Before(c.expiry) {
+				sf.m.Unlock()
+				return c.v, nil, true
+			}
+			c.timer.Stop()
+		default:
+			c.dups++
+			sf.m.Unlock()
+			<-c.done
+			if c.panicked {
+				panic(c.panicValue)
+			}
+			return c.v, c.err, true
+		}
+	}
+	c := &singleFlightCall[V]{done: make(chan struct{})}
+	sf.calls[key] = c
+	sf.m.Unlock()
+	shared = sf.doCall(key, c, fn)
+	return c.v, c.err, shared
+}
+
+func (sf *singleFlight[K, V]) Forget(key K) {
+	sf.m.Lock()
+	defer sf.m.Unlock()
+	if c := sf.calls[key]; c != nil {
+		if c.timer != nil {
+			c.timer.Stop()
+		}
+		delete(sf.calls, key)
+	}
+}
+
+// doCall calls fn for c and records its result and error.
+//
+// It reports whether the result and error are given to other callers.
+func (sf *singleFlight[K, V]) doCall(
+	key K,
+	c *singleFlightCall[V],
+	fn func() (V, error),
+) (shared bool) {
+	var normalReturn bool
+	defer func() {
+		var e any
+		if !normalReturn {
+			e = recover()
+			if e != nil {
+				c.panicked, c.panicValue = true, e
+			} else {
+				c.err = ErrSingleFlightGoexit
+			}
+		}
+		sf.m.Lock()
+		shared = c.dups > 0
+		if sf.calls[key] == c {
+			if normalReturn && c.err == nil && sf.ttl > 0 {
+				c.expiry = time.Now().Add(sf.ttl)
+				c.timer = time.AfterFunc(sf.ttl, func() {
+					sf.m.Lock()
+					defer sf.m.Unlock()
+					if sf.calls[key] == c {
+						delete(sf.calls, key)
+					}
+				})
+			} else {
+				delete(sf.calls, key)
+			}
+		}
+		sf.m.Unlock()
+		close(c.done)
+		if e != nil {
+			panic(e)
+		}
+	}()
+	c.v, c.err = fn()
+	normalReturn = true
+	return
+}
@@ -0,0 +1,188 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package concurrency_test
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/concurrency"
+)
+
+func TestSingleFlight_Do(t *testing.T) {
+	sf := concurrency.NewSingleFlight[string, int](0)
+	v, err, shared := sf.Do("a", func() (int, error) {
+		return 1, nil
+	})
+	if v != 1 || err != nil || shared {
+		t.Errorf("got (%d, %v, %t); want (1, <nil>, false)", v, err, shared)
+	}
+	testErr := errors.New("test error")
+	v, err, shared = sf.Do("a", func() (int, error) {
+		return 2, testErr
+	})
+	if v != 2 || !errors.Is(err, testErr) || shared {
+		t.Errorf("got (%d, %v, %t); want (2, %v, false)",
+			v, err, shared, testErr)
+	}
+}
+
+func TestSingleFlight_Do_Dedup(t *testing.T) {
+	const N = 10
+	sf := concurrency.NewSingleFlight[string, int](0)
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fn := func() (int, error) {
+		calls.Add(1)
+		<-release
+		return 42, nil
+	}
+	var wg sync.WaitGroup
+	var numShared atomic.Int32
+	wg.Add(N)
+	for i := range N {
+		go func(rank int) {
+			defer wg.Done()
+			v, err, shared := sf.Do("key", fn)
+			if v != 42 || err != nil {
+				t.Errorf("goroutine %d, got (%d, %v); want (42, <nil>)",
+					rank, v, err)
+			}
+			if shared {
+				numShared.Add(1)
+			}
+		}(i)
+	}
+	time.Sleep(10 * time.Millisecond) // let the goroutines join the call
+	close(release)
+	wg.Wait()
+	if n := calls.Load(); n < 1 || n >= N {
+		t.Errorf("got %d calls; want at least 1 and less than %d", n, N)
+	}
+	if n := numShared.Load(); n == 0 {
+		t.Error("no caller got a shared result")
+	}
+}
+
+func TestSingleFlight_Do_TTL(t *testing.T) {
+	const TTL = 50 * time.Millisecond
+	sf := concurrency.NewSingleFlight[int, int](TTL)
+	var calls int
+	fn := func() (int, error) {
+		calls++
+		return calls, nil
+	}
+	if v, _, shared := sf.Do(1, fn); v != 1 || shared {
+		t.Errorf("first call, got (%d, %t); want (1, false)", v, shared)
+	}
+	if v, _, shared := sf.Do(1, fn); v != 1 || !shared {
+		t.Errorf("cached call, got (%d, %t); want (1, true)", v, shared)
+	}
+	sf.Forget(1)
+	if v, _, shared := sf.Do(1, fn); v != 2 || shared {
+		t.Errorf("after Forget, got (%d, %t); want (2, false)", v, shared)
+	}
+	time.Sleep(TTL * 2)
+	if v, _, shared := sf.Do(1, fn); v != 3 || shared {
+		t.Errorf("after expiry, got (%d, %t); want (3, false)", v, shared)
+	}
+	testErr := errors.New("test error")
+	errFn := func() (int, error) {
+		calls++
+		return calls, testErr
+	}
+	sf.Forget(1)
+	if _, err, _ := sf.Do(1, errFn); !errors.Is(err, testErr) {
+		t.Errorf("got error %v; want %v", err, testErr)
+	}
+	if v, err, _ := sf.Do(1, fn); v != 5 || err != nil {
+		t.Errorf("after an error, got (%d, %v); want (5, <nil>)", v, err)
+	}
+}
+
+func TestSingleFlight_Do_Panic(t *testing.T) {
+	const PanicMsg = "test panic"
+	sf := concurrency.NewSingleFlight[string, int](time.Minute)
+	release := make(chan struct{})
+	waiterC := make(chan any, 1)
+	go func() {
+		defer func() {
+			waiterC <- recover()
+		}()
+		time.Sleep(10 * time.Millisecond) // let the leader start first
+		sf.Do("key", func() (int, error) {
+			return 0, nil
+		})
+	}()
+	time.AfterFunc(20*time.Millisecond, func() {
+		close(release)
+	})
+	func() {
+		defer func() {
+			if e := recover(); e != PanicMsg {
+				t.Errorf("leader, got panic %v; want %q", e, PanicMsg)
+			}
+		}()
+		sf.Do("key", func() (int, error) {
+			<-release
+			panic(PanicMsg)
+		})
+	}()
+	if e := <-waiterC; e != PanicMsg && e != nil {
+		t.Errorf("waiter, got panic %v; want %q", e, PanicMsg)
+	}
+	// The panicked call must not be cached.
+	if v, err, _ := sf.Do("key", func() (int, error) {
+		return 1, nil
+	}); v != 1 || err != nil {
+		t.Errorf("after panic, got (%d, %v); want (1, <nil>)", v, err)
+	}
+}
+
+func TestSingleFlight_Do_Goexit(t *testing.T) {
+	sf := concurrency.NewSingleFlight[string, int](0)
+	started, release := make(chan struct{}), make(chan struct{})
+	go func() {
+		sf.Do("key", func() (int, error) {
+			close(started)
+			<-release
+			runtime.Goexit()
+			return 0, nil
+		})
+	}()
+	<-started
+	errC := make(chan error, 1)
+	go func() {
+		_, err, _ := sf.Do("key", func() (int, error) {
+			return 1, nil
+		})
+		errC <- err
+	}()
+	time.Sleep(10 * time.Millisecond) // let the waiter join the call
+	close(release)
+	if err := <-errC; err != nil &&
+		!errors.Is(err, concurrency.ErrSingleFlightGoexit) {
+		t.Errorf("got error %v; want %v or <nil>",
+			err, concurrency.ErrSingleFlightGoexit)
+	}
+}
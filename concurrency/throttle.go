@@ -0,0 +1,167 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package concurrency
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// Throttler is a wrapper of a function that limits the function
+// to be called at most once per interval.
+//
+// The first call to the wrapper in an interval calls the function
+// immediately, and the subsequent calls in the same interval are
+// coalesced into one trailing call at the end of the interval.
+// Thus, the last call to the wrapper is never lost.
+//
+// The wrapped function is never called concurrently with itself.
+//
+// It is safe for concurrent use by multiple goroutines.
+type Throttler interface {
+	// Call calls the wrapped function immediately on the current goroutine
+	// if at least one interval has elapsed since the last call to
+	// the wrapped function.
+	// Otherwise, it schedules a call to the wrapped function at the end of
+	// the interval, on another goroutine.
+	// If there is a scheduled call, Call does nothing.
+	//
+	// After calling the method Stop, Call does nothing.
+	Call()
+
+	// Flush calls the wrapped function immediately on the current goroutine
+	// if there is a scheduled call, and cancels the scheduled call.
+	//
+	// It reports whether the wrapped function is called.
+	Flush() bool
+
+	// Stop cancels the scheduled call (if any) and
+	// makes subsequent calls to the method Call do nothing.
+	//
+	// It reports whether a scheduled call is canceled.
+	Stop() bool
+}
+
+// Throttle wraps fn as a Throttler with the specified interval.
+//
+// Throttle panics if fn is nil or interval is nonpositive.
+func Throttle(fn func(), interval time.Duration) Throttler {
+	if fn == nil {
+		panic(errors.AutoMsg("fn is nil"))
+	} else if interval <= 0 {
+		panic(errors.AutoMsg(fmt.Sprintf(
+			"interval (%v) is nonpositive", interval)))
+	}
+	return &throttler{fn: fn, interval: interval}
+}
+
+// throttler is an implementation of interface Throttler.
+type throttler struct {
+	fn       func()        // The wrapped function.
+	interval time.Duration // The interval.
+	fm       sync.Mutex    // Lock to avoid calling fn concurrently.
+
+	m       sync.Mutex  // Lock for the following fields.
+	last    time.Time   // Time of the last call to fn.
+	timer   *time.Timer // Timer for the scheduled call.
+	gen     uint64      // Generation of the scheduled call, to identify outdated timers.
+	pending bool        // An indicator to report whether there is a scheduled call.
+	stopped bool        // An indicator to report whether the method Stop is called.
+}
+
+func (th *throttler) Call() {
+	th.m.Lock()
+	if th.stopped || th.pending {
+		th.m.Unlock()
+		return
+	}
+	now := time.Now()
+	if th.last.IsZero() || now.Sub(th.last) >= th.interval {
+		th.last = now
+		th.m.Unlock()
+		th.callFn()
+		return
+	}
+	th.pending = true
+	gen := th.gen
+	th.timer = time.AfterFunc(th.last.Add(th.interval).Sub(now), func() {
+		th.fire(gen)
+	})
+	th.m.Unlock()
+}
+
+func (th *throttler) Flush() bool {
+	th.m.Lock()
+	if !th.pending {
+		th.m.Unlock()
+		return false
+	}
+	th.cancelTimer()
+	th.pending = false
+	th.last = time.Now()
+	th.m.Unlock()
+	th.callFn()
+	return true
+}
+
+func (th *throttler) Stop() bool {
+	th.m.Lock()
+	defer th.m.Unlock()
+	th.stopped = true
+	pending := th.pending
+	th.cancelTimer()
+	th.pending = false
+	return pending
+}
+
+// cancelTimer stops the current timer and
+// makes it outdated in case it has fired.
+//
+// The caller must hold th.m.
+func (th *throttler) cancelTimer() {
+	if th.timer != nil {
+		th.timer.Stop()
+		th.timer = nil
+	}
+	th.gen++
+}
+
+// fire is called by the timer of generation gen.
+func (th *throttler) fire(gen uint64) {
+	th.m.Lock()
+	if gen != th.gen || !th.pending {
+		th.m.Unlock()
+		return
+	}
+	th.timer = nil
+	th.pending = false
+	th.last = time.Now()
+	th.m.Unlock()
+	th.callFn()
+}
+
+// callFn calls the wrapped function without concurrency.
+func (th *throttler) callFn() {
+	th.fm.Lock()
+	defer th.fm.Unlock()
+	th.fn()
+}
@@ -0,0 +1,93 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package concurrency_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/concurrency"
+)
+
+func TestThrottle(t *testing.T) {
+	const Interval = 50 * time.Millisecond
+	var n atomic.Int32
+	called := make(chan struct{}, 10)
+	th := concurrency.Throttle(func() {
+		n.Add(1)
+		called <- struct{}{}
+	}, Interval)
+	th.Call() // leading call
+	if got := n.Load(); got != 1 {
+		t.Errorf("after the first call, got %d calls; want 1", got)
+	}
+	<-called
+	for range 5 {
+		th.Call() // coalesced into one trailing call
+	}
+	if got := n.Load(); got != 1 {
+		t.Errorf("within the interval, got %d calls; want 1", got)
+	}
+	select {
+	case <-called:
+	case <-time.After(time.Second):
+		t.Fatal("trailing call is not made")
+	}
+	if got := n.Load(); got != 2 {
+		t.Errorf("after the interval, got %d calls; want 2", got)
+	}
+}
+
+func TestThrottler_Flush(t *testing.T) {
+	var n atomic.Int32
+	th := concurrency.Throttle(func() {
+		n.Add(1)
+	}, time.Hour)
+	th.Call()
+	if th.Flush() {
+		t.Error("Flush returned true without a scheduled call")
+	}
+	th.Call()
+	th.Call()
+	if !th.Flush() {
+		t.Error("Flush returned false with a scheduled call")
+	}
+	if got := n.Load(); got != 2 {
+		t.Errorf("got %d calls; want 2", got)
+	}
+}
+
+func TestThrottler_Stop(t *testing.T) {
+	const Interval = 5 * time.Millisecond
+	var n atomic.Int32
+	th := concurrency.Throttle(func() {
+		n.Add(1)
+	}, Interval)
+	th.Call()
+	th.Call()
+	if !th.Stop() {
+		t.Error("Stop returned false with a scheduled call")
+	}
+	th.Call()
+	time.Sleep(Interval * 4)
+	if got := n.Load(); got != 1 {
+		t.Errorf("got %d calls; want 1", got)
+	}
+}
@@ -0,0 +1,296 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/filesys"
+	"github.com/donyori/gogo/filesys/local"
+)
+
+// Struct tags recognized by this package.
+//
+// The tag "config" specifies the key of the field in configuration files
+// and the name used to derive environment variable names.
+// If it is "-", the field is ignored.
+// If it is absent, the field name is used,
+// and the keys in configuration files are matched case-insensitively.
+//
+// The tag "env" specifies the name of the environment variable
+// for the field explicitly.
+// The option EnvPrefix is not applied to this name.
+//
+// The tag "default" specifies the default value of the field,
+// in the same text format as environment variables.
+const (
+	TagConfig  = "config"
+	TagEnv     = "env"
+	TagDefault = "default"
+)
+
+// Validator is an interface that wraps the method Validate.
+//
+// If a configuration struct (or a struct nested in it) implements Validator,
+// Load calls its method Validate after all layers are applied.
+// Nested structs are validated before the structs containing them.
+type Validator interface {
+	// Validate reports an error if the configuration is invalid.
+	Validate() error
+}
+
+// Options are options for function Load.
+type Options struct {
+	// Names of the configuration files.
+	//
+	// The files are applied in order,
+	// so the values in a later file override those in an earlier file.
+	//
+	// The format of a file is determined by its extension,
+	// either ".json" or ".toml".
+	// Compressed files (e.g., "config.toml.gz") are supported
+	// as they are read through package filesys.
+	Files []string
+
+	// The filesystem to read configuration files from.
+	// If it is nil, the files are read from the local filesystem.
+	FS fs.FS
+
+	// Options for reading configuration files.
+	// Nil for using a zero-value filesys.ReadOptions.
+	ReadOptions *filesys.ReadOptions
+
+	// True if to skip the configuration files that do not exist,
+	// instead of reporting an error.
+	IgnoreMissingFiles bool
+
+	// Prefix of the environment variable names
+	// derived from the configuration keys.
+	//
+	// If it is nonempty, the environment variable name of a field
+	// without the tag "env" is the uppercase of the prefix
+	// followed by the keys on the path to the field,
+	// separated by underscores (e.g., "APP_SERVER_PORT"
+	// for the field with key "port" in the struct with key "server"
+	// and the prefix "app").
+	// Hyphens and dots in keys are replaced with underscores.
+	//
+	// If it is empty, only the fields with the tag "env"
+	// are loaded from environment variables.
+	EnvPrefix string
+
+	// True if not to load environment variables.
+	DisableEnv bool
+
+	// A function to retrieve the value of the environment variable
+	// named by key.
+	// Nil for using os.LookupEnv.
+	LookupEnv func(key string) (value string, ok bool)
+
+	// A validation hook called after all layers are applied
+	// and all Validators are passed.
+	//
+	// Its parameter is the argument cfg passed to Load.
+	Validate func(cfg any) error
+}
+
+// ErrUnknownFormat is an error indicating that
+// the format of the configuration file is unknown.
+//
+// The client should use errors.Is to test whether
+// an error is ErrUnknownFormat.
+var ErrUnknownFormat = errors.AutoNewCustom(
+	"unknown configuration file format",
+	errors.PrependFullPkgName,
+	0,
+)
+
+// Load loads the configuration into cfg with options opts.
+//
+// cfg must be a non-nil pointer to a struct.
+//
+// Load first sets the fields to their default values
+// specified by the struct tag "default",
+// then applies the configuration files specified in opts,
+// then applies the environment variables,
+// and finally validates the result.
+// See the documentation of this package and the constants
+// TagConfig, TagEnv, and TagDefault for details.
+//
+// The fields without a default value and absent from
+// all the configuration files and environment variables are left unchanged.
+//
+// If opts are nil, a zero-value Options is used.
+//
+// Load panics if cfg is not a non-nil pointer to a struct.
+func Load(cfg any, opts *Options) error {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Pointer || v.IsNil() ||
+		v.Elem().Kind() != reflect.Struct {
+		panic(errors.AutoMsg(fmt.Sprintf(
+			"cfg (type: %T) is not a non-nil pointer to a struct", cfg)))
+	}
+	if opts == nil {
+		opts = new(Options)
+	}
+	v = v.Elem()
+	err := applyDefaults(v, "")
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	for _, name := range opts.Files {
+		var m map[string]any
+		m, err = readFile(name, opts)
+		if err != nil {
+			if opts.IgnoreMissingFiles && errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return errors.AutoWrap(fmt.Errorf("read %q: %w", name, err))
+		}
+		err = setStruct(v, m, "")
+		if err != nil {
+			return errors.AutoWrap(fmt.Errorf("apply %q: %w", name, err))
+		}
+	}
+	if !opts.DisableEnv {
+		lookupEnv := opts.LookupEnv
+		if lookupEnv == nil {
+			lookupEnv = os.LookupEnv
+		}
+		_, err = applyEnv(v, "", envKey(opts.EnvPrefix), lookupEnv)
+		if err != nil {
+			return errors.AutoWrap(err)
+		}
+	}
+	err = validate(v, "")
+	if err == nil && opts.Validate != nil {
+		err = opts.Validate(cfg)
+	}
+	return errors.AutoWrap(err)
+}
+
+// LoadFromReader decodes the configuration in the specified format
+// (either "json" or "toml", case-insensitive) from r into cfg.
+//
+// Unlike Load, it neither applies default values
+// nor loads environment variables.
+// It only sets the fields present in the configuration.
+//
+// LoadFromReader panics if cfg is not a non-nil pointer to a struct.
+func LoadFromReader(cfg any, r io.Reader, format string) error {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Pointer || v.IsNil() ||
+		v.Elem().Kind() != reflect.Struct {
+		panic(errors.AutoMsg(fmt.Sprintf(
+			"cfg (type: %T) is not a non-nil pointer to a struct", cfg)))
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	m, err := decode(data, strings.ToLower(format))
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	return errors.AutoWrap(setStruct(v.Elem(), m, ""))
+}
+
+// readFile reads and decodes the configuration file with specified name.
+func readFile(name string, opts *Options) (m map[string]any, err error) {
+	format := fileFormat(name)
+	if format == "" {
+		return nil, ErrUnknownFormat
+	}
+	var r filesys.Reader
+	if opts.FS != nil {
+		r, err = filesys.ReadFromFS(opts.FS, name, opts.ReadOptions)
+	} else {
+		r, err = local.Read(name, opts.ReadOptions)
+	}
+	if err != nil {
+		return
+	}
+	defer func() {
+		err = errors.Combine(err, r.Close())
+	}()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return
+	}
+	return decode(data, format)
+}
+
+// fileFormat returns the format of the configuration file
+// according to its name.
+//
+// It returns "json", "toml", or "" (for unknown formats).
+// The extensions of compression formats (".gz", ".bz2", and ".zst")
+// are skipped.
+func fileFormat(name string) string {
+	name = strings.ToLower(name)
+	for {
+		switch ext := path.Ext(name); ext {
+		case ".gz", ".bz2", ".zst":
+			name = name[:len(name)-len(ext)]
+		case ".json":
+			return "json"
+		case ".toml":
+			return "toml"
+		default:
+			return ""
+		}
+	}
+}
+
+// decode decodes data in the specified format ("json" or "toml")
+// into a map.
+func decode(data []byte, format string) (m map[string]any, err error) {
+	switch format {
+	case "json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		err = dec.Decode(&m)
+	case "toml":
+		m, err = parseTOML(data)
+	default:
+		err = ErrUnknownFormat
+	}
+	return
+}
+
+// envKey converts s to the form of environment variable names.
+//
+// It converts s to uppercase and replaces hyphens and dots with underscores.
+func envKey(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' {
+			return '_'
+		}
+		return r
+	}, strings.ToUpper(s))
+}
@@ -0,0 +1,288 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package config_test
+
+import (
+	"fmt"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/donyori/gogo/config"
+	"github.com/donyori/gogo/errors"
+)
+
+type ServerConfig struct {
+	Host    string        `config:"host" default:"localhost"`
+	Port    int           `config:"port" default:"8080"`
+	Timeout time.Duration `config:"timeout" default:"5s"`
+}
+
+func (sc *ServerConfig) Validate() error {
+	if sc.Port <= 0 || sc.Port > 65535 {
+		return errors.New("port out of range")
+	}
+	return nil
+}
+
+type Config struct {
+	Name    string            `config:"name" default:"app"`
+	Debug   bool              `config:"debug"`
+	Tags    []string          `config:"tags"`
+	Labels  map[string]string `config:"labels"`
+	Server  ServerConfig      `config:"server"`
+	Cache   *ServerConfig     `config:"cache"`
+	Token   string            `env:"SECRET_TOKEN"`
+	Ignored string            `config:"-" default:"ignored"`
+}
+
+var testFS = fstest.MapFS{
+	"base.json": {Data: []byte(`{
+	"name": "json-app",
+	"debug": true,
+	"tags": ["a", "b"],
+	"labels": {"env": "dev", "team": "core"},
+	"server": {"host": "0.0.0.0"}
+}`)},
+	"override.toml": {Data: []byte(`
+name = "toml-app"
+
+[labels]
+env = "prod"
+
+[server]
+port = 9090
+timeout = "1m"
+`)},
+	"bad_port.toml": {Data: []byte("[server]\nport = 70000\n")},
+	"config.yaml":   {Data: []byte("name: yaml\n")},
+}
+
+func newLookupEnv(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+}
+
+func TestLoad(t *testing.T) {
+	testCases := []struct {
+		name string
+		opts *config.Options
+		want Config
+	}{
+		{
+			name: "defaults",
+			opts: &config.Options{DisableEnv: true},
+			want: Config{
+				Name: "app",
+				Server: ServerConfig{
+					Host:    "localhost",
+					Port:    8080,
+					Timeout: 5 * time.Second,
+				},
+			},
+		},
+		{
+			name: "files",
+			opts: &config.Options{
+				Files:      []string{"base.json", "override.toml"},
+				FS:         testFS,
+				DisableEnv: true,
+			},
+			want: Config{
+				Name:   "toml-app",
+				Debug:  true,
+				Tags:   []string{"a", "b"},
+				Labels: map[string]string{"env": "prod", "team": "core"},
+				Server: ServerConfig{
+					Host:    "0.0.0.0",
+					Port:    9090,
+					Timeout: time.Minute,
+				},
+			},
+		},
+		{
+			name: "files+env",
+			opts: &config.Options{
+				Files:              []string{"base.json", "missing.toml"},
+				FS:                 testFS,
+				IgnoreMissingFiles: true,
+				EnvPrefix:          "app",
+				LookupEnv: newLookupEnv(map[string]string{
+					"APP_NAME":        "env-app",
+					"APP_TAGS":        "x, y, z",
+					"APP_SERVER_PORT": "1234",
+					"APP_CACHE_HOST":  "cache.local",
+					"APP_CACHE_PORT":  "6379",
+					"APP_LABELS":      "k=v",
+					"SECRET_TOKEN":    "s3cr3t",
+					"APP_IGNORED":     "not ignored",
+				}),
+			},
+			want: Config{
+				Name:   "env-app",
+				Debug:  true,
+				Tags:   []string{"x", "y", "z"},
+				Labels: map[string]string{"env": "dev", "team": "core", "k": "v"},
+				Server: ServerConfig{
+					Host:    "0.0.0.0",
+					Port:    1234,
+					Timeout: 5 * time.Second,
+				},
+				Cache: &ServerConfig{Host: "cache.local", Port: 6379},
+				Token: "s3cr3t",
+			},
+		},
+		{
+			name: "explicit env without prefix",
+			opts: &config.Options{
+				LookupEnv: newLookupEnv(map[string]string{
+					"SECRET_TOKEN": "s3cr3t",
+					"NAME":         "should not be used",
+				}),
+			},
+			want: Config{
+				Name: "app",
+				Server: ServerConfig{
+					Host:    "localhost",
+					Port:    8080,
+					Timeout: 5 * time.Second,
+				},
+				Token: "s3cr3t",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var cfg Config
+			err := config.Load(&cfg, tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(cfg, tc.want) {
+				t.Errorf("got %+v\nwant %+v", cfg, tc.want)
+			}
+		})
+	}
+}
+
+func TestLoad_Error(t *testing.T) {
+	var validateCalled bool
+	testCases := []struct {
+		name   string
+		opts   *config.Options
+		target error
+	}{
+		{
+			name:   "missing file",
+			opts:   &config.Options{Files: []string{"missing.json"}, FS: testFS},
+			target: fs.ErrNotExist,
+		},
+		{
+			name: "unknown format",
+			opts: &config.Options{
+				Files: []string{"config.yaml"},
+				FS:    testFS,
+			},
+			target: config.ErrUnknownFormat,
+		},
+		{
+			name: "validator",
+			opts: &config.Options{
+				Files: []string{"bad_port.toml"},
+				FS:    testFS,
+			},
+		},
+		{
+			name: "invalid env",
+			opts: &config.Options{
+				EnvPrefix: "APP",
+				LookupEnv: newLookupEnv(map[string]string{
+					"APP_DEBUG": "maybe",
+				}),
+			},
+		},
+		{
+			name: "validate hook",
+			opts: &config.Options{
+				DisableEnv: true,
+				Validate: func(cfg any) error {
+					validateCalled = true
+					if cfg.(*Config).Token == "" {
+						return errors.New("token is required")
+					}
+					return nil
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var cfg Config
+			err := config.Load(&cfg, tc.opts)
+			if err == nil {
+				t.Fatal("got nil error")
+			} else if tc.target != nil && !errors.Is(err, tc.target) {
+				t.Errorf("got error %v; want %v", err, tc.target)
+			}
+		})
+	}
+	if !validateCalled {
+		t.Error("validate hook is not called")
+	}
+}
+
+func TestLoadFromReader(t *testing.T) {
+	cfg := Config{Name: "unchanged", Debug: true}
+	err := config.LoadFromReader(
+		&cfg,
+		strings.NewReader("[server]\nhost = \"example.com\"\n"),
+		"TOML",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Config{
+		Name:   "unchanged",
+		Debug:  true,
+		Server: ServerConfig{Host: "example.com"},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("got %+v; want %+v", cfg, want)
+	}
+}
+
+func TestLoad_PanicOnInvalidTarget(t *testing.T) {
+	for _, cfg := range []any{nil, Config{}, new(int), (*Config)(nil)} {
+		t.Run(fmt.Sprintf("%T", cfg), func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("no panic")
+				}
+			}()
+			_ = config.Load(cfg, nil)
+		})
+	}
+}
@@ -0,0 +1,532 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// field is the information of a configurable field in a struct.
+type field struct {
+	index  []int  // index sequence for reflect.Value.FieldByIndex
+	key    string // key in configuration files
+	tagged bool   // true if key is specified by the tag "config"
+	env    string // environment variable name specified by the tag "env"
+	def    string // default value specified by the tag "default"
+	hasDef bool   // true if the tag "default" is present
+}
+
+var (
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	validatorType       = reflect.TypeFor[Validator]()
+	durationType        = reflect.TypeFor[time.Duration]()
+	timeType            = reflect.TypeFor[time.Time]()
+)
+
+// structFields returns the configurable fields of the struct type t.
+//
+// The exported fields without the tag "config" set to "-" are configurable.
+// The fields of an embedded struct without the tag "config"
+// are treated as fields of the outer struct.
+func structFields(t reflect.Type) []field {
+	var fields []field
+	var walk func(t reflect.Type, index []int)
+	walk = func(t reflect.Type, index []int) {
+		for i := range t.NumField() {
+			sf := t.Field(i)
+			tag, tagged := sf.Tag.Lookup(TagConfig)
+			if tag == "-" {
+				continue
+			}
+			idx := append(index[:len(index):len(index)], i)
+			if sf.Anonymous && !tagged && sf.Type.Kind() == reflect.Struct {
+				walk(sf.Type, idx)
+				continue
+			} else if !sf.IsExported() {
+				continue
+			}
+			f := field{index: idx, key: tag, tagged: tagged && tag != ""}
+			if !f.tagged {
+				f.key = sf.Name
+			}
+			f.env = sf.Tag.Get(TagEnv)
+			f.def, f.hasDef = sf.Tag.Lookup(TagDefault)
+			fields = append(fields, f)
+		}
+	}
+	walk(t, nil)
+	return fields
+}
+
+// isLeafType reports whether the values of type t are
+// treated as a whole rather than a set of fields.
+func isLeafType(t reflect.Type) bool {
+	return t.Kind() != reflect.Struct ||
+		reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// structElem returns the struct that v refers to
+// if v is a struct or a non-nil pointer to a struct,
+// and that struct is not a leaf type (see isLeafType).
+func structElem(v reflect.Value) (elem reflect.Value, ok bool) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if isLeafType(v.Type()) {
+		return
+	}
+	return v, true
+}
+
+// joinPath joins the key to the path of its parent.
+func joinPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// lookupKey finds the value of the field f in m.
+func lookupKey(m map[string]any, f *field) (value any, ok bool) {
+	value, ok = m[f.key]
+	if ok || f.tagged {
+		return
+	}
+	for k, x := range m {
+		if strings.EqualFold(k, f.key) {
+			return x, true
+		}
+	}
+	return
+}
+
+// applyDefaults sets the fields of the struct v
+// to the values specified by their tag "default".
+func applyDefaults(v reflect.Value, path string) error {
+	for _, f := range structFields(v.Type()) {
+		fv, p := v.FieldByIndex(f.index), joinPath(path, f.key)
+		if f.hasDef {
+			err := setValue(fv, f.def, p)
+			if err != nil {
+				return err
+			}
+		} else if elem, ok := structElem(fv); ok {
+			err := applyDefaults(elem, p)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applyEnv sets the fields of the struct v
+// to the values of the corresponding environment variables.
+//
+// prefix is the environment variable name prefix
+// for the fields of v without the tag "env".
+// If prefix is empty, such fields are skipped.
+//
+// It reports whether any field is set.
+func applyEnv(
+	v reflect.Value,
+	path string,
+	prefix string,
+	lookupEnv func(key string) (value string, ok bool),
+) (set bool, err error) {
+	for _, f := range structFields(v.Type()) {
+		fv, p := v.FieldByIndex(f.index), joinPath(path, f.key)
+		var name, subPrefix string
+		if prefix != "" {
+			subPrefix = prefix + "_" + envKey(f.key)
+		}
+		if f.env != "" {
+			name = f.env
+		} else {
+			name = subPrefix
+		}
+		if name != "" {
+			if s, ok := lookupEnv(name); ok {
+				err = setValue(fv, s, p)
+				if err != nil {
+					return
+				}
+				set = true
+				continue
+			}
+		}
+		t := fv.Type()
+		if t.Kind() == reflect.Pointer && fv.IsNil() && !isLeafType(t.Elem()) {
+			// Allocate the struct only if any of its fields is set.
+			elem := reflect.New(t.Elem())
+			var ok bool
+			ok, err = applyEnv(elem.Elem(), p, subPrefix, lookupEnv)
+			if err != nil {
+				return
+			} else if ok {
+				fv.Set(elem)
+				set = true
+			}
+		} else if elem, ok := structElem(fv); ok {
+			ok, err = applyEnv(elem, p, subPrefix, lookupEnv)
+			if err != nil {
+				return
+			}
+			set = set || ok
+		}
+	}
+	return
+}
+
+// validate calls the method Validate of the structs that implement Validator,
+// from the innermost to the outermost.
+func validate(v reflect.Value, path string) error {
+	for _, f := range structFields(v.Type()) {
+		if elem, ok := structElem(v.FieldByIndex(f.index)); ok {
+			err := validate(elem, joinPath(path, f.key))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(validatorType) {
+		err := v.Addr().Interface().(Validator).Validate()
+		if err != nil {
+			if path != "" {
+				return fmt.Errorf("validate %s: %w", path, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// setStruct sets the fields of the struct v to the values in m.
+//
+// The fields absent from m are left unchanged.
+func setStruct(v reflect.Value, m map[string]any, path string) error {
+	for _, f := range structFields(v.Type()) {
+		if x, ok := lookupKey(m, &f); ok {
+			err := setValue(v.FieldByIndex(f.index), x, joinPath(path, f.key))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setValue sets v to src.
+//
+// src is a value decoded from a configuration file
+// (nil, bool, string, int64, float64, json.Number, time.Time,
+// []any, or map[string]any),
+// or a string from a struct tag or an environment variable.
+//
+// The maps and structs are merged with the existing values in v.
+func setValue(v reflect.Value, src any, path string) error {
+	if src == nil {
+		v.SetZero()
+		return nil
+	}
+	if s, ok := src.(string); ok && v.Kind() != reflect.Pointer &&
+		v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		err := v.Addr().Interface().(encoding.TextUnmarshaler).
+			UnmarshalText([]byte(s))
+		if err != nil {
+			return fmt.Errorf("set %s: %w", path, err)
+		}
+		return nil
+	}
+	if t, ok := src.(time.Time); ok && v.Type() == timeType {
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setValue(v.Elem(), src, path)
+	case reflect.Interface:
+		if v.NumMethod() == 0 {
+			v.Set(reflect.ValueOf(src))
+			return nil
+		}
+	case reflect.Struct:
+		if m, ok := src.(map[string]any); ok {
+			return setStruct(v, m, path)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() == reflect.String {
+			return setMap(v, src, path)
+		}
+	case reflect.Slice, reflect.Array:
+		return setList(v, src, path)
+	case reflect.Bool:
+		switch x := src.(type) {
+		case bool:
+			v.SetBool(x)
+			return nil
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(x))
+			if err != nil {
+				return fmt.Errorf("set %s: %w", path, err)
+			}
+			v.SetBool(b)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok, err := toInt64(src, v.Type() == durationType)
+		if err != nil {
+			return fmt.Errorf("set %s: %w", path, err)
+		} else if ok {
+			if v.OverflowInt(i) {
+				return fmt.Errorf("set %s: value %d overflows %v",
+					path, i, v.Type())
+			}
+			v.SetInt(i)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		u, ok, err := toUint64(src)
+		if err != nil {
+			return fmt.Errorf("set %s: %w", path, err)
+		} else if ok {
+			if v.OverflowUint(u) {
+				return fmt.Errorf("set %s: value %d overflows %v",
+					path, u, v.Type())
+			}
+			v.SetUint(u)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		f, ok, err := toFloat64(src)
+		if err != nil {
+			return fmt.Errorf("set %s: %w", path, err)
+		} else if ok {
+			if v.OverflowFloat(f) {
+				return fmt.Errorf("set %s: value %g overflows %v",
+					path, f, v.Type())
+			}
+			v.SetFloat(f)
+			return nil
+		}
+	case reflect.String:
+		switch x := src.(type) {
+		case string:
+			v.SetString(x)
+			return nil
+		case json.Number:
+			v.SetString(x.String())
+			return nil
+		}
+	}
+	return fmt.Errorf("set %s: cannot assign a value of type %T to %v",
+		path, src, v.Type())
+}
+
+// setMap sets the map v (with string-kind keys) to src.
+//
+// src is either a map[string]any or a string in the form
+// "k1=v1,k2=v2,...".
+func setMap(v reflect.Value, src any, path string) error {
+	var m map[string]any
+	switch x := src.(type) {
+	case map[string]any:
+		m = x
+	case string:
+		m = make(map[string]any)
+		for _, pair := range splitList(x) {
+			k, val, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("set %s: invalid key-value pair %q",
+					path, pair)
+			}
+			m[strings.TrimSpace(k)] = strings.TrimSpace(val)
+		}
+	default:
+		return fmt.Errorf("set %s: cannot assign a value of type %T to %v",
+			path, src, v.Type())
+	}
+	t := v.Type()
+	if v.IsNil() {
+		v.Set(reflect.MakeMapWithSize(t, len(m)))
+	}
+	for k, x := range m {
+		key := reflect.ValueOf(k).Convert(t.Key())
+		elem := reflect.New(t.Elem()).Elem()
+		if old := v.MapIndex(key); old.IsValid() {
+			elem.Set(old)
+		}
+		err := setValue(elem, x, joinPath(path, k))
+		if err != nil {
+			return err
+		}
+		v.SetMapIndex(key, elem)
+	}
+	return nil
+}
+
+// setList sets the slice or array v to src.
+//
+// src is either a []any or a comma-separated string.
+func setList(v reflect.Value, src any, path string) error {
+	var list []any
+	switch x := src.(type) {
+	case []any:
+		list = x
+	case string:
+		items := splitList(x)
+		list = make([]any, len(items))
+		for i := range items {
+			list[i] = strings.TrimSpace(items[i])
+		}
+	default:
+		return fmt.Errorf("set %s: cannot assign a value of type %T to %v",
+			path, src, v.Type())
+	}
+	if v.Kind() == reflect.Array {
+		if len(list) > v.Len() {
+			return fmt.Errorf("set %s: too many items (%d) for %v",
+				path, len(list), v.Type())
+		}
+		v.SetZero()
+	} else {
+		v.Set(reflect.MakeSlice(v.Type(), len(list), len(list)))
+	}
+	for i := range list {
+		err := setValue(v.Index(i), list[i], path+"["+strconv.Itoa(i)+"]")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitList splits the comma-separated list s.
+//
+// It returns nil if s contains only white space.
+func splitList(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// toInt64 converts src to int64.
+//
+// If isDuration is true, src of type string is parsed by time.ParseDuration.
+//
+// It reports false if src is of an unsupported type.
+func toInt64(src any, isDuration bool) (i int64, ok bool, err error) {
+	switch x := src.(type) {
+	case int64:
+		return x, true, nil
+	case float64:
+		if x != math.Trunc(x) || x < math.MinInt64 || x >= math.MaxInt64 {
+			return 0, true, fmt.Errorf("%g is not a valid integer", x)
+		}
+		return int64(x), true, nil
+	case json.Number:
+		i, err = x.Int64()
+		if err != nil {
+			var f float64
+			f, err = x.Float64()
+			if err == nil {
+				return toInt64(f, false)
+			}
+		}
+		return i, true, err
+	case string:
+		x = strings.TrimSpace(x)
+		if isDuration {
+			var d time.Duration
+			d, err = time.ParseDuration(x)
+			return int64(d), true, err
+		}
+		i, err = strconv.ParseInt(strings.ReplaceAll(x, "_", ""), 0, 64)
+		return i, true, err
+	}
+	return 0, false, nil
+}
+
+// toUint64 converts src to uint64.
+//
+// It reports false if src is of an unsupported type.
+func toUint64(src any) (u uint64, ok bool, err error) {
+	switch x := src.(type) {
+	case int64:
+		if x < 0 {
+			return 0, true, fmt.Errorf("%d is negative", x)
+		}
+		return uint64(x), true, nil
+	case float64:
+		if x != math.Trunc(x) || x < 0 || x >= math.MaxUint64 {
+			return 0, true, fmt.Errorf("%g is not a valid unsigned integer", x)
+		}
+		return uint64(x), true, nil
+	case json.Number:
+		u, err = strconv.ParseUint(x.String(), 10, 64)
+		if err != nil {
+			var f float64
+			f, err = x.Float64()
+			if err == nil {
+				return toUint64(f)
+			}
+		}
+		return u, true, err
+	case string:
+		u, err = strconv.ParseUint(
+			strings.ReplaceAll(strings.TrimSpace(x), "_", ""), 0, 64)
+		return u, true, err
+	}
+	return 0, false, nil
+}
+
+// toFloat64 converts src to float64.
+//
+// It reports false if src is of an unsupported type.
+func toFloat64(src any) (f float64, ok bool, err error) {
+	switch x := src.(type) {
+	case float64:
+		return x, true, nil
+	case int64:
+		return float64(x), true, nil
+	case json.Number:
+		f, err = x.Float64()
+		return f, true, err
+	case string:
+		f, err = strconv.ParseFloat(
+			strings.ReplaceAll(strings.TrimSpace(x), "_", ""), 64)
+		return f, true, err
+	}
+	return 0, false, nil
+}
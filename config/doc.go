@@ -0,0 +1,31 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package config provides functions to load configurations
+// into tagged structs.
+//
+// A configuration is assembled from several layers.
+// From the lowest to the highest precedence, they are
+// default values (specified by the struct tag "default"),
+// configuration files (in JSON or TOML),
+// and environment variables.
+// The values in a higher layer override those in lower layers.
+//
+// For better performance, all functions in this package are unsafe
+// for concurrency unless otherwise specified.
+package config
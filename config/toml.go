@@ -0,0 +1,812 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// parseTOML parses a TOML v1.0.0 document into a map.
+//
+// The values in the map are of type
+// bool, string, int64, float64, time.Time, []any, or map[string]any.
+// Local dates, local date-times, and local times are
+// converted to time.Time in time.Local.
+func parseTOML(data []byte) (map[string]any, error) {
+	p := &tomlParser{
+		s:    string(data),
+		line: 1,
+		root: newTOMLTable(tomlTableHeader),
+	}
+	p.cur = p.root
+	err := p.parse()
+	if err != nil {
+		return nil, fmt.Errorf("toml: line %d: %w", p.line, err)
+	}
+	return p.root.toMap(), nil
+}
+
+// tomlTableKind indicates how a TOML table was created,
+// which determines whether and how it can be extended later.
+type tomlTableKind int8
+
+const (
+	// tomlTableImplicit indicates a table created implicitly
+	// as a parent in a table header.
+	// It can be defined by a table header later, at most once.
+	tomlTableImplicit tomlTableKind = iota
+
+	// tomlTableHeader indicates a table defined by a table header
+	// ("[a]" or "[[a]]").
+	// It cannot be defined again or extended by dotted keys.
+	tomlTableHeader
+
+	// tomlTableDotted indicates a table created by a dotted key ("a.b = 1").
+	// It cannot be defined by a table header,
+	// but can be extended by dotted keys in the same table,
+	// and can have subtables defined by table headers.
+	tomlTableDotted
+
+	// tomlTableInline indicates an inline table ("a = {b = 1}")
+	// or a table created by a dotted key in an inline table.
+	// It cannot be extended in any way.
+	tomlTableInline
+)
+
+// tomlTable is a table being parsed.
+type tomlTable struct {
+	kind tomlTableKind
+
+	// The values are of type bool, string, int64, float64, time.Time,
+	// *tomlArray, or *tomlTable.
+	m map[string]any
+}
+
+// newTOMLTable creates a new empty table of the specified kind.
+func newTOMLTable(kind tomlTableKind) *tomlTable {
+	return &tomlTable{kind: kind, m: make(map[string]any)}
+}
+
+// freeze marks t and all its subtables as inline tables.
+func (t *tomlTable) freeze() {
+	t.kind = tomlTableInline
+	for _, v := range t.m {
+		if sub, ok := v.(*tomlTable); ok {
+			sub.freeze()
+		}
+	}
+}
+
+// toMap converts t to a map,
+// with its arrays and subtables converted recursively.
+func (t *tomlTable) toMap() map[string]any {
+	m := make(map[string]any, len(t.m))
+	for k, v := range t.m {
+		m[k] = tomlValue(v)
+	}
+	return m
+}
+
+// tomlArray is an array being parsed.
+type tomlArray struct {
+	// True if the array is created by array of tables headers ("[[a]]").
+	// Otherwise, the array is a static array ("a = [1, 2]"),
+	// which cannot be extended.
+	ofTables bool
+
+	// The items are of the same types as the values of tomlTable.
+	items []any
+}
+
+// toSlice converts a to a slice,
+// with its items converted recursively.
+func (a *tomlArray) toSlice() []any {
+	list := make([]any, len(a.items))
+	for i, v := range a.items {
+		list[i] = tomlValue(v)
+	}
+	return list
+}
+
+// tomlValue converts v, which is a value in a tomlTable or tomlArray,
+// to a value in the result of parseTOML.
+func tomlValue(v any) any {
+	switch x := v.(type) {
+	case *tomlTable:
+		return x.toMap()
+	case *tomlArray:
+		return x.toSlice()
+	}
+	return v
+}
+
+// tomlParser is a parser for TOML documents.
+type tomlParser struct {
+	s    string // the document
+	pos  int    // current position in s
+	line int    // current line number, starting from 1
+
+	root *tomlTable // the root table
+	cur  *tomlTable // the table to which key-value pairs are added
+}
+
+// parse parses the whole document.
+func (p *tomlParser) parse() error {
+	for {
+		p.skipBlank(true)
+		if p.pos >= len(p.s) {
+			return nil
+		}
+		var err error
+		if p.s[p.pos] == '[' {
+			err = p.parseTableHeader()
+		} else {
+			err = p.parseKeyValue(p.cur)
+		}
+		if err != nil {
+			return err
+		}
+		err = p.expectLineEnd()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// skipBlank skips white space and comments.
+// If newline is true, it also skips newlines.
+func (p *tomlParser) skipBlank(newline bool) {
+	for p.pos < len(p.s) {
+		switch p.s[p.pos] {
+		case ' ', '\t':
+			p.pos++
+		case '#':
+			for p.pos < len(p.s) && p.s[p.pos] != '\n' {
+				p.pos++
+			}
+		case '\r':
+			if !newline || !strings.HasPrefix(p.s[p.pos:], "\r\n") {
+				return
+			}
+			p.pos++
+		case '\n':
+			if !newline {
+				return
+			}
+			p.pos++
+			p.line++
+		default:
+			return
+		}
+	}
+}
+
+// skipSpace skips white space (excluding newlines).
+func (p *tomlParser) skipSpace() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// expectLineEnd skips white space and a comment,
+// and then expects a newline or the end of the document.
+func (p *tomlParser) expectLineEnd() error {
+	p.skipBlank(false)
+	switch {
+	case p.pos >= len(p.s):
+		return nil
+	case p.s[p.pos] == '\n':
+		p.pos++
+		p.line++
+		return nil
+	case strings.HasPrefix(p.s[p.pos:], "\r\n"):
+		p.pos += 2
+		p.line++
+		return nil
+	}
+	return fmt.Errorf("unexpected character %q", p.s[p.pos])
+}
+
+// parseTableHeader parses a table header ("[a.b]")
+// or an array of tables header ("[[a.b]]").
+func (p *tomlParser) parseTableHeader() error {
+	isArray := strings.HasPrefix(p.s[p.pos:], "[[")
+	if isArray {
+		p.pos += 2
+	} else {
+		p.pos++
+	}
+	p.skipSpace()
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpace()
+	closing := "]"
+	if isArray {
+		closing = "]]"
+	}
+	if !strings.HasPrefix(p.s[p.pos:], closing) {
+		return fmt.Errorf("table header is not closed with %q", closing)
+	}
+	p.pos += len(closing)
+	parent, err := p.descendHeader(keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	if isArray {
+		table := newTOMLTable(tomlTableHeader)
+		switch x := parent.m[last].(type) {
+		case nil:
+			parent.m[last] = &tomlArray{ofTables: true, items: []any{table}}
+		case *tomlArray:
+			if !x.ofTables {
+				return fmt.Errorf("static array %q cannot be extended",
+					strings.Join(keys, "."))
+			}
+			x.items = append(x.items, table)
+		default:
+			return fmt.Errorf("key %q is already defined", strings.Join(keys, "."))
+		}
+		p.cur = table
+		return nil
+	}
+	switch x := parent.m[last].(type) {
+	case nil:
+		p.cur = newTOMLTable(tomlTableHeader)
+		parent.m[last] = p.cur
+	case *tomlTable:
+		switch x.kind {
+		case tomlTableImplicit:
+			x.kind = tomlTableHeader
+			p.cur = x
+		case tomlTableHeader:
+			return fmt.Errorf("table %q is defined more than once",
+				strings.Join(keys, "."))
+		default:
+			return fmt.Errorf(
+				"table %q is already defined by a dotted key or an inline table",
+				strings.Join(keys, "."))
+		}
+	default:
+		return fmt.Errorf("key %q is already defined", strings.Join(keys, "."))
+	}
+	return nil
+}
+
+// descendHeader walks from the root table along keys in a table header,
+// creating the implicit tables that do not exist,
+// and returns the table at the end.
+//
+// If a key refers to an array of tables, its last table is used.
+func (p *tomlParser) descendHeader(keys []string) (*tomlTable, error) {
+	t := p.root
+	for i, k := range keys {
+		switch x := t.m[k].(type) {
+		case nil:
+			sub := newTOMLTable(tomlTableImplicit)
+			t.m[k], t = sub, sub
+		case *tomlTable:
+			if x.kind == tomlTableInline {
+				return nil, fmt.Errorf("inline table %q cannot be extended",
+					strings.Join(keys[:i+1], "."))
+			}
+			t = x
+		case *tomlArray:
+			if !x.ofTables {
+				return nil, fmt.Errorf("static array %q cannot be extended",
+					strings.Join(keys[:i+1], "."))
+			}
+			// An array of tables is never empty.
+			t = x.items[len(x.items)-1].(*tomlTable)
+		default:
+			return nil, fmt.Errorf("key %q is not a table",
+				strings.Join(keys[:i+1], "."))
+		}
+	}
+	return t, nil
+}
+
+// descendDotted walks from the table t along keys in a dotted key,
+// creating the tables that do not exist,
+// and returns the table at the end.
+//
+// Only the tables created by dotted keys can be walked through.
+func descendDotted(t *tomlTable, keys []string) (*tomlTable, error) {
+	for i, k := range keys {
+		switch x := t.m[k].(type) {
+		case nil:
+			sub := newTOMLTable(tomlTableDotted)
+			t.m[k], t = sub, sub
+		case *tomlTable:
+			if x.kind != tomlTableDotted {
+				return nil, fmt.Errorf("table %q cannot be extended by dotted keys",
+					strings.Join(keys[:i+1], "."))
+			}
+			t = x
+		default:
+			return nil, fmt.Errorf("key %q is not a table",
+				strings.Join(keys[:i+1], "."))
+		}
+	}
+	return t, nil
+}
+
+// parseKeyValue parses a key-value pair and adds it to the table t.
+func (p *tomlParser) parseKeyValue(t *tomlTable) error {
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpace()
+	if p.pos >= len(p.s) || p.s[p.pos] != '=' {
+		return fmt.Errorf("expected '=' after key %q", strings.Join(keys, "."))
+	}
+	p.pos++
+	p.skipSpace()
+	value, err := p.parseValue()
+	if err != nil {
+		return err
+	}
+	t, err = descendDotted(t, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	if _, ok := t.m[last]; ok {
+		return fmt.Errorf("key %q is already defined", strings.Join(keys, "."))
+	}
+	t.m[last] = value
+	return nil
+}
+
+// parseKey parses a (possibly dotted) key.
+func (p *tomlParser) parseKey() (keys []string, err error) {
+	for {
+		var k string
+		switch {
+		case p.pos >= len(p.s):
+			return nil, fmt.Errorf("unexpected end of document, expected a key")
+		case p.s[p.pos] == '"':
+			if strings.HasPrefix(p.s[p.pos:], `"""`) {
+				return nil, fmt.Errorf("multi-line string cannot be a key")
+			}
+			k, err = p.parseBasicString()
+		case p.s[p.pos] == '\'':
+			if strings.HasPrefix(p.s[p.pos:], "'''") {
+				return nil, fmt.Errorf("multi-line string cannot be a key")
+			}
+			k, err = p.parseLiteralString()
+		default:
+			start := p.pos
+			for p.pos < len(p.s) && isBareKeyChar(p.s[p.pos]) {
+				p.pos++
+			}
+			if p.pos == start {
+				return nil, fmt.Errorf("unexpected character %q, expected a key",
+					p.s[p.pos])
+			}
+			k = p.s[start:p.pos]
+		}
+		if err != nil {
+			return
+		}
+		keys = append(keys, k)
+		p.skipSpace()
+		if p.pos >= len(p.s) || p.s[p.pos] != '.' {
+			return
+		}
+		p.pos++
+		p.skipSpace()
+	}
+}
+
+// isBareKeyChar reports whether c is allowed in bare keys.
+func isBareKeyChar(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' ||
+		c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// parseValue parses a value.
+func (p *tomlParser) parseValue() (any, error) {
+	if p.pos >= len(p.s) {
+		return nil, fmt.Errorf("unexpected end of document, expected a value")
+	}
+	switch c := p.s[p.pos]; {
+	case c == '"':
+		if strings.HasPrefix(p.s[p.pos:], `"""`) {
+			return p.parseMultiLineBasicString()
+		}
+		return p.parseBasicString()
+	case c == '\'':
+		if strings.HasPrefix(p.s[p.pos:], "'''") {
+			return p.parseMultiLineLiteralString()
+		}
+		return p.parseLiteralString()
+	case c == '[':
+		return p.parseArray()
+	case c == '{':
+		return p.parseInlineTable()
+	case strings.HasPrefix(p.s[p.pos:], "true"):
+		p.pos += 4
+		return true, nil
+	case strings.HasPrefix(p.s[p.pos:], "false"):
+		p.pos += 5
+		return false, nil
+	}
+	return p.parseNumberOrDateTime()
+}
+
+// parseBasicString parses a basic string ("...").
+func (p *tomlParser) parseBasicString() (string, error) {
+	p.pos++ // skip the opening quotation mark
+	var b strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		switch c {
+		case '"':
+			p.pos++
+			return b.String(), nil
+		case '\\':
+			err := p.parseEscape(&b)
+			if err != nil {
+				return "", err
+			}
+		case '\n', '\r':
+			return "", fmt.Errorf("newline in basic string")
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", fmt.Errorf("basic string is not closed")
+}
+
+// parseMultiLineBasicString parses a multi-line basic string ("""...""").
+func (p *tomlParser) parseMultiLineBasicString() (string, error) {
+	p.pos += 3 // skip the opening delimiter
+	p.skipLeadingNewline()
+	var b strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		switch {
+		case strings.HasPrefix(p.s[p.pos:], `"""`):
+			// Up to two additional quotation marks are part of the string.
+			n := 3
+			for n < 5 && p.pos+n < len(p.s) && p.s[p.pos+n] == '"' {
+				n++
+			}
+			b.WriteString(p.s[p.pos+3 : p.pos+n])
+			p.pos += n
+			return b.String(), nil
+		case c == '\\':
+			// Line ending backslash trims the following white space.
+			i := p.pos + 1
+			for i < len(p.s) && (p.s[i] == ' ' || p.s[i] == '\t') {
+				i++
+			}
+			if i < len(p.s) && (p.s[i] == '\n' || p.s[i] == '\r') {
+				p.pos = i
+			trimLoop:
+				for p.pos < len(p.s) {
+					switch p.s[p.pos] {
+					case '\n':
+						p.line++
+						p.pos++
+					case ' ', '\t', '\r':
+						p.pos++
+					default:
+						break trimLoop
+					}
+				}
+				continue
+			}
+			err := p.parseEscape(&b)
+			if err != nil {
+				return "", err
+			}
+		default:
+			if c == '\n' {
+				p.line++
+			}
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", fmt.Errorf("multi-line basic string is not closed")
+}
+
+// parseEscape parses an escape sequence in a basic string
+// and writes the result to b.
+func (p *tomlParser) parseEscape(b *strings.Builder) error {
+	if p.pos+1 >= len(p.s) {
+		return fmt.Errorf("incomplete escape sequence")
+	}
+	c := p.s[p.pos+1]
+	p.pos += 2
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case 'e':
+		b.WriteByte('\x1b')
+	case '"':
+		b.WriteByte('"')
+	case '\\':
+		b.WriteByte('\\')
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if p.pos+n > len(p.s) {
+			return fmt.Errorf("incomplete escape sequence")
+		}
+		code, err := strconv.ParseUint(p.s[p.pos:p.pos+n], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return fmt.Errorf("invalid escape sequence %q",
+				p.s[p.pos-2:p.pos+n])
+		}
+		b.WriteRune(rune(code))
+		p.pos += n
+	default:
+		return fmt.Errorf("invalid escape sequence %q", p.s[p.pos-2:p.pos])
+	}
+	return nil
+}
+
+// parseLiteralString parses a literal string ('...').
+func (p *tomlParser) parseLiteralString() (string, error) {
+	p.pos++ // skip the opening apostrophe
+	start := p.pos
+	for p.pos < len(p.s) {
+		switch p.s[p.pos] {
+		case '\'':
+			p.pos++
+			return p.s[start : p.pos-1], nil
+		case '\n', '\r':
+			return "", fmt.Errorf("newline in literal string")
+		}
+		p.pos++
+	}
+	return "", fmt.Errorf("literal string is not closed")
+}
+
+// parseMultiLineLiteralString parses a multi-line literal string,
+// which is enclosed by three apostrophes on each side.
+func (p *tomlParser) parseMultiLineLiteralString() (string, error) {
+	p.pos += 3 // skip the opening delimiter
+	p.skipLeadingNewline()
+	i := strings.Index(p.s[p.pos:], "'''")
+	if i < 0 {
+		return "", fmt.Errorf("multi-line literal string is not closed")
+	}
+	end := p.pos + i + 3
+	// Up to two additional apostrophes are part of the string.
+	for n := 0; n < 2 && end < len(p.s) && p.s[end] == '\''; n++ {
+		end++
+	}
+	s := p.s[p.pos : end-3]
+	p.line += strings.Count(s, "\n")
+	p.pos = end
+	return s, nil
+}
+
+// skipLeadingNewline skips a newline immediately following
+// the opening delimiter of a multi-line string.
+func (p *tomlParser) skipLeadingNewline() {
+	if strings.HasPrefix(p.s[p.pos:], "\n") {
+		p.pos++
+		p.line++
+	} else if strings.HasPrefix(p.s[p.pos:], "\r\n") {
+		p.pos += 2
+		p.line++
+	}
+}
+
+// parseArray parses a static array.
+func (p *tomlParser) parseArray() (*tomlArray, error) {
+	p.pos++ // skip '['
+	a := &tomlArray{items: make([]any, 0)}
+	for {
+		p.skipBlank(true)
+		if p.pos >= len(p.s) {
+			return nil, fmt.Errorf("array is not closed")
+		} else if p.s[p.pos] == ']' {
+			p.pos++
+			return a, nil
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		a.items = append(a.items, value)
+		p.skipBlank(true)
+		if p.pos >= len(p.s) {
+			return nil, fmt.Errorf("array is not closed")
+		}
+		switch p.s[p.pos] {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return a, nil
+		default:
+			return nil, fmt.Errorf("unexpected character %q in array",
+				p.s[p.pos])
+		}
+	}
+}
+
+// parseInlineTable parses an inline table.
+//
+// The tables created by dotted keys in the inline table
+// can be extended by dotted keys until the inline table is closed.
+func (p *tomlParser) parseInlineTable() (*tomlTable, error) {
+	p.pos++ // skip '{'
+	t := newTOMLTable(tomlTableInline)
+	p.skipSpace()
+	if p.pos < len(p.s) && p.s[p.pos] == '}' {
+		p.pos++
+		return t, nil
+	}
+	for {
+		p.skipSpace()
+		err := p.parseKeyValue(t)
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.pos >= len(p.s) {
+			return nil, fmt.Errorf("inline table is not closed")
+		}
+		switch p.s[p.pos] {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			t.freeze()
+			return t, nil
+		default:
+			return nil, fmt.Errorf("unexpected character %q in inline table",
+				p.s[p.pos])
+		}
+	}
+}
+
+// tomlDateTimeLayouts are the layouts for parsing TOML
+// offset date-times, local date-times, local dates, and local times.
+//
+// The separator between the date and time is normalized to 'T'
+// before parsing.
+var tomlDateTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04",
+	time.DateOnly,
+	"15:04:05.999999999",
+	"15:04",
+}
+
+// parseNumberOrDateTime parses an integer, a float, or a date-time.
+func (p *tomlParser) parseNumberOrDateTime() (any, error) {
+	start := p.pos
+	for p.pos < len(p.s) && !strings.ContainsRune(" \t\r\n,]}#", rune(p.s[p.pos])) {
+		p.pos++
+	}
+	// A date and a time may be separated by a space.
+	if p.pos-start == 10 && p.pos+3 < len(p.s) && p.s[p.pos] == ' ' &&
+		isDigit(p.s[p.pos+1]) && isDigit(p.s[p.pos+2]) && p.s[p.pos+3] == ':' {
+		p.pos++
+		for p.pos < len(p.s) &&
+			!strings.ContainsRune(" \t\r\n,]}#", rune(p.s[p.pos])) {
+			p.pos++
+		}
+	}
+	tok := p.s[start:p.pos]
+	if tok == "" {
+		return nil, fmt.Errorf("unexpected character %q, expected a value",
+			p.s[p.pos])
+	}
+	if len(tok) >= 5 && (tok[2] == ':' || tok[4] == '-') && isDigit(tok[0]) {
+		s := tok
+		if len(s) > 10 && (s[10] == ' ' || s[10] == 't') {
+			s = s[:10] + "T" + s[11:]
+		}
+		s = strings.ToUpper(s)
+		for _, layout := range tomlDateTimeLayouts {
+			if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid date-time %q", tok)
+	}
+	switch strings.TrimLeft(tok, "+-") {
+	case "inf":
+		if tok[0] == '-' {
+			return math.Inf(-1), nil
+		}
+		return math.Inf(1), nil
+	case "nan":
+		return math.NaN(), nil
+	}
+	if !validUnderscores(tok) {
+		return nil, fmt.Errorf("invalid number %q", tok)
+	}
+	s := strings.ReplaceAll(tok, "_", "")
+	if len(s) > 2 && s[0] == '0' && strings.ContainsRune("xob", rune(s[1])) {
+		i, err := strconv.ParseInt(s, 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", tok)
+		}
+		return i, nil
+	}
+	digits := strings.TrimLeft(s, "+-")
+	if len(digits) > 1 && digits[0] == '0' && isDigit(digits[1]) {
+		return nil, fmt.Errorf("leading zeros are not allowed: %q", tok)
+	}
+	if strings.ContainsAny(s, ".eE") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid float %q", tok)
+		}
+		return f, nil
+	}
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid integer %q", tok)
+	}
+	return i, nil
+}
+
+// isDigit reports whether c is a decimal digit.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// validUnderscores reports whether each underscore in the number token tok
+// is surrounded by at least one digit on each side.
+func validUnderscores(tok string) bool {
+	for i := range len(tok) {
+		if tok[i] == '_' && (i == 0 || i == len(tok)-1 ||
+			!isHexDigit(tok[i-1]) || !isHexDigit(tok[i+1])) {
+			return false
+		}
+	}
+	return true
+}
+
+// isHexDigit reports whether c is a hexadecimal digit.
+func isHexDigit(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
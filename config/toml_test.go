@@ -0,0 +1,192 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package config_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/config"
+)
+
+func TestParseTOML(t *testing.T) {
+	const Doc = `# This is a TOML document.
+title = "TOML \"Example\""
+literal = 'C:\Users\nodejs'
+multi = """
+Roses are red,\
+   violets are blue."""
+raw = '''
+first
+second'''
+
+[owner]
+name = "Tom"
+dob = 1979-05-27T07:32:00-08:00
+
+[database]
+enabled = true
+ports = [ 8000, 8001, 8002 ]
+data = [ ["delta", "phi"], [3.14] ]
+temp_targets = { cpu = 79.5, case = 72.0 }
+size = 1_000
+hex = 0xDEAD_BEEF
+oct = 0o755
+neg = -inf
+
+[servers.alpha]
+ip = "10.0.0.1"
+
+[[products]]
+name = "Hammer"
+sku = 738594937
+
+[[products]]
+
+[[products]]
+name = "Nail"
+color.main = "gray"
+`
+	got, err := config.ParseTOML([]byte(Doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"title":   `TOML "Example"`,
+		"literal": `C:\Users\nodejs`,
+		"multi":   "Roses are red,violets are blue.",
+		"raw":     "first\nsecond",
+		"owner": map[string]any{
+			"name": "Tom",
+			"dob": time.Date(1979, 5, 27, 7, 32, 0, 0,
+				time.FixedZone("", -8*3600)),
+		},
+		"database": map[string]any{
+			"enabled": true,
+			"ports":   []any{int64(8000), int64(8001), int64(8002)},
+			"data": []any{
+				[]any{"delta", "phi"},
+				[]any{3.14},
+			},
+			"temp_targets": map[string]any{"cpu": 79.5, "case": 72.0},
+			"size":         int64(1000),
+			"hex":          int64(0xDEADBEEF),
+			"oct":          int64(0o755),
+			"neg":          math.Inf(-1),
+		},
+		"servers": map[string]any{
+			"alpha": map[string]any{"ip": "10.0.0.1"},
+		},
+		"products": []any{
+			map[string]any{"name": "Hammer", "sku": int64(738594937)},
+			map[string]any{},
+			map[string]any{
+				"name":  "Nail",
+				"color": map[string]any{"main": "gray"},
+			},
+		},
+	}
+	// Compare the time separately because time.Time contains a location.
+	gotDob := got["owner"].(map[string]any)["dob"].(time.Time)
+	wantDob := want["owner"].(map[string]any)["dob"].(time.Time)
+	if !gotDob.Equal(wantDob) {
+		t.Errorf("got dob %v; want %v", gotDob, wantDob)
+	}
+	got["owner"].(map[string]any)["dob"] = nil
+	want["owner"].(map[string]any)["dob"] = nil
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v\nwant %#v", got, want)
+	}
+}
+
+func TestParseTOML_TableDefinition(t *testing.T) {
+	const Doc = `[a.b.c]
+x = 1
+
+[a]
+y = 2
+d.e = 3
+
+[a.d.f]
+z = 4
+
+[[a.g]]
+[a.g.h]
+w = 5
+`
+	got, err := config.ParseTOML([]byte(Doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"a": map[string]any{
+			"b": map[string]any{"c": map[string]any{"x": int64(1)}},
+			"y": int64(2),
+			"d": map[string]any{
+				"e": int64(3),
+				"f": map[string]any{"z": int64(4)},
+			},
+			"g": []any{
+				map[string]any{"h": map[string]any{"w": int64(5)}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v\nwant %#v", got, want)
+	}
+}
+
+func TestParseTOML_Error(t *testing.T) {
+	testCases := []struct {
+		name string
+		doc  string
+	}{
+		{"duplicate key", "a = 1\na = 2"},
+		{"duplicate table", "[a]\n[a]"},
+		{"missing value", "a ="},
+		{"unclosed string", `a = "abc`},
+		{"unclosed array", "a = [1, 2"},
+		{"leading zeros", "a = 012"},
+		{"bad underscore", "a = 1__0"},
+		{"invalid escape", `a = "\q"`},
+		{"trailing content", "a = 1 b = 2"},
+		{"key redefined as table", "a = 1\n[a]"},
+		{"inline table redefined", "a = {x = 1}\n[a]\ny = 2"},
+		{"inline table extended by header", "a = {x = 1}\n[a.b]"},
+		{"inline table extended by dotted key", "a = {x = 1}\na.y = 2"},
+		{"inline table dotted key extended", "a = {b.x = 1}\n[a.b.c]"},
+		{"static array extended", "a = [1, 2]\n[[a]]\nx = 1"},
+		{"static array as parent", "a = [{}]\n[a.b]"},
+		{"dotted table redefined", "a.b = 1\n[a]"},
+		{"dotted table redefined nested", "[x]\na.b = 1\n[x.a]"},
+		{"header table extended by dotted key", "[a.b]\nx = 1\n[a]\nb.y = 2"},
+		{"implicit table extended by dotted key", "[a.b.c]\n[a]\nb.c.t = 1"},
+		{"table redefined as array", "[a]\n[[a]]"},
+		{"array redefined as table", "[[a]]\n[a]"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := config.ParseTOML([]byte(tc.doc)); err == nil {
+				t.Error("got nil error")
+			}
+		})
+	}
+}
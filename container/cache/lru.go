@@ -0,0 +1,336 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cache
+
+import (
+	"container/list"
+	"fmt"
+	"time"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// EvictReason is the reason why an entry is evicted from a cache.
+type EvictReason int8
+
+const (
+	// EvictCapacity means that the entry is evicted to make room
+	// for a new entry because the cache is full.
+	EvictCapacity EvictReason = 1 + iota
+
+	// EvictExpired means that the entry is evicted
+	// because it exceeds its time to live.
+	EvictExpired
+)
+
+var evictReasonStrings = [...]string{
+	EvictCapacity: "capacity",
+	EvictExpired:  "expired",
+}
+
+// String returns the name of the reason.
+func (r EvictReason) String() string {
+	if r > 0 && int(r) < len(evictReasonStrings) {
+		return evictReasonStrings[r]
+	}
+	return fmt.Sprintf("EvictReason(%d)", r)
+}
+
+// LRU is an interface representing a cache
+// that evicts the least recently used entry when it is full.
+//
+// Optionally, its entries expire after a fixed time to live (TTL)
+// since they were last set.
+// The expired entries are removed lazily,
+// i.e., when they are accessed or when the cache is full,
+// or explicitly by the method RemoveExpired.
+type LRU[Key comparable, Value any] interface {
+	// Len returns the number of entries in the cache,
+	// including the expired entries that have not been removed yet.
+	Len() int
+
+	// Cap returns the capacity of the cache,
+	// i.e., the maximum number of entries in the cache.
+	Cap() int
+
+	// Get returns the value bound to the specified key
+	// and marks the entry as the most recently used.
+	//
+	// It also returns an indicator present to report
+	// whether the key is present and not expired.
+	Get(key Key) (value Value, present bool)
+
+	// Peek is like Get but does not mark the entry as recently used.
+	Peek(key Key) (value Value, present bool)
+
+	// Contains reports whether the key is present and not expired,
+	// without marking the entry as recently used.
+	Contains(key Key) bool
+
+	// Set binds the value to the specified key,
+	// marks the entry as the most recently used,
+	// and resets its time to live.
+	//
+	// If the cache is full, the least recently used entry is evicted.
+	Set(key Key, value Value)
+
+	// Remove removes the entry with the specified key
+	// without calling the eviction callback.
+	//
+	// It returns the value (if any) bound to the key
+	// and an indicator present to report whether
+	// the key was present (even if expired).
+	Remove(key Key) (value Value, present bool)
+
+	// RemoveExpired removes all expired entries,
+	// calls the eviction callback (if any) for each of them,
+	// and returns the number of entries removed.
+	RemoveExpired() int
+
+	// Range accesses the unexpired entries
+	// from the most recently used to the least recently used,
+	// without marking them as recently used.
+	//
+	// Its parameter handler is a function to deal with the entry
+	// and report whether to continue to access the next entry.
+	//
+	// The cache must not be modified during ranging.
+	Range(handler func(key Key, value Value) (cont bool))
+
+	// Clear removes all entries in the cache
+	// without calling the eviction callback.
+	Clear()
+}
+
+// LRUOptions are options for creating an LRU cache.
+type LRUOptions[Key comparable, Value any] struct {
+	// The time to live of the entries since they were last set.
+	// Nonpositive values for no expiration.
+	TTL time.Duration
+
+	// The function called each time an entry is evicted,
+	// either due to insufficient capacity or expiration.
+	//
+	// It is not called for the entries removed by
+	// the methods Remove and Clear,
+	// and the entries whose values are overwritten by the method Set.
+	//
+	// It is called synchronously by the method that evicts the entry
+	// (after the cache is updated),
+	// so it must not call the methods of the cache.
+	OnEvict func(key Key, value Value, reason EvictReason)
+}
+
+// lruEntry is an entry of the LRU cache.
+type lruEntry[Key comparable, Value any] struct {
+	key    Key
+	value  Value
+	expiry time.Time // Expiration time, or zero if the entry never expires.
+
+	// The element of the entry in the expiry list, or nil if no expiration.
+	expElem *list.Element
+}
+
+// lru is an implementation of interface LRU,
+// based on doubly linked lists and a Go map.
+type lru[Key comparable, Value any] struct {
+	capacity int
+	ttl      time.Duration
+	onEvict  func(key Key, value Value, reason EvictReason)
+	ll       *list.List // The entries from the most recently used to the least recently used.
+	m        map[Key]*list.Element
+
+	// The entries from the most recently set to the least recently set,
+	// only used if ttl is positive.
+	//
+	// As the TTL is the same for all entries,
+	// it is also the order from the latest to the earliest expiration time,
+	// so that the expired entries are always at the back.
+	el *list.List
+}
+
+// NewLRU creates a new LRU cache with the specified capacity.
+//
+// If opts are nil, a zero-value LRUOptions is used.
+//
+// It panics if capacity is nonpositive.
+func NewLRU[Key comparable, Value any](
+	capacity int,
+	opts *LRUOptions[Key, Value],
+) LRU[Key, Value] {
+	if capacity <= 0 {
+		panic(errors.AutoMsg(fmt.Sprintf(
+			"capacity (%d) is nonpositive", capacity)))
+	}
+	if opts == nil {
+		opts = new(LRUOptions[Key, Value])
+	}
+	return &lru[Key, Value]{
+		capacity: capacity,
+		ttl:      max(opts.TTL, 0),
+		onEvict:  opts.OnEvict,
+		ll:       list.New(),
+		m:        make(map[Key]*list.Element),
+		el:       list.New(),
+	}
+}
+
+func (c *lru[Key, Value]) Len() int {
+	return c.ll.Len()
+}
+
+func (c *lru[Key, Value]) Cap() int {
+	return c.capacity
+}
+
+func (c *lru[Key, Value]) Get(key Key) (value Value, present bool) {
+	elem := c.lookup(key)
+	if elem != nil {
+		c.ll.MoveToFront(elem)
+		value, present = elem.Value.(*lruEntry[Key, Value]).value, true
+	}
+	return
+}
+
+func (c *lru[Key, Value]) Peek(key Key) (value Value, present bool) {
+	elem := c.lookup(key)
+	if elem != nil {
+		value, present = elem.Value.(*lruEntry[Key, Value]).value, true
+	}
+	return
+}
+
+func (c *lru[Key, Value]) Contains(key Key) bool {
+	return c.lookup(key) != nil
+}
+
+func (c *lru[Key, Value]) Set(key Key, value Value) {
+	var expiry time.Time
+	if c.ttl > 0 {
+		expiry = time.Now().Add(c.ttl)
+	}
+	if elem := c.m[key]; elem != nil {
+		entry := elem.Value.(*lruEntry[Key, Value])
+		entry.value, entry.expiry = value, expiry
+		c.ll.MoveToFront(elem)
+		if entry.expElem != nil {
+			c.el.MoveToFront(entry.expElem)
+		}
+		return
+	}
+	entry := &lruEntry[Key, Value]{key: key, value: value, expiry: expiry}
+	c.m[key] = c.ll.PushFront(entry)
+	if c.ttl > 0 {
+		entry.expElem = c.el.PushFront(entry)
+	}
+	if c.ll.Len() <= c.capacity {
+		return
+	}
+	// Prefer evicting expired entries to the least recently used one.
+	if c.ttl > 0 && c.RemoveExpired() > 0 {
+		return
+	}
+	entry = c.removeElement(c.ll.Back())
+	if c.onEvict != nil {
+		c.onEvict(entry.key, entry.value, EvictCapacity)
+	}
+}
+
+func (c *lru[Key, Value]) Remove(key Key) (value Value, present bool) {
+	if elem := c.m[key]; elem != nil {
+		value, present = c.removeElement(elem).value, true
+	}
+	return
+}
+
+func (c *lru[Key, Value]) RemoveExpired() int {
+	if c.ttl <= 0 {
+		return 0
+	}
+	now := time.Now()
+	var expired []*lruEntry[Key, Value]
+	for elem := c.el.Back(); elem != nil; elem = c.el.Back() {
+		entry := elem.Value.(*lruEntry[Key, Value])
+		if now.Before(entry.expiry) {
+			break
+		}
+		expired = append(expired, c.removeElement(c.m[entry.key]))
+	}
+	if c.onEvict != nil {
+		for _, entry := range expired {
+			c.onEvict(entry.key, entry.value, EvictExpired)
+		}
+	}
+	return len(expired)
+}
+
+func (c *lru[Key, Value]) Range(handler func(key Key, value Value) (cont bool)) {
+	var now time.Time
+	if c.ttl > 0 {
+		now = time.Now()
+	}
+	for elem := c.ll.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*lruEntry[Key, Value])
+		if c.ttl > 0 && !now.Before(entry.expiry) {
+			continue
+		}
+		if !handler(entry.key, entry.value) {
+			return
+		}
+	}
+}
+
+func (c *lru[Key, Value]) Clear() {
+	c.ll.Init()
+	c.el.Init()
+	c.m = make(map[Key]*list.Element)
+}
+
+// lookup returns the list element of the specified key,
+// or nil if the key is not present.
+//
+// If the entry of the key has expired,
+// lookup removes it, calls the eviction callback (if any),
+// and returns nil.
+func (c *lru[Key, Value]) lookup(key Key) *list.Element {
+	elem := c.m[key]
+	if elem == nil || c.ttl <= 0 {
+		return elem
+	}
+	entry := elem.Value.(*lruEntry[Key, Value])
+	if time.Now().Before(entry.expiry) {
+		return elem
+	}
+	c.removeElement(elem)
+	if c.onEvict != nil {
+		c.onEvict(entry.key, entry.value, EvictExpired)
+	}
+	return nil
+}
+
+// removeElement removes elem from the cache and returns its entry.
+func (c *lru[Key, Value]) removeElement(elem *list.Element) *lruEntry[Key, Value] {
+	entry := c.ll.Remove(elem).(*lruEntry[Key, Value])
+	delete(c.m, entry.key)
+	if entry.expElem != nil {
+		c.el.Remove(entry.expElem)
+		entry.expElem = nil
+	}
+	return entry
+}
@@ -0,0 +1,191 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cache_test
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/container/cache"
+)
+
+type evictRecord struct {
+	key    string
+	value  int
+	reason cache.EvictReason
+}
+
+func TestLRU_Capacity(t *testing.T) {
+	var evicted []evictRecord
+	c := cache.NewLRU(3, &cache.LRUOptions[string, int]{
+		OnEvict: func(key string, value int, reason cache.EvictReason) {
+			evicted = append(evicted, evictRecord{key, value, reason})
+		},
+	})
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Errorf("got Get(a) %d, %t; want 1, true", v, ok)
+	}
+	if v, ok := c.Peek("b"); !ok || v != 2 {
+		t.Errorf("got Peek(b) %d, %t; want 2, true", v, ok)
+	}
+	c.Set("d", 4) // evicts b, which is not marked as used by Peek
+	if want := []evictRecord{{"b", 2, cache.EvictCapacity}}; !slices.Equal(evicted, want) {
+		t.Errorf("got evicted %v; want %v", evicted, want)
+	}
+	checkLRU(t, c, []string{"d", "a", "c"})
+	c.Set("c", 30) // overwrite, no eviction
+	checkLRU(t, c, []string{"c", "d", "a"})
+	if v, ok := c.Remove("d"); !ok || v != 4 {
+		t.Errorf("got Remove(d) %d, %t; want 4, true", v, ok)
+	}
+	if c.Contains("d") {
+		t.Error("got Contains(d) true after Remove; want false")
+	}
+	checkLRU(t, c, []string{"c", "a"})
+	c.Clear()
+	checkLRU(t, c, nil)
+	if len(evicted) != 1 {
+		t.Errorf("got %d evictions; want 1", len(evicted))
+	}
+	if n := c.Cap(); n != 3 {
+		t.Errorf("got cap %d; want 3", n)
+	}
+}
+
+func TestLRU_TTL(t *testing.T) {
+	const TTL = 50 * time.Millisecond
+	var evicted []evictRecord
+	c := cache.NewLRU(3, &cache.LRUOptions[string, int]{
+		TTL: TTL,
+		OnEvict: func(key string, value int, reason cache.EvictReason) {
+			evicted = append(evicted, evictRecord{key, value, reason})
+		},
+	})
+	c.Set("a", 1)
+	c.Set("b", 2)
+	time.Sleep(TTL * 2)
+	c.Set("c", 3)
+	if _, ok := c.Get("a"); ok {
+		t.Error("got Get(a) present after TTL; want absent")
+	}
+	if want := []evictRecord{{"a", 1, cache.EvictExpired}}; !slices.Equal(evicted, want) {
+		t.Errorf("got evicted %v; want %v", evicted, want)
+	}
+	if n := c.Len(); n != 2 {
+		t.Errorf("got len %d; want 2 (including expired b)", n)
+	}
+	checkLRU(t, c, []string{"c"})
+	if n := c.RemoveExpired(); n != 1 {
+		t.Errorf("got RemoveExpired %d; want 1", n)
+	}
+	if n := c.Len(); n != 1 {
+		t.Errorf("got len %d; want 1", n)
+	}
+	if v, ok := c.Get("c"); !ok || v != 3 {
+		t.Errorf("got Get(c) %d, %t; want 3, true", v, ok)
+	}
+}
+
+func TestLRU_TTLReset(t *testing.T) {
+	const TTL = 100 * time.Millisecond
+	var evicted []evictRecord
+	c := cache.NewLRU(2, &cache.LRUOptions[string, int]{
+		TTL: TTL,
+		OnEvict: func(key string, value int, reason cache.EvictReason) {
+			evicted = append(evicted, evictRecord{key, value, reason})
+		},
+	})
+	c.Set("a", 1)
+	c.Set("b", 2)
+	time.Sleep(TTL * 3 / 5)
+	c.Set("a", 10) // reset the TTL of a
+	time.Sleep(TTL * 3 / 5)
+	c.Set("c", 3)
+	if want := []evictRecord{{"b", 2, cache.EvictExpired}}; !slices.Equal(evicted, want) {
+		t.Errorf("got evicted %v; want %v", evicted, want)
+	}
+	checkLRU(t, c, []string{"c", "a"})
+}
+
+func TestNewLRU_NonpositiveCapacity(t *testing.T) {
+	defer func() {
+		if e := recover(); e == nil {
+			t.Error("want panic but not")
+		}
+	}()
+	cache.NewLRU[string, int](0, nil)
+}
+
+func TestSynchronized(t *testing.T) {
+	const NumGoroutine, NumKey = 8, 100
+	c := cache.Synchronized(cache.NewLRU[string, int](NumKey/2, nil))
+	if cache.Synchronized(c) != c {
+		t.Error("Synchronized wraps a synchronized cache again")
+	}
+	var wg sync.WaitGroup
+	wg.Add(NumGoroutine)
+	for g := range NumGoroutine {
+		go func() {
+			defer wg.Done()
+			for i := range NumKey {
+				key := fmt.Sprint(i)
+				c.Set(key, g)
+				c.Get(key)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := c.Len(); n != NumKey/2 {
+		t.Errorf("got len %d; want %d", n, NumKey/2)
+	}
+}
+
+func TestEvictReason_String(t *testing.T) {
+	testCases := []struct {
+		r    cache.EvictReason
+		want string
+	}{
+		{cache.EvictCapacity, "capacity"},
+		{cache.EvictExpired, "expired"},
+		{0, "EvictReason(0)"},
+	}
+	for _, tc := range testCases {
+		if got := tc.r.String(); got != tc.want {
+			t.Errorf("got %q; want %q", got, tc.want)
+		}
+	}
+}
+
+func checkLRU(t *testing.T, c cache.LRU[string, int], wantKeys []string) {
+	t.Helper()
+	var keys []string
+	c.Range(func(key string, value int) (cont bool) {
+		keys = append(keys, key)
+		return true
+	})
+	if !slices.Equal(keys, wantKeys) {
+		t.Errorf("got keys %v; want %v", keys, wantKeys)
+	}
+}
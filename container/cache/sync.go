@@ -0,0 +1,110 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cache
+
+import "sync"
+
+// syncLRU is a concurrency-safe wrapper of interface LRU.
+type syncLRU[Key comparable, Value any] struct {
+	m sync.Mutex
+	c LRU[Key, Value]
+}
+
+// Synchronized returns a wrapper of the LRU cache c
+// that is safe for concurrent use by multiple goroutines.
+//
+// All methods of the wrapper are serialized by a mutex.
+// The eviction callback of c and the handler passed to the method Range
+// are called while holding the mutex,
+// so they must not call the methods of the wrapper.
+//
+// The client should not use c directly after wrapping it.
+//
+// If c is already returned by Synchronized, it returns c itself.
+// If c is nil, it returns nil.
+func Synchronized[Key comparable, Value any](
+	c LRU[Key, Value],
+) LRU[Key, Value] {
+	if c == nil {
+		return nil
+	} else if _, ok := c.(*syncLRU[Key, Value]); ok {
+		return c
+	}
+	return &syncLRU[Key, Value]{c: c}
+}
+
+func (s *syncLRU[Key, Value]) Len() int {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.c.Len()
+}
+
+func (s *syncLRU[Key, Value]) Cap() int {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.c.Cap()
+}
+
+func (s *syncLRU[Key, Value]) Get(key Key) (value Value, present bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.c.Get(key)
+}
+
+func (s *syncLRU[Key, Value]) Peek(key Key) (value Value, present bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.c.Peek(key)
+}
+
+func (s *syncLRU[Key, Value]) Contains(key Key) bool {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.c.Contains(key)
+}
+
+func (s *syncLRU[Key, Value]) Set(key Key, value Value) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.c.Set(key, value)
+}
+
+func (s *syncLRU[Key, Value]) Remove(key Key) (value Value, present bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.c.Remove(key)
+}
+
+func (s *syncLRU[Key, Value]) RemoveExpired() int {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.c.RemoveExpired()
+}
+
+func (s *syncLRU[Key, Value]) Range(handler func(key Key, value Value) (cont bool)) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.c.Range(handler)
+}
+
+func (s *syncLRU[Key, Value]) Clear() {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.c.Clear()
+}
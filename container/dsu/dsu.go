@@ -0,0 +1,210 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package dsu
+
+import (
+	"fmt"
+	"iter"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// DisjointSet is an interface representing a collection of disjoint sets
+// of the elements 0, 1, ..., Len()-1.
+//
+// Initially, each element is in its own set.
+// The sets are merged by the method Union.
+//
+// The amortized time complexity of the methods Find, Union, and Same is
+// O(α(n)), where α is the inverse Ackermann function,
+// and n = Len().
+type DisjointSet interface {
+	// Len returns the number of elements.
+	Len() int
+
+	// NumSets returns the number of disjoint sets.
+	NumSets() int
+
+	// Add adds a new element in its own set
+	// and returns the new element, which is equal to the previous Len().
+	Add() int
+
+	// Find returns the representative element of the set containing x.
+	//
+	// Two elements are in the same set
+	// if and only if they have the same representative element.
+	// The representative element may change after calling Union.
+	//
+	// It panics if x is out of range.
+	Find(x int) int
+
+	// Union merges the sets containing x and y.
+	//
+	// It reports whether the sets are merged,
+	// i.e., whether x and y were in different sets.
+	//
+	// It panics if x or y is out of range.
+	Union(x, y int) (merged bool)
+
+	// Same reports whether x and y are in the same set.
+	//
+	// It panics if x or y is out of range.
+	Same(x, y int) bool
+
+	// Size returns the number of elements in the set containing x.
+	//
+	// It panics if x is out of range.
+	Size(x int) int
+
+	// Groups returns an iterator over the disjoint sets.
+	//
+	// Each set is yielded as a newly allocated Go slice
+	// of its elements in ascending order.
+	// The sets are yielded in ascending order of their smallest elements.
+	//
+	// Time complexity: O(n α(n)), where n = Len().
+	//
+	// The disjoint sets must not be modified during iteration.
+	Groups() iter.Seq[[]int]
+}
+
+// disjointSet is an implementation of interface DisjointSet,
+// based on a forest with path compression and union by rank.
+type disjointSet struct {
+	parent []int   // The parent of each element; an element is a root if it is its own parent.
+	rank   []uint8 // The rank of each root, an upper bound on the height of its tree.
+	size   []int   // The size of the set of each root.
+	ns     int     // The number of disjoint sets.
+}
+
+// New creates a new DisjointSet of n elements,
+// each in its own set.
+//
+// It panics if n is negative.
+func New(n int) DisjointSet {
+	if n < 0 {
+		panic(errors.AutoMsg(fmt.Sprintf("n (%d) is negative", n)))
+	}
+	ds := &disjointSet{
+		parent: make([]int, n),
+		rank:   make([]uint8, n),
+		size:   make([]int, n),
+		ns:     n,
+	}
+	for i := range n {
+		ds.parent[i], ds.size[i] = i, 1
+	}
+	return ds
+}
+
+func (ds *disjointSet) Len() int {
+	return len(ds.parent)
+}
+
+func (ds *disjointSet) NumSets() int {
+	return ds.ns
+}
+
+func (ds *disjointSet) Add() int {
+	x := len(ds.parent)
+	ds.parent = append(ds.parent, x)
+	ds.rank = append(ds.rank, 0)
+	ds.size = append(ds.size, 1)
+	ds.ns++
+	return x
+}
+
+func (ds *disjointSet) Find(x int) int {
+	ds.checkElement(x)
+	return ds.find(x)
+}
+
+func (ds *disjointSet) Union(x, y int) (merged bool) {
+	ds.checkElement(x)
+	ds.checkElement(y)
+	x, y = ds.find(x), ds.find(y)
+	if x == y {
+		return false
+	}
+	if ds.rank[x] < ds.rank[y] {
+		x, y = y, x
+	} else if ds.rank[x] == ds.rank[y] {
+		ds.rank[x]++
+	}
+	ds.parent[y] = x
+	ds.size[x] += ds.size[y]
+	ds.ns--
+	return true
+}
+
+func (ds *disjointSet) Same(x, y int) bool {
+	ds.checkElement(x)
+	ds.checkElement(y)
+	return ds.find(x) == ds.find(y)
+}
+
+func (ds *disjointSet) Size(x int) int {
+	ds.checkElement(x)
+	return ds.size[ds.find(x)]
+}
+
+func (ds *disjointSet) Groups() iter.Seq[[]int] {
+	return func(yield func([]int) bool) {
+		n := len(ds.parent)
+		if n == 0 {
+			return
+		}
+		// Collect the elements of each set by its root,
+		// and record the order of the roots by their smallest elements.
+		groups := make(map[int][]int, ds.ns)
+		roots := make([]int, 0, ds.ns)
+		for x := range n {
+			r := ds.find(x)
+			g, ok := groups[r]
+			if !ok {
+				g = make([]int, 0, ds.size[r])
+				roots = append(roots, r)
+			}
+			groups[r] = append(g, x)
+		}
+		for _, r := range roots {
+			if !yield(groups[r]) {
+				return
+			}
+		}
+	}
+}
+
+// find returns the root of the tree containing x,
+// halving the path to the root.
+func (ds *disjointSet) find(x int) int {
+	for ds.parent[x] != x {
+		ds.parent[x] = ds.parent[ds.parent[x]]
+		x = ds.parent[x]
+	}
+	return x
+}
+
+// checkElement panics if x is out of range.
+func (ds *disjointSet) checkElement(x int) {
+	if x < 0 || x >= len(ds.parent) {
+		panic(errors.AutoMsgCustom(fmt.Sprintf(
+			"element %d is out of range [0:%d]", x, len(ds.parent)), -1, 1))
+	}
+}
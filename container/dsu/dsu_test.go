@@ -0,0 +1,139 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package dsu_test
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/donyori/gogo/container/dsu"
+)
+
+func TestDisjointSet(t *testing.T) {
+	ds := dsu.New(6)
+	if n := ds.NumSets(); n != 6 {
+		t.Errorf("got NumSets %d; want 6", n)
+	}
+	if !ds.Union(0, 1) || !ds.Union(2, 3) || !ds.Union(1, 3) {
+		t.Error("got Union false for different sets; want true")
+	}
+	if ds.Union(0, 2) {
+		t.Error("got Union(0, 2) true for the same set; want false")
+	}
+	if !ds.Same(0, 3) || ds.Same(0, 4) {
+		t.Errorf("got Same(0, 3) %t, Same(0, 4) %t; want true, false",
+			ds.Same(0, 3), ds.Same(0, 4))
+	}
+	if n := ds.Size(2); n != 4 {
+		t.Errorf("got Size(2) %d; want 4", n)
+	}
+	if x := ds.Add(); x != 6 {
+		t.Errorf("got Add %d; want 6", x)
+	}
+	ds.Union(6, 4)
+	if n := ds.NumSets(); n != 3 {
+		t.Errorf("got NumSets %d; want 3", n)
+	}
+	want := [][]int{{0, 1, 2, 3}, {4, 6}, {5}}
+	got := slices.Collect(ds.Groups())
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("got Groups %v; want %v", got, want)
+	}
+	for g := range ds.Groups() {
+		if !slices.Equal(g, want[0]) {
+			t.Errorf("got first group %v; want %v", g, want[0])
+		}
+		break
+	}
+}
+
+func TestDisjointSet_Random(t *testing.T) {
+	const N = 200
+	random := rand.New(rand.NewChaCha8([32]byte([]byte("ABCDEFGHIJKLMNOPQRSTUVWXYZ123456"))))
+	ds := dsu.New(N)
+	label := make([]int, N) // naive implementation: label of each element
+	for i := range label {
+		label[i] = i
+	}
+	numSets := N
+	for range 300 {
+		x, y := random.IntN(N), random.IntN(N)
+		wantMerged := label[x] != label[y]
+		if wantMerged {
+			old := label[y]
+			for i := range label {
+				if label[i] == old {
+					label[i] = label[x]
+				}
+			}
+			numSets--
+		}
+		if merged := ds.Union(x, y); merged != wantMerged {
+			t.Fatalf("Union(%d, %d) got %t; want %t", x, y, merged, wantMerged)
+		}
+		if n := ds.NumSets(); n != numSets {
+			t.Fatalf("got NumSets %d; want %d", n, numSets)
+		}
+	}
+	for x := range N {
+		var size int
+		for y := range N {
+			if same := ds.Same(x, y); same != (label[x] == label[y]) {
+				t.Fatalf("Same(%d, %d) got %t; want %t", x, y, same, !same)
+			}
+			if label[x] == label[y] {
+				size++
+			}
+		}
+		if n := ds.Size(x); n != size {
+			t.Fatalf("Size(%d) got %d; want %d", x, n, size)
+		}
+	}
+	var total int
+	for g := range ds.Groups() {
+		total += len(g)
+		if !slices.IsSorted(g) {
+			t.Errorf("group %v is not sorted", g)
+		}
+		for _, x := range g {
+			if label[x] != label[g[0]] {
+				t.Errorf("group %v contains elements of different sets", g)
+				break
+			}
+		}
+	}
+	if total != N {
+		t.Errorf("got %d elements in groups; want %d", total, N)
+	}
+}
+
+func TestDisjointSet_OutOfRange(t *testing.T) {
+	ds := dsu.New(3)
+	for _, x := range []int{-1, 3} {
+		func() {
+			defer func() {
+				if e := recover(); e == nil {
+					t.Errorf("Find(%d), want panic but not", x)
+				}
+			}()
+			ds.Find(x)
+		}()
+	}
+}
@@ -0,0 +1,150 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package graph
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/donyori/gogo/constraints"
+	"github.com/donyori/gogo/container/heap/pqueue"
+	"github.com/donyori/gogo/errors"
+)
+
+// ShortestPaths finds the shortest paths from the vertex source
+// to all vertices reachable from it in the graph g,
+// using Dijkstra's algorithm.
+//
+// It returns two maps:
+//   - dist: the distance from source to each reachable vertex
+//     (including source itself, whose distance is 0);
+//   - prev: the predecessor of each reachable vertex
+//     (except for source) on its shortest path.
+//
+// It returns (nil, nil) if source is not in g.
+//
+// It panics if g is nil,
+// or if any edge examined has a negative or NaN weight.
+//
+// Time complexity: O((n + m) log n), where n is the number of vertices,
+// and m is the number of edges.
+func ShortestPaths[Vertex comparable, Weight constraints.Real](
+	g Graph[Vertex, Weight],
+	source Vertex,
+) (dist map[Vertex]Weight, prev map[Vertex]Vertex) {
+	if g == nil {
+		panic(errors.AutoMsg("g is nil"))
+	} else if !g.HasVertex(source) {
+		return
+	}
+	return dijkstra(g, source, nil)
+}
+
+// ShortestPath finds the shortest path from the vertex source
+// to the vertex target in the graph g, using Dijkstra's algorithm.
+//
+// It returns the path (starting with source and ending with target)
+// and its length (i.e., the sum of the weights of the edges on the path).
+// It also returns an indicator ok to report whether target is reachable.
+//
+// The search stops as soon as the shortest path to target is found.
+//
+// It panics if g is nil,
+// or if any edge examined has a negative or NaN weight.
+func ShortestPath[Vertex comparable, Weight constraints.Real](
+	g Graph[Vertex, Weight],
+	source Vertex,
+	target Vertex,
+) (path []Vertex, dist Weight, ok bool) {
+	if g == nil {
+		panic(errors.AutoMsg("g is nil"))
+	} else if !g.HasVertex(source) || !g.HasVertex(target) {
+		return
+	}
+	distMap, prev := dijkstra(g, source, func(v Vertex) bool {
+		return v == target
+	})
+	dist, ok = distMap[target]
+	if !ok {
+		return
+	}
+	for v := target; ; v = prev[v] {
+		path = append(path, v)
+		if v == source {
+			break
+		}
+	}
+	slices.Reverse(path)
+	return
+}
+
+// dijkstraItem is the item of the priority queue used in Dijkstra's algorithm.
+type dijkstraItem[Vertex, Weight any] struct {
+	v Vertex
+	d Weight
+}
+
+// dijkstra is the implementation of Dijkstra's algorithm
+// for functions ShortestPaths and ShortestPath.
+//
+// stop is called on each vertex whose distance is finalized.
+// If stop is non-nil and returns true, dijkstra returns immediately.
+//
+// Only the vertices in dist are guaranteed to have valid entries in prev.
+//
+// The caller must guarantee that g is non-nil and source is in g.
+func dijkstra[Vertex comparable, Weight constraints.Real](
+	g Graph[Vertex, Weight],
+	source Vertex,
+	stop func(v Vertex) bool,
+) (dist map[Vertex]Weight, prev map[Vertex]Vertex) {
+	dist, prev = make(map[Vertex]Weight), make(map[Vertex]Vertex)
+	pq := pqueue.NewUpdatable(func(a, b dijkstraItem[Vertex, Weight]) bool {
+		return a.d < b.d
+	}, 0)
+	handles := map[Vertex]*pqueue.Handle[dijkstraItem[Vertex, Weight]]{
+		source: pq.Push(dijkstraItem[Vertex, Weight]{v: source}),
+	}
+	for pq.Len() > 0 {
+		x := pq.Dequeue()
+		delete(handles, x.v)
+		dist[x.v] = x.d
+		if stop != nil && stop(x.v) {
+			return
+		}
+		for v, w := range g.Neighbors(x.v) {
+			if !(w >= 0) {
+				panic(errors.AutoMsg(fmt.Sprintf(
+					"edge %v has a negative or NaN weight",
+					Edge[Vertex, Weight]{From: x.v, To: v, Weight: w})))
+			} else if _, done := dist[v]; done {
+				continue
+			}
+			d := x.d + w
+			if h := handles[v]; h == nil {
+				handles[v] = pq.Push(dijkstraItem[Vertex, Weight]{v: v, d: d})
+				prev[v] = x.v
+			} else if d < h.Item().d {
+				pq.Update(h, dijkstraItem[Vertex, Weight]{v: v, d: d})
+				prev[v] = x.v
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,142 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package graph_test
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/donyori/gogo/container/graph"
+)
+
+func TestShortestPath(t *testing.T) {
+	g := graph.New[string, float64](true)
+	g.AddEdge("A", "B", 4)
+	g.AddEdge("A", "C", 1)
+	g.AddEdge("C", "B", 2)
+	g.AddEdge("B", "D", 1)
+	g.AddEdge("C", "D", 5)
+	g.AddVertex("E")
+	path, dist, ok := graph.ShortestPath(g, "A", "D")
+	want := []string{"A", "C", "B", "D"}
+	if !ok || dist != 4 || len(path) != len(want) {
+		t.Fatalf("got (%v, %v, %t); want (%v, 4, true)", path, dist, ok, want)
+	}
+	for i := range want {
+		if path[i] != want[i] {
+			t.Fatalf("got path %v; want %v", path, want)
+		}
+	}
+	if path, dist, ok = graph.ShortestPath(g, "A", "A"); !ok || dist != 0 ||
+		len(path) != 1 || path[0] != "A" {
+		t.Errorf("got (%v, %v, %t); want ([A], 0, true)", path, dist, ok)
+	}
+	if path, _, ok = graph.ShortestPath(g, "D", "A"); ok || path != nil {
+		t.Errorf("got (%v, %t); want (nil, false)", path, ok)
+	}
+	if path, _, ok = graph.ShortestPath(g, "A", "E"); ok || path != nil {
+		t.Errorf("got (%v, %t); want (nil, false)", path, ok)
+	}
+	if path, _, ok = graph.ShortestPath(g, "A", "Z"); ok || path != nil {
+		t.Errorf("got (%v, %t); want (nil, false)", path, ok)
+	}
+}
+
+func TestShortestPaths_Random(t *testing.T) {
+	const NumVertex, NumEdge = 30, 120
+	random := rand.New(rand.NewChaCha8(
+		[32]byte([]byte("ABCDEFGHIJKLMNOPQRSTUVWXYZ123456"))))
+	for _, directed := range []bool{false, true} {
+		for range 20 {
+			g := graph.New[int, int](directed)
+			for v := range NumVertex {
+				g.AddVertex(v)
+			}
+			for range NumEdge {
+				g.AddEdge(random.IntN(NumVertex), random.IntN(NumVertex),
+					random.IntN(100))
+			}
+			dist, prev := graph.ShortestPaths(g, 0)
+			want := bellmanFord(g, 0)
+			if len(dist) != len(want) {
+				t.Fatalf("directed %t, got %d reachable vertices; want %d",
+					directed, len(dist), len(want))
+			}
+			for v, d := range want {
+				if dist[v] != d {
+					t.Fatalf("directed %t, got dist[%d] %d; want %d",
+						directed, v, dist[v], d)
+				}
+				if v == 0 {
+					if _, ok := prev[v]; ok {
+						t.Fatal("source has a predecessor")
+					}
+					continue
+				}
+				p, ok := prev[v]
+				w, present := g.Weight(p, v)
+				if !ok || !present || dist[p]+w != d {
+					t.Fatalf("directed %t, got invalid prev[%d] %d (%t)",
+						directed, v, p, ok)
+				}
+			}
+		}
+	}
+}
+
+func TestShortestPaths_NegativeWeight(t *testing.T) {
+	for _, w := range []float64{-1, math.NaN()} {
+		g := graph.New[int, float64](true)
+		g.AddEdge(0, 1, w)
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("weight %v, want panic but not", w)
+				}
+			}()
+			graph.ShortestPaths(g, 0)
+		}()
+	}
+}
+
+// bellmanFord computes the distances from source
+// using the Bellman-Ford algorithm.
+func bellmanFord(g graph.Graph[int, int], source int) map[int]int {
+	dist := map[int]int{source: 0}
+	for range g.NumVertices() {
+		for e := range g.Edges() {
+			relax(dist, e.From, e.To, e.Weight)
+			if !g.Directed() {
+				relax(dist, e.To, e.From, e.Weight)
+			}
+		}
+	}
+	return dist
+}
+
+func relax(dist map[int]int, from, to, w int) {
+	d, ok := dist[from]
+	if !ok {
+		return
+	}
+	if old, ok := dist[to]; !ok || d+w < old {
+		dist[to] = d + w
+	}
+}
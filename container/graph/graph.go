@@ -0,0 +1,406 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package graph
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+)
+
+// Edge represents an edge of Graph.
+//
+// For an undirected graph, From and To are the two endpoints of the edge,
+// and their order is insignificant.
+type Edge[Vertex, Weight any] struct {
+	From   Vertex
+	To     Vertex
+	Weight Weight
+}
+
+// String formats the edge in the form of
+//
+//	<from> " -> " <to> " (" <weight> ")"
+//
+// For example, the result of
+//
+//	Edge[string, int]{From: "A", To: "B", Weight: 1}.String()
+//
+// is
+//
+//	A -> B (1)
+func (e Edge[Vertex, Weight]) String() string {
+	return fmt.Sprintf("%v -> %v (%v)", e.From, e.To, e.Weight)
+}
+
+// Graph is an interface representing a weighted graph
+// implemented with adjacency lists.
+//
+// The graph can be either directed or undirected,
+// which is specified when it is created and cannot be changed.
+// Self-loops are allowed, whereas parallel edges are not:
+// there is at most one edge from one vertex to another
+// (or between two vertices in an undirected graph).
+//
+// The vertices are iterated in the order they were added to the graph.
+// The neighbors of a vertex are iterated in the order
+// the corresponding edges were added.
+// Therefore, the search algorithms running over the graph
+// are deterministic.
+//
+// The graph must not be modified during the iteration
+// of its vertices, edges, or neighbors.
+type Graph[Vertex comparable, Weight any] interface {
+	// Directed reports whether the graph is directed.
+	Directed() bool
+
+	// NumVertices returns the number of vertices in the graph.
+	NumVertices() int
+
+	// NumEdges returns the number of edges in the graph.
+	//
+	// For an undirected graph, each edge is counted once.
+	NumEdges() int
+
+	// HasVertex reports whether the specified vertex is in the graph.
+	HasVertex(v Vertex) bool
+
+	// AddVertex adds the specified vertex to the graph.
+	//
+	// It reports whether the vertex is added,
+	// i.e., whether the vertex was absent.
+	AddVertex(v Vertex) (added bool)
+
+	// RemoveVertex removes the specified vertex
+	// together with all edges incident to it.
+	//
+	// It reports whether the vertex was present.
+	//
+	// Time complexity: O(n + m), where n is the number of vertices,
+	// and m is the total degree of the vertex and its neighbors.
+	RemoveVertex(v Vertex) (removed bool)
+
+	// HasEdge reports whether there is an edge from the vertex from
+	// to the vertex to.
+	//
+	// For an undirected graph, HasEdge(u, v) is equivalent to HasEdge(v, u).
+	HasEdge(from, to Vertex) bool
+
+	// Weight returns the weight of the edge from the vertex from
+	// to the vertex to.
+	//
+	// It also returns an indicator present to report
+	// whether the edge is present.
+	Weight(from, to Vertex) (weight Weight, present bool)
+
+	// AddEdge adds an edge with the specified weight
+	// from the vertex from to the vertex to.
+	// The vertices are added to the graph if absent.
+	//
+	// If the edge is already present, its weight is updated.
+	//
+	// It reports whether the edge is newly added.
+	AddEdge(from, to Vertex, weight Weight) (added bool)
+
+	// RemoveEdge removes the edge from the vertex from to the vertex to.
+	// The vertices are kept in the graph.
+	//
+	// It reports whether the edge was present.
+	//
+	// Time complexity: O(d), where d is the degree of the vertices.
+	RemoveEdge(from, to Vertex) (removed bool)
+
+	// Degree returns the number of neighbors of the specified vertex,
+	// i.e., the out-degree for a directed graph.
+	//
+	// A self-loop is counted once.
+	//
+	// It returns 0 if the vertex is absent.
+	Degree(v Vertex) int
+
+	// Vertices returns an iterator over the vertices of the graph,
+	// in the order they were added.
+	Vertices() iter.Seq[Vertex]
+
+	// Edges returns an iterator over the edges of the graph.
+	//
+	// For an undirected graph, each edge is yielded once,
+	// where the field From is the endpoint added to the graph earlier.
+	Edges() iter.Seq[Edge[Vertex, Weight]]
+
+	// Neighbors returns an iterator over the neighbors of
+	// the specified vertex (the heads of the outgoing edges
+	// for a directed graph) and the weights of the corresponding edges.
+	//
+	// It yields nothing if the vertex is absent.
+	Neighbors(v Vertex) iter.Seq2[Vertex, Weight]
+
+	// Clear removes all vertices and edges in the graph.
+	Clear()
+}
+
+// neighbor consists of an adjacent vertex and the weight of the edge.
+type neighbor[Vertex, Weight any] struct {
+	v Vertex
+	w Weight
+}
+
+// vertexData holds the adjacency list and indices of a vertex.
+type vertexData[Vertex comparable, Weight any] struct {
+	// idx is the index of the vertex in graph.vs.
+	idx int
+
+	// out is the adjacency list, in the order the edges were added.
+	out []neighbor[Vertex, Weight]
+
+	// outIdx maps the adjacent vertices to their indices in out.
+	outIdx map[Vertex]int
+
+	// in is the set of the tails of the incoming edges.
+	// It is used only in a directed graph.
+	in map[Vertex]struct{}
+}
+
+// removeOut removes the vertex v from the adjacency list.
+//
+// The caller must guarantee that v is in the adjacency list.
+func (vd *vertexData[Vertex, Weight]) removeOut(v Vertex) {
+	i := vd.outIdx[v]
+	delete(vd.outIdx, v)
+	vd.out = slices.Delete(vd.out, i, i+1)
+	for ; i < len(vd.out); i++ {
+		vd.outIdx[vd.out[i].v] = i
+	}
+}
+
+// graph is an implementation of interface Graph.
+type graph[Vertex comparable, Weight any] struct {
+	directed bool
+	numEdges int
+	vs       []Vertex
+	vdMap    map[Vertex]*vertexData[Vertex, Weight]
+}
+
+// New creates a new empty graph.
+//
+// directed indicates whether the graph is directed.
+func New[Vertex comparable, Weight any](directed bool) Graph[Vertex, Weight] {
+	return &graph[Vertex, Weight]{
+		directed: directed,
+		vdMap:    make(map[Vertex]*vertexData[Vertex, Weight]),
+	}
+}
+
+func (g *graph[Vertex, Weight]) Directed() bool {
+	return g.directed
+}
+
+func (g *graph[Vertex, Weight]) NumVertices() int {
+	return len(g.vs)
+}
+
+func (g *graph[Vertex, Weight]) NumEdges() int {
+	return g.numEdges
+}
+
+func (g *graph[Vertex, Weight]) HasVertex(v Vertex) bool {
+	_, ok := g.vdMap[v]
+	return ok
+}
+
+func (g *graph[Vertex, Weight]) AddVertex(v Vertex) (added bool) {
+	if g.vdMap[v] != nil {
+		return
+	}
+	g.addVertex(v)
+	return true
+}
+
+func (g *graph[Vertex, Weight]) RemoveVertex(v Vertex) (removed bool) {
+	vd := g.vdMap[v]
+	if vd == nil {
+		return
+	}
+	for _, nb := range vd.out {
+		if nb.v == v {
+			continue
+		}
+		if g.directed {
+			delete(g.vdMap[nb.v].in, v)
+		} else {
+			g.vdMap[nb.v].removeOut(v)
+		}
+	}
+	g.numEdges -= len(vd.out)
+	for u := range vd.in {
+		if u != v {
+			g.vdMap[u].removeOut(v)
+			g.numEdges--
+		}
+	}
+	delete(g.vdMap, v)
+	g.vs = slices.Delete(g.vs, vd.idx, vd.idx+1)
+	for i := vd.idx; i < len(g.vs); i++ {
+		g.vdMap[g.vs[i]].idx = i
+	}
+	return true
+}
+
+func (g *graph[Vertex, Weight]) HasEdge(from, to Vertex) bool {
+	vd := g.vdMap[from]
+	if vd == nil {
+		return false
+	}
+	_, ok := vd.outIdx[to]
+	return ok
+}
+
+func (g *graph[Vertex, Weight]) Weight(from, to Vertex) (
+	weight Weight, present bool) {
+	vd := g.vdMap[from]
+	if vd == nil {
+		return
+	}
+	i, ok := vd.outIdx[to]
+	if !ok {
+		return
+	}
+	return vd.out[i].w, true
+}
+
+func (g *graph[Vertex, Weight]) AddEdge(from, to Vertex, weight Weight) (
+	added bool) {
+	fromVD := g.vdMap[from]
+	if fromVD == nil {
+		fromVD = g.addVertex(from)
+	}
+	toVD := g.vdMap[to]
+	if toVD == nil {
+		toVD = g.addVertex(to)
+	}
+	if i, ok := fromVD.outIdx[to]; ok {
+		fromVD.out[i].w = weight
+		if !g.directed && from != to {
+			toVD.out[toVD.outIdx[from]].w = weight
+		}
+		return
+	}
+	fromVD.outIdx[to] = len(fromVD.out)
+	fromVD.out = append(fromVD.out, neighbor[Vertex, Weight]{v: to, w: weight})
+	if g.directed {
+		toVD.in[from] = struct{}{}
+	} else if from != to {
+		toVD.outIdx[from] = len(toVD.out)
+		toVD.out = append(toVD.out, neighbor[Vertex, Weight]{
+			v: from,
+			w: weight,
+		})
+	}
+	g.numEdges++
+	return true
+}
+
+func (g *graph[Vertex, Weight]) RemoveEdge(from, to Vertex) (removed bool) {
+	fromVD := g.vdMap[from]
+	if fromVD == nil {
+		return
+	}
+	if _, ok := fromVD.outIdx[to]; !ok {
+		return
+	}
+	fromVD.removeOut(to)
+	if g.directed {
+		delete(g.vdMap[to].in, from)
+	} else if from != to {
+		g.vdMap[to].removeOut(from)
+	}
+	g.numEdges--
+	return true
+}
+
+func (g *graph[Vertex, Weight]) Degree(v Vertex) int {
+	vd := g.vdMap[v]
+	if vd == nil {
+		return 0
+	}
+	return len(vd.out)
+}
+
+func (g *graph[Vertex, Weight]) Vertices() iter.Seq[Vertex] {
+	return func(yield func(Vertex) bool) {
+		for _, v := range g.vs {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+func (g *graph[Vertex, Weight]) Edges() iter.Seq[Edge[Vertex, Weight]] {
+	return func(yield func(Edge[Vertex, Weight]) bool) {
+		for _, v := range g.vs {
+			vd := g.vdMap[v]
+			for _, nb := range vd.out {
+				if !g.directed && g.vdMap[nb.v].idx < vd.idx {
+					continue // yielded as the edge from nb.v
+				}
+				if !yield(Edge[Vertex, Weight]{From: v, To: nb.v, Weight: nb.w}) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (g *graph[Vertex, Weight]) Neighbors(v Vertex) iter.Seq2[Vertex, Weight] {
+	return func(yield func(Vertex, Weight) bool) {
+		vd := g.vdMap[v]
+		if vd == nil {
+			return
+		}
+		for _, nb := range vd.out {
+			if !yield(nb.v, nb.w) {
+				return
+			}
+		}
+	}
+}
+
+func (g *graph[Vertex, Weight]) Clear() {
+	g.numEdges, g.vs = 0, nil
+	clear(g.vdMap)
+}
+
+// addVertex adds the vertex v to the graph and returns its data.
+//
+// The caller must guarantee that v is absent.
+func (g *graph[Vertex, Weight]) addVertex(
+	v Vertex,
+) *vertexData[Vertex, Weight] {
+	vd := &vertexData[Vertex, Weight]{
+		idx:    len(g.vs),
+		outIdx: make(map[Vertex]int),
+	}
+	if g.directed {
+		vd.in = make(map[Vertex]struct{})
+	}
+	g.vs = append(g.vs, v)
+	g.vdMap[v] = vd
+	return vd
+}
@@ -0,0 +1,220 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package graph_test
+
+import (
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/donyori/gogo/container/graph"
+)
+
+func TestGraph_Basic(t *testing.T) {
+	for _, directed := range []bool{false, true} {
+		t.Run(fmt.Sprintf("directed=%t", directed), func(t *testing.T) {
+			g := graph.New[string, int](directed)
+			if g.Directed() != directed {
+				t.Errorf("got Directed %t; want %t", g.Directed(), directed)
+			}
+			g.AddVertex("X")
+			if !g.AddEdge("A", "B", 1) {
+				t.Error("AddEdge A-B returned false")
+			}
+			g.AddEdge("A", "C", 2)
+			g.AddEdge("B", "C", 3)
+			g.AddEdge("C", "C", 4)
+			if g.AddEdge("A", "B", 5) {
+				t.Error("AddEdge A-B again returned true")
+			}
+			if got := g.NumVertices(); got != 4 {
+				t.Errorf("got NumVertices %d; want 4", got)
+			}
+			if got := g.NumEdges(); got != 4 {
+				t.Errorf("got NumEdges %d; want 4", got)
+			}
+			gotVs := slices.Collect(g.Vertices())
+			wantVs := []string{"X", "A", "B", "C"}
+			if !slices.Equal(gotVs, wantVs) {
+				t.Errorf("got Vertices %v; want %v", gotVs, wantVs)
+			}
+			gotEs := slices.Collect(g.Edges())
+			wantEs := []graph.Edge[string, int]{
+				{From: "A", To: "B", Weight: 5},
+				{From: "A", To: "C", Weight: 2},
+				{From: "B", To: "C", Weight: 3},
+				{From: "C", To: "C", Weight: 4},
+			}
+			if !slices.Equal(gotEs, wantEs) {
+				t.Errorf("got Edges %v; want %v", gotEs, wantEs)
+			}
+			gotNbs := slices.Collect(maps.Keys(maps.Collect(g.Neighbors("C"))))
+			slices.Sort(gotNbs)
+			wantNbs := []string{"C"}
+			if !directed {
+				wantNbs = []string{"A", "B", "C"}
+			}
+			if !slices.Equal(gotNbs, wantNbs) {
+				t.Errorf("got Neighbors(C) %v; want %v", gotNbs, wantNbs)
+			}
+			if w, ok := g.Weight("B", "A"); ok != !directed ||
+				(ok && w != 5) {
+				t.Errorf("got Weight(B, A) (%d, %t)", w, ok)
+			}
+			if !g.RemoveVertex("C") {
+				t.Error("RemoveVertex C returned false")
+			}
+			if got := g.NumEdges(); got != 1 {
+				t.Errorf("got NumEdges %d after removing C; want 1", got)
+			}
+			gotVs = slices.Collect(g.Vertices())
+			wantVs = []string{"X", "A", "B"}
+			if !slices.Equal(gotVs, wantVs) {
+				t.Errorf("got Vertices %v; want %v", gotVs, wantVs)
+			}
+			g.Clear()
+			if g.NumVertices() != 0 || g.NumEdges() != 0 {
+				t.Errorf("got (%d, %d) after Clear; want (0, 0)",
+					g.NumVertices(), g.NumEdges())
+			}
+		})
+	}
+}
+
+func TestEdge_String(t *testing.T) {
+	e := graph.Edge[string, int]{From: "A", To: "B", Weight: 1}
+	if got := e.String(); got != "A -> B (1)" {
+		t.Errorf("got %q; want %q", got, "A -> B (1)")
+	}
+}
+
+// model is a naive graph used to check the implementation.
+type model struct {
+	directed bool
+	vs       []int
+	es       map[[2]int]int
+}
+
+func (m *model) key(from, to int) [2]int {
+	if !m.directed && from > to {
+		from, to = to, from
+	}
+	return [2]int{from, to}
+}
+
+func (m *model) addVertex(v int) {
+	if !slices.Contains(m.vs, v) {
+		m.vs = append(m.vs, v)
+	}
+}
+
+func TestGraph_Random(t *testing.T) {
+	const NumVertex, NumOp = 12, 3000
+	for _, directed := range []bool{false, true} {
+		t.Run(fmt.Sprintf("directed=%t", directed), func(t *testing.T) {
+			random := rand.New(rand.NewChaCha8(
+				[32]byte([]byte("ABCDEFGHIJKLMNOPQRSTUVWXYZ123456"))))
+			g := graph.New[int, int](directed)
+			m := &model{directed: directed, es: make(map[[2]int]int)}
+			for i := range NumOp {
+				u, v, w := random.IntN(NumVertex), random.IntN(NumVertex), i
+				switch random.IntN(10) {
+				case 0:
+					m.addVertex(u)
+					g.AddVertex(u)
+				case 1:
+					idx := slices.Index(m.vs, u)
+					if got := g.RemoveVertex(u); got != (idx >= 0) {
+						t.Fatalf("op %d, got RemoveVertex(%d) %t", i, u, got)
+					}
+					if idx >= 0 {
+						m.vs = slices.Delete(m.vs, idx, idx+1)
+						maps.DeleteFunc(m.es, func(k [2]int, _ int) bool {
+							return k[0] == u || k[1] == u
+						})
+					}
+				case 2, 3:
+					k := m.key(u, v)
+					_, present := m.es[k]
+					if got := g.RemoveEdge(u, v); got != present {
+						t.Fatalf("op %d, got RemoveEdge(%d, %d) %t; want %t",
+							i, u, v, got, present)
+					}
+					delete(m.es, k)
+				default:
+					m.addVertex(u)
+					m.addVertex(v)
+					k := m.key(u, v)
+					_, present := m.es[k]
+					if got := g.AddEdge(u, v, w); got == present {
+						t.Fatalf("op %d, got AddEdge(%d, %d) %t; want %t",
+							i, u, v, got, !present)
+					}
+					m.es[k] = w
+				}
+				checkGraph(t, i, g, m)
+			}
+		})
+	}
+}
+
+func checkGraph(t *testing.T, op int, g graph.Graph[int, int], m *model) {
+	t.Helper()
+	if got := slices.Collect(g.Vertices()); !slices.Equal(got, m.vs) {
+		t.Fatalf("op %d, got Vertices %v; want %v", op, got, m.vs)
+	}
+	if g.NumVertices() != len(m.vs) {
+		t.Fatalf("op %d, got NumVertices %d; want %d",
+			op, g.NumVertices(), len(m.vs))
+	}
+	if g.NumEdges() != len(m.es) {
+		t.Fatalf("op %d, got NumEdges %d; want %d", op, g.NumEdges(), len(m.es))
+	}
+	es := make(map[[2]int]int, len(m.es))
+	for e := range g.Edges() {
+		k := m.key(e.From, e.To)
+		if _, dup := es[k]; dup {
+			t.Fatalf("op %d, edge %v yielded more than once", op, e)
+		}
+		es[k] = e.Weight
+	}
+	if !maps.Equal(es, m.es) {
+		t.Fatalf("op %d, got Edges %v; want %v", op, es, m.es)
+	}
+	for _, u := range m.vs {
+		deg := 0
+		for v, w := range g.Neighbors(u) {
+			deg++
+			if want, ok := m.es[m.key(u, v)]; !ok || w != want {
+				t.Fatalf("op %d, got neighbor (%d, %d) of %d; want (%d, %t)",
+					op, v, w, u, want, ok)
+			}
+			if gw, ok := g.Weight(u, v); !ok || gw != w || !g.HasEdge(u, v) {
+				t.Fatalf("op %d, got Weight(%d, %d) (%d, %t); want (%d, true)",
+					op, u, v, gw, ok, w)
+			}
+		}
+		if g.Degree(u) != deg {
+			t.Fatalf("op %d, got Degree(%d) %d; want %d",
+				op, u, g.Degree(u), deg)
+		}
+	}
+}
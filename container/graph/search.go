@@ -0,0 +1,142 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package graph
+
+import (
+	"fmt"
+
+	"github.com/donyori/gogo/algorithm/search/graphv"
+	"github.com/donyori/gogo/errors"
+)
+
+// Searcher is an adapter that enables the search algorithms in
+// package github.com/donyori/gogo/algorithm/search/graphv
+// (such as BFS, DFS, IDS, and their *Path variants) to run over a Graph.
+//
+// Its method Init accepts at most one argument to set the search goal:
+//   - a value of type Vertex: the vertex to find;
+//   - a function of type func(vertex Vertex) bool:
+//     a predicate to report whether a vertex is the search goal;
+//   - a function of type func(vertex Vertex, depth int) (found, cont bool):
+//     a visitor called on each vertex examined,
+//     with the same semantics as
+//     github.com/donyori/gogo/algorithm/search/graphv.AccessVertex;
+//   - nothing (or nil): no goal, the search traverses
+//     all vertices reachable from the root.
+//
+// Init panics if the argument is of any other type.
+type Searcher[Vertex comparable] interface {
+	graphv.IDSAccessVertex[Vertex]
+	graphv.IDSAccessPath[Vertex]
+}
+
+// searcher is an implementation of interface Searcher.
+type searcher[Vertex comparable, Weight any] struct {
+	g          Graph[Vertex, Weight]
+	root       Vertex
+	visit      func(vertex Vertex, depth int) (found, cont bool)
+	discovered map[Vertex]struct{}
+}
+
+// NewSearcher creates a new Searcher over the graph g,
+// starting the search at the specified root.
+//
+// The graph must not be modified during the search.
+//
+// It panics if g is nil.
+func NewSearcher[Vertex comparable, Weight any](
+	g Graph[Vertex, Weight],
+	root Vertex,
+) Searcher[Vertex] {
+	if g == nil {
+		panic(errors.AutoMsg("g is nil"))
+	}
+	return &searcher[Vertex, Weight]{
+		g:          g,
+		root:       root,
+		discovered: make(map[Vertex]struct{}),
+	}
+}
+
+// Init sets the search goal as well as resets the discovered vertices.
+//
+// See the documentation of interface Searcher for the argument.
+func (s *searcher[Vertex, Weight]) Init(args ...any) {
+	s.visit = nil
+	if len(args) > 0 {
+		switch goal := args[0].(type) {
+		case nil:
+		case func(vertex Vertex) bool:
+			s.visit = func(vertex Vertex, _ int) (found, cont bool) {
+				return goal(vertex), true
+			}
+		case func(vertex Vertex, depth int) (found, cont bool):
+			s.visit = goal
+		case Vertex:
+			s.visit = func(vertex Vertex, _ int) (found, cont bool) {
+				return vertex == goal, true
+			}
+		default:
+			panic(errors.AutoMsg(fmt.Sprintf(
+				"unsupported search goal type %T", args[0])))
+		}
+	}
+	clear(s.discovered)
+}
+
+func (s *searcher[Vertex, Weight]) Root() Vertex {
+	return s.root
+}
+
+// Adjacency returns the neighbors of the specified vertex in the graph,
+// in the order the corresponding edges were added.
+func (s *searcher[Vertex, Weight]) Adjacency(vertex Vertex) []Vertex {
+	n := s.g.Degree(vertex)
+	if n == 0 {
+		return nil
+	}
+	adj := make([]Vertex, 0, n)
+	for v := range s.g.Neighbors(vertex) {
+		adj = append(adj, v)
+	}
+	return adj
+}
+
+func (s *searcher[Vertex, Weight]) Discovered(vertex Vertex) bool {
+	_, ok := s.discovered[vertex]
+	return ok
+}
+
+func (s *searcher[Vertex, Weight]) AccessVertex(vertex Vertex, depth int) (
+	found, cont bool) {
+	s.discovered[vertex] = struct{}{}
+	if s.visit == nil {
+		return false, true
+	}
+	return s.visit(vertex, depth)
+}
+
+func (s *searcher[Vertex, Weight]) AccessPath(path []Vertex) (
+	found, cont bool) {
+	return s.AccessVertex(path[len(path)-1], len(path)-1)
+}
+
+func (s *searcher[Vertex, Weight]) ResetSearchState() {
+	clear(s.discovered)
+}
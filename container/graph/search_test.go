@@ -0,0 +1,123 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package graph_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/donyori/gogo/algorithm/search/graphv"
+	"github.com/donyori/gogo/container/graph"
+)
+
+// newSearchGraph creates an undirected graph as follows:
+//
+//	0 - 1 - 3 - 5
+//	|   |
+//	2 - 4   6
+func newSearchGraph() graph.Graph[int, struct{}] {
+	g := graph.New[int, struct{}](false)
+	for _, e := range [][2]int{{0, 1}, {0, 2}, {1, 3}, {1, 4}, {2, 4}, {3, 5}} {
+		g.AddEdge(e[0], e[1], struct{}{})
+	}
+	g.AddVertex(6)
+	return g
+}
+
+func TestSearcher_BFS_DFS(t *testing.T) {
+	s := graph.NewSearcher(newSearchGraph(), 0)
+	testCases := []struct {
+		name   string
+		search func(goal any) (int, bool)
+	}{
+		{"BFS", func(goal any) (int, bool) { return graphv.BFS(s, goal) }},
+		{"DFS", func(goal any) (int, bool) { return graphv.DFS(s, goal) }},
+		{"IDS", func(goal any) (int, bool) { return graphv.IDS(s, 0, goal) }},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for goal := range 7 {
+				v, found := tc.search(goal)
+				if want := goal != 6; found != want || found && v != goal {
+					t.Errorf("goal %d, got (%d, %t); want (%d, %t)",
+						goal, v, found, goal, want)
+				}
+			}
+			v, found := tc.search(func(vertex int) bool { return vertex > 3 })
+			if !found || v <= 3 {
+				t.Errorf("predicate goal, got (%d, %t)", v, found)
+			}
+		})
+	}
+}
+
+func TestSearcher_Visitor(t *testing.T) {
+	s := graph.NewSearcher(newSearchGraph(), 0)
+	var order, depths []int
+	_, found := graphv.BFS(s, func(vertex, depth int) (found, cont bool) {
+		order, depths = append(order, vertex), append(depths, depth)
+		return false, true
+	})
+	if found {
+		t.Error("found is true")
+	}
+	wantOrder, wantDepths := []int{0, 1, 2, 3, 4, 5}, []int{0, 1, 1, 2, 2, 3}
+	if !slices.Equal(order, wantOrder) || !slices.Equal(depths, wantDepths) {
+		t.Errorf("got order %v, depths %v; want %v, %v",
+			order, depths, wantOrder, wantDepths)
+	}
+
+	order = order[:0]
+	graphv.DFS(s, func(vertex, _ int) (found, cont bool) {
+		order = append(order, vertex)
+		return false, vertex != 3
+	})
+	if want := []int{0, 1, 3}; !slices.Equal(order, want) {
+		t.Errorf("got DFS order %v; want %v", order, want)
+	}
+}
+
+func TestSearcher_Path(t *testing.T) {
+	s := graph.NewSearcher(newSearchGraph(), 0)
+	got, want := graphv.BFSPath(s, 5), []int{0, 1, 3, 5}
+	if !slices.Equal(got, want) {
+		t.Errorf("got BFSPath %v; want %v", got, want)
+	}
+	got, want = graphv.DFSPath(s, 4), []int{0, 1, 4}
+	if !slices.Equal(got, want) {
+		t.Errorf("got DFSPath %v; want %v", got, want)
+	}
+	got, want = graphv.IDSPath(s, 1, 4), []int{0, 1, 4}
+	if !slices.Equal(got, want) {
+		t.Errorf("got IDSPath %v; want %v", got, want)
+	}
+	if got = graphv.BFSPath(s, 6); got != nil {
+		t.Errorf("got BFSPath %v; want nil", got)
+	}
+}
+
+func TestSearcher_Init_UnsupportedGoal(t *testing.T) {
+	s := graph.NewSearcher(newSearchGraph(), 0)
+	defer func() {
+		if recover() == nil {
+			t.Error("want panic but not")
+		}
+	}()
+	s.Init("0")
+}
@@ -0,0 +1,273 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pqueue
+
+import (
+	"container/heap"
+
+	"github.com/donyori/gogo/container"
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/function/compare"
+)
+
+// Handle is a reference to an item in an UpdatablePriorityQueue,
+// returned by its method Push.
+//
+// The client can use the handle to update or remove the item
+// without searching for it in the queue.
+type Handle[Item any] struct {
+	item  Item
+	index int                           // Index in the heap, or -1 if the item is not in the queue.
+	owner *updatablePriorityQueue[Item] // The queue that created the handle.
+}
+
+// Item returns the item referenced by the handle.
+//
+// After the item is removed from the queue,
+// it returns the item at the time of the removal.
+func (h *Handle[Item]) Item() Item {
+	return h.item
+}
+
+// UpdatablePriorityQueue is an interface representing a priority queue
+// that can change the priority of its items in O(log n),
+// as required by algorithms like Dijkstra's algorithm and A* search.
+//
+// The items are referenced by the handles returned by the method Push.
+//
+// Its method Range may not access items in a priority-related order.
+// It only guarantees that each item is accessed once.
+type UpdatablePriorityQueue[Item any] interface {
+	container.Container[Item]
+
+	// Cap returns the current capacity of the queue.
+	Cap() int
+
+	// Push adds x into the queue and returns the handle of x.
+	//
+	// Time complexity: O(log n), where n = pq.Len().
+	Push(x Item) *Handle[Item]
+
+	// Dequeue removes and returns the highest-priority item in the queue.
+	//
+	// It panics if the queue is nil or empty.
+	//
+	// Time complexity: O(log n), where n = pq.Len().
+	Dequeue() Item
+
+	// Top returns the highest-priority item in the queue,
+	// without modifying the queue.
+	//
+	// It panics if the queue is nil or empty.
+	//
+	// Time complexity: O(1).
+	Top() Item
+
+	// TopHandle returns the handle of the highest-priority item
+	// in the queue, without modifying the queue.
+	//
+	// It panics if the queue is nil or empty.
+	//
+	// Time complexity: O(1).
+	TopHandle() *Handle[Item]
+
+	// Contains reports whether the item referenced by h is in the queue.
+	//
+	// It returns false if h is nil or was created by another queue.
+	//
+	// Time complexity: O(1).
+	Contains(h *Handle[Item]) bool
+
+	// Update replaces the item referenced by h with newX,
+	// and restores the heap order,
+	// which increases or decreases the priority of the item.
+	//
+	// It panics if h is not in the queue (see method Contains).
+	//
+	// Time complexity: O(log n), where n = pq.Len().
+	Update(h *Handle[Item], newX Item)
+
+	// Remove removes and returns the item referenced by h.
+	//
+	// It panics if h is not in the queue (see method Contains).
+	//
+	// Time complexity: O(log n), where n = pq.Len().
+	Remove(h *Handle[Item]) Item
+
+	// Clear removes all items in the queue and asks to release the memory.
+	//
+	// The handles of the removed items are no longer in the queue.
+	Clear()
+}
+
+const handleNotInQueuePanicMessage = "handle is not in the priority queue"
+
+// updatablePriorityQueue is an implementation of
+// interface UpdatablePriorityQueue, based on container/heap.
+type updatablePriorityQueue[Item any] struct {
+	hh handleHeap[Item]
+}
+
+// NewUpdatable creates a new updatable priority queue.
+// In this priority queue, the smaller the item
+// (compared by the function lessFn), the higher its priority.
+//
+// lessFn is a function to report whether a < b.
+// It must describe a strict weak ordering.
+// See <https://en.wikipedia.org/wiki/Weak_ordering#Strict_weak_orderings>
+// for details.
+//
+// Note that floating-point comparison
+// (the < operator on float32 or float64 values)
+// is not a strict weak ordering when not-a-number (NaN) values are involved.
+//
+// capacity asks to allocate enough space to hold
+// the specified number of items.
+// Nonpositive values for no preallocation.
+//
+// It panics if lessFn is nil.
+func NewUpdatable[Item any](
+	lessFn compare.LessFunc[Item],
+	capacity int,
+) UpdatablePriorityQueue[Item] {
+	if lessFn == nil {
+		panic(errors.AutoMsg("lessFn is nil"))
+	}
+	pq := &updatablePriorityQueue[Item]{
+		handleHeap[Item]{lessFn: lessFn},
+	}
+	if capacity > 0 {
+		pq.hh.hs = make([]*Handle[Item], 0, capacity)
+	}
+	return pq
+}
+
+func (pq *updatablePriorityQueue[Item]) Len() int {
+	return len(pq.hh.hs)
+}
+
+// Range accesses the items in the queue.
+// Each item is accessed once.
+// The order of access may not involve priority.
+//
+// Its parameter handler is a function to deal with the item x in the
+// queue and report whether to continue to access the next item.
+//
+// The client should do read-only operations on x
+// to avoid corrupting the priority queue.
+func (pq *updatablePriorityQueue[Item]) Range(handler func(x Item) (cont bool)) {
+	for _, h := range pq.hh.hs {
+		if !handler(h.item) {
+			return
+		}
+	}
+}
+
+func (pq *updatablePriorityQueue[Item]) Cap() int {
+	return cap(pq.hh.hs)
+}
+
+func (pq *updatablePriorityQueue[Item]) Push(x Item) *Handle[Item] {
+	h := &Handle[Item]{item: x, owner: pq}
+	heap.Push(&pq.hh, h)
+	return h
+}
+
+func (pq *updatablePriorityQueue[Item]) Dequeue() Item {
+	if len(pq.hh.hs) == 0 {
+		panic(errors.AutoMsg(emptyQueuePanicMessage))
+	}
+	return heap.Pop(&pq.hh).(*Handle[Item]).item
+}
+
+func (pq *updatablePriorityQueue[Item]) Top() Item {
+	if len(pq.hh.hs) == 0 {
+		panic(errors.AutoMsg(emptyQueuePanicMessage))
+	}
+	return pq.hh.hs[0].item
+}
+
+func (pq *updatablePriorityQueue[Item]) TopHandle() *Handle[Item] {
+	if len(pq.hh.hs) == 0 {
+		panic(errors.AutoMsg(emptyQueuePanicMessage))
+	}
+	return pq.hh.hs[0]
+}
+
+func (pq *updatablePriorityQueue[Item]) Contains(h *Handle[Item]) bool {
+	return h != nil && h.owner == pq && h.index >= 0
+}
+
+func (pq *updatablePriorityQueue[Item]) Update(h *Handle[Item], newX Item) {
+	if !pq.Contains(h) {
+		panic(errors.AutoMsg(handleNotInQueuePanicMessage))
+	}
+	h.item = newX
+	heap.Fix(&pq.hh, h.index)
+}
+
+func (pq *updatablePriorityQueue[Item]) Remove(h *Handle[Item]) Item {
+	if !pq.Contains(h) {
+		panic(errors.AutoMsg(handleNotInQueuePanicMessage))
+	}
+	return heap.Remove(&pq.hh, h.index).(*Handle[Item]).item
+}
+
+func (pq *updatablePriorityQueue[Item]) Clear() {
+	for _, h := range pq.hh.hs {
+		h.index = -1
+	}
+	pq.hh.hs = nil
+}
+
+// handleHeap is a slice of handles
+// that implements the interface container/heap.Interface,
+// maintaining the field index of each handle.
+type handleHeap[Item any] struct {
+	hs     []*Handle[Item]
+	lessFn compare.LessFunc[Item]
+}
+
+func (hh *handleHeap[Item]) Len() int {
+	return len(hh.hs)
+}
+
+func (hh *handleHeap[Item]) Less(i, j int) bool {
+	return hh.lessFn(hh.hs[i].item, hh.hs[j].item)
+}
+
+func (hh *handleHeap[Item]) Swap(i, j int) {
+	hh.hs[i], hh.hs[j] = hh.hs[j], hh.hs[i]
+	hh.hs[i].index, hh.hs[j].index = i, j
+}
+
+func (hh *handleHeap[Item]) Push(x any) {
+	h := x.(*Handle[Item])
+	h.index = len(hh.hs)
+	hh.hs = append(hh.hs, h)
+}
+
+func (hh *handleHeap[Item]) Pop() any {
+	back := len(hh.hs) - 1
+	h := hh.hs[back]
+	hh.hs[back] = nil // avoid memory leak
+	hh.hs = hh.hs[:back]
+	h.index = -1
+	return h
+}
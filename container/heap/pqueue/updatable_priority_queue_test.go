@@ -0,0 +1,152 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pqueue_test
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/donyori/gogo/container/heap/pqueue"
+)
+
+func TestUpdatablePriorityQueue_PushDequeue(t *testing.T) {
+	for _, data := range dataList {
+		sorted := copyAndSort(data)
+		t.Run("data="+sliceToName(data), func(t *testing.T) {
+			pq := pqueue.NewUpdatable(IntLess, len(data))
+			for _, x := range data {
+				if h := pq.Push(x); h.Item() != x {
+					t.Errorf("got handle item %d; want %d", h.Item(), x)
+				}
+			}
+			if n := pq.Len(); n != len(data) {
+				t.Errorf("got len %d; want %d", n, len(data))
+			}
+			for i, want := range sorted {
+				if x := pq.Top(); x != want {
+					t.Errorf("i:%d, got top %d; want %d", i, x, want)
+				}
+				if x := pq.Dequeue(); x != want {
+					t.Errorf("i:%d, got %d; want %d", i, x, want)
+				}
+			}
+			defer func() {
+				if e := recover(); !isDequeuePanicMessage(e) {
+					t.Error(e)
+				}
+			}()
+			x := pq.Dequeue() // want panic here
+			t.Errorf("dequeued more than %d items, got %v", len(sorted), x)
+		})
+	}
+}
+
+func TestUpdatablePriorityQueue_UpdateRemove(t *testing.T) {
+	random := rand.New(rand.NewChaCha8([32]byte([]byte("ABCDEFGHIJKLMNOPQRSTUVWXYZ123456"))))
+	for round := range 100 {
+		pq := pqueue.NewUpdatable(IntLess, 0)
+		var handles []*pqueue.Handle[int]
+		var want []int
+		for range random.IntN(30) {
+			x := random.IntN(50)
+			handles = append(handles, pq.Push(x))
+			want = append(want, x)
+		}
+		for range 40 {
+			if len(handles) == 0 {
+				break
+			}
+			i := random.IntN(len(handles))
+			h := handles[i]
+			if !pq.Contains(h) {
+				t.Fatalf("round %d, handle %d not in queue", round, i)
+			}
+			if random.IntN(3) == 0 {
+				if x := pq.Remove(h); x != want[i] {
+					t.Fatalf("round %d, got removed %d; want %d", round, x, want[i])
+				}
+				if pq.Contains(h) {
+					t.Fatalf("round %d, removed handle is still in queue", round)
+				}
+				handles = slices.Delete(handles, i, i+1)
+				want = slices.Delete(want, i, i+1)
+				continue
+			}
+			x := random.IntN(50)
+			pq.Update(h, x)
+			want[i] = x
+			if h.Item() != x {
+				t.Fatalf("round %d, got handle item %d; want %d", round, h.Item(), x)
+			}
+		}
+		slices.Sort(want)
+		for i, w := range want {
+			top := pq.TopHandle()
+			if x := pq.Dequeue(); x != w {
+				t.Fatalf("round %d, i:%d, got %d; want %d", round, i, x, w)
+			}
+			if pq.Contains(top) {
+				t.Fatalf("round %d, i:%d, dequeued handle is still in queue", round, i)
+			}
+		}
+		if n := pq.Len(); n != 0 {
+			t.Errorf("round %d, got len %d after dequeuing all; want 0", round, n)
+		}
+	}
+}
+
+func TestUpdatablePriorityQueue_Contains(t *testing.T) {
+	pq1 := pqueue.NewUpdatable(IntLess, 0)
+	pq2 := pqueue.NewUpdatable(IntLess, 0)
+	h1, h2 := pq1.Push(1), pq2.Push(2)
+	if !pq1.Contains(h1) {
+		t.Error("pq1 does not contain h1")
+	}
+	if pq1.Contains(h2) {
+		t.Error("pq1 contains h2")
+	}
+	if pq1.Contains(nil) {
+		t.Error("pq1 contains nil")
+	}
+	defer func() {
+		if e := recover(); e == nil {
+			t.Error("want panic but not")
+		}
+	}()
+	pq1.Update(h2, 0) // want panic here
+}
+
+func TestUpdatablePriorityQueue_Clear(t *testing.T) {
+	pq := pqueue.NewUpdatable(IntLess, 0)
+	handles := []*pqueue.Handle[int]{pq.Push(3), pq.Push(1), pq.Push(2)}
+	pq.Clear()
+	if n := pq.Len(); n != 0 {
+		t.Errorf("got len %d; want 0", n)
+	}
+	for i, h := range handles {
+		if pq.Contains(h) {
+			t.Errorf("handle %d is still in queue", i)
+		}
+	}
+	h := pq.Push(5)
+	if x := pq.Top(); x != 5 || !pq.Contains(h) {
+		t.Errorf("got top %d, contains %t; want 5, true", x, pq.Contains(h))
+	}
+}
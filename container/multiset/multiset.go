@@ -0,0 +1,302 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package multiset
+
+import (
+	"cmp"
+	"fmt"
+	"iter"
+	"slices"
+
+	"github.com/donyori/gogo/container"
+	"github.com/donyori/gogo/container/mapping"
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/function/compare"
+)
+
+// Multiset is an interface representing a multiset,
+// which is a set that allows duplicate items.
+//
+// Its method Len returns the total number of items,
+// counting duplicates.
+// Its method Range accesses each item as many times as it occurs,
+// and the occurrences of the same item are accessed consecutively.
+type Multiset[Item any] interface {
+	container.Container[Item]
+
+	// Filter refines items in the multiset.
+	//
+	// Its parameter filter is a function to report whether to keep
+	// all occurrences of the item x.
+	// It is called once for each distinct item.
+	Filter(filter func(x Item, count int) (keep bool))
+
+	// NumDistinct returns the number of distinct items in the multiset.
+	NumDistinct() int
+
+	// Count returns the number of occurrences of x in the multiset.
+	Count(x Item) int
+
+	// Add adds n occurrences of x to the multiset.
+	//
+	// It panics if n is negative.
+	Add(x Item, n int)
+
+	// AddItems adds one occurrence of each item in c to the multiset.
+	AddItems(c container.Container[Item])
+
+	// Remove removes at most n occurrences of x from the multiset
+	// and returns the number of occurrences removed.
+	//
+	// It panics if n is negative.
+	Remove(x Item, n int) (removed int)
+
+	// RemoveAll removes all occurrences of x from the multiset
+	// and returns the number of occurrences removed.
+	RemoveAll(x Item) (removed int)
+
+	// SetCount sets the number of occurrences of x to n
+	// and returns the previous number.
+	//
+	// It panics if n is negative.
+	SetCount(x Item, n int) (previous int)
+
+	// Counts returns an iterator over the distinct items
+	// and their numbers of occurrences.
+	// The order of iteration is random.
+	Counts() iter.Seq2[Item, int]
+
+	// Equal reports whether the multiset other contains
+	// the same items with the same numbers of occurrences
+	// as this multiset.
+	//
+	// A nil other is treated as an empty multiset.
+	Equal(other Multiset[Item]) bool
+
+	// MostCommon returns the k distinct items with the most occurrences
+	// and their numbers of occurrences,
+	// in descending order of the numbers of occurrences.
+	// The items with the same number of occurrences
+	// are in unspecified order.
+	//
+	// If k is negative or greater than NumDistinct(),
+	// it returns all distinct items.
+	MostCommon(k int) []mapping.Entry[Item, int]
+
+	// ToSortedSlice returns a new Go slice consisting of all items
+	// (counting duplicates) in ascending order,
+	// compared by the function lessFn.
+	//
+	// lessFn is a function to report whether a < b.
+	// It must describe a strict weak ordering.
+	//
+	// It panics if lessFn is nil.
+	ToSortedSlice(lessFn compare.LessFunc[Item]) []Item
+
+	// Clear removes all items in the multiset and asks to release the memory.
+	Clear()
+}
+
+// mapMultiset is an implementation of interface Multiset
+// based on Go map.
+type mapMultiset[Item comparable] struct {
+	m map[Item]int // The number of occurrences of each item, always positive.
+	n int          // The total number of occurrences.
+}
+
+// New creates a new Go-map-based multiset.
+//
+// items are the initial items added to the multiset.
+func New[Item comparable](items container.Container[Item]) Multiset[Item] {
+	ms := &mapMultiset[Item]{m: make(map[Item]int)}
+	ms.AddItems(items)
+	return ms
+}
+
+func (ms *mapMultiset[Item]) Len() int {
+	return ms.n
+}
+
+// Range accesses each item in the multiset as many times as it occurs.
+// The order of distinct items is random,
+// and the occurrences of the same item are accessed consecutively.
+//
+// Its parameter handler is a function to deal with the item x in the
+// multiset and report whether to continue to access the next item.
+func (ms *mapMultiset[Item]) Range(handler func(x Item) (cont bool)) {
+	for x, c := range ms.m {
+		for range c {
+			if !handler(x) {
+				return
+			}
+		}
+	}
+}
+
+func (ms *mapMultiset[Item]) Filter(filter func(x Item, count int) (keep bool)) {
+	for x, c := range ms.m {
+		if !filter(x, c) {
+			delete(ms.m, x)
+			ms.n -= c
+		}
+	}
+}
+
+func (ms *mapMultiset[Item]) NumDistinct() int {
+	return len(ms.m)
+}
+
+func (ms *mapMultiset[Item]) Count(x Item) int {
+	return ms.m[x]
+}
+
+func (ms *mapMultiset[Item]) Add(x Item, n int) {
+	checkNonnegative(n)
+	if n > 0 {
+		ms.m[x] += n
+		ms.n += n
+	}
+}
+
+func (ms *mapMultiset[Item]) AddItems(c container.Container[Item]) {
+	if c == nil || c.Len() == 0 {
+		return
+	}
+	c.Range(func(x Item) (cont bool) {
+		ms.m[x]++
+		ms.n++
+		return true
+	})
+}
+
+func (ms *mapMultiset[Item]) Remove(x Item, n int) (removed int) {
+	checkNonnegative(n)
+	c := ms.m[x]
+	if c == 0 || n == 0 {
+		return 0
+	} else if n >= c {
+		delete(ms.m, x)
+		ms.n -= c
+		return c
+	}
+	ms.m[x] = c - n
+	ms.n -= n
+	return n
+}
+
+func (ms *mapMultiset[Item]) RemoveAll(x Item) (removed int) {
+	removed = ms.m[x]
+	if removed > 0 {
+		delete(ms.m, x)
+		ms.n -= removed
+	}
+	return
+}
+
+func (ms *mapMultiset[Item]) SetCount(x Item, n int) (previous int) {
+	checkNonnegative(n)
+	previous = ms.m[x]
+	if n > 0 {
+		ms.m[x] = n
+	} else {
+		delete(ms.m, x)
+	}
+	ms.n += n - previous
+	return
+}
+
+func (ms *mapMultiset[Item]) Counts() iter.Seq2[Item, int] {
+	return func(yield func(Item, int) bool) {
+		for x, c := range ms.m {
+			if !yield(x, c) {
+				return
+			}
+		}
+	}
+}
+
+func (ms *mapMultiset[Item]) Equal(other Multiset[Item]) bool {
+	if other == nil {
+		return ms.n == 0
+	} else if other.Len() != ms.n || other.NumDistinct() != len(ms.m) {
+		return false
+	}
+	for x, c := range ms.m {
+		if other.Count(x) != c {
+			return false
+		}
+	}
+	return true
+}
+
+func (ms *mapMultiset[Item]) MostCommon(k int) []mapping.Entry[Item, int] {
+	if k < 0 || k > len(ms.m) {
+		k = len(ms.m)
+	}
+	if k == 0 {
+		return nil
+	}
+	entries := make([]mapping.Entry[Item, int], 0, len(ms.m))
+	for x, c := range ms.m {
+		entries = append(entries, mapping.Entry[Item, int]{Key: x, Value: c})
+	}
+	slices.SortFunc(entries, func(a, b mapping.Entry[Item, int]) int {
+		return cmp.Compare(b.Value, a.Value)
+	})
+	return entries[:k:k]
+}
+
+func (ms *mapMultiset[Item]) ToSortedSlice(lessFn compare.LessFunc[Item]) []Item {
+	if lessFn == nil {
+		panic(errors.AutoMsg("lessFn is nil"))
+	} else if ms.n == 0 {
+		return nil
+	}
+	distinct := make([]Item, 0, len(ms.m))
+	for x := range ms.m {
+		distinct = append(distinct, x)
+	}
+	slices.SortFunc(distinct, func(a, b Item) int {
+		switch {
+		case lessFn(a, b):
+			return -1
+		case lessFn(b, a):
+			return 1
+		}
+		return 0
+	})
+	s := make([]Item, 0, ms.n)
+	for _, x := range distinct {
+		for range ms.m[x] {
+			s = append(s, x)
+		}
+	}
+	return s
+}
+
+func (ms *mapMultiset[Item]) Clear() {
+	ms.m, ms.n = make(map[Item]int), 0
+}
+
+// checkNonnegative panics if n is negative.
+func checkNonnegative(n int) {
+	if n < 0 {
+		panic(errors.AutoMsgCustom(fmt.Sprintf("n (%d) is negative", n), -1, 1))
+	}
+}
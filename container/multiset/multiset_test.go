@@ -0,0 +1,145 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package multiset_test
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/donyori/gogo/container/multiset"
+	"github.com/donyori/gogo/container/sequence/array"
+	"github.com/donyori/gogo/function/compare"
+)
+
+type StrSDAPtr = *array.SliceDynamicArray[string]
+
+func TestNew(t *testing.T) {
+	data := []string{"b", "a", "c", "a", "b", "a"}
+	ms := multiset.New(StrSDAPtr(&data))
+	checkMultiset(t, ms, map[string]int{"a": 3, "b": 2, "c": 1})
+	if got := ms.ToSortedSlice(compare.OrderedLess[string]); !slices.Equal(
+		got, []string{"a", "a", "a", "b", "b", "c"}) {
+		t.Errorf("got ToSortedSlice %v; want [a a a b b c]", got)
+	}
+	var ranged []string
+	ms.Range(func(x string) (cont bool) {
+		ranged = append(ranged, x)
+		return true
+	})
+	slices.Sort(ranged)
+	if !slices.Equal(ranged, []string{"a", "a", "a", "b", "b", "c"}) {
+		t.Errorf("got Range %v; want [a a a b b c]", ranged)
+	}
+}
+
+func TestMultiset_AddRemove(t *testing.T) {
+	ms := multiset.New[string](nil)
+	ms.Add("x", 3)
+	ms.Add("y", 0)
+	checkMultiset(t, ms, map[string]int{"x": 3})
+	if removed := ms.Remove("x", 2); removed != 2 {
+		t.Errorf("got removed %d; want 2", removed)
+	}
+	checkMultiset(t, ms, map[string]int{"x": 1})
+	if removed := ms.Remove("x", 5); removed != 1 {
+		t.Errorf("got removed %d; want 1", removed)
+	}
+	checkMultiset(t, ms, map[string]int{})
+	ms.Add("y", 4)
+	if prev := ms.SetCount("y", 2); prev != 4 {
+		t.Errorf("got previous %d; want 4", prev)
+	}
+	if prev := ms.SetCount("z", 1); prev != 0 {
+		t.Errorf("got previous %d; want 0", prev)
+	}
+	checkMultiset(t, ms, map[string]int{"y": 2, "z": 1})
+	if removed := ms.RemoveAll("y"); removed != 2 {
+		t.Errorf("got removed %d; want 2", removed)
+	}
+	ms.SetCount("z", 0)
+	checkMultiset(t, ms, map[string]int{})
+
+	defer func() {
+		if e := recover(); e == nil {
+			t.Error("want panic but not")
+		}
+	}()
+	ms.Add("x", -1) // want panic here
+}
+
+func TestMultiset_FilterEqualClear(t *testing.T) {
+	data := []string{"a", "b", "b", "c", "c", "c"}
+	ms := multiset.New(StrSDAPtr(&data))
+	other := multiset.New(StrSDAPtr(&data))
+	if !ms.Equal(other) {
+		t.Error("got Equal false; want true")
+	}
+	other.Add("a", 1)
+	if ms.Equal(other) {
+		t.Error("got Equal true after adding; want false")
+	}
+	ms.Filter(func(x string, count int) (keep bool) {
+		return count >= 2
+	})
+	checkMultiset(t, ms, map[string]int{"b": 2, "c": 3})
+	ms.Clear()
+	checkMultiset(t, ms, map[string]int{})
+	if !ms.Equal(nil) {
+		t.Error("empty multiset is not equal to nil")
+	}
+}
+
+func TestMultiset_MostCommon(t *testing.T) {
+	data := []string{"a", "b", "b", "c", "c", "c"}
+	ms := multiset.New(StrSDAPtr(&data))
+	got := ms.MostCommon(2)
+	if len(got) != 2 || got[0].Key != "c" || got[0].Value != 3 ||
+		got[1].Key != "b" || got[1].Value != 2 {
+		t.Errorf("got %v; want [c: 3 b: 2]", got)
+	}
+	if got = ms.MostCommon(-1); len(got) != 3 {
+		t.Errorf("got %v; want 3 entries", got)
+	}
+	if got = ms.MostCommon(0); got != nil {
+		t.Errorf("got %v; want <nil>", got)
+	}
+}
+
+func checkMultiset(t *testing.T, ms multiset.Multiset[string], want map[string]int) {
+	t.Helper()
+	var total int
+	for _, c := range want {
+		total += c
+	}
+	if n := ms.Len(); n != total {
+		t.Errorf("got len %d; want %d", n, total)
+	}
+	if n := ms.NumDistinct(); n != len(want) {
+		t.Errorf("got NumDistinct %d; want %d", n, len(want))
+	}
+	for x, c := range want {
+		if got := ms.Count(x); got != c {
+			t.Errorf("got Count(%q) %d; want %d", x, got, c)
+		}
+	}
+	if got := maps.Collect(ms.Counts()); !maps.Equal(got, want) {
+		t.Errorf("got Counts %v; want %v", got, want)
+	}
+}
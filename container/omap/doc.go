@@ -0,0 +1,30 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package omap provides an ordered map based on a balanced binary search tree
+// (an AVL tree).
+//
+// The ordered map implements the interface
+// github.com/donyori/gogo/container/mapping.Map,
+// and keeps its key-value pairs sorted by key,
+// which supports queries for the minimum and maximum keys,
+// range queries, and iteration in key order.
+//
+// For better performance, all functions in this package are unsafe
+// for concurrency unless otherwise specified.
+package omap
@@ -0,0 +1,66 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package omap
+
+import "fmt"
+
+// Export for testing only.
+
+// CheckTree checks whether the tree of the ordered map m
+// is a valid AVL tree with the correct number of nodes.
+//
+// It returns nil if the tree is valid.
+// Otherwise, it returns an error describing the problem.
+func CheckTree[Key, Value any](m OrderedMap[Key, Value]) error {
+	om := m.(*orderedMap[Key, Value])
+	var n int
+	var check func(nd *node[Key, Value], lo, hi *Key) (int, error)
+	check = func(nd *node[Key, Value], lo, hi *Key) (int, error) {
+		if nd == nil {
+			return 0, nil
+		}
+		n++
+		if lo != nil && !om.t.lessFn(*lo, nd.key) ||
+			hi != nil && !om.t.lessFn(nd.key, *hi) {
+			return 0, fmt.Errorf("key %v is out of order", nd.key)
+		}
+		lh, err := check(nd.left, lo, &nd.key)
+		if err != nil {
+			return 0, err
+		}
+		rh, err := check(nd.right, &nd.key, hi)
+		if err != nil {
+			return 0, err
+		}
+		if h := max(lh, rh) + 1; nd.height != h {
+			return 0, fmt.Errorf("key %v, got height %d; want %d",
+				nd.key, nd.height, h)
+		} else if lh-rh > 1 || rh-lh > 1 {
+			return 0, fmt.Errorf("key %v is unbalanced, heights %d and %d",
+				nd.key, lh, rh)
+		}
+		return nd.height, nil
+	}
+	if _, err := check(om.t.root, nil, nil); err != nil {
+		return err
+	} else if n != om.t.n {
+		return fmt.Errorf("got %d nodes; want %d", n, om.t.n)
+	}
+	return nil
+}
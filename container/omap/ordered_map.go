@@ -0,0 +1,318 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package omap
+
+import (
+	"iter"
+
+	"github.com/donyori/gogo/container/mapping"
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/function/compare"
+)
+
+// OrderedMap is an interface representing a map
+// whose key-value pairs are sorted by key.
+//
+// Its methods Range and Filter access the key-value pairs
+// in ascending order of their keys.
+//
+// The map must not be modified during ranging or iterating over it,
+// except by the method Filter itself.
+type OrderedMap[Key, Value any] interface {
+	mapping.Map[Key, Value]
+
+	// Min returns the key-value pair with the minimum key.
+	//
+	// It also returns an indicator present to report
+	// whether the map is nonempty.
+	//
+	// Time complexity: O(log n), where n = m.Len().
+	Min() (entry mapping.Entry[Key, Value], present bool)
+
+	// Max returns the key-value pair with the maximum key.
+	//
+	// It also returns an indicator present to report
+	// whether the map is nonempty.
+	//
+	// Time complexity: O(log n), where n = m.Len().
+	Max() (entry mapping.Entry[Key, Value], present bool)
+
+	// Floor returns the key-value pair with the greatest key
+	// less than or equal to the specified key.
+	//
+	// It also returns an indicator present to report
+	// whether such a key-value pair exists.
+	//
+	// Time complexity: O(log n), where n = m.Len().
+	Floor(key Key) (entry mapping.Entry[Key, Value], present bool)
+
+	// Ceiling returns the key-value pair with the least key
+	// greater than or equal to the specified key.
+	//
+	// It also returns an indicator present to report
+	// whether such a key-value pair exists.
+	//
+	// Time complexity: O(log n), where n = m.Len().
+	Ceiling(key Key) (entry mapping.Entry[Key, Value], present bool)
+
+	// RangeBetween accesses the key-value pairs
+	// with keys in the interval [low, high),
+	// in ascending order of their keys.
+	//
+	// Its parameter handler is a function to deal with the key-value pair x
+	// and report whether to continue to access the next key-value pair.
+	//
+	// Time complexity: O(log n + k), where n = m.Len(),
+	// and k is the number of key-value pairs accessed.
+	RangeBetween(
+		low, high Key,
+		handler func(x mapping.Entry[Key, Value]) (cont bool),
+	)
+
+	// All returns an iterator over the key-value pairs
+	// in ascending order of their keys.
+	All() iter.Seq2[Key, Value]
+
+	// Backward returns an iterator over the key-value pairs
+	// in descending order of their keys.
+	Backward() iter.Seq2[Key, Value]
+
+	// Between returns an iterator over the key-value pairs
+	// with keys in the interval [low, high),
+	// in ascending order of their keys.
+	Between(low, high Key) iter.Seq2[Key, Value]
+}
+
+// orderedMap is an implementation of interface OrderedMap,
+// based on an AVL tree.
+type orderedMap[Key, Value any] struct {
+	t tree[Key, Value]
+}
+
+// New creates a new ordered map.
+//
+// lessFn is a function to report whether a < b for the keys.
+// It must describe a strict weak ordering.
+// See <https://en.wikipedia.org/wiki/Weak_ordering#Strict_weak_orderings>
+// for details.
+// Two keys a and b are considered equal if
+// !lessFn(a, b) && !lessFn(b, a).
+//
+// Note that floating-point comparison
+// (the < operator on float32 or float64 values)
+// is not a strict weak ordering when not-a-number (NaN) values are involved.
+//
+// data is the initial key-value pairs added to the map.
+//
+// It panics if lessFn is nil.
+func New[Key, Value any](
+	lessFn compare.LessFunc[Key],
+	data mapping.Map[Key, Value],
+) OrderedMap[Key, Value] {
+	if lessFn == nil {
+		panic(errors.AutoMsg("lessFn is nil"))
+	}
+	m := &orderedMap[Key, Value]{tree[Key, Value]{lessFn: lessFn}}
+	m.SetMap(data)
+	return m
+}
+
+func (m *orderedMap[Key, Value]) Len() int {
+	return m.t.n
+}
+
+// Range accesses the key-value pairs in the map
+// in ascending order of their keys.
+// Each key-value pair is accessed once.
+//
+// Its parameter handler is a function to deal with the key-value pair x
+// in the map and report whether to continue to access the next key-value pair.
+func (m *orderedMap[Key, Value]) Range(
+	handler func(x mapping.Entry[Key, Value]) (cont bool),
+) {
+	m.t.ascend(m.t.root, nil, nil, func(nd *node[Key, Value]) bool {
+		return handler(mapping.Entry[Key, Value]{Key: nd.key, Value: nd.value})
+	})
+}
+
+// Filter refines key-value pairs in the map.
+//
+// Its parameter filter is a function to report
+// whether to keep the key-value pair x.
+// The key-value pairs are passed to filter
+// in ascending order of their keys.
+//
+// Time complexity: O(n), where n = m.Len().
+func (m *orderedMap[Key, Value]) Filter(
+	filter func(x mapping.Entry[Key, Value]) (keep bool),
+) {
+	if m.t.n == 0 {
+		return
+	}
+	kept := make([]*node[Key, Value], 0, m.t.n)
+	m.t.ascend(m.t.root, nil, nil, func(nd *node[Key, Value]) bool {
+		if filter(mapping.Entry[Key, Value]{Key: nd.key, Value: nd.value}) {
+			kept = append(kept, nd)
+		}
+		return true
+	})
+	if len(kept) < m.t.n {
+		m.t.root, m.t.n = build(kept), len(kept)
+	}
+}
+
+func (m *orderedMap[Key, Value]) Get(key Key) (value Value, present bool) {
+	if nd := m.t.find(key); nd != nil {
+		value, present = nd.value, true
+	}
+	return
+}
+
+func (m *orderedMap[Key, Value]) Set(key Key, value Value) {
+	m.t.insert(key, value)
+}
+
+func (m *orderedMap[Key, Value]) GetAndSet(key Key, value Value) (
+	previous Value, present bool) {
+	return m.t.insert(key, value)
+}
+
+func (m *orderedMap[Key, Value]) SetMap(other mapping.Map[Key, Value]) {
+	if other == nil || other.Len() == 0 {
+		return
+	}
+	other.Range(func(x mapping.Entry[Key, Value]) (cont bool) {
+		m.t.insert(x.Key, x.Value)
+		return true
+	})
+}
+
+// GetAndSetMap adds the key-value pairs in other to this map.
+// Any existing mapping is overwritten.
+//
+// Unlike SetMap, GetAndSetMap returns the previous values (if any)
+// bound to the keys in other in the form of OrderedMap
+// (with the same key order as this map).
+// If other is nil or empty, or all keys in other are not present
+// in this map, GetAndSetMap returns nil.
+func (m *orderedMap[Key, Value]) GetAndSetMap(
+	other mapping.Map[Key, Value],
+) (previous mapping.Map[Key, Value]) {
+	if other == nil || other.Len() == 0 {
+		return
+	}
+	var prev *orderedMap[Key, Value]
+	other.Range(func(x mapping.Entry[Key, Value]) (cont bool) {
+		v, present := m.t.insert(x.Key, x.Value)
+		if present {
+			if prev == nil {
+				prev = &orderedMap[Key, Value]{
+					tree[Key, Value]{lessFn: m.t.lessFn},
+				}
+			}
+			prev.t.insert(x.Key, v)
+		}
+		return true
+	})
+	if prev != nil {
+		previous = prev
+	}
+	return
+}
+
+func (m *orderedMap[Key, Value]) Remove(key ...Key) {
+	for _, k := range key {
+		if m.t.n == 0 {
+			return
+		}
+		m.t.delete(k)
+	}
+}
+
+func (m *orderedMap[Key, Value]) GetAndRemove(key Key) (
+	previous Value, present bool) {
+	return m.t.delete(key)
+}
+
+func (m *orderedMap[Key, Value]) Clear() {
+	m.t.root, m.t.n = nil, 0
+}
+
+func (m *orderedMap[Key, Value]) Min() (
+	entry mapping.Entry[Key, Value], present bool) {
+	return toEntry(m.t.first())
+}
+
+func (m *orderedMap[Key, Value]) Max() (
+	entry mapping.Entry[Key, Value], present bool) {
+	return toEntry(m.t.last())
+}
+
+func (m *orderedMap[Key, Value]) Floor(key Key) (
+	entry mapping.Entry[Key, Value], present bool) {
+	return toEntry(m.t.floor(key))
+}
+
+func (m *orderedMap[Key, Value]) Ceiling(key Key) (
+	entry mapping.Entry[Key, Value], present bool) {
+	return toEntry(m.t.ceiling(key))
+}
+
+func (m *orderedMap[Key, Value]) RangeBetween(
+	low, high Key,
+	handler func(x mapping.Entry[Key, Value]) (cont bool),
+) {
+	m.t.ascend(m.t.root, &low, &high, func(nd *node[Key, Value]) bool {
+		return handler(mapping.Entry[Key, Value]{Key: nd.key, Value: nd.value})
+	})
+}
+
+func (m *orderedMap[Key, Value]) All() iter.Seq2[Key, Value] {
+	return func(yield func(Key, Value) bool) {
+		m.t.ascend(m.t.root, nil, nil, func(nd *node[Key, Value]) bool {
+			return yield(nd.key, nd.value)
+		})
+	}
+}
+
+func (m *orderedMap[Key, Value]) Backward() iter.Seq2[Key, Value] {
+	return func(yield func(Key, Value) bool) {
+		descend(m.t.root, func(nd *node[Key, Value]) bool {
+			return yield(nd.key, nd.value)
+		})
+	}
+}
+
+func (m *orderedMap[Key, Value]) Between(low, high Key) iter.Seq2[Key, Value] {
+	return func(yield func(Key, Value) bool) {
+		m.t.ascend(m.t.root, &low, &high, func(nd *node[Key, Value]) bool {
+			return yield(nd.key, nd.value)
+		})
+	}
+}
+
+// toEntry returns the key-value pair of nd and true,
+// or a zero-value entry and false if nd is nil.
+func toEntry[Key, Value any](nd *node[Key, Value]) (
+	entry mapping.Entry[Key, Value], present bool) {
+	if nd != nil {
+		entry, present = mapping.Entry[Key, Value]{Key: nd.key, Value: nd.value}, true
+	}
+	return
+}
@@ -0,0 +1,257 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package omap_test
+
+import (
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/donyori/gogo/container/mapping"
+	"github.com/donyori/gogo/container/omap"
+	"github.com/donyori/gogo/function/compare"
+)
+
+var ChaCha8Seed = [32]byte([]byte("ABCDEFGHIJKLMNOPQRSTUVWXYZ123456"))
+
+var IntLess = compare.OrderedLess[int]
+
+func TestNew(t *testing.T) {
+	data := mapping.GoMap[int, string]{3: "c", 1: "a", 2: "b"}
+	m := omap.New[int, string](IntLess, &data)
+	checkOrderedMap(t, m, map[int]string(data))
+}
+
+func TestNew_NilLessFn(t *testing.T) {
+	defer func() {
+		if e := recover(); e == nil {
+			t.Error("want panic but not")
+		}
+	}()
+	omap.New[int, int](nil, nil)
+}
+
+// TestOrderedMap_AgainstGoMap performs random operations
+// on the ordered map and Go map,
+// and checks that they have the same results.
+func TestOrderedMap_AgainstGoMap(t *testing.T) {
+	random := rand.New(rand.NewChaCha8(ChaCha8Seed))
+	m := omap.New[int, int](IntLess, nil)
+	want := make(map[int]int)
+	for i := range 3000 {
+		k, v := random.IntN(200), random.Int()
+		switch random.IntN(5) {
+		case 0, 1:
+			m.Set(k, v)
+			want[k] = v
+		case 2:
+			prev, present := m.GetAndSet(k, v)
+			wantPrev, wantPresent := want[k]
+			if prev != wantPrev || present != wantPresent {
+				t.Fatalf("i:%d, GetAndSet(%d) got (%d, %t); want (%d, %t)",
+					i, k, prev, present, wantPrev, wantPresent)
+			}
+			want[k] = v
+		case 3:
+			m.Remove(k, k+1)
+			delete(want, k)
+			delete(want, k+1)
+		case 4:
+			prev, present := m.GetAndRemove(k)
+			wantPrev, wantPresent := want[k]
+			if prev != wantPrev || present != wantPresent {
+				t.Fatalf("i:%d, GetAndRemove(%d) got (%d, %t); want (%d, %t)",
+					i, k, prev, present, wantPrev, wantPresent)
+			}
+			delete(want, k)
+		}
+		if err := omap.CheckTree(m); err != nil {
+			t.Fatalf("i:%d, %v", i, err)
+		}
+		if n := m.Len(); n != len(want) {
+			t.Fatalf("i:%d, got len %d; want %d", i, n, len(want))
+		}
+	}
+	checkOrderedMap(t, m, want)
+
+	m.Filter(func(x mapping.Entry[int, int]) (keep bool) {
+		return x.Key%3 != 0
+	})
+	maps.DeleteFunc(want, func(k, _ int) bool {
+		return k%3 == 0
+	})
+	if err := omap.CheckTree(m); err != nil {
+		t.Fatal("after Filter,", err)
+	}
+	checkOrderedMap(t, m, want)
+
+	m.Clear()
+	checkOrderedMap(t, m, nil)
+}
+
+func TestOrderedMap_Queries(t *testing.T) {
+	m := omap.New[int, int](IntLess, nil)
+	if _, present := m.Min(); present {
+		t.Error("Min on empty map, got present true")
+	}
+	if _, present := m.Max(); present {
+		t.Error("Max on empty map, got present true")
+	}
+	for k := 10; k <= 50; k += 10 {
+		m.Set(k, -k)
+	}
+	if e, present := m.Min(); !present || e.Key != 10 || e.Value != -10 {
+		t.Errorf("got Min %v, %t; want 10: -10, true", e, present)
+	}
+	if e, present := m.Max(); !present || e.Key != 50 || e.Value != -50 {
+		t.Errorf("got Max %v, %t; want 50: -50, true", e, present)
+	}
+	testCases := []struct {
+		key                      int
+		floor, ceiling           int
+		floorPresent, ceilingPre bool
+	}{
+		{5, 0, 10, false, true},
+		{10, 10, 10, true, true},
+		{25, 20, 30, true, true},
+		{50, 50, 50, true, true},
+		{55, 50, 0, true, false},
+	}
+	for _, tc := range testCases {
+		e, present := m.Floor(tc.key)
+		if present != tc.floorPresent || present && e.Key != tc.floor {
+			t.Errorf("Floor(%d) got %v, %t; want %d, %t",
+				tc.key, e, present, tc.floor, tc.floorPresent)
+		}
+		e, present = m.Ceiling(tc.key)
+		if present != tc.ceilingPre || present && e.Key != tc.ceiling {
+			t.Errorf("Ceiling(%d) got %v, %t; want %d, %t",
+				tc.key, e, present, tc.ceiling, tc.ceilingPre)
+		}
+	}
+
+	rangeCases := []struct {
+		low, high int
+		want      []int
+	}{
+		{0, 100, []int{10, 20, 30, 40, 50}},
+		{10, 50, []int{10, 20, 30, 40}},
+		{15, 35, []int{20, 30}},
+		{30, 31, []int{30}},
+		{30, 30, nil},
+		{40, 20, nil},
+		{60, 70, nil},
+	}
+	for _, rc := range rangeCases {
+		var got []int
+		m.RangeBetween(rc.low, rc.high, func(x mapping.Entry[int, int]) (cont bool) {
+			got = append(got, x.Key)
+			return true
+		})
+		if !slices.Equal(got, rc.want) {
+			t.Errorf("RangeBetween(%d, %d) got %v; want %v",
+				rc.low, rc.high, got, rc.want)
+		}
+		got = got[:0]
+		for k := range m.Between(rc.low, rc.high) {
+			got = append(got, k)
+		}
+		if len(got) == 0 {
+			got = nil
+		}
+		if !slices.Equal(got, rc.want) {
+			t.Errorf("Between(%d, %d) got %v; want %v",
+				rc.low, rc.high, got, rc.want)
+		}
+	}
+
+	var got []int
+	for k, v := range m.Backward() {
+		if v != -k {
+			t.Errorf("Backward, key %d, got value %d; want %d", k, v, -k)
+		}
+		if got = append(got, k); len(got) == 3 {
+			break
+		}
+	}
+	if want := []int{50, 40, 30}; !slices.Equal(got, want) {
+		t.Errorf("Backward got %v; want %v", got, want)
+	}
+}
+
+func TestOrderedMap_GetAndSetMap(t *testing.T) {
+	m := omap.New[int, string](IntLess, nil)
+	m.Set(1, "a")
+	m.Set(2, "b")
+	other := mapping.GoMap[int, string]{2: "B", 3: "C"}
+	prev := m.GetAndSetMap(&other)
+	if prev == nil || prev.Len() != 1 {
+		t.Fatalf("got previous %v; want {2: b}", prev)
+	}
+	if v, present := prev.Get(2); !present || v != "b" {
+		t.Errorf("got previous[2] %q, %t; want %q, true", v, present, "b")
+	}
+	checkOrderedMap(t, m, map[int]string{1: "a", 2: "B", 3: "C"})
+	other = mapping.GoMap[int, string]{4: "D"}
+	if prev = m.GetAndSetMap(&other); prev != nil {
+		t.Errorf("got previous %v; want <nil>", prev)
+	}
+}
+
+func checkOrderedMap[Value comparable](
+	t *testing.T,
+	m omap.OrderedMap[int, Value],
+	want map[int]Value,
+) {
+	t.Helper()
+	if n := m.Len(); n != len(want) {
+		t.Errorf("got len %d; want %d", n, len(want))
+	}
+	wantKeys := slices.Sorted(maps.Keys(want))
+	var keys []int
+	m.Range(func(x mapping.Entry[int, Value]) (cont bool) {
+		keys = append(keys, x.Key)
+		if v, ok := want[x.Key]; !ok || v != x.Value {
+			t.Errorf("Range, got %v; want value %v", x, v)
+		}
+		return true
+	})
+	if !slices.Equal(keys, wantKeys) {
+		t.Errorf("Range got keys %v; want %v", keys, wantKeys)
+	}
+	keys = keys[:0]
+	for k, v := range m.All() {
+		keys = append(keys, k)
+		if v != want[k] {
+			t.Errorf("All, key %d, got value %v; want %v", k, v, want[k])
+		}
+	}
+	if len(keys) == 0 {
+		keys = nil
+	}
+	if !slices.Equal(keys, wantKeys) {
+		t.Errorf("All got keys %v; want %v", keys, wantKeys)
+	}
+	for k, w := range want {
+		if v, present := m.Get(k); !present || v != w {
+			t.Errorf("Get(%d) got %v, %t; want %v, true", k, v, present, w)
+		}
+	}
+}
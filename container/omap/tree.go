@@ -0,0 +1,334 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package omap
+
+import "github.com/donyori/gogo/function/compare"
+
+// node is a node of an AVL tree.
+type node[Key, Value any] struct {
+	key    Key
+	value  Value
+	left   *node[Key, Value]
+	right  *node[Key, Value]
+	height int // The height of the subtree rooted at this node, 1 for a leaf.
+}
+
+// height returns the height of the subtree rooted at nd,
+// or 0 if nd is nil.
+func height[Key, Value any](nd *node[Key, Value]) int {
+	if nd == nil {
+		return 0
+	}
+	return nd.height
+}
+
+// update recalculates the height of nd from its children.
+func (nd *node[Key, Value]) update() {
+	nd.height = max(height(nd.left), height(nd.right)) + 1
+}
+
+// rotateLeft rotates the subtree rooted at nd to the left
+// and returns the new root of the subtree.
+func (nd *node[Key, Value]) rotateLeft() *node[Key, Value] {
+	r := nd.right
+	nd.right, r.left = r.left, nd
+	nd.update()
+	r.update()
+	return r
+}
+
+// rotateRight rotates the subtree rooted at nd to the right
+// and returns the new root of the subtree.
+func (nd *node[Key, Value]) rotateRight() *node[Key, Value] {
+	l := nd.left
+	nd.left, l.right = l.right, nd
+	nd.update()
+	l.update()
+	return l
+}
+
+// balance restores the AVL property of the subtree rooted at nd,
+// whose children are balanced and differ in height by at most 2,
+// and returns the new root of the subtree.
+func (nd *node[Key, Value]) balance() *node[Key, Value] {
+	nd.update()
+	switch bf := height(nd.left) - height(nd.right); {
+	case bf > 1:
+		if height(nd.left.left) < height(nd.left.right) {
+			nd.left = nd.left.rotateLeft()
+		}
+		return nd.rotateRight()
+	case bf < -1:
+		if height(nd.right.right) < height(nd.right.left) {
+			nd.right = nd.right.rotateRight()
+		}
+		return nd.rotateLeft()
+	}
+	return nd
+}
+
+// tree is an AVL tree ordered by the keys of its nodes.
+//
+// The keys are unique according to lessFn,
+// i.e., two keys a and b are considered equal
+// if !lessFn(a, b) && !lessFn(b, a).
+type tree[Key, Value any] struct {
+	root   *node[Key, Value]
+	n      int
+	lessFn compare.LessFunc[Key]
+}
+
+// find returns the node with the specified key,
+// or nil if the key is not present.
+func (t *tree[Key, Value]) find(key Key) *node[Key, Value] {
+	nd := t.root
+	for nd != nil {
+		switch {
+		case t.lessFn(key, nd.key):
+			nd = nd.left
+		case t.lessFn(nd.key, key):
+			nd = nd.right
+		default:
+			return nd
+		}
+	}
+	return nil
+}
+
+// first returns the node with the minimum key,
+// or nil if the tree is empty.
+func (t *tree[Key, Value]) first() *node[Key, Value] {
+	nd := t.root
+	if nd != nil {
+		for nd.left != nil {
+			nd = nd.left
+		}
+	}
+	return nd
+}
+
+// last returns the node with the maximum key,
+// or nil if the tree is empty.
+func (t *tree[Key, Value]) last() *node[Key, Value] {
+	nd := t.root
+	if nd != nil {
+		for nd.right != nil {
+			nd = nd.right
+		}
+	}
+	return nd
+}
+
+// floor returns the node with the greatest key
+// less than or equal to the specified key,
+// or nil if there is no such node.
+func (t *tree[Key, Value]) floor(key Key) *node[Key, Value] {
+	var result *node[Key, Value]
+	nd := t.root
+	for nd != nil {
+		if t.lessFn(key, nd.key) {
+			nd = nd.left
+		} else {
+			result, nd = nd, nd.right
+		}
+	}
+	return result
+}
+
+// ceiling returns the node with the least key
+// greater than or equal to the specified key,
+// or nil if there is no such node.
+func (t *tree[Key, Value]) ceiling(key Key) *node[Key, Value] {
+	var result *node[Key, Value]
+	nd := t.root
+	for nd != nil {
+		if t.lessFn(nd.key, key) {
+			nd = nd.right
+		} else {
+			result, nd = nd, nd.left
+		}
+	}
+	return result
+}
+
+// insert sets the value bound to the specified key.
+//
+// It returns the previous value (if any) bound to the key
+// and an indicator present to report whether the key was present.
+func (t *tree[Key, Value]) insert(key Key, value Value) (
+	previous Value, present bool) {
+	t.root = t.insertAt(t.root, key, value, &previous, &present)
+	if !present {
+		t.n++
+	}
+	return
+}
+
+// insertAt inserts the key-value pair into the subtree rooted at nd
+// and returns the new root of the subtree.
+//
+// If the key is present, it overwrites the value,
+// and sets *previous to the previous value and *present to true.
+func (t *tree[Key, Value]) insertAt(
+	nd *node[Key, Value],
+	key Key,
+	value Value,
+	previous *Value,
+	present *bool,
+) *node[Key, Value] {
+	switch {
+	case nd == nil:
+		return &node[Key, Value]{key: key, value: value, height: 1}
+	case t.lessFn(key, nd.key):
+		nd.left = t.insertAt(nd.left, key, value, previous, present)
+	case t.lessFn(nd.key, key):
+		nd.right = t.insertAt(nd.right, key, value, previous, present)
+	default:
+		*previous, *present = nd.value, true
+		nd.value = value
+		return nd
+	}
+	return nd.balance()
+}
+
+// delete removes the node with the specified key.
+//
+// It returns the value (if any) bound to the key
+// and an indicator present to report whether the key was present.
+func (t *tree[Key, Value]) delete(key Key) (previous Value, present bool) {
+	t.root = t.deleteAt(t.root, key, &previous, &present)
+	if present {
+		t.n--
+	}
+	return
+}
+
+// deleteAt removes the node with the specified key
+// from the subtree rooted at nd
+// and returns the new root of the subtree.
+//
+// If the key is present, it sets *previous to the value bound to the key
+// and *present to true.
+func (t *tree[Key, Value]) deleteAt(
+	nd *node[Key, Value],
+	key Key,
+	previous *Value,
+	present *bool,
+) *node[Key, Value] {
+	switch {
+	case nd == nil:
+		return nil
+	case t.lessFn(key, nd.key):
+		nd.left = t.deleteAt(nd.left, key, previous, present)
+	case t.lessFn(nd.key, key):
+		nd.right = t.deleteAt(nd.right, key, previous, present)
+	default:
+		*previous, *present = nd.value, true
+		if nd.left == nil {
+			return nd.right
+		} else if nd.right == nil {
+			return nd.left
+		}
+		var successor *node[Key, Value]
+		nd.right, successor = deleteMin(nd.right)
+		successor.left, successor.right = nd.left, nd.right
+		return successor.balance()
+	}
+	return nd.balance()
+}
+
+// deleteMin removes the node with the minimum key
+// from the nonempty subtree rooted at nd.
+//
+// It returns the new root of the subtree and the removed node.
+func deleteMin[Key, Value any](nd *node[Key, Value]) (
+	root, removed *node[Key, Value]) {
+	if nd.left == nil {
+		return nd.right, nd
+	}
+	nd.left, removed = deleteMin(nd.left)
+	return nd.balance(), removed
+}
+
+// ascend calls yield on the nodes in the subtree rooted at nd
+// in ascending order of their keys,
+// with the keys within the specified bounds.
+//
+// If lo is not nil, only the nodes with keys greater than or equal to *lo
+// are accessed.
+// If hi is not nil, only the nodes with keys less than *hi are accessed.
+//
+// It stops and returns false if yield returns false.
+// Otherwise, it returns true.
+func (t *tree[Key, Value]) ascend(
+	nd *node[Key, Value],
+	lo, hi *Key,
+	yield func(nd *node[Key, Value]) bool,
+) bool {
+	for nd != nil {
+		aboveLo := lo == nil || !t.lessFn(nd.key, *lo)
+		belowHi := hi == nil || t.lessFn(nd.key, *hi)
+		if aboveLo {
+			if !t.ascend(nd.left, lo, hi, yield) {
+				return false
+			}
+			if belowHi && !yield(nd) {
+				return false
+			}
+		}
+		if !belowHi {
+			return true
+		}
+		nd = nd.right
+	}
+	return true
+}
+
+// descend calls yield on the nodes in the subtree rooted at nd
+// in descending order of their keys.
+//
+// It stops and returns false if yield returns false.
+// Otherwise, it returns true.
+func descend[Key, Value any](
+	nd *node[Key, Value],
+	yield func(nd *node[Key, Value]) bool,
+) bool {
+	for nd != nil {
+		if !descend(nd.right, yield) || !yield(nd) {
+			return false
+		}
+		nd = nd.left
+	}
+	return true
+}
+
+// build builds a balanced tree from the nodes sorted by key
+// and returns its root.
+//
+// The fields left, right, and height of the nodes are overwritten.
+func build[Key, Value any](nodes []*node[Key, Value]) *node[Key, Value] {
+	if len(nodes) == 0 {
+		return nil
+	}
+	mid := len(nodes) / 2
+	nd := nodes[mid]
+	nd.left, nd.right = build(nodes[:mid]), build(nodes[mid+1:])
+	nd.update()
+	return nd
+}
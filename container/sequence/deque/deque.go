@@ -0,0 +1,392 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package deque
+
+import (
+	"fmt"
+
+	"github.com/donyori/gogo/container"
+	"github.com/donyori/gogo/container/sequence"
+	"github.com/donyori/gogo/container/sequence/array"
+	"github.com/donyori/gogo/errors"
+)
+
+// Deque is an interface representing a double-ended queue.
+//
+// It is also a dynamic array,
+// whose item at index 0 is the front of the queue.
+// Its method Push is equivalent to PushBack,
+// and its method Pop is equivalent to PopBack.
+//
+// Its method Range accesses the items from front to back.
+type Deque[Item any] interface {
+	array.DynamicArray[Item]
+
+	// PushFront adds x to the front of the queue.
+	//
+	// Time complexity: amortized O(1).
+	PushFront(x Item)
+
+	// PushBack adds x to the back of the queue.
+	//
+	// Time complexity: amortized O(1).
+	PushBack(x Item)
+
+	// PopFront removes and returns the first item.
+	//
+	// It panics if the queue is empty.
+	//
+	// Time complexity: O(1).
+	PopFront() Item
+
+	// PopBack removes and returns the last item.
+	//
+	// It panics if the queue is empty.
+	//
+	// Time complexity: O(1).
+	PopBack() Item
+}
+
+// minGrowCap is the minimum capacity allocated
+// when a deque grows automatically.
+const minGrowCap = 8
+
+// deque is an implementation of interface Deque,
+// based on a ring buffer.
+type deque[Item any] struct {
+	ring[Item]
+}
+
+// New creates a new double-ended queue.
+//
+// capacity asks to allocate enough space to hold
+// the specified number of items.
+// Nonpositive values for no preallocation.
+//
+// data is the initial items added to the queue, from front to back.
+func New[Item any](capacity int, data container.Container[Item]) Deque[Item] {
+	d := new(deque[Item])
+	if data != nil {
+		capacity = max(capacity, data.Len())
+	}
+	d.Reserve(capacity)
+	if data != nil {
+		data.Range(func(x Item) (cont bool) {
+			d.PushBack(x)
+			return true
+		})
+	}
+	return d
+}
+
+func (d *deque[Item]) Filter(filter func(x Item) (keep bool)) {
+	var w int
+	for i := range d.n {
+		p := d.pos(i)
+		if x := d.buf[p]; filter(x) {
+			d.buf[d.pos(w)], w = x, w+1
+		}
+	}
+	d.zero(w, d.n) // avoid memory leak
+	d.n = w
+	d.normalize()
+}
+
+func (d *deque[Item]) Cap() int {
+	return len(d.buf)
+}
+
+func (d *deque[Item]) Push(x Item) {
+	d.PushBack(x)
+}
+
+func (d *deque[Item]) Pop() Item {
+	return d.PopBack()
+}
+
+// Append adds s to the back of the queue.
+//
+// s shouldn't be modified during calling this method,
+// otherwise, unknown error may occur.
+// It is OK to append the queue to itself.
+func (d *deque[Item]) Append(s sequence.Sequence[Item]) {
+	if s == nil {
+		return
+	}
+	m := s.Len()
+	if m == 0 {
+		return
+	}
+	// Reserve enough space first,
+	// so that the buffer is not reallocated while ranging over s,
+	// even if s is d itself.
+	d.grow(m)
+	s.Range(func(x Item) (cont bool) {
+		d.buf[d.pos(d.n)] = x
+		d.n++
+		return true
+	})
+}
+
+func (d *deque[Item]) Truncate(i int) {
+	if i < 0 || i >= d.n {
+		return
+	}
+	d.zero(i, d.n) // avoid memory leak
+	d.n = i
+	d.normalize()
+}
+
+func (d *deque[Item]) Insert(i int, x Item) {
+	switch i {
+	case 0:
+		d.PushFront(x)
+	case d.n:
+		d.PushBack(x)
+	default:
+		d.Expand(i, 1)
+		d.buf[d.pos(i)] = x
+	}
+}
+
+func (d *deque[Item]) Remove(i int) Item {
+	d.checkIndex(i)
+	x := d.buf[d.pos(i)]
+	d.Cut(i, i+1)
+	return x
+}
+
+func (d *deque[Item]) RemoveWithoutOrder(i int) Item {
+	d.checkIndex(i)
+	switch {
+	case i == 0:
+		return d.PopFront()
+	case i == d.n-1:
+		return d.PopBack()
+	}
+	p := d.pos(i)
+	x := d.buf[p]
+	if i < d.n/2 {
+		d.buf[p] = d.PopFront()
+	} else {
+		d.buf[p] = d.PopBack()
+	}
+	return x
+}
+
+// InsertSequence inserts s to the front of the item at index i.
+//
+// It panics if i is out of range, i.e., i < 0 or i > Len().
+//
+// s shouldn't be modified during calling this method,
+// otherwise, unknown error may occur.
+// It is OK to insert the queue (or a slice of it) into itself.
+func (d *deque[Item]) InsertSequence(i int, s sequence.Sequence[Item]) {
+	if i < 0 || i > d.n {
+		panic(errors.AutoMsg(fmt.Sprintf(
+			"index %d is out of range [0:%d]", i, d.n+1)))
+	} else if s == nil {
+		return
+	}
+	m := s.Len()
+	if m == 0 {
+		return
+	}
+	var items []Item
+	switch t := s.(type) {
+	case *deque[Item]:
+		if len(t.buf) > 0 && len(d.buf) > 0 && &t.buf[0] == &d.buf[0] {
+			items = t.toSlice()
+		}
+	case *ring[Item]:
+		if len(t.buf) > 0 && len(d.buf) > 0 && &t.buf[0] == &d.buf[0] {
+			items = t.toSlice()
+		}
+	}
+	d.Expand(i, m)
+	if items != nil {
+		for k := range items {
+			d.buf[d.pos(i+k)] = items[k]
+		}
+		return
+	}
+	s.Range(func(x Item) (cont bool) {
+		d.buf[d.pos(i)], i = x, i+1
+		return true
+	})
+}
+
+func (d *deque[Item]) Cut(begin, end int) {
+	d.checkRange(begin, end)
+	m := end - begin
+	if m == 0 {
+		return
+	}
+	if begin < d.n-end {
+		// Move the items before begin backward.
+		for k := begin - 1; k >= 0; k-- {
+			d.buf[d.pos(k+m)] = d.buf[d.pos(k)]
+		}
+		d.zero(0, m) // avoid memory leak
+		d.head = d.pos(m)
+	} else {
+		// Move the items after end forward.
+		for k := end; k < d.n; k++ {
+			d.buf[d.pos(k-m)] = d.buf[d.pos(k)]
+		}
+		d.zero(d.n-m, d.n) // avoid memory leak
+	}
+	d.n -= m
+	d.normalize()
+}
+
+func (d *deque[Item]) CutWithoutOrder(begin, end int) {
+	d.checkRange(begin, end)
+	m := end - begin
+	if m == 0 {
+		return
+	}
+	if begin < d.n-end {
+		// Fill the hole with the first items.
+		for k := range min(m, begin) {
+			d.buf[d.pos(end-1-k)] = d.buf[d.pos(k)]
+		}
+		d.zero(0, m) // avoid memory leak
+		d.head = d.pos(m)
+	} else {
+		// Fill the hole with the last items.
+		for k := range min(m, d.n-end) {
+			d.buf[d.pos(begin+k)] = d.buf[d.pos(d.n-1-k)]
+		}
+		d.zero(d.n-m, d.n) // avoid memory leak
+	}
+	d.n -= m
+	d.normalize()
+}
+
+func (d *deque[Item]) Extend(n int) {
+	d.Expand(d.n, n)
+}
+
+func (d *deque[Item]) Expand(i, n int) {
+	if n < 0 {
+		panic(errors.AutoMsg(fmt.Sprintf("n is %d < 0", n)))
+	} else if i < 0 || i > d.n {
+		panic(errors.AutoMsg(fmt.Sprintf(
+			"index %d is out of range [0:%d]", i, d.n+1)))
+	} else if n == 0 {
+		return
+	}
+	d.grow(n)
+	if i < d.n-i {
+		// Move the items before i forward.
+		d.head = d.pos(len(d.buf) - n)
+		d.n += n
+		for k := range i {
+			d.buf[d.pos(k)] = d.buf[d.pos(k+n)]
+		}
+	} else {
+		// Move the items after i backward.
+		d.n += n
+		for k := d.n - 1; k >= i+n; k-- {
+			d.buf[d.pos(k)] = d.buf[d.pos(k-n)]
+		}
+	}
+	d.zero(i, i+n)
+}
+
+func (d *deque[Item]) Reserve(capacity int) {
+	if capacity > len(d.buf) {
+		d.realloc(capacity)
+	}
+}
+
+func (d *deque[Item]) Shrink() {
+	if d.n < len(d.buf) {
+		d.realloc(d.n)
+	}
+}
+
+func (d *deque[Item]) Clear() {
+	d.buf, d.head, d.n = nil, 0, 0
+}
+
+func (d *deque[Item]) PushFront(x Item) {
+	d.grow(1)
+	d.head = d.pos(len(d.buf) - 1)
+	d.buf[d.head] = x
+	d.n++
+}
+
+func (d *deque[Item]) PushBack(x Item) {
+	d.grow(1)
+	d.buf[d.pos(d.n)] = x
+	d.n++
+}
+
+func (d *deque[Item]) PopFront() Item {
+	d.checkNonempty()
+	var zero Item
+	x := d.buf[d.head]
+	d.buf[d.head] = zero // avoid memory leak
+	d.head = d.pos(1)
+	d.n--
+	d.normalize()
+	return x
+}
+
+func (d *deque[Item]) PopBack() Item {
+	d.checkNonempty()
+	var zero Item
+	p := d.pos(d.n - 1)
+	x := d.buf[p]
+	d.buf[p] = zero // avoid memory leak
+	d.n--
+	d.normalize()
+	return x
+}
+
+// grow ensures that the buffer has enough space
+// for n more items.
+func (d *deque[Item]) grow(n int) {
+	if need := d.n + n; need > len(d.buf) {
+		d.realloc(max(need, len(d.buf)*2, minGrowCap))
+	}
+}
+
+// realloc allocates a new buffer of the specified capacity
+// and moves the items into it, starting from index 0.
+//
+// The caller must guarantee that capacity >= d.n.
+func (d *deque[Item]) realloc(capacity int) {
+	var buf []Item
+	if capacity > 0 {
+		buf = make([]Item, capacity)
+		d.copyTo(buf)
+	}
+	d.buf, d.head = buf, 0
+}
+
+// normalize resets the head to 0 if the deque is empty,
+// to reduce the wraparounds of the subsequent operations.
+func (d *deque[Item]) normalize() {
+	if d.n == 0 {
+		d.head = 0
+	}
+}
@@ -0,0 +1,340 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package deque_test
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/donyori/gogo/container/sequence/array"
+	"github.com/donyori/gogo/container/sequence/deque"
+)
+
+type IntSDAPtr = *array.SliceDynamicArray[int]
+
+var ChaCha8Seed = [32]byte([]byte("ABCDEFGHIJKLMNOPQRSTUVWXYZ123456"))
+
+func TestNew(t *testing.T) {
+	for _, data := range [][]int{nil, {}, {1}, {1, 2, 3}} {
+		for _, capacity := range []int{-1, 0, 2, 10} {
+			t.Run(fmt.Sprintf("data=%v&cap=%d", data, capacity), func(t *testing.T) {
+				d := deque.New[int](capacity, IntSDAPtr(&data))
+				checkDeque(t, d, data)
+				if c := d.Cap(); c < max(capacity, len(data)) {
+					t.Errorf("got cap %d; want >= %d", c, max(capacity, len(data)))
+				}
+			})
+		}
+	}
+}
+
+func TestDeque_PushPop(t *testing.T) {
+	d := deque.New[int](0, nil)
+	var want []int
+	for i := range 100 {
+		if i%3 == 0 {
+			d.PushFront(i)
+			want = slices.Insert(want, 0, i)
+		} else {
+			d.PushBack(i)
+			want = append(want, i)
+		}
+	}
+	checkDeque(t, d, want)
+	for len(want) > 0 {
+		var got, w int
+		if len(want)%2 == 0 {
+			got, w, want = d.PopFront(), want[0], want[1:]
+		} else {
+			got, w, want = d.PopBack(), want[len(want)-1], want[:len(want)-1]
+		}
+		if got != w {
+			t.Fatalf("got %d; want %d", got, w)
+		}
+	}
+	checkDeque(t, d, nil)
+}
+
+func TestDeque_Pop_Empty(t *testing.T) {
+	d := deque.New[int](4, nil)
+	for name, f := range map[string]func(){
+		"PopFront": func() { d.PopFront() },
+		"PopBack":  func() { d.PopBack() },
+		"Pop":      func() { d.Pop() },
+		"Front":    func() { d.Front() },
+		"Back":     func() { d.Back() },
+		"Get":      func() { d.Get(0) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if e := recover(); e == nil {
+					t.Error("want panic but not")
+				}
+			}()
+			f()
+		})
+	}
+}
+
+func TestDeque_Slice(t *testing.T) {
+	d := newWrappedDeque(8, []int{0, 1, 2, 3, 4, 5})
+	s := d.Slice(1, 5)
+	checkArray(t, s, []int{1, 2, 3, 4})
+	s.Set(0, 10)
+	if x := d.Get(1); x != 10 {
+		t.Errorf("got d.Get(1) %d after s.Set(0, 10); want 10", x)
+	}
+	s.Reverse()
+	checkArray(t, d, []int{0, 4, 3, 2, 10, 5})
+	checkArray(t, s.Slice(1, 3), []int{3, 2})
+	checkArray(t, d.Slice(3, 3), nil)
+}
+
+// TestDeque_AgainstSlice performs random operations
+// on the deque and SliceDynamicArray,
+// and checks that they have the same results.
+func TestDeque_AgainstSlice(t *testing.T) {
+	random := rand.New(rand.NewChaCha8(ChaCha8Seed))
+	for round := range 200 {
+		var initial []int
+		for i := range random.IntN(12) {
+			initial = append(initial, i)
+		}
+		d := newWrappedDeque(random.IntN(16), initial)
+		sda := array.SliceDynamicArray[int](slices.Clone(initial))
+		var log []string
+		next := 100
+		for range 60 {
+			n := sda.Len()
+			var op string
+			switch k := random.IntN(18); k {
+			case 0:
+				op = "PushFront"
+				d.PushFront(next)
+				sda.Insert(0, next)
+				next++
+			case 1:
+				op = "PushBack"
+				d.PushBack(next)
+				sda.Push(next)
+				next++
+			case 2, 3:
+				if n == 0 {
+					continue
+				}
+				op = "PopFront"
+				if got, want := d.PopFront(), sda.Remove(0); got != want {
+					t.Fatalf("round %d, %v, got %d; want %d", round, log, got, want)
+				}
+			case 4:
+				if n == 0 {
+					continue
+				}
+				op = "PopBack"
+				if got, want := d.PopBack(), sda.Pop(); got != want {
+					t.Fatalf("round %d, %v, got %d; want %d", round, log, got, want)
+				}
+			case 5:
+				i := random.IntN(n + 1)
+				op = fmt.Sprintf("Insert(%d)", i)
+				d.Insert(i, next)
+				sda.Insert(i, next)
+				next++
+			case 6:
+				if n == 0 {
+					continue
+				}
+				i := random.IntN(n)
+				op = fmt.Sprintf("Remove(%d)", i)
+				if got, want := d.Remove(i), sda.Remove(i); got != want {
+					t.Fatalf("round %d, %v, got %d; want %d", round, log, got, want)
+				}
+			case 7:
+				if n == 0 {
+					continue
+				}
+				i := random.IntN(n)
+				op = fmt.Sprintf("RemoveWithoutOrder(%d)", i)
+				got := d.RemoveWithoutOrder(i)
+				if want := sda.Get(i); got != want {
+					t.Fatalf("round %d, %v, got %d; want %d", round, log, got, want)
+				}
+				sda.Remove(i)
+				sortBoth(d, &sda)
+			case 8:
+				if n == 0 {
+					continue // SliceDynamicArray panics on empty
+				}
+				begin := random.IntN(n + 1)
+				end := begin + random.IntN(n-begin+1)
+				op = fmt.Sprintf("Cut(%d, %d)", begin, end)
+				d.Cut(begin, end)
+				sda.Cut(begin, end)
+			case 9:
+				if n == 0 {
+					continue // SliceDynamicArray panics on empty
+				}
+				begin := random.IntN(n + 1)
+				end := begin + random.IntN(n-begin+1)
+				op = fmt.Sprintf("CutWithoutOrder(%d, %d)", begin, end)
+				d.CutWithoutOrder(begin, end)
+				sda.CutWithoutOrder(begin, end)
+				sortBoth(d, &sda)
+			case 10:
+				i, m := random.IntN(n+1), random.IntN(5)
+				op = fmt.Sprintf("Expand(%d, %d)", i, m)
+				d.Expand(i, m)
+				sda.Expand(i, m)
+			case 11:
+				m := random.IntN(5)
+				op = fmt.Sprintf("Extend(%d)", m)
+				d.Extend(m)
+				sda.Extend(m)
+			case 12:
+				i := random.IntN(n + 2)
+				op = fmt.Sprintf("Truncate(%d)", i)
+				d.Truncate(i)
+				sda.Truncate(i)
+			case 13:
+				i := random.IntN(n + 1)
+				var s []int
+				for range random.IntN(4) {
+					s, next = append(s, next), next+1
+				}
+				op = fmt.Sprintf("InsertSequence(%d, %v)", i, s)
+				d.InsertSequence(i, IntSDAPtr(&s))
+				sda.InsertSequence(i, IntSDAPtr(&s))
+			case 14:
+				i := random.IntN(n + 1)
+				op = fmt.Sprintf("InsertSequence(%d, self)", i)
+				self := slices.Clone(sda)
+				d.InsertSequence(i, d)
+				sda.InsertSequence(i, &self)
+			case 15:
+				op = "Append(self)"
+				self := slices.Clone(sda)
+				d.Append(d)
+				sda.Append(&self)
+			case 16:
+				op = "Filter(even)"
+				even := func(x int) bool { return x%2 == 0 }
+				d.Filter(even)
+				sda.Filter(even)
+			case 17:
+				op = "Reverse"
+				d.Reverse()
+				sda.Reverse()
+			}
+			log = append(log, op)
+			if !slices.Equal(toSlice(d), sda) {
+				t.Fatalf("round %d, %v, got %v; want %v",
+					round, log, toSlice(d), []int(sda))
+			}
+			if n := d.Len(); n > d.Cap() {
+				t.Fatalf("round %d, %v, got len %d > cap %d",
+					round, log, n, d.Cap())
+			}
+		}
+	}
+}
+
+func TestDeque_ReserveShrinkClear(t *testing.T) {
+	d := newWrappedDeque(6, []int{0, 1, 2, 3, 4})
+	d.Reserve(20)
+	if c := d.Cap(); c < 20 {
+		t.Errorf("got cap %d after Reserve(20); want >= 20", c)
+	}
+	checkDeque(t, d, []int{0, 1, 2, 3, 4})
+	d.Shrink()
+	if c := d.Cap(); c != 5 {
+		t.Errorf("got cap %d after Shrink; want 5", c)
+	}
+	checkDeque(t, d, []int{0, 1, 2, 3, 4})
+	d.Clear()
+	if c := d.Cap(); c != 0 {
+		t.Errorf("got cap %d after Clear; want 0", c)
+	}
+	checkDeque(t, d, nil)
+	d.PushFront(1)
+	checkDeque(t, d, []int{1})
+}
+
+// newWrappedDeque creates a deque with the specified capacity
+// and items data, whose items wrap around the end of its buffer
+// if possible.
+func newWrappedDeque(capacity int, data []int) deque.Deque[int] {
+	d := deque.New[int](max(capacity, len(data)), nil)
+	for i := len(data) - 1; i >= 0; i-- {
+		d.PushFront(data[i])
+	}
+	return d
+}
+
+// sortBoth sorts the items in d and sda,
+// to compare the results of the operations without preserving order.
+func sortBoth(d deque.Deque[int], sda IntSDAPtr) {
+	s := toSlice(d)
+	slices.Sort(s)
+	for i := range s {
+		d.Set(i, s[i])
+	}
+	slices.Sort(*sda)
+}
+
+func toSlice(a array.Array[int]) []int {
+	s := make([]int, 0, a.Len())
+	a.Range(func(x int) (cont bool) {
+		s = append(s, x)
+		return true
+	})
+	return s
+}
+
+func checkDeque(t *testing.T, d deque.Deque[int], want []int) {
+	t.Helper()
+	checkArray(t, d, want)
+	if n := d.Len(); n > d.Cap() {
+		t.Errorf("got len %d > cap %d", n, d.Cap())
+	}
+}
+
+func checkArray(t *testing.T, a array.Array[int], want []int) {
+	t.Helper()
+	if n := a.Len(); n != len(want) {
+		t.Errorf("got len %d; want %d", n, len(want))
+	}
+	if s := toSlice(a); !slices.Equal(s, want) {
+		t.Errorf("got %v; want %v", s, want)
+	}
+	for i := range want {
+		if x := a.Get(i); x != want[i] {
+			t.Errorf("got Get(%d) %d; want %d", i, x, want[i])
+		}
+	}
+	if len(want) > 0 {
+		if x := a.Front(); x != want[0] {
+			t.Errorf("got Front %d; want %d", x, want[0])
+		}
+		if x := a.Back(); x != want[len(want)-1] {
+			t.Errorf("got Back %d; want %d", x, want[len(want)-1])
+		}
+	}
+}
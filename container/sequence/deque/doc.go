@@ -0,0 +1,30 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package deque provides an OOP-style double-ended queue
+// backed by a ring buffer.
+//
+// The double-ended queue supports amortized O(1) insertion and removal
+// at both ends,
+// which makes it more suitable than
+// github.com/donyori/gogo/container/sequence/array.SliceDynamicArray
+// for FIFO queues (e.g., in breadth-first search) and sliding windows.
+//
+// For better performance, all functions in this package are unsafe
+// for concurrency unless otherwise specified.
+package deque
@@ -0,0 +1,199 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package deque
+
+import (
+	"fmt"
+
+	"github.com/donyori/gogo/container/sequence/array"
+	"github.com/donyori/gogo/errors"
+)
+
+// ring is a sequence of n items stored in a ring buffer,
+// starting at index head of the buffer.
+//
+// *ring implements the interface
+// github.com/donyori/gogo/container/sequence/array.Array.
+// It is used as the slice of a deque,
+// and embedded in deque to provide the array methods.
+type ring[Item any] struct {
+	buf  []Item
+	head int
+	n    int
+}
+
+var _ array.Array[any] = (*ring[any])(nil)
+
+func (r *ring[Item]) Len() int {
+	return r.n
+}
+
+// Range accesses the items from first to last.
+// Each item is accessed once.
+//
+// Its parameter handler is a function to deal with the item x in the
+// sequence and report whether to continue to access the next item.
+//
+// The items appended to the sequence during ranging are not accessed.
+func (r *ring[Item]) Range(handler func(x Item) (cont bool)) {
+	buf, head, n := r.buf, r.head, r.n
+	for i := range n {
+		p := head + i
+		if p >= len(buf) {
+			p -= len(buf)
+		}
+		if !handler(buf[p]) {
+			return
+		}
+	}
+}
+
+func (r *ring[Item]) Front() Item {
+	r.checkNonempty()
+	return r.buf[r.head]
+}
+
+func (r *ring[Item]) SetFront(x Item) {
+	r.checkNonempty()
+	r.buf[r.head] = x
+}
+
+func (r *ring[Item]) Back() Item {
+	r.checkNonempty()
+	return r.buf[r.pos(r.n-1)]
+}
+
+func (r *ring[Item]) SetBack(x Item) {
+	r.checkNonempty()
+	r.buf[r.pos(r.n-1)] = x
+}
+
+func (r *ring[Item]) Reverse() {
+	for i, j := 0, r.n-1; i < j; i, j = i+1, j-1 {
+		p, q := r.pos(i), r.pos(j)
+		r.buf[p], r.buf[q] = r.buf[q], r.buf[p]
+	}
+}
+
+func (r *ring[Item]) Get(i int) Item {
+	r.checkIndex(i)
+	return r.buf[r.pos(i)]
+}
+
+func (r *ring[Item]) Set(i int, x Item) {
+	r.checkIndex(i)
+	r.buf[r.pos(i)] = x
+}
+
+func (r *ring[Item]) Swap(i, j int) {
+	r.checkIndex(i)
+	r.checkIndex(j)
+	p, q := r.pos(i), r.pos(j)
+	r.buf[p], r.buf[q] = r.buf[q], r.buf[p]
+}
+
+// Slice returns a slice from argument begin (inclusive) to
+// argument end (exclusive) of the sequence, as an Array.
+//
+// The returned Array shares the underlying buffer with the sequence,
+// like a Go slice.
+// If the deque reallocates its buffer later
+// (e.g., when its capacity is insufficient),
+// the returned Array no longer reflects the changes of the deque.
+//
+// It panics if begin or end is out of range, or begin > end.
+func (r *ring[Item]) Slice(begin, end int) array.Array[Item] {
+	r.checkRange(begin, end)
+	head := r.head
+	if end > begin {
+		head = r.pos(begin)
+	}
+	return &ring[Item]{buf: r.buf, head: head, n: end - begin}
+}
+
+// pos returns the index in the buffer of the item at index i.
+//
+// The caller must guarantee that 0 <= i < 2*len(r.buf)-r.head.
+func (r *ring[Item]) pos(i int) int {
+	p := r.head + i
+	if p >= len(r.buf) {
+		p -= len(r.buf)
+	}
+	return p
+}
+
+// zero sets the items from index begin (inclusive)
+// to index end (exclusive) to zero values.
+func (r *ring[Item]) zero(begin, end int) {
+	if begin >= end {
+		return
+	}
+	p, q := r.pos(begin), r.pos(end-1)
+	if p <= q {
+		clear(r.buf[p : q+1])
+	} else {
+		clear(r.buf[p:])
+		clear(r.buf[:q+1])
+	}
+}
+
+// copyTo copies the items to dst from index 0.
+//
+// The caller must guarantee that len(dst) >= r.n.
+func (r *ring[Item]) copyTo(dst []Item) {
+	if r.n == 0 {
+		return
+	}
+	k := copy(dst[:r.n], r.buf[r.head:])
+	copy(dst[k:r.n], r.buf)
+}
+
+// toSlice returns a copy of the items as a new Go slice.
+func (r *ring[Item]) toSlice() []Item {
+	s := make([]Item, r.n)
+	r.copyTo(s)
+	return s
+}
+
+// checkNonempty panics if the sequence is empty.
+func (r *ring[Item]) checkNonempty() {
+	if r.n == 0 {
+		panic(errors.AutoMsgCustom(emptyPanicMessage, -1, 1))
+	}
+}
+
+// checkIndex panics if i is out of range [0, r.n).
+func (r *ring[Item]) checkIndex(i int) {
+	if i < 0 || i >= r.n {
+		panic(errors.AutoMsgCustom(fmt.Sprintf(
+			"index %d is out of range [0:%d]", i, r.n), -1, 1))
+	}
+}
+
+// checkRange panics if begin or end is out of range [0, r.n],
+// or begin > end.
+func (r *ring[Item]) checkRange(begin, end int) {
+	if begin < 0 || end > r.n || begin > end {
+		panic(errors.AutoMsgCustom(fmt.Sprintf(
+			"slice bounds [%d:%d] is out of range [0:%d]", begin, end, r.n),
+			-1, 1))
+	}
+}
+
+const emptyPanicMessage = "deque is empty"
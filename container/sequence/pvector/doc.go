@@ -0,0 +1,31 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package pvector provides an immutable persistent vector,
+// which is a sequence whose update operations return new versions
+// and leave the original one unchanged.
+//
+// The versions share their structure,
+// so the update operations take only O(log n) time and space,
+// and snapshots of large sequences can be kept cheaply.
+//
+// As vectors are never modified after creation,
+// all functions in this package are safe for concurrency.
+// In particular, a vector can be passed to other goroutines
+// without copying, where sharing a mutable Go slice is unsafe.
+package pvector
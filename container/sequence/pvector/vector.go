@@ -0,0 +1,407 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pvector
+
+import (
+	"fmt"
+	"iter"
+
+	"github.com/donyori/gogo/container"
+	"github.com/donyori/gogo/errors"
+)
+
+const (
+	// bits is the number of bits of an index consumed by each trie level.
+	bits = 5
+
+	// width is the maximum number of children of a node.
+	width = 1 << bits
+
+	// mask is the bit mask to get the child index in a node.
+	mask = width - 1
+)
+
+// Vector is an interface representing an immutable persistent vector.
+//
+// Its methods Set, Append, and Pop return new vectors
+// that share structure with the original one,
+// and the original vector remains unchanged.
+//
+// It is implemented as a 32-way trie with a tail buffer,
+// so the time complexity of Get, Set, and Pop is O(log n),
+// where n = Len(), and the logarithm base is 32
+// (i.e., at most 7 levels for 2^32 items).
+// The amortized time complexity of appending an item is O(1)
+// (plus O(log n) every 32 items).
+//
+// Its method Range accesses the items from first to last.
+//
+// The client should not modify the items
+// (e.g., the contents pointed to by pointer items)
+// unless they are not shared with other goroutines.
+type Vector[Item any] interface {
+	container.Container[Item]
+
+	// Get returns the item at index i.
+	//
+	// It panics if i is out of range.
+	Get(i int) Item
+
+	// Front returns the first item.
+	//
+	// It panics if the vector is empty.
+	Front() Item
+
+	// Back returns the last item.
+	//
+	// It panics if the vector is empty.
+	Back() Item
+
+	// Set returns a new vector with the item at index i replaced by x.
+	//
+	// It panics if i is out of range.
+	Set(i int, x Item) Vector[Item]
+
+	// Append returns a new vector with the specified items
+	// appended to the back.
+	//
+	// If no items are specified, it returns the vector itself.
+	Append(x ...Item) Vector[Item]
+
+	// Pop returns a new vector with the last item removed.
+	//
+	// It panics if the vector is empty.
+	Pop() Vector[Item]
+
+	// All returns an iterator over index-item pairs in the vector,
+	// traversing it from first to last with the index in ascending order.
+	All() iter.Seq2[int, Item]
+
+	// Backward returns an iterator over index-item pairs in the vector,
+	// traversing it from last to first with the index in descending order.
+	Backward() iter.Seq2[int, Item]
+
+	// ToSlice returns a newly allocated Go slice of the items in the vector.
+	//
+	// It returns nil if the vector is empty.
+	ToSlice() []Item
+}
+
+// node is a node of the trie.
+//
+// An internal node has only children,
+// and a leaf node has only items (exactly width items).
+//
+// Nodes are never modified after they become reachable from a vector.
+type node[Item any] struct {
+	children []*node[Item]
+	items    []Item
+}
+
+// vector is an implementation of interface Vector.
+//
+// The last 1 to width items (or no items if the vector is empty)
+// are stored in tail, and the others are stored in the trie rooted at root.
+//
+// The slices tail and the slices in the nodes are never modified in place.
+type vector[Item any] struct {
+	n     int
+	shift uint
+	root  *node[Item]
+	tail  []Item
+}
+
+// New creates a new vector with the specified items.
+//
+// The vector does not retain the Go slice of the items.
+func New[Item any](x ...Item) Vector[Item] {
+	v := &vector[Item]{shift: bits, root: new(node[Item])}
+	if len(x) == 0 {
+		return v
+	}
+	return v.Append(x...)
+}
+
+func (v *vector[Item]) Len() int {
+	return v.n
+}
+
+// Range accesses the items in the vector from first to last.
+// Each item is accessed once.
+//
+// Its parameter handler is a function to deal with the item x in the
+// vector and report whether to continue to access the next item.
+func (v *vector[Item]) Range(handler func(x Item) (cont bool)) {
+	for _, x := range v.All() {
+		if !handler(x) {
+			return
+		}
+	}
+}
+
+func (v *vector[Item]) Get(i int) Item {
+	v.checkIndex(i)
+	return v.leafFor(i)[i&mask]
+}
+
+func (v *vector[Item]) Front() Item {
+	v.checkNonEmpty()
+	return v.leafFor(0)[0]
+}
+
+func (v *vector[Item]) Back() Item {
+	v.checkNonEmpty()
+	return v.tail[len(v.tail)-1]
+}
+
+func (v *vector[Item]) Set(i int, x Item) Vector[Item] {
+	v.checkIndex(i)
+	r := *v
+	if off := v.tailOffset(); i >= off {
+		r.tail = append(v.tail[:0:0], v.tail...)
+		r.tail[i-off] = x
+	} else {
+		r.root = setInNode(v.root, v.shift, i, x)
+	}
+	return &r
+}
+
+func (v *vector[Item]) Append(x ...Item) Vector[Item] {
+	if len(x) == 0 {
+		return v
+	}
+	r := *v
+	tail := make([]Item, len(v.tail), min(len(v.tail)+len(x), width))
+	copy(tail, v.tail)
+	for i := range x {
+		if len(tail) == width {
+			r.pushTail(tail)
+			tail = make([]Item, 0, min(len(x)-i, width))
+		}
+		tail = append(tail, x[i])
+		r.n++
+	}
+	r.tail = tail
+	return &r
+}
+
+func (v *vector[Item]) Pop() Vector[Item] {
+	v.checkNonEmpty()
+	if v.n == 1 {
+		return New[Item]()
+	}
+	r := *v
+	r.n--
+	if len(v.tail) > 1 {
+		r.tail = v.tail[:len(v.tail)-1]
+		return &r
+	}
+	r.tail = v.leafFor(v.n - 2)
+	r.root = r.popTail(v.root, v.shift)
+	if r.root == nil {
+		r.root = new(node[Item])
+	} else if r.shift > bits && len(r.root.children) == 1 {
+		r.root = r.root.children[0]
+		r.shift -= bits
+	}
+	return &r
+}
+
+func (v *vector[Item]) All() iter.Seq2[int, Item] {
+	return func(yield func(int, Item) bool) {
+		for base := 0; base < v.n; base += width {
+			for j, x := range v.leafFor(base) {
+				if !yield(base+j, x) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (v *vector[Item]) Backward() iter.Seq2[int, Item] {
+	return func(yield func(int, Item) bool) {
+		if v.n == 0 {
+			return
+		}
+		for base := (v.n - 1) &^ mask; base >= 0; base -= width {
+			leaf := v.leafFor(base)
+			for j := len(leaf) - 1; j >= 0; j-- {
+				if !yield(base+j, leaf[j]) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (v *vector[Item]) ToSlice() []Item {
+	if v.n == 0 {
+		return nil
+	}
+	s := make([]Item, 0, v.n)
+	for base := 0; base < v.n; base += width {
+		s = append(s, v.leafFor(base)...)
+	}
+	return s
+}
+
+// tailOffset returns the index of the first item in the tail.
+func (v *vector[Item]) tailOffset() int {
+	if v.n == 0 {
+		return 0
+	}
+	return (v.n - 1) &^ mask
+}
+
+// leafFor returns the Go slice of items (a leaf or the tail)
+// containing the item at index i.
+//
+// The caller must guarantee that i is in range.
+func (v *vector[Item]) leafFor(i int) []Item {
+	if i >= v.tailOffset() {
+		return v.tail
+	}
+	nd := v.root
+	for level := v.shift; level > 0; level -= bits {
+		nd = nd.children[i>>level&mask]
+	}
+	return nd.items
+}
+
+// pushTail pushes the full tail into the trie of v,
+// updating v.root and v.shift.
+//
+// The tail is retained by the trie,
+// so the caller must not modify it afterward.
+//
+// v.n must be the number of items before pushing the tail,
+// including the items in the tail.
+func (v *vector[Item]) pushTail(tail []Item) {
+	leaf := &node[Item]{items: tail}
+	if v.n>>bits > 1<<v.shift {
+		// The trie is full. Add a new level.
+		v.root = &node[Item]{
+			children: []*node[Item]{v.root, newPath(v.shift, leaf)},
+		}
+		v.shift += bits
+		return
+	}
+	v.root = v.pushLeaf(v.root, v.shift, leaf)
+}
+
+// pushLeaf returns a copy of nd with the leaf inserted as
+// the last leaf in the subtrie rooted at nd, whose level is specified.
+func (v *vector[Item]) pushLeaf(
+	nd *node[Item],
+	level uint,
+	leaf *node[Item],
+) *node[Item] {
+	idx := (v.n - 1) >> level & mask
+	children := make(
+		[]*node[Item], len(nd.children), max(idx+1, len(nd.children)))
+	copy(children, nd.children)
+	var child *node[Item]
+	switch {
+	case level == bits:
+		child = leaf
+	case idx < len(nd.children):
+		child = v.pushLeaf(nd.children[idx], level-bits, leaf)
+	default:
+		child = newPath(level-bits, leaf)
+	}
+	if idx < len(children) {
+		children[idx] = child
+	} else {
+		children = append(children, child)
+	}
+	return &node[Item]{children: children}
+}
+
+// popTail returns a copy of nd with the last leaf removed from
+// the subtrie rooted at nd, whose level is specified.
+// It returns nil if the subtrie becomes empty.
+//
+// v.n must be the number of items after popping the last item,
+// so that the index of the new last item is v.n-1.
+func (v *vector[Item]) popTail(nd *node[Item], level uint) *node[Item] {
+	idx := (v.n - 1) >> level & mask
+	var child *node[Item]
+	if level > bits {
+		child = v.popTail(nd.children[idx], level-bits)
+	}
+	if child == nil && idx == 0 {
+		return nil
+	}
+	n := idx
+	if child != nil {
+		n++
+	}
+	children := make([]*node[Item], n)
+	copy(children, nd.children)
+	if child != nil {
+		children[idx] = child
+	}
+	return &node[Item]{children: children}
+}
+
+// checkIndex panics if i is out of range.
+func (v *vector[Item]) checkIndex(i int) {
+	if i < 0 || i >= v.n {
+		panic(errors.AutoMsgCustom(
+			fmt.Sprintf("index %d out of range [0:%d]", i, v.n), -1, 1))
+	}
+}
+
+// checkNonEmpty panics if the vector is empty.
+func (v *vector[Item]) checkNonEmpty() {
+	if v.n == 0 {
+		panic(errors.AutoMsgCustom("vector is empty", -1, 1))
+	}
+}
+
+// newPath returns a path of nodes from the specified level
+// down to the leaf.
+func newPath[Item any](level uint, leaf *node[Item]) *node[Item] {
+	nd := leaf
+	for ; level > 0; level -= bits {
+		nd = &node[Item]{children: []*node[Item]{nd}}
+	}
+	return nd
+}
+
+// setInNode returns a copy of nd with the item at index i replaced by x
+// in the subtrie rooted at nd, whose level is specified.
+func setInNode[Item any](
+	nd *node[Item],
+	level uint,
+	i int,
+	x Item,
+) *node[Item] {
+	r := new(node[Item])
+	if level == 0 {
+		r.items = append(nd.items[:0:0], nd.items...)
+		r.items[i&mask] = x
+	} else {
+		r.children = append(nd.children[:0:0], nd.children...)
+		idx := i >> level & mask
+		r.children[idx] = setInNode(nd.children[idx], level-bits, i, x)
+	}
+	return r
+}
@@ -0,0 +1,198 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pvector_test
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/donyori/gogo/container/sequence/pvector"
+)
+
+func TestVector_AppendPop(t *testing.T) {
+	const N = 32*32*32 + 32*32 + 100
+	want := make([]int, N)
+	for i := range want {
+		want[i] = i
+	}
+	vs := make([]pvector.Vector[int], N+1)
+	vs[0] = pvector.New[int]()
+	for i := range N {
+		vs[i+1] = vs[i].Append(i)
+	}
+	for _, n := range []int{0, 1, 31, 32, 33, 1024, 1056, 1057, 32800, 32801, N} {
+		checkVector(t, "Append", vs[n], want[:n])
+	}
+	v := vs[N]
+	for n := N; n > 0; n-- {
+		if got := v.Back(); got != n-1 {
+			t.Fatalf("len %d, got Back %d; want %d", n, got, n-1)
+		}
+		v = v.Pop()
+		if v.Len() != n-1 {
+			t.Fatalf("got Len %d after Pop; want %d", v.Len(), n-1)
+		}
+		switch n - 1 {
+		case 0, 1, 31, 32, 33, 1024, 1056, 1057, 32800, 32801:
+			checkVector(t, "Pop", v, want[:n-1])
+			checkVector(t, "Pop-then-Append", v.Append(want[n-1:]...), want)
+		}
+	}
+	// The old versions must be unchanged.
+	for _, n := range []int{0, 33, 1057, N} {
+		checkVector(t, "old version", vs[n], want[:n])
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, n := range []int{0, 1, 32, 33, 1056, 1057, 5000} {
+		want := make([]int, n)
+		for i := range want {
+			want[i] = i * 2
+		}
+		checkVector(t, "New", pvector.New(want...), want)
+	}
+}
+
+func TestVector_Random(t *testing.T) {
+	const NumOp = 20000
+	random := rand.New(rand.NewChaCha8(
+		[32]byte([]byte("ABCDEFGHIJKLMNOPQRSTUVWXYZ123456"))))
+	type version struct {
+		v pvector.Vector[int]
+		s []int
+	}
+	versions := []version{{v: pvector.New[int]()}}
+	for i := range NumOp {
+		base := versions[random.IntN(len(versions))]
+		var next version
+		switch op := random.IntN(10); {
+		case op < 3 && len(base.s) > 0:
+			j := random.IntN(len(base.s))
+			next.v = base.v.Set(j, i)
+			next.s = slices.Clone(base.s)
+			next.s[j] = i
+		case op < 5 && len(base.s) > 0:
+			next.v = base.v.Pop()
+			next.s = base.s[:len(base.s)-1]
+		default:
+			x := make([]int, random.IntN(70)+1)
+			for k := range x {
+				x[k] = i*100 + k
+			}
+			next.v = base.v.Append(x...)
+			next.s = append(slices.Clip(base.s), x...)
+		}
+		if next.v.Len() != len(next.s) {
+			t.Fatalf("op %d, got Len %d; want %d", i, next.v.Len(), len(next.s))
+		}
+		if len(next.s) > 0 {
+			j := random.IntN(len(next.s))
+			if got := next.v.Get(j); got != next.s[j] {
+				t.Fatalf("op %d, got Get(%d) %d; want %d", i, j, got, next.s[j])
+			}
+		}
+		versions = append(versions, next)
+	}
+	for _, ver := range versions {
+		if got := ver.v.ToSlice(); !slices.Equal(got, ver.s) {
+			t.Fatalf("got %v; want %v", got, ver.s)
+		}
+	}
+}
+
+func TestVector_Panic(t *testing.T) {
+	v := pvector.New(1, 2, 3)
+	testCases := []struct {
+		name string
+		f    func()
+	}{
+		{"Get(-1)", func() { v.Get(-1) }},
+		{"Get(3)", func() { v.Get(3) }},
+		{"Set(3)", func() { v.Set(3, 0) }},
+		{"empty Front", func() { pvector.New[int]().Front() }},
+		{"empty Back", func() { pvector.New[int]().Back() }},
+		{"empty Pop", func() { pvector.New[int]().Pop() }},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("want panic but not")
+				}
+			}()
+			tc.f()
+		})
+	}
+}
+
+func checkVector(t *testing.T, name string, v pvector.Vector[int], want []int) {
+	t.Helper()
+	if v.Len() != len(want) {
+		t.Fatalf("%s, got Len %d; want %d", name, v.Len(), len(want))
+	}
+	if got := v.ToSlice(); !slices.Equal(got, want) {
+		t.Fatalf("%s, len %d, ToSlice mismatch", name, len(want))
+	}
+	for i := range want {
+		if got := v.Get(i); got != want[i] {
+			t.Fatalf("%s, len %d, got Get(%d) %d; want %d",
+				name, len(want), i, got, want[i])
+		}
+	}
+	var i int
+	for j, x := range v.All() {
+		if j != i || x != want[i] {
+			t.Fatalf("%s, len %d, got All (%d, %d); want (%d, %d)",
+				name, len(want), j, x, i, want[i])
+		}
+		i++
+	}
+	i = len(want) - 1
+	for j, x := range v.Backward() {
+		if j != i || x != want[i] {
+			t.Fatalf("%s, len %d, got Backward (%d, %d); want (%d, %d)",
+				name, len(want), j, x, i, want[i])
+		}
+		i--
+	}
+	var rangeItems []int
+	v.Range(func(x int) (cont bool) {
+		rangeItems = append(rangeItems, x)
+		return true
+	})
+	if !slices.Equal(rangeItems, want) {
+		t.Fatalf("%s, len %d, Range mismatch", name, len(want))
+	}
+	if len(want) > 0 {
+		if v.Front() != want[0] || v.Back() != want[len(want)-1] {
+			t.Fatalf("%s, len %d, got Front %d, Back %d; want %d, %d", name,
+				len(want), v.Front(), v.Back(), want[0], want[len(want)-1])
+		}
+		s := v.Set(len(want)/2, -1)
+		if got := s.Get(len(want) / 2); got != -1 {
+			t.Fatalf("%s, len %d, got %d after Set; want -1",
+				name, len(want), got)
+		}
+		if got := v.Get(len(want) / 2); got != want[len(want)/2] {
+			t.Fatalf("%s, len %d, original changed after Set", name, len(want))
+		}
+	}
+}
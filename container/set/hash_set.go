@@ -0,0 +1,298 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package set
+
+import (
+	"iter"
+
+	"github.com/donyori/gogo/container"
+	"github.com/donyori/gogo/errors"
+)
+
+// HashSet is a set wrapped on Go map.
+// *HashSet implements the interface Set.
+//
+// The client can convert a Go map to HashSet by type conversion, e.g.:
+//
+//	HashSet[string](map[string]struct{}{"A": {}})
+//
+// Or allocate a new HashSet by the map literal or
+// the built-in function make, e.g.:
+//
+//	HashSet[string]{"A": {}}
+//	make(HashSet[string])
+type HashSet[Item comparable] map[Item]struct{}
+
+var _ Set[string] = (*HashSet[string])(nil)
+
+// Len returns the number of items in the set.
+//
+// It returns 0 if the set is nil.
+func (hs *HashSet[Item]) Len() int {
+	var n int
+	if hs != nil {
+		n = len(*hs)
+	}
+	return n
+}
+
+// Range accesses the items in the set.
+// Each item is accessed once.
+// The order of access is random.
+//
+// Its parameter handler is a function to deal with the item x in the
+// set and report whether to continue to access the next item.
+func (hs *HashSet[Item]) Range(handler func(x Item) (cont bool)) {
+	if hs != nil {
+		for x := range *hs {
+			if !handler(x) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over the items in the set.
+// The order of iteration is random.
+func (hs *HashSet[Item]) All() iter.Seq[Item] {
+	return func(yield func(Item) bool) {
+		hs.Range(yield)
+	}
+}
+
+// Filter refines items in the set.
+//
+// Its parameter filter is a function to report whether to keep the item x.
+func (hs *HashSet[Item]) Filter(filter func(x Item) (keep bool)) {
+	if hs != nil {
+		for x := range *hs {
+			if !filter(x) {
+				delete(*hs, x)
+			}
+		}
+	}
+}
+
+// ContainsItem reports whether the item x is in the set.
+func (hs *HashSet[Item]) ContainsItem(x Item) bool {
+	if hs == nil {
+		return false
+	}
+	_, ok := (*hs)[x]
+	return ok
+}
+
+// ContainsSet reports whether the set s is a subset of this set.
+func (hs *HashSet[Item]) ContainsSet(s Set[Item]) bool {
+	if s == nil {
+		return true
+	}
+	n := s.Len()
+	if n == 0 {
+		return true
+	} else if n > hs.Len() {
+		return false
+	}
+	return hs.ContainsAll(s)
+}
+
+// ContainsAny reports whether any item in c is in this set.
+//
+// If c is nil or empty, it returns false.
+func (hs *HashSet[Item]) ContainsAny(c container.Container[Item]) bool {
+	if c == nil || c.Len() == 0 || hs.Len() == 0 {
+		return false
+	}
+	var ok bool
+	c.Range(func(x Item) (cont bool) {
+		_, ok = (*hs)[x]
+		return !ok
+	})
+	return ok
+}
+
+// ContainsAll reports whether all items in c are in this set.
+//
+// Unlike ContainsSet, c can be any container,
+// possibly with duplicate items.
+//
+// If c is nil or empty, it returns true.
+func (hs *HashSet[Item]) ContainsAll(c container.Container[Item]) bool {
+	if c == nil || c.Len() == 0 {
+		return true
+	} else if hs.Len() == 0 {
+		return false
+	}
+	ok := true
+	c.Range(func(x Item) (cont bool) {
+		_, ok = (*hs)[x]
+		return ok
+	})
+	return ok
+}
+
+// Equal reports whether the set s contains exactly
+// the same items as this set.
+//
+// A nil s is treated as an empty set.
+func (hs *HashSet[Item]) Equal(s Set[Item]) bool {
+	if s == nil {
+		return hs.Len() == 0
+	}
+	return s.Len() == hs.Len() && hs.ContainsAll(s)
+}
+
+// Add adds x to the set.
+//
+// It panics if x is not empty and hs is nil.
+func (hs *HashSet[Item]) Add(x ...Item) {
+	if len(x) == 0 {
+		return
+	}
+	hs.init(len(x))
+	for _, item := range x {
+		(*hs)[item] = struct{}{}
+	}
+}
+
+// AddSeq adds the items yielded by seq to the set.
+//
+// It panics if seq is not nil and hs is nil.
+func (hs *HashSet[Item]) AddSeq(seq iter.Seq[Item]) {
+	if seq == nil {
+		return
+	}
+	hs.init(0)
+	for x := range seq {
+		(*hs)[x] = struct{}{}
+	}
+}
+
+// Remove removes x from the set.
+//
+// It does nothing for the items in x that are not in the set.
+func (hs *HashSet[Item]) Remove(x ...Item) {
+	if hs != nil {
+		for _, item := range x {
+			delete(*hs, item)
+		}
+	}
+}
+
+// Union adds the items in s to this set.
+// That is, perform the following assignment:
+//
+//	thisSet = thisSet ∪ s
+//
+// It panics if s is not nil or empty and hs is nil.
+func (hs *HashSet[Item]) Union(s Set[Item]) {
+	if s == nil {
+		return
+	}
+	n := s.Len()
+	if n == 0 {
+		return
+	}
+	hs.init(n)
+	s.Range(func(x Item) (cont bool) {
+		(*hs)[x] = struct{}{}
+		return true
+	})
+}
+
+// Intersect removes the items not in s.
+// That is, perform the following assignment:
+//
+//	thisSet = thisSet ∩ s
+func (hs *HashSet[Item]) Intersect(s Set[Item]) {
+	if hs.Len() == 0 {
+		return
+	} else if s == nil || s.Len() == 0 {
+		clear(*hs)
+		return
+	}
+	for x := range *hs {
+		if !s.ContainsItem(x) {
+			delete(*hs, x)
+		}
+	}
+}
+
+// Subtract removes the items in s.
+// That is, perform the following assignment:
+//
+//	thisSet = thisSet \ s
+func (hs *HashSet[Item]) Subtract(s Set[Item]) {
+	if hs.Len() == 0 || s == nil || s.Len() == 0 {
+		return
+	} else if t, ok := s.(*HashSet[Item]); ok && t == hs {
+		clear(*hs)
+		return
+	}
+	s.Range(func(x Item) (cont bool) {
+		delete(*hs, x)
+		return true
+	})
+}
+
+// DisjunctiveUnion removes the items both in s and this set and
+// adds the items only in s.
+// That is, perform the following assignment:
+//
+//	thisSet = thisSet △ s
+//
+// It panics if s is not nil or empty and hs is nil.
+func (hs *HashSet[Item]) DisjunctiveUnion(s Set[Item]) {
+	if s == nil || s.Len() == 0 {
+		return
+	} else if t, ok := s.(*HashSet[Item]); ok && t == hs {
+		clear(*hs)
+		return
+	}
+	hs.init(0)
+	s.Range(func(x Item) (cont bool) {
+		if _, ok := (*hs)[x]; ok {
+			delete(*hs, x)
+		} else {
+			(*hs)[x] = struct{}{}
+		}
+		return true
+	})
+}
+
+// Clear sets the set to nil.
+func (hs *HashSet[Item]) Clear() {
+	if hs != nil {
+		*hs = nil
+	}
+}
+
+// init allocates the Go map with the specified capacity hint
+// if *hs is nil.
+//
+// It panics if hs is nil.
+func (hs *HashSet[Item]) init(capacity int) {
+	if hs == nil {
+		panic(errors.AutoMsgCustom(nilHashSetPointerPanicMessage, -1, 1))
+	} else if *hs == nil {
+		*hs = make(HashSet[Item], capacity)
+	}
+}
+
+const nilHashSetPointerPanicMessage = "*HashSet[...] is nil"
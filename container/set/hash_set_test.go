@@ -0,0 +1,152 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package set_test
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/donyori/gogo/container/sequence/array"
+	"github.com/donyori/gogo/container/set"
+)
+
+type IntSDAPtr = *array.SliceDynamicArray[int]
+
+func TestHashSet_NilAndZero(t *testing.T) {
+	var hs set.HashSet[int]
+	if n := hs.Len(); n != 0 {
+		t.Errorf("got len %d; want 0", n)
+	}
+	if hs.ContainsItem(1) {
+		t.Error("zero-value set contains 1")
+	}
+	hs.Add(1, 2, 2)
+	checkHashSet(t, &hs, 1, 2)
+
+	var nilPtr *set.HashSet[int]
+	if n := nilPtr.Len(); n != 0 {
+		t.Errorf("nil pointer, got len %d; want 0", n)
+	}
+	nilPtr.Remove(1) // no panic
+	defer func() {
+		if e := recover(); e == nil {
+			t.Error("want panic but not")
+		}
+	}()
+	nilPtr.Add(1) // want panic here
+}
+
+func TestHashSet_AddSeqAll(t *testing.T) {
+	hs := make(set.HashSet[int])
+	hs.AddSeq(slices.Values([]int{3, 1, 3, 2}))
+	checkHashSet(t, &hs, 1, 2, 3)
+	got := slices.Sorted(hs.All())
+	if want := []int{1, 2, 3}; !slices.Equal(got, want) {
+		t.Errorf("got All %v; want %v", got, want)
+	}
+	var n int
+	for range hs.All() {
+		if n++; n == 2 {
+			break
+		}
+	}
+	if n != 2 {
+		t.Errorf("got %d iterations after break; want 2", n)
+	}
+}
+
+func TestHashSet_ContainsAllAndEqual(t *testing.T) {
+	hs := set.HashSet[int]{1: {}, 2: {}, 3: {}}
+	testCases := []struct {
+		data        []int
+		containsAll bool
+		equal       bool
+	}{
+		{nil, true, false},
+		{[]int{1, 1, 2}, true, false},
+		{[]int{1, 2, 3}, true, true},
+		{[]int{3, 2, 1, 1}, true, false},
+		{[]int{1, 4}, false, false},
+	}
+	for _, tc := range testCases {
+		data := tc.data
+		if got := hs.ContainsAll(IntSDAPtr(&data)); got != tc.containsAll {
+			t.Errorf("ContainsAll(%v), got %t; want %t", data, got, tc.containsAll)
+		}
+		other := make(set.HashSet[int])
+		other.Add(data...)
+		wantEqual := tc.equal || len(other) == 3 && tc.containsAll
+		if got := hs.Equal(&other); got != wantEqual {
+			t.Errorf("Equal(%v), got %t; want %t", data, got, wantEqual)
+		}
+	}
+	var empty set.HashSet[int]
+	if !empty.Equal(nil) {
+		t.Error("empty set is not equal to nil")
+	}
+}
+
+func TestHashSet_Algebra(t *testing.T) {
+	testCases := []struct {
+		name string
+		op   func(hs *set.HashSet[int], s set.Set[int])
+		want []int
+	}{
+		{"Union", (*set.HashSet[int]).Union, []int{1, 2, 3, 4, 5}},
+		{"Intersect", (*set.HashSet[int]).Intersect, []int{3}},
+		{"Subtract", (*set.HashSet[int]).Subtract, []int{1, 2}},
+		{"DisjunctiveUnion", (*set.HashSet[int]).DisjunctiveUnion, []int{1, 2, 4, 5}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hs := set.HashSet[int]{1: {}, 2: {}, 3: {}}
+			tc.op(&hs, &set.HashSet[int]{3: {}, 4: {}, 5: {}})
+			checkHashSet(t, &hs, tc.want...)
+		})
+	}
+}
+
+func TestHashSet_FilterClear(t *testing.T) {
+	hs := set.HashSet[int]{1: {}, 2: {}, 3: {}, 4: {}}
+	hs.Filter(func(x int) (keep bool) {
+		return x%2 == 0
+	})
+	checkHashSet(t, &hs, 2, 4)
+	hs.Clear()
+	if hs != nil {
+		t.Errorf("got %v after Clear; want <nil>", hs)
+	}
+}
+
+func checkHashSet(t *testing.T, hs *set.HashSet[int], want ...int) {
+	t.Helper()
+	if n := hs.Len(); n != len(want) {
+		t.Errorf("got len %d; want %d", n, len(want))
+	}
+	got := slices.Sorted(maps.Keys(*hs))
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+	for _, x := range want {
+		if !hs.ContainsItem(x) {
+			t.Errorf("item %d not in set", x)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package set
+
+import "iter"
+
+// SortedSet is an interface representing a set
+// whose items are sorted in ascending order.
+//
+// Its methods Range and Filter access the items in ascending order.
+//
+// The set must not be modified during ranging or iterating over it,
+// except by the method Filter itself.
+type SortedSet[Item any] interface {
+	Set[Item]
+
+	// Min returns the minimum item in the set.
+	//
+	// It also returns an indicator present to report
+	// whether the set is nonempty.
+	Min() (x Item, present bool)
+
+	// Max returns the maximum item in the set.
+	//
+	// It also returns an indicator present to report
+	// whether the set is nonempty.
+	Max() (x Item, present bool)
+
+	// Floor returns the greatest item in the set
+	// less than or equal to x.
+	//
+	// It also returns an indicator present to report
+	// whether such an item exists.
+	Floor(x Item) (floor Item, present bool)
+
+	// Ceiling returns the least item in the set
+	// greater than or equal to x.
+	//
+	// It also returns an indicator present to report
+	// whether such an item exists.
+	Ceiling(x Item) (ceiling Item, present bool)
+
+	// RangeBetween accesses the items in the interval [low, high)
+	// in ascending order.
+	//
+	// Its parameter handler is a function to deal with the item x
+	// and report whether to continue to access the next item.
+	RangeBetween(low, high Item, handler func(x Item) (cont bool))
+
+	// All returns an iterator over the items in ascending order.
+	All() iter.Seq[Item]
+
+	// Backward returns an iterator over the items in descending order.
+	Backward() iter.Seq[Item]
+
+	// Between returns an iterator over the items in the interval [low, high)
+	// in ascending order.
+	Between(low, high Item) iter.Seq[Item]
+
+	// Clone returns a copy of the set,
+	// with the same order of items.
+	Clone() SortedSet[Item]
+}
@@ -0,0 +1,319 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sortedset
+
+import (
+	"iter"
+
+	"github.com/donyori/gogo/container"
+	"github.com/donyori/gogo/container/mapping"
+	"github.com/donyori/gogo/container/omap"
+	"github.com/donyori/gogo/container/set"
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/function/compare"
+)
+
+// sortedSet is an implementation of interface
+// github.com/donyori/container/set.SortedSet based on
+// github.com/donyori/gogo/container/omap.OrderedMap.
+type sortedSet[Item any] struct {
+	lessFn compare.LessFunc[Item]
+	m      omap.OrderedMap[Item, struct{}]
+}
+
+// New creates a new sorted set.
+//
+// lessFn is a function to report whether a < b.
+// It must describe a strict weak ordering.
+// See <https://en.wikipedia.org/wiki/Weak_ordering#Strict_weak_orderings>
+// for details.
+// Two items a and b are considered equal if
+// !lessFn(a, b) && !lessFn(b, a).
+//
+// Note that floating-point comparison
+// (the < operator on float32 or float64 values)
+// is not a strict weak ordering when not-a-number (NaN) values are involved.
+//
+// items are the initial items added to the set.
+//
+// It panics if lessFn is nil.
+func New[Item any](
+	lessFn compare.LessFunc[Item],
+	items container.Container[Item],
+) set.SortedSet[Item] {
+	if lessFn == nil {
+		panic(errors.AutoMsg("lessFn is nil"))
+	}
+	ss := &sortedSet[Item]{
+		lessFn: lessFn,
+		m:      omap.New[Item, struct{}](lessFn, nil),
+	}
+	if items != nil && items.Len() > 0 {
+		items.Range(func(x Item) (cont bool) {
+			ss.m.Set(x, struct{}{})
+			return true
+		})
+	}
+	return ss
+}
+
+// Union returns a new sorted set consisting of
+// the items in s or in any of others.
+//
+// The new set uses the same order of items as s.
+// s and others are not modified.
+//
+// It panics if s is nil.
+func Union[Item any](
+	s set.SortedSet[Item],
+	others ...set.Set[Item],
+) set.SortedSet[Item] {
+	if s == nil {
+		panic(errors.AutoMsg("s is nil"))
+	}
+	result := s.Clone()
+	for _, other := range others {
+		result.Union(other)
+	}
+	return result
+}
+
+// Intersection returns a new sorted set consisting of
+// the items in s and in all of others.
+//
+// The new set uses the same order of items as s.
+// s and others are not modified.
+//
+// It panics if s is nil.
+func Intersection[Item any](
+	s set.SortedSet[Item],
+	others ...set.Set[Item],
+) set.SortedSet[Item] {
+	if s == nil {
+		panic(errors.AutoMsg("s is nil"))
+	}
+	result := s.Clone()
+	for _, other := range others {
+		result.Intersect(other)
+	}
+	return result
+}
+
+// Difference returns a new sorted set consisting of
+// the items in s but not in any of others.
+//
+// The new set uses the same order of items as s.
+// s and others are not modified.
+//
+// It panics if s is nil.
+func Difference[Item any](
+	s set.SortedSet[Item],
+	others ...set.Set[Item],
+) set.SortedSet[Item] {
+	if s == nil {
+		panic(errors.AutoMsg("s is nil"))
+	}
+	result := s.Clone()
+	for _, other := range others {
+		result.Subtract(other)
+	}
+	return result
+}
+
+func (ss *sortedSet[Item]) Len() int {
+	return ss.m.Len()
+}
+
+// Range accesses the items in the set in ascending order.
+// Each item is accessed once.
+//
+// Its parameter handler is a function to deal with the item x in the
+// set and report whether to continue to access the next item.
+func (ss *sortedSet[Item]) Range(handler func(x Item) (cont bool)) {
+	for x := range ss.m.All() {
+		if !handler(x) {
+			return
+		}
+	}
+}
+
+func (ss *sortedSet[Item]) Filter(filter func(x Item) (keep bool)) {
+	ss.m.Filter(func(x mapping.Entry[Item, struct{}]) (keep bool) {
+		return filter(x.Key)
+	})
+}
+
+func (ss *sortedSet[Item]) ContainsItem(x Item) bool {
+	_, present := ss.m.Get(x)
+	return present
+}
+
+func (ss *sortedSet[Item]) ContainsSet(s set.Set[Item]) bool {
+	if s == nil {
+		return true
+	}
+	n := s.Len()
+	if n == 0 {
+		return true
+	} else if n > ss.m.Len() {
+		return false
+	}
+	ok := true
+	s.Range(func(x Item) (cont bool) {
+		ok = ss.ContainsItem(x)
+		return ok
+	})
+	return ok
+}
+
+func (ss *sortedSet[Item]) ContainsAny(c container.Container[Item]) bool {
+	if c == nil || c.Len() == 0 {
+		return false
+	}
+	var ok bool
+	c.Range(func(x Item) (cont bool) {
+		ok = ss.ContainsItem(x)
+		return !ok
+	})
+	return ok
+}
+
+func (ss *sortedSet[Item]) Add(x ...Item) {
+	for _, item := range x {
+		ss.m.Set(item, struct{}{})
+	}
+}
+
+func (ss *sortedSet[Item]) Remove(x ...Item) {
+	ss.m.Remove(x...)
+}
+
+func (ss *sortedSet[Item]) Union(s set.Set[Item]) {
+	if s == nil || s.Len() == 0 || s == set.Set[Item](ss) {
+		return
+	}
+	s.Range(func(x Item) (cont bool) {
+		ss.m.Set(x, struct{}{})
+		return true
+	})
+}
+
+func (ss *sortedSet[Item]) Intersect(s set.Set[Item]) {
+	if s == nil || s.Len() == 0 {
+		ss.m.Clear()
+		return
+	}
+	ss.m.Filter(func(x mapping.Entry[Item, struct{}]) (keep bool) {
+		return s.ContainsItem(x.Key)
+	})
+}
+
+func (ss *sortedSet[Item]) Subtract(s set.Set[Item]) {
+	if s == nil || s.Len() == 0 {
+		return
+	} else if s == set.Set[Item](ss) {
+		ss.m.Clear()
+		return
+	}
+	s.Range(func(x Item) (cont bool) {
+		ss.m.Remove(x)
+		return ss.m.Len() > 0
+	})
+}
+
+func (ss *sortedSet[Item]) DisjunctiveUnion(s set.Set[Item]) {
+	if s == nil || s.Len() == 0 {
+		return
+	} else if s == set.Set[Item](ss) {
+		ss.m.Clear()
+		return
+	}
+	s.Range(func(x Item) (cont bool) {
+		if _, present := ss.m.GetAndRemove(x); !present {
+			ss.m.Set(x, struct{}{})
+		}
+		return true
+	})
+}
+
+func (ss *sortedSet[Item]) Clear() {
+	ss.m.Clear()
+}
+
+func (ss *sortedSet[Item]) Min() (x Item, present bool) {
+	entry, present := ss.m.Min()
+	return entry.Key, present
+}
+
+func (ss *sortedSet[Item]) Max() (x Item, present bool) {
+	entry, present := ss.m.Max()
+	return entry.Key, present
+}
+
+func (ss *sortedSet[Item]) Floor(x Item) (floor Item, present bool) {
+	entry, present := ss.m.Floor(x)
+	return entry.Key, present
+}
+
+func (ss *sortedSet[Item]) Ceiling(x Item) (ceiling Item, present bool) {
+	entry, present := ss.m.Ceiling(x)
+	return entry.Key, present
+}
+
+func (ss *sortedSet[Item]) RangeBetween(
+	low, high Item,
+	handler func(x Item) (cont bool),
+) {
+	for x := range ss.m.Between(low, high) {
+		if !handler(x) {
+			return
+		}
+	}
+}
+
+func (ss *sortedSet[Item]) All() iter.Seq[Item] {
+	return keys(ss.m.All())
+}
+
+func (ss *sortedSet[Item]) Backward() iter.Seq[Item] {
+	return keys(ss.m.Backward())
+}
+
+func (ss *sortedSet[Item]) Between(low, high Item) iter.Seq[Item] {
+	return keys(ss.m.Between(low, high))
+}
+
+func (ss *sortedSet[Item]) Clone() set.SortedSet[Item] {
+	m := omap.New[Item, struct{}](ss.lessFn, nil)
+	for x := range ss.m.All() {
+		m.Set(x, struct{}{})
+	}
+	return &sortedSet[Item]{lessFn: ss.lessFn, m: m}
+}
+
+// keys returns an iterator over the keys of seq.
+func keys[Item any](seq iter.Seq2[Item, struct{}]) iter.Seq[Item] {
+	return func(yield func(Item) bool) {
+		for x := range seq {
+			if !yield(x) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,169 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package sortedset_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/donyori/gogo/container/sequence/array"
+	"github.com/donyori/gogo/container/set"
+	"github.com/donyori/gogo/container/set/mapset"
+	"github.com/donyori/gogo/container/set/sortedset"
+	"github.com/donyori/gogo/function/compare"
+)
+
+type IntSDAPtr = *array.SliceDynamicArray[int]
+
+var IntLess = compare.OrderedLess[int]
+
+func TestNew(t *testing.T) {
+	data := []int{5, 3, 1, 3, 4, 1}
+	ss := sortedset.New(IntLess, IntSDAPtr(&data))
+	checkSortedSet(t, ss, []int{1, 3, 4, 5})
+}
+
+func TestSortedSet_AddRemoveContains(t *testing.T) {
+	ss := sortedset.New[int](IntLess, nil)
+	ss.Add(4, 2, 8, 6, 2)
+	checkSortedSet(t, ss, []int{2, 4, 6, 8})
+	ss.Remove(4, 5)
+	checkSortedSet(t, ss, []int{2, 6, 8})
+	if !ss.ContainsItem(6) || ss.ContainsItem(4) {
+		t.Errorf("got ContainsItem(6) %t, ContainsItem(4) %t; want true, false",
+			ss.ContainsItem(6), ss.ContainsItem(4))
+	}
+	sub := []int{2, 8}
+	if !ss.ContainsSet(mapset.New(0, IntSDAPtr(&sub))) {
+		t.Error("got ContainsSet({2, 8}) false; want true")
+	}
+	sub = append(sub, 3)
+	if ss.ContainsSet(mapset.New(0, IntSDAPtr(&sub))) {
+		t.Error("got ContainsSet({2, 3, 8}) true; want false")
+	}
+	if !ss.ContainsAny(IntSDAPtr(&sub)) {
+		t.Error("got ContainsAny({2, 8, 3}) false; want true")
+	}
+	ss.Filter(func(x int) (keep bool) {
+		return x > 2
+	})
+	checkSortedSet(t, ss, []int{6, 8})
+	ss.Clear()
+	checkSortedSet(t, ss, nil)
+}
+
+func TestSortedSet_Queries(t *testing.T) {
+	data := []int{10, 20, 30, 40}
+	ss := sortedset.New(IntLess, IntSDAPtr(&data))
+	if x, present := ss.Min(); !present || x != 10 {
+		t.Errorf("got Min %d, %t; want 10, true", x, present)
+	}
+	if x, present := ss.Max(); !present || x != 40 {
+		t.Errorf("got Max %d, %t; want 40, true", x, present)
+	}
+	if x, present := ss.Floor(25); !present || x != 20 {
+		t.Errorf("got Floor(25) %d, %t; want 20, true", x, present)
+	}
+	if _, present := ss.Floor(5); present {
+		t.Error("got Floor(5) present true; want false")
+	}
+	if x, present := ss.Ceiling(25); !present || x != 30 {
+		t.Errorf("got Ceiling(25) %d, %t; want 30, true", x, present)
+	}
+	if _, present := ss.Ceiling(45); present {
+		t.Error("got Ceiling(45) present true; want false")
+	}
+	var got []int
+	ss.RangeBetween(15, 40, func(x int) (cont bool) {
+		got = append(got, x)
+		return true
+	})
+	if want := []int{20, 30}; !slices.Equal(got, want) {
+		t.Errorf("got RangeBetween(15, 40) %v; want %v", got, want)
+	}
+	if got, want := slices.Collect(ss.Between(20, 41)), []int{20, 30, 40}; !slices.Equal(got, want) {
+		t.Errorf("got Between(20, 41) %v; want %v", got, want)
+	}
+	if got, want := slices.Collect(ss.Backward()), []int{40, 30, 20, 10}; !slices.Equal(got, want) {
+		t.Errorf("got Backward %v; want %v", got, want)
+	}
+}
+
+func TestSortedSet_InPlaceAlgebra(t *testing.T) {
+	a, b := []int{1, 2, 3, 4}, []int{3, 4, 5, 6}
+	testCases := []struct {
+		name string
+		op   func(ss set.SortedSet[int], s set.Set[int])
+		want []int
+	}{
+		{"Union", set.SortedSet[int].Union, []int{1, 2, 3, 4, 5, 6}},
+		{"Intersect", set.SortedSet[int].Intersect, []int{3, 4}},
+		{"Subtract", set.SortedSet[int].Subtract, []int{1, 2}},
+		{"DisjunctiveUnion", set.SortedSet[int].DisjunctiveUnion, []int{1, 2, 5, 6}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ss := sortedset.New(IntLess, IntSDAPtr(&a))
+			tc.op(ss, mapset.New(0, IntSDAPtr(&b)))
+			checkSortedSet(t, ss, tc.want)
+		})
+	}
+}
+
+func TestUnionIntersectionDifference(t *testing.T) {
+	a, b, c := []int{1, 2, 3, 4}, []int{3, 4, 5, 6}, []int{4, 6, 7}
+	sa := sortedset.New(IntLess, IntSDAPtr(&a))
+	sb := sortedset.New(IntLess, IntSDAPtr(&b))
+	sc := mapset.New(0, IntSDAPtr(&c))
+	checkSortedSet(t, sortedset.Union(sa, sb, sc), []int{1, 2, 3, 4, 5, 6, 7})
+	checkSortedSet(t, sortedset.Intersection(sa, sb, sc), []int{4})
+	checkSortedSet(t, sortedset.Difference(sa, sb, sc), []int{1, 2})
+	checkSortedSet(t, sortedset.Union[int](sa), a)
+	// The operands are not modified.
+	checkSortedSet(t, sa, a)
+	checkSortedSet(t, sb, b)
+}
+
+func TestSortedSet_Clone(t *testing.T) {
+	data := []int{3, 1, 2}
+	ss := sortedset.New(compare.OrderedLess[int], IntSDAPtr(&data))
+	clone := ss.Clone()
+	clone.Add(0)
+	ss.Remove(1)
+	checkSortedSet(t, ss, []int{2, 3})
+	checkSortedSet(t, clone, []int{0, 1, 2, 3})
+}
+
+func checkSortedSet(t *testing.T, ss set.SortedSet[int], want []int) {
+	t.Helper()
+	if n := ss.Len(); n != len(want) {
+		t.Errorf("got len %d; want %d", n, len(want))
+	}
+	var got []int
+	ss.Range(func(x int) (cont bool) {
+		got = append(got, x)
+		return true
+	})
+	if !slices.Equal(got, want) {
+		t.Errorf("got Range %v; want %v", got, want)
+	}
+	if got = slices.Collect(ss.All()); !slices.Equal(got, want) {
+		t.Errorf("got All %v; want %v", got, want)
+	}
+}
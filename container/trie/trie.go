@@ -0,0 +1,254 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"iter"
+	"slices"
+
+	"github.com/donyori/gogo/constraints"
+)
+
+// Trie is an interface representing a trie (prefix tree),
+// which maps byte string keys to values.
+//
+// The key type can be any type whose underlying type is []byte or string
+// (see github.com/donyori/gogo/constraints.ByteString).
+// The trie does not retain the keys passed to its methods.
+//
+// The keys are iterated in lexicographic (byte-wise) order.
+type Trie[Key constraints.ByteString, Value any] interface {
+	// Len returns the number of keys in the trie.
+	Len() int
+
+	// Get returns the value bound to the specified key.
+	//
+	// It also returns an indicator present to report
+	// whether the key is present.
+	//
+	// Time complexity: O(m log σ), where m = len(key),
+	// and σ is the maximum number of children of a node (at most 256).
+	Get(key Key) (value Value, present bool)
+
+	// Insert binds the value to the specified key.
+	// Any existing value is overwritten.
+	//
+	// It returns the previous value (if any) bound to the key
+	// and an indicator present to report whether the key was present.
+	//
+	// Time complexity: O(m σ) in the worst case, where m = len(key),
+	// and σ is the maximum number of children of a node (at most 256).
+	Insert(key Key, value Value) (previous Value, present bool)
+
+	// Delete removes the specified key and its value from the trie.
+	//
+	// It returns the value (if any) bound to the key
+	// and an indicator present to report whether the key was present.
+	Delete(key Key) (previous Value, present bool)
+
+	// LongestPrefixMatch finds the longest key in the trie
+	// that is a prefix of s (including s itself),
+	// and returns that key and its value.
+	//
+	// It also returns an indicator ok to report
+	// whether such a key exists.
+	//
+	// The returned prefix is a slice of s
+	// (sharing the underlying array if Key is a byte slice).
+	LongestPrefixMatch(s Key) (prefix Key, value Value, ok bool)
+
+	// WalkPrefix returns an iterator over the keys with the specified prefix
+	// (including the prefix itself) and their values,
+	// in lexicographic order of the keys.
+	//
+	// Each yielded key is newly allocated.
+	//
+	// The trie must not be modified during iteration.
+	WalkPrefix(prefix Key) iter.Seq2[Key, Value]
+
+	// All returns an iterator over all keys and their values
+	// in lexicographic order of the keys.
+	//
+	// It is equivalent to WalkPrefix with an empty prefix.
+	All() iter.Seq2[Key, Value]
+
+	// Clear removes all keys in the trie.
+	Clear()
+}
+
+// node is a node of the trie.
+type node[Value any] struct {
+	labels   []byte         // The labels of the edges to the children, in ascending order.
+	children []*node[Value] // The children, corresponding to labels.
+	value    Value
+	hasValue bool // An indicator to report whether a key ends at this node.
+}
+
+// child returns the child with the specified label and its index,
+// or nil and the index to insert the child if there is no such child.
+func (nd *node[Value]) child(label byte) (*node[Value], int) {
+	i, found := slices.BinarySearch(nd.labels, label)
+	if found {
+		return nd.children[i], i
+	}
+	return nil, i
+}
+
+// trie is an implementation of interface Trie.
+type trie[Key constraints.ByteString, Value any] struct {
+	root node[Value]
+	n    int
+}
+
+// New creates a new empty trie.
+func New[Key constraints.ByteString, Value any]() Trie[Key, Value] {
+	return new(trie[Key, Value])
+}
+
+func (t *trie[Key, Value]) Len() int {
+	return t.n
+}
+
+func (t *trie[Key, Value]) Get(key Key) (value Value, present bool) {
+	if nd := t.find(key); nd != nil && nd.hasValue {
+		value, present = nd.value, true
+	}
+	return
+}
+
+func (t *trie[Key, Value]) Insert(key Key, value Value) (
+	previous Value, present bool) {
+	nd := &t.root
+	for i := range len(key) {
+		c, idx := nd.child(key[i])
+		if c == nil {
+			c = new(node[Value])
+			nd.labels = slices.Insert(nd.labels, idx, key[i])
+			nd.children = slices.Insert(nd.children, idx, c)
+		}
+		nd = c
+	}
+	previous, present = nd.value, nd.hasValue
+	nd.value, nd.hasValue = value, true
+	if !present {
+		t.n++
+	}
+	return
+}
+
+func (t *trie[Key, Value]) Delete(key Key) (previous Value, present bool) {
+	path := make([]*node[Value], 0, len(key)+1)
+	nd := &t.root
+	path = append(path, nd)
+	for i := range len(key) {
+		nd, _ = nd.child(key[i])
+		if nd == nil {
+			return
+		}
+		path = append(path, nd)
+	}
+	if !nd.hasValue {
+		return
+	}
+	var zero Value
+	previous, present = nd.value, true
+	nd.value, nd.hasValue = zero, false // avoid memory leak
+	t.n--
+	// Prune the nodes that no longer lead to any key.
+	for i := len(key); i > 0; i-- {
+		nd = path[i]
+		if nd.hasValue || len(nd.children) > 0 {
+			break
+		}
+		parent := path[i-1]
+		_, idx := parent.child(key[i-1])
+		parent.labels = slices.Delete(parent.labels, idx, idx+1)
+		parent.children = slices.Delete(parent.children, idx, idx+1)
+	}
+	return
+}
+
+func (t *trie[Key, Value]) LongestPrefixMatch(s Key) (
+	prefix Key, value Value, ok bool) {
+	nd := &t.root
+	for i := 0; nd != nil; i++ {
+		if nd.hasValue {
+			prefix, value, ok = s[:i], nd.value, true
+		}
+		if i == len(s) {
+			break
+		}
+		nd, _ = nd.child(s[i])
+	}
+	return
+}
+
+func (t *trie[Key, Value]) WalkPrefix(prefix Key) iter.Seq2[Key, Value] {
+	return func(yield func(Key, Value) bool) {
+		nd := t.find(prefix)
+		if nd == nil {
+			return
+		}
+		buf := make([]byte, len(prefix), len(prefix)+16)
+		copy(buf, prefix)
+		walk(nd, buf, yield)
+	}
+}
+
+func (t *trie[Key, Value]) All() iter.Seq2[Key, Value] {
+	return func(yield func(Key, Value) bool) {
+		walk(&t.root, nil, yield)
+	}
+}
+
+func (t *trie[Key, Value]) Clear() {
+	t.root, t.n = node[Value]{}, 0
+}
+
+// find returns the node corresponding to the specified key,
+// or nil if there is no such node.
+func (t *trie[Key, Value]) find(key Key) *node[Value] {
+	nd := &t.root
+	for i := 0; nd != nil && i < len(key); i++ {
+		nd, _ = nd.child(key[i])
+	}
+	return nd
+}
+
+// walk calls yield on the keys and values in the subtree rooted at nd
+// in lexicographic order of the keys,
+// where buf is the key corresponding to nd.
+//
+// It stops and returns false if yield returns false.
+// Otherwise, it returns true.
+func walk[Key constraints.ByteString, Value any](
+	nd *node[Value],
+	buf []byte,
+	yield func(Key, Value) bool,
+) bool {
+	if nd.hasValue && !yield(Key(slices.Clone(buf)), nd.value) {
+		return false
+	}
+	for i, c := range nd.children {
+		if !walk(c, append(buf, nd.labels[i]), yield) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,192 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package trie_test
+
+import (
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/donyori/gogo/container/trie"
+)
+
+func TestTrie_InsertGetDelete(t *testing.T) {
+	tr := trie.New[string, int]()
+	keys := []string{"", "a", "ab", "abc", "abd", "b", "ba"}
+	for i, k := range keys {
+		if _, present := tr.Insert(k, i); present {
+			t.Errorf("Insert(%q) got present true; want false", k)
+		}
+	}
+	if prev, present := tr.Insert("ab", 20); !present || prev != 2 {
+		t.Errorf("Insert(ab) got %d, %t; want 2, true", prev, present)
+	}
+	if n := tr.Len(); n != len(keys) {
+		t.Errorf("got len %d; want %d", n, len(keys))
+	}
+	if v, present := tr.Get("ab"); !present || v != 20 {
+		t.Errorf("Get(ab) got %d, %t; want 20, true", v, present)
+	}
+	if _, present := tr.Get("abx"); present {
+		t.Error("Get(abx) got present true; want false")
+	}
+	if _, present := tr.Get("ac"); present {
+		t.Error("Get(ac) got present true; want false")
+	}
+	if prev, present := tr.Delete("ab"); !present || prev != 20 {
+		t.Errorf("Delete(ab) got %d, %t; want 20, true", prev, present)
+	}
+	if _, present := tr.Delete("ab"); present {
+		t.Error("Delete(ab) again got present true; want false")
+	}
+	if _, present := tr.Delete("abcd"); present {
+		t.Error("Delete(abcd) got present true; want false")
+	}
+	if v, present := tr.Get("abc"); !present || v != 3 {
+		t.Errorf("Get(abc) got %d, %t; want 3, true", v, present)
+	}
+	checkAll(t, tr, map[string]int{"": 0, "a": 1, "abc": 3, "abd": 4, "b": 5, "ba": 6})
+	tr.Clear()
+	checkAll(t, tr, map[string]int{})
+}
+
+func TestTrie_LongestPrefixMatch(t *testing.T) {
+	tr := trie.New[[]byte, string]()
+	for _, k := range []string{"/", "/api", "/api/v1", "/static"} {
+		tr.Insert([]byte(k), k)
+	}
+	testCases := []struct {
+		s      string
+		prefix string
+		ok     bool
+	}{
+		{"/api/v1/users", "/api/v1", true},
+		{"/api/v2", "/api", true},
+		{"/api", "/api", true},
+		{"/index.html", "/", true},
+		{"api", "", false},
+		{"", "", false},
+	}
+	for _, tc := range testCases {
+		prefix, value, ok := tr.LongestPrefixMatch([]byte(tc.s))
+		if ok != tc.ok || string(prefix) != tc.prefix || ok && value != tc.prefix {
+			t.Errorf("LongestPrefixMatch(%q) got %q, %q, %t; want %q, %[3]q, %t",
+				tc.s, prefix, value, ok, tc.prefix, tc.ok)
+		}
+	}
+}
+
+func TestTrie_WalkPrefix(t *testing.T) {
+	tr := trie.New[string, int]()
+	words := []string{"car", "card", "care", "cat", "dog", "ca"}
+	for i, w := range words {
+		tr.Insert(w, i)
+	}
+	testCases := []struct {
+		prefix string
+		want   []string
+	}{
+		{"ca", []string{"ca", "car", "card", "care", "cat"}},
+		{"car", []string{"car", "card", "care"}},
+		{"card", []string{"card"}},
+		{"d", []string{"dog"}},
+		{"x", nil},
+		{"", []string{"ca", "car", "card", "care", "cat", "dog"}},
+	}
+	for _, tc := range testCases {
+		var got []string
+		for k, v := range tr.WalkPrefix(tc.prefix) {
+			got = append(got, k)
+			if words[v] != k {
+				t.Errorf("WalkPrefix(%q), key %q, got value %d", tc.prefix, k, v)
+			}
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("WalkPrefix(%q) got %v; want %v", tc.prefix, got, tc.want)
+		}
+	}
+	var got []string
+	for k := range tr.All() {
+		if got = append(got, k); len(got) == 2 {
+			break
+		}
+	}
+	if want := []string{"ca", "car"}; !slices.Equal(got, want) {
+		t.Errorf("All with break got %v; want %v", got, want)
+	}
+}
+
+func TestTrie_Random(t *testing.T) {
+	random := rand.New(rand.NewChaCha8([32]byte([]byte("ABCDEFGHIJKLMNOPQRSTUVWXYZ123456"))))
+	tr := trie.New[[]byte, int]()
+	want := make(map[string]int)
+	for i := range 2000 {
+		key := make([]byte, random.IntN(5))
+		for j := range key {
+			key[j] = "abc"[random.IntN(3)]
+		}
+		if random.IntN(3) == 0 {
+			prev, present := tr.Delete(key)
+			wantPrev, wantPresent := want[string(key)]
+			if prev != wantPrev || present != wantPresent {
+				t.Fatalf("i:%d, Delete(%q) got %d, %t; want %d, %t",
+					i, key, prev, present, wantPrev, wantPresent)
+			}
+			delete(want, string(key))
+		} else {
+			tr.Insert(key, i)
+			want[string(key)] = i
+		}
+		if n := tr.Len(); n != len(want) {
+			t.Fatalf("i:%d, got len %d; want %d", i, n, len(want))
+		}
+	}
+	got := make(map[string]int, len(want))
+	var keys []string
+	for k, v := range tr.All() {
+		got[string(k)] = v
+		keys = append(keys, string(k))
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+	if !slices.IsSorted(keys) {
+		t.Errorf("keys %v are not sorted", keys)
+	}
+}
+
+func checkAll(t *testing.T, tr trie.Trie[string, int], want map[string]int) {
+	t.Helper()
+	if n := tr.Len(); n != len(want) {
+		t.Errorf("got len %d; want %d", n, len(want))
+	}
+	got := make(map[string]int, len(want))
+	var keys []string
+	for k, v := range tr.All() {
+		got[k] = v
+		keys = append(keys, k)
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+	if wantKeys := slices.Sorted(maps.Keys(want)); !slices.Equal(keys, wantKeys) {
+		t.Errorf("got keys %q; want %q", keys, wantKeys)
+	}
+}
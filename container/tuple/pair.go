@@ -0,0 +1,122 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tuple
+
+import (
+	"fmt"
+
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/function/compare"
+)
+
+// Pair is a 2-tuple.
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+// MakePair returns a Pair of a and b.
+func MakePair[A, B any](a A, b B) Pair[A, B] {
+	return Pair[A, B]{First: a, Second: b}
+}
+
+// Unpack returns the components of the pair.
+func (p Pair[A, B]) Unpack() (A, B) {
+	return p.First, p.Second
+}
+
+// Swap returns a new pair with the components exchanged.
+func (p Pair[A, B]) Swap() Pair[B, A] {
+	return Pair[B, A]{First: p.Second, Second: p.First}
+}
+
+// String formats the pair in the form of
+//
+//	"(" <first> ", " <second> ")"
+//
+// It is equivalent to fmt.Sprintf("(%v, %v)", p.First, p.Second).
+func (p Pair[A, B]) String() string {
+	return fmt.Sprintf("(%v, %v)", p.First, p.Second)
+}
+
+// PairEqual returns an EqualFunc that reports whether two pairs are equal,
+// i.e., their first components are equal according to eqA
+// and their second components are equal according to eqB.
+//
+// PairEqual panics if eqA or eqB is nil.
+func PairEqual[A, B any](
+	eqA compare.EqualFunc[A],
+	eqB compare.EqualFunc[B],
+) compare.EqualFunc[Pair[A, B]] {
+	if eqA == nil {
+		panic(errors.AutoMsg("eqA is nil"))
+	} else if eqB == nil {
+		panic(errors.AutoMsg("eqB is nil"))
+	}
+	return func(x, y Pair[A, B]) bool {
+		return eqA(x.First, y.First) && eqB(x.Second, y.Second)
+	}
+}
+
+// PairCompare returns a CompareFunc that compares two pairs
+// lexicographically:
+// it compares their first components by cmpA, and if they are equal,
+// compares their second components by cmpB.
+//
+// PairCompare panics if cmpA or cmpB is nil.
+func PairCompare[A, B any](
+	cmpA compare.CompareFunc[A],
+	cmpB compare.CompareFunc[B],
+) compare.CompareFunc[Pair[A, B]] {
+	if cmpA == nil {
+		panic(errors.AutoMsg("cmpA is nil"))
+	} else if cmpB == nil {
+		panic(errors.AutoMsg("cmpB is nil"))
+	}
+	return func(x, y Pair[A, B]) int {
+		if c := cmpA(x.First, y.First); c != 0 {
+			return c
+		}
+		return cmpB(x.Second, y.Second)
+	}
+}
+
+// PairLess returns a LessFunc that compares two pairs lexicographically:
+// it compares their first components by lessA, and if neither is less,
+// compares their second components by lessB.
+//
+// PairLess panics if lessA or lessB is nil.
+func PairLess[A, B any](
+	lessA compare.LessFunc[A],
+	lessB compare.LessFunc[B],
+) compare.LessFunc[Pair[A, B]] {
+	if lessA == nil {
+		panic(errors.AutoMsg("lessA is nil"))
+	} else if lessB == nil {
+		panic(errors.AutoMsg("lessB is nil"))
+	}
+	return func(x, y Pair[A, B]) bool {
+		if lessA(x.First, y.First) {
+			return true
+		} else if lessA(y.First, x.First) {
+			return false
+		}
+		return lessB(x.Second, y.Second)
+	}
+}
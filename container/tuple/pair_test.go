@@ -0,0 +1,119 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tuple_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/donyori/gogo/container/tuple"
+	"github.com/donyori/gogo/function/compare"
+)
+
+func TestPair_Unpack(t *testing.T) {
+	p := tuple.MakePair(1, "a")
+	a, b := p.Unpack()
+	if a != 1 || b != "a" {
+		t.Errorf("got (%d, %q); want (1, \"a\")", a, b)
+	}
+}
+
+func TestPair_Swap(t *testing.T) {
+	got := tuple.MakePair(1, "a").Swap()
+	want := tuple.MakePair("a", 1)
+	if got != want {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func TestPair_String(t *testing.T) {
+	p := tuple.MakePair(1, "a")
+	if got := p.String(); got != "(1, a)" {
+		t.Errorf("got %q; want %q", got, "(1, a)")
+	}
+}
+
+func TestPair_MapKey(t *testing.T) {
+	m := map[tuple.Pair[int, string]]int{
+		tuple.MakePair(1, "a"): 1,
+		tuple.MakePair(1, "b"): 2,
+	}
+	if got := m[tuple.MakePair(1, "b")]; got != 2 {
+		t.Errorf("got %d; want 2", got)
+	}
+}
+
+func TestPairEqual(t *testing.T) {
+	eq := tuple.PairEqual(compare.Equal[int], compare.Equal[string])
+	testCases := []struct {
+		x, y tuple.Pair[int, string]
+		want bool
+	}{
+		{tuple.MakePair(1, "a"), tuple.MakePair(1, "a"), true},
+		{tuple.MakePair(1, "a"), tuple.MakePair(2, "a"), false},
+		{tuple.MakePair(1, "a"), tuple.MakePair(1, "b"), false},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("x=%v&y=%v", tc.x, tc.y), func(t *testing.T) {
+			if got := eq(tc.x, tc.y); got != tc.want {
+				t.Errorf("got %t; want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPairCompare(t *testing.T) {
+	cmp := tuple.PairCompare(
+		compare.OrderedCompare[int],
+		compare.CompareFunc[string](compare.OrderedCompare[string]).Reverse(),
+	)
+	less := tuple.PairLess(
+		compare.OrderedLess[int],
+		compare.LessFunc[string](compare.OrderedLess[string]).Reverse(),
+	)
+	testCases := []struct {
+		x, y tuple.Pair[int, string]
+		want int
+	}{
+		{tuple.MakePair(1, "a"), tuple.MakePair(1, "a"), 0},
+		{tuple.MakePair(1, "a"), tuple.MakePair(2, "a"), -1},
+		{tuple.MakePair(2, "a"), tuple.MakePair(1, "z"), 1},
+		{tuple.MakePair(1, "a"), tuple.MakePair(1, "b"), 1},
+		{tuple.MakePair(1, "b"), tuple.MakePair(1, "a"), -1},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("x=%v&y=%v", tc.x, tc.y), func(t *testing.T) {
+			if got := cmp(tc.x, tc.y); got != tc.want {
+				t.Errorf("compare got %d; want %d", got, tc.want)
+			}
+			if got := less(tc.x, tc.y); got != (tc.want < 0) {
+				t.Errorf("less got %t; want %t", got, tc.want < 0)
+			}
+		})
+	}
+}
+
+func TestPairCompare_NilPanic(t *testing.T) {
+	defer func() {
+		if e := recover(); e == nil {
+			t.Error("want panic but not")
+		}
+	}()
+	tuple.PairCompare[int, int](compare.OrderedCompare[int], nil)
+}
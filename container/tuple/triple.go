@@ -0,0 +1,136 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tuple
+
+import (
+	"fmt"
+
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/function/compare"
+)
+
+// Triple is a 3-tuple.
+type Triple[A, B, C any] struct {
+	First  A
+	Second B
+	Third  C
+}
+
+// MakeTriple returns a Triple of a, b, and c.
+func MakeTriple[A, B, C any](a A, b B, c C) Triple[A, B, C] {
+	return Triple[A, B, C]{First: a, Second: b, Third: c}
+}
+
+// Unpack returns the components of the triple.
+func (t Triple[A, B, C]) Unpack() (A, B, C) {
+	return t.First, t.Second, t.Third
+}
+
+// String formats the triple in the form of
+//
+//	"(" <first> ", " <second> ", " <third> ")"
+//
+// It is equivalent to
+// fmt.Sprintf("(%v, %v, %v)", t.First, t.Second, t.Third).
+func (t Triple[A, B, C]) String() string {
+	return fmt.Sprintf("(%v, %v, %v)", t.First, t.Second, t.Third)
+}
+
+// TripleEqual returns an EqualFunc that reports whether two triples
+// are equal, i.e., their components are equal according to
+// eqA, eqB, and eqC, respectively.
+//
+// TripleEqual panics if eqA, eqB, or eqC is nil.
+func TripleEqual[A, B, C any](
+	eqA compare.EqualFunc[A],
+	eqB compare.EqualFunc[B],
+	eqC compare.EqualFunc[C],
+) compare.EqualFunc[Triple[A, B, C]] {
+	if eqA == nil {
+		panic(errors.AutoMsg("eqA is nil"))
+	} else if eqB == nil {
+		panic(errors.AutoMsg("eqB is nil"))
+	} else if eqC == nil {
+		panic(errors.AutoMsg("eqC is nil"))
+	}
+	return func(x, y Triple[A, B, C]) bool {
+		return eqA(x.First, y.First) &&
+			eqB(x.Second, y.Second) &&
+			eqC(x.Third, y.Third)
+	}
+}
+
+// TripleCompare returns a CompareFunc that compares two triples
+// lexicographically, using cmpA, cmpB, and cmpC
+// for the first, second, and third components, respectively.
+//
+// TripleCompare panics if cmpA, cmpB, or cmpC is nil.
+func TripleCompare[A, B, C any](
+	cmpA compare.CompareFunc[A],
+	cmpB compare.CompareFunc[B],
+	cmpC compare.CompareFunc[C],
+) compare.CompareFunc[Triple[A, B, C]] {
+	if cmpA == nil {
+		panic(errors.AutoMsg("cmpA is nil"))
+	} else if cmpB == nil {
+		panic(errors.AutoMsg("cmpB is nil"))
+	} else if cmpC == nil {
+		panic(errors.AutoMsg("cmpC is nil"))
+	}
+	return func(x, y Triple[A, B, C]) int {
+		if c := cmpA(x.First, y.First); c != 0 {
+			return c
+		} else if c = cmpB(x.Second, y.Second); c != 0 {
+			return c
+		}
+		return cmpC(x.Third, y.Third)
+	}
+}
+
+// TripleLess returns a LessFunc that compares two triples
+// lexicographically, using lessA, lessB, and lessC
+// for the first, second, and third components, respectively.
+//
+// TripleLess panics if lessA, lessB, or lessC is nil.
+func TripleLess[A, B, C any](
+	lessA compare.LessFunc[A],
+	lessB compare.LessFunc[B],
+	lessC compare.LessFunc[C],
+) compare.LessFunc[Triple[A, B, C]] {
+	if lessA == nil {
+		panic(errors.AutoMsg("lessA is nil"))
+	} else if lessB == nil {
+		panic(errors.AutoMsg("lessB is nil"))
+	} else if lessC == nil {
+		panic(errors.AutoMsg("lessC is nil"))
+	}
+	return func(x, y Triple[A, B, C]) bool {
+		switch {
+		case lessA(x.First, y.First):
+			return true
+		case lessA(y.First, x.First):
+			return false
+		case lessB(x.Second, y.Second):
+			return true
+		case lessB(y.Second, x.Second):
+			return false
+		}
+		return lessC(x.Third, y.Third)
+	}
+}
@@ -0,0 +1,99 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tuple_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/donyori/gogo/container/tuple"
+	"github.com/donyori/gogo/function/compare"
+)
+
+func TestTriple_Unpack(t *testing.T) {
+	tr := tuple.MakeTriple(1, "a", 2.5)
+	a, b, c := tr.Unpack()
+	if a != 1 || b != "a" || c != 2.5 {
+		t.Errorf("got (%d, %q, %v); want (1, \"a\", 2.5)", a, b, c)
+	}
+}
+
+func TestTriple_String(t *testing.T) {
+	tr := tuple.MakeTriple(1, "a", 2.5)
+	if got := tr.String(); got != "(1, a, 2.5)" {
+		t.Errorf("got %q; want %q", got, "(1, a, 2.5)")
+	}
+}
+
+func TestTripleEqual(t *testing.T) {
+	eq := tuple.TripleEqual(
+		compare.Equal[int],
+		compare.Equal[string],
+		compare.FloatEqual[float64],
+	)
+	testCases := []struct {
+		x, y tuple.Triple[int, string, float64]
+		want bool
+	}{
+		{tuple.MakeTriple(1, "a", 1.0), tuple.MakeTriple(1, "a", 1.0), true},
+		{tuple.MakeTriple(1, "a", 1.0), tuple.MakeTriple(2, "a", 1.0), false},
+		{tuple.MakeTriple(1, "a", 1.0), tuple.MakeTriple(1, "b", 1.0), false},
+		{tuple.MakeTriple(1, "a", 1.0), tuple.MakeTriple(1, "a", 2.0), false},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("x=%v&y=%v", tc.x, tc.y), func(t *testing.T) {
+			if got := eq(tc.x, tc.y); got != tc.want {
+				t.Errorf("got %t; want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTripleCompare(t *testing.T) {
+	cmp := tuple.TripleCompare(
+		compare.OrderedCompare[int],
+		compare.OrderedCompare[string],
+		compare.OrderedCompare[int],
+	)
+	less := tuple.TripleLess(
+		compare.OrderedLess[int],
+		compare.OrderedLess[string],
+		compare.OrderedLess[int],
+	)
+	testCases := []struct {
+		x, y tuple.Triple[int, string, int]
+		want int
+	}{
+		{tuple.MakeTriple(1, "a", 1), tuple.MakeTriple(1, "a", 1), 0},
+		{tuple.MakeTriple(1, "z", 9), tuple.MakeTriple(2, "a", 0), -1},
+		{tuple.MakeTriple(1, "b", 0), tuple.MakeTriple(1, "a", 9), 1},
+		{tuple.MakeTriple(1, "a", 1), tuple.MakeTriple(1, "a", 2), -1},
+		{tuple.MakeTriple(1, "a", 3), tuple.MakeTriple(1, "a", 2), 1},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("x=%v&y=%v", tc.x, tc.y), func(t *testing.T) {
+			if got := cmp(tc.x, tc.y); got != tc.want {
+				t.Errorf("compare got %d; want %d", got, tc.want)
+			}
+			if got := less(tc.x, tc.y); got != (tc.want < 0) {
+				t.Errorf("less got %t; want %t", got, tc.want < 0)
+			}
+		})
+	}
+}
@@ -0,0 +1,345 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// ConvertArchiveOptions are options for function ConvertArchive.
+//
+// A nil *ConvertArchiveOptions is equivalent to
+// a zero-value ConvertArchiveOptions.
+type ConvertArchiveOptions struct {
+	// Filter selects the entries to be converted
+	// by their names in the source archive.
+	// An entry is converted if Filter returns true.
+	//
+	// If Filter is nil, all entries are converted.
+	Filter func(name string) bool
+
+	// True if to store the entries without compression
+	// when converting a tar archive to ZIP.
+	// Otherwise, the entries are compressed with DEFLATE.
+	ZipStore bool
+}
+
+// ConvertArchiveReport is the report of function ConvertArchive.
+type ConvertArchiveReport struct {
+	// NumEntry is the number of entries written to the destination.
+	NumEntry int
+
+	// Skipped maps the names of the source entries to the attributes
+	// that cannot be represented in the destination and are dropped,
+	// such as "uid", "gid", "uname", "gname", "atime", "ctime",
+	// "xattrs" (PAX records), "comment", and "extra".
+	// The entries that cannot be represented at all
+	// (e.g., device files and hard links in a tar archive
+	// converted to ZIP) are mapped to "entry".
+	// The attributes of the source archive itself
+	// (e.g., the ZIP archive comment) are mapped to the empty name "".
+	//
+	// Entries with no attribute dropped are absent.
+	Skipped map[string][]string
+}
+
+// ConvertArchive streams the entries from the archive src
+// to the archive dst with specified options opts,
+// preserving the metadata (names, modes, modification times,
+// and symbolic links) where representable, and returns a report
+// of the converted entries and the dropped attributes.
+//
+// src and dst can be a tar archive (including compressed ones like ".tgz")
+// or a ZIP archive, in any combination.
+// When converting a tar archive, the remaining entries of src
+// (i.e., the entries not yet read by the method TarNext) are converted.
+// When converting a ZIP archive to ZIP,
+// the entries are copied in raw form by the method ZipCopy of dst.
+//
+// ConvertArchive does not close src or dst.
+// The client should close dst to complete the destination archive.
+//
+// ConvertArchive reports ErrNotTar if src (or dst)
+// is neither a tar archive nor a ZIP archive.
+// (To test whether err is ErrNotTar, use function errors.Is.)
+//
+// ConvertArchive panics if src or dst is nil.
+func ConvertArchive(src Reader, dst Writer, opts *ConvertArchiveOptions) (
+	report *ConvertArchiveReport, err error) {
+	if src == nil {
+		panic(errors.AutoMsg("src is nil"))
+	} else if dst == nil {
+		panic(errors.AutoMsg("dst is nil"))
+	}
+	var o ConvertArchiveOptions
+	if opts != nil {
+		o = *opts
+	}
+	switch {
+	case !src.TarEnabled() && !src.ZipEnabled():
+		return nil, errors.AutoWrap(
+			fmt.Errorf("src is not a tar or ZIP archive: %w", ErrNotTar))
+	case !dst.TarEnabled() && !dst.ZipEnabled():
+		return nil, errors.AutoWrap(
+			fmt.Errorf("dst is not a tar or ZIP archive: %w", ErrNotTar))
+	}
+	ca := &archiveConverter{
+		src:    src,
+		dst:    dst,
+		opts:   &o,
+		report: &ConvertArchiveReport{Skipped: make(map[string][]string)},
+	}
+	if src.TarEnabled() {
+		err = ca.fromTar()
+	} else {
+		err = ca.fromZip()
+	}
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	return ca.report, nil
+}
+
+// archiveConverter is the state of function ConvertArchive.
+type archiveConverter struct {
+	src    Reader
+	dst    Writer
+	opts   *ConvertArchiveOptions
+	report *ConvertArchiveReport
+}
+
+// skip records that the attributes attrs of the entry name are dropped.
+func (ca *archiveConverter) skip(name string, attrs ...string) {
+	if len(attrs) > 0 {
+		ca.report.Skipped[name] = append(ca.report.Skipped[name], attrs...)
+	}
+}
+
+// fromTar converts the remaining entries of the tar archive ca.src.
+func (ca *archiveConverter) fromTar() error {
+	for {
+		hdr, err := ca.src.TarNext()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		} else if ca.opts.Filter != nil && !ca.opts.Filter(hdr.Name) {
+			continue
+		}
+		if !ca.dst.TarEnabled() {
+			err = ca.tarToZip(hdr)
+		} else if err = ca.dst.TarCopy(hdr, ca.src); err == nil {
+			ca.report.NumEntry++
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// tarToZip writes the tar entry hdr (and its content read from ca.src)
+// to the ZIP archive ca.dst.
+func (ca *archiveConverter) tarToZip(hdr *tar.Header) error {
+	var content io.Reader
+	name := hdr.Name
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		if !strings.HasSuffix(name, "/") {
+			name += "/"
+		}
+	case tar.TypeReg, tar.TypeGNUSparse:
+		content = ca.src
+	case tar.TypeSymlink:
+		content = strings.NewReader(hdr.Linkname)
+	default:
+		ca.skip(hdr.Name, "entry")
+		return nil
+	}
+	var attrs []string
+	if hdr.Uid != 0 {
+		attrs = append(attrs, "uid")
+	}
+	if hdr.Gid != 0 {
+		attrs = append(attrs, "gid")
+	}
+	if hdr.Uname != "" {
+		attrs = append(attrs, "uname")
+	}
+	if hdr.Gname != "" {
+		attrs = append(attrs, "gname")
+	}
+	if !hdr.AccessTime.IsZero() {
+		attrs = append(attrs, "atime")
+	}
+	if !hdr.ChangeTime.IsZero() {
+		attrs = append(attrs, "ctime")
+	}
+	if len(hdr.PAXRecords) > 0 {
+		for key := range hdr.PAXRecords {
+			// The names and times are already handled above.
+			if strings.HasPrefix(key, "SCHILY.xattr.") ||
+				strings.HasPrefix(key, "LIBARCHIVE.xattr.") {
+				attrs = append(attrs, "xattrs")
+				break
+			}
+		}
+	}
+	ca.skip(hdr.Name, attrs...)
+
+	fh := &zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: hdr.ModTime,
+	}
+	if ca.opts.ZipStore || content == nil {
+		fh.Method = zip.Store
+	}
+	fh.SetMode(hdr.FileInfo().Mode())
+	err := ca.dst.ZipCreateHeader(fh)
+	if err == nil && content != nil {
+		_, err = ca.dst.ReadFrom(content)
+	}
+	if err != nil {
+		return err
+	}
+	ca.report.NumEntry++
+	return nil
+}
+
+// fromZip converts the entries of the ZIP archive ca.src.
+func (ca *archiveConverter) fromZip() error {
+	comment, err := ca.src.ZipComment()
+	if err != nil {
+		return err
+	} else if comment != "" && ca.dst.TarEnabled() {
+		ca.skip("", "comment")
+	}
+	files, err := ca.src.ZipFiles()
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if ca.opts.Filter != nil && !ca.opts.Filter(f.Name) {
+			continue
+		}
+		if ca.dst.ZipEnabled() {
+			err = ca.dst.ZipCopy(f)
+		} else {
+			err = ca.zipToTar(f)
+		}
+		if err != nil {
+			return err
+		}
+		ca.report.NumEntry++
+	}
+	return nil
+}
+
+// zipToTar writes the ZIP entry f to the tar archive ca.dst.
+func (ca *archiveConverter) zipToTar(f *zip.File) (err error) {
+	var attrs []string
+	if f.Comment != "" {
+		attrs = append(attrs, "comment")
+	}
+	if hasUnrepresentedZipExtra(f.Extra) {
+		attrs = append(attrs, "extra")
+	}
+	ca.skip(f.Name, attrs...)
+
+	mode := f.Mode()
+	hdr := &tar.Header{
+		Name:    f.Name,
+		Mode:    int64(mode.Perm()),
+		ModTime: f.Modified,
+		Format:  tar.FormatPAX,
+	}
+	if hdr.ModTime.IsZero() {
+		hdr.ModTime = f.ModTime()
+	}
+	switch {
+	case mode.IsDir():
+		hdr.Typeflag = tar.TypeDir
+		if !strings.HasSuffix(hdr.Name, "/") {
+			hdr.Name += "/"
+		}
+		return ca.dst.TarWriteHeader(hdr)
+	case mode&fs.ModeSymlink != 0:
+		hdr.Typeflag = tar.TypeSymlink
+		var target []byte
+		target, err = ca.readZipFile(f)
+		if err != nil {
+			return err
+		}
+		hdr.Linkname = string(target)
+		return ca.dst.TarWriteHeader(hdr)
+	}
+	hdr.Typeflag = tar.TypeReg
+	hdr.Size = int64(f.UncompressedSize64)
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer func(rc io.ReadCloser) {
+		_ = rc.Close() // ignore error
+	}(rc)
+	return ca.dst.TarCopy(hdr, rc)
+}
+
+// hasUnrepresentedZipExtra reports whether the extra field extra
+// of a ZIP entry contains any block other than those
+// whose information is kept in the tar header
+// (i.e., ZIP64 and the timestamps).
+func hasUnrepresentedZipExtra(extra []byte) bool {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		switch id {
+		case 0x0001, // ZIP64 extended information
+			0x000a, // NTFS extra field (timestamps)
+			0x5455: // extended timestamp
+		default:
+			return true
+		}
+		if 4+size > len(extra) {
+			return true
+		}
+		extra = extra[4+size:]
+	}
+	return len(extra) > 0
+}
+
+// readZipFile reads the entire content of the ZIP entry f.
+func (ca *archiveConverter) readZipFile(f *zip.File) (data []byte, err error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func(rc io.ReadCloser) {
+		_ = rc.Close() // ignore error
+	}(rc)
+	return io.ReadAll(rc)
+}
@@ -0,0 +1,292 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"slices"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/donyori/gogo/filesys"
+)
+
+var testConvertModTime = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// writeTestConvertTar writes a tar archive for testing ConvertArchive
+// to a file with the specified name, and returns the file.
+func writeTestConvertTar(t *testing.T, name string) *WritableFileImpl {
+	t.Helper()
+	file := &WritableFileImpl{Name: name}
+	w, err := filesys.Write(file, nil, true)
+	if err != nil {
+		t.Fatal("create tar -", err)
+	}
+	hdrs := []*tar.Header{
+		{Typeflag: tar.TypeDir, Name: "dir/", Mode: 0o755},
+		{Typeflag: tar.TypeReg, Name: "dir/a.txt", Mode: 0o644, Size: 5,
+			Uid: 1000, Uname: "user"},
+		{Typeflag: tar.TypeSymlink, Name: "link", Linkname: "dir/a.txt",
+			Mode: 0o777},
+		{Typeflag: tar.TypeFifo, Name: "fifo", Mode: 0o644},
+		{Typeflag: tar.TypeReg, Name: "b.txt", Mode: 0o600, Size: 0},
+	}
+	for _, hdr := range hdrs {
+		hdr.ModTime = testConvertModTime
+		err = w.TarWriteHeader(hdr)
+		if err == nil && hdr.Size > 0 {
+			_, err = w.WriteString("hello")
+		}
+		if err != nil {
+			_ = w.Close()
+			t.Fatal("write tar -", err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal("close tar -", err)
+	}
+	return file
+}
+
+func TestConvertArchive_TarToZip(t *testing.T) {
+	for _, name := range []string{"test.tar", "test.tgz"} {
+		t.Run("src="+name, func(t *testing.T) {
+			tarFile := writeTestConvertTar(t, name)
+			src, err := filesys.ReadFromFS(
+				fstest.MapFS{name: {Data: tarFile.Data}}, name, nil)
+			if err != nil {
+				t.Fatal("open src -", err)
+			}
+			defer func() {
+				if err := src.Close(); err != nil {
+					t.Error("close src -", err)
+				}
+			}()
+			zipFile := &WritableFileImpl{Name: "test.zip"}
+			dst, err := filesys.Write(zipFile, nil, true)
+			if err != nil {
+				t.Fatal("create dst -", err)
+			}
+			report, err := filesys.ConvertArchive(src, dst, nil)
+			if err != nil {
+				_ = dst.Close()
+				t.Fatal("convert -", err)
+			}
+			if err = dst.Close(); err != nil {
+				t.Fatal("close dst -", err)
+			}
+			if report.NumEntry != 4 {
+				t.Errorf("got NumEntry %d; want 4", report.NumEntry)
+			}
+			wantSkipped := map[string][]string{
+				"dir/a.txt": {"uid", "uname"},
+				"fifo":      {"entry"},
+			}
+			for k, v := range wantSkipped {
+				if !slices.Equal(report.Skipped[k], v) {
+					t.Errorf("got Skipped[%q] %v; want %v",
+						k, report.Skipped[k], v)
+				}
+			}
+			if len(report.Skipped) != len(wantSkipped) {
+				t.Errorf("got Skipped %v; want %v",
+					report.Skipped, wantSkipped)
+			}
+
+			zr, err := zip.NewReader(
+				bytes.NewReader(zipFile.Data), int64(len(zipFile.Data)))
+			if err != nil {
+				t.Fatal("read zip -", err)
+			}
+			want := map[string]struct {
+				mode fs.FileMode
+				data string
+			}{
+				"dir/":      {fs.ModeDir | 0o755, ""},
+				"dir/a.txt": {0o644, "hello"},
+				"link":      {fs.ModeSymlink | 0o777, "dir/a.txt"},
+				"b.txt":     {0o600, ""},
+			}
+			if len(zr.File) != len(want) {
+				t.Errorf("got %d files; want %d", len(zr.File), len(want))
+			}
+			for _, f := range zr.File {
+				w, ok := want[f.Name]
+				if !ok {
+					t.Errorf("unexpected file %q", f.Name)
+					continue
+				} else if f.Mode() != w.mode {
+					t.Errorf("%s - got mode %v; want %v", f.Name, f.Mode(), w.mode)
+				} else if !f.Modified.Equal(testConvertModTime) {
+					t.Errorf("%s - got mod time %v; want %v",
+						f.Name, f.Modified, testConvertModTime)
+				}
+				rc, err := f.Open()
+				if err != nil {
+					t.Errorf("%s - open - %v", f.Name, err)
+					continue
+				}
+				data, err := io.ReadAll(rc)
+				_ = rc.Close()
+				if err != nil {
+					t.Errorf("%s - read - %v", f.Name, err)
+				} else if string(data) != w.data {
+					t.Errorf("%s - got %q; want %q", f.Name, data, w.data)
+				}
+			}
+		})
+	}
+}
+
+func TestConvertArchive_ZipToTar(t *testing.T) {
+	zipFile := &WritableFileImpl{Name: "test.zip"}
+	zw := zip.NewWriter(zipFile)
+	if err := zw.SetComment("archive comment"); err != nil {
+		t.Fatal(err)
+	}
+	entries := []struct {
+		name    string
+		mode    fs.FileMode
+		data    string
+		comment string
+	}{
+		{"dir/", fs.ModeDir | 0o755, "", ""},
+		{"dir/a.txt", 0o644, "hello", "file comment"},
+		{"link", fs.ModeSymlink | 0o777, "dir/a.txt", ""},
+		{"skip.txt", 0o644, "skipped", ""},
+	}
+	for _, e := range entries {
+		fh := &zip.FileHeader{
+			Name:     e.name,
+			Method:   zip.Deflate,
+			Modified: testConvertModTime,
+			Comment:  e.comment,
+		}
+		fh.SetMode(e.mode)
+		w, err := zw.CreateHeader(fh)
+		if err == nil {
+			_, err = io.WriteString(w, e.data)
+		}
+		if err != nil {
+			t.Fatal("write zip -", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal("close zip -", err)
+	}
+
+	src, err := filesys.ReadFromFS(
+		fstest.MapFS{"test.zip": {Data: zipFile.Data}}, "test.zip", nil)
+	if err != nil {
+		t.Fatal("open src -", err)
+	}
+	defer func() {
+		if err := src.Close(); err != nil {
+			t.Error("close src -", err)
+		}
+	}()
+	tarFile := &WritableFileImpl{Name: "test.tar.gz"}
+	dst, err := filesys.Write(tarFile, nil, true)
+	if err != nil {
+		t.Fatal("create dst -", err)
+	}
+	report, err := filesys.ConvertArchive(src, dst, &filesys.ConvertArchiveOptions{
+		Filter: func(name string) bool { return name != "skip.txt" },
+	})
+	if err != nil {
+		_ = dst.Close()
+		t.Fatal("convert -", err)
+	}
+	if err = dst.Close(); err != nil {
+		t.Fatal("close dst -", err)
+	}
+	if report.NumEntry != 3 {
+		t.Errorf("got NumEntry %d; want 3", report.NumEntry)
+	}
+	if !slices.Equal(report.Skipped[""], []string{"comment"}) ||
+		!slices.Equal(report.Skipped["dir/a.txt"], []string{"comment"}) {
+		t.Errorf("got Skipped %v", report.Skipped)
+	}
+
+	r, err := filesys.ReadFromFS(
+		fstest.MapFS{"test.tar.gz": {Data: tarFile.Data}}, "test.tar.gz", nil)
+	if err != nil {
+		t.Fatal("open result -", err)
+	}
+	defer func() {
+		if err := r.Close(); err != nil {
+			t.Error("close result -", err)
+		}
+	}()
+	for _, e := range entries[:3] {
+		hdr, err := r.TarNext()
+		if err != nil {
+			t.Fatal("tar next -", err)
+		} else if hdr.Name != e.name || hdr.FileInfo().Mode() != e.mode ||
+			!hdr.ModTime.Equal(testConvertModTime) {
+			t.Errorf("got %q (mode %v, mod time %v); want %q (mode %v, mod time %v)",
+				hdr.Name, hdr.FileInfo().Mode(), hdr.ModTime,
+				e.name, e.mode, testConvertModTime)
+		}
+		var data string
+		if hdr.Typeflag == tar.TypeSymlink {
+			data = hdr.Linkname
+		} else if hdr.Typeflag == tar.TypeReg {
+			b, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal("read -", err)
+			}
+			data = string(b)
+		}
+		if data != e.data {
+			t.Errorf("%s - got %q; want %q", e.name, data, e.data)
+		}
+	}
+	if _, err = r.TarNext(); !errors.Is(err, io.EOF) {
+		t.Errorf("got %v; want io.EOF", err)
+	}
+}
+
+func TestConvertArchive_NotArchive(t *testing.T) {
+	src, err := filesys.ReadFromFS(
+		fstest.MapFS{"a.txt": {Data: []byte("a")}}, "a.txt", nil)
+	if err != nil {
+		t.Fatal("open src -", err)
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+	dst, err := filesys.Write(&WritableFileImpl{Name: "a.zip"}, nil, true)
+	if err != nil {
+		t.Fatal("create dst -", err)
+	}
+	defer func() {
+		_ = dst.Close()
+	}()
+	_, err = filesys.ConvertArchive(src, dst, nil)
+	if !errors.Is(err, filesys.ErrNotTar) {
+		t.Errorf("got %v; want %v", err, filesys.ErrNotTar)
+	}
+}
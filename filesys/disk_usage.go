@@ -0,0 +1,318 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys
+
+import (
+	"io/fs"
+	"path"
+
+	"github.com/donyori/gogo/concurrency"
+	"github.com/donyori/gogo/concurrency/framework/jobsched"
+	"github.com/donyori/gogo/errors"
+)
+
+// DiskUsageOptions are options for function DiskUsage.
+//
+// A nil *DiskUsageOptions is equivalent to a zero-value DiskUsageOptions.
+type DiskUsageOptions struct {
+	// NumWorker is the number of goroutines to scan the directories.
+	//
+	// If NumWorker is 0 or 1, the directories are scanned sequentially
+	// in the calling goroutine.
+	// If NumWorker is greater than 1, the directories are scanned
+	// concurrently by NumWorker goroutines with the framework
+	// github.com/donyori/gogo/concurrency/framework/jobsched.
+	// If NumWorker is negative, the directories are scanned concurrently
+	// with the default number of goroutines of the framework.
+	NumWorker int
+
+	// FollowSymlinks indicates whether to follow symbolic links.
+	//
+	// If FollowSymlinks is false, a symbolic link is counted as a file
+	// with the size of the link itself (as reported by the directory entry).
+	// Otherwise, the file or directory the link points to is counted instead.
+	//
+	// To avoid infinite loops, symbolic links to directories
+	// are followed only if SameFile is not nil,
+	// and a directory is not walked into if it is the same as
+	// any of its ancestors (according to SameFile).
+	FollowSymlinks bool
+
+	// SameFile reports whether two files are the same file,
+	// like function os.SameFile.
+	//
+	// If SameFile is not nil, the same file (e.g., hard links to a file)
+	// is counted only once, at the first path found.
+	// Only the files of the same size are compared.
+	SameFile func(fi1, fi2 fs.FileInfo) bool
+}
+
+// DirUsage is the usage of a directory.
+type DirUsage struct {
+	Size    int64 // Total size of the files in the directory and its subdirectories, in bytes.
+	NumFile int   // Number of files (non-directories) in the directory and its subdirectories.
+	NumDir  int   // Number of subdirectories in the directory, recursively.
+}
+
+// DiskUsageResult is the result of function DiskUsage.
+type DiskUsageResult struct {
+	// Total is the usage of the root directory,
+	// i.e., the total size and numbers of files and subdirectories.
+	Total DirUsage
+
+	// Dirs is the usage of each directory (including root itself),
+	// keyed by the path of the directory, in the same form as those passed to
+	// the callback function of io/fs.WalkDir.
+	Dirs map[string]DirUsage
+}
+
+// DiskUsage calculates the disk usage of the directory root in fsys,
+// including the total size, the number of files and subdirectories,
+// and the usage of each directory, with specified options opts.
+//
+// The size of a file is the size reported by its io/fs.FileInfo;
+// the size of directories themselves is not counted.
+//
+// If root is not a directory, the result only contains
+// the information of root itself (with Dirs empty).
+//
+// DiskUsage stops at the first error encountered
+// (e.g., an error reading a directory) and reports it.
+//
+// DiskUsage panics if fsys is nil.
+func DiskUsage(fsys fs.FS, root string, opts *DiskUsageOptions) (
+	result *DiskUsageResult, err error) {
+	if fsys == nil {
+		panic(errors.AutoMsg("fsys is nil"))
+	}
+	du := &diskUsage{fsys: fsys, own: make(map[string]*DirUsage)}
+	if opts != nil {
+		du.opts = *opts
+	}
+	if du.opts.SameFile != nil {
+		du.seen = make(map[int64][]fs.FileInfo)
+	}
+	info, err := fs.Stat(fsys, root)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	result = &DiskUsageResult{Dirs: make(map[string]DirUsage)}
+	if !info.IsDir() {
+		result.Total.Size, result.Total.NumFile = info.Size(), 1
+		return result, nil
+	}
+	rootJob := &diskUsageJob{dir: root, info: info}
+	if du.opts.NumWorker == 0 || du.opts.NumWorker == 1 {
+		err = du.runSequential(rootJob)
+	} else {
+		err = du.runConcurrent(rootJob)
+	}
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	for dir, u := range du.own {
+		for p := dir; ; p = path.Dir(p) {
+			acc := result.Dirs[p]
+			acc.Size += u.Size
+			acc.NumFile += u.NumFile
+			acc.NumDir += u.NumDir
+			result.Dirs[p] = acc
+			if p == root || p == "." {
+				break
+			}
+		}
+	}
+	result.Total = result.Dirs[root]
+	return result, nil
+}
+
+// diskUsage is the state of function DiskUsage.
+type diskUsage struct {
+	fsys fs.FS
+	opts DiskUsageOptions
+	own  map[string]*DirUsage    // usage of files and subdirectories directly in each directory
+	seen map[int64][]fs.FileInfo // counted files grouped by size, used if opts.SameFile is not nil
+}
+
+// diskUsageJob is a directory to be scanned.
+type diskUsageJob struct {
+	dir    string
+	info   fs.FileInfo
+	parent *diskUsageJob // nil for root
+}
+
+// diskUsageFeedback is the result of scanning a directory.
+type diskUsageFeedback struct {
+	dir   string
+	files []fs.FileInfo
+	dirs  []*diskUsageJob
+	err   error
+}
+
+// runSequential scans the directories in the calling goroutine.
+func (du *diskUsage) runSequential(rootJob *diskUsageJob) error {
+	stack := []*diskUsageJob{rootJob}
+	for len(stack) > 0 {
+		job := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		fb := du.scan(job)
+		if fb.err != nil {
+			return fb.err
+		}
+		du.add(fb)
+		stack = append(stack, fb.dirs...)
+	}
+	return nil
+}
+
+// runConcurrent scans the directories concurrently
+// with the framework jobsched.
+func (du *diskUsage) runConcurrent(rootJob *diskUsageJob) error {
+	var err error
+	ctrl := jobsched.New(
+		func(canceler concurrency.Canceler, _ int, job *diskUsageJob) (
+			newJobs []*jobsched.MetaJob[*diskUsageJob, jobsched.NoProperty],
+			feedback *diskUsageFeedback,
+		) {
+			if canceler.Canceled() {
+				return
+			}
+			feedback = du.scan(job)
+			if feedback.err == nil && len(feedback.dirs) > 0 {
+				newJobs = make(
+					[]*jobsched.MetaJob[*diskUsageJob, jobsched.NoProperty],
+					len(feedback.dirs),
+				)
+				for i := range feedback.dirs {
+					newJobs[i] = &jobsched.MetaJob[
+						*diskUsageJob, jobsched.NoProperty]{
+						Job: feedback.dirs[i],
+					}
+				}
+			}
+			return
+		},
+		func(canceler concurrency.Canceler, feedback *diskUsageFeedback) {
+			if feedback == nil || err != nil {
+				return
+			} else if feedback.err != nil {
+				err = feedback.err
+				canceler.Cancel()
+				return
+			}
+			du.add(feedback)
+		},
+		&jobsched.Options[*diskUsageJob, jobsched.NoProperty, *diskUsageFeedback]{
+			NumWorker: max(du.opts.NumWorker, 0),
+		},
+		&jobsched.MetaJob[*diskUsageJob, jobsched.NoProperty]{Job: rootJob},
+	)
+	ctrl.Run()
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		el := errors.NewErrorList(true)
+		for _, pr := range prs {
+			el.Append(pr)
+		}
+		return el.ToError()
+	}
+	return err
+}
+
+// scan reads the directory of job and collects the information of
+// the files and subdirectories in it.
+//
+// It is safe for concurrent use by multiple goroutines.
+func (du *diskUsage) scan(job *diskUsageJob) *diskUsageFeedback {
+	fb := &diskUsageFeedback{dir: job.dir}
+	entries, err := fs.ReadDir(du.fsys, job.dir)
+	if err != nil {
+		fb.err = err
+		return fb
+	}
+	for _, entry := range entries {
+		name := path.Join(job.dir, entry.Name())
+		var info fs.FileInfo
+		if du.opts.FollowSymlinks && entry.Type()&fs.ModeSymlink != 0 {
+			info, err = fs.Stat(du.fsys, name)
+			if err == nil && info.IsDir() &&
+				(du.opts.SameFile == nil || du.isAncestor(job, info)) {
+				// Treat the link to a directory as a file
+				// if it cannot be followed safely.
+				info, err = entry.Info()
+			}
+		} else {
+			info, err = entry.Info()
+		}
+		if err != nil {
+			fb.err = err
+			return fb
+		}
+		if info.IsDir() {
+			fb.dirs = append(fb.dirs, &diskUsageJob{
+				dir:    name,
+				info:   info,
+				parent: job,
+			})
+		} else {
+			fb.files = append(fb.files, info)
+		}
+	}
+	return fb
+}
+
+// isAncestor reports whether info describes the same directory as
+// job or any of its ancestors, according to du.opts.SameFile.
+func (du *diskUsage) isAncestor(job *diskUsageJob, info fs.FileInfo) bool {
+	for ; job != nil; job = job.parent {
+		if du.opts.SameFile(job.info, info) {
+			return true
+		}
+	}
+	return false
+}
+
+// add adds the information in fb to du.own.
+//
+// It is not safe for concurrent use.
+func (du *diskUsage) add(fb *diskUsageFeedback) {
+	u := du.own[fb.dir]
+	if u == nil {
+		u = new(DirUsage)
+		du.own[fb.dir] = u
+	}
+	u.NumDir += len(fb.dirs)
+	for _, info := range fb.files {
+		if du.seen != nil {
+			size := info.Size()
+			var dup bool
+			for _, fi := range du.seen[size] {
+				if du.opts.SameFile(fi, info) {
+					dup = true
+					break
+				}
+			}
+			if dup {
+				continue
+			}
+			du.seen[size] = append(du.seen[size], info)
+		}
+		u.Size += info.Size()
+		u.NumFile++
+	}
+}
@@ -0,0 +1,88 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys_test
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"testing"
+	"testing/fstest"
+
+	"github.com/donyori/gogo/filesys"
+)
+
+func TestDiskUsage(t *testing.T) {
+	mapFS := fstest.MapFS{
+		"root/a.txt":          {Data: make([]byte, 10)},
+		"root/dir1/b.txt":     {Data: make([]byte, 20)},
+		"root/dir1/c.txt":     {Data: make([]byte, 30)},
+		"root/dir1/sub/d.txt": {Data: make([]byte, 40)},
+		"root/dir2/e.txt":     {Data: make([]byte, 50)},
+		"root/empty":          {Mode: fs.ModeDir | 0o755},
+		"other/f.txt":         {Data: make([]byte, 1000)},
+	}
+	want := map[string]filesys.DirUsage{
+		"root":          {Size: 150, NumFile: 5, NumDir: 4},
+		"root/dir1":     {Size: 90, NumFile: 3, NumDir: 1},
+		"root/dir1/sub": {Size: 40, NumFile: 1},
+		"root/dir2":     {Size: 50, NumFile: 1},
+		"root/empty":    {},
+	}
+	for _, numWorker := range []int{0, 1, 4, -1} {
+		t.Run(fmt.Sprintf("numWorker=%d", numWorker), func(t *testing.T) {
+			result, err := filesys.DiskUsage(
+				mapFS,
+				"root",
+				&filesys.DiskUsageOptions{NumWorker: numWorker},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Total != want["root"] {
+				t.Errorf("got total %+v; want %+v", result.Total, want["root"])
+			}
+			if !maps.Equal(result.Dirs, want) {
+				t.Errorf("got dirs %+v; want %+v", result.Dirs, want)
+			}
+		})
+	}
+}
+
+func TestDiskUsage_File(t *testing.T) {
+	mapFS := fstest.MapFS{"a.txt": {Data: make([]byte, 10)}}
+	result, err := filesys.DiskUsage(mapFS, "a.txt", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := filesys.DirUsage{Size: 10, NumFile: 1}
+	if result.Total != want {
+		t.Errorf("got %+v; want %+v", result.Total, want)
+	}
+	if len(result.Dirs) != 0 {
+		t.Errorf("got dirs %v; want empty", result.Dirs)
+	}
+}
+
+func TestDiskUsage_NotExist(t *testing.T) {
+	_, err := filesys.DiskUsage(fstest.MapFS{}, "nonexistent", nil)
+	if err == nil {
+		t.Error("got nil error")
+	}
+}
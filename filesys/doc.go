@@ -24,4 +24,6 @@
 //
 // For better performance, all functions in this package and its subpackages
 // are unsafe for concurrency unless otherwise specified.
+// To share a Reader or Writer among goroutines,
+// wrap it with SyncReader or SyncWriter.
 package filesys
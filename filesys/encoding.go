@@ -0,0 +1,218 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// utf8BOM is the byte order mark (U+FEFF) encoded in UTF-8.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// DecodeUTF16LE returns a reader that decodes the UTF-16 little-endian
+// text read from r into UTF-8.
+//
+// The byte order mark (if any) is decoded as U+FEFF, not removed.
+// Invalid sequences (e.g., unpaired surrogates) are decoded as U+FFFD.
+//
+// It can be used as the option Encoding of ReadOptions.
+func DecodeUTF16LE(r io.Reader) io.Reader {
+	return newDecodeReader(r, utf16Decode(binary.LittleEndian))
+}
+
+// DecodeUTF16BE returns a reader that decodes the UTF-16 big-endian
+// text read from r into UTF-8.
+//
+// The byte order mark (if any) is decoded as U+FEFF, not removed.
+// Invalid sequences (e.g., unpaired surrogates) are decoded as U+FFFD.
+//
+// It can be used as the option Encoding of ReadOptions.
+func DecodeUTF16BE(r io.Reader) io.Reader {
+	return newDecodeReader(r, utf16Decode(binary.BigEndian))
+}
+
+// DecodeUTF16 returns a reader that decodes the UTF-16 text read from r
+// into UTF-8, with the byte order determined by the byte order mark.
+//
+// If the text starts with a byte order mark, the mark is removed.
+// Otherwise, the text is decoded as big-endian, as specified by RFC 2781.
+// Invalid sequences (e.g., unpaired surrogates) are decoded as U+FFFD.
+//
+// It can be used as the option Encoding of ReadOptions.
+func DecodeUTF16(r io.Reader) io.Reader {
+	var decode func(in []byte, atEOF bool) (out []byte, consumed int)
+	return newDecodeReader(r, func(in []byte, atEOF bool) ([]byte, int) {
+		if decode != nil {
+			return decode(in, atEOF)
+		} else if len(in) < 2 && !atEOF {
+			return nil, 0
+		}
+		var skip int
+		switch {
+		case len(in) >= 2 && in[0] == 0xFF && in[1] == 0xFE:
+			decode, skip = utf16Decode(binary.LittleEndian), 2
+		case len(in) >= 2 && in[0] == 0xFE && in[1] == 0xFF:
+			decode, skip = utf16Decode(binary.BigEndian), 2
+		default:
+			decode = utf16Decode(binary.BigEndian)
+		}
+		out, consumed := decode(in[skip:], atEOF)
+		return out, consumed + skip
+	})
+}
+
+// DecodeLatin1 returns a reader that decodes the ISO 8859-1 (Latin-1)
+// text read from r into UTF-8.
+//
+// It can be used as the option Encoding of ReadOptions.
+func DecodeLatin1(r io.Reader) io.Reader {
+	return newDecodeReader(r, func(in []byte, _ bool) ([]byte, int) {
+		out := make([]byte, 0, len(in)*2)
+		for _, b := range in {
+			out = utf8.AppendRune(out, rune(b))
+		}
+		return out, len(in)
+	})
+}
+
+// utf16Decode returns a decoding function for UTF-16
+// with the specified byte order, used by decodeReader.
+func utf16Decode(order binary.ByteOrder) func(
+	in []byte, atEOF bool) (out []byte, consumed int) {
+	return func(in []byte, atEOF bool) (out []byte, consumed int) {
+		out = make([]byte, 0, len(in)/2*3+utf8.UTFMax)
+		for consumed+1 < len(in) {
+			u := rune(order.Uint16(in[consumed:]))
+			if !utf16.IsSurrogate(u) {
+				out = utf8.AppendRune(out, u)
+				consumed += 2
+				continue
+			} else if consumed+3 >= len(in) && !atEOF {
+				break // wait for the next code unit
+			}
+			r := utf8.RuneError
+			if consumed+3 < len(in) {
+				r = utf16.DecodeRune(u, rune(order.Uint16(in[consumed+2:])))
+			}
+			out = utf8.AppendRune(out, r)
+			if r != utf8.RuneError {
+				consumed += 4
+			} else {
+				consumed += 2
+			}
+		}
+		if atEOF && consumed < len(in) {
+			// An odd trailing byte.
+			out = utf8.AppendRune(out, utf8.RuneError)
+			consumed = len(in)
+		}
+		return
+	}
+}
+
+// decodeReaderBufSize is the size of the buffer of decodeReader
+// for the undecoded data.
+const decodeReaderBufSize = 4096
+
+// decodeReader is a reader that decodes the data read from
+// the underlying reader through a decoding function.
+type decodeReader struct {
+	r      io.Reader
+	decode func(in []byte, atEOF bool) (out []byte, consumed int)
+	in     []byte // undecoded data
+	out    []byte // decoded data not yet read
+	err    error  // error from r
+}
+
+// newDecodeReader creates a decodeReader on r with
+// the specified decoding function decode.
+//
+// decode decodes the data in "in" and returns the decoded data
+// and the number of bytes consumed.
+// atEOF indicates whether there is no more data after "in".
+// If atEOF is true, decode must consume all data in "in".
+func newDecodeReader(
+	r io.Reader,
+	decode func(in []byte, atEOF bool) (out []byte, consumed int),
+) *decodeReader {
+	return &decodeReader{
+		r:      r,
+		decode: decode,
+		in:     make([]byte, 0, decodeReaderBufSize),
+	}
+}
+
+func (dr *decodeReader) Read(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return
+	}
+	for len(dr.out) == 0 {
+		if dr.err != nil && len(dr.in) == 0 {
+			return 0, dr.err
+		}
+		if dr.err == nil {
+			var m int
+			m, dr.err = dr.r.Read(dr.in[len(dr.in):cap(dr.in)])
+			dr.in = dr.in[:len(dr.in)+m]
+		}
+		var consumed int
+		dr.out, consumed = dr.decode(dr.in, dr.err != nil)
+		dr.in = dr.in[:copy(dr.in, dr.in[consumed:])]
+		if dr.err != nil && !errors.Is(dr.err, io.EOF) {
+			// Discard the undecoded data when encountering an error.
+			dr.in = dr.in[:0]
+		}
+	}
+	n = copy(p, dr.out)
+	dr.out = dr.out[n:]
+	return
+}
+
+// bomStripReader is a reader that removes
+// the leading UTF-8 byte order mark (if any) from the underlying reader.
+type bomStripReader struct {
+	r       io.Reader
+	head    []byte // the leading bytes read from r but not yet returned
+	checked bool   // true if the leading bytes have been checked
+}
+
+func (bsr *bomStripReader) Read(p []byte) (n int, err error) {
+	if !bsr.checked {
+		buf := make([]byte, len(utf8BOM))
+		m, err := io.ReadFull(bsr.r, buf)
+		if err != nil && !errors.Is(err, io.EOF) &&
+			!errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, err
+		}
+		bsr.checked = true
+		bsr.head = bytes.TrimPrefix(buf[:m], utf8BOM)
+	}
+	if len(bsr.head) > 0 {
+		n = copy(p, bsr.head)
+		bsr.head = bsr.head[n:]
+		return
+	}
+	return bsr.r.Read(p)
+}
@@ -0,0 +1,153 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+	"testing/fstest"
+	"testing/iotest"
+
+	"github.com/donyori/gogo/filesys"
+)
+
+func TestDecode(t *testing.T) {
+	const text = "Hello, 世界! \U0001F600"
+	le := []byte{0xFF, 0xFE}
+	be := []byte{0xFE, 0xFF}
+	for _, r := range []rune(text) {
+		if r > 0xFFFF {
+			// Surrogate pair of U+1F600.
+			le = append(le, 0x3D, 0xD8, 0x00, 0xDE)
+			be = append(be, 0xD8, 0x3D, 0xDE, 0x00)
+		} else {
+			le = append(le, byte(r), byte(r>>8))
+			be = append(be, byte(r>>8), byte(r))
+		}
+	}
+	testCases := []struct {
+		name   string
+		decode func(r io.Reader) io.Reader
+		input  []byte
+		want   string
+	}{
+		{"UTF16LE", filesys.DecodeUTF16LE, le, "\uFEFF" + text},
+		{"UTF16BE", filesys.DecodeUTF16BE, be, "\uFEFF" + text},
+		{"UTF16-LE", filesys.DecodeUTF16, le, text},
+		{"UTF16-BE", filesys.DecodeUTF16, be, text},
+		{"UTF16-noBOM", filesys.DecodeUTF16, be[2:], text},
+		{"UTF16-empty", filesys.DecodeUTF16, nil, ""},
+		{"UTF16LE-odd", filesys.DecodeUTF16LE, []byte{'a', 0, 'b'}, "a�"},
+		{"UTF16LE-unpaired", filesys.DecodeUTF16LE, []byte{0x3D, 0xD8, 'a', 0}, "�a"},
+		{"UTF16LE-unpairedEnd", filesys.DecodeUTF16LE, []byte{'a', 0, 0x3D, 0xD8}, "a�"},
+		{"Latin1", filesys.DecodeLatin1, []byte("caf\xe9 \xa9"), "café ©"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, oneByte := range []bool{false, true} {
+				var r io.Reader = bytes.NewReader(tc.input)
+				if oneByte {
+					r = iotest.OneByteReader(r)
+				}
+				got, err := io.ReadAll(tc.decode(r))
+				if err != nil {
+					t.Fatal(err)
+				} else if string(got) != tc.want {
+					t.Errorf("oneByte=%t - got %q; want %q",
+						oneByte, got, tc.want)
+				}
+			}
+		})
+	}
+}
+
+func TestReadFromFS_Encoding(t *testing.T) {
+	mapFS := fstest.MapFS{
+		"utf16.txt": {Data: []byte{
+			0xFF, 0xFE, 'a', 0, '\n', 0, 0xE9, 0, '\n', 0,
+		}},
+		"bom.txt":      {Data: []byte("\xEF\xBB\xBFline1\nline2\n")},
+		"bom-only.txt": {Data: []byte("\xEF\xBB\xBF")},
+		"short.txt":    {Data: []byte("a")},
+	}
+	testCases := []struct {
+		name string
+		opts *filesys.ReadOptions
+		want string
+	}{
+		{"utf16.txt", &filesys.ReadOptions{Encoding: filesys.DecodeUTF16LE}, "\uFEFFa\né\n"},
+		{"utf16.txt", &filesys.ReadOptions{Encoding: filesys.DecodeUTF16LE, StripBOM: true}, "a\né\n"},
+		{"bom.txt", nil, "\uFEFFline1\nline2\n"},
+		{"bom.txt", &filesys.ReadOptions{StripBOM: true}, "line1\nline2\n"},
+		{"bom-only.txt", &filesys.ReadOptions{StripBOM: true}, ""},
+		{"short.txt", &filesys.ReadOptions{StripBOM: true}, "a"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := filesys.ReadFromFS(mapFS, tc.name, tc.opts)
+			if err != nil {
+				t.Fatal("create -", err)
+			}
+			defer func() {
+				if err := r.Close(); err != nil {
+					t.Error("close -", err)
+				}
+			}()
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal("read -", err)
+			} else if string(got) != tc.want {
+				t.Errorf("got %q; want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestReadFromFS_EncodingReadLine(t *testing.T) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	_, err := gw.Write([]byte("caf\xe9\nna\xefve\n"))
+	if err == nil {
+		err = gw.Close()
+	}
+	if err != nil {
+		t.Fatal("gzip -", err)
+	}
+	mapFS := fstest.MapFS{"latin1.txt.gz": {Data: buf.Bytes()}}
+	r, err := filesys.ReadFromFS(mapFS, "latin1.txt.gz",
+		&filesys.ReadOptions{Encoding: filesys.DecodeLatin1})
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	defer func() {
+		if err := r.Close(); err != nil {
+			t.Error("close -", err)
+		}
+	}()
+	for _, want := range []string{"café", "naïve"} {
+		line, err := r.ReadEntireLine()
+		if err != nil {
+			t.Fatal("read line -", err)
+		} else if string(line) != want {
+			t.Errorf("got %q; want %q", line, want)
+		}
+	}
+}
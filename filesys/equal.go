@@ -0,0 +1,230 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// equalChunkSize is the size of the chunks read for comparing file contents.
+const equalChunkSize = 32 << 10
+
+// EqualOptions are options for functions EqualFiles and EqualTrees.
+//
+// A nil *EqualOptions is equivalent to a zero-value EqualOptions,
+// which compares the file contents only.
+type EqualOptions struct {
+	// CompareMode indicates whether to compare the file mode bits
+	// (as reported by the method Mode of io/fs.FileInfo).
+	CompareMode bool
+
+	// CompareModTime indicates whether to compare the modification time.
+	CompareModTime bool
+}
+
+// EqualFiles reports whether the file pathA in fsysA and
+// the file pathB in fsysB have the same contents,
+// with specified options opts.
+//
+// The file contents are compared chunk by chunk,
+// and the comparison stops at the first difference.
+// If both files are regular files with different sizes,
+// their contents are not read.
+//
+// If either file is a directory, EqualFiles reports ErrIsDir.
+// (To test whether err is ErrIsDir, use function errors.Is.)
+//
+// EqualFiles panics if fsysA or fsysB is nil.
+func EqualFiles(
+	fsysA fs.FS,
+	pathA string,
+	fsysB fs.FS,
+	pathB string,
+	opts *EqualOptions,
+) (equal bool, err error) {
+	if fsysA == nil {
+		panic(errors.AutoMsg("fsysA is nil"))
+	} else if fsysB == nil {
+		panic(errors.AutoMsg("fsysB is nil"))
+	}
+	equal, err = equalFiles(fsysA, pathA, fsysB, pathB, opts)
+	return equal, errors.AutoWrap(err)
+}
+
+// EqualTrees reports whether the file tree rooted at rootA in fsysA and
+// the file tree rooted at rootB in fsysB are the same,
+// with specified options opts.
+//
+// Two trees are the same if they have the same set of paths
+// (relative to their roots), each path refers to a directory
+// in both trees or a non-directory file in both trees,
+// and the files at each path have the same contents
+// (and the same metadata, as specified by opts).
+// The metadata of directories (including the roots) are also compared
+// if specified by opts.
+//
+// The comparison stops at the first difference.
+//
+// If rootA and rootB are both non-directory files,
+// EqualTrees compares them like EqualFiles.
+//
+// EqualTrees panics if fsysA or fsysB is nil.
+func EqualTrees(
+	fsysA fs.FS,
+	rootA string,
+	fsysB fs.FS,
+	rootB string,
+	opts *EqualOptions,
+) (equal bool, err error) {
+	if fsysA == nil {
+		panic(errors.AutoMsg("fsysA is nil"))
+	} else if fsysB == nil {
+		panic(errors.AutoMsg("fsysB is nil"))
+	}
+	equal, err = equalTrees(fsysA, rootA, fsysB, rootB, opts)
+	return equal, errors.AutoWrap(err)
+}
+
+// equalInfo reports whether the metadata in infoA and infoB
+// are the same, as specified by opts.
+func equalInfo(infoA, infoB fs.FileInfo, opts *EqualOptions) bool {
+	if infoA.IsDir() != infoB.IsDir() {
+		return false
+	} else if opts == nil {
+		return true
+	}
+	return (!opts.CompareMode || infoA.Mode() == infoB.Mode()) &&
+		(!opts.CompareModTime || infoA.ModTime().Equal(infoB.ModTime()))
+}
+
+// equalFiles is the implementation of function EqualFiles.
+func equalFiles(
+	fsysA fs.FS,
+	pathA string,
+	fsysB fs.FS,
+	pathB string,
+	opts *EqualOptions,
+) (equal bool, err error) {
+	fA, err := fsysA.Open(pathA)
+	if err != nil {
+		return false, err
+	}
+	defer func(f fs.File) {
+		_ = f.Close() // ignore error
+	}(fA)
+	fB, err := fsysB.Open(pathB)
+	if err != nil {
+		return false, err
+	}
+	defer func(f fs.File) {
+		_ = f.Close() // ignore error
+	}(fB)
+	infoA, err := fA.Stat()
+	if err != nil {
+		return false, err
+	}
+	infoB, err := fB.Stat()
+	if err != nil {
+		return false, err
+	}
+	if infoA.IsDir() || infoB.IsDir() {
+		return false, ErrIsDir
+	} else if !equalInfo(infoA, infoB, opts) ||
+		infoA.Mode().IsRegular() && infoB.Mode().IsRegular() &&
+			infoA.Size() != infoB.Size() {
+		return false, nil
+	}
+	return equalReaders(fA, fB)
+}
+
+// equalReaders reports whether rA and rB have the same contents.
+func equalReaders(rA, rB io.Reader) (equal bool, err error) {
+	bufA := make([]byte, equalChunkSize)
+	bufB := make([]byte, equalChunkSize)
+	for {
+		nA, errA := io.ReadFull(rA, bufA)
+		if errA != nil && !errors.Is(errA, io.EOF) &&
+			!errors.Is(errA, io.ErrUnexpectedEOF) {
+			return false, errA
+		}
+		nB, errB := io.ReadFull(rB, bufB)
+		if errB != nil && !errors.Is(errB, io.EOF) &&
+			!errors.Is(errB, io.ErrUnexpectedEOF) {
+			return false, errB
+		}
+		if nA != nB || !bytes.Equal(bufA[:nA], bufB[:nB]) {
+			return false, nil
+		} else if errA != nil || errB != nil {
+			// Both readers reach EOF (as nA == nB < equalChunkSize).
+			return true, nil
+		}
+	}
+}
+
+// equalTrees is the implementation of function EqualTrees.
+func equalTrees(
+	fsysA fs.FS,
+	rootA string,
+	fsysB fs.FS,
+	rootB string,
+	opts *EqualOptions,
+) (equal bool, err error) {
+	infoA, err := fs.Stat(fsysA, rootA)
+	if err != nil {
+		return false, err
+	}
+	infoB, err := fs.Stat(fsysB, rootB)
+	if err != nil {
+		return false, err
+	}
+	if !equalInfo(infoA, infoB, opts) {
+		return false, nil
+	} else if !infoA.IsDir() {
+		return equalFiles(fsysA, rootA, fsysB, rootB, opts)
+	}
+	entriesA, err := fs.ReadDir(fsysA, rootA)
+	if err != nil {
+		return false, err
+	}
+	entriesB, err := fs.ReadDir(fsysB, rootB)
+	if err != nil {
+		return false, err
+	}
+	if len(entriesA) != len(entriesB) ||
+		!slices.EqualFunc(entriesA, entriesB, func(a, b fs.DirEntry) bool {
+			return a.Name() == b.Name() && a.IsDir() == b.IsDir()
+		}) {
+		return false, nil
+	}
+	for i := range entriesA {
+		name := entriesA[i].Name()
+		equal, err = equalTrees(
+			fsysA, path.Join(rootA, name), fsysB, path.Join(rootB, name), opts)
+		if err != nil || !equal {
+			return false, err
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,136 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys_test
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/donyori/gogo/filesys"
+)
+
+func TestEqualFiles(t *testing.T) {
+	big := bytes.Repeat([]byte("0123456789"), 10000)
+	bigDiff := bytes.Clone(big)
+	bigDiff[len(bigDiff)-1] = 'x'
+	t1 := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	t2 := t1.Add(time.Second)
+	mapFS := fstest.MapFS{
+		"a.txt":        {Data: []byte("hello"), Mode: 0o644, ModTime: t1},
+		"b.txt":        {Data: []byte("hello"), Mode: 0o600, ModTime: t2},
+		"c.txt":        {Data: []byte("world"), Mode: 0o644, ModTime: t1},
+		"d.txt":        {Data: []byte("hello!"), Mode: 0o644, ModTime: t1},
+		"big.dat":      {Data: big},
+		"big-same.dat": {Data: bytes.Clone(big)},
+		"big-diff.dat": {Data: bigDiff},
+		"empty1":       {},
+		"empty2":       {},
+	}
+	testCases := []struct {
+		a, b string
+		opts *filesys.EqualOptions
+		want bool
+	}{
+		{"a.txt", "a.txt", nil, true},
+		{"a.txt", "b.txt", nil, true},
+		{"a.txt", "b.txt", &filesys.EqualOptions{CompareMode: true}, false},
+		{"a.txt", "b.txt", &filesys.EqualOptions{CompareModTime: true}, false},
+		{"a.txt", "c.txt", &filesys.EqualOptions{CompareMode: true, CompareModTime: true}, false},
+		{"a.txt", "d.txt", nil, false},
+		{"big.dat", "big-same.dat", nil, true},
+		{"big.dat", "big-diff.dat", nil, false},
+		{"empty1", "empty2", nil, true},
+		{"empty1", "a.txt", nil, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.a+"&"+tc.b, func(t *testing.T) {
+			got, err := filesys.EqualFiles(mapFS, tc.a, mapFS, tc.b, tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			} else if got != tc.want {
+				t.Errorf("got %t; want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEqualFiles_Error(t *testing.T) {
+	mapFS := fstest.MapFS{"dir/a.txt": {Data: []byte("a")}}
+	_, err := filesys.EqualFiles(mapFS, "dir", mapFS, "dir/a.txt", nil)
+	if !errors.Is(err, filesys.ErrIsDir) {
+		t.Errorf("got %v; want %v", err, filesys.ErrIsDir)
+	}
+	_, err = filesys.EqualFiles(mapFS, "dir/a.txt", mapFS, "b.txt", nil)
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got %v; want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestEqualTrees(t *testing.T) {
+	t1 := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	mapFS := fstest.MapFS{
+		"x/a.txt":         {Data: []byte("a"), ModTime: t1},
+		"x/dir/b.txt":     {Data: []byte("b"), ModTime: t1},
+		"x/empty":         {Mode: fs.ModeDir | 0o755, ModTime: t1},
+		"same/a.txt":      {Data: []byte("a"), ModTime: t1},
+		"same/dir/b.txt":  {Data: []byte("b"), ModTime: t1},
+		"same/empty":      {Mode: fs.ModeDir | 0o755, ModTime: t1},
+		"diff/a.txt":      {Data: []byte("a"), ModTime: t1},
+		"diff/dir/b.txt":  {Data: []byte("B"), ModTime: t1},
+		"diff/empty":      {Mode: fs.ModeDir | 0o755, ModTime: t1},
+		"extra/a.txt":     {Data: []byte("a"), ModTime: t1},
+		"extra/dir/b.txt": {Data: []byte("b"), ModTime: t1},
+		"extra/dir/c.txt": {Data: []byte("c"), ModTime: t1},
+		"extra/empty":     {Mode: fs.ModeDir | 0o755, ModTime: t1},
+		"kind/a.txt":      {Data: []byte("a"), ModTime: t1},
+		"kind/dir/b.txt":  {Data: []byte("b"), ModTime: t1},
+		"kind/empty":      {Data: []byte{}, ModTime: t1},
+		"mtime/a.txt":     {Data: []byte("a"), ModTime: t1.Add(time.Hour)},
+		"mtime/dir/b.txt": {Data: []byte("b"), ModTime: t1},
+		"mtime/empty":     {Mode: fs.ModeDir | 0o755, ModTime: t1},
+	}
+	testCases := []struct {
+		b    string
+		opts *filesys.EqualOptions
+		want bool
+	}{
+		{"x", nil, true},
+		{"same", nil, true},
+		{"same", &filesys.EqualOptions{CompareMode: true}, true},
+		{"diff", nil, false},
+		{"extra", nil, false},
+		{"kind", nil, false},
+		{"mtime", nil, true},
+		{"mtime", &filesys.EqualOptions{CompareModTime: true}, false},
+	}
+	for _, tc := range testCases {
+		t.Run("b="+tc.b, func(t *testing.T) {
+			got, err := filesys.EqualTrees(mapFS, "x", mapFS, tc.b, tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			} else if got != tc.want {
+				t.Errorf("got %t; want %t", got, tc.want)
+			}
+		})
+	}
+}
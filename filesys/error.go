@@ -32,6 +32,16 @@ var ErrIsDir = errors.AutoNewCustom(
 	0,
 )
 
+// ErrNotGzip is an error indicating that the file is not compressed by gzip,
+// or is opened in raw mode.
+//
+// The client should use errors.Is to test whether an error is ErrNotGzip.
+var ErrNotGzip = errors.AutoNewCustom(
+	"file is not compressed by gzip or is opened in raw mode",
+	errors.PrependFullPkgName,
+	0,
+)
+
 // ErrNotTar is an error indicating that the file is not archived by tar,
 // or is opened in raw mode.
 //
@@ -73,6 +83,28 @@ var ErrZipWriteBeforeCreate = errors.AutoNewCustom(
 	0,
 )
 
+// ErrWriteLimitExceeded is an error indicating that
+// the data written to the writer exceed the limit
+// specified by the option MaxBytes of WriteOptions.
+//
+// The client should use errors.Is to test whether
+// an error is ErrWriteLimitExceeded.
+var ErrWriteLimitExceeded = errors.AutoNewCustom(
+	"data written exceed the limit",
+	errors.PrependFullPkgName,
+	0,
+)
+
+// ErrPathEscape is an error indicating that a path
+// (possibly through symbolic links) escapes from the root directory.
+//
+// The client should use errors.Is to test whether an error is ErrPathEscape.
+var ErrPathEscape = errors.AutoNewCustom(
+	"path escapes from the root directory",
+	errors.PrependFullPkgName,
+	0,
+)
+
 // ErrFileReaderClosed is an error indicating that
 // the file reader is already closed.
 //
@@ -23,4 +23,5 @@ package filesys
 var (
 	NonNilDeduplicatedHashVerifiers = nonNilDeduplicatedHashVerifiers
 	TarHeaderIsDir                  = tarHeaderIsDir
+	PBKDF2SHA1                      = pbkdf2SHA1
 )
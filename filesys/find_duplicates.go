@@ -0,0 +1,179 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"hash"
+	"io/fs"
+	"iter"
+	"slices"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// FindDuplicatesOptions are options for function FindDuplicates.
+//
+// A nil *FindDuplicatesOptions is equivalent to
+// a zero-value FindDuplicatesOptions.
+type FindDuplicatesOptions struct {
+	// Walk are the options for walking the file tree,
+	// used to select the files to be examined
+	// (see function Walk for details).
+	// Only regular files are examined.
+	Walk *WalkOptions
+
+	// NewHash is the function to create a new hash function
+	// used to compare the file contents (e.g., crypto/sha256.New).
+	//
+	// If NewHash is nil, crypto/sha256.New is used.
+	NewHash func() hash.Hash
+
+	// NumWorker is the number of goroutines hashing the files concurrently.
+	// If NumWorker is nonpositive, runtime.GOMAXPROCS(0) is used.
+	NumWorker int
+
+	// MinSize is the minimum size of the files to be examined, in bytes.
+	// Files smaller than MinSize are ignored.
+	//
+	// In particular, if MinSize is nonpositive, empty files are examined
+	// (and all empty files are reported as duplicates).
+	MinSize int64
+}
+
+// FindDuplicates returns an iterator over the sets of duplicate files
+// in the file tree rooted at root in fsys, with specified options opts.
+//
+// The files are first grouped by size,
+// and then the files of the same size are grouped by
+// the checksum of their contents.
+// Each set yielded by the iterator consists of the paths
+// (in the same form as those passed to the callback function of
+// io/fs.WalkDir) of at least two files with the same size and checksum,
+// sorted in lexical order.
+// The sets are yielded in lexical order of their first paths,
+// so the output is deterministic.
+//
+// All files are examined before the first set is yielded.
+// The examination starts when the iteration starts,
+// and is done again for each iteration.
+//
+// The iteration stops at the first error encountered
+// (e.g., an invalid pattern in opts, or an error reading a file).
+// After the iteration ends, the error can be retrieved through
+// the returned pointer errPtr, which is never nil.
+// *errPtr is reset to nil at the start of each iteration.
+//
+// FindDuplicates panics if fsys is nil.
+func FindDuplicates(
+	fsys fs.FS,
+	root string,
+	opts *FindDuplicatesOptions,
+) (seq iter.Seq[[]string], errPtr *error) {
+	if fsys == nil {
+		panic(errors.AutoMsg("fsys is nil"))
+	}
+	var o FindDuplicatesOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.NewHash == nil {
+		o.NewHash = sha256.New
+	}
+	errPtr = new(error)
+	seq = func(yield func([]string) bool) {
+		*errPtr = nil
+		sets, err := findDuplicates(fsys, root, &o)
+		if err != nil {
+			*errPtr = errors.AutoWrap(err)
+			return
+		}
+		for _, set := range sets {
+			if !yield(set) {
+				return
+			}
+		}
+	}
+	return
+}
+
+// findDuplicates finds the sets of duplicate files
+// for function FindDuplicates.
+func findDuplicates(
+	fsys fs.FS,
+	root string,
+	opts *FindDuplicatesOptions,
+) (sets [][]string, err error) {
+	walkSeq, walkErrPtr := Walk(fsys, root, opts.Walk)
+	sizeGroups := make(map[int64][]string)
+	for p, d := range walkSeq {
+		if !d.Type().IsRegular() {
+			continue
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil, err
+		} else if info.Size() < opts.MinSize {
+			continue
+		}
+		sizeGroups[info.Size()] = append(sizeGroups[info.Size()], p)
+	}
+	if *walkErrPtr != nil {
+		return nil, *walkErrPtr
+	}
+	checksums, err := hashFSFiles(fsys, opts.NewHash, opts.NumWorker, false,
+		func(send func(name string) bool) error {
+			for _, names := range sizeGroups {
+				if len(names) < 2 {
+					continue
+				}
+				for _, name := range names {
+					if !send(name) {
+						return nil
+					}
+				}
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, names := range sizeGroups {
+		if len(names) < 2 {
+			continue
+		}
+		hashGroups := make(map[string][]string, len(names))
+		for _, name := range names {
+			cs := checksums[name]
+			hashGroups[cs] = append(hashGroups[cs], name)
+		}
+		for _, set := range hashGroups {
+			if len(set) > 1 {
+				slices.Sort(set)
+				sets = append(sets, set)
+			}
+		}
+	}
+	slices.SortFunc(sets, func(a, b []string) int {
+		return cmp.Compare(a[0], b[0])
+	})
+	return
+}
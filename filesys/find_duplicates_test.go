@@ -0,0 +1,121 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys_test
+
+import (
+	"crypto/md5"
+	"fmt"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/donyori/gogo/filesys"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	mapFS := fstest.MapFS{
+		"a.txt":         {Data: []byte("hello")},
+		"dir/a.txt":     {Data: []byte("hello")},
+		"dir/sub/a.txt": {Data: []byte("hello")},
+		"b.txt":         {Data: []byte("world")}, // same size as a.txt but different
+		"c.txt":         {Data: []byte("foo bar")},
+		"dir/c.txt":     {Data: []byte("foo bar")},
+		"d.txt":         {Data: []byte("unique")},
+		"empty1":        {},
+		"dir/empty2":    {},
+		"skip/a.txt":    {Data: []byte("hello")},
+	}
+	testCases := []struct {
+		name string
+		opts *filesys.FindDuplicatesOptions
+		want [][]string
+	}{
+		{
+			"nil",
+			nil,
+			[][]string{
+				{"a.txt", "dir/a.txt", "dir/sub/a.txt", "skip/a.txt"},
+				{"c.txt", "dir/c.txt"},
+				{"dir/empty2", "empty1"},
+			},
+		},
+		{
+			"MinSize=1&Exclude=skip&md5&NumWorker=1",
+			&filesys.FindDuplicatesOptions{
+				Walk:      &filesys.WalkOptions{Exclude: []string{"skip"}},
+				NewHash:   md5.New,
+				NumWorker: 1,
+				MinSize:   1,
+			},
+			[][]string{
+				{"a.txt", "dir/a.txt", "dir/sub/a.txt"},
+				{"c.txt", "dir/c.txt"},
+			},
+		},
+		{
+			"MinSize=6",
+			&filesys.FindDuplicatesOptions{MinSize: 6},
+			[][]string{{"c.txt", "dir/c.txt"}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run("opts="+tc.name, func(t *testing.T) {
+			seq, errPtr := filesys.FindDuplicates(mapFS, ".", tc.opts)
+			var got [][]string
+			for set := range seq {
+				got = append(got, set)
+			}
+			if *errPtr != nil {
+				t.Fatal(*errPtr)
+			}
+			if !slices.EqualFunc(got, tc.want, slices.Equal) {
+				t.Errorf("got %v; want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFindDuplicates_Break(t *testing.T) {
+	mapFS := fstest.MapFS{}
+	for i := range 5 {
+		mapFS[fmt.Sprintf("x%d", i)] = &fstest.MapFile{Data: []byte("x")}
+		mapFS[fmt.Sprintf("y%d", i)] = &fstest.MapFile{Data: []byte("yy")}
+	}
+	seq, errPtr := filesys.FindDuplicates(mapFS, ".", nil)
+	var n int
+	for range seq {
+		n++
+		break
+	}
+	if *errPtr != nil {
+		t.Fatal(*errPtr)
+	} else if n != 1 {
+		t.Errorf("got %d sets; want 1", n)
+	}
+}
+
+func TestFindDuplicates_Error(t *testing.T) {
+	seq, errPtr := filesys.FindDuplicates(fstest.MapFS{}, "nonexistent", nil)
+	for range seq {
+		t.Error("got a set; want none")
+	}
+	if *errPtr == nil {
+		t.Error("got nil error")
+	}
+}
@@ -0,0 +1,438 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys
+
+import (
+	"fmt"
+	"io/fs"
+	"iter"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// Glob returns the names of all files and directories in fsys
+// matching pattern, in lexical order.
+//
+// In addition to the syntax of path.Match, the pattern supports:
+//   - "**" as a whole path element, matching zero or more path elements
+//     (e.g., "a/**/*.go" matches "a/b.go" and "a/b/c/d.go");
+//   - brace expansion, such as "*.{go,mod}" and "{a,b{c,d}}/x",
+//     which may be nested.
+//
+// Character classes (e.g., "[a-z]" and "[^0-9]") are supported
+// as in path.Match.
+//
+// Like io/fs.Glob, Glob ignores file system errors such as
+// I/O errors reading directories.
+// The only possible returned error is path.ErrBadPattern,
+// reporting that the pattern is malformed.
+// Braces whose alternatives contain a slash or "**" are expanded in advance,
+// and a pattern that expands into more than 1024 patterns in this way
+// is also considered malformed.
+// The other braces are matched lazily and have no such limit.
+//
+// Glob panics if fsys is nil.
+func Glob(fsys fs.FS, pattern string) (matches []string, err error) {
+	seq, err := GlobSeq(fsys, pattern)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	for name := range seq {
+		matches = append(matches, name)
+	}
+	slices.Sort(matches)
+	return
+}
+
+// GlobSeq is like Glob but returns an iterator over the matched names.
+//
+// The names are yielded in the walking order of io/fs.WalkDir
+// (i.e., the entries of each directory in lexical order,
+// with directories in pre-order),
+// which may differ from the order of Glob.
+// Each name is yielded at most once.
+//
+// The pattern is validated when GlobSeq is called;
+// the only possible returned error is path.ErrBadPattern.
+//
+// GlobSeq panics if fsys is nil.
+func GlobSeq(fsys fs.FS, pattern string) (seq iter.Seq[string], err error) {
+	if fsys == nil {
+		panic(errors.AutoMsg("fsys is nil"))
+	}
+	patterns, err := compileGlob(pattern)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	return func(yield func(string) bool) {
+		_ = fs.WalkDir(fsys, ".", func(
+			p string,
+			d fs.DirEntry,
+			err error,
+		) error {
+			if err != nil || p == "." {
+				return nil // ignore errors and the root, as described above
+			}
+			elems := strings.Split(p, "/")
+			var matched, descend bool
+			for _, pat := range patterns {
+				if !matched && globMatch(pat, elems) {
+					matched = true
+				}
+				if !descend && d.IsDir() && globCanDescend(pat, elems) {
+					descend = true
+				}
+			}
+			if matched && !yield(p) {
+				return fs.SkipAll
+			} else if d.IsDir() && !descend {
+				return fs.SkipDir
+			}
+			return nil
+		})
+	}, nil
+}
+
+// MatchGlob reports whether name matches pattern,
+// where the pattern syntax is the same as that of Glob.
+//
+// Both name and pattern are slash-separated paths.
+//
+// The only possible returned error is path.ErrBadPattern.
+func MatchGlob(pattern, name string) (matched bool, err error) {
+	patterns, err := compileGlob(pattern)
+	if err != nil {
+		return false, errors.AutoWrap(err)
+	}
+	elems := strings.Split(name, "/")
+	for _, pat := range patterns {
+		if globMatch(pat, elems) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// maxGlobPatterns is the maximum number of patterns
+// into which compileGlob expands a glob pattern.
+const maxGlobPatterns = 1 << 10
+
+// globElem is a compiled path element of a glob pattern.
+type globElem struct {
+	pat string         // the pattern element
+	re  *regexp.Regexp // the regular expression for pat if pat contains braces
+}
+
+// isDoubleStar reports whether e is "**".
+func (e globElem) isDoubleStar() bool {
+	return e.re == nil && e.pat == "**"
+}
+
+// match reports whether the path element name matches e.
+func (e globElem) match(name string) bool {
+	if e.re != nil {
+		return e.re.MatchString(name)
+	}
+	matched, _ := path.Match(e.pat, name)
+	return matched
+}
+
+// compileGlob expands the braces in pattern that span path elements,
+// splits each resulting pattern into path elements,
+// and compiles them.
+func compileGlob(pattern string) ([][]globElem, error) {
+	expanded, err := globExpandBraces(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns := make([][]globElem, 0, len(expanded))
+	for _, p := range expanded {
+		elems := strings.Split(p, "/")
+		// Collapse consecutive "**" elements.
+		elems = slices.CompactFunc(elems, func(a, b string) bool {
+			return a == "**" && b == "**"
+		})
+		pat := make([]globElem, len(elems))
+		for i, elem := range elems {
+			pat[i], err = compileGlobElem(elem)
+			if err != nil {
+				return nil, err
+			}
+		}
+		patterns = append(patterns, pat)
+	}
+	return patterns, nil
+}
+
+// globExpandBraces expands the braces in pattern
+// whose alternatives contain a slash or "**",
+// which may span path elements or form "**" elements.
+// The other braces are left to compileGlobElem.
+//
+// It reports path.ErrBadPattern if the braces are unbalanced
+// or if pattern expands into more than maxGlobPatterns patterns.
+func globExpandBraces(pattern string) ([]string, error) {
+	x := &globExpander{seen: make(map[string]bool)}
+	err := x.expand(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return x.result, nil
+}
+
+// globExpander holds the states of globExpandBraces.
+type globExpander struct {
+	result []string
+	seen   map[string]bool // the patterns in result
+	n      int             // number of expanded patterns, including duplicates
+}
+
+// expand expands the first brace that needs to be expanded in pattern,
+// and then expands the results recursively.
+// The final results are appended to x.result.
+func (x *globExpander) expand(pattern string) error {
+	start, end := -1, -1
+	var commas []int
+	depth, spanning := 0, false
+Loop:
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++ // skip the escaped character
+		case '[':
+			// Braces and commas in a character class are literal.
+			for i++; i < len(pattern) && pattern[i] != ']'; i++ {
+				if pattern[i] == '\\' {
+					i++
+				}
+			}
+		case '{':
+			if depth == 0 {
+				start, commas, spanning = i, commas[:0], false
+			}
+			depth++
+		case ',':
+			if depth == 1 {
+				commas = append(commas, i)
+			}
+		case '/':
+			spanning = spanning || depth > 0
+		case '*':
+			spanning = spanning || depth > 0 &&
+				i+1 < len(pattern) && pattern[i+1] == '*'
+		case '}':
+			if depth > 0 {
+				depth--
+				if depth == 0 && spanning {
+					end = i
+					break Loop
+				}
+			}
+		}
+	}
+	if depth > 0 {
+		return path.ErrBadPattern
+	} else if end < 0 {
+		x.n++
+		if x.n > maxGlobPatterns {
+			return path.ErrBadPattern
+		} else if !x.seen[pattern] {
+			x.seen[pattern] = true
+			x.result = append(x.result, pattern)
+		}
+		return nil
+	}
+	prefix, suffix := pattern[:start], pattern[end+1:]
+	from := start + 1
+	for _, to := range append(commas, end) {
+		err := x.expand(prefix + pattern[from:to] + suffix)
+		if err != nil {
+			return err
+		}
+		from = to + 1
+	}
+	return nil
+}
+
+// compileGlobElem compiles the path element elem of a glob pattern.
+//
+// If elem contains braces, it is translated into a regular expression
+// with the braces as alternations,
+// so that the alternatives are matched lazily
+// instead of being expanded into all their combinations.
+//
+// It reports path.ErrBadPattern if elem is malformed.
+func compileGlobElem(elem string) (globElem, error) {
+	var b strings.Builder
+	b.WriteString("^(?:")
+	var depth, from int
+	var hasBraces bool
+	// writeChunk validates elem[from:to], which contains no braces,
+	// and writes its regular expression to b.
+	writeChunk := func(to int) error {
+		_, err := path.Match(elem[from:to], "")
+		if err == nil {
+			globChunkToRegexp(&b, elem[from:to])
+		}
+		from = to + 1
+		return err
+	}
+	for i := 0; i < len(elem); i++ {
+		var sep string
+		switch elem[i] {
+		case '\\':
+			i++ // skip the escaped character
+			continue
+		case '[':
+			for i++; i < len(elem) && elem[i] != ']'; i++ {
+				if elem[i] == '\\' {
+					i++
+				}
+			}
+			continue
+		case '{':
+			depth++
+			hasBraces, sep = true, "(?:"
+		case ',':
+			if depth == 0 {
+				continue
+			}
+			sep = "|"
+		case '}':
+			if depth == 0 {
+				continue
+			}
+			depth--
+			sep = ")"
+		default:
+			continue
+		}
+		err := writeChunk(i)
+		if err != nil {
+			return globElem{}, err
+		}
+		b.WriteString(sep)
+	}
+	if !hasBraces {
+		_, err := path.Match(elem, "")
+		return globElem{pat: elem}, err
+	} else if depth > 0 {
+		return globElem{}, path.ErrBadPattern
+	}
+	err := writeChunk(len(elem))
+	if err != nil {
+		return globElem{}, err
+	}
+	b.WriteString(")$")
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return globElem{}, path.ErrBadPattern
+	}
+	return globElem{pat: elem, re: re}, nil
+}
+
+// globChunkToRegexp writes the regular expression equivalent to
+// the well-formed path.Match pattern chunk to b.
+func globChunkToRegexp(b *strings.Builder, chunk string) {
+	for len(chunk) > 0 {
+		switch chunk[0] {
+		case '*':
+			b.WriteString("[^/]*")
+			chunk = chunk[1:]
+		case '?':
+			b.WriteString("[^/]")
+			chunk = chunk[1:]
+		case '[':
+			b.WriteByte('[')
+			chunk = chunk[1:]
+			if chunk[0] == '^' {
+				b.WriteByte('^')
+				chunk = chunk[1:]
+			}
+			for n := 0; n == 0 || chunk[0] != ']'; n++ {
+				var r rune
+				r, chunk = globClassChar(chunk)
+				fmt.Fprintf(b, `\x{%x}`, r)
+				if chunk[0] == '-' {
+					r, chunk = globClassChar(chunk[1:])
+					fmt.Fprintf(b, `-\x{%x}`, r)
+				}
+			}
+			b.WriteByte(']')
+			chunk = chunk[1:]
+		default:
+			var r rune
+			r, chunk = globClassChar(chunk)
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+}
+
+// globClassChar decodes the first (possibly escaped) character in chunk,
+// and returns it with the rest of chunk.
+func globClassChar(chunk string) (r rune, rest string) {
+	if chunk[0] == '\\' {
+		chunk = chunk[1:]
+	}
+	r, size := utf8.DecodeRuneInString(chunk)
+	return r, chunk[size:]
+}
+
+// globMatch reports whether the path elements elems
+// match the pattern elements pat.
+func globMatch(pat []globElem, elems []string) bool {
+	for len(pat) > 0 {
+		if pat[0].isDoubleStar() {
+			for i := 0; i <= len(elems); i++ {
+				if globMatch(pat[1:], elems[i:]) {
+					return true
+				}
+			}
+			return false
+		} else if len(elems) == 0 {
+			return false
+		}
+		if !pat[0].match(elems[0]) {
+			return false
+		}
+		pat, elems = pat[1:], elems[1:]
+	}
+	return len(elems) == 0
+}
+
+// globCanDescend reports whether any descendant of the directory
+// with the path elements dirElems may match the pattern elements pat.
+func globCanDescend(pat []globElem, dirElems []string) bool {
+	for len(dirElems) > 0 {
+		if len(pat) == 0 {
+			return false
+		} else if pat[0].isDoubleStar() {
+			return true
+		} else if !pat[0].match(dirElems[0]) {
+			return false
+		}
+		pat, dirElems = pat[1:], dirElems[1:]
+	}
+	return len(pat) > 0
+}
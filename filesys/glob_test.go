@@ -0,0 +1,149 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys_test
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/donyori/gogo/filesys"
+)
+
+func TestGlob(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		want    []string
+	}{
+		{"*.txt", []string{"a.txt"}},
+		{"**/*.txt", []string{
+			"a.txt", "dir/c.txt", "dir/sub/e.txt", "vendor/sub/g.txt",
+		}},
+		{"dir/**", []string{
+			"dir", "dir/c.txt", "dir/d.go", "dir/sub",
+			"dir/sub/deep", "dir/sub/deep/h.json", "dir/sub/e.txt",
+		}},
+		{"dir/**/*.json", []string{"dir/sub/deep/h.json"}},
+		{"**/sub/*.txt", []string{"dir/sub/e.txt", "vendor/sub/g.txt"}},
+		{"*.{go,txt}", []string{"a.txt", "b.go"}},
+		{"{dir,vendor}/*.go", []string{"dir/d.go", "vendor/f.go"}},
+		{"{dir/{c,d},b}.*", []string{"b.go", "dir/c.txt", "dir/d.go"}},
+		{"dir/[a-c]*", []string{"dir/c.txt"}},
+		{"dir/[^c]*", []string{"dir/d.go", "dir/sub"}},
+		{"[{]*", nil},
+		{"dir/sub/e.txt", []string{"dir/sub/e.txt"}},
+		{"nonexistent/**", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("pattern=%+q", tc.pattern), func(t *testing.T) {
+			got, err := filesys.Glob(testWalkFS, tc.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("got %q; want %q", got, tc.want)
+			}
+
+			seq, err := filesys.GlobSeq(testWalkFS, tc.pattern)
+			if err != nil {
+				t.Fatal("GlobSeq -", err)
+			}
+			var fromSeq []string
+			for name := range seq {
+				fromSeq = append(fromSeq, name)
+			}
+			slices.Sort(fromSeq)
+			if !slices.Equal(fromSeq, tc.want) {
+				t.Errorf("GlobSeq - got %q; want %q", fromSeq, tc.want)
+			}
+		})
+	}
+}
+
+func TestGlob_BadPattern(t *testing.T) {
+	for _, pattern := range []string{
+		"[",
+		"{a,b",
+		"a/{b,[}",
+		"{a,[}b",
+		"{a,b}[",
+		strings.Repeat("{a/,b/}", 11),
+	} {
+		t.Run(fmt.Sprintf("pattern=%+q", pattern), func(t *testing.T) {
+			_, err := filesys.Glob(testWalkFS, pattern)
+			if !errors.Is(err, path.ErrBadPattern) {
+				t.Errorf("got %v; want %v", err, path.ErrBadPattern)
+			}
+		})
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"**", "a", true},
+		{"**", "a/b/c", true},
+		{"a/**/b", "a/b", true},
+		{"a/**/b", "a/x/y/b", true},
+		{"a/**/b", "a/x/y/c", false},
+		{"a/**/**/b", "a/b", true},
+		{"*.{go,mod}", "go.mod", true},
+		{"*.{go,mod}", "go.sum", false},
+		{"{a,b}/{c,d}", "b/c", true},
+		{"{a,b}/{c,d}", "c/b", false},
+		{"\\{a\\}", "{a}", true},
+		{"[ab]?", "bz", true},
+		{"*", "a/b", false},
+		{"{a,b{c,d}}/x", "bd/x", true},
+		{"{a,b{c,d}}/x", "b/x", false},
+		{"x{[a-c],?z}y", "xby", true},
+		{"x{[a-c],?z}y", "xqzy", true},
+		{"x{[^a-c],z}y", "xay", false},
+		{"x{\\,,y}", "x,", true},
+		{"{a/b,c}/d", "a/b/d", true},
+		{"{a/b,c}/d", "c/d", true},
+		{"{a/b,c}/d", "a/d", false},
+		{"{**,x}/y", "a/b/y", true},
+		{"{**,x}/y", "y", true},
+		{strings.Repeat("{a,b,c}", 12), "abcabcabcabc", true},
+		{strings.Repeat("{a,b,c}", 12), "abc", false},
+		{strings.Repeat("{*,?}", 20) + "x", strings.Repeat("a", 40), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(
+			fmt.Sprintf("pattern=%+q&name=%+q", tc.pattern, tc.name),
+			func(t *testing.T) {
+				got, err := filesys.MatchGlob(tc.pattern, tc.name)
+				if err != nil {
+					t.Fatal(err)
+				} else if got != tc.want {
+					t.Errorf("got %t; want %t", got, tc.want)
+				}
+			},
+		)
+	}
+}
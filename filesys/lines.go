@@ -0,0 +1,143 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys
+
+import (
+	"bytes"
+	"io"
+	"iter"
+
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/inout"
+)
+
+// LinePos is the position of a line yielded by IterLinesWithPos.
+type LinePos struct {
+	// No is the line number, starting from 1.
+	No int
+
+	// Offset is the byte offset of the beginning of the line,
+	// relative to the position of the reader
+	// when the iteration starts.
+	//
+	// For a Reader, the offset is counted on the data
+	// after decompression and decoding,
+	// i.e., the data returned by its Read method.
+	Offset int64
+}
+
+// IterLinesWithPos returns an iterator over the lines read from r,
+// yielding the position and content of each line.
+//
+// Like the method ReadEntireLine of r,
+// the lines exclude the end-of-line bytes ("\n" or "\r\n"),
+// and the content before EOF is treated as a line
+// even if the input ends without end-of-line bytes.
+// The yielded line is always valid, so the client can keep it safely.
+//
+// The position consists of the line number and the byte offset
+// of the beginning of the line (see LinePos for details),
+// which is useful to report precise error positions when parsing.
+//
+// The iteration reads from the current position of r,
+// and stops at the end of the input or the first error encountered.
+// The error (excluding io.EOF) can be retrieved through
+// the returned pointer errPtr after the iteration ends,
+// which is never nil.
+// *errPtr is reset to nil at the start of each iteration.
+// Each iteration continues to read from r,
+// and counts the line numbers and byte offsets from the beginning again.
+//
+// IterLinesWithPos panics if r is nil.
+func IterLinesWithPos(r inout.BufferedReader) (
+	seq iter.Seq2[LinePos, []byte], errPtr *error) {
+	if r == nil {
+		panic(errors.AutoMsg("r is nil"))
+	}
+	errPtr = new(error)
+	seq = func(yield func(LinePos, []byte) bool) {
+		*errPtr = nil
+		pos := LinePos{No: 1}
+		for {
+			line, n, err := readLineWithLen(r)
+			if n > 0 {
+				if !yield(pos, line) {
+					return
+				}
+				pos.No++
+				pos.Offset += int64(n)
+			}
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					*errPtr = errors.AutoWrap(err)
+				}
+				return
+			}
+		}
+	}
+	return
+}
+
+// readLineWithLen reads a line from r,
+// returning the line excluding the end-of-line bytes
+// and the number of bytes consumed from r
+// (including the end-of-line bytes).
+//
+// If n > 0, the returned line is valid (may be empty),
+// and err is the error encountered after reading the line,
+// which is also reported by the next call.
+func readLineWithLen(r inout.BufferedReader) (
+	line []byte, n int, err error) {
+	for {
+		if r.Buffered() == 0 {
+			_, err = r.Peek(1) // fill the buffer
+			if err != nil {
+				break
+			}
+		}
+		var data []byte
+		data, err = r.Peek(r.Buffered())
+		if err != nil {
+			break
+		}
+		i := bytes.IndexByte(data, '\n')
+		if i >= 0 {
+			data = data[:i+1]
+		}
+		line = append(line, data...)
+		n += len(data)
+		_, err = r.Discard(len(data))
+		if err != nil || i >= 0 {
+			break
+		}
+	}
+	if n == 0 {
+		return nil, 0, err
+	}
+	if line[len(line)-1] == '\n' {
+		line = line[:len(line)-1]
+		if len(line) > 0 && line[len(line)-1] == '\r' {
+			line = line[:len(line)-1]
+		}
+	}
+	if errors.Is(err, io.EOF) {
+		err = nil // EOF will be reported by the next call
+	}
+	return
+}
@@ -0,0 +1,140 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/donyori/gogo/filesys"
+	"github.com/donyori/gogo/inout"
+)
+
+func TestIterLinesWithPos(t *testing.T) {
+	longLine := strings.Repeat("x", 50)
+	testCases := []struct {
+		input string
+		want  []filesys.LinePos
+		lines []string
+	}{
+		{"", nil, nil},
+		{"a", []filesys.LinePos{{1, 0}}, []string{"a"}},
+		{"a\nbc\n", []filesys.LinePos{{1, 0}, {2, 2}}, []string{"a", "bc"}},
+		{"a\r\n\nbc", []filesys.LinePos{{1, 0}, {2, 3}, {3, 4}}, []string{"a", "", "bc"}},
+		{longLine + "\r\n" + longLine, []filesys.LinePos{{1, 0}, {2, 52}}, []string{longLine, longLine}},
+		{"a\rb\n", []filesys.LinePos{{1, 0}}, []string{"a\rb"}},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("input=%+q", tc.input), func(t *testing.T) {
+			for _, oneByte := range []bool{false, true} {
+				var r io.Reader = strings.NewReader(tc.input)
+				if oneByte {
+					r = iotest.OneByteReader(r)
+				}
+				seq, errPtr := filesys.IterLinesWithPos(
+					inout.NewBufferedReaderSize(r, 16))
+				var gotPos []filesys.LinePos
+				var gotLines []string
+				for pos, line := range seq {
+					gotPos = append(gotPos, pos)
+					gotLines = append(gotLines, string(line))
+				}
+				if *errPtr != nil {
+					t.Errorf("oneByte=%t - %v", oneByte, *errPtr)
+				}
+				if fmt.Sprint(gotPos) != fmt.Sprint(tc.want) {
+					t.Errorf("oneByte=%t - got positions %v; want %v",
+						oneByte, gotPos, tc.want)
+				}
+				if fmt.Sprintf("%q", gotLines) != fmt.Sprintf("%q", tc.lines) {
+					t.Errorf("oneByte=%t - got lines %q; want %q",
+						oneByte, gotLines, tc.lines)
+				}
+			}
+		})
+	}
+}
+
+func TestIterLinesWithPos_Break(t *testing.T) {
+	br := inout.NewBufferedReader(strings.NewReader("a\nb\nc\n"))
+	seq, errPtr := filesys.IterLinesWithPos(br)
+	for pos, line := range seq {
+		if pos.No != 1 || string(line) != "a" {
+			t.Errorf("got (%v, %q); want ({1 0}, %q)", pos, line, "a")
+		}
+		break
+	}
+	// The next iteration continues from the current position.
+	var got []string
+	for pos, line := range seq {
+		got = append(got, fmt.Sprintf("%d:%d:%s", pos.No, pos.Offset, line))
+	}
+	if *errPtr != nil {
+		t.Error(*errPtr)
+	}
+	if want := []string{"1:0:b", "2:2:c"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func TestIterLinesWithPos_Error(t *testing.T) {
+	errTest := errors.New("test error")
+	br := inout.NewBufferedReader(iotest.DataErrReader(
+		iotest.ErrReader(errTest)))
+	seq, errPtr := filesys.IterLinesWithPos(br)
+	for range seq {
+		t.Error("yielded a line unexpectedly")
+	}
+	if !errors.Is(*errPtr, errTest) {
+		t.Errorf("got %v; want %v", *errPtr, errTest)
+	}
+}
+
+func TestIterLinesWithPos_Reader(t *testing.T) {
+	r, err := filesys.ReadFromFS(testFS, "file1.txt", nil)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	defer func(r filesys.Reader) {
+		if err := r.Close(); err != nil {
+			t.Error("close -", err)
+		}
+	}(r)
+	data := testFS["file1.txt"].Data
+	seq, errPtr := filesys.IterLinesWithPos(r)
+	var n int
+	for pos, line := range seq {
+		n++
+		end := pos.Offset + int64(len(line))
+		if end > int64(len(data)) ||
+			string(data[pos.Offset:end]) != string(line) {
+			t.Errorf("line %d at offset %d mismatched", pos.No, pos.Offset)
+		}
+	}
+	if *errPtr != nil {
+		t.Error(*errPtr)
+	}
+	if want := strings.Count(strings.TrimSuffix(string(data), "\n"), "\n") + 1; n != want {
+		t.Errorf("got %d lines; want %d", n, want)
+	}
+}
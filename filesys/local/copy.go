@@ -0,0 +1,390 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package local
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/filesys"
+)
+
+// CopyOptions are options for CopyFile and CopyDir.
+//
+// A nil *CopyOptions is equivalent to a zero-value CopyOptions.
+type CopyOptions struct {
+	// True if to preserve the permission bits of the source files
+	// and directories.
+	//
+	// If false, new files are created with permission 0666 (before umask),
+	// and new directories are created with permission 0777 (before umask).
+	PreservePerm bool
+
+	// True if to preserve the modification times of the source files
+	// and directories.
+	PreserveModTime bool
+
+	// True if to copy the files and directories that symlinks point to,
+	// instead of the symlinks themselves.
+	//
+	// If false, symlinks are recreated at the destination
+	// with the same targets (not adjusted to the destination).
+	FollowSymlinks bool
+
+	// True if to overwrite the existing files at the destination.
+	//
+	// If false, copying to an existing file reports an error
+	// that satisfies errors.Is(err, fs.ErrExist).
+	// Existing directories are always merged with the source directories.
+	Overwrite bool
+
+	// Skip, if not nil, is called for each file and directory
+	// in the source directory (excluding the root) by CopyDir,
+	// with its path relative to the source directory
+	// and its directory entry.
+	// If Skip returns true, the file is not copied;
+	// for a directory, its entire subtree is not copied.
+	//
+	// It is not used by CopyFile.
+	Skip func(relPath string, d fs.DirEntry) bool
+
+	// Progress, if not nil, is called after each chunk of data is copied,
+	// with the total number of bytes copied so far in the whole operation
+	// and the path of the source file currently being copied.
+	Progress func(copied int64, path string)
+}
+
+// CopyFile copies the file src to dst with options opts.
+//
+// If src is a symlink and the option FollowSymlinks is false,
+// dst is created as a symlink with the same target.
+// Otherwise, the content of src (or its target) is copied to dst.
+//
+// If src is a directory, CopyFile reports
+// github.com/donyori/gogo/filesys.ErrIsDir.
+// (To test whether err is filesys.ErrIsDir, use function errors.Is.)
+//
+// If dst exists and is the same file as src
+// (e.g., dst is src itself, or a hard link or symlink to src),
+// CopyFile reports an error and leaves the file unchanged,
+// even if the option Overwrite is true.
+//
+// The parent directory of dst must exist.
+func CopyFile(dst, src string, opts *CopyOptions) error {
+	c := newCopier(opts)
+	info, err := c.stat(src)
+	if err != nil {
+		return errors.AutoWrap(err)
+	} else if info.IsDir() {
+		return errors.AutoWrap(errIsDir(src))
+	}
+	return errors.AutoWrap(c.copyEntry(dst, src, info))
+}
+
+// CopyDir copies the directory src and all its contents to dst
+// with options opts.
+//
+// If dst does not exist, it is created (along with its parents).
+// If dst exists, it must be a directory,
+// and the contents of src are merged into it.
+//
+// Symlinks are handled according to the option FollowSymlinks
+// (see CopyOptions for details).
+// When following symlinks, symlinks that lead to cycles are reported
+// as errors.
+//
+// dst must not be src or inside src.
+// Files and directories at the destination that are the same as
+// the corresponding source ones (e.g., hard links or symlinks to them)
+// are reported as errors and left unchanged.
+func CopyDir(dst, src string, opts *CopyOptions) error {
+	c := newCopier(opts)
+	info, err := c.stat(src)
+	if err != nil {
+		return errors.AutoWrap(err)
+	} else if !info.IsDir() {
+		return errors.AutoNew("src is not a directory")
+	}
+	absSrc, err := filepath.Abs(src)
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	absDst, err := filepath.Abs(dst)
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	if absDst == absSrc ||
+		strings.HasPrefix(absDst, absSrc+string(filepath.Separator)) {
+		return errors.AutoNew("dst is src or inside src")
+	}
+	err = os.MkdirAll(filepath.Dir(absDst), 0777)
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	err = c.copyDir(dst, src, info, "")
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	return errors.AutoWrap(c.setDirModTimes())
+}
+
+// copier holds the states of a copy operation.
+type copier struct {
+	opts    CopyOptions
+	copied  int64 // total number of bytes copied, for Progress
+	buf     []byte
+	visited map[string]bool // real paths of directories being copied, for FollowSymlinks
+	dirs    []dirModTime    // directories whose modification times are to be set
+}
+
+// dirModTime consists of a directory path and its modification time.
+type dirModTime struct {
+	path    string
+	modTime time.Time
+}
+
+// newCopier creates a new copier with options opts.
+func newCopier(opts *CopyOptions) *copier {
+	c := new(copier)
+	if opts != nil {
+		c.opts = *opts
+	}
+	return c
+}
+
+// stat returns the FileInfo of name, following symlinks
+// if the option FollowSymlinks is true.
+func (c *copier) stat(name string) (fs.FileInfo, error) {
+	if c.opts.FollowSymlinks {
+		return os.Stat(name)
+	}
+	return os.Lstat(name)
+}
+
+// copyEntry copies a non-directory file src to dst.
+func (c *copier) copyEntry(dst, src string, info fs.FileInfo) error {
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		return c.copySymlink(dst, src)
+	case !info.Mode().IsRegular():
+		return errors.New("cannot copy irregular file " + src)
+	}
+	return c.copyRegular(dst, src, info)
+}
+
+// copySymlink creates dst as a symlink with the same target as src.
+func (c *copier) copySymlink(dst, src string) error {
+	target, err := os.Readlink(src)
+	if err != nil {
+		return err
+	}
+	if c.opts.Overwrite {
+		if info, err := os.Lstat(dst); err == nil && !info.IsDir() {
+			err = os.Remove(dst)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return os.Symlink(target, dst)
+}
+
+// copyRegular copies the content of the regular file src to dst.
+func (c *copier) copyRegular(dst, src string, info fs.FileInfo) (err error) {
+	// Check before opening dst with O_TRUNC,
+	// which would destroy the content of src.
+	err = checkSameFile(dst, src, info)
+	if err != nil {
+		return
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func(f *os.File) {
+		_ = f.Close() // ignore error
+	}(in)
+	flag := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if c.opts.Overwrite {
+		flag = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	perm := fs.FileMode(0666)
+	if c.opts.PreservePerm {
+		perm = info.Mode().Perm()
+	}
+	out, err := os.OpenFile(dst, flag, perm)
+	if err != nil {
+		return err
+	}
+	defer func(f *os.File) {
+		closeErr := f.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}(out)
+	if c.buf == nil {
+		c.buf = make([]byte, 32<<10)
+	}
+	var w io.Writer = out
+	if c.opts.Progress != nil {
+		w = &progressWriter{w: out, c: c, path: src}
+	}
+	_, err = io.CopyBuffer(w, in, c.buf)
+	if err != nil {
+		return err
+	}
+	if c.opts.PreservePerm {
+		// Apply the permission regardless of umask
+		// and of whether the file already existed.
+		err = out.Chmod(info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+	}
+	if c.opts.PreserveModTime {
+		err = os.Chtimes(dst, time.Time{}, info.ModTime())
+	}
+	return
+}
+
+// copyDir copies the directory src to dst recursively.
+//
+// rel is the path of src relative to the root source directory,
+// which is empty for the root.
+func (c *copier) copyDir(dst, src string, info fs.FileInfo, rel string) error {
+	if c.opts.FollowSymlinks {
+		realPath, err := filepath.EvalSymlinks(src)
+		if err != nil {
+			return err
+		} else if c.visited[realPath] {
+			return errors.New("symlink cycle detected at " + src)
+		}
+		if c.visited == nil {
+			c.visited = make(map[string]bool)
+		}
+		c.visited[realPath] = true
+		defer delete(c.visited, realPath)
+	}
+	perm := fs.FileMode(0777)
+	if c.opts.PreservePerm {
+		perm = info.Mode().Perm()
+	}
+	err := os.Mkdir(dst, perm)
+	if err != nil {
+		dstInfo, statErr := os.Stat(dst)
+		if statErr != nil || !dstInfo.IsDir() {
+			return err
+		} else if os.SameFile(dstInfo, info) {
+			return errSameFile(dst, src)
+		}
+	}
+	if c.opts.PreservePerm {
+		err = os.Chmod(dst, perm)
+		if err != nil {
+			return err
+		}
+	}
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		entryRel := entry.Name()
+		if rel != "" {
+			entryRel = filepath.Join(rel, entryRel)
+		}
+		if c.opts.Skip != nil && c.opts.Skip(entryRel, entry) {
+			continue
+		}
+		entrySrc := filepath.Join(src, entry.Name())
+		entryDst := filepath.Join(dst, entry.Name())
+		entryInfo, err := c.stat(entrySrc)
+		if err != nil {
+			return err
+		}
+		if entryInfo.IsDir() {
+			err = c.copyDir(entryDst, entrySrc, entryInfo, entryRel)
+		} else {
+			err = c.copyEntry(entryDst, entrySrc, entryInfo)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if c.opts.PreserveModTime {
+		// Set the modification times of directories at last
+		// because creating files in them changes their modification times.
+		c.dirs = append(c.dirs, dirModTime{path: dst, modTime: info.ModTime()})
+	}
+	return nil
+}
+
+// setDirModTimes sets the modification times of the directories
+// recorded by copyDir.
+func (c *copier) setDirModTimes() error {
+	for _, d := range c.dirs {
+		err := os.Chtimes(d.path, time.Time{}, d.modTime)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// progressWriter is an io.Writer that reports the progress
+// of a copy operation.
+type progressWriter struct {
+	w    io.Writer
+	c    *copier
+	path string
+}
+
+func (pw *progressWriter) Write(p []byte) (n int, err error) {
+	n, err = pw.w.Write(p)
+	if n > 0 {
+		pw.c.copied += int64(n)
+		pw.c.opts.Progress(pw.c.copied, pw.path)
+	}
+	return
+}
+
+// checkSameFile reports an error if dst exists and is the same file as src,
+// whose FileInfo is srcInfo.
+func checkSameFile(dst, src string, srcInfo fs.FileInfo) error {
+	dstInfo, err := os.Stat(dst)
+	if err == nil && os.SameFile(dstInfo, srcInfo) {
+		return errSameFile(dst, src)
+	}
+	return nil
+}
+
+// errSameFile returns an error indicating that dst and src are the same file.
+func errSameFile(dst, src string) error {
+	return errors.New(dst + " and " + src + " are the same file")
+}
+
+// errIsDir returns an error indicating that name is a directory.
+func errIsDir(name string) error {
+	return &fs.PathError{Op: "copy", Path: name, Err: filesys.ErrIsDir}
+}
@@ -0,0 +1,299 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package local_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/filesys"
+	"github.com/donyori/gogo/filesys/local"
+)
+
+func TestCopyFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	data := []byte("Hello, world!\n")
+	writeTestFile(t, src, data, 0640)
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(src, time.Time{}, modTime); err != nil {
+		t.Fatal("set mod time -", err)
+	}
+
+	dst := filepath.Join(dir, "dst.txt")
+	var copied int64
+	var progressPath string
+	err := local.CopyFile(dst, src, &local.CopyOptions{
+		PreservePerm:    true,
+		PreserveModTime: true,
+		Progress: func(n int64, path string) {
+			copied, progressPath = n, path
+		},
+	})
+	if err != nil {
+		t.Fatal("copy -", err)
+	}
+	checkTestFile(t, dst, data)
+	info, err := os.Stat(dst)
+	if err != nil {
+		t.Fatal("stat -", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0640 {
+		t.Errorf("got perm %v; want %v", perm, fs.FileMode(0640))
+	}
+	if !info.ModTime().Equal(modTime) {
+		t.Errorf("got mod time %v; want %v", info.ModTime(), modTime)
+	}
+	if copied != int64(len(data)) {
+		t.Errorf("got copied %d; want %d", copied, len(data))
+	}
+	if progressPath != src {
+		t.Errorf("got progress path %q; want %q", progressPath, src)
+	}
+
+	err = local.CopyFile(dst, src, nil)
+	if !errors.Is(err, fs.ErrExist) {
+		t.Errorf("copy to existing file without Overwrite - got %v; want %v",
+			err, fs.ErrExist)
+	}
+	newData := []byte("new")
+	writeTestFile(t, src, newData, 0600)
+	err = local.CopyFile(dst, src, &local.CopyOptions{Overwrite: true})
+	if err != nil {
+		t.Fatal("copy with Overwrite -", err)
+	}
+	checkTestFile(t, dst, newData)
+
+	err = local.CopyFile(filepath.Join(dir, "d"), dir, nil)
+	if !errors.Is(err, filesys.ErrIsDir) {
+		t.Errorf("copy directory - got %v; want %v", err, filesys.ErrIsDir)
+	}
+}
+
+func TestCopyFile_Symlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target.txt")
+	data := []byte("target")
+	writeTestFile(t, target, data, 0600)
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink("target.txt", link); err != nil {
+		t.Skip("cannot create symlink -", err)
+	}
+
+	dst := filepath.Join(dir, "dst-link")
+	if err := local.CopyFile(dst, link, nil); err != nil {
+		t.Fatal("copy without FollowSymlinks -", err)
+	}
+	if s, err := os.Readlink(dst); err != nil {
+		t.Error("read link -", err)
+	} else if s != "target.txt" {
+		t.Errorf("got link target %q; want %q", s, "target.txt")
+	}
+
+	dst = filepath.Join(dir, "dst-file")
+	err := local.CopyFile(dst, link, &local.CopyOptions{FollowSymlinks: true})
+	if err != nil {
+		t.Fatal("copy with FollowSymlinks -", err)
+	}
+	if info, err := os.Lstat(dst); err != nil {
+		t.Error("lstat -", err)
+	} else if !info.Mode().IsRegular() {
+		t.Errorf("got mode %v; want a regular file", info.Mode())
+	}
+	checkTestFile(t, dst, data)
+}
+
+func TestCopyFile_SameFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	data := []byte("important data")
+	writeTestFile(t, src, data, 0600)
+	hardLink := filepath.Join(dir, "hard-link")
+	if err := os.Link(src, hardLink); err != nil {
+		t.Fatal("create hard link -", err)
+	}
+	dsts := []string{src, hardLink}
+	symlink := filepath.Join(dir, "symlink")
+	if err := os.Symlink("src.txt", symlink); err == nil {
+		dsts = append(dsts, symlink)
+	} else {
+		t.Log("cannot create symlink -", err)
+	}
+
+	for _, dst := range dsts {
+		t.Run("dst="+filepath.Base(dst), func(t *testing.T) {
+			err := local.CopyFile(dst, src, &local.CopyOptions{Overwrite: true})
+			if err == nil {
+				t.Error("got nil error; want a same-file error")
+			}
+			checkTestFile(t, src, data)
+		})
+	}
+}
+
+func TestCopyDir_SameFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	if err := os.Mkdir(src, 0755); err != nil {
+		t.Fatal("make directory -", err)
+	}
+	data := []byte("important data")
+	writeTestFile(t, filepath.Join(src, "a.txt"), data, 0600)
+	dst := filepath.Join(dir, "dst")
+	if err := os.Mkdir(dst, 0755); err != nil {
+		t.Fatal("make directory -", err)
+	}
+	err := os.Link(filepath.Join(src, "a.txt"), filepath.Join(dst, "a.txt"))
+	if err != nil {
+		t.Fatal("create hard link -", err)
+	}
+
+	err = local.CopyDir(dst, src, &local.CopyOptions{Overwrite: true})
+	if err == nil {
+		t.Error("got nil error; want a same-file error")
+	}
+	checkTestFile(t, filepath.Join(src, "a.txt"), data)
+}
+
+func TestCopyDir(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	files := map[string]string{
+		"a.txt":          "file a",
+		"sub/b.txt":      "file b",
+		"sub/skip.txt":   "skipped",
+		"sub/deep/c.txt": "file c",
+		"skipdir/d.txt":  "file d",
+	}
+	var total int64
+	for name, content := range files {
+		name = filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal("make directory -", err)
+		}
+		writeTestFile(t, name, []byte(content), 0644)
+	}
+	for _, name := range []string{"a.txt", "sub/b.txt", "sub/deep/c.txt"} {
+		total += int64(len(files[name]))
+	}
+	modTime := time.Date(2021, 6, 7, 8, 9, 10, 0, time.UTC)
+	if err := os.Chtimes(
+		filepath.Join(src, "sub"), time.Time{}, modTime); err != nil {
+		t.Fatal("set mod time -", err)
+	}
+
+	dst := filepath.Join(dir, "out", "dst")
+	var copied int64
+	err := local.CopyDir(dst, src, &local.CopyOptions{
+		PreserveModTime: true,
+		Skip: func(relPath string, d fs.DirEntry) bool {
+			return relPath == filepath.Join("sub", "skip.txt") ||
+				d.IsDir() && d.Name() == "skipdir"
+		},
+		Progress: func(n int64, _ string) {
+			copied = n
+		},
+	})
+	if err != nil {
+		t.Fatal("copy -", err)
+	}
+	for name, content := range files {
+		name = filepath.Join(dst, filepath.FromSlash(name))
+		if filepath.Base(name) == "skip.txt" ||
+			filepath.Base(filepath.Dir(name)) == "skipdir" {
+			if _, err := os.Lstat(name); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("%s - got %v; want %v", name, err, fs.ErrNotExist)
+			}
+			continue
+		}
+		checkTestFile(t, name, []byte(content))
+	}
+	if _, err := os.Lstat(filepath.Join(dst, "skipdir")); !errors.Is(
+		err, fs.ErrNotExist) {
+		t.Errorf("skipdir - got %v; want %v", err, fs.ErrNotExist)
+	}
+	if info, err := os.Stat(filepath.Join(dst, "sub")); err != nil {
+		t.Error("stat -", err)
+	} else if !info.ModTime().Equal(modTime) {
+		t.Errorf("got mod time %v; want %v", info.ModTime(), modTime)
+	}
+	if copied != total {
+		t.Errorf("got copied %d; want %d", copied, total)
+	}
+
+	err = local.CopyDir(filepath.Join(src, "sub", "inner"), src, nil)
+	if err == nil {
+		t.Error("copy into itself - got nil error")
+	}
+}
+
+func TestCopyDir_SymlinkCycle(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	if err := os.Mkdir(src, 0755); err != nil {
+		t.Fatal("make directory -", err)
+	}
+	if err := os.Symlink("..", filepath.Join(src, "parent")); err != nil {
+		t.Skip("cannot create symlink -", err)
+	}
+	dst := filepath.Join(dir, "dst")
+	if err := local.CopyDir(dst, src, nil); err != nil {
+		t.Fatal("copy without FollowSymlinks -", err)
+	}
+	if s, err := os.Readlink(filepath.Join(dst, "parent")); err != nil {
+		t.Error("read link -", err)
+	} else if s != ".." {
+		t.Errorf("got link target %q; want %q", s, "..")
+	}
+	err := local.CopyDir(
+		filepath.Join(t.TempDir(), "dst"),
+		src,
+		&local.CopyOptions{FollowSymlinks: true},
+	)
+	if err == nil {
+		t.Error("copy with FollowSymlinks - got nil error; want a cycle error")
+	}
+}
+
+// writeTestFile writes data to the file name with permission perm,
+// and sets its permission to perm regardless of umask.
+func writeTestFile(t *testing.T, name string, data []byte, perm fs.FileMode) {
+	t.Helper()
+	if err := os.WriteFile(name, data, perm); err != nil {
+		t.Fatal("write file -", err)
+	}
+	if err := os.Chmod(name, perm); err != nil {
+		t.Fatal("chmod -", err)
+	}
+}
+
+// checkTestFile checks whether the content of the file name is want.
+func checkTestFile(t *testing.T, name string, want []byte) {
+	t.Helper()
+	got, err := os.ReadFile(name)
+	if err != nil {
+		t.Error("read file -", err)
+	} else if string(got) != string(want) {
+		t.Errorf("%s - got %q; want %q", name, got, want)
+	}
+}
@@ -0,0 +1,52 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package local
+
+import (
+	"os"
+
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/filesys"
+)
+
+// DiskUsage calculates the disk usage of the local directory dir
+// with specified options opts.
+//
+// It works like function github.com/donyori/gogo/filesys.DiskUsage
+// on os.DirFS(dir) with root ".",
+// so the keys of the field Dirs of the result are slash-separated paths
+// relative to dir ("." for dir itself).
+//
+// If opts are nil or the field SameFile of opts is nil,
+// os.SameFile is used,
+// so that hard links to the same file are counted only once
+// and symbolic links to directories can be followed
+// (if the field FollowSymlinks of opts is true).
+func DiskUsage(dir string, opts *filesys.DiskUsageOptions) (
+	result *filesys.DiskUsageResult, err error) {
+	var o filesys.DiskUsageOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.SameFile == nil {
+		o.SameFile = os.SameFile
+	}
+	result, err = filesys.DiskUsage(os.DirFS(dir), ".", &o)
+	return result, errors.AutoWrap(err)
+}
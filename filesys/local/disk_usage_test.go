@@ -0,0 +1,69 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package local_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/donyori/gogo/filesys"
+	"github.com/donyori/gogo/filesys/local"
+)
+
+func TestDiskUsage(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal("mkdir -", err)
+	}
+	writeTestFile(t, filepath.Join(dir, "a.txt"), make([]byte, 100), 0o644)
+	writeTestFile(t, filepath.Join(sub, "b.txt"), make([]byte, 10), 0o644)
+	err := os.Link(filepath.Join(dir, "a.txt"), filepath.Join(sub, "hard.txt"))
+	if err != nil {
+		t.Skip("cannot create hard link -", err)
+	}
+	if err = os.Symlink("..", filepath.Join(sub, "parent")); err != nil {
+		t.Skip("cannot create symlink -", err)
+	}
+	if err = os.Symlink("b.txt", filepath.Join(sub, "link.txt")); err != nil {
+		t.Skip("cannot create symlink -", err)
+	}
+
+	for _, numWorker := range []int{0, 4} {
+		result, err := local.DiskUsage(dir, &filesys.DiskUsageOptions{
+			NumWorker:      numWorker,
+			FollowSymlinks: true,
+		})
+		if err != nil {
+			t.Fatalf("numWorker=%d - %v", numWorker, err)
+		}
+		// a.txt and sub/hard.txt are the same file;
+		// sub/link.txt is the same as sub/b.txt;
+		// sub/parent points to dir, so it is counted as a link itself.
+		if result.Total.NumDir != 1 || result.Total.NumFile != 3 {
+			t.Errorf("numWorker=%d - got %+v; want NumDir 1, NumFile 3",
+				numWorker, result.Total)
+		}
+		if _, ok := result.Dirs["sub"]; !ok {
+			t.Errorf("numWorker=%d - sub not in Dirs %v",
+				numWorker, result.Dirs)
+		}
+	}
+}
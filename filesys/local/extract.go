@@ -0,0 +1,561 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package local
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/filesys"
+)
+
+// CollisionPolicy specifies how to handle an archive entry
+// whose destination already exists when extracting an archive.
+type CollisionPolicy uint8
+
+const (
+	// CollisionError indicates to report an error that satisfies
+	// errors.Is(err, fs.ErrExist) when the destination exists.
+	CollisionError CollisionPolicy = iota
+
+	// CollisionSkip indicates to skip the entry
+	// and keep the existing file.
+	CollisionSkip
+
+	// CollisionOverwrite indicates to remove the existing file
+	// and extract the entry.
+	CollisionOverwrite
+
+	maxCollisionPolicy = CollisionOverwrite
+)
+
+// String returns the name of the collision policy.
+func (p CollisionPolicy) String() string {
+	switch p {
+	case CollisionError:
+		return "Error"
+	case CollisionSkip:
+		return "Skip"
+	case CollisionOverwrite:
+		return "Overwrite"
+	}
+	return fmt.Sprintf("CollisionPolicy(%d)", uint8(p))
+}
+
+// ExtractTarOptions are options for ExtractTar.
+//
+// A nil *ExtractTarOptions is equivalent to a zero-value ExtractTarOptions.
+type ExtractTarOptions struct {
+	// True if not to restore the permission bits recorded in the archive.
+	//
+	// If true, files are created with permission 0666 (before umask),
+	// and directories are created with permission 0777 (before umask).
+	IgnorePerm bool
+
+	// True if not to restore the modification times recorded in the archive.
+	IgnoreModTime bool
+
+	// Collision specifies how to handle the entries
+	// whose destinations already exist,
+	// including those extracted earlier from the same archive.
+	//
+	// Existing directories are always merged with the directory entries,
+	// and are never replaced by non-directory entries:
+	// such an entry causes an error that satisfies
+	// errors.Is(err, fs.ErrExist) unless Collision is CollisionSkip.
+	Collision CollisionPolicy
+
+	// Filter, if not nil, is called for each entry in the archive.
+	// Only the entries for which Filter returns true are extracted.
+	Filter func(hdr *tar.Header) bool
+}
+
+// ExtractTar extracts the tar archive read by r into the directory destDir
+// with options opts.
+//
+// It restores regular files, directories, symbolic links, and hard links,
+// along with their permission bits and modification times
+// (unless disabled by opts).
+// The modification times of symbolic links are not restored.
+// Other types of entries (e.g., character devices and FIFOs) are skipped.
+// If destDir does not exist, it is created (along with its parents).
+//
+// ExtractTar guarantees that no file is written outside destDir:
+//   - Entries with absolute names or names going beyond destDir
+//     (e.g., "../a") are rejected.
+//   - Files are never written through a symbolic link,
+//     including the ones extracted from the archive.
+//   - Symbolic links with absolute targets or relative targets
+//     lexically going beyond destDir are rejected,
+//     as are symbolic links whose targets pass through
+//     other symbolic links (which may resolve beyond destDir),
+//     and symbolic links that other extracted symbolic links pass through.
+//   - Hard links must refer to a regular file within destDir.
+//
+// The rejected entries cause an error wrapping
+// github.com/donyori/gogo/filesys.ErrPathEscape.
+// (To test whether err is filesys.ErrPathEscape, use function errors.Is.)
+//
+// ExtractTar stops at the first error.
+// The entries extracted before the error remain in destDir.
+//
+// r must be a reader on a tar archive positioned before the entries
+// to be extracted (usually, a newly created reader).
+// If r is not archived by tar or is opened in raw mode,
+// ExtractTar reports github.com/donyori/gogo/filesys.ErrNotTar.
+//
+// ExtractTar panics if r is nil.
+func ExtractTar(r filesys.Reader, destDir string, opts *ExtractTarOptions) error {
+	if r == nil {
+		panic(errors.AutoMsg("filesys.Reader is nil"))
+	} else if !r.TarEnabled() {
+		return errors.AutoWrap(filesys.ErrNotTar)
+	}
+	o := new(ExtractTarOptions)
+	if opts != nil {
+		*o = *opts
+	}
+	if o.Collision > maxCollisionPolicy {
+		return errors.AutoWrap(fmt.Errorf(
+			"option Collision (%v) is unknown", o.Collision))
+	}
+	e, err := newExtractor(destDir, o.Collision)
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	for {
+		hdr, err := r.TarNext()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return errors.AutoWrap(err)
+		}
+		if o.Filter != nil && !o.Filter(hdr) {
+			continue
+		}
+		err = e.extractTarEntry(r, hdr, o)
+		if err != nil {
+			return errors.AutoWrap(err)
+		}
+	}
+	return errors.AutoWrap(e.finishDirs(!o.IgnorePerm, !o.IgnoreModTime))
+}
+
+// extractor holds the states of an extraction operation.
+type extractor struct {
+	dest      string
+	collision CollisionPolicy
+	buf       []byte
+	safeDirs  map[string]bool // slash-separated relative paths of verified directories
+	linkDirs  map[string]bool // slash-separated relative paths that the targets of the extracted symbolic links pass through
+	dirs      []extractedDir  // directories whose metadata are to be set
+}
+
+// extractedDir consists of a directory path, its permission bits,
+// and its modification time.
+type extractedDir struct {
+	path    string
+	perm    fs.FileMode
+	modTime time.Time
+}
+
+// newExtractor creates destDir (if necessary) and a new extractor on it.
+func newExtractor(destDir string, collision CollisionPolicy) (*extractor, error) {
+	if destDir == "" {
+		return nil, errors.New("destDir is empty")
+	}
+	dest := filepath.Clean(destDir)
+	err := os.MkdirAll(dest, 0777)
+	if err != nil {
+		return nil, err
+	}
+	return &extractor{
+		dest:      dest,
+		collision: collision,
+		safeDirs:  map[string]bool{".": true},
+		linkDirs:  make(map[string]bool),
+	}, nil
+}
+
+// cleanEntryName cleans the entry name in the archive
+// and returns the slash-separated relative path of the entry.
+//
+// It reports an error wrapping filesys.ErrPathEscape
+// if name is absolute or goes beyond the destination directory.
+func cleanEntryName(name string) (string, error) {
+	rel := path.Clean(name)
+	if path.IsAbs(name) || !filepath.IsLocal(filepath.FromSlash(rel)) {
+		return "", &fs.PathError{
+			Op:   "extract",
+			Path: name,
+			Err:  filesys.ErrPathEscape,
+		}
+	}
+	return rel, nil
+}
+
+// fullPath returns the path in the local file system
+// corresponding to the slash-separated relative path rel.
+func (e *extractor) fullPath(rel string) string {
+	return filepath.Join(e.dest, filepath.FromSlash(rel))
+}
+
+// ensureDir makes sure that the directory rel exists
+// and none of it and its parents is a symbolic link.
+// The missing directories are created with permission 0777 (before umask).
+func (e *extractor) ensureDir(rel string) error {
+	return e.checkDir(rel, true)
+}
+
+// checkDir checks that the directory rel exists
+// and none of it and its parents is a symbolic link.
+//
+// If create is true, the missing directories are created
+// with permission 0777 (before umask).
+// Otherwise, the missing directories cause an error
+// that satisfies errors.Is(err, fs.ErrNotExist).
+func (e *extractor) checkDir(rel string, create bool) error {
+	if e.safeDirs[rel] {
+		return nil
+	}
+	err := e.checkDir(path.Dir(rel), create)
+	if err != nil {
+		return err
+	}
+	full := e.fullPath(rel)
+	info, err := os.Lstat(full)
+	switch {
+	case create && errors.Is(err, fs.ErrNotExist):
+		err = os.Mkdir(full, 0777)
+		if err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	case info.Mode()&fs.ModeSymlink != 0:
+		return &fs.PathError{
+			Op:   "extract",
+			Path: full,
+			Err:  filesys.ErrPathEscape,
+		}
+	case !info.IsDir():
+		return &fs.PathError{
+			Op:   "extract",
+			Path: full,
+			Err:  errors.New("not a directory"),
+		}
+	}
+	e.safeDirs[rel] = true
+	return nil
+}
+
+// prepare makes sure the parent directory of rel exists
+// and handles the existing file at rel according to the collision policy.
+//
+// It returns the full path of rel and whether to extract the entry.
+func (e *extractor) prepare(rel string) (full string, ok bool, err error) {
+	err = e.ensureDir(path.Dir(rel))
+	if err != nil {
+		return
+	}
+	full = e.fullPath(rel)
+	info, err := os.Lstat(full)
+	if errors.Is(err, fs.ErrNotExist) {
+		return full, true, nil
+	} else if err != nil {
+		return
+	}
+	switch {
+	case e.collision == CollisionSkip:
+		return full, false, nil
+	case e.collision == CollisionOverwrite && !info.IsDir():
+		err = os.Remove(full)
+		return full, err == nil, err
+	}
+	return full, false, &fs.PathError{
+		Op:   "extract",
+		Path: full,
+		Err:  fs.ErrExist,
+	}
+}
+
+// extractDir extracts the directory rel.
+func (e *extractor) extractDir(
+	rel string,
+	perm fs.FileMode,
+	modTime time.Time,
+) error {
+	if rel == "." {
+		return nil // do not change the metadata of the destination directory
+	}
+	full := e.fullPath(rel)
+	if info, err := os.Lstat(full); err == nil && !info.IsDir() {
+		switch e.collision {
+		case CollisionSkip:
+			return nil
+		case CollisionOverwrite:
+			err = os.Remove(full)
+			if err != nil {
+				return err
+			}
+		default:
+			return &fs.PathError{
+				Op:   "extract",
+				Path: full,
+				Err:  fs.ErrExist,
+			}
+		}
+	}
+	err := e.ensureDir(rel)
+	if err != nil {
+		return err
+	}
+	e.dirs = append(e.dirs, extractedDir{
+		path:    full,
+		perm:    perm,
+		modTime: modTime,
+	})
+	return nil
+}
+
+// extractRegular extracts a regular file rel with the content read from r.
+func (e *extractor) extractRegular(
+	rel string,
+	r io.Reader,
+	perm fs.FileMode,
+	modTime time.Time,
+	setPerm bool,
+	setModTime bool,
+) error {
+	full, ok, err := e.prepare(rel)
+	if err != nil || !ok {
+		return err
+	}
+	if e.buf == nil {
+		e.buf = make([]byte, 32<<10)
+	}
+	return writeRegular(full, r, perm, modTime, setPerm, setModTime, e.buf)
+}
+
+// writeRegular creates the regular file full
+// with the content read from r, using buf as the copy buffer.
+// The file must not exist.
+//
+// It is safe for concurrent use by multiple goroutines
+// with different full and buf.
+func writeRegular(
+	full string,
+	r io.Reader,
+	perm fs.FileMode,
+	modTime time.Time,
+	setPerm bool,
+	setModTime bool,
+	buf []byte,
+) (err error) {
+	if !setPerm {
+		perm = 0666
+	}
+	// Always use O_EXCL to avoid writing through
+	// a symbolic link created concurrently.
+	f, err := os.OpenFile(full, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return
+	}
+	defer func(f *os.File) {
+		closeErr := f.Close()
+		if err == nil {
+			err = closeErr
+		}
+		if err == nil && setModTime {
+			err = os.Chtimes(full, time.Time{}, modTime)
+		}
+	}(f)
+	_, err = io.CopyBuffer(f, r, buf)
+	if err == nil && setPerm {
+		// Apply the permission regardless of umask.
+		err = f.Chmod(perm)
+	}
+	return
+}
+
+// extractSymlink extracts a symbolic link rel with the specified target.
+//
+// The target must lexically stay within the destination directory,
+// and must not pass through any symbolic link.
+// Besides, rel must not be a path that the targets of
+// the symbolic links extracted earlier pass through.
+// Otherwise, the symbolic links may resolve beyond
+// the destination directory when followed in a chain.
+func (e *extractor) extractSymlink(rel, target string) error {
+	escapeErr := &fs.PathError{
+		Op:   "extract",
+		Path: rel + " -> " + target,
+		Err:  filesys.ErrPathEscape,
+	}
+	linkTarget := filepath.FromSlash(target)
+	if target == "" || filepath.IsAbs(linkTarget) || path.IsAbs(target) ||
+		!filepath.IsLocal(filepath.Join(
+			filepath.FromSlash(path.Dir(rel)), linkTarget)) ||
+		e.linkDirs[rel] {
+		return escapeErr
+	}
+	dirs := symlinkTargetDirs(path.Dir(rel), target)
+	for _, dir := range dirs {
+		info, err := os.Lstat(e.fullPath(dir))
+		if err == nil && info.Mode()&fs.ModeSymlink != 0 {
+			return escapeErr
+		} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	full, ok, err := e.prepare(rel)
+	if err != nil || !ok {
+		return err
+	}
+	err = os.Symlink(linkTarget, full)
+	if err == nil {
+		for _, dir := range dirs {
+			e.linkDirs[dir] = true
+		}
+	}
+	return err
+}
+
+// symlinkTargetDirs returns the slash-separated relative paths
+// that the target of a symbolic link in the directory dir passes through
+// as directories when resolved lexically.
+//
+// The target must lexically stay within the destination directory.
+func symlinkTargetDirs(dir, target string) []string {
+	elems := strings.Split(target, "/")
+	var dirs []string
+	cur := dir
+	for _, elem := range elems[:len(elems)-1] {
+		switch elem {
+		case "", ".":
+		case "..":
+			cur = path.Dir(cur)
+		default:
+			cur = path.Join(cur, elem)
+			dirs = append(dirs, cur)
+		}
+	}
+	return dirs
+}
+
+// extractHardLink extracts a hard link rel to the entry target.
+func (e *extractor) extractHardLink(rel, target string) error {
+	targetRel, err := cleanEntryName(target)
+	if err != nil {
+		return err
+	}
+	// Only check the parent directories of the target
+	// without creating them, as the target must already exist.
+	err = e.checkDir(path.Dir(targetRel), false)
+	if err != nil {
+		return err
+	}
+	targetFull := e.fullPath(targetRel)
+	info, err := os.Lstat(targetFull)
+	if err != nil {
+		return err
+	} else if !info.Mode().IsRegular() {
+		return &fs.PathError{
+			Op:   "extract",
+			Path: rel,
+			Err:  errors.New("hard link target is not a regular file"),
+		}
+	}
+	full, ok, err := e.prepare(rel)
+	if err != nil || !ok {
+		return err
+	}
+	return os.Link(targetFull, full)
+}
+
+// finishDirs sets the permission bits (if setPerm is true)
+// and modification times (if setModTime is true)
+// of the extracted directories.
+//
+// It must be called after all entries are extracted
+// because creating files in the directories changes their modification times,
+// and the permission bits may forbid creating files.
+func (e *extractor) finishDirs(setPerm, setModTime bool) error {
+	// Process the deeper directories first.
+	for _, d := range slices.Backward(e.dirs) {
+		if setPerm {
+			err := os.Chmod(d.path, d.perm)
+			if err != nil {
+				return err
+			}
+		}
+		if setModTime {
+			err := os.Chtimes(d.path, time.Time{}, d.modTime)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// extractTarEntry extracts the tar entry with header hdr,
+// whose content is read from r.
+func (e *extractor) extractTarEntry(
+	r io.Reader,
+	hdr *tar.Header,
+	opts *ExtractTarOptions,
+) error {
+	switch hdr.Typeflag {
+	case tar.TypeReg, tar.TypeDir, tar.TypeSymlink, tar.TypeLink:
+	default:
+		return nil // skip other types
+	}
+	rel, err := cleanEntryName(hdr.Name)
+	if err != nil {
+		return err
+	}
+	perm := fs.FileMode(hdr.Mode).Perm()
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		return e.extractDir(rel, perm, hdr.ModTime)
+	case tar.TypeSymlink:
+		return e.extractSymlink(rel, hdr.Linkname)
+	case tar.TypeLink:
+		return e.extractHardLink(rel, hdr.Linkname)
+	}
+	if rel == "." {
+		return &fs.PathError{
+			Op:   "extract",
+			Path: hdr.Name,
+			Err:  fs.ErrInvalid,
+		}
+	}
+	return e.extractRegular(
+		rel, r, perm, hdr.ModTime, !opts.IgnorePerm, !opts.IgnoreModTime)
+}
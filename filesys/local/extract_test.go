@@ -0,0 +1,277 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package local_test
+
+import (
+	"archive/tar"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/filesys"
+	"github.com/donyori/gogo/filesys/local"
+)
+
+func TestExtractTar(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks and permission bits are not fully supported on Windows")
+	}
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	name := writeTestTar(t, []tarTestEntry{
+		{&tar.Header{Typeflag: tar.TypeDir, Name: "dir/", Mode: 0750, ModTime: modTime}, ""},
+		{&tar.Header{Typeflag: tar.TypeReg, Name: "dir/a.txt", Mode: 0640, ModTime: modTime}, "file a"},
+		{&tar.Header{Typeflag: tar.TypeReg, Name: "./b/c.txt", Mode: 0600, ModTime: modTime}, "file c"},
+		{&tar.Header{Typeflag: tar.TypeSymlink, Name: "dir/link", Linkname: "a.txt", ModTime: modTime}, ""},
+		{&tar.Header{Typeflag: tar.TypeLink, Name: "hard.txt", Linkname: "dir/a.txt", ModTime: modTime}, ""},
+		{&tar.Header{Typeflag: tar.TypeFifo, Name: "fifo", Mode: 0600, ModTime: modTime}, ""},
+	})
+	dest := filepath.Join(t.TempDir(), "dest")
+	extractTestTar(t, name, dest, nil)
+
+	checkTestFile(t, filepath.Join(dest, "dir", "a.txt"), []byte("file a"))
+	checkTestFile(t, filepath.Join(dest, "b", "c.txt"), []byte("file c"))
+	checkTestFile(t, filepath.Join(dest, "hard.txt"), []byte("file a"))
+	for _, x := range []struct {
+		name string
+		perm fs.FileMode
+	}{
+		{"dir", 0750},
+		{filepath.Join("dir", "a.txt"), 0640},
+		{filepath.Join("b", "c.txt"), 0600},
+	} {
+		info, err := os.Stat(filepath.Join(dest, x.name))
+		if err != nil {
+			t.Error("stat -", err)
+			continue
+		}
+		if perm := info.Mode().Perm(); perm != x.perm {
+			t.Errorf("%s - got perm %v; want %v", x.name, perm, x.perm)
+		}
+		if !info.ModTime().Equal(modTime) {
+			t.Errorf("%s - got mod time %v; want %v",
+				x.name, info.ModTime(), modTime)
+		}
+	}
+	if s, err := os.Readlink(filepath.Join(dest, "dir", "link")); err != nil {
+		t.Error("read link -", err)
+	} else if s != "a.txt" {
+		t.Errorf("got link target %q; want %q", s, "a.txt")
+	}
+	if _, err := os.Lstat(filepath.Join(dest, "fifo")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("fifo - got %v; want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestExtractTar_Collision(t *testing.T) {
+	name := writeTestTar(t, []tarTestEntry{
+		{&tar.Header{Typeflag: tar.TypeReg, Name: "a.txt", Mode: 0600}, "new"},
+	})
+	testCases := []struct {
+		collision local.CollisionPolicy
+		wantErr   error
+		want      string
+	}{
+		{local.CollisionError, fs.ErrExist, "old"},
+		{local.CollisionSkip, nil, "old"},
+		{local.CollisionOverwrite, nil, "new"},
+	}
+	for _, tc := range testCases {
+		t.Run("collision="+tc.collision.String(), func(t *testing.T) {
+			dest := t.TempDir()
+			file := filepath.Join(dest, "a.txt")
+			writeTestFile(t, file, []byte("old"), 0600)
+			r, err := local.Read(name, nil)
+			if err != nil {
+				t.Fatal("create reader -", err)
+			}
+			defer func(r filesys.Reader) {
+				_ = r.Close() // ignore error
+			}(r)
+			err = local.ExtractTar(r, dest, &local.ExtractTarOptions{
+				Collision: tc.collision,
+			})
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("got error %v; want %v", err, tc.wantErr)
+			}
+			checkTestFile(t, file, []byte(tc.want))
+		})
+	}
+}
+
+func TestExtractTar_PathEscape(t *testing.T) {
+	testCases := []struct {
+		name    string
+		entries []tarTestEntry
+	}{
+		{"dotdot", []tarTestEntry{
+			{&tar.Header{Typeflag: tar.TypeReg, Name: "../evil.txt", Mode: 0600}, "evil"},
+		}},
+		{"absolute", []tarTestEntry{
+			{&tar.Header{Typeflag: tar.TypeReg, Name: "/evil.txt", Mode: 0600}, "evil"},
+		}},
+		{"symlinkAbs", []tarTestEntry{
+			{&tar.Header{Typeflag: tar.TypeSymlink, Name: "link", Linkname: "/etc"}, ""},
+		}},
+		{"symlinkDotdot", []tarTestEntry{
+			{&tar.Header{Typeflag: tar.TypeSymlink, Name: "dir/link", Linkname: "../.."}, ""},
+		}},
+		{"hardLinkDotdot", []tarTestEntry{
+			{&tar.Header{Typeflag: tar.TypeLink, Name: "hard", Linkname: "../outside.txt"}, ""},
+		}},
+		{"symlinkChain", []tarTestEntry{
+			{&tar.Header{Typeflag: tar.TypeSymlink, Name: "sub/up", Linkname: ".."}, ""},
+			{&tar.Header{Typeflag: tar.TypeSymlink, Name: "sub/up2", Linkname: "up/.."}, ""},
+		}},
+		{"symlinkChainReversed", []tarTestEntry{
+			{&tar.Header{Typeflag: tar.TypeSymlink, Name: "sub/up2", Linkname: "up/.."}, ""},
+			{&tar.Header{Typeflag: tar.TypeSymlink, Name: "sub/up", Linkname: ".."}, ""},
+		}},
+		{"throughSymlink", []tarTestEntry{
+			{&tar.Header{Typeflag: tar.TypeDir, Name: "sub/", Mode: 0755}, ""},
+			{&tar.Header{Typeflag: tar.TypeSymlink, Name: "link", Linkname: "sub"}, ""},
+			{&tar.Header{Typeflag: tar.TypeReg, Name: "link/evil.txt", Mode: 0600}, "evil"},
+		}},
+	}
+	for _, tc := range testCases {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			name := writeTestTar(t, tc.entries)
+			dir := t.TempDir()
+			dest := filepath.Join(dir, "dest")
+			r, err := local.Read(name, nil)
+			if err != nil {
+				t.Fatal("create reader -", err)
+			}
+			defer func(r filesys.Reader) {
+				_ = r.Close() // ignore error
+			}(r)
+			err = local.ExtractTar(r, dest, nil)
+			if !errors.Is(err, filesys.ErrPathEscape) {
+				t.Errorf("got %v; want %v", err, filesys.ErrPathEscape)
+			}
+			for _, f := range []string{
+				filepath.Join(dir, "evil.txt"),
+				filepath.Join(dest, "sub", "evil.txt"),
+			} {
+				if _, err := os.Lstat(f); !errors.Is(err, fs.ErrNotExist) {
+					t.Errorf("%s - got %v; want %v", f, err, fs.ErrNotExist)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractTar_HardLinkMissingTarget(t *testing.T) {
+	name := writeTestTar(t, []tarTestEntry{
+		{&tar.Header{Typeflag: tar.TypeLink, Name: "hard", Linkname: "missing/a.txt"}, ""},
+	})
+	dest := t.TempDir()
+	r, err := local.Read(name, nil)
+	if err != nil {
+		t.Fatal("create reader -", err)
+	}
+	defer func(r filesys.Reader) {
+		_ = r.Close() // ignore error
+	}(r)
+	err = local.ExtractTar(r, dest, nil)
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got %v; want %v", err, fs.ErrNotExist)
+	}
+	missing := filepath.Join(dest, "missing")
+	if _, err := os.Lstat(missing); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("%s - got %v; want %v", missing, err, fs.ErrNotExist)
+	}
+}
+
+func TestExtractTar_NotTar(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "a.txt")
+	writeTestFile(t, name, []byte("not a tar"), 0600)
+	r, err := local.Read(name, nil)
+	if err != nil {
+		t.Fatal("create reader -", err)
+	}
+	defer func(r filesys.Reader) {
+		_ = r.Close() // ignore error
+	}(r)
+	err = local.ExtractTar(r, t.TempDir(), nil)
+	if !errors.Is(err, filesys.ErrNotTar) {
+		t.Errorf("got %v; want %v", err, filesys.ErrNotTar)
+	}
+}
+
+// tarTestEntry consists of a tar header and the entry body.
+type tarTestEntry struct {
+	hdr  *tar.Header
+	body string
+}
+
+// writeTestTar writes a tar archive with the specified entries
+// to a temporary file and returns the file name.
+func writeTestTar(t *testing.T, entries []tarTestEntry) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "test.tar")
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal("create tar file -", err)
+	}
+	defer func(f *os.File) {
+		if err := f.Close(); err != nil {
+			t.Fatal("close tar file -", err)
+		}
+	}(f)
+	tw := tar.NewWriter(f)
+	for _, entry := range entries {
+		entry.hdr.Size = int64(len(entry.body))
+		if err = tw.WriteHeader(entry.hdr); err != nil {
+			t.Fatal("write tar header -", err)
+		}
+		if _, err = tw.Write([]byte(entry.body)); err != nil {
+			t.Fatal("write tar body -", err)
+		}
+	}
+	if err = tw.Close(); err != nil {
+		t.Fatal("close tar writer -", err)
+	}
+	return name
+}
+
+// extractTestTar extracts the tar archive name into dest with opts.
+func extractTestTar(
+	t *testing.T,
+	name string,
+	dest string,
+	opts *local.ExtractTarOptions,
+) {
+	t.Helper()
+	r, err := local.Read(name, nil)
+	if err != nil {
+		t.Fatal("create reader -", err)
+	}
+	defer func(r filesys.Reader) {
+		if err := r.Close(); err != nil {
+			t.Error("close reader -", err)
+		}
+	}(r)
+	if err = local.ExtractTar(r, dest, opts); err != nil {
+		t.Fatal("extract -", err)
+	}
+}
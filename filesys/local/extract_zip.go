@@ -0,0 +1,378 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package local
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+
+	"github.com/donyori/gogo/concurrency"
+	"github.com/donyori/gogo/concurrency/framework/jobsched"
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/filesys"
+)
+
+// maxZipSymlinkTargetLen is the maximum length of the target
+// of a symbolic link in the ZIP archive accepted by ExtractZip.
+const maxZipSymlinkTargetLen = 4096
+
+// ExtractZipOptions are options for ExtractZip.
+//
+// A nil *ExtractZipOptions is equivalent to a zero-value ExtractZipOptions.
+type ExtractZipOptions struct {
+	// NumWorker is the number of goroutines to extract the regular files.
+	//
+	// If NumWorker is 0 or 1, the files are extracted sequentially
+	// in the calling goroutine.
+	// If NumWorker is greater than 1, the files are extracted
+	// concurrently by NumWorker goroutines with the framework
+	// github.com/donyori/gogo/concurrency/framework/jobsched.
+	// If NumWorker is negative, the files are extracted concurrently
+	// with the default number of goroutines of the framework.
+	//
+	// Directories and symbolic links are always created sequentially
+	// before extracting the regular files.
+	NumWorker int
+
+	// True if not to restore the permission bits recorded in the archive.
+	//
+	// If true, files are created with permission 0666 (before umask),
+	// and directories are created with permission 0777 (before umask).
+	IgnorePerm bool
+
+	// True if not to restore the modification times recorded in the archive.
+	IgnoreModTime bool
+
+	// Collision specifies how to handle the entries
+	// whose destinations already exist,
+	// including those of the earlier entries in the same archive
+	// (e.g., the entries with duplicate names).
+	//
+	// Existing directories are always merged with the directory entries,
+	// and are never replaced by non-directory entries:
+	// such an entry causes an error that satisfies
+	// errors.Is(err, fs.ErrExist) unless Collision is CollisionSkip.
+	Collision CollisionPolicy
+
+	// Include is a list of patterns (in the syntax of function path.Match)
+	// to select the entries to be extracted.
+	//
+	// A pattern matches an entry if it matches the cleaned entry name
+	// or the name of any parent directory of the entry.
+	// For example, the pattern "docs" matches "docs/a.txt" and "docs/b/c.md".
+	//
+	// If Include is empty, all entries are selected.
+	Include []string
+
+	// Exclude is a list of patterns (in the syntax of function path.Match)
+	// to exclude the entries from extraction,
+	// even if they are selected by Include.
+	//
+	// The patterns are matched in the same way as Include.
+	Exclude []string
+}
+
+// ExtractZip extracts the ZIP archive read by r into the directory destDir
+// with options opts.
+//
+// It restores regular files, directories, and symbolic links,
+// along with their permission bits and modification times
+// (unless disabled by opts).
+// The modification times of symbolic links are not restored.
+// Other types of entries are skipped.
+// If destDir does not exist, it is created (along with its parents).
+//
+// ExtractZip provides the same protection against path traversal
+// as ExtractTar, and reports an error wrapping
+// github.com/donyori/gogo/filesys.ErrPathEscape for the rejected entries.
+// (To test whether err is filesys.ErrPathEscape, use function errors.Is.)
+//
+// ExtractZip stops at the first error
+// (when extracting concurrently, the files being extracted
+// by other goroutines are completed).
+// The entries extracted before the error remain in destDir.
+//
+// If r is not archived by ZIP or is opened in raw mode,
+// ExtractZip reports github.com/donyori/gogo/filesys.ErrNotZip.
+// If any pattern in the options Include and Exclude is malformed,
+// ExtractZip reports an error wrapping path.ErrBadPattern
+// before extracting any entry.
+//
+// ExtractZip panics if r is nil.
+func ExtractZip(r filesys.Reader, destDir string, opts *ExtractZipOptions) error {
+	if r == nil {
+		panic(errors.AutoMsg("filesys.Reader is nil"))
+	}
+	files, err := r.ZipFiles()
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	o := new(ExtractZipOptions)
+	if opts != nil {
+		*o = *opts
+	}
+	if o.Collision > maxCollisionPolicy {
+		return errors.AutoWrap(fmt.Errorf(
+			"option Collision (%v) is unknown", o.Collision))
+	}
+	for _, patterns := range [][]string{o.Include, o.Exclude} {
+		for _, pattern := range patterns {
+			if _, err = path.Match(pattern, ""); err != nil {
+				return errors.AutoWrap(fmt.Errorf(
+					"pattern %q - %w", pattern, err))
+			}
+		}
+	}
+	e, err := newExtractor(destDir, o.Collision)
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	jobs, err := e.prepareZip(files, o)
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	if o.NumWorker == 0 || o.NumWorker == 1 {
+		if len(jobs) > 0 {
+			e.buf = make([]byte, 32<<10)
+		}
+		for _, job := range jobs {
+			err = job.do(o, e.buf)
+			if err != nil {
+				return errors.AutoWrap(err)
+			}
+		}
+	} else if len(jobs) > 0 {
+		err = runZipExtractJobs(jobs, o)
+		if err != nil {
+			return errors.AutoWrap(err)
+		}
+	}
+	return errors.AutoWrap(e.finishDirs(!o.IgnorePerm, !o.IgnoreModTime))
+}
+
+// zipExtractJob is a regular file in the ZIP archive to be extracted.
+type zipExtractJob struct {
+	file *zip.File
+	full string // path of the destination file
+}
+
+// do extracts the file of the job, using buf as the copy buffer.
+func (job *zipExtractJob) do(opts *ExtractZipOptions, buf []byte) error {
+	rc, err := job.file.Open()
+	if err != nil {
+		return err
+	}
+	defer func(rc io.ReadCloser) {
+		_ = rc.Close() // ignore error
+	}(rc)
+	info := job.file.FileInfo()
+	return writeRegular(
+		job.full,
+		rc,
+		info.Mode().Perm(),
+		info.ModTime(),
+		!opts.IgnorePerm,
+		!opts.IgnoreModTime,
+		buf,
+	)
+}
+
+// prepareZip selects the entries in files according to opts,
+// creates the directories and symbolic links,
+// and returns the jobs to extract the regular files.
+//
+// The regular files scheduled but not yet extracted are
+// treated as existing files when applying the collision policy,
+// so that no two jobs write the same file.
+func (e *extractor) prepareZip(
+	files []*zip.File,
+	opts *ExtractZipOptions,
+) (jobs []*zipExtractJob, err error) {
+	scheduled := make(map[string]int) // full paths of the scheduled regular files to their indices in jobs
+	for _, file := range files {
+		rel, err := cleanEntryName(file.Name)
+		if err != nil {
+			return nil, err
+		} else if !zipEntrySelected(rel, opts) {
+			continue
+		}
+		for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+			if full := e.fullPath(dir); scheduled[full] > 0 {
+				return nil, &fs.PathError{
+					Op:   "extract",
+					Path: full,
+					Err:  errors.New("not a directory"),
+				}
+			}
+		}
+		if full := e.fullPath(rel); scheduled[full] > 0 {
+			switch e.collision {
+			case CollisionSkip:
+				continue
+			case CollisionOverwrite:
+				jobs[scheduled[full]-1] = nil
+				delete(scheduled, full)
+			default:
+				return nil, &fs.PathError{
+					Op:   "extract",
+					Path: full,
+					Err:  fs.ErrExist,
+				}
+			}
+		}
+		info := file.FileInfo()
+		mode := info.Mode()
+		switch {
+		case mode.IsDir():
+			perm := mode.Perm()
+			err = e.extractDir(rel, perm, info.ModTime())
+		case mode&fs.ModeSymlink != 0:
+			var target string
+			target, err = readZipSymlinkTarget(file)
+			if err == nil {
+				err = e.extractSymlink(rel, target)
+			}
+		case mode.IsRegular():
+			if rel == "." {
+				return nil, &fs.PathError{
+					Op:   "extract",
+					Path: file.Name,
+					Err:  fs.ErrInvalid,
+				}
+			}
+			var full string
+			var ok bool
+			full, ok, err = e.prepare(rel)
+			if err == nil && ok {
+				jobs = append(jobs, &zipExtractJob{file: file, full: full})
+				scheduled[full] = len(jobs) // index plus 1, to distinguish from absent
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return slices.DeleteFunc(jobs, func(job *zipExtractJob) bool {
+		return job == nil
+	}), nil
+}
+
+// zipEntrySelected reports whether the entry rel is selected
+// by the options Include and Exclude.
+//
+// The patterns must have been checked.
+func zipEntrySelected(rel string, opts *ExtractZipOptions) bool {
+	return (len(opts.Include) == 0 || matchEntry(rel, opts.Include)) &&
+		!matchEntry(rel, opts.Exclude)
+}
+
+// matchEntry reports whether any of patterns matches rel
+// or any parent directory of rel.
+//
+// The patterns must have been checked.
+func matchEntry(rel string, patterns []string) bool {
+	for name := rel; name != "." && name != "/"; name = path.Dir(name) {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readZipSymlinkTarget reads the target of the symbolic link file.
+func readZipSymlinkTarget(file *zip.File) (string, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer func(rc io.ReadCloser) {
+		_ = rc.Close() // ignore error
+	}(rc)
+	target, err := io.ReadAll(io.LimitReader(rc, maxZipSymlinkTargetLen+1))
+	if err != nil {
+		return "", err
+	} else if len(target) > maxZipSymlinkTargetLen {
+		return "", &fs.PathError{
+			Op:   "extract",
+			Path: file.Name,
+			Err:  errors.New("symbolic link target is too long"),
+		}
+	}
+	return string(target), nil
+}
+
+// runZipExtractJobs runs jobs concurrently with the framework jobsched.
+func runZipExtractJobs(jobs []*zipExtractJob, opts *ExtractZipOptions) error {
+	metaJobs := make(
+		[]*jobsched.MetaJob[*zipExtractJob, jobsched.NoProperty], len(jobs))
+	for i := range jobs {
+		metaJobs[i] = &jobsched.MetaJob[*zipExtractJob, jobsched.NoProperty]{
+			Job: jobs[i],
+		}
+	}
+	var err error
+	ctrl := jobsched.NewWithState(
+		func(
+			jobsched.Controller[*zipExtractJob, jobsched.NoProperty, error],
+			int,
+		) []byte {
+			return make([]byte, 32<<10) // copy buffer reused by the worker
+		},
+		nil,
+		func(
+			canceler concurrency.Canceler,
+			_ int,
+			buf []byte,
+			job *zipExtractJob,
+		) (
+			_ []*jobsched.MetaJob[*zipExtractJob, jobsched.NoProperty],
+			feedback error,
+		) {
+			if canceler.Canceled() {
+				return
+			}
+			return nil, job.do(opts, buf)
+		},
+		func(canceler concurrency.Canceler, feedback error) {
+			if feedback != nil && err == nil {
+				err = feedback
+				canceler.Cancel()
+			}
+		},
+		&jobsched.Options[*zipExtractJob, jobsched.NoProperty, error]{
+			NumWorker: max(opts.NumWorker, 0),
+		},
+		metaJobs...,
+	)
+	ctrl.Run()
+	if prs := ctrl.PanicRecords(); len(prs) > 0 {
+		el := errors.NewErrorList(true)
+		for _, pr := range prs {
+			el.Append(pr)
+		}
+		return el.ToError()
+	}
+	return err
+}
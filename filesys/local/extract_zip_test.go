@@ -0,0 +1,277 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package local_test
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/filesys"
+	"github.com/donyori/gogo/filesys/local"
+)
+
+func TestExtractZip(t *testing.T) {
+	modTime := time.Date(2020, 1, 2, 3, 4, 6, 0, time.UTC)
+	entries := []zipTestEntry{
+		{"dir/", 0750 | fs.ModeDir, ""},
+		{"dir/a.txt", 0640, "file a"},
+		{"dir/sub/b.txt", 0600, "file b"},
+		{"docs/readme.md", 0644, "readme"},
+		{"c.log", 0644, "log"},
+	}
+	for i := range 20 {
+		entries = append(entries, zipTestEntry{
+			fmt.Sprintf("many/%02d.txt", i), 0644, fmt.Sprintf("file %d", i),
+		})
+	}
+	name := writeTestZip(t, entries, modTime)
+	testCases := []struct {
+		name    string
+		opts    *local.ExtractZipOptions
+		present []string
+		absent  []string
+	}{
+		{"nil", nil, []string{"dir/a.txt", "dir/sub/b.txt", "docs/readme.md", "c.log", "many/19.txt"}, nil},
+		{"numWorker=4", &local.ExtractZipOptions{NumWorker: 4}, []string{"dir/a.txt", "many/00.txt", "many/19.txt"}, nil},
+		{"numWorker=-1", &local.ExtractZipOptions{NumWorker: -1}, []string{"dir/sub/b.txt", "many/10.txt"}, nil},
+		{"include", &local.ExtractZipOptions{Include: []string{"dir", "*.log"}}, []string{"dir/a.txt", "dir/sub/b.txt", "c.log"}, []string{"docs/readme.md", "many/00.txt"}},
+		{"exclude", &local.ExtractZipOptions{Exclude: []string{"dir/sub", "many/*"}}, []string{"dir/a.txt", "docs/readme.md"}, []string{"dir/sub/b.txt", "many/00.txt"}},
+	}
+	bodyMap := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		bodyMap[entry.name] = entry.body
+	}
+	for _, tc := range testCases {
+		t.Run("opts="+tc.name, func(t *testing.T) {
+			dest := filepath.Join(t.TempDir(), "dest")
+			extractTestZip(t, name, dest, tc.opts)
+			for _, p := range tc.present {
+				checkTestFile(t, filepath.Join(dest, filepath.FromSlash(p)), []byte(bodyMap[p]))
+			}
+			for _, p := range tc.absent {
+				_, err := os.Lstat(filepath.Join(dest, filepath.FromSlash(p)))
+				if !errors.Is(err, fs.ErrNotExist) {
+					t.Errorf("%s - got %v; want %v", p, err, fs.ErrNotExist)
+				}
+			}
+			info, err := os.Stat(filepath.Join(dest, tc.present[0]))
+			if err != nil {
+				t.Fatal("stat -", err)
+			}
+			if !info.ModTime().Equal(modTime) {
+				t.Errorf("got mod time %v; want %v", info.ModTime(), modTime)
+			}
+		})
+	}
+}
+
+func TestExtractZip_Collision(t *testing.T) {
+	name := writeTestZip(t, []zipTestEntry{{"a.txt", 0600, "new"}}, time.Now())
+	testCases := []struct {
+		collision local.CollisionPolicy
+		wantErr   error
+		want      string
+	}{
+		{local.CollisionError, fs.ErrExist, "old"},
+		{local.CollisionSkip, nil, "old"},
+		{local.CollisionOverwrite, nil, "new"},
+	}
+	for _, tc := range testCases {
+		t.Run("collision="+tc.collision.String(), func(t *testing.T) {
+			dest := t.TempDir()
+			file := filepath.Join(dest, "a.txt")
+			writeTestFile(t, file, []byte("old"), 0600)
+			r, err := local.Read(name, nil)
+			if err != nil {
+				t.Fatal("create reader -", err)
+			}
+			defer func(r filesys.Reader) {
+				_ = r.Close() // ignore error
+			}(r)
+			err = local.ExtractZip(r, dest, &local.ExtractZipOptions{
+				NumWorker: 2,
+				Collision: tc.collision,
+			})
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("got error %v; want %v", err, tc.wantErr)
+			}
+			checkTestFile(t, file, []byte(tc.want))
+		})
+	}
+}
+
+func TestExtractZip_Duplicate(t *testing.T) {
+	name := writeTestZip(t, []zipTestEntry{
+		{"a.txt", 0600, "first"},
+		{"b.txt", 0600, "b"},
+		{"a.txt", 0600, "second"},
+	}, time.Now())
+	testCases := []struct {
+		collision local.CollisionPolicy
+		wantErr   error
+		want      string
+	}{
+		{local.CollisionError, fs.ErrExist, ""},
+		{local.CollisionSkip, nil, "first"},
+		{local.CollisionOverwrite, nil, "second"},
+	}
+	for _, tc := range testCases {
+		for _, numWorker := range []int{1, 4} {
+			t.Run(fmt.Sprintf("collision=%s&numWorker=%d",
+				tc.collision, numWorker), func(t *testing.T) {
+				dest := t.TempDir()
+				r, err := local.Read(name, nil)
+				if err != nil {
+					t.Fatal("create reader -", err)
+				}
+				defer func(r filesys.Reader) {
+					_ = r.Close() // ignore error
+				}(r)
+				err = local.ExtractZip(r, dest, &local.ExtractZipOptions{
+					NumWorker: numWorker,
+					Collision: tc.collision,
+				})
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("got error %v; want %v", err, tc.wantErr)
+				}
+				if tc.wantErr == nil {
+					checkTestFile(t, filepath.Join(dest, "a.txt"), []byte(tc.want))
+					checkTestFile(t, filepath.Join(dest, "b.txt"), []byte("b"))
+				}
+			})
+		}
+	}
+}
+
+func TestExtractZip_Invalid(t *testing.T) {
+	testCases := []struct {
+		name    string
+		entries []zipTestEntry
+		opts    *local.ExtractZipOptions
+		wantErr error
+	}{
+		{"dotdot", []zipTestEntry{{"../evil.txt", 0600, "evil"}}, nil, filesys.ErrPathEscape},
+		{"symlinkDotdot", []zipTestEntry{{"link", 0777 | fs.ModeSymlink, "../evil"}}, nil, filesys.ErrPathEscape},
+		{"badPattern", []zipTestEntry{{"a.txt", 0600, "a"}}, &local.ExtractZipOptions{Include: []string{"["}}, path.ErrBadPattern},
+	}
+	for _, tc := range testCases {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			name := writeTestZip(t, tc.entries, time.Now())
+			dir := t.TempDir()
+			r, err := local.Read(name, nil)
+			if err != nil {
+				t.Fatal("create reader -", err)
+			}
+			defer func(r filesys.Reader) {
+				_ = r.Close() // ignore error
+			}(r)
+			err = local.ExtractZip(r, filepath.Join(dir, "dest"), tc.opts)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("got %v; want %v", err, tc.wantErr)
+			}
+			if _, err = os.Lstat(filepath.Join(dir, "evil.txt")); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("evil.txt - got %v; want %v", err, fs.ErrNotExist)
+			}
+		})
+	}
+}
+
+func TestExtractZip_NotZip(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "a.txt")
+	writeTestFile(t, name, []byte("not a zip"), 0600)
+	r, err := local.Read(name, nil)
+	if err != nil {
+		t.Fatal("create reader -", err)
+	}
+	defer func(r filesys.Reader) {
+		_ = r.Close() // ignore error
+	}(r)
+	err = local.ExtractZip(r, t.TempDir(), nil)
+	if !errors.Is(err, filesys.ErrNotZip) {
+		t.Errorf("got %v; want %v", err, filesys.ErrNotZip)
+	}
+}
+
+// zipTestEntry consists of the name, mode, and body of a ZIP entry.
+type zipTestEntry struct {
+	name string
+	mode fs.FileMode
+	body string
+}
+
+// writeTestZip writes a ZIP archive with the specified entries
+// to a temporary file and returns the file name.
+func writeTestZip(t *testing.T, entries []zipTestEntry, modTime time.Time) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "test.zip")
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal("create zip file -", err)
+	}
+	defer func(f *os.File) {
+		if err := f.Close(); err != nil {
+			t.Fatal("close zip file -", err)
+		}
+	}(f)
+	zw := zip.NewWriter(f)
+	for _, entry := range entries {
+		fh := &zip.FileHeader{Name: entry.name, Modified: modTime}
+		fh.SetMode(entry.mode)
+		w, err := zw.CreateHeader(fh)
+		if err != nil {
+			t.Fatal("create zip entry -", err)
+		}
+		if _, err = w.Write([]byte(entry.body)); err != nil {
+			t.Fatal("write zip entry -", err)
+		}
+	}
+	if err = zw.Close(); err != nil {
+		t.Fatal("close zip writer -", err)
+	}
+	return name
+}
+
+// extractTestZip extracts the ZIP archive name into dest with opts.
+func extractTestZip(
+	t *testing.T,
+	name string,
+	dest string,
+	opts *local.ExtractZipOptions,
+) {
+	t.Helper()
+	r, err := local.Read(name, nil)
+	if err != nil {
+		t.Fatal("create reader -", err)
+	}
+	defer func(r filesys.Reader) {
+		if err := r.Close(); err != nil {
+			t.Error("close reader -", err)
+		}
+	}(r)
+	if err = local.ExtractZip(r, dest, opts); err != nil {
+		t.Fatal("extract -", err)
+	}
+}
@@ -0,0 +1,522 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package local
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/flate"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/filesys"
+	"github.com/donyori/gogo/inout"
+)
+
+// RotateOptions are options for function NewRotatingWriter.
+//
+// A nil *RotateOptions is equivalent to a zero-value RotateOptions.
+type RotateOptions struct {
+	// MaxSize is the maximum size of the active file, in bytes.
+	//
+	// Before each write, if the active file is not empty and
+	// the write would make its size exceed MaxSize,
+	// the file is rotated first.
+	// A single write larger than MaxSize is not split.
+	//
+	// If MaxSize is nonpositive, the file is not rotated by size.
+	MaxSize int64
+
+	// MaxAge is the maximum age of the active file.
+	//
+	// The age is measured from the time the active file is opened
+	// by the writer (rather than the time the file is created).
+	// Before each write, if the active file is not empty and
+	// its age reaches MaxAge, the file is rotated first.
+	//
+	// If MaxAge is nonpositive, the file is not rotated by age.
+	MaxAge time.Duration
+
+	// MaxBackups is the maximum number of rotated files to keep.
+	// The oldest ones are removed upon rotation.
+	//
+	// If MaxBackups is nonpositive, all rotated files are kept.
+	MaxBackups int
+
+	// Compress indicates whether to compress the rotated files with gzip.
+	Compress bool
+
+	// Write are the options for writing the active file,
+	// handled the same as in
+	// function github.com/donyori/gogo/filesys.Write,
+	// except that the option Raw is always true
+	// (i.e., the active file is never compressed or archived).
+	Write *filesys.WriteOptions
+}
+
+// RotatingWriter is an implementation of interface
+// github.com/donyori/gogo/filesys.Writer
+// that writes to a local file (called the active file)
+// and rotates it by size or age.
+//
+// Upon rotation, the active file is renamed to name + ".1",
+// the existing backups name + ".<i>" are renamed to name + ".<i+1>",
+// and a new active file is created.
+// If the option Compress is true, the rotated file is then compressed
+// with gzip to name + ".1.gz", and the backups have the suffix ".gz".
+//
+// The active file is written in raw mode,
+// so the methods related to tar and ZIP report
+// github.com/donyori/gogo/filesys.ErrNotTar and
+// github.com/donyori/gogo/filesys.ErrNotZip, respectively.
+// The methods Checksums, BytesWritten, Stats, and FileStat
+// are about the current active file.
+//
+// RotatingWriter is not safe for concurrent use.
+// To use it concurrently, wrap it with
+// function github.com/donyori/gogo/filesys.SyncWriter.
+type RotatingWriter struct {
+	name   string
+	perm   fs.FileMode
+	opts   RotateOptions
+	wOpts  filesys.WriteOptions
+	w      filesys.Writer // writer of the active file
+	size0  int64          // size of the active file when opened
+	opened time.Time      // time when the active file is opened
+}
+
+var _ filesys.Writer = (*RotatingWriter)(nil)
+
+// NewRotatingWriter creates (if necessary) and opens a local file
+// with specified name for appending, which is rotated
+// according to the specified options opts.
+//
+// If the file does not exist, it is created
+// with specified permission perm (before umask).
+// The rotated files are also created with perm.
+//
+// mkDirs indicates whether to make necessary directories
+// before opening the file.
+func NewRotatingWriter(
+	name string,
+	perm fs.FileMode,
+	mkDirs bool,
+	opts *RotateOptions,
+) (rw *RotatingWriter, err error) {
+	if name == "" {
+		return nil, errors.AutoNew("name is empty")
+	}
+	rw = &RotatingWriter{
+		name:  filepath.Clean(name),
+		perm:  perm,
+		wOpts: filesys.WriteOptions{DeflateLv: flate.BestCompression}, // the default options of filesys.Write
+	}
+	if opts != nil {
+		rw.opts = *opts
+		if opts.Write != nil {
+			rw.wOpts = *opts.Write
+		}
+	}
+	rw.opts.Write = nil
+	rw.wOpts.Raw = true
+	if mkDirs {
+		err = os.MkdirAll(filepath.Dir(rw.name), perm)
+		if err != nil {
+			return nil, errors.AutoWrap(err)
+		}
+	}
+	err = rw.open()
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	return
+}
+
+// Name returns the name of the active file.
+func (rw *RotatingWriter) Name() string {
+	return rw.name
+}
+
+// Rotate rotates the active file immediately,
+// even if the active file is empty.
+//
+// If the writer is closed, it does nothing and reports
+// github.com/donyori/gogo/filesys.ErrFileWriterClosed.
+func (rw *RotatingWriter) Rotate() error {
+	if rw.w.Closed() {
+		return errors.AutoWrap(filesys.ErrFileWriterClosed)
+	}
+	return errors.AutoWrap(rw.rotate())
+}
+
+func (rw *RotatingWriter) Close() error {
+	return errors.AutoWrap(rw.w.Close())
+}
+
+func (rw *RotatingWriter) Closed() bool {
+	return rw.w.Closed()
+}
+
+func (rw *RotatingWriter) Write(p []byte) (n int, err error) {
+	err = rw.checkRotate(len(p))
+	if err != nil {
+		return 0, errors.AutoWrap(err)
+	}
+	n, err = rw.w.Write(p)
+	return n, errors.AutoWrap(err)
+}
+
+func (rw *RotatingWriter) MustWrite(p []byte) (n int) {
+	n, err := rw.Write(p)
+	if err != nil {
+		panic(inout.NewWritePanic(errors.AutoWrap(err)))
+	}
+	return
+}
+
+func (rw *RotatingWriter) WriteByte(c byte) error {
+	err := rw.checkRotate(1)
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	return errors.AutoWrap(rw.w.WriteByte(c))
+}
+
+func (rw *RotatingWriter) MustWriteByte(c byte) {
+	err := rw.WriteByte(c)
+	if err != nil {
+		panic(inout.NewWritePanic(errors.AutoWrap(err)))
+	}
+}
+
+func (rw *RotatingWriter) WriteRune(r rune) (size int, err error) {
+	err = rw.checkRotate(4)
+	if err != nil {
+		return 0, errors.AutoWrap(err)
+	}
+	size, err = rw.w.WriteRune(r)
+	return size, errors.AutoWrap(err)
+}
+
+func (rw *RotatingWriter) MustWriteRune(r rune) (size int) {
+	size, err := rw.WriteRune(r)
+	if err != nil {
+		panic(inout.NewWritePanic(errors.AutoWrap(err)))
+	}
+	return
+}
+
+func (rw *RotatingWriter) WriteString(s string) (n int, err error) {
+	err = rw.checkRotate(len(s))
+	if err != nil {
+		return 0, errors.AutoWrap(err)
+	}
+	n, err = rw.w.WriteString(s)
+	return n, errors.AutoWrap(err)
+}
+
+func (rw *RotatingWriter) MustWriteString(s string) (n int) {
+	n, err := rw.WriteString(s)
+	if err != nil {
+		panic(inout.NewWritePanic(errors.AutoWrap(err)))
+	}
+	return
+}
+
+// ReadFrom reads data from r until EOF or error
+// and writes it to the active file.
+//
+// As the size of the data is unknown in advance,
+// the active file is rotated (if necessary) only before reading,
+// and all data read from r is written to the same file.
+func (rw *RotatingWriter) ReadFrom(r io.Reader) (n int64, err error) {
+	err = rw.checkRotate(0)
+	if err != nil {
+		return 0, errors.AutoWrap(err)
+	}
+	n, err = rw.w.ReadFrom(r)
+	return n, errors.AutoWrap(err)
+}
+
+func (rw *RotatingWriter) Printf(format string, args ...any) (n int, err error) {
+	n, err = rw.WriteString(fmt.Sprintf(format, args...))
+	return n, errors.AutoWrap(err)
+}
+
+func (rw *RotatingWriter) MustPrintf(format string, args ...any) (n int) {
+	n, err := rw.Printf(format, args...)
+	if err != nil {
+		panic(inout.NewWritePanic(errors.AutoWrap(err)))
+	}
+	return
+}
+
+func (rw *RotatingWriter) Print(args ...any) (n int, err error) {
+	n, err = rw.WriteString(fmt.Sprint(args...))
+	return n, errors.AutoWrap(err)
+}
+
+func (rw *RotatingWriter) MustPrint(args ...any) (n int) {
+	n, err := rw.Print(args...)
+	if err != nil {
+		panic(inout.NewWritePanic(errors.AutoWrap(err)))
+	}
+	return
+}
+
+func (rw *RotatingWriter) Println(args ...any) (n int, err error) {
+	n, err = rw.WriteString(fmt.Sprintln(args...))
+	return n, errors.AutoWrap(err)
+}
+
+func (rw *RotatingWriter) MustPrintln(args ...any) (n int) {
+	n, err := rw.Println(args...)
+	if err != nil {
+		panic(inout.NewWritePanic(errors.AutoWrap(err)))
+	}
+	return
+}
+
+func (rw *RotatingWriter) Flush() error {
+	return errors.AutoWrap(rw.w.Flush())
+}
+
+func (rw *RotatingWriter) Size() int {
+	return rw.w.Size()
+}
+
+func (rw *RotatingWriter) Buffered() int {
+	return rw.w.Buffered()
+}
+
+func (rw *RotatingWriter) Available() int {
+	return rw.w.Available()
+}
+
+func (rw *RotatingWriter) TarEnabled() bool {
+	return false
+}
+
+func (rw *RotatingWriter) TarWriteHeader(*tar.Header) error {
+	return errors.AutoWrap(filesys.ErrNotTar)
+}
+
+func (rw *RotatingWriter) TarCopy(*tar.Header, io.Reader) error {
+	return errors.AutoWrap(filesys.ErrNotTar)
+}
+
+func (rw *RotatingWriter) TarWriteSparse(*tar.Header, io.ReadSeeker) error {
+	return errors.AutoWrap(filesys.ErrNotTar)
+}
+
+func (rw *RotatingWriter) TarAddFS(fs.FS) error {
+	return errors.AutoWrap(filesys.ErrNotTar)
+}
+
+func (rw *RotatingWriter) TarAddFSWithOptions(
+	fs.FS,
+	*filesys.TarAddFSOptions,
+) error {
+	return errors.AutoWrap(filesys.ErrNotTar)
+}
+
+func (rw *RotatingWriter) ZipEnabled() bool {
+	return false
+}
+
+func (rw *RotatingWriter) ZipCreate(string) error {
+	return errors.AutoWrap(filesys.ErrNotZip)
+}
+
+func (rw *RotatingWriter) ZipCreateHeader(*zip.FileHeader) error {
+	return errors.AutoWrap(filesys.ErrNotZip)
+}
+
+func (rw *RotatingWriter) ZipCreateRaw(*zip.FileHeader) error {
+	return errors.AutoWrap(filesys.ErrNotZip)
+}
+
+func (rw *RotatingWriter) ZipCopy(*zip.File) error {
+	return errors.AutoWrap(filesys.ErrNotZip)
+}
+
+func (rw *RotatingWriter) ZipAddFS(fs.FS) error {
+	return errors.AutoWrap(filesys.ErrNotZip)
+}
+
+func (rw *RotatingWriter) Checksums(upper bool) (
+	uncompressed, compressed []string) {
+	return rw.w.Checksums(upper)
+}
+
+func (rw *RotatingWriter) BytesWritten() int64 {
+	return rw.w.BytesWritten()
+}
+
+func (rw *RotatingWriter) Stats() filesys.Stats {
+	return rw.w.Stats()
+}
+
+func (rw *RotatingWriter) Sync() error {
+	return errors.AutoWrap(rw.w.Sync())
+}
+
+// Options returns a copy of options used for writing the active file.
+func (rw *RotatingWriter) Options() *filesys.WriteOptions {
+	return rw.w.Options()
+}
+
+func (rw *RotatingWriter) FileStat() (info fs.FileInfo, err error) {
+	return rw.w.FileStat()
+}
+
+// open opens the active file and updates rw.w, rw.size0, and rw.opened.
+func (rw *RotatingWriter) open() error {
+	w, err := WriteAppend(rw.name, rw.perm, false, &rw.wOpts)
+	if err != nil {
+		return err
+	}
+	info, err := w.FileStat()
+	if err != nil {
+		_ = w.Close() // ignore error
+		return err
+	}
+	rw.w, rw.size0, rw.opened = w, info.Size(), time.Now()
+	return nil
+}
+
+// checkRotate rotates the active file if it is necessary
+// before writing n bytes.
+//
+// If the writer is closed, it does nothing and returns nil,
+// leaving the error to the subsequent write.
+func (rw *RotatingWriter) checkRotate(n int) error {
+	if rw.w.Closed() {
+		return nil
+	}
+	size := rw.size0 + rw.w.BytesWritten() + int64(rw.w.Buffered())
+	if size == 0 ||
+		(rw.opts.MaxSize <= 0 || size+int64(n) <= rw.opts.MaxSize) &&
+			(rw.opts.MaxAge <= 0 || time.Since(rw.opened) < rw.opts.MaxAge) {
+		return nil
+	}
+	return rw.rotate()
+}
+
+// rotate closes the active file, renames it to the first backup,
+// shifts the existing backups, and opens a new active file.
+// If the option Compress is true, it also compresses the new backup.
+func (rw *RotatingWriter) rotate() error {
+	err := rw.w.Close()
+	if err != nil {
+		return err
+	}
+	err = rw.shiftBackups()
+	if err == nil {
+		err = os.Rename(rw.name, rw.backupName(1, false))
+	}
+	if err != nil {
+		// Reopen the active file so that the writer is still usable.
+		return errors.Combine(err, rw.open())
+	}
+	err = rw.open()
+	if err != nil {
+		return err
+	}
+	if rw.opts.Compress {
+		return rw.compressBackup(rw.backupName(1, false))
+	}
+	return nil
+}
+
+// backupName returns the name of the i-th backup.
+func (rw *RotatingWriter) backupName(i int, gz bool) string {
+	name := rw.name + "." + strconv.Itoa(i)
+	if gz {
+		name += ".gz"
+	}
+	return name
+}
+
+// existingBackup returns the name of the i-th backup if it exists.
+// Otherwise, it returns an empty string.
+func (rw *RotatingWriter) existingBackup(i int) string {
+	for _, gz := range [...]bool{true, false} {
+		name := rw.backupName(i, gz)
+		if _, err := os.Lstat(name); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// shiftBackups removes the backups exceeding the option MaxBackups
+// and renames the i-th backup to the (i+1)-th.
+func (rw *RotatingWriter) shiftBackups() error {
+	var n int
+	for rw.existingBackup(n+1) != "" {
+		n++
+	}
+	if rw.opts.MaxBackups > 0 {
+		for ; n >= rw.opts.MaxBackups; n-- {
+			err := os.Remove(rw.existingBackup(n))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for i := n; i > 0; i-- {
+		old := rw.existingBackup(i)
+		err := os.Rename(old, rw.backupName(i+1, filepath.Ext(old) == ".gz"))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compressBackup compresses the file name with gzip
+// to name + ".gz" and then removes the file name.
+func (rw *RotatingWriter) compressBackup(name string) (err error) {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer func(f *os.File) {
+		_ = f.Close() // ignore error
+	}(src)
+	w, err := WriteTrunc(name+".gz", rw.perm, false, nil)
+	if err != nil {
+		return err
+	}
+	_, err = w.ReadFrom(src)
+	err = errors.Combine(err, w.Close())
+	if err != nil {
+		_ = os.Remove(name + ".gz") // ignore error
+		return err
+	}
+	_ = src.Close() // close before removing, required on Windows
+	return os.Remove(name)
+}
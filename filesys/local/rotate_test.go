@@ -0,0 +1,168 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package local_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/filesys"
+	"github.com/donyori/gogo/filesys/local"
+)
+
+func TestRotatingWriter_MaxSize(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	rw, err := local.NewRotatingWriter(name, 0o644, false, &local.RotateOptions{
+		MaxSize:    10,
+		MaxBackups: 2,
+	})
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	for _, line := range []string{"line0001\n", "line0002\n", "line0003\n", "line0004\n"} {
+		if _, err = rw.WriteString(line); err != nil {
+			t.Fatal("write -", err)
+		}
+	}
+	if err = rw.Close(); err != nil {
+		t.Fatal("close -", err)
+	}
+	checkTestFile(t, name, []byte("line0004\n"))
+	checkTestFile(t, name+".1", []byte("line0003\n"))
+	checkTestFile(t, name+".2", []byte("line0002\n"))
+	if _, err = os.Stat(name + ".3"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("backup 3 - got %v; want %v", err, os.ErrNotExist)
+	}
+	if _, err = rw.WriteString("x"); !errors.Is(err, filesys.ErrFileWriterClosed) {
+		t.Errorf("write after close - got %v; want %v",
+			err, filesys.ErrFileWriterClosed)
+	}
+}
+
+func TestRotatingWriter_Append(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	writeTestFile(t, name, []byte("old\n"), 0o644)
+	rw, err := local.NewRotatingWriter(
+		name, 0o644, false, &local.RotateOptions{MaxSize: 8})
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	if _, err = rw.Printf("%s\n", "new"); err != nil {
+		t.Fatal("write -", err)
+	}
+	// The existing file (4 bytes) plus "more\n" (5 bytes) exceeds 8 bytes.
+	if _, err = rw.Println("more"); err != nil {
+		t.Fatal("write -", err)
+	}
+	if err = rw.Close(); err != nil {
+		t.Fatal("close -", err)
+	}
+	checkTestFile(t, name, []byte("more\n"))
+	checkTestFile(t, name+".1", []byte("old\nnew\n"))
+}
+
+func TestRotatingWriter_MaxAgeAndCompress(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "logs", "app.log")
+	rw, err := local.NewRotatingWriter(name, 0o644, true, &local.RotateOptions{
+		MaxAge:   time.Millisecond,
+		Compress: true,
+	})
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	for i, s := range []string{"first\n", "second\n", "third\n"} {
+		if i > 0 {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if _, err = rw.WriteString(s); err != nil {
+			t.Fatal("write -", err)
+		}
+	}
+	if err = rw.Close(); err != nil {
+		t.Fatal("close -", err)
+	}
+	checkTestFile(t, name, []byte("third\n"))
+	for i, want := range []string{"second\n", "first\n"} {
+		gzName := name + "." + strconv.Itoa(i+1) + ".gz"
+		r, err := local.Read(gzName, nil)
+		if err != nil {
+			t.Fatalf("read %s - %v", gzName, err)
+		}
+		data, err := io.ReadAll(r)
+		_ = r.Close()
+		if err != nil {
+			t.Fatalf("read %s - %v", gzName, err)
+		} else if string(data) != want {
+			t.Errorf("%s - got %q; want %q", gzName, data, want)
+		}
+	}
+	entries, err := os.ReadDir(filepath.Dir(name))
+	if err != nil {
+		t.Fatal("read dir -", err)
+	}
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), ".gz") && e.Name() != "app.log" {
+			t.Errorf("unexpected file %s", e.Name())
+		}
+	}
+}
+
+func TestRotatingWriter_Rotate(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	rw, err := local.NewRotatingWriter(name, 0o644, false, nil)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	var w filesys.Writer = filesys.SyncWriter(rw)
+	if w.TarEnabled() || w.ZipEnabled() {
+		t.Error("tar or ZIP enabled")
+	}
+	if err = w.ZipCreate("a"); !errors.Is(err, filesys.ErrNotZip) {
+		t.Errorf("ZipCreate - got %v; want %v", err, filesys.ErrNotZip)
+	}
+	_, err = w.WriteString("a\n")
+	if err == nil {
+		err = rw.Rotate()
+	}
+	if err == nil {
+		_, err = w.WriteString("b\n")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal("close -", err)
+	}
+	checkTestFile(t, name, []byte("b\n"))
+	checkTestFile(t, name+".1", []byte("a\n"))
+	if err = rw.Rotate(); !errors.Is(err, filesys.ErrFileWriterClosed) {
+		t.Errorf("rotate after close - got %v; want %v",
+			err, filesys.ErrFileWriterClosed)
+	}
+}
@@ -0,0 +1,214 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package local
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/filesys"
+)
+
+// WriteSplitZip creates a ZIP archive split across multiple files
+// (also known as a split or multi-volume ZIP archive)
+// with specified name and options opts for writing.
+//
+// name is the name of the last part, which must have the extension ".zip"
+// (e.g., "a.zip").
+// The archive is written to the files with the same name
+// but the extensions ".z01", ".z02", and so on
+// (in the same case as the extension ".zip"),
+// each of which is volumeSize bytes long.
+// When the returned writer is closed,
+// the last file is renamed to name.
+// Existing files are truncated.
+// If the archive is not larger than volumeSize,
+// it is written to name only.
+//
+// The parts are simply the pieces of a regular ZIP archive,
+// so concatenating them in order produces the original archive.
+// The offsets in the archive are relative to the beginning of the archive,
+// not to the beginning of each part as in the PKWARE split format.
+// The archive can be read by
+// github.com/donyori/gogo/filesys.ReadSplitZipFromFS.
+//
+// The files are created with specified permission perm (before umask).
+//
+// mkDirs indicates whether to make necessary directories
+// before creating the files.
+//
+// opts are handled the same as in
+// function github.com/donyori/gogo/filesys.Write.
+//
+// The files are closed when the returned writer is closed.
+//
+// This function panics if volumeSize is nonpositive.
+func WriteSplitZip(
+	name string,
+	volumeSize int64,
+	perm fs.FileMode,
+	mkDirs bool,
+	opts *filesys.WriteOptions,
+) (w filesys.Writer, err error) {
+	if volumeSize <= 0 {
+		panic(errors.AutoMsg(fmt.Sprintf(
+			"volumeSize (%d) is nonpositive", volumeSize)))
+	} else if name == "" {
+		return nil, errors.AutoNew("name is empty")
+	}
+	name = filepath.Clean(name)
+	ext := filepath.Ext(name)
+	if !strings.EqualFold(ext, ".zip") {
+		return nil, errors.AutoNew(`file extension is not ".zip"`)
+	}
+	if mkDirs {
+		err = os.MkdirAll(filepath.Dir(name), perm)
+		if err != nil {
+			return nil, errors.AutoWrap(err)
+		}
+	}
+	f := &splitVolumeFile{
+		name:       name,
+		partFmt:    name[:len(name)-len(ext)] + ".z%02d",
+		volumeSize: volumeSize,
+		perm:       perm,
+	}
+	if ext[1] == 'Z' {
+		f.partFmt = name[:len(name)-len(ext)] + ".Z%02d"
+	}
+	w, err = filesys.Write(f, opts, true)
+	return w, errors.AutoWrap(err)
+}
+
+// splitVolumeFile is a github.com/donyori/gogo/filesys.WritableFile
+// that splits the data written into volumes of a fixed size.
+type splitVolumeFile struct {
+	name       string
+	partFmt    string
+	volumeSize int64
+	perm       fs.FileMode
+	cur        *os.File
+	curName    string
+	curN       int64 // the number of bytes written to the current volume
+	total      int64 // the number of bytes written to all volumes
+	numVolume  int
+	modTime    time.Time
+	closed     bool
+}
+
+func (f *splitVolumeFile) Write(p []byte) (n int, err error) {
+	if f.closed {
+		return 0, errors.AutoWrap(fs.ErrClosed)
+	}
+	for n < len(p) {
+		if f.cur == nil || f.curN >= f.volumeSize {
+			err = f.nextVolume()
+			if err != nil {
+				return n, errors.AutoWrap(err)
+			}
+		}
+		k := int(min(int64(len(p)-n), f.volumeSize-f.curN))
+		var m int
+		m, err = f.cur.Write(p[n : n+k])
+		n, f.curN, f.total = n+m, f.curN+int64(m), f.total+int64(m)
+		if err != nil {
+			return n, errors.AutoWrap(err)
+		}
+	}
+	f.modTime = time.Now()
+	return
+}
+
+// nextVolume closes the current volume (if any) and creates the next one.
+func (f *splitVolumeFile) nextVolume() error {
+	if f.cur != nil {
+		err := f.cur.Close()
+		f.cur = nil
+		if err != nil {
+			return err
+		}
+	}
+	f.numVolume++
+	name := fmt.Sprintf(f.partFmt, f.numVolume)
+	cur, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.perm)
+	if err != nil {
+		return err
+	}
+	f.cur, f.curName, f.curN = cur, name, 0
+	return nil
+}
+
+func (f *splitVolumeFile) Close() error {
+	if f.closed {
+		return errors.AutoWrap(fs.ErrClosed)
+	}
+	f.closed = true
+	if f.cur == nil {
+		cur, err := os.OpenFile(
+			f.name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.perm)
+		if err != nil {
+			return errors.AutoWrap(err)
+		}
+		return errors.AutoWrap(cur.Close())
+	}
+	err := f.cur.Close()
+	f.cur = nil
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	return errors.AutoWrap(os.Rename(f.curName, f.name))
+}
+
+func (f *splitVolumeFile) Stat() (fs.FileInfo, error) {
+	return &splitVolumeFileInfo{f: f}, nil
+}
+
+// splitVolumeFileInfo is the io/fs.FileInfo of splitVolumeFile.
+type splitVolumeFileInfo struct {
+	f *splitVolumeFile
+}
+
+func (info *splitVolumeFileInfo) Name() string {
+	return filepath.Base(info.f.name)
+}
+
+func (info *splitVolumeFileInfo) Size() int64 {
+	return info.f.total
+}
+
+func (info *splitVolumeFileInfo) Mode() fs.FileMode {
+	return info.f.perm.Perm()
+}
+
+func (info *splitVolumeFileInfo) ModTime() time.Time {
+	return info.f.modTime
+}
+
+func (info *splitVolumeFileInfo) IsDir() bool {
+	return false
+}
+
+func (info *splitVolumeFileInfo) Sys() any {
+	return nil
+}
@@ -0,0 +1,134 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package local_test
+
+import (
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/donyori/gogo/filesys"
+	"github.com/donyori/gogo/filesys/local"
+	"github.com/donyori/gogo/randbytes"
+)
+
+func TestWriteSplitZip(t *testing.T) {
+	const VolumeSize int64 = 4 << 10
+	big := randbytes.Make(rand.NewChaCha8(ChaCha8Seed), 13<<10)
+	zipNameBodyMap := map[string][]byte{
+		"zipdir/":              nil,
+		"zipdir/zip file1.txt": []byte("This is ZIP file 1."),
+		"emptydir/":            nil,
+		"13KB.dat":             big,
+	}
+	dir := t.TempDir()
+	name := filepath.Join(dir, "test.zip")
+	w, err := local.WriteSplitZip(name, VolumeSize, 0600, true, nil)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	for zipName, zipBody := range zipNameBodyMap {
+		err = w.ZipCreate(zipName)
+		if err != nil {
+			_ = w.Close() // ignore error
+			t.Fatalf("create %q - %v", zipName, err)
+		}
+		if len(zipBody) > 0 {
+			_, err = w.Write(zipBody)
+			if err != nil {
+				_ = w.Close() // ignore error
+				t.Fatalf("write %q file body - %v", zipName, err)
+			}
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatal("close -", err)
+	}
+
+	// Check the volumes and concatenate them.
+	var whole []byte
+	for i := 1; ; i++ {
+		data, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("test.z%02d", i)))
+		if os.IsNotExist(err) {
+			break
+		} else if err != nil {
+			t.Fatalf("read volume %d - %v", i, err)
+		} else if int64(len(data)) != VolumeSize {
+			t.Errorf("volume %d - got size %d; want %d", i, len(data), VolumeSize)
+		}
+		whole = append(whole, data...)
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal("read last volume -", err)
+	} else if len(data) == 0 || int64(len(data)) > VolumeSize {
+		t.Errorf("last volume - got size %d; want in (0, %d]",
+			len(data), VolumeSize)
+	}
+	whole = append(whole, data...)
+	if int64(len(whole)) <= VolumeSize {
+		t.Fatalf("archive size %d is not greater than volume size %d",
+			len(whole), VolumeSize)
+	}
+	wholeName := filepath.Join(dir, "whole.zip")
+	err = os.WriteFile(wholeName, whole, 0600)
+	if err != nil {
+		t.Fatal("write whole archive -", err)
+	}
+	testZipFile(t, wholeName, zipNameBodyMap)
+
+	// Read it with filesys.ReadSplitZipFromFS.
+	r, err := filesys.ReadSplitZipFromFS(os.DirFS(dir), "test.zip", nil)
+	if err != nil {
+		t.Fatal("read split zip -", err)
+	}
+	defer func(r filesys.Reader) {
+		if err := r.Close(); err != nil {
+			t.Error("close reader -", err)
+		}
+	}(r)
+	f, err := r.ZipOpen("13KB.dat")
+	if err != nil {
+		t.Fatal("zip open -", err)
+	}
+	defer func(f io.Closer) {
+		if err := f.Close(); err != nil {
+			t.Error("close zip file -", err)
+		}
+	}(f)
+	got, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal("read zip file -", err)
+	} else if string(got) != string(big) {
+		t.Errorf("got (len: %d); want (len: %d)", len(got), len(big))
+	}
+}
+
+func TestWriteSplitZip_NotZip(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.tar")
+	w, err := local.WriteSplitZip(name, 1024, 0600, true, nil)
+	if err == nil {
+		_ = w.Close() // ignore error
+		t.Error("got nil error")
+	}
+}
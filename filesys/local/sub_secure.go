@@ -0,0 +1,98 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package local
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/filesys"
+)
+
+// SubSecure returns a filesystem (an io/fs.FS) corresponding to
+// the local directory tree rooted at dir,
+// in which no path escapes from dir through symbolic links.
+//
+// It works like function github.com/donyori/gogo/filesys.SubSecure
+// on os.DirFS(dir) with root ".",
+// except that the symbolic links are always checked
+// (using os.Lstat and os.Readlink),
+// regardless of whether the filesystem returned by os.DirFS
+// supports reading symbolic links in the current Go version.
+// Any symbolic link pointing outside dir causes an error wrapping
+// github.com/donyori/gogo/filesys.ErrPathEscape.
+// (To test whether err is filesys.ErrPathEscape, use function errors.Is.)
+//
+// Note that the check is made before opening the file,
+// so it cannot prevent the symbolic links changed concurrently
+// by others from escaping.
+func SubSecure(dir string) (fs.FS, error) {
+	fsys, err := filesys.SubSecure(&linkDirFS{FS: os.DirFS(dir), dir: dir}, ".")
+	return fsys, errors.AutoWrap(err)
+}
+
+// linkDirFS is a filesystem for the local directory tree rooted at dir,
+// which supports reading symbolic links.
+//
+// It has the same method set as io/fs.ReadLinkFS.
+type linkDirFS struct {
+	fs.FS // the filesystem returned by os.DirFS(dir)
+
+	dir string
+}
+
+func (l *linkDirFS) ReadLink(name string) (string, error) {
+	full, err := l.join("readlink", name)
+	if err != nil {
+		return "", err
+	}
+	target, err := os.Readlink(full)
+	return target, fixPathErr(err, name)
+}
+
+func (l *linkDirFS) Lstat(name string) (fs.FileInfo, error) {
+	full, err := l.join("lstat", name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Lstat(full)
+	return info, fixPathErr(err, name)
+}
+
+// join checks name and returns the corresponding local path.
+//
+// op is the operation name used in the returned *io/fs.PathError.
+func (l *linkDirFS) join(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return filepath.Join(l.dir, filepath.FromSlash(name)), nil
+}
+
+// fixPathErr replaces the path in err (if it is an *io/fs.PathError)
+// with name, so as not to expose the local path.
+func fixPathErr(err error, name string) error {
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		return &fs.PathError{Op: pe.Op, Path: name, Err: pe.Err}
+	}
+	return err
+}
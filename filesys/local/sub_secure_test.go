@@ -0,0 +1,81 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package local_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/donyori/gogo/filesys"
+	"github.com/donyori/gogo/filesys/local"
+)
+
+func TestSubSecure(t *testing.T) {
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "root")
+	if err := os.MkdirAll(filepath.Join(root, "dir"), 0755); err != nil {
+		t.Fatal("make directory -", err)
+	}
+	writeTestFile(t, filepath.Join(root, "dir", "file.txt"), []byte("inside"), 0644)
+	writeTestFile(t, filepath.Join(tmpDir, "secret.txt"), []byte("secret"), 0644)
+	for link, target := range map[string]string{
+		"link-in":      "dir/file.txt",
+		"link-dir":     "dir",
+		"link-out":     "../secret.txt",
+		"link-abs":     filepath.Join(tmpDir, "secret.txt"),
+		"dir/link-out": "../link-up",
+		"link-up":      "..",
+	} {
+		err := os.Symlink(target, filepath.Join(root, filepath.FromSlash(link)))
+		if err != nil {
+			t.Skip("cannot create symlink -", err)
+		}
+	}
+
+	fsys, err := local.SubSecure(root)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	for _, name := range []string{"dir/file.txt", "link-in", "link-dir/file.txt"} {
+		t.Run("name="+name, func(t *testing.T) {
+			data, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				t.Fatal(err)
+			} else if string(data) != "inside" {
+				t.Errorf("got %q; want %q", data, "inside")
+			}
+		})
+	}
+	for _, name := range []string{
+		"link-out",
+		"link-abs",
+		"link-up/secret.txt",
+		"dir/link-out/secret.txt",
+	} {
+		t.Run("name="+name, func(t *testing.T) {
+			_, err := fs.ReadFile(fsys, name)
+			if !errors.Is(err, filesys.ErrPathEscape) {
+				t.Errorf("got %v; want %v", err, filesys.ErrPathEscape)
+			}
+		})
+	}
+}
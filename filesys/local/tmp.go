@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/inout"
 )
 
 // ErrContainsPathSeparator is an error indicating that
@@ -124,6 +125,82 @@ func TmpDir(dir, prefix, suffix string, perm fs.FileMode) (
 	return "", errors.AutoWrap(err)
 }
 
+// TmpWithCloser is like Tmp,
+// but also returns a closer that closes f and removes the file.
+//
+// The closer ignores the error caused by f being closed
+// or the file having been removed, so the client can close f
+// or remove the file in advance.
+// The errors in closing f and in removing the file are combined by
+// function github.com/donyori/gogo/errors.Combine.
+// After the first successful call to the method Close of the closer,
+// subsequent calls do nothing and return nil.
+//
+// It is convenient to compose the cleanup with other errors, for example:
+//
+//	f, closer, err := local.TmpWithCloser("", "prefix-", ".tmp", 0600)
+//	if err != nil {
+//		return err
+//	}
+//	defer func() {
+//		err = errors.Combine(err, closer.Close())
+//	}()
+//
+// If Tmp fails, TmpWithCloser returns a nil f, a nil closer, and the error.
+func TmpWithCloser(dir, prefix, suffix string, perm fs.FileMode) (
+	f *os.File, closer inout.Closer, err error) {
+	f, err = Tmp(dir, prefix, suffix, perm)
+	if err != nil {
+		return nil, nil, errors.AutoWrap(err)
+	}
+	return f, inout.WrapNoErrorCloser(&tmpFileRemover{f: f}), nil
+}
+
+// TmpDirWithCloser is like TmpDir,
+// but also returns a closer that removes the directory
+// and all its contents (by os.RemoveAll).
+//
+// After the first successful call to the method Close of the closer,
+// subsequent calls do nothing and return nil.
+//
+// Like TmpWithCloser, the closer can be composed with other errors
+// by function github.com/donyori/gogo/errors.Combine.
+//
+// If TmpDir fails, TmpDirWithCloser returns an empty name, a nil closer,
+// and the error.
+func TmpDirWithCloser(dir, prefix, suffix string, perm fs.FileMode) (
+	name string, closer inout.Closer, err error) {
+	name, err = TmpDir(dir, prefix, suffix, perm)
+	if err != nil {
+		return "", nil, errors.AutoWrap(err)
+	}
+	return name, inout.WrapNoErrorCloser(tmpDirRemover(name)), nil
+}
+
+// tmpFileRemover closes and removes a temporary file.
+type tmpFileRemover struct {
+	f *os.File
+}
+
+func (tfr *tmpFileRemover) Close() error {
+	closeErr := tfr.f.Close()
+	if errors.Is(closeErr, os.ErrClosed) {
+		closeErr = nil
+	}
+	removeErr := os.Remove(tfr.f.Name())
+	if errors.Is(removeErr, fs.ErrNotExist) {
+		removeErr = nil
+	}
+	return errors.AutoWrap(errors.Combine(closeErr, removeErr))
+}
+
+// tmpDirRemover is the name of a temporary directory to be removed.
+type tmpDirRemover string
+
+func (tdr tmpDirRemover) Close() error {
+	return errors.AutoWrap(os.RemoveAll(string(tdr)))
+}
+
 // checkTmpPrefixAndSuffix checks whether prefix or suffix has a path separator.
 //
 // If a path separator is in prefix or suffix,
@@ -19,7 +19,10 @@
 package local_test
 
 import (
+	"errors"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 
@@ -102,3 +105,61 @@ func TestTmpDir_Sync(t *testing.T) {
 		set[dir] = struct{}{}
 	}
 }
+
+func TestTmpWithCloser(t *testing.T) {
+	tmpRoot := t.TempDir()
+	for _, closeFirst := range []bool{false, true} {
+		f, closer, err := local.TmpWithCloser(tmpRoot, "f.", ".tmp", 0600)
+		if err != nil {
+			t.Fatal("create -", err)
+		}
+		name := f.Name()
+		if _, err = f.WriteString("temporary"); err != nil {
+			t.Error("write -", err)
+		}
+		if closeFirst {
+			if err = f.Close(); err != nil {
+				t.Error("close file -", err)
+			}
+		}
+		if err = closer.Close(); err != nil {
+			t.Errorf("closeFirst=%t - close - %v", closeFirst, err)
+		}
+		if !closer.Closed() {
+			t.Errorf("closeFirst=%t - closer not closed", closeFirst)
+		}
+		if _, err = os.Lstat(name); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("closeFirst=%t - got %v; want %v",
+				closeFirst, err, fs.ErrNotExist)
+		}
+		if err = closer.Close(); err != nil {
+			t.Errorf("closeFirst=%t - close again - %v", closeFirst, err)
+		}
+	}
+
+	_, closer, err := local.TmpWithCloser(tmpRoot, "a/", "", 0600)
+	if !errors.Is(err, local.ErrContainsPathSeparator) {
+		t.Errorf("got %v; want %v", err, local.ErrContainsPathSeparator)
+	}
+	if closer != nil {
+		t.Error("got non-nil closer on error")
+	}
+}
+
+func TestTmpDirWithCloser(t *testing.T) {
+	tmpRoot := t.TempDir()
+	name, closer, err := local.TmpDirWithCloser(tmpRoot, "d.", "", 0700)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	writeTestFile(t, filepath.Join(name, "file.txt"), []byte("file"), 0600)
+	if err = closer.Close(); err != nil {
+		t.Error("close -", err)
+	}
+	if _, err = os.Lstat(name); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got %v; want %v", err, fs.ErrNotExist)
+	}
+	if err = closer.Close(); err != nil {
+		t.Error("close again -", err)
+	}
+}
@@ -0,0 +1,440 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package local
+
+import (
+	"io/fs"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/donyori/gogo/concurrency"
+	"github.com/donyori/gogo/errors"
+)
+
+// WatchOp is a set of file system operations reported by Watcher.
+type WatchOp uint8
+
+const (
+	// WatchCreate indicates that the file is created.
+	WatchCreate WatchOp = 1 << iota
+
+	// WatchWrite indicates that the file content, size,
+	// or modification time is changed.
+	WatchWrite
+
+	// WatchRemove indicates that the file is removed.
+	WatchRemove
+
+	// WatchRename indicates that the file is renamed (or moved)
+	// from WatchEvent.OldName to WatchEvent.Name.
+	WatchRename
+
+	// WatchChmod indicates that the file permission is changed.
+	WatchChmod
+)
+
+// watchOpNames are the names of the operations,
+// in the order of their bits.
+var watchOpNames = [...]string{"CREATE", "WRITE", "REMOVE", "RENAME", "CHMOD"}
+
+// Has reports whether op contains all operations in x.
+func (op WatchOp) Has(x WatchOp) bool {
+	return op&x == x
+}
+
+// String returns the names of the operations in op,
+// separated by "|" (e.g., "CREATE|WRITE").
+//
+// It returns "NONE" if op is 0.
+func (op WatchOp) String() string {
+	if op == 0 {
+		return "NONE"
+	}
+	var names []string
+	for i, name := range watchOpNames {
+		if op&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// WatchEvent is a file system event reported by Watcher.
+type WatchEvent struct {
+	// Name is the path of the file,
+	// in the same form as the path passed to the method Add of Watcher
+	// (joined with the relative path of the file, for files in directories).
+	Name string
+
+	// OldName is the previous path of the file for WatchRename events.
+	// It is empty for other events.
+	OldName string
+
+	// Op is the set of operations that happened on the file.
+	//
+	// Operations on the same file within the debounce interval
+	// are merged into one event.
+	Op WatchOp
+}
+
+// WatcherOptions are options for NewWatcher.
+//
+// A nil *WatcherOptions is equivalent to a zero-value WatcherOptions.
+type WatcherOptions struct {
+	// Recursive indicates whether to watch directories recursively.
+	//
+	// If false, only a watched directory itself
+	// and its direct children are watched.
+	Recursive bool
+
+	// Interval is the time interval for polling the file system.
+	//
+	// If Interval is nonpositive, DefaultWatchInterval is used.
+	Interval time.Duration
+
+	// Debounce is the quiet period for the events on the same file.
+	// An event is not reported until no more operations happen on that file
+	// for the duration Debounce; operations within this period are merged.
+	//
+	// If Debounce is nonpositive, events are reported
+	// as soon as they are detected.
+	Debounce time.Duration
+
+	// Canceler is used to shut down the watcher.
+	//
+	// If Canceler is nil, a new Canceler is created,
+	// which can be got through the method Canceler of Watcher.
+	Canceler concurrency.Canceler
+
+	// OnError, if not nil, is called with the errors encountered
+	// while polling the file system, other than the errors
+	// indicating that the file does not exist.
+	//
+	// It is called in the goroutine of the watcher,
+	// and should not block for a long time.
+	OnError func(err error)
+}
+
+// DefaultWatchInterval is the default polling interval of Watcher.
+const DefaultWatchInterval = 100 * time.Millisecond
+
+// Watcher watches files and directories on the local file system
+// for creation, modification, removal, renaming, and permission changes.
+//
+// Watcher polls the file system periodically,
+// so it works on any platform without native notification support,
+// at the cost of latency (up to the polling interval)
+// and missing changes that are reverted within one interval.
+type Watcher interface {
+	// Add starts watching the specified file or directory.
+	//
+	// The current state of the file (or directory tree) is recorded
+	// immediately; subsequent changes are reported as events.
+	// Adding a path that is already watched does nothing.
+	//
+	// Add reports an error if name does not exist.
+	Add(name string) error
+
+	// Remove stops watching the specified file or directory.
+	//
+	// It does nothing if name is not watched.
+	Remove(name string)
+
+	// Events returns an iterator over the events.
+	//
+	// The iterator blocks until the next event is available,
+	// and ends when the watcher is shut down.
+	// If Events is used by multiple goroutines simultaneously,
+	// each event is yielded to only one of them.
+	Events() iter.Seq[WatchEvent]
+
+	// Canceler returns the Canceler used to shut down the watcher.
+	Canceler() concurrency.Canceler
+}
+
+// NewWatcher creates a new Watcher with options opts,
+// and starts its polling goroutine.
+//
+// The polling goroutine exits after the Canceler of the watcher
+// broadcasts the cancellation signal.
+func NewWatcher(opts *WatcherOptions) Watcher {
+	w := &watcher{
+		roots:   make(map[string]map[string]watchFileState),
+		pending: make(map[string]*pendingWatchEvent),
+		events:  make(chan WatchEvent, 64),
+	}
+	if opts != nil {
+		w.opts = *opts
+	}
+	if w.opts.Interval <= 0 {
+		w.opts.Interval = DefaultWatchInterval
+	}
+	if w.opts.Canceler == nil {
+		w.opts.Canceler = concurrency.NewCanceler()
+	}
+	go w.run()
+	return w
+}
+
+// watchFileState is the recorded state of a watched file.
+type watchFileState struct {
+	info fs.FileInfo
+}
+
+// pendingWatchEvent is an event waiting for the debounce period to pass.
+type pendingWatchEvent struct {
+	event WatchEvent
+	last  time.Time // the time of the last operation
+}
+
+// watcher is an implementation of interface Watcher.
+type watcher struct {
+	opts WatcherOptions
+
+	lock  sync.Mutex
+	roots map[string]map[string]watchFileState // root -> path -> state
+
+	pending map[string]*pendingWatchEvent // accessed only by run
+	events  chan WatchEvent
+}
+
+func (w *watcher) Add(name string) error {
+	name = filepath.Clean(name)
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if _, ok := w.roots[name]; ok {
+		return nil
+	}
+	snapshot, err := w.scan(name)
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	w.roots[name] = snapshot
+	return nil
+}
+
+func (w *watcher) Remove(name string) {
+	name = filepath.Clean(name)
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	delete(w.roots, name)
+}
+
+func (w *watcher) Events() iter.Seq[WatchEvent] {
+	return func(yield func(WatchEvent) bool) {
+		for event := range w.events {
+			if !yield(event) {
+				return
+			}
+		}
+	}
+}
+
+func (w *watcher) Canceler() concurrency.Canceler {
+	return w.opts.Canceler
+}
+
+// run is the polling loop of the watcher.
+func (w *watcher) run() {
+	defer close(w.events)
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+	cancelC := w.opts.Canceler.C()
+	for {
+		select {
+		case <-cancelC:
+			return
+		case <-ticker.C:
+		}
+		now := time.Now()
+		for _, event := range w.poll() {
+			w.addPending(event, now)
+		}
+		if !w.flush(now, cancelC) {
+			return
+		}
+	}
+}
+
+// poll scans all watched roots and returns the events
+// since the last scan.
+func (w *watcher) poll() []WatchEvent {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	var events []WatchEvent
+	for root, old := range w.roots {
+		snapshot, err := w.scan(root)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			w.reportError(err)
+			continue
+		}
+		events = append(events, diffWatchSnapshots(old, snapshot)...)
+		w.roots[root] = snapshot
+	}
+	return events
+}
+
+// scan returns the states of the file root
+// and (for a directory) the files in it.
+//
+// If root does not exist, scan returns an empty snapshot
+// along with an error that satisfies errors.Is(err, fs.ErrNotExist).
+func (w *watcher) scan(root string) (map[string]watchFileState, error) {
+	snapshot := make(map[string]watchFileState)
+	info, err := os.Lstat(root)
+	if err != nil {
+		return snapshot, err
+	}
+	snapshot[root] = watchFileState{info: info}
+	if !info.IsDir() {
+		return snapshot, nil
+	}
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == root {
+				return err // reported by the caller
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				w.reportError(err)
+			}
+			return nil
+		} else if p == root {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				w.reportError(err)
+			}
+			return nil
+		}
+		snapshot[p] = watchFileState{info: info}
+		if d.IsDir() && !w.opts.Recursive {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	return snapshot, err
+}
+
+// reportError calls the OnError callback, if any, with err.
+func (w *watcher) reportError(err error) {
+	if w.opts.OnError != nil {
+		w.opts.OnError(err)
+	}
+}
+
+// diffWatchSnapshots compares two snapshots and returns the events
+// that turn old into cur, in lexical order of names.
+func diffWatchSnapshots(old, cur map[string]watchFileState) []WatchEvent {
+	var created, removed []string
+	var events []WatchEvent
+	for name, c := range cur {
+		o, ok := old[name]
+		if !ok {
+			created = append(created, name)
+			continue
+		}
+		var op WatchOp
+		if !c.info.IsDir() && (c.info.Size() != o.info.Size() ||
+			!c.info.ModTime().Equal(o.info.ModTime())) {
+			op |= WatchWrite
+		}
+		if c.info.Mode() != o.info.Mode() {
+			op |= WatchChmod
+		}
+		if op != 0 {
+			events = append(events, WatchEvent{Name: name, Op: op})
+		}
+	}
+	for name := range old {
+		if _, ok := cur[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	// Pair removed and created files referring to the same file as renames.
+	for i := 0; i < len(removed); i++ {
+		for j := 0; j < len(created); j++ {
+			if os.SameFile(old[removed[i]].info, cur[created[j]].info) {
+				events = append(events, WatchEvent{
+					Name:    created[j],
+					OldName: removed[i],
+					Op:      WatchRename,
+				})
+				removed = append(removed[:i], removed[i+1:]...)
+				created = append(created[:j], created[j+1:]...)
+				i--
+				break
+			}
+		}
+	}
+	for _, name := range created {
+		events = append(events, WatchEvent{Name: name, Op: WatchCreate})
+	}
+	for _, name := range removed {
+		events = append(events, WatchEvent{Name: name, Op: WatchRemove})
+	}
+	slices.SortFunc(events, func(a, b WatchEvent) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return events
+}
+
+// addPending records event as a pending event at time now,
+// merging it with the pending event on the same file, if any.
+func (w *watcher) addPending(event WatchEvent, now time.Time) {
+	p := w.pending[event.Name]
+	if p == nil {
+		w.pending[event.Name] = &pendingWatchEvent{event: event, last: now}
+		return
+	}
+	p.event.Op |= event.Op
+	if event.OldName != "" {
+		p.event.OldName = event.OldName
+	}
+	p.last = now
+}
+
+// flush sends the pending events whose debounce period has passed.
+//
+// It returns false if the watcher is shut down during sending.
+func (w *watcher) flush(now time.Time, cancelC <-chan struct{}) bool {
+	var ready []WatchEvent
+	for name, p := range w.pending {
+		if now.Sub(p.last) >= w.opts.Debounce {
+			ready = append(ready, p.event)
+			delete(w.pending, name)
+		}
+	}
+	slices.SortFunc(ready, func(a, b WatchEvent) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	for _, event := range ready {
+		select {
+		case <-cancelC:
+			return false
+		case w.events <- event:
+		}
+	}
+	return true
+}
@@ -0,0 +1,168 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package local_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/filesys/local"
+)
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal("make directory -", err)
+	}
+	w := local.NewWatcher(&local.WatcherOptions{
+		Recursive: true,
+		Interval:  5 * time.Millisecond,
+		OnError: func(err error) {
+			t.Error("watcher -", err)
+		},
+	})
+	defer w.Canceler().Cancel()
+	if err := w.Add(dir); err != nil {
+		t.Fatal("add -", err)
+	}
+	eventC := make(chan local.WatchEvent)
+	go func() {
+		defer close(eventC)
+		for event := range w.Events() {
+			eventC <- event
+		}
+	}()
+
+	name := filepath.Join(sub, "a.txt")
+	writeTestFile(t, name, []byte("a"), 0644)
+	waitWatchEvent(t, eventC, local.WatchEvent{Name: name, Op: local.WatchCreate})
+
+	// Wait to ensure that the modification time changes
+	// on file systems with coarse timestamps.
+	time.Sleep(20 * time.Millisecond)
+	writeTestFile(t, name, []byte("abc"), 0644)
+	waitWatchEvent(t, eventC, local.WatchEvent{Name: name, Op: local.WatchWrite})
+
+	newName := filepath.Join(dir, "b.txt")
+	if err := os.Rename(name, newName); err != nil {
+		t.Fatal("rename -", err)
+	}
+	waitWatchEvent(t, eventC, local.WatchEvent{
+		Name:    newName,
+		OldName: name,
+		Op:      local.WatchRename,
+	})
+
+	if err := os.Remove(newName); err != nil {
+		t.Fatal("remove -", err)
+	}
+	waitWatchEvent(t, eventC, local.WatchEvent{
+		Name: newName,
+		Op:   local.WatchRemove,
+	})
+
+	w.Canceler().Cancel()
+	select {
+	case event, ok := <-eventC:
+		if ok {
+			t.Errorf("got unexpected event %+v after cancellation", event)
+		}
+	case <-time.After(time.Second):
+		t.Error("Events did not end after cancellation")
+	}
+}
+
+func TestWatcher_Debounce(t *testing.T) {
+	dir := t.TempDir()
+	w := local.NewWatcher(&local.WatcherOptions{
+		Interval: 5 * time.Millisecond,
+		Debounce: 100 * time.Millisecond,
+	})
+	defer w.Canceler().Cancel()
+	if err := w.Add(dir); err != nil {
+		t.Fatal("add -", err)
+	}
+	name := filepath.Join(dir, "a.txt")
+	for i := range 5 {
+		writeTestFile(t, name, make([]byte, i+1), 0644)
+		time.Sleep(10 * time.Millisecond)
+	}
+	for event := range w.Events() {
+		if event.Name != name {
+			t.Errorf("got event on %q; want %q", event.Name, name)
+		} else if !event.Op.Has(local.WatchCreate) {
+			t.Errorf("got op %v; want it to contain %v",
+				event.Op, local.WatchCreate)
+		}
+		break
+	}
+}
+
+func TestWatcher_AddNotExist(t *testing.T) {
+	w := local.NewWatcher(nil)
+	defer w.Canceler().Cancel()
+	if err := w.Add(filepath.Join(t.TempDir(), "nonexistent")); err == nil {
+		t.Error("got nil error")
+	}
+}
+
+func TestWatchOp_String(t *testing.T) {
+	testCases := []struct {
+		op   local.WatchOp
+		want string
+	}{
+		{0, "NONE"},
+		{local.WatchCreate, "CREATE"},
+		{local.WatchCreate | local.WatchWrite, "CREATE|WRITE"},
+		{local.WatchRemove | local.WatchChmod, "REMOVE|CHMOD"},
+	}
+	for _, tc := range testCases {
+		t.Run("want="+tc.want, func(t *testing.T) {
+			if got := tc.op.String(); got != tc.want {
+				t.Errorf("got %q; want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// waitWatchEvent waits for the event want from eventC,
+// skipping other events, and reports an error on timeout.
+func waitWatchEvent(
+	t *testing.T,
+	eventC <-chan local.WatchEvent,
+	want local.WatchEvent,
+) {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case event, ok := <-eventC:
+			if !ok {
+				t.Fatalf("event channel closed; want %+v", want)
+			} else if event == want {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timeout waiting for %+v", want)
+		}
+	}
+}
@@ -19,9 +19,11 @@
 package local
 
 import (
+	"compress/flate"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/donyori/gogo/errors"
 	"github.com/donyori/gogo/filesys"
@@ -134,3 +136,42 @@ func WriteExcl(
 		name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm, mkDirs, opts)
 	return w, errors.AutoWrap(err)
 }
+
+// WriteTarAppend creates (if necessary) and opens a tar archive file
+// with specified name and options opts for appending new entries.
+//
+// It sets the option TarAppend to true and
+// opens the file for both reading and writing,
+// so that new entries are appended after the existing ones
+// without rewriting the archive
+// (see the option TarAppend of
+// github.com/donyori/gogo/filesys.WriteOptions for details).
+// The file extension must be ".tar".
+//
+// If the file does not exist, it is created
+// with specified permission perm (before umask).
+//
+// mkDirs indicates whether to make necessary directories
+// before opening the file.
+//
+// Other options in opts are handled the same as in
+// function github.com/donyori/gogo/filesys.Write.
+//
+// The file is closed when the returned writer is closed.
+func WriteTarAppend(
+	name string,
+	perm fs.FileMode,
+	mkDirs bool,
+	opts *filesys.WriteOptions,
+) (w filesys.Writer, err error) {
+	if !strings.EqualFold(filepath.Ext(name), ".tar") {
+		return nil, errors.AutoNew(`file extension is not ".tar"`)
+	}
+	o := filesys.WriteOptions{DeflateLv: flate.BestCompression} // the default options of filesys.Write
+	if opts != nil {
+		o = *opts
+	}
+	o.TarAppend = true
+	w, err = writeOpenFile(name, os.O_RDWR|os.O_CREATE, perm, mkDirs, &o)
+	return w, errors.AutoWrap(err)
+}
@@ -127,6 +127,107 @@ Sugar is sweet.
 	}
 }
 
+func TestWriteTarAppend(t *testing.T) {
+	big := randbytes.Make(rand.NewChaCha8(ChaCha8Seed), 13<<10)
+	tarFiles := []tarFileNameBody{
+		{name: "tardir/", body: nil},
+		{name: "tardir/tar file1.txt", body: []byte("This is tar file 1.")},
+		{name: "13KB.dat", body: big},
+		{name: "tardir/tar file2.txt", body: []byte("Here is tar file 2!")},
+		{name: "emptydir/", body: nil},
+		{name: "empty.txt", body: []byte{}},
+		{name: "roses are red.txt", body: []byte(`Roses are red.
+  Violets are blue.
+Sugar is sweet.
+  And so are you.
+`)},
+	}
+	tmpRoot := t.TempDir()
+	for _, split := range []int{0, 1, 3, 6} {
+		t.Run(fmt.Sprintf("split=%d", split), func(t *testing.T) {
+			name := filepath.Join(tmpRoot, fmt.Sprintf("test%d.tar", split))
+			if split > 0 {
+				writeTarFiles(t, name, tarFiles[:split])
+				if t.Failed() {
+					return
+				}
+			}
+			for i := split; i < len(tarFiles); i += 2 {
+				w, err := local.WriteTarAppend(name, 0600, true, nil)
+				if err != nil {
+					t.Fatal("create -", err)
+				}
+				writeTarEntries(t, w, tarFiles[i:min(i+2, len(tarFiles))])
+				if err = w.Close(); err != nil {
+					t.Error("close -", err)
+				}
+				if t.Failed() {
+					return
+				}
+			}
+			testTarTgzFile(t, name, tarFiles)
+		})
+	}
+}
+
+func TestWriteTarAppend_SkipBodies(t *testing.T) {
+	big := randbytes.Make(rand.NewChaCha8(ChaCha8Seed), 1<<20)
+	tarFiles := []tarFileNameBody{
+		{name: "1MB.dat", body: big},
+		{name: "small.txt", body: []byte("small")},
+	}
+	name := filepath.Join(t.TempDir(), "test.tar")
+	writeTarFiles(t, name, tarFiles[:1])
+	if t.Failed() {
+		return
+	}
+	f, err := os.OpenFile(name, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal("open -", err)
+	}
+	rf := &readCountFile{File: f}
+	w, err := filesys.Write(rf, &filesys.WriteOptions{TarAppend: true}, true)
+	if err != nil {
+		_ = f.Close() // ignore error
+		t.Fatal("create -", err)
+	}
+	if rf.n >= int64(len(big)) {
+		t.Errorf("read %d bytes; want less than the body size %d",
+			rf.n, len(big))
+	}
+	writeTarEntries(t, w, tarFiles[1:])
+	if err = w.Close(); err != nil {
+		t.Error("close -", err)
+	}
+	testTarTgzFile(t, name, tarFiles)
+}
+
+// readCountFile is an *os.File that counts the bytes read by Read.
+type readCountFile struct {
+	*os.File
+	n int64
+}
+
+func (rf *readCountFile) Read(p []byte) (n int, err error) {
+	n, err = rf.File.Read(p)
+	rf.n += int64(n)
+	return
+}
+
+func TestWriteTarAppend_NotTar(t *testing.T) {
+	tmpRoot := t.TempDir()
+	for _, filename := range []string{"test.txt", "test.tgz", "test.tar.gz"} {
+		t.Run(fmt.Sprintf("file=%+q", filename), func(t *testing.T) {
+			w, err := local.WriteTarAppend(
+				filepath.Join(tmpRoot, filename), 0600, true, nil)
+			if err == nil {
+				_ = w.Close()
+				t.Error("create - no error but file is not an uncompressed tar archive")
+			}
+		})
+	}
+}
+
 func TestWriteTrunc_TarAddFS(t *testing.T) {
 	big := randbytes.Make(rand.NewChaCha8(ChaCha8Seed), 13<<10)
 	fsys := fstest.MapFS{
@@ -428,6 +529,13 @@ func writeTarFiles(t *testing.T, name string, tarFiles []tarFileNameBody) {
 			t.Error("close -", err)
 		}
 	}(w)
+	writeTarEntries(t, w, tarFiles)
+}
+
+// writeTarEntries writes tarFiles to w.
+//
+// Caller should guarantee that w is in tar mode.
+func writeTarEntries(t *testing.T, w filesys.Writer, tarFiles []tarFileNameBody) {
 	for i := range tarFiles {
 		hdr := &tar.Header{
 			Name:    tarFiles[i].name,
@@ -435,7 +543,7 @@ func writeTarFiles(t *testing.T, name string, tarFiles []tarFileNameBody) {
 			Mode:    0600,
 			ModTime: time.Now(),
 		}
-		err = w.TarWriteHeader(hdr)
+		err := w.TarWriteHeader(hdr)
 		if err != nil {
 			t.Errorf("write No.%d tar header - %v", i, err)
 			return
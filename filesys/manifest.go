@@ -0,0 +1,452 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"path"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// HashFS calculates the checksums of the regular files in fsys
+// using the hash function created by newHash,
+// and returns a map from the path of each file
+// (slash-separated, relative to the root of fsys) to its checksum
+// in lowercase hexadecimal representation.
+//
+// patterns select the files to be hashed,
+// in the same syntax as that of function Glob.
+// A file is selected if its path matches any of the patterns.
+// If patterns are empty, all regular files are selected.
+//
+// numWorker is the number of goroutines hashing the files concurrently.
+// If numWorker is nonpositive, runtime.GOMAXPROCS(0) is used.
+//
+// HashFS stops at the first error encountered
+// (e.g., an invalid pattern, or an error reading a file)
+// and returns a nil map with that error.
+//
+// The result can be written as a manifest by function WriteManifest.
+//
+// HashFS panics if fsys or newHash is nil.
+func HashFS(
+	fsys fs.FS,
+	newHash func() hash.Hash,
+	patterns []string,
+	numWorker int,
+) (checksums map[string]string, err error) {
+	if fsys == nil {
+		panic(errors.AutoMsg("fsys is nil"))
+	} else if newHash == nil {
+		panic(errors.AutoMsg("newHash is nil"))
+	}
+	pats, err := compileManifestPatterns(patterns)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	checksums, err = hashFSFiles(fsys, newHash, numWorker, false,
+		func(send func(name string) bool) error {
+			return walkManifestFiles(fsys, pats, send)
+		},
+	)
+	return checksums, errors.AutoWrap(err)
+}
+
+// compileManifestPatterns compiles the glob patterns
+// for selecting the files.
+func compileManifestPatterns(patterns []string) ([][]globElem, error) {
+	pats := make([][]globElem, 0, len(patterns))
+	for _, pattern := range patterns {
+		ps, err := compileGlob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		pats = append(pats, ps...)
+	}
+	return pats, nil
+}
+
+// walkManifestFiles walks fsys and calls f with the path of
+// each regular file matching any of the compiled glob patterns pats
+// (or each regular file if pats are empty),
+// until f returns false.
+func walkManifestFiles(
+	fsys fs.FS,
+	pats [][]globElem,
+	f func(name string) bool,
+) error {
+	return fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		} else if !d.Type().IsRegular() ||
+			len(pats) > 0 && !manifestMatch(pats, p) {
+			return nil
+		} else if !f(p) {
+			return fs.SkipAll
+		}
+		return nil
+	})
+}
+
+// hashFSFiles calculates the checksums of the files in fsys
+// with numWorker goroutines concurrently.
+//
+// The files are specified by walk, which calls send with each file path.
+// send returns false if walk should stop because of an error.
+//
+// If skipMissing is true, the files that do not exist or are directories
+// are skipped (i.e., are absent from the returned map)
+// instead of causing an error.
+func hashFSFiles(
+	fsys fs.FS,
+	newHash func() hash.Hash,
+	numWorker int,
+	skipMissing bool,
+	walk func(send func(name string) bool) error,
+) (checksums map[string]string, err error) {
+	if numWorker <= 0 {
+		numWorker = runtime.GOMAXPROCS(0)
+	}
+	nameCh := make(chan string, numWorker)
+	quit := make(chan struct{})
+	var mu sync.Mutex // lock for checksums and err
+	checksums = make(map[string]string)
+	setErr := func(e error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err == nil {
+			err = e
+			close(quit)
+		}
+	}
+	var wg sync.WaitGroup
+	wg.Add(numWorker)
+	for range numWorker {
+		go func() {
+			defer wg.Done()
+			for name := range nameCh {
+				cs, e := ChecksumFromFS(fsys, name, false, newHash)
+				if e != nil {
+					if skipMissing && (errors.Is(e, fs.ErrNotExist) ||
+						errors.Is(e, ErrIsDir)) {
+						continue
+					}
+					setErr(e)
+					return
+				}
+				mu.Lock()
+				checksums[name] = cs[0]
+				mu.Unlock()
+			}
+		}()
+	}
+
+	walkErr := walk(func(name string) bool {
+		select {
+		case nameCh <- name:
+			return true
+		case <-quit:
+			return false
+		}
+	})
+	close(nameCh)
+	wg.Wait()
+	if walkErr != nil {
+		setErr(walkErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+// manifestMatch reports whether name matches any of
+// the compiled glob patterns pats.
+func manifestMatch(pats [][]globElem, name string) bool {
+	elems := strings.Split(name, "/")
+	for _, pat := range pats {
+		if globMatch(pat, elems) {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteManifest writes checksums (a map from the file path to its checksum,
+// such as that returned by function HashFS) to w
+// in the format of the output of GNU coreutils sha256sum
+// (also known as the SHA256SUMS format),
+// one line per file, sorted by the file path.
+//
+// Each line consists of the checksum, two spaces, and the file path.
+// If the file path contains a backslash or a newline character,
+// the line starts with a backslash,
+// and the backslashes and newline characters in the file path
+// are escaped as "\\" and "\n", respectively.
+//
+// The manifest can be verified by function VerifyManifest.
+//
+// WriteManifest panics if w is nil.
+func WriteManifest(w io.Writer, checksums map[string]string) error {
+	if w == nil {
+		panic(errors.AutoMsg("w is nil"))
+	}
+	names := make([]string, 0, len(checksums))
+	for name := range checksums {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	bw := bufio.NewWriter(w)
+	// The errors during writing are reported by the method Flush.
+	for _, name := range names {
+		checksum := checksums[name]
+		if strings.ContainsAny(name, "\\\n") {
+			_ = bw.WriteByte('\\')
+			name = manifestEscaper.Replace(name)
+		}
+		_, _ = bw.WriteString(checksum)
+		_, _ = bw.WriteString("  ")
+		_, _ = bw.WriteString(name)
+		_ = bw.WriteByte('\n')
+	}
+	return errors.AutoWrap(bw.Flush())
+}
+
+// manifestEscaper escapes the file path in the manifest.
+var manifestEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+// VerifyManifestOptions are options for function VerifyManifest.
+//
+// A nil *VerifyManifestOptions is equivalent to
+// a zero-value VerifyManifestOptions.
+type VerifyManifestOptions struct {
+	// NewHash creates the hash function used by the manifest.
+	//
+	// If NewHash is nil, crypto/sha256.New is used.
+	NewHash func() hash.Hash
+
+	// Patterns select the files in the filesystem that are expected
+	// to be listed in the manifest, in the same syntax as that of function Glob.
+	// The selected files not listed in the manifest are reported as extra.
+	//
+	// If Patterns are empty, all regular files are selected.
+	Patterns []string
+
+	// NumWorker is the number of goroutines hashing the files concurrently.
+	//
+	// If NumWorker is nonpositive, runtime.GOMAXPROCS(0) is used.
+	NumWorker int
+}
+
+// ManifestResult is the result of function VerifyManifest.
+//
+// Each field is a list of file paths sorted in ascending order.
+type ManifestResult struct {
+	// Matched are the files whose checksums match the manifest.
+	Matched []string
+
+	// Mismatched are the files whose checksums do not match the manifest.
+	Mismatched []string
+
+	// Missing are the files listed in the manifest
+	// but not existing in the filesystem (or being directories).
+	Missing []string
+
+	// Extra are the files selected by the option Patterns
+	// but not listed in the manifest.
+	Extra []string
+}
+
+// OK reports whether all the files listed in the manifest match
+// and there are no extra files.
+func (mr *ManifestResult) OK() bool {
+	return len(mr.Mismatched) == 0 && len(mr.Missing) == 0 && len(mr.Extra) == 0
+}
+
+// VerifyManifest verifies the files in fsys against manifest,
+// which is in the format written by function WriteManifest
+// (i.e., the format of the output of GNU coreutils sha256sum),
+// and reports the result with options opts.
+//
+// Both the text mode (checksum followed by two spaces)
+// and the binary mode (checksum followed by a space and an asterisk)
+// are accepted.
+// Empty lines are ignored.
+// The checksums are compared case-insensitively.
+//
+// VerifyManifest reports an error if the manifest is malformed,
+// an invalid pattern is specified in opts,
+// or an error other than nonexistence occurs while reading the files.
+// File mismatches are not errors; they are reported in the result.
+//
+// VerifyManifest panics if fsys or manifest is nil.
+func VerifyManifest(
+	fsys fs.FS,
+	manifest io.Reader,
+	opts *VerifyManifestOptions,
+) (result *ManifestResult, err error) {
+	if fsys == nil {
+		panic(errors.AutoMsg("fsys is nil"))
+	} else if manifest == nil {
+		panic(errors.AutoMsg("manifest is nil"))
+	}
+	if opts == nil {
+		opts = new(VerifyManifestOptions)
+	}
+	newHash := opts.NewHash
+	if newHash == nil {
+		newHash = sha256.New
+	}
+	pats, err := compileManifestPatterns(opts.Patterns)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	want, names, err := parseManifest(manifest)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	got, err := hashFSFiles(fsys, newHash, opts.NumWorker, true,
+		func(send func(name string) bool) error {
+			for _, name := range names {
+				if !send(name) {
+					break
+				}
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+
+	result = new(ManifestResult)
+	for _, name := range names {
+		checksum, ok := got[name]
+		switch {
+		case !ok:
+			result.Missing = append(result.Missing, name)
+		case strings.EqualFold(checksum, want[name]):
+			result.Matched = append(result.Matched, name)
+		default:
+			result.Mismatched = append(result.Mismatched, name)
+		}
+	}
+	err = walkManifestFiles(fsys, pats, func(name string) bool {
+		if _, ok := want[name]; !ok {
+			result.Extra = append(result.Extra, name)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	slices.Sort(result.Extra)
+	return
+}
+
+// parseManifest parses the manifest in the format written by
+// function WriteManifest.
+//
+// It returns a map from the file path to its checksum
+// and the file paths sorted in ascending order.
+func parseManifest(manifest io.Reader) (
+	checksums map[string]string, names []string, err error) {
+	checksums = make(map[string]string)
+	scanner := bufio.NewScanner(manifest)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		escaped := line[0] == '\\'
+		if escaped {
+			line = line[1:]
+		}
+		checksum, name, ok := strings.Cut(line, " ")
+		if !ok || checksum == "" || name == "" ||
+			name[0] != ' ' && name[0] != '*' ||
+			strings.IndexFunc(checksum, isNotHexDigit) >= 0 {
+			return nil, nil, fmt.Errorf("malformed manifest line %d", lineNo)
+		}
+		name = name[1:]
+		if escaped {
+			name, ok = manifestUnescape(name)
+			if !ok {
+				return nil, nil, fmt.Errorf(
+					"invalid escape sequence in manifest line %d", lineNo)
+			}
+		}
+		name = path.Clean(name)
+		if !fs.ValidPath(name) || name == "." {
+			return nil, nil, fmt.Errorf(
+				"invalid file path %q in manifest line %d", name, lineNo)
+		} else if _, ok = checksums[name]; ok {
+			return nil, nil, fmt.Errorf(
+				"duplicate file path %q in manifest line %d", name, lineNo)
+		}
+		checksums[name] = checksum
+		names = append(names, name)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	slices.Sort(names)
+	return
+}
+
+// manifestUnescape reverses the escaping of manifestEscaper.
+//
+// It returns false if s contains an invalid escape sequence.
+func manifestUnescape(s string) (string, bool) {
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		} else if i+1 == len(s) {
+			return "", false
+		}
+		i++
+		switch s[i] {
+		case '\\':
+			b.WriteByte('\\')
+		case 'n':
+			b.WriteByte('\n')
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
+
+// isNotHexDigit reports whether r is not a hexadecimal digit.
+func isNotHexDigit(r rune) bool {
+	return r < '0' || r > '9' && r < 'A' || r > 'F' && r < 'a' || r > 'f'
+}
@@ -0,0 +1,201 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/donyori/gogo/filesys"
+)
+
+var testManifestFS = fstest.MapFS{
+	"a.txt":           {Data: []byte("file a\n")},
+	"b.dat":           {Data: []byte{0, 1, 2, 3}},
+	"dir/c.txt":       {Data: []byte("file c\n")},
+	"dir/sub/d.txt":   {Data: []byte("file d\n")},
+	"dir/sub/e.log":   {Data: []byte("log e\n")},
+	"empty":           {Mode: fs.ModeDir | 0755},
+	"back\\slash.txt": {Data: []byte("backslash\n")},
+}
+
+func testManifestChecksum(name string) string {
+	sum := sha256.Sum256(testManifestFS[name].Data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestHashFS(t *testing.T) {
+	testCases := []struct {
+		patterns []string
+		want     []string
+	}{
+		{nil, []string{"a.txt", "b.dat", "dir/c.txt", "dir/sub/d.txt", "dir/sub/e.log", "back\\slash.txt"}},
+		{[]string{"**/*.txt"}, []string{"a.txt", "dir/c.txt", "dir/sub/d.txt", "back\\slash.txt"}},
+		{[]string{"dir/**/*.{log,dat}", "*.dat"}, []string{"b.dat", "dir/sub/e.log"}},
+		{[]string{"nonexistent/*"}, nil},
+	}
+	for _, tc := range testCases {
+		for _, numWorker := range []int{0, 1, 3} {
+			t.Run(fmt.Sprintf("patterns=%q&numWorker=%d",
+				tc.patterns, numWorker), func(t *testing.T) {
+				got, err := filesys.HashFS(
+					testManifestFS, sha256.New, tc.patterns, numWorker)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := make(map[string]string, len(tc.want))
+				for _, name := range tc.want {
+					want[name] = testManifestChecksum(name)
+				}
+				if !maps.Equal(got, want) {
+					t.Errorf("got %v; want %v", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestHashFS_BadPattern(t *testing.T) {
+	_, err := filesys.HashFS(testManifestFS, sha256.New, []string{"[a"}, 0)
+	if !errors.Is(err, path.ErrBadPattern) {
+		t.Errorf("got %v; want %v", err, path.ErrBadPattern)
+	}
+}
+
+func TestWriteManifest(t *testing.T) {
+	checksums := map[string]string{
+		"b.txt":      "bbbb",
+		"a.txt":      "aaaa",
+		`back\slash`: "cccc",
+		"new\nline":  "dddd",
+	}
+	var b strings.Builder
+	err := filesys.WriteManifest(&b, checksums)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "aaaa  a.txt\n" +
+		"bbbb  b.txt\n" +
+		`\cccc  back\\slash` + "\n" +
+		`\dddd  new\nline` + "\n"
+	if got := b.String(); got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestVerifyManifest(t *testing.T) {
+	checksums, err := filesys.HashFS(testManifestFS, sha256.New, nil, 0)
+	if err != nil {
+		t.Fatal("HashFS -", err)
+	}
+	delete(checksums, "dir/sub/e.log")                 // extra
+	checksums["b.dat"] = strings.Repeat("0", 64)       // mismatched
+	checksums["missing.txt"] = strings.Repeat("1", 64) // missing
+	checksums["a.txt"] = strings.ToUpper(checksums["a.txt"])
+	var b strings.Builder
+	err = filesys.WriteManifest(&b, checksums)
+	if err != nil {
+		t.Fatal("WriteManifest -", err)
+	}
+
+	testCases := []struct {
+		patterns []string
+		want     filesys.ManifestResult
+	}{
+		{nil, filesys.ManifestResult{
+			Matched:    []string{"a.txt", "back\\slash.txt", "dir/c.txt", "dir/sub/d.txt"},
+			Mismatched: []string{"b.dat"},
+			Missing:    []string{"missing.txt"},
+			Extra:      []string{"dir/sub/e.log"},
+		}},
+		{[]string{"*.txt"}, filesys.ManifestResult{
+			Matched:    []string{"a.txt", "back\\slash.txt", "dir/c.txt", "dir/sub/d.txt"},
+			Mismatched: []string{"b.dat"},
+			Missing:    []string{"missing.txt"},
+		}},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("patterns=%q", tc.patterns), func(t *testing.T) {
+			got, err := filesys.VerifyManifest(
+				testManifestFS,
+				strings.NewReader(b.String()),
+				&filesys.VerifyManifestOptions{Patterns: tc.patterns},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*got, tc.want) {
+				t.Errorf("got %+v; want %+v", *got, tc.want)
+			}
+			if got.OK() {
+				t.Error("got OK true; want false")
+			}
+		})
+	}
+}
+
+func TestVerifyManifest_OK(t *testing.T) {
+	checksums, err := filesys.HashFS(testManifestFS, sha256.New, nil, 0)
+	if err != nil {
+		t.Fatal("HashFS -", err)
+	}
+	var b strings.Builder
+	err = filesys.WriteManifest(&b, checksums)
+	if err != nil {
+		t.Fatal("WriteManifest -", err)
+	}
+	// Use the binary mode and CRLF line endings for one of the lines.
+	manifest := strings.Replace(b.String(), "  a.txt\n", " *a.txt\r\n", 1)
+	got, err := filesys.VerifyManifest(
+		testManifestFS, strings.NewReader(manifest), nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if !got.OK() || len(got.Matched) != len(checksums) {
+		t.Errorf("got %+v; want all %d files matched", *got, len(checksums))
+	}
+}
+
+func TestVerifyManifest_Malformed(t *testing.T) {
+	manifests := []string{
+		"abcd a.txt\n",
+		"abcd\n",
+		"xyz  a.txt\n",
+		"abcd  ../a.txt\n",
+		"abcd  a.txt\nabcd  ./a.txt\n",
+		`\abcd  a\tb` + "\n",
+	}
+	for _, manifest := range manifests {
+		t.Run(fmt.Sprintf("manifest=%q", manifest), func(t *testing.T) {
+			_, err := filesys.VerifyManifest(
+				testManifestFS, strings.NewReader(manifest), nil)
+			if err == nil {
+				t.Error("got nil error")
+			}
+		})
+	}
+}
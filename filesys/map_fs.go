@@ -0,0 +1,205 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys
+
+import (
+	"io/fs"
+	"strings"
+	"sync"
+	"testing/fstest"
+	"time"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// MapWritableFS is an in-memory filesystem that supports
+// both creating files for writing (as WritableFile)
+// and reading files (as io/fs.FS).
+//
+// It implements io/fs.ReadDirFS, io/fs.ReadFileFS, and io/fs.StatFS.
+// Files created by the method Create can be passed to function Write,
+// and then read back through functions like ReadFromFS,
+// which is convenient for round-trip tests.
+//
+// Directories are implicit:
+// a directory exists if and only if there is a file in it,
+// like io/fs.FS returned by testing/fstest.MapFS.
+//
+// The data written to a file is visible to the readers immediately,
+// even before the file is closed.
+// A file opened for reading is a snapshot taken at the time of opening,
+// and is not affected by the subsequent writes.
+//
+// The zero value is an empty filesystem ready to use.
+//
+// MapWritableFS is safe for concurrent use by multiple goroutines.
+// It must not be copied after first use.
+type MapWritableFS struct {
+	mu    sync.RWMutex
+	files fstest.MapFS
+}
+
+var (
+	_ fs.ReadDirFS  = (*MapWritableFS)(nil)
+	_ fs.ReadFileFS = (*MapWritableFS)(nil)
+	_ fs.StatFS     = (*MapWritableFS)(nil)
+)
+
+// Create creates a file with the specified name and permission perm
+// in the filesystem and returns it for writing.
+// If the file already exists, it is truncated
+// (the file previously returned by Create for the same name
+// no longer affects the filesystem).
+//
+// name must be a valid path (see io/fs.ValidPath) other than ".".
+// Create reports an error wrapping io/fs.ErrInvalid
+// if name is invalid, and an error wrapping io/fs.ErrExist
+// if name is an existing directory or any parent of name is a file.
+func (m *MapWritableFS) Create(name string, perm fs.FileMode) (
+	file WritableFile, err error) {
+	if !fs.ValidPath(name) || name == "." {
+		return nil, errors.AutoWrap(&fs.PathError{
+			Op:   "create",
+			Path: name,
+			Err:  fs.ErrInvalid,
+		})
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.files == nil {
+		m.files = make(fstest.MapFS)
+	}
+	prefix := name + "/"
+	for k := range m.files {
+		if strings.HasPrefix(k, prefix) || strings.HasPrefix(name, k+"/") {
+			return nil, errors.AutoWrap(&fs.PathError{
+				Op:   "create",
+				Path: name,
+				Err:  fs.ErrExist,
+			})
+		}
+	}
+	mf := &fstest.MapFile{
+		Data:    []byte{},
+		Mode:    perm & fs.ModePerm,
+		ModTime: time.Now(),
+	}
+	m.files[name] = mf
+	return &mapWritableFile{fsys: m, name: name, mf: mf}, nil
+}
+
+// Remove removes the file with the specified name.
+//
+// It reports an error wrapping io/fs.ErrNotExist
+// if the file does not exist.
+// Directories cannot be removed explicitly;
+// they disappear when all files in them are removed.
+func (m *MapWritableFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.files[name]; !ok {
+		return errors.AutoWrap(&fs.PathError{
+			Op:   "remove",
+			Path: name,
+			Err:  fs.ErrNotExist,
+		})
+	}
+	delete(m.files, name)
+	return nil
+}
+
+func (m *MapWritableFS) Open(name string) (fs.File, error) {
+	return m.snapshot().Open(name)
+}
+
+func (m *MapWritableFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return m.snapshot().ReadDir(name)
+}
+
+func (m *MapWritableFS) ReadFile(name string) ([]byte, error) {
+	return m.snapshot().ReadFile(name)
+}
+
+func (m *MapWritableFS) Stat(name string) (fs.FileInfo, error) {
+	return m.snapshot().Stat(name)
+}
+
+// snapshot returns a copy of the files in m.
+//
+// The file data are not copied because they are append-only
+// (Create replaces the file rather than truncating the data in place).
+func (m *MapWritableFS) snapshot() fstest.MapFS {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	files := make(fstest.MapFS, len(m.files))
+	for k, mf := range m.files {
+		c := *mf
+		files[k] = &c
+	}
+	return files
+}
+
+// mapWritableFile is the file returned by MapWritableFS.Create.
+type mapWritableFile struct {
+	fsys   *MapWritableFS
+	name   string
+	mf     *fstest.MapFile
+	closed bool
+}
+
+var _ WritableFile = (*mapWritableFile)(nil)
+
+func (f *mapWritableFile) Write(p []byte) (n int, err error) {
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+	if f.closed {
+		return 0, errors.AutoWrap(&fs.PathError{
+			Op:   "write",
+			Path: f.name,
+			Err:  fs.ErrClosed,
+		})
+	}
+	if len(p) > 0 {
+		f.mf.Data = append(f.mf.Data, p...)
+		f.mf.ModTime = time.Now()
+	}
+	return len(p), nil
+}
+
+func (f *mapWritableFile) Close() error {
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+	if f.closed {
+		return errors.AutoWrap(&fs.PathError{
+			Op:   "close",
+			Path: f.name,
+			Err:  fs.ErrClosed,
+		})
+	}
+	f.closed = true
+	return nil
+}
+
+func (f *mapWritableFile) Stat() (info fs.FileInfo, err error) {
+	f.fsys.mu.RLock()
+	c := *f.mf
+	f.fsys.mu.RUnlock()
+	info, err = fstest.MapFS{f.name: &c}.Stat(f.name)
+	return info, errors.AutoWrap(err)
+}
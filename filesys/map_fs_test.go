@@ -0,0 +1,187 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys_test
+
+import (
+	"archive/tar"
+	"errors"
+	"io"
+	"io/fs"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/donyori/gogo/filesys"
+)
+
+func TestMapWritableFS_RoundTrip(t *testing.T) {
+	var mfs filesys.MapWritableFS
+	const data = "Hello, world!"
+	names := []string{"a.txt", "dir/b.txt.gz", "dir/sub/c.tar.gz"}
+	for _, name := range names {
+		file, err := mfs.Create(name, 0o644)
+		if err != nil {
+			t.Fatal("create -", err)
+		}
+		w, err := filesys.Write(file, nil, true)
+		if err != nil {
+			t.Fatal("write -", err)
+		}
+		if w.TarEnabled() {
+			err = w.TarWriteHeader(&tar.Header{
+				Name: "data.txt",
+				Mode: 0o644,
+				Size: int64(len(data)),
+			})
+		}
+		if err == nil {
+			_, err = w.WriteString(data)
+		}
+		if err != nil {
+			_ = w.Close()
+			t.Fatal("write -", err)
+		}
+		if err = w.Close(); err != nil {
+			t.Fatal("close -", err)
+		}
+	}
+	if err := fstest.TestFS(&mfs, names...); err != nil {
+		t.Error(err)
+	}
+	for _, name := range names {
+		t.Run("file="+name, func(t *testing.T) {
+			r, err := filesys.ReadFromFS(&mfs, name, nil)
+			if err != nil {
+				t.Fatal("read -", err)
+			}
+			defer func() {
+				if err := r.Close(); err != nil {
+					t.Error("close -", err)
+				}
+			}()
+			if r.TarEnabled() {
+				if _, err = r.TarNext(); err != nil {
+					t.Fatal("tar next -", err)
+				}
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal("read all -", err)
+			} else if string(got) != data {
+				t.Errorf("got %q; want %q", got, data)
+			}
+		})
+	}
+}
+
+func TestMapWritableFS_Create(t *testing.T) {
+	var mfs filesys.MapWritableFS
+	file, err := mfs.Create("dir/a.txt", 0o600)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	if _, err = file.Write([]byte("old")); err != nil {
+		t.Fatal("write -", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		t.Fatal("stat -", err)
+	} else if info.Name() != "a.txt" || info.Size() != 3 ||
+		info.Mode() != 0o600 {
+		t.Errorf("got name %q, size %d, mode %v; want a.txt, 3, %v",
+			info.Name(), info.Size(), info.Mode(), fs.FileMode(0o600))
+	}
+	if err = file.Close(); err != nil {
+		t.Fatal("close -", err)
+	}
+	if _, err = file.Write([]byte("x")); !errors.Is(err, fs.ErrClosed) {
+		t.Errorf("write after close - got %v; want %v", err, fs.ErrClosed)
+	}
+
+	// Truncate.
+	file, err = mfs.Create("dir/a.txt", 0o644)
+	if err != nil {
+		t.Fatal("create again -", err)
+	}
+	if data, err := mfs.ReadFile("dir/a.txt"); err != nil {
+		t.Error("read file -", err)
+	} else if len(data) != 0 {
+		t.Errorf("got %q; want empty", data)
+	}
+	_ = file.Close()
+
+	for _, name := range []string{"", ".", "/a", "../a", "dir", "dir/a.txt/b"} {
+		_, err = mfs.Create(name, 0o644)
+		if err == nil {
+			t.Errorf("create %q - got nil error", name)
+		}
+	}
+
+	if err = mfs.Remove("dir/a.txt"); err != nil {
+		t.Error("remove -", err)
+	}
+	if _, err = mfs.Stat("dir"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("stat removed dir - got %v; want %v", err, fs.ErrNotExist)
+	}
+	if err = mfs.Remove("dir/a.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("remove again - got %v; want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestMapWritableFS_Concurrent(t *testing.T) {
+	var mfs filesys.MapWritableFS
+	file, err := mfs.Create("a.txt", 0o644)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	const n = 100
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for range n {
+			if _, err := file.Write([]byte{'a'}); err != nil {
+				t.Error("write -", err)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range n {
+			data, err := mfs.ReadFile("a.txt")
+			if err != nil {
+				t.Error("read file -", err)
+				return
+			}
+			for _, b := range data {
+				if b != 'a' {
+					t.Errorf("got byte %q; want 'a'", b)
+					return
+				}
+			}
+		}
+	}()
+	wg.Wait()
+	if data, err := mfs.ReadFile("a.txt"); err != nil {
+		t.Error("read file -", err)
+	} else if len(data) != n {
+		t.Errorf("got %d bytes; want %d", len(data), n)
+	}
+}
@@ -0,0 +1,138 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"runtime"
+)
+
+// NewlineMode is the mode of line-ending normalization
+// for the option NewlineMode of WriteOptions.
+type NewlineMode uint8
+
+const (
+	// NewlineAsIs indicates that the line endings are written as is.
+	NewlineAsIs NewlineMode = iota
+
+	// NewlineLF indicates that the line endings are normalized to
+	// "\n" (LF, used on Unix-like systems).
+	NewlineLF
+
+	// NewlineCRLF indicates that the line endings are normalized to
+	// "\r\n" (CRLF, used on Windows).
+	NewlineCRLF
+
+	// NewlinePlatform indicates that the line endings are normalized to
+	// the native line ending of the current platform,
+	// i.e., CRLF on Windows, and LF otherwise.
+	NewlinePlatform
+
+	maxNewlineMode = NewlinePlatform
+)
+
+// String returns the name of the newline mode.
+func (m NewlineMode) String() string {
+	switch m {
+	case NewlineAsIs:
+		return "AsIs"
+	case NewlineLF:
+		return "LF"
+	case NewlineCRLF:
+		return "CRLF"
+	case NewlinePlatform:
+		return "Platform"
+	}
+	return fmt.Sprintf("NewlineMode(%d)", uint8(m))
+}
+
+// crlf reports whether the line endings are normalized to CRLF.
+//
+// It must not be called on NewlineAsIs.
+func (m NewlineMode) crlf() bool {
+	return m == NewlineCRLF || m == NewlinePlatform && runtime.GOOS == "windows"
+}
+
+// newlineWriter is a writer that normalizes the line endings
+// ("\n" and "\r\n") of the data written to it.
+//
+// A lone "\r" (not followed by "\n") is written as is.
+//
+// Its method Close flushes the pending "\r" (if any)
+// without closing the underlying writer.
+type newlineWriter struct {
+	w    io.Writer
+	crlf bool // true to normalize to CRLF, false to LF
+	cr   bool // true if the last byte written is '\r'
+	buf  []byte
+}
+
+func (nw *newlineWriter) Write(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return
+	}
+	n = len(p)
+	nw.buf = nw.buf[:0]
+	if nw.cr && !nw.crlf && p[0] != '\n' {
+		// Flush the pending '\r' not followed by '\n'.
+		nw.buf = append(nw.buf, '\r')
+	}
+	for len(p) > 0 {
+		i := bytes.IndexAny(p, "\r\n")
+		if i < 0 {
+			nw.buf = append(nw.buf, p...)
+			nw.cr = false
+			break
+		}
+		nw.buf = append(nw.buf, p[:i]...)
+		c := p[i]
+		p = p[i+1:]
+		if c == '\r' {
+			// In LF mode, hold '\r' until the next byte is known.
+			if nw.crlf {
+				nw.buf = append(nw.buf, '\r')
+			} else if len(p) > 0 && p[0] != '\n' {
+				nw.buf = append(nw.buf, '\r')
+			}
+			nw.cr = true
+			continue
+		}
+		if nw.crlf && (i > 0 || !nw.cr) {
+			nw.buf = append(nw.buf, '\r')
+		}
+		nw.buf = append(nw.buf, '\n')
+		nw.cr = false
+	}
+	_, err = nw.w.Write(nw.buf)
+	if err != nil {
+		return 0, err
+	}
+	return
+}
+
+func (nw *newlineWriter) Close() error {
+	if nw.cr && !nw.crlf {
+		nw.cr = false
+		_, err := nw.w.Write([]byte{'\r'})
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys_test
+
+import (
+	"archive/tar"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"testing/iotest"
+
+	"github.com/donyori/gogo/filesys"
+)
+
+func TestWrite_NewlineMode(t *testing.T) {
+	const input = "a\nb\r\nc\rd\r\r\n\n\r"
+	platform := "a\nb\nc\rd\r\n\n\r"
+	if runtime.GOOS == "windows" {
+		platform = "a\r\nb\r\nc\rd\r\r\n\r\n\r"
+	}
+	testCases := []struct {
+		mode filesys.NewlineMode
+		want string
+	}{
+		{filesys.NewlineAsIs, input},
+		{filesys.NewlineLF, "a\nb\nc\rd\r\n\n\r"},
+		{filesys.NewlineCRLF, "a\r\nb\r\nc\rd\r\r\n\r\n\r"},
+		{filesys.NewlinePlatform, platform},
+	}
+	methods := []string{"WriteString", "WriteByte+Flush", "ReadFrom"}
+	for _, tc := range testCases {
+		for _, method := range methods {
+			t.Run("mode="+tc.mode.String()+"&method="+method, func(t *testing.T) {
+				file := &WritableFileImpl{Name: "test.txt"}
+				w, err := filesys.Write(file, &filesys.WriteOptions{
+					NewlineMode: tc.mode,
+				}, true)
+				if err != nil {
+					t.Fatal("create -", err)
+				}
+				switch method {
+				case "WriteString":
+					_, err = w.WriteString(input)
+				case "WriteByte+Flush":
+					for i := 0; i < len(input) && err == nil; i++ {
+						err = w.WriteByte(input[i])
+						if err == nil {
+							err = w.Flush()
+						}
+					}
+				case "ReadFrom":
+					_, err = w.ReadFrom(
+						iotest.OneByteReader(strings.NewReader(input)))
+				}
+				if err != nil {
+					_ = w.Close()
+					t.Fatal("write -", err)
+				}
+				if err = w.Close(); err != nil {
+					t.Fatal("close -", err)
+				}
+				if string(file.Data) != tc.want {
+					t.Errorf("got %q; want %q", file.Data, tc.want)
+				}
+			})
+		}
+	}
+}
+
+func TestWrite_NewlineModeGzip(t *testing.T) {
+	file := &WritableFileImpl{Name: "test.txt.gz"}
+	w, err := filesys.Write(file, &filesys.WriteOptions{
+		DeflateLv:   -1,
+		NewlineMode: filesys.NewlineCRLF,
+	}, true)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	_, err = w.Println("line1")
+	if err == nil {
+		_, err = w.Printf("line%d\n", 2)
+	}
+	if err != nil {
+		_ = w.Close()
+		t.Fatal("write -", err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal("close -", err)
+	}
+	mapFS := fstest.MapFS{file.Name: {Data: file.Data}}
+	r, err := filesys.ReadFromFS(mapFS, file.Name, nil)
+	if err != nil {
+		t.Fatal("read -", err)
+	}
+	defer func() {
+		if err := r.Close(); err != nil {
+			t.Error("close reader -", err)
+		}
+	}()
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal("read all -", err)
+	} else if want := "line1\r\nline2\r\n"; string(got) != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestWrite_NewlineModeTar(t *testing.T) {
+	const data = "a\nb\n"
+	file := &WritableFileImpl{Name: "test.tar"}
+	w, err := filesys.Write(file, &filesys.WriteOptions{
+		NewlineMode: filesys.NewlineCRLF,
+	}, true)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	err = w.TarWriteHeader(&tar.Header{
+		Name: "a.txt",
+		Mode: 0o644,
+		Size: int64(len(data)),
+	})
+	if err == nil {
+		_, err = w.WriteString(data)
+	}
+	if err != nil {
+		_ = w.Close()
+		t.Fatal("write -", err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal("close -", err)
+	}
+	tr := tar.NewReader(strings.NewReader(string(file.Data)))
+	if _, err = tr.Next(); err != nil {
+		t.Fatal("tar next -", err)
+	}
+	got, err := io.ReadAll(tr)
+	if err != nil {
+		t.Fatal("read -", err)
+	} else if string(got) != data {
+		t.Errorf("got %q; want %q", got, data)
+	}
+}
+
+func TestWrite_NewlineModeUnknown(t *testing.T) {
+	_, err := filesys.Write(
+		&WritableFileImpl{Name: "test.txt"},
+		&filesys.WriteOptions{NewlineMode: filesys.NewlinePlatform + 1},
+		true,
+	)
+	if err == nil {
+		t.Error("got nil error")
+	}
+}
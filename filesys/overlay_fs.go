@@ -0,0 +1,260 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys
+
+import (
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// WhiteoutPrefix is the prefix of the name of a whiteout file,
+// which hides the file (or directory) with the rest of its name
+// in the lower layers of the filesystem returned by OverlayFS.
+//
+// For example, a file ".wh.a.txt" in a directory of an upper layer
+// hides the file "a.txt" in the same directory of the lower layers.
+const WhiteoutPrefix = ".wh."
+
+// OpaqueWhiteout is the name of an opaque whiteout file,
+// which hides all the entries in its directory
+// in the lower layers of the filesystem returned by OverlayFS.
+const OpaqueWhiteout = WhiteoutPrefix + WhiteoutPrefix + ".opq"
+
+// OverlayFS returns a filesystem (an io/fs.FS) that layers upper
+// on top of lowers, like a union mount.
+// The returned filesystem also implements io/fs.ReadDirFS
+// and io/fs.StatFS.
+//
+// The layers are searched from upper to lowers (in order)
+// when opening a file, and the first one found is used.
+// The entries of a directory are merged from all the layers
+// having that directory, sorted by filename.
+//
+// The files in a lower layer can be hidden by whiteout files
+// in an upper layer (in the AUFS style):
+// a file whose name starts with WhiteoutPrefix hides
+// the file with the rest of its name in the same directory,
+// and a file named OpaqueWhiteout hides all the entries
+// in its directory.
+// A non-directory file in an upper layer also hides
+// everything under the same path in the lower layers.
+// The whiteout files themselves are invisible.
+//
+// The returned filesystem is read-only,
+// and it is safe for concurrent use if the layers are.
+//
+// OverlayFS panics if upper or any of lowers is nil.
+func OverlayFS(upper fs.FS, lowers ...fs.FS) fs.FS {
+	layers := make([]fs.FS, 1+len(lowers))
+	layers[0] = upper
+	copy(layers[1:], lowers)
+	for i := range layers {
+		if layers[i] == nil {
+			if i == 0 {
+				panic(errors.AutoMsg("upper is nil"))
+			}
+			panic(errors.AutoMsg("lower is nil"))
+		}
+	}
+	return &overlayFS{layers: layers}
+}
+
+// overlayFS is the filesystem returned by OverlayFS.
+type overlayFS struct {
+	layers []fs.FS
+}
+
+var (
+	_ fs.ReadDirFS = (*overlayFS)(nil)
+	_ fs.StatFS    = (*overlayFS)(nil)
+)
+
+func (o *overlayFS) Open(name string) (fs.File, error) {
+	k, info, err := o.resolve("open", name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := o.layers[k].Open(name)
+	if err != nil || !info.IsDir() {
+		return f, err
+	}
+	entries, err := o.mergeDir(k, name)
+	if err != nil {
+		_ = f.Close() // ignore error
+		return nil, err
+	}
+	return &overlayDir{File: f, name: name, entries: entries}, nil
+}
+
+func (o *overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	k, info, err := o.resolve("readdir", name)
+	if err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, &fs.PathError{
+			Op:   "readdir",
+			Path: name,
+			Err:  errors.New("not a directory"),
+		}
+	}
+	return o.mergeDir(k, name)
+}
+
+func (o *overlayFS) Stat(name string) (fs.FileInfo, error) {
+	_, info, err := o.resolve("stat", name)
+	return info, err
+}
+
+// resolve finds the uppermost layer where the file with specified name
+// is visible, and returns the index of that layer and the file information.
+//
+// op is the operation name used in the returned *io/fs.PathError.
+func (o *overlayFS) resolve(op, name string) (k int, info fs.FileInfo, err error) {
+	if !fs.ValidPath(name) {
+		return -1, nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	} else if name != "." {
+		for _, elem := range strings.Split(name, "/") {
+			if strings.HasPrefix(elem, WhiteoutPrefix) {
+				return -1, nil, &fs.PathError{
+					Op:   op,
+					Path: name,
+					Err:  fs.ErrNotExist,
+				}
+			}
+		}
+	}
+	for k = range o.layers {
+		info, err = fs.Stat(o.layers[k], name)
+		if err == nil {
+			return
+		} else if o.whitedOut(k, name) {
+			break
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return -1, nil, err
+		}
+	}
+	return -1, nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+}
+
+// whitedOut reports whether the file with specified name
+// in the layers below the k-th layer is hidden by the k-th layer,
+// either by a whiteout file (of the file itself or one of its ancestors),
+// an opaque whiteout file in one of its ancestors,
+// or a non-directory file at the path of one of its ancestors.
+//
+// name must be a valid path not existing in the k-th layer.
+func (o *overlayFS) whitedOut(k int, name string) bool {
+	layer := o.layers[k]
+	for p := name; p != "."; {
+		dir, base := path.Dir(p), path.Base(p)
+		if overlayExists(layer, path.Join(dir, WhiteoutPrefix+base)) ||
+			overlayExists(layer, path.Join(dir, OpaqueWhiteout)) {
+			return true
+		} else if dir != "." {
+			info, err := fs.Stat(layer, dir)
+			if err == nil && !info.IsDir() {
+				return true
+			}
+		}
+		p = dir
+	}
+	return false
+}
+
+// overlayExists reports whether the file with specified name exists in fsys.
+func overlayExists(fsys fs.FS, name string) bool {
+	_, err := fs.Stat(fsys, name)
+	return err == nil
+}
+
+// mergeDir merges the entries of the directory with specified name
+// from the k-th layer and the layers below it,
+// and returns the entries sorted by filename.
+func (o *overlayFS) mergeDir(k int, name string) ([]fs.DirEntry, error) {
+	var entries []fs.DirEntry
+	seen := make(map[string]bool)
+	hidden := make(map[string]bool)
+	for j := k; j < len(o.layers); j++ {
+		if j > k && o.whitedOut(j-1, name) {
+			break
+		}
+		layerEntries, err := fs.ReadDir(o.layers[j], name)
+		if err != nil {
+			if j == k {
+				return nil, err
+			}
+			continue // not a directory in this layer
+		}
+		var opaque bool
+		var layerHidden []string
+		for _, e := range layerEntries {
+			n := e.Name()
+			switch {
+			case n == OpaqueWhiteout:
+				opaque = true
+			case strings.HasPrefix(n, WhiteoutPrefix):
+				layerHidden = append(layerHidden, n[len(WhiteoutPrefix):])
+			case !seen[n] && !hidden[n]:
+				seen[n] = true
+				entries = append(entries, e)
+			}
+		}
+		if opaque {
+			break
+		}
+		for _, n := range layerHidden {
+			hidden[n] = true
+		}
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return entries, nil
+}
+
+// overlayDir is a directory opened from overlayFS.
+//
+// It embeds the directory opened from the uppermost layer
+// for the methods Stat, Read, and Close,
+// and lists the merged entries in the method ReadDir.
+type overlayDir struct {
+	fs.File
+	name    string
+	entries []fs.DirEntry
+	offset  int
+}
+
+var _ fs.ReadDirFile = (*overlayDir)(nil)
+
+func (d *overlayDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n > 0 {
+		if len(rest) == 0 {
+			return nil, io.EOF
+		}
+		rest = rest[:min(n, len(rest))]
+	}
+	d.offset += len(rest)
+	return slices.Clone(rest), nil
+}
@@ -0,0 +1,162 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys_test
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/donyori/gogo/filesys"
+)
+
+func newTestOverlayFS() fs.FS {
+	upper := fstest.MapFS{
+		"a.txt":     {Data: []byte("upper a")},
+		"dir/c.txt": {Data: []byte("upper c")},
+		"dir/" + filesys.WhiteoutPrefix + "d.txt": {Data: nil},
+		filesys.WhiteoutPrefix + "gone":           {Data: nil},
+		"opaque/" + filesys.OpaqueWhiteout:        {Data: nil},
+		"opaque/new.txt":                          {Data: []byte("upper new")},
+		"blocker":                                 {Data: []byte("upper blocker")},
+	}
+	middle := fstest.MapFS{
+		"b.txt":        {Data: []byte("middle b")},
+		"dir/d.txt":    {Data: []byte("middle d")},
+		"dir/e.txt":    {Data: []byte("middle e")},
+		"gone/f.txt":   {Data: []byte("middle f")},
+		"opaque/g.txt": {Data: []byte("middle g")},
+	}
+	lower := fstest.MapFS{
+		"a.txt":          {Data: []byte("lower a")},
+		"b.txt":          {Data: []byte("lower b")},
+		"dir/e.txt":      {Data: []byte("lower e")},
+		"dir/sub/h.txt":  {Data: []byte("lower h")},
+		"blocker/i.txt":  {Data: []byte("lower i")},
+		"opaque/j.txt":   {Data: []byte("lower j")},
+		"lower-only.txt": {Data: []byte("lower only")},
+	}
+	return filesys.OverlayFS(upper, middle, lower)
+}
+
+func TestOverlayFS(t *testing.T) {
+	fsys := newTestOverlayFS()
+	err := fstest.TestFS(
+		fsys,
+		"a.txt",
+		"b.txt",
+		"blocker",
+		"dir/c.txt",
+		"dir/e.txt",
+		"dir/sub/h.txt",
+		"lower-only.txt",
+		"opaque/new.txt",
+	)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOverlayFS_Contents(t *testing.T) {
+	fsys := newTestOverlayFS()
+	testCases := []struct {
+		name string
+		want string // empty for nonexistent files
+	}{
+		{"a.txt", "upper a"},
+		{"b.txt", "middle b"},
+		{"dir/c.txt", "upper c"},
+		{"dir/d.txt", ""},
+		{"dir/e.txt", "middle e"},
+		{"dir/sub/h.txt", "lower h"},
+		{"gone/f.txt", ""},
+		{"gone", ""},
+		{"opaque/new.txt", "upper new"},
+		{"opaque/g.txt", ""},
+		{"opaque/j.txt", ""},
+		{"blocker", "upper blocker"},
+		{"blocker/i.txt", ""},
+		{"dir/" + filesys.WhiteoutPrefix + "d.txt", ""},
+	}
+	for _, tc := range testCases {
+		t.Run("name="+tc.name, func(t *testing.T) {
+			data, err := fs.ReadFile(fsys, tc.name)
+			if tc.want == "" {
+				if !errors.Is(err, fs.ErrNotExist) {
+					t.Errorf("got (%q, %v); want %v", data, err, fs.ErrNotExist)
+				}
+			} else if err != nil || string(data) != tc.want {
+				t.Errorf("got (%q, %v); want (%q, nil)", data, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestOverlayFS_ReadDir(t *testing.T) {
+	fsys := newTestOverlayFS()
+	testCases := []struct {
+		name string
+		want []string
+	}{
+		{".", []string{"a.txt", "b.txt", "blocker", "dir", "lower-only.txt", "opaque"}},
+		{"dir", []string{"c.txt", "e.txt", "sub"}},
+		{"opaque", []string{"new.txt"}},
+	}
+	for _, tc := range testCases {
+		t.Run("name="+tc.name, func(t *testing.T) {
+			entries, err := fs.ReadDir(fsys, tc.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, len(entries))
+			for i := range entries {
+				got[i] = entries[i].Name()
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("got %q; want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestOverlayFS_ReadFromFS(t *testing.T) {
+	upper := fstest.MapFS{}
+	name := testFSGzFilenames[0]
+	fsys := filesys.OverlayFS(upper, testFS)
+	r, err := filesys.ReadFromFS(fsys, name, nil)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	defer func(r filesys.Reader) {
+		if err := r.Close(); err != nil {
+			t.Error("close -", err)
+		}
+	}(r)
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal("read -", err)
+	}
+	want := testFS[name[:len(name)-3]].Data
+	if string(got) != string(want) {
+		t.Errorf("got (len: %d); want (len: %d)", len(got), len(want))
+	}
+}
@@ -0,0 +1,217 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/binary"
+	"hash"
+	"hash/crc32"
+	"io"
+	"runtime"
+	"sync"
+
+	"github.com/donyori/gogo/errors"
+)
+
+const (
+	// parallelGzipBlockSize is the size of the uncompressed data
+	// compressed by one goroutine at a time.
+	parallelGzipBlockSize = 1 << 20
+
+	// parallelGzipDictSize is the size of the preset dictionary
+	// taken from the end of the previous block,
+	// which is the window size of DEFLATE.
+	parallelGzipDictSize = 32 << 10
+)
+
+// parallelGzipWriter is a gzip writer that splits the data into blocks
+// and compresses them in parallel.
+//
+// Each block is compressed into a sequence of DEFLATE blocks
+// ending with a sync flush (except for the last one),
+// using the end of the previous block as the preset dictionary,
+// so the output is a single standard gzip member.
+type parallelGzipWriter struct {
+	w     io.Writer
+	level int
+	buf   []byte // the current block
+	dict  []byte // the end of the previous block
+	crc   hash.Hash32
+	size  uint32 // the uncompressed size modulo 2^32
+
+	pending chan chan parallelGzipResult // the compressed blocks in order
+	done    chan struct{}                // closed when the output goroutine exits
+	errLock sync.Mutex
+	err     error
+	closed  bool
+}
+
+// parallelGzipResult is the result of compressing a block.
+type parallelGzipResult struct {
+	data []byte
+	err  error
+}
+
+// newParallelGzipWriter creates a parallelGzipWriter on w
+// with the specified compression level, header, and number of workers.
+//
+// If workers is nonpositive, runtime.GOMAXPROCS(0) is used.
+func newParallelGzipWriter(
+	w io.Writer,
+	level int,
+	hdr *gzip.Header,
+	workers int,
+) (*parallelGzipWriter, error) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	// Write the gzip header through compress/gzip.Writer.
+	// Its method Flush writes the header followed by an empty,
+	// non-final DEFLATE block, after which the blocks can be appended.
+	gw, err := gzip.NewWriterLevel(w, level)
+	if err != nil {
+		return nil, err
+	}
+	if hdr != nil {
+		gw.Header = *hdr
+	}
+	err = gw.Flush()
+	if err != nil {
+		return nil, err
+	}
+	pw := &parallelGzipWriter{
+		w:       w,
+		level:   level,
+		buf:     make([]byte, 0, parallelGzipBlockSize),
+		crc:     crc32.NewIEEE(),
+		pending: make(chan chan parallelGzipResult, workers),
+		done:    make(chan struct{}),
+	}
+	go pw.output()
+	return pw, nil
+}
+
+func (pw *parallelGzipWriter) Write(p []byte) (n int, err error) {
+	if pw.closed {
+		return 0, errors.AutoWrap(ErrFileWriterClosed)
+	} else if err = pw.getErr(); err != nil {
+		return 0, errors.AutoWrap(err)
+	}
+	for len(p) > 0 {
+		k := copy(pw.buf[len(pw.buf):cap(pw.buf)], p)
+		pw.buf = pw.buf[:len(pw.buf)+k]
+		_, _ = pw.crc.Write(p[:k]) // never returns an error
+		pw.size += uint32(k)
+		n, p = n+k, p[k:]
+		if len(pw.buf) == cap(pw.buf) {
+			pw.dispatch(false)
+		}
+	}
+	return
+}
+
+// Close compresses the remaining data, waits for all blocks to be written,
+// and writes the gzip trailer.
+//
+// It does not close the underlying writer.
+func (pw *parallelGzipWriter) Close() error {
+	if pw.closed {
+		return nil
+	}
+	pw.closed = true
+	pw.dispatch(true)
+	close(pw.pending)
+	<-pw.done
+	if err := pw.getErr(); err != nil {
+		return errors.AutoWrap(err)
+	}
+	var trailer [8]byte
+	binary.LittleEndian.PutUint32(trailer[:4], pw.crc.Sum32())
+	binary.LittleEndian.PutUint32(trailer[4:], pw.size)
+	_, err := pw.w.Write(trailer[:])
+	return errors.AutoWrap(err)
+}
+
+// dispatch starts compressing the current block in a new goroutine.
+//
+// final indicates whether the block is the last one.
+func (pw *parallelGzipWriter) dispatch(final bool) {
+	data, dict := pw.buf, pw.dict
+	if !final {
+		// Non-final blocks are full, and they are never modified
+		// after dispatching, so their ends can be used as dictionaries.
+		pw.dict = data[len(data)-parallelGzipDictSize:]
+		pw.buf = make([]byte, 0, parallelGzipBlockSize)
+	}
+	c := make(chan parallelGzipResult, 1)
+	// Block when there are too many blocks in progress.
+	pw.pending <- c
+	go func() {
+		var out bytes.Buffer
+		fw, err := flate.NewWriterDict(&out, pw.level, dict)
+		if err == nil {
+			_, err = fw.Write(data)
+		}
+		if err == nil {
+			if final {
+				err = fw.Close()
+			} else {
+				err = fw.Flush()
+			}
+		}
+		c <- parallelGzipResult{data: out.Bytes(), err: err}
+	}()
+}
+
+// output writes the compressed blocks to the underlying writer in order.
+func (pw *parallelGzipWriter) output() {
+	defer close(pw.done)
+	for c := range pw.pending {
+		r := <-c
+		if pw.getErr() != nil {
+			continue // drain the pending blocks
+		} else if r.err != nil {
+			pw.setErr(r.err)
+			continue
+		}
+		_, err := pw.w.Write(r.data)
+		if err != nil {
+			pw.setErr(err)
+		}
+	}
+}
+
+// getErr returns the first error encountered.
+func (pw *parallelGzipWriter) getErr() error {
+	pw.errLock.Lock()
+	defer pw.errLock.Unlock()
+	return pw.err
+}
+
+// setErr records err if no error has been recorded.
+func (pw *parallelGzipWriter) setErr(err error) {
+	pw.errLock.Lock()
+	defer pw.errLock.Unlock()
+	if pw.err == nil {
+		pw.err = err
+	}
+}
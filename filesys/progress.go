@@ -0,0 +1,93 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// progressCounter counts the bytes transferred
+// and reports the progress to a callback function.
+//
+// The counter is updated atomically, as the files in a ZIP archive
+// may be read concurrently through the underlying io.ReaderAt.
+type progressCounter struct {
+	n     atomic.Int64         // the number of bytes transferred
+	total int64                // the total number of bytes, or -1 if unknown
+	fn    func(n, total int64) // the callback function, or nil
+}
+
+// add adds k to the counter and calls the callback function if k > 0.
+func (pc *progressCounter) add(k int) {
+	if k > 0 {
+		n := pc.n.Add(int64(k))
+		if pc.fn != nil {
+			pc.fn(n, pc.total)
+		}
+	}
+}
+
+// progressReader wraps an io.Reader to count the bytes read.
+type progressReader struct {
+	*progressCounter
+	r io.Reader
+}
+
+func (pr *progressReader) Read(p []byte) (n int, err error) {
+	n, err = pr.r.Read(p)
+	pr.add(n)
+	return
+}
+
+// progressReaderAt is like progressReader,
+// but also implements io.ReaderAt.
+type progressReaderAt struct {
+	progressReader
+	ra io.ReaderAt
+}
+
+func (pr *progressReaderAt) ReadAt(p []byte, off int64) (n int, err error) {
+	n, err = pr.ra.ReadAt(p, off)
+	pr.add(n)
+	return
+}
+
+// newProgressReader wraps r to count the bytes read with pc.
+//
+// The returned reader implements io.ReaderAt if r does.
+func newProgressReader(r io.Reader, pc *progressCounter) io.Reader {
+	pr := progressReader{progressCounter: pc, r: r}
+	if ra, ok := r.(io.ReaderAt); ok {
+		return &progressReaderAt{progressReader: pr, ra: ra}
+	}
+	return &pr
+}
+
+// progressWriter wraps an io.Writer to count the bytes written.
+type progressWriter struct {
+	*progressCounter
+	w io.Writer
+}
+
+func (pw *progressWriter) Write(p []byte) (n int, err error) {
+	n, err = pw.w.Write(p)
+	pw.add(n)
+	return
+}
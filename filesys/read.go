@@ -21,16 +21,21 @@ package filesys
 import (
 	"archive/tar"
 	"archive/zip"
+	"bufio"
+	"bytes"
 	"compress/bzip2"
 	"compress/gzip"
 	"fmt"
 	"io"
 	"io/fs"
 	"maps"
+	"net/http"
 	"path"
 	"slices"
 	"strings"
 
+	"github.com/klauspost/compress/zstd"
+
 	"github.com/donyori/gogo/errors"
 	"github.com/donyori/gogo/inout"
 )
@@ -50,10 +55,59 @@ type ReadOptions struct {
 	// Nonpositive values for no limit.
 	Limit int64
 
-	// True if not to decompress when the file is compressed by gzip or bzip2,
+	// True if not to decompress when the file is compressed by
+	// gzip, bzip2, or Zstandard (zstd),
 	// and not to restore when the file is archived by tar (i.e., tape archive).
 	Raw bool
 
+	// True if to detect the compression and archive formats
+	// from the content (i.e., the magic bytes) of the file
+	// instead of the filename extension.
+	//
+	// The supported formats are gzip, bzip2, Zstandard (zstd), tar, and ZIP.
+	// The decompressors are chained as needed;
+	// for example, a tar archive compressed by gzip is restored
+	// regardless of whether the filename extension is ".tgz", ".gz",
+	// or something else.
+	// A file in an unrecognized format is read as is.
+	//
+	// To detect a ZIP archive, the file (after applying Offset and Limit)
+	// must implement io.ReaderAt, or ZipReaderAtFunc must be specified.
+	//
+	// This option is ignored if Raw is true.
+	SniffContent bool
+
+	// The maximum window size allowed when decompressing
+	// the Zstandard (zstd) stream, in bytes.
+	// Streams requiring a larger window are rejected.
+	// If nonzero, it must be at least 1024 (1 KiB).
+	// Zero for using default value (see package
+	// github.com/klauspost/compress/zstd for details).
+	//
+	// This option only takes effect when Raw is false,
+	// and the file extension is ".zst" or ".tzst".
+	ZstdMaxWindow uint64
+
+	// An extension-decompressor map for decompressing files
+	// in custom formats, such as LZ4 and Snappy.
+	//
+	// The keys are filename extensions including the leading dot
+	// (e.g., ".lz4"), and are case-insensitive.
+	// The values are functions that wrap the compressed data reader
+	// with a decompressor.
+	// The returned io.ReadCloser is closed when the Reader is closed.
+	// (Nil functions are ignored.)
+	//
+	// These decompressors take precedence over the built-in ones
+	// (for ".gz", ".bz2", and ".zst"), and are chained in the same way;
+	// for example, the file "a.tar.lz4" is decompressed
+	// by the decompressor for ".lz4" and then restored by tar.
+	// The keys ".tar", ".zip", ".tgz", ".tbz", and ".tzst" are ignored.
+	//
+	// This option only takes effect when Raw is false
+	// and SniffContent is false.
+	ExtDecompressors map[string]func(r io.Reader) (io.ReadCloser, error)
+
 	// A method-decompressor map for reading the ZIP archive.
 	// These decompressors are registered to the archive/zip.Reader.
 	// (Nil decompressors are ignored.)
@@ -73,6 +127,59 @@ type ReadOptions struct {
 	//
 	// It will only be called when the reader does not implement io.ReaderAt.
 	ZipReaderAtFunc func(r io.Reader) (ra io.ReaderAt, size int64, err error)
+
+	// A callback function to report the reading progress.
+	//
+	// It is called each time data is read from the file,
+	// with n being the number of bytes read from the file so far
+	// (the same as the method BytesRead of Reader returns)
+	// and total being the number of bytes that can be read from the file
+	// (i.e., the file size minus the offset, limited by the option Limit),
+	// or -1 if the file is not a regular file.
+	// Since the data is read from the file through buffers,
+	// it is called once per buffer fill rather than once per method call.
+	//
+	// For a ZIP archive, the data may be read from the file
+	// more than once, so n may exceed total.
+	// In addition, if the files in the ZIP archive are read concurrently,
+	// Progress may be called concurrently by multiple goroutines.
+	//
+	// Nil for no callback.
+	Progress func(n, total int64)
+
+	// A function that wraps the reader of the file content
+	// to decode the text in a character encoding other than UTF-8
+	// into UTF-8, such as DecodeUTF16LE, DecodeUTF16BE, DecodeUTF16,
+	// and DecodeLatin1.
+	// Custom decoders (e.g., from package golang.org/x/text/encoding)
+	// can also be used.
+	//
+	// The decoder is applied after decompression,
+	// so the methods of Reader (e.g., ReadLine and ReadEntireLine)
+	// yield the decoded text.
+	// It does not take effect when the file is archived by tar or ZIP
+	// (and is not opened in raw mode).
+	//
+	// Nil for no decoding (i.e., the file content is used as is).
+	Encoding func(r io.Reader) io.Reader
+
+	// True if to remove the leading byte order mark (U+FEFF)
+	// from the file content (after decoding by Encoding, if any).
+	//
+	// Like Encoding, it does not take effect when the file is archived
+	// by tar or ZIP (and is not opened in raw mode).
+	StripBOM bool
+
+	// A pool of buffers for the reader.
+	//
+	// If it is not nil, the buffer of the reader is taken from the pool
+	// and is put back into the pool when the reader is closed,
+	// which saves allocations when creating many readers.
+	// In this case, the option BufSize is ignored,
+	// and the buffer size is determined by the pool.
+	//
+	// Nil for allocating a new buffer for each reader.
+	BufferPool *ReadBufferPool
 }
 
 // Reader is a device to read data from a file.
@@ -87,6 +194,17 @@ type Reader interface {
 	inout.Closer
 	inout.BufferedReader
 
+	// GzipHeader returns a copy of the header of the gzip stream,
+	// including the original filename, modification time, and comment.
+	//
+	// If the file is compressed by gzip multiple times,
+	// it returns the header of the outermost gzip stream.
+	//
+	// If the file is not compressed by gzip or is opened in raw mode,
+	// it does nothing and reports ErrNotGzip.
+	// (To test whether err is ErrNotGzip, use function errors.Is.)
+	GzipHeader() (hdr *gzip.Header, err error)
+
 	// TarEnabled returns true if the file is archived by tar
 	// (i.e., tape archive) and is not opened in raw mode.
 	TarEnabled() bool
@@ -96,6 +214,9 @@ type Reader interface {
 	// The tar.Header.Size determines how many bytes can be read
 	// for the next file.
 	// Any remaining data in current file is automatically discarded.
+	// The holes in a sparse file (e.g., written by
+	// the method TarWriteSparse of Writer) are read as zeros,
+	// and tar.Header.Size is the size of the entire file.
 	//
 	// io.EOF is returned at the end of the input.
 	//
@@ -104,6 +225,62 @@ type Reader interface {
 	// (To test whether err is ErrNotTar, use function errors.Is.)
 	TarNext() (hdr *tar.Header, err error)
 
+	// TarFS returns a file system (an io/fs.FS) consisting of
+	// the entries in the tar archive.
+	// The returned file system also implements io/fs.ReadDirFS
+	// and io/fs.StatFS.
+	//
+	// If the tar archive is not compressed and the underlying file
+	// implements io.ReaderAt, only the headers and offsets of the entries
+	// are indexed, and the file contents are read from the underlying file
+	// on demand, without affecting TarNext.
+	// In this case, the file contents cannot be read
+	// after the reader is closed.
+	// Otherwise, TarFS reads the remaining entries of the tar archive
+	// (i.e., those after the entry returned by the last call to TarNext)
+	// into memory, after which TarNext returns io.EOF.
+	//
+	// The index is built on the first call and is reused afterward.
+	//
+	// Entry names are cleaned (e.g., "./a//b" becomes "a/b"),
+	// and entries with invalid names (see io/fs.ValidPath) are ignored.
+	// If there are multiple entries with the same name,
+	// the last one takes effect.
+	// Parent directories without their own entries are synthesized.
+	// Hard links are resolved to their targets in the archive.
+	//
+	// If the file is not archived by tar or is opened in raw mode,
+	// it does nothing and reports ErrNotTar.
+	// (To test whether err is ErrNotTar, use function errors.Is.)
+	TarFS() (fsys fs.FS, err error)
+
+	// TarOpen opens the entry with the specified name in the tar archive,
+	// without the client iterating over the entries through TarNext.
+	//
+	// The name must satisfy io/fs.ValidPath,
+	// and is compared with the cleaned entry names (see TarFS).
+	//
+	// If the tar archive is not compressed and the underlying file
+	// implements io.ReaderAt, or the index has been built by TarFS,
+	// TarOpen uses the index of the entries (building it on the first call
+	// if necessary), so repeated calls take constant time,
+	// and the returned file is the same as that opened by TarFS.
+	//
+	// Otherwise, TarOpen scans forward from the current position
+	// (as TarNext does) for the first entry with the specified name,
+	// and the returned file reads the contents from the tar stream.
+	// In this case, the returned file is valid only until
+	// the next call to TarNext, TarOpen, TarFS, or Close,
+	// and the entries before it are skipped.
+	// If no such entry is found, TarOpen reports an error
+	// that satisfies errors.Is(err, io/fs.ErrNotExist),
+	// and the tar stream is exhausted.
+	//
+	// If the file is not archived by tar or is opened in raw mode,
+	// it does nothing and reports ErrNotTar.
+	// (To test whether err is ErrNotTar, use function errors.Is.)
+	TarOpen(name string) (file fs.File, err error)
+
 	// ZipEnabled returns true if the file is archived by ZIP
 	// and is not opened in raw mode.
 	ZipEnabled() bool
@@ -119,7 +296,23 @@ type Reader interface {
 	// (To test whether the error is ErrNotZip, use function errors.Is.)
 	ZipOpen(name string) (file fs.File, err error)
 
+	// ZipFS returns a file system (an io/fs.FS) consisting of
+	// the files in the ZIP archive.
+	//
+	// The returned file system follows the same naming rules as ZipOpen.
+	// It can be used with io/fs.Sub, html/template.ParseFS,
+	// Writer.ZipAddFS, and so on.
+	//
+	// The returned file system reads the contents from the reader's file,
+	// so it is valid only until the reader is closed.
+	//
+	// If the reader's file is not archived by ZIP or is opened in raw mode,
+	// it does nothing and reports ErrNotZip.
+	// (To test whether the error is ErrNotZip, use function errors.Is.)
+	ZipFS() (fsys fs.FS, err error)
+
 	// ZipFiles returns the files in the ZIP archive, sorted by filename.
+	// The files with the same name keep their order in the archive.
 	//
 	// If the reader's file is not archived by ZIP or is opened in raw mode,
 	// it does nothing and reports ErrNotZip.
@@ -134,6 +327,39 @@ type Reader interface {
 	// (To test whether the error is ErrNotZip, use function errors.Is.)
 	ZipComment() (comment string, err error)
 
+	// ContentType detects the MIME type of the data to be read
+	// using the algorithm described at https://mimesniff.spec.whatwg.org/
+	// (as implemented by net/http.DetectContentType),
+	// and returns a valid MIME type
+	// ("application/octet-stream" if no more specific type is detected).
+	//
+	// It considers at most the first 512 bytes of the data
+	// (limited by the buffer size) to be read,
+	// without consuming them.
+	// If the file is not opened in raw mode, the data are decompressed;
+	// for example, ContentType of "a.txt.gz" is
+	// "text/plain; charset=utf-8" rather than "application/x-gzip".
+	// If the file is a tar archive, the data are that of the current entry.
+	//
+	// If the file is a ZIP archive and is not opened in raw mode,
+	// it reports ErrReadZip.
+	// (To test whether err is ErrReadZip, use function errors.Is.)
+	ContentType() (contentType string, err error)
+
+	// BytesRead returns the number of bytes read from the file so far,
+	// excluding the bytes skipped for the option Offset.
+	//
+	// For compressed files and archives, it counts the bytes
+	// read from the file rather than the bytes decompressed,
+	// which is suitable for reporting the progress against the file size.
+	// The data buffered but not yet consumed by the client is also counted.
+	BytesRead() int64
+
+	// Stats returns the statistics of the data read so far,
+	// including the raw (compressed) bytes, the logical (decompressed) bytes,
+	// and the elapsed time (see Stats for details).
+	Stats() Stats
+
 	// Options returns a copy of options used by this reader.
 	Options() *ReadOptions
 
@@ -151,8 +377,16 @@ type reader struct {
 	c    inout.Closer
 	opts ReadOptions
 	f    fs.File
+	gr   *gzip.Reader // the outermost gzip reader, or nil
 	tr   *tar.Reader
 	zr   *zip.Reader
+
+	tarRA  *io.SectionReader // the uncompressed tar archive for random access, or nil
+	tarIdx *tarIndex         // the index of the tar entries, or nil if not built
+
+	pc progressCounter // the counter for the bytes read from the file
+	cr countReader     // the reader underlying the buffer, counting the logical bytes
+	sw stopwatch
 }
 
 // Read creates a reader on the specified file with options opts.
@@ -229,10 +463,28 @@ func Read(file fs.File, opts *ReadOptions, closeFile bool) (
 			Offset:          opts.Offset,
 			Limit:           opts.Limit,
 			Raw:             opts.Raw,
+			SniffContent:    opts.SniffContent,
+			ZstdMaxWindow:   opts.ZstdMaxWindow,
 			ZipDcomp:        maps.Clone(opts.ZipDcomp),
 			ZipReaderAtFunc: opts.ZipReaderAtFunc,
+			Progress:        opts.Progress,
+			Encoding:        opts.Encoding,
+			StripBOM:        opts.StripBOM,
+			BufferPool:      opts.BufferPool,
 		},
-		f: file,
+		f:  file,
+		sw: startStopwatch(),
+	}
+	if len(opts.ExtDecompressors) > 0 {
+		fr.opts.ExtDecompressors = make(
+			map[string]func(r io.Reader) (io.ReadCloser, error),
+			len(opts.ExtDecompressors),
+		)
+		for ext, dcomp := range opts.ExtDecompressors {
+			if dcomp != nil {
+				fr.opts.ExtDecompressors[strings.ToLower(ext)] = dcomp
+			}
+		}
 	}
 	maps.DeleteFunc(
 		fr.opts.ZipDcomp,
@@ -282,10 +534,27 @@ func (fr *reader) init(
 	if err != nil {
 		return err
 	}
-	err = fr.initRaw(info, n, pClosers)
+	fr.pc.total, fr.pc.fn = -1, fr.opts.Progress
+	if info.Mode().IsRegular() {
+		fr.pc.total = n
+	}
+	fr.ur = newProgressReader(fr.ur, &fr.pc)
+	if fr.opts.SniffContent && !fr.opts.Raw {
+		err = fr.initSniff(n, pClosers)
+	} else {
+		err = fr.initRaw(info, n, pClosers)
+	}
 	if err != nil {
 		return err
 	}
+	if fr.tr == nil && fr.zr == nil {
+		if fr.opts.Encoding != nil {
+			fr.ur = fr.opts.Encoding(fr.ur)
+		}
+		if fr.opts.StripBOM {
+			fr.ur = &bomStripReader{r: fr.ur}
+		}
+	}
 	fr.initCloserAndBuffer(*pClosers)
 	return nil
 }
@@ -353,6 +622,16 @@ func (fr *reader) initRaw(
 	for loop {
 		name = name[:len(name)-len(ext)]
 		ext = path.Ext(name)
+		if dcomp := fr.opts.ExtDecompressors[ext]; dcomp != nil &&
+			!isReservedExt(ext) {
+			rc, err := dcomp(fr.ur)
+			if err != nil {
+				return err
+			}
+			*pClosers = append(*pClosers, rc)
+			fr.ur = rc
+			continue
+		}
 		switch ext {
 		case ".tgz":
 			name = name[:len(name)-len(ext)] + ".tar.gz"
@@ -360,40 +639,38 @@ func (fr *reader) initRaw(
 		case ".tbz":
 			name = name[:len(name)-len(ext)] + ".tar.bz2"
 			ext = ""
+		case ".tzst":
+			name = name[:len(name)-len(ext)] + ".tar.zst"
+			ext = ""
 		case ".gz":
 			gr, err := gzip.NewReader(fr.ur)
 			if err != nil {
 				return err
 			}
 			*pClosers = append(*pClosers, gr)
+			if fr.gr == nil {
+				fr.gr = gr
+			}
 			fr.ur = gr
 		case ".bz2":
 			fr.ur = bzip2.NewReader(fr.ur)
+		case ".zst":
+			err := fr.initZstd(pClosers)
+			if err != nil {
+				return err
+			}
 		case ".tar":
+			if ra, ok := fr.ur.(io.ReaderAt); ok && size >= 0 {
+				fr.tarRA = io.NewSectionReader(ra, 0, size)
+			}
 			fr.tr = tar.NewReader(fr.ur)
 			fr.ur = fr.tr
 			loop = false
 		case ".zip":
-			r, ok := fr.ur.(io.ReaderAt)
-			var err error
-			if !ok {
-				if fr.opts.ZipReaderAtFunc != nil {
-					r, size, err = fr.opts.ZipReaderAtFunc(fr.ur)
-					if err != nil {
-						return err
-					}
-				} else {
-					return errors.New("cannot wrap to io.ReaderAt")
-				}
-			}
-			fr.zr, err = zip.NewReader(r, size)
+			err := fr.initZip(size)
 			if err != nil {
 				return err
 			}
-			for method, dcomp := range fr.opts.ZipDcomp {
-				fr.zr.RegisterDecompressor(method, dcomp)
-			}
-			fr.ur, fr.err = readZipErrorReader, ErrReadZip
 			loop = false
 		default:
 			loop = false
@@ -402,6 +679,118 @@ func (fr *reader) initRaw(
 	return nil
 }
 
+// isReservedExt reports whether ext is a filename extension
+// that cannot be overridden by the option ExtDecompressors.
+func isReservedExt(ext string) bool {
+	switch ext {
+	case ".tar", ".zip", ".tgz", ".tbz", ".tzst":
+		return true
+	}
+	return false
+}
+
+// initSniff is like initRaw, but detects the formats
+// from the content of the file instead of the filename extension.
+//
+// size is obtained from initOffsetAndLimit, not the file size.
+//
+// It may update closers.
+func (fr *reader) initSniff(size int64, pClosers *[]io.Closer) error {
+	// Detect the ZIP archive before wrapping fr.ur with a buffer,
+	// which would hide the method ReadAt of fr.ur.
+	if ra, ok := fr.ur.(io.ReaderAt); ok {
+		var magic [4]byte
+		n, err := ra.ReadAt(magic[:], 0)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		} else if isZipMagic(magic[:n]) {
+			return fr.initZip(size)
+		}
+	}
+	for {
+		br := bufio.NewReaderSize(fr.ur, tarBlockSize)
+		fr.ur = br
+		head, err := br.Peek(tarBlockSize)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		switch {
+		case bytes.HasPrefix(head, gzipMagic):
+			gr, err := gzip.NewReader(fr.ur)
+			if err != nil {
+				return err
+			}
+			*pClosers = append(*pClosers, gr)
+			if fr.gr == nil {
+				fr.gr = gr
+			}
+			fr.ur = gr
+		case len(head) >= 4 && bytes.HasPrefix(head, bzip2Magic) &&
+			head[3] >= '1' && head[3] <= '9':
+			fr.ur = bzip2.NewReader(fr.ur)
+		case bytes.HasPrefix(head, zstdMagic):
+			err = fr.initZstd(pClosers)
+			if err != nil {
+				return err
+			}
+		case isZipMagic(head):
+			return fr.initZip(-1)
+		case isTarHeader(head):
+			fr.tr = tar.NewReader(fr.ur)
+			fr.ur = fr.tr
+			return nil
+		default:
+			return nil
+		}
+	}
+}
+
+// initZstd wraps fr.ur with a Zstandard decompressor.
+//
+// It updates closers.
+func (fr *reader) initZstd(pClosers *[]io.Closer) error {
+	var dOpts []zstd.DOption
+	if fr.opts.ZstdMaxWindow != 0 {
+		dOpts = append(dOpts, zstd.WithDecoderMaxWindow(fr.opts.ZstdMaxWindow))
+	}
+	zr, err := zstd.NewReader(fr.ur, dOpts...)
+	if err != nil {
+		return err
+	}
+	rc := zr.IOReadCloser()
+	*pClosers = append(*pClosers, rc)
+	fr.ur = rc
+	return nil
+}
+
+// initZip creates fr.zr on fr.ur.
+//
+// size is the size of the ZIP archive,
+// which is ignored if fr.ur is not an io.ReaderAt.
+func (fr *reader) initZip(size int64) error {
+	r, ok := fr.ur.(io.ReaderAt)
+	var err error
+	if !ok {
+		if fr.opts.ZipReaderAtFunc != nil {
+			r, size, err = fr.opts.ZipReaderAtFunc(fr.ur)
+			if err != nil {
+				return err
+			}
+		} else {
+			return errors.New("cannot wrap to io.ReaderAt")
+		}
+	}
+	fr.zr, err = zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+	for method, dcomp := range fr.opts.ZipDcomp {
+		fr.zr.RegisterDecompressor(method, dcomp)
+	}
+	fr.ur, fr.err = readZipErrorReader, ErrReadZip
+	return nil
+}
+
 // initCloserAndBuffer sets fr.c and creates a buffer.
 func (fr *reader) initCloserAndBuffer(closers []io.Closer) {
 	switch len(closers) {
@@ -412,21 +801,40 @@ func (fr *reader) initCloserAndBuffer(closers []io.Closer) {
 	default:
 		fr.c = inout.NewMultiCloser(true, true, closers...)
 	}
-	if fr.opts.BufSize <= 0 {
-		fr.br = inout.NewBufferedReader(fr.ur)
+	fr.cr.r = fr.ur
+	if fr.opts.BufferPool != nil {
+		fr.br = fr.opts.BufferPool.get(&fr.cr)
+	} else if fr.opts.BufSize <= 0 {
+		fr.br = inout.NewBufferedReader(&fr.cr)
 	} else {
-		fr.br = inout.NewBufferedReaderSize(fr.ur, fr.opts.BufSize)
+		fr.br = inout.NewBufferedReaderSize(&fr.cr, fr.opts.BufSize)
 	}
 }
 
+// resetBuffer discards the buffered data and
+// resets the buffer to read from fr.ur.
+func (fr *reader) resetBuffer() {
+	fr.cr.r = fr.ur
+	fr.br.Reset(&fr.cr)
+}
+
 func (fr *reader) Close() error {
 	if fr.c.Closed() {
 		return nil
 	}
 	err := fr.c.Close()
 	if fr.c.Closed() {
+		fr.sw.stop()
 		fr.ur, fr.err = closedErrorReader, ErrFileReaderClosed
-		fr.br.Reset(fr.ur)
+		if fr.opts.BufferPool != nil {
+			fr.opts.BufferPool.put(fr.br)
+			// The pooled buffer may be reused by other readers,
+			// so replace it with a minimal one.
+			fr.cr.r = fr.ur
+			fr.br = inout.NewBufferedReaderSize(&fr.cr, 0)
+		} else {
+			fr.resetBuffer()
+		}
 	}
 	return errors.AutoWrap(err)
 }
@@ -557,6 +965,21 @@ func (fr *reader) Peek(n int) (data []byte, err error) {
 	return data, errors.AutoWrap(err)
 }
 
+func (fr *reader) ContentType() (contentType string, err error) {
+	if fr.err != nil {
+		return "", errors.AutoWrap(fr.err)
+	}
+	data, err := fr.br.Peek(min(contentTypeSniffLen, fr.br.Size()))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", errors.AutoWrap(err)
+	}
+	return http.DetectContentType(data), nil
+}
+
+// contentTypeSniffLen is the maximum number of bytes
+// used by net/http.DetectContentType.
+const contentTypeSniffLen = 512
+
 func (fr *reader) Discard(n int) (discarded int, err error) {
 	if fr.err != nil {
 		return 0, errors.AutoWrap(fr.err)
@@ -565,6 +988,15 @@ func (fr *reader) Discard(n int) (discarded int, err error) {
 	return discarded, errors.AutoWrap(err)
 }
 
+func (fr *reader) GzipHeader() (hdr *gzip.Header, err error) {
+	if fr.gr == nil {
+		return nil, errors.AutoWrap(ErrNotGzip)
+	} else if fr.c.Closed() {
+		return nil, errors.AutoWrap(ErrFileReaderClosed)
+	}
+	return cloneGzipHeader(&fr.gr.Header), nil
+}
+
 func (fr *reader) TarEnabled() bool {
 	return fr.tr != nil
 }
@@ -584,10 +1016,55 @@ func (fr *reader) TarNext() (hdr *tar.Header, err error) {
 	default:
 		fr.ur, fr.err = fr.tr, nil
 	}
-	fr.br.Reset(fr.ur)
+	fr.resetBuffer()
 	return hdr, errors.AutoWrap(err)
 }
 
+func (fr *reader) TarFS() (fsys fs.FS, err error) {
+	if fr.tr == nil {
+		return nil, errors.AutoWrap(ErrNotTar)
+	} else if fr.c.Closed() {
+		return nil, errors.AutoWrap(ErrFileReaderClosed)
+	}
+	err = fr.tarBuildIndex()
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	return fr.tarIdx, nil
+}
+
+func (fr *reader) TarOpen(name string) (file fs.File, err error) {
+	if fr.tr == nil {
+		return nil, errors.AutoWrap(ErrNotTar)
+	} else if fr.c.Closed() {
+		return nil, errors.AutoWrap(ErrFileReaderClosed)
+	} else if !fs.ValidPath(name) {
+		return nil, errors.AutoWrap(
+			&fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid})
+	}
+	if fr.tarIdx == nil && fr.tarRA != nil {
+		err = fr.tarBuildIndex()
+		if err != nil {
+			return nil, errors.AutoWrap(err)
+		}
+	}
+	if fr.tarIdx != nil {
+		file, err = fr.tarIdx.Open(name)
+		return file, errors.AutoWrap(err)
+	}
+	for {
+		hdr, err := fr.TarNext()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.AutoWrap(
+				&fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist})
+		} else if err != nil {
+			return nil, errors.AutoWrap(err)
+		} else if cleaned, ok := cleanTarName(hdr.Name); ok && cleaned == name {
+			return &tarStreamFile{fr: fr, info: hdr.FileInfo()}, nil
+		}
+	}
+}
+
 func (fr *reader) ZipEnabled() bool {
 	return fr.zr != nil
 }
@@ -602,6 +1079,15 @@ func (fr *reader) ZipOpen(name string) (file fs.File, err error) {
 	return file, errors.AutoWrap(err)
 }
 
+func (fr *reader) ZipFS() (fsys fs.FS, err error) {
+	if fr.zr == nil {
+		return nil, errors.AutoWrap(ErrNotZip)
+	} else if fr.c.Closed() {
+		return nil, errors.AutoWrap(ErrFileReaderClosed)
+	}
+	return fr.zr, nil
+}
+
 func (fr *reader) ZipFiles() (files []*zip.File, err error) {
 	switch {
 	case fr.zr == nil:
@@ -613,7 +1099,7 @@ func (fr *reader) ZipFiles() (files []*zip.File, err error) {
 	}
 	files = make([]*zip.File, len(fr.zr.File))
 	copy(files, fr.zr.File)
-	slices.SortFunc(files, func(a, b *zip.File) int {
+	slices.SortStableFunc(files, func(a, b *zip.File) int {
 		if a.Name < b.Name {
 			return -1
 		} else if a.Name > b.Name {
@@ -633,14 +1119,33 @@ func (fr *reader) ZipComment() (comment string, err error) {
 	return fr.zr.Comment, nil
 }
 
+func (fr *reader) BytesRead() int64 {
+	return fr.pc.n.Load()
+}
+
+func (fr *reader) Stats() Stats {
+	return Stats{
+		RawBytes:     fr.pc.n.Load(),
+		LogicalBytes: fr.cr.n,
+		Elapsed:      fr.sw.get(),
+	}
+}
+
 func (fr *reader) Options() *ReadOptions {
 	opts := &ReadOptions{
-		BufSize:         fr.opts.BufSize,
-		Offset:          fr.opts.Offset,
-		Limit:           fr.opts.Limit,
-		Raw:             fr.opts.Raw,
-		ZipDcomp:        maps.Clone(fr.opts.ZipDcomp),
-		ZipReaderAtFunc: fr.opts.ZipReaderAtFunc,
+		BufSize:          fr.opts.BufSize,
+		Offset:           fr.opts.Offset,
+		Limit:            fr.opts.Limit,
+		Raw:              fr.opts.Raw,
+		SniffContent:     fr.opts.SniffContent,
+		ZstdMaxWindow:    fr.opts.ZstdMaxWindow,
+		ExtDecompressors: maps.Clone(fr.opts.ExtDecompressors),
+		ZipDcomp:         maps.Clone(fr.opts.ZipDcomp),
+		ZipReaderAtFunc:  fr.opts.ZipReaderAtFunc,
+		Progress:         fr.opts.Progress,
+		Encoding:         fr.opts.Encoding,
+		StripBOM:         fr.opts.StripBOM,
+		BufferPool:       fr.opts.BufferPool,
 	}
 	return opts
 }
@@ -649,6 +1154,57 @@ func (fr *reader) FileStat() (info fs.FileInfo, err error) {
 	return fr.f.Stat()
 }
 
+// tarBlockSize is the size of a block (and a header) in a tar archive.
+const tarBlockSize = 512
+
+// Magic bytes of compression formats.
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// isZipMagic reports whether head starts with the signature
+// of a local file header or an end of central directory record
+// (for an empty archive) of a ZIP archive.
+func isZipMagic(head []byte) bool {
+	return len(head) >= 4 && head[0] == 'P' && head[1] == 'K' &&
+		(head[2] == 3 && head[3] == 4 || head[2] == 5 && head[3] == 6)
+}
+
+// isTarHeader reports whether head starts with a valid tar header,
+// by checking the header checksum.
+func isTarHeader(head []byte) bool {
+	if len(head) < tarBlockSize {
+		return false
+	}
+	// The checksum field is at [148, 156),
+	// an octal number terminated by NUL or space.
+	field := bytes.Trim(head[148:156], " \x00")
+	if len(field) == 0 {
+		return false
+	}
+	var want int64
+	for _, c := range field {
+		if c < '0' || c > '7' {
+			return false
+		}
+		want = want<<3 | int64(c-'0')
+	}
+	// The checksum is the sum of all header bytes,
+	// with the checksum field treated as spaces.
+	// Some implementations use signed bytes, so accept both.
+	var unsigned, signed int64
+	for i, c := range head[:tarBlockSize] {
+		if i >= 148 && i < 156 {
+			c = ' '
+		}
+		unsigned += int64(c)
+		signed += int64(int8(c))
+	}
+	return want == unsigned || want == signed
+}
+
 // tarHeaderIsDir reports whether the tar header represents a directory.
 func tarHeaderIsDir(hdr *tar.Header) bool {
 	return hdr != nil &&
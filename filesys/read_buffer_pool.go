@@ -0,0 +1,72 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys
+
+import (
+	"io"
+	"sync"
+
+	"github.com/donyori/gogo/inout"
+)
+
+// ReadBufferPool is a pool of buffers for Reader,
+// used through the option BufferPool of ReadOptions.
+//
+// It reduces the allocations when creating a large number of readers,
+// for example, when scanning many small files.
+//
+// ReadBufferPool is safe for concurrent use by multiple goroutines.
+type ReadBufferPool struct {
+	size int
+	pool sync.Pool
+}
+
+// NewReadBufferPool creates a new ReadBufferPool
+// whose buffers have at least the specified size.
+//
+// Nonpositive size for using the default buffer size of Reader.
+func NewReadBufferPool(size int) *ReadBufferPool {
+	return &ReadBufferPool{size: size}
+}
+
+// Size returns the minimum size of the buffers in the pool,
+// or a nonpositive value for the default buffer size of Reader.
+func (p *ReadBufferPool) Size() int {
+	return p.size
+}
+
+// get returns a buffered reader on r from the pool,
+// or creates a new one if the pool is empty.
+func (p *ReadBufferPool) get(r io.Reader) inout.ResettableBufferedReader {
+	if br, ok := p.pool.Get().(inout.ResettableBufferedReader); ok {
+		br.Reset(r)
+		return br
+	} else if p.size <= 0 {
+		return inout.NewBufferedReader(r)
+	}
+	return inout.NewBufferedReaderSize(r, p.size)
+}
+
+// put puts br back into the pool.
+//
+// br must not be used after calling put.
+func (p *ReadBufferPool) put(br inout.ResettableBufferedReader) {
+	br.Reset(nil) // release the underlying reader
+	p.pool.Put(br)
+}
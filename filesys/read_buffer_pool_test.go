@@ -0,0 +1,123 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/donyori/gogo/filesys"
+)
+
+func TestReadFromFS_BufferPool(t *testing.T) {
+	const NumFile int = 64
+	mapFS := make(fstest.MapFS, NumFile)
+	for i := range NumFile {
+		mapFS[fmt.Sprintf("file%02d.txt", i)] = &fstest.MapFile{
+			Data: []byte(fmt.Sprintf("content of file %d\n", i)),
+		}
+	}
+	for _, size := range []int{0, 16, 1024} {
+		t.Run(fmt.Sprintf("size=%d", size), func(t *testing.T) {
+			pool := filesys.NewReadBufferPool(size)
+			if got := pool.Size(); got != size {
+				t.Errorf("got pool size %d; want %d", got, size)
+			}
+			opts := &filesys.ReadOptions{BufferPool: pool}
+			var wg sync.WaitGroup
+			for g := range 4 {
+				wg.Add(1)
+				go func(g int) {
+					defer wg.Done()
+					for i := g; i < NumFile; i += 4 {
+						testReadWithBufferPool(t, mapFS, i, opts)
+					}
+				}(g)
+			}
+			wg.Wait()
+		})
+	}
+}
+
+func testReadWithBufferPool(
+	t *testing.T,
+	mapFS fstest.MapFS,
+	i int,
+	opts *filesys.ReadOptions,
+) {
+	name := fmt.Sprintf("file%02d.txt", i)
+	r, err := filesys.ReadFromFS(mapFS, name, opts)
+	if err != nil {
+		t.Error(name, "- create -", err)
+		return
+	}
+	if got := r.Options().BufferPool; got != opts.BufferPool {
+		t.Errorf("%s - got BufferPool %p; want %p",
+			name, got, opts.BufferPool)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Error(name, "- read -", err)
+	} else if string(got) != string(mapFS[name].Data) {
+		t.Errorf("%s - got %q; want %q", name, got, mapFS[name].Data)
+	}
+	if err = r.Close(); err != nil {
+		t.Error(name, "- close -", err)
+	}
+	n, err := r.Read(make([]byte, 8))
+	if n != 0 || !errors.Is(err, filesys.ErrFileReaderClosed) {
+		t.Errorf("%s - after close, got (%d, %v); want (0, %v)",
+			name, n, err, filesys.ErrFileReaderClosed)
+	}
+}
+
+func BenchmarkReadFromFS_BufferPool(b *testing.B) {
+	mapFS := fstest.MapFS{"small.txt": {Data: []byte("small file\n")}}
+	benchmarks := []struct {
+		name string
+		opts *filesys.ReadOptions
+	}{
+		{"noPool", nil},
+		{"pool", &filesys.ReadOptions{
+			BufferPool: filesys.NewReadBufferPool(0),
+		}},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				r, err := filesys.ReadFromFS(mapFS, "small.txt", bm.opts)
+				if err != nil {
+					b.Fatal("create -", err)
+				}
+				_, err = io.Copy(io.Discard, r)
+				if err != nil {
+					b.Fatal("read -", err)
+				}
+				if err = r.Close(); err != nil {
+					b.Fatal("close -", err)
+				}
+			}
+		})
+	}
+}
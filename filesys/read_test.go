@@ -20,6 +20,8 @@ package filesys_test
 
 import (
 	"bytes"
+	"compress/gzip"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
@@ -27,7 +29,11 @@ import (
 	"math"
 	"strings"
 	"testing"
+	"testing/fstest"
 	"testing/iotest"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
 
 	"github.com/donyori/gogo/filesys"
 )
@@ -143,6 +149,473 @@ func TestReadFromFS_Gz(t *testing.T) {
 	}
 }
 
+func TestReadFromFS_Progress(t *testing.T) {
+	names := []string{testFSGzFilenames[0], testFSZipFilenames[0]}
+	for _, name := range names {
+		t.Run(fmt.Sprintf("file=%+q", name), func(t *testing.T) {
+			size := int64(len(testFS[name].Data))
+			var calls int
+			var lastN, lastTotal int64
+			opts := &filesys.ReadOptions{
+				BufSize: 16,
+				Progress: func(n, total int64) {
+					if n <= lastN {
+						t.Errorf("call %d - got n %d; want > %d", calls, n, lastN)
+					}
+					calls++
+					lastN, lastTotal = n, total
+				},
+			}
+			r, err := filesys.ReadFromFS(testFS, name, opts)
+			if err != nil {
+				t.Fatal("create -", err)
+			}
+			defer func(r filesys.Reader) {
+				if err := r.Close(); err != nil {
+					t.Error("close -", err)
+				}
+			}(r)
+			if r.ZipEnabled() {
+				testZipFiles(t, r)
+			} else {
+				_, err = io.Copy(io.Discard, r)
+				if err != nil {
+					t.Fatal("read -", err)
+				}
+				if n := r.BytesRead(); n != size {
+					t.Errorf("got BytesRead %d; want %d", n, size)
+				}
+			}
+			if calls == 0 {
+				t.Fatal("Progress not called")
+			} else if lastN != r.BytesRead() {
+				t.Errorf("got last n %d; want %d", lastN, r.BytesRead())
+			}
+			if lastTotal != size {
+				t.Errorf("got total %d; want %d", lastTotal, size)
+			}
+		})
+	}
+}
+
+func TestReader_ContentType(t *testing.T) {
+	html := []byte("<!DOCTYPE html><html><body>Hello</body></html>\n")
+	var gzBuf bytes.Buffer
+	gw := gzip.NewWriter(&gzBuf)
+	_, err := gw.Write(html)
+	if err == nil {
+		err = gw.Close()
+	}
+	if err != nil {
+		t.Fatal("create gzip data -", err)
+	}
+	fsys := fstest.MapFS{
+		"page.html":    {Data: html},
+		"page.html.gz": {Data: gzBuf.Bytes()},
+		"data.bin":     {Data: []byte{0, 1, 2, 3, 4, 5}},
+		"empty.txt":    {},
+	}
+	testCases := []struct {
+		name string
+		opts *filesys.ReadOptions
+		want string
+	}{
+		{"page.html", nil, "text/html; charset=utf-8"},
+		{"page.html.gz", nil, "text/html; charset=utf-8"},
+		{"page.html.gz", &filesys.ReadOptions{Raw: true}, "application/x-gzip"},
+		{"page.html.gz", &filesys.ReadOptions{BufSize: 16}, "text/html; charset=utf-8"},
+		{"data.bin", nil, "application/octet-stream"},
+		{"empty.txt", nil, "text/plain; charset=utf-8"},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("file=%+q&opts=%+v", tc.name, tc.opts), func(t *testing.T) {
+			r, err := filesys.ReadFromFS(fsys, tc.name, tc.opts)
+			if err != nil {
+				t.Fatal("create -", err)
+			}
+			defer func(r filesys.Reader) {
+				if err := r.Close(); err != nil {
+					t.Error("close -", err)
+				}
+			}(r)
+			got, err := r.ContentType()
+			if err != nil {
+				t.Fatal("ContentType -", err)
+			} else if got != tc.want {
+				t.Errorf("got %q; want %q", got, tc.want)
+			}
+			// ContentType must not consume the data.
+			data, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal("read -", err)
+			}
+			want := fsys[tc.name].Data
+			if tc.name == "page.html.gz" && (tc.opts == nil || !tc.opts.Raw) {
+				want = html
+			}
+			if !bytes.Equal(data, want) {
+				t.Errorf("got data %q; want %q", data, want)
+			}
+		})
+	}
+}
+
+func TestReader_ContentType_Zip(t *testing.T) {
+	r, err := filesys.ReadFromFS(testFS, testFSZipFilenames[0], nil)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	defer func(r filesys.Reader) {
+		if err := r.Close(); err != nil {
+			t.Error("close -", err)
+		}
+	}(r)
+	_, err = r.ContentType()
+	if !errors.Is(err, filesys.ErrReadZip) {
+		t.Errorf("got %v; want %v", err, filesys.ErrReadZip)
+	}
+}
+
+func TestReadFromFS_GzipHeader(t *testing.T) {
+	want := gzip.Header{
+		Comment: "a comment",
+		Extra:   []byte("extra data"),
+		ModTime: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Name:    "original.txt",
+		OS:      3, // Unix
+	}
+	buf := new(bytes.Buffer)
+	gw := gzip.NewWriter(buf)
+	gw.Header = want
+	if _, err := gw.Write([]byte("Hello, world!")); err != nil {
+		t.Fatal("compress -", err)
+	} else if err = gw.Close(); err != nil {
+		t.Fatal("close gzip writer -", err)
+	}
+	fsys := fstest.MapFS{
+		"header.txt.gz": {Data: buf.Bytes()},
+		"header.txt":    {Data: []byte("Hello, world!")},
+	}
+
+	for _, sniff := range []bool{false, true} {
+		t.Run(fmt.Sprintf("SniffContent=%t", sniff), func(t *testing.T) {
+			r, err := filesys.ReadFromFS(
+				fsys,
+				"header.txt.gz",
+				&filesys.ReadOptions{SniffContent: sniff},
+			)
+			if err != nil {
+				t.Fatal("create -", err)
+			}
+			hdr, err := r.GzipHeader()
+			if err != nil {
+				t.Error("GzipHeader -", err)
+			} else if hdr.Name != want.Name || hdr.Comment != want.Comment ||
+				!hdr.ModTime.Equal(want.ModTime) || hdr.OS != want.OS ||
+				!bytes.Equal(hdr.Extra, want.Extra) {
+				t.Errorf("got %+v; want %+v", *hdr, want)
+			}
+			if err = r.Close(); err != nil {
+				t.Fatal("close -", err)
+			}
+			_, err = r.GzipHeader()
+			if !errors.Is(err, filesys.ErrFileReaderClosed) {
+				t.Errorf("after close - got %v; want %v",
+					err, filesys.ErrFileReaderClosed)
+			}
+		})
+	}
+
+	testCases := []struct {
+		name string
+		opts *filesys.ReadOptions
+	}{
+		{"header.txt", nil},
+		{"header.txt.gz", &filesys.ReadOptions{Raw: true}},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("file=%+q&opts=%+v", tc.name, tc.opts), func(t *testing.T) {
+			r, err := filesys.ReadFromFS(fsys, tc.name, tc.opts)
+			if err != nil {
+				t.Fatal("create -", err)
+			}
+			defer func(r filesys.Reader) {
+				_ = r.Close() // ignore error
+			}(r)
+			_, err = r.GzipHeader()
+			if !errors.Is(err, filesys.ErrNotGzip) {
+				t.Errorf("got %v; want %v", err, filesys.ErrNotGzip)
+			}
+		})
+	}
+}
+
+func TestReadFromFS_SniffContent(t *testing.T) {
+	const SniffName = "sniffed"
+	opts := &filesys.ReadOptions{SniffContent: true}
+	check := func(t *testing.T, name string, f func(r filesys.Reader)) {
+		fsys := fstest.MapFS{SniffName: {Data: testFS[name].Data}}
+		r, err := filesys.ReadFromFS(fsys, SniffName, opts)
+		if err != nil {
+			t.Fatal("create -", err)
+		}
+		defer func(r filesys.Reader) {
+			if err := r.Close(); err != nil {
+				t.Error("close -", err)
+			}
+		}(r)
+		f(r)
+	}
+
+	for _, name := range testFSBasicFilenames {
+		t.Run(fmt.Sprintf("file=%+q", name), func(t *testing.T) {
+			check(t, name, func(r filesys.Reader) {
+				if r.TarEnabled() || r.ZipEnabled() {
+					t.Error("got archive; want regular file")
+				}
+				err := iotest.TestReader(r, testFS[name].Data)
+				if err != nil {
+					t.Error("test read -", err)
+				}
+			})
+		})
+	}
+	for _, name := range testFSGzFilenames {
+		t.Run(fmt.Sprintf("file=%+q", name), func(t *testing.T) {
+			check(t, name, func(r filesys.Reader) {
+				err := iotest.TestReader(r, testFS[name[:len(name)-3]].Data)
+				if err != nil {
+					t.Error("test read -", err)
+				}
+			})
+		})
+	}
+	for _, name := range append(testFSTarFilenames, testFSTgzFilenames...) {
+		t.Run(fmt.Sprintf("file=%+q", name), func(t *testing.T) {
+			check(t, name, func(r filesys.Reader) {
+				if !r.TarEnabled() {
+					t.Fatal("tar is not enabled")
+				}
+				hdr, err := r.TarNext()
+				if err != nil {
+					t.Fatal("read first tar header -", err)
+				} else if hdr.Name != testFSTarFiles[0].name {
+					t.Errorf("got first tar header name %q; want %q",
+						hdr.Name, testFSTarFiles[0].name)
+				}
+			})
+		})
+	}
+	for _, name := range testFSZipFilenames {
+		if name == testFSZipOffsetName {
+			continue // the ZIP data does not start at the beginning
+		}
+		t.Run(fmt.Sprintf("file=%+q", name), func(t *testing.T) {
+			check(t, name, func(r filesys.Reader) {
+				if !r.ZipEnabled() {
+					t.Fatal("ZIP is not enabled")
+				}
+				testZipOpen(t, r)
+			})
+		})
+	}
+	t.Run("file=tzst", func(t *testing.T) {
+		file := &WritableFileImpl{Name: "tar file.tzst"}
+		writeTarFiles(t, file)
+		if t.Failed() {
+			return
+		}
+		fsys := fstest.MapFS{SniffName: {Data: file.Data}}
+		r, err := filesys.ReadFromFS(fsys, SniffName, opts)
+		if err != nil {
+			t.Fatal("create -", err)
+		}
+		defer func(r filesys.Reader) {
+			if err := r.Close(); err != nil {
+				t.Error("close -", err)
+			}
+		}(r)
+		if !r.TarEnabled() {
+			t.Error("tar is not enabled")
+		}
+	})
+}
+
+func TestReadFromFS_Zst(t *testing.T) {
+	data := testFS["13KB.dat"].Data
+	buf := new(bytes.Buffer)
+	zw, err := zstd.NewWriter(buf, zstd.WithWindowSize(1<<20))
+	if err != nil {
+		t.Fatal("create zstd writer -", err)
+	}
+	_, err = zw.Write(data)
+	if err != nil {
+		t.Fatal("compress -", err)
+	}
+	err = zw.Close()
+	if err != nil {
+		t.Fatal("close zstd writer -", err)
+	}
+	fsys := fstest.MapFS{"13KB.dat.zst": {Data: buf.Bytes()}}
+
+	t.Run("opts=<nil>", func(t *testing.T) {
+		r, err := filesys.ReadFromFS(fsys, "13KB.dat.zst", nil)
+		if err != nil {
+			t.Fatal("create -", err)
+		}
+		defer func(r filesys.Reader) {
+			if err := r.Close(); err != nil {
+				t.Error("close -", err)
+			}
+		}(r)
+		err = iotest.TestReader(r, data)
+		if err != nil {
+			t.Error("test read -", err)
+		}
+	})
+
+	t.Run("opts=ZstdMaxWindow=1024", func(t *testing.T) {
+		r, err := filesys.ReadFromFS(
+			fsys,
+			"13KB.dat.zst",
+			&filesys.ReadOptions{ZstdMaxWindow: 1 << 10},
+		)
+		if err != nil {
+			t.Fatal("create -", err)
+		}
+		defer func(r filesys.Reader) {
+			_ = r.Close()
+		}(r)
+		_, err = io.ReadAll(r)
+		if err == nil {
+			t.Error("read - no error but window size exceeds the limit")
+		}
+	})
+}
+
+func TestReadFromFS_TarZst(t *testing.T) {
+	for _, name := range []string{"tar file.tar.zst", "tar file.tzst"} {
+		t.Run(fmt.Sprintf("file=%+q", name), func(t *testing.T) {
+			file := &WritableFileImpl{Name: name}
+			writeTarFiles(t, file)
+			if t.Failed() {
+				return
+			}
+			fsys := fstest.MapFS{name: {Data: file.Data}}
+			r, err := filesys.ReadFromFS(fsys, name, nil)
+			if err != nil {
+				t.Fatal("create -", err)
+			}
+			defer func(r filesys.Reader) {
+				if err := r.Close(); err != nil {
+					t.Error("close -", err)
+				}
+			}(r)
+			if !r.TarEnabled() {
+				t.Fatal("tar is not enabled")
+			}
+			for i := range testFSTarFiles {
+				hdr, err := r.TarNext()
+				if err != nil {
+					t.Fatalf("read No.%d tar header - %v", i, err)
+				} else if hdr.Name != testFSTarFiles[i].name {
+					t.Errorf("No.%d tar header name - got %s; want %s",
+						i, hdr.Name, testFSTarFiles[i].name)
+				}
+				if hdr.FileInfo().IsDir() {
+					continue
+				}
+				body, err := io.ReadAll(r)
+				if err != nil {
+					t.Errorf("read No.%d tar file body - %v", i, err)
+				} else if string(body) != testFSTarFiles[i].body {
+					t.Errorf("No.%d tar file body unequal", i)
+				}
+			}
+			if _, err = r.TarNext(); !errors.Is(err, io.EOF) {
+				t.Errorf("got %v; want io.EOF", err)
+			}
+		})
+	}
+}
+
+func TestReadFromFS_ExtDecompressors(t *testing.T) {
+	newBase64Reader := func(r io.Reader) (io.ReadCloser, error) {
+		return io.NopCloser(base64.NewDecoder(base64.StdEncoding, r)), nil
+	}
+	extDcomp := map[string]func(r io.Reader) (io.ReadCloser, error){
+		".B64": newBase64Reader,
+		".gz":  newBase64Reader, // override the built-in decompressor
+		".tar": newBase64Reader, // ignored
+		".nil": nil,
+	}
+	data := testFS["13KB.dat"].Data
+	encoded := []byte(base64.StdEncoding.EncodeToString(data))
+
+	for _, name := range []string{"13KB.dat.b64", "13KB.dat.gz"} {
+		t.Run(fmt.Sprintf("file=%+q", name), func(t *testing.T) {
+			fsys := fstest.MapFS{name: {Data: encoded}}
+			r, err := filesys.ReadFromFS(
+				fsys,
+				name,
+				&filesys.ReadOptions{ExtDecompressors: extDcomp},
+			)
+			if err != nil {
+				t.Fatal("create -", err)
+			}
+			defer func(r filesys.Reader) {
+				if err := r.Close(); err != nil {
+					t.Error("close -", err)
+				}
+			}(r)
+			err = iotest.TestReader(r, data)
+			if err != nil {
+				t.Error("test read -", err)
+			}
+		})
+	}
+
+	t.Run("file=\"tar file.tar.b64\"", func(t *testing.T) {
+		file := &WritableFileImpl{Name: "tar file.tar"}
+		writeTarFiles(t, file)
+		if t.Failed() {
+			return
+		}
+		const Name = "tar file.tar.b64"
+		fsys := fstest.MapFS{Name: {
+			Data: []byte(base64.StdEncoding.EncodeToString(file.Data)),
+		}}
+		r, err := filesys.ReadFromFS(
+			fsys,
+			Name,
+			&filesys.ReadOptions{ExtDecompressors: extDcomp},
+		)
+		if err != nil {
+			t.Fatal("create -", err)
+		}
+		defer func(r filesys.Reader) {
+			if err := r.Close(); err != nil {
+				t.Error("close -", err)
+			}
+		}(r)
+		if !r.TarEnabled() {
+			t.Fatal("tar is not enabled")
+		}
+		for i := range testFSTarFiles {
+			hdr, err := r.TarNext()
+			if err != nil {
+				t.Fatalf("read No.%d tar header - %v", i, err)
+			} else if hdr.Name != testFSTarFiles[i].name {
+				t.Errorf("No.%d tar header name - got %s; want %s",
+					i, hdr.Name, testFSTarFiles[i].name)
+			}
+		}
+		if _, err = r.TarNext(); !errors.Is(err, io.EOF) {
+			t.Errorf("got %v; want io.EOF", err)
+		}
+	})
+}
+
 func TestReadFromFS_TarTgz(t *testing.T) {
 	for _, name := range append(testFSTarFilenames, testFSTgzFilenames...) {
 		t.Run(fmt.Sprintf("file=%+q", name), func(t *testing.T) {
@@ -209,6 +682,61 @@ func TestReadFromFS_Zip_Open(t *testing.T) {
 	}
 }
 
+func TestReadFromFS_Zip_FS(t *testing.T) {
+	var expected []string
+	for zipFilename := range testFSZipFileNameBodyMap {
+		if !strings.HasSuffix(zipFilename, "/") {
+			expected = append(expected, zipFilename)
+		}
+	}
+	for _, name := range testFSZipFilenames {
+		t.Run(fmt.Sprintf("file=%+q", name), func(t *testing.T) {
+			r, err := filesys.ReadFromFS(testFS, name, nil)
+			if err != nil {
+				t.Fatal("create -", err)
+			}
+			defer func(r filesys.Reader) {
+				if err := r.Close(); err != nil {
+					t.Error("close -", err)
+				}
+			}(r)
+			fsys, err := r.ZipFS()
+			if err != nil {
+				t.Fatal("ZipFS -", err)
+			}
+			err = fstest.TestFS(fsys, expected...)
+			if err != nil {
+				t.Error(err)
+			}
+			for zipFilename, body := range testFSZipFileNameBodyMap {
+				if strings.HasSuffix(zipFilename, "/") {
+					continue
+				}
+				data, err := fs.ReadFile(fsys, zipFilename)
+				if err != nil {
+					t.Errorf("read %q - %v", zipFilename, err)
+				} else if string(data) != body {
+					t.Errorf("read %q - got %q; want %q",
+						zipFilename, data, body)
+				}
+			}
+		})
+	}
+}
+
+func TestReadFromFS_Zip_NotZip_FS(t *testing.T) {
+	r, err := filesys.ReadFromFS(testFS, "file1.txt", nil)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	defer func(r filesys.Reader) {
+		_ = r.Close() // ignore error
+	}(r)
+	if _, err = r.ZipFS(); !errors.Is(err, filesys.ErrNotZip) {
+		t.Errorf("got %v; want %v", err, filesys.ErrNotZip)
+	}
+}
+
 func TestReadFromFS_Zip_Files(t *testing.T) {
 	for _, name := range testFSZipFilenames {
 		t.Run(fmt.Sprintf("file=%+q", name), func(t *testing.T) {
@@ -518,6 +1046,24 @@ func TestReadFromFS_AfterClose(t *testing.T) {
 			},
 			filesys.ErrFileReaderClosed,
 		},
+		{
+			"ZipFS-notZip",
+			RegFile,
+			func(t *testing.T, r filesys.Reader) error {
+				_, err := r.ZipFS()
+				return err
+			},
+			filesys.ErrNotZip,
+		},
+		{
+			"ZipFS-isZip",
+			ZipFile,
+			func(t *testing.T, r filesys.Reader) error {
+				_, err := r.ZipFS()
+				return err
+			},
+			filesys.ErrFileReaderClosed,
+		},
 		{
 			"ZipFiles-notZip",
 			RegFile,
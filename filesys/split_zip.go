@@ -0,0 +1,395 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/donyori/gogo/errors"
+)
+
+const (
+	zipDirHeaderSignature uint32 = 0x02014b50
+	zipDirEndSignature    uint32 = 0x06054b50
+	zipDirHeaderLen              = 46
+	zipDirEndLen                 = 22
+)
+
+// ReadSplitZip creates a reader on a ZIP archive split across
+// multiple files (also known as a split or multi-volume ZIP archive),
+// with options opts.
+//
+// parts are the files of the archive in order,
+// where the last one contains the end of central directory record
+// (e.g., "a.z01", "a.z02", ..., "a.zip").
+// Each part must implement io.ReaderAt,
+// and the name of the last part must have the extension ".zip".
+//
+// Both the PKWARE split format (where the offsets in the archive
+// are relative to the beginning of each part,
+// as produced by "zip -s" of Info-ZIP)
+// and the byte-split format (where the parts are simply the pieces of
+// a regular ZIP archive, as produced by
+// github.com/donyori/gogo/filesys/local.WriteSplitZip)
+// are supported.
+// ZIP64 split archives in the PKWARE format are not supported.
+//
+// opts are handled the same as in function Read.
+//
+// closeFiles indicates whether the reader should close the parts
+// when calling its method Close.
+// If closeFiles is true, the parts are also closed by this function
+// when encountering an error.
+//
+// This function panics if parts is empty or any part is nil.
+func ReadSplitZip(parts []fs.File, opts *ReadOptions, closeFiles bool) (
+	r Reader, err error) {
+	if len(parts) == 0 {
+		panic(errors.AutoMsg("parts is empty"))
+	}
+	for i := range parts {
+		if parts[i] == nil {
+			panic(errors.AutoMsg(fmt.Sprintf("parts[%d] is nil", i)))
+		}
+	}
+	f, err := newSplitZipFile(parts, closeFiles)
+	if err != nil {
+		if closeFiles {
+			for i := len(parts) - 1; i >= 0; i-- {
+				_ = parts[i].Close() // ignore error
+			}
+		}
+		return nil, errors.AutoWrap(err)
+	}
+	r, err = Read(f, opts, true)
+	return r, errors.AutoWrap(err)
+}
+
+// ReadSplitZipFromFS opens a split ZIP archive from fsys
+// with options opts for reading.
+//
+// name is the name of the last part, which must have the extension ".zip"
+// (e.g., "a.zip").
+// The preceding parts are the files with the same name
+// but the extensions ".z01", ".z02", and so on
+// (in the same case as the extension ".zip"), if any.
+//
+// See ReadSplitZip for details.
+//
+// The parts are closed when the returned reader is closed.
+//
+// This function panics if fsys is nil.
+func ReadSplitZipFromFS(fsys fs.FS, name string, opts *ReadOptions) (
+	r Reader, err error) {
+	if fsys == nil {
+		panic(errors.AutoMsg("fsys is nil"))
+	}
+	ext := path.Ext(name)
+	if !strings.EqualFold(ext, ".zip") {
+		return nil, errors.AutoNew(`file extension is not ".zip"`)
+	}
+	partFmt := name[:len(name)-len(ext)] + ".z%02d"
+	if ext[1] == 'Z' {
+		partFmt = name[:len(name)-len(ext)] + ".Z%02d"
+	}
+	var parts []fs.File
+	defer func() {
+		if err != nil {
+			for i := len(parts) - 1; i >= 0; i-- {
+				_ = parts[i].Close() // ignore error
+			}
+		}
+	}()
+	for i := 1; ; i++ {
+		f, err := fsys.Open(fmt.Sprintf(partFmt, i))
+		if errors.Is(err, fs.ErrNotExist) {
+			break
+		} else if err != nil {
+			return nil, errors.AutoWrap(err)
+		}
+		parts = append(parts, f)
+	}
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	parts = append(parts, f)
+	r, err = ReadSplitZip(parts, opts, true)
+	if err != nil {
+		parts = nil // the parts have been closed by ReadSplitZip
+	}
+	return r, errors.AutoWrap(err)
+}
+
+// splitZipFile is a virtual file consisting of the parts
+// of a split ZIP archive.
+//
+// If the archive is in the PKWARE split format,
+// its central directory is replaced with a patched one
+// whose offsets are relative to the beginning of the virtual file,
+// so that it can be read by archive/zip.Reader.
+type splitZipFile struct {
+	parts      []fs.File
+	ras        []io.ReaderAt
+	starts     []int64 // starts[i] is the offset of parts[i]; starts[len(parts)] is the total size
+	tail       []byte  // the patched central directory and end of central directory record, or nil
+	tailOff    int64   // the offset of tail, which is also the size of the data from parts
+	info       fs.FileInfo
+	off        int64 // the offset for the methods Read and Seek
+	closeFiles bool
+	closed     bool
+}
+
+// newSplitZipFile creates a splitZipFile on the specified parts.
+func newSplitZipFile(parts []fs.File, closeFiles bool) (*splitZipFile, error) {
+	f := &splitZipFile{
+		parts:      parts,
+		ras:        make([]io.ReaderAt, len(parts)),
+		starts:     make([]int64, len(parts)+1),
+		closeFiles: closeFiles,
+	}
+	for i, part := range parts {
+		info, err := part.Stat()
+		if err != nil {
+			return nil, err
+		} else if info.IsDir() {
+			return nil, ErrIsDir
+		}
+		var ok bool
+		f.ras[i], ok = part.(io.ReaderAt)
+		if !ok {
+			return nil, fmt.Errorf("part %d (%s) does not implement io.ReaderAt",
+				i, info.Name())
+		}
+		f.starts[i+1] = f.starts[i] + info.Size()
+		f.info = info
+	}
+	if !strings.EqualFold(path.Ext(f.info.Name()), ".zip") {
+		return nil, errors.New(`file extension of the last part is not ".zip"`)
+	}
+	f.tailOff = f.starts[len(parts)]
+	err := f.patch()
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// patch patches the central directory if the archive is
+// in the PKWARE split format.
+func (f *splitZipFile) patch() error {
+	size := f.starts[len(f.parts)]
+	bufLen := min(size, zipDirEndLen+int64(maxUint16))
+	buf := make([]byte, bufLen)
+	_, err := f.readData(buf, size-bufLen)
+	if err != nil {
+		return err
+	}
+	var eocd []byte
+	for i := len(buf) - zipDirEndLen; i >= 0; i-- {
+		if binary.LittleEndian.Uint32(buf[i:]) == zipDirEndSignature &&
+			i+zipDirEndLen+int(binary.LittleEndian.Uint16(buf[i+20:])) <= len(buf) {
+			eocd = buf[i:]
+			break
+		}
+	}
+	if eocd == nil {
+		return errors.New("end of central directory record not found")
+	}
+	diskNum := binary.LittleEndian.Uint16(eocd[4:])
+	if diskNum == 0 {
+		return nil // not in the PKWARE split format
+	}
+	cdDisk := binary.LittleEndian.Uint16(eocd[6:])
+	cdSize := binary.LittleEndian.Uint32(eocd[12:])
+	cdOff := binary.LittleEndian.Uint32(eocd[16:])
+	if diskNum == 0xffff || cdOff == 0xffffffff || cdSize == 0xffffffff {
+		return errors.New("ZIP64 split archive is not supported")
+	} else if int(cdDisk) >= len(f.parts) {
+		return fmt.Errorf("central directory is on disk %d, but there are only %d parts",
+			cdDisk, len(f.parts))
+	}
+	cdAbs := f.starts[cdDisk] + int64(cdOff)
+	if cdAbs > 0xffffffff {
+		return errors.New("archive is too large (ZIP64 is not supported)")
+	}
+	cd := make([]byte, cdSize, int(cdSize)+len(eocd))
+	_, err = f.readData(cd, cdAbs)
+	if err != nil {
+		return err
+	}
+	for p := 0; p < len(cd); {
+		h := cd[p:]
+		if len(h) < zipDirHeaderLen ||
+			binary.LittleEndian.Uint32(h) != zipDirHeaderSignature {
+			return errors.New("invalid central directory header")
+		}
+		disk := binary.LittleEndian.Uint16(h[34:])
+		off := binary.LittleEndian.Uint32(h[42:])
+		if disk == 0xffff || off == 0xffffffff {
+			return errors.New("ZIP64 split archive is not supported")
+		} else if int(disk) >= len(f.parts) {
+			return fmt.Errorf("file is on disk %d, but there are only %d parts",
+				disk, len(f.parts))
+		}
+		abs := f.starts[disk] + int64(off)
+		if abs > 0xffffffff {
+			return errors.New("archive is too large (ZIP64 is not supported)")
+		}
+		binary.LittleEndian.PutUint16(h[34:], 0)
+		binary.LittleEndian.PutUint32(h[42:], uint32(abs))
+		p += zipDirHeaderLen + int(binary.LittleEndian.Uint16(h[28:])) +
+			int(binary.LittleEndian.Uint16(h[30:])) +
+			int(binary.LittleEndian.Uint16(h[32:]))
+	}
+	tail := append(cd, eocd[:zipDirEndLen+int(binary.LittleEndian.Uint16(eocd[20:]))]...)
+	end := tail[len(cd):]
+	binary.LittleEndian.PutUint16(end[4:], 0)                                    // number of this disk
+	binary.LittleEndian.PutUint16(end[6:], 0)                                    // disk where central directory starts
+	binary.LittleEndian.PutUint16(end[8:], binary.LittleEndian.Uint16(end[10:])) // number of entries on this disk
+	binary.LittleEndian.PutUint32(end[16:], uint32(cdAbs))
+	f.tail, f.tailOff = tail, cdAbs
+	return nil
+}
+
+// readData reads the data from the parts at offset off.
+func (f *splitZipFile) readData(p []byte, off int64) (n int, err error) {
+	size := f.starts[len(f.parts)]
+	for n < len(p) && off < size {
+		i := sort.Search(len(f.parts), func(i int) bool {
+			return f.starts[i+1] > off
+		})
+		k := int(min(int64(len(p)-n), f.starts[i+1]-off))
+		var m int
+		m, err = f.ras[i].ReadAt(p[n:n+k], off-f.starts[i])
+		n, off = n+m, off+int64(m)
+		if m == k && errors.Is(err, io.EOF) {
+			err = nil
+		}
+		if err != nil {
+			return
+		}
+	}
+	if n < len(p) {
+		err = io.EOF
+	}
+	return
+}
+
+// size returns the size of the virtual file.
+func (f *splitZipFile) size() int64 {
+	return f.tailOff + int64(len(f.tail))
+}
+
+func (f *splitZipFile) ReadAt(p []byte, off int64) (n int, err error) {
+	if f.closed {
+		return 0, errors.AutoWrap(fs.ErrClosed)
+	} else if off < 0 {
+		return 0, errors.AutoNew("negative offset")
+	}
+	if off < f.tailOff {
+		end := min(int64(len(p)), f.tailOff-off)
+		n, err = f.readData(p[:end], off)
+		if err != nil && !(errors.Is(err, io.EOF) && f.tail != nil) {
+			return n, errors.AutoWrap(err)
+		}
+		err = nil
+	}
+	if n < len(p) {
+		tailStart := off + int64(n) - f.tailOff
+		if tailStart >= int64(len(f.tail)) {
+			return n, io.EOF
+		}
+		n += copy(p[n:], f.tail[tailStart:])
+		if n < len(p) {
+			err = io.EOF
+		}
+	}
+	return
+}
+
+func (f *splitZipFile) Read(p []byte) (n int, err error) {
+	if f.off >= f.size() {
+		if f.closed {
+			return 0, errors.AutoWrap(fs.ErrClosed)
+		}
+		return 0, io.EOF
+	}
+	n, err = f.ReadAt(p, f.off)
+	f.off += int64(n)
+	if n > 0 && errors.Is(err, io.EOF) {
+		err = nil
+	}
+	return
+}
+
+func (f *splitZipFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.off
+	case io.SeekEnd:
+		offset += f.size()
+	default:
+		return 0, errors.AutoNew("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.AutoNew("negative position")
+	}
+	f.off = offset
+	return offset, nil
+}
+
+func (f *splitZipFile) Stat() (fs.FileInfo, error) {
+	return &splitZipFileInfo{FileInfo: f.info, size: f.size()}, nil
+}
+
+func (f *splitZipFile) Close() error {
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	if !f.closeFiles {
+		return nil
+	}
+	el := errors.NewErrorList(true)
+	for i := len(f.parts) - 1; i >= 0; i-- {
+		el.Append(f.parts[i].Close())
+	}
+	return errors.AutoWrap(el.ToError())
+}
+
+// splitZipFileInfo is the io/fs.FileInfo of splitZipFile.
+//
+// It is the io/fs.FileInfo of the last part,
+// except that the size is that of the virtual file.
+type splitZipFileInfo struct {
+	fs.FileInfo
+	size int64
+}
+
+func (info *splitZipFileInfo) Size() int64 {
+	return info.size
+}
@@ -0,0 +1,161 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/donyori/gogo/filesys"
+)
+
+func TestReadSplitZipFromFS(t *testing.T) {
+	for _, name := range testFSZipFilenames {
+		if name == testFSZipOffsetName {
+			continue // the ZIP data does not start at the beginning
+		}
+		data := testFS[name].Data
+		for _, pkware := range []bool{false, true} {
+			t.Run(fmt.Sprintf("file=%+q&pkware=%t", name, pkware), func(t *testing.T) {
+				var parts [][]byte
+				if pkware {
+					parts = splitZipPKWARE(t, data)
+				} else {
+					parts = [][]byte{
+						data[:len(data)/3],
+						data[len(data)/3 : len(data)*2/3],
+						data[len(data)*2/3:],
+					}
+				}
+				fsys := make(fstest.MapFS, len(parts))
+				for i := range len(parts) - 1 {
+					fsys[fmt.Sprintf("split.z%02d", i+1)] = &fstest.MapFile{
+						Data: parts[i],
+					}
+				}
+				fsys["split.zip"] = &fstest.MapFile{Data: parts[len(parts)-1]}
+
+				r, err := filesys.ReadSplitZipFromFS(fsys, "split.zip", nil)
+				if err != nil {
+					t.Fatal("create -", err)
+				}
+				defer func(r filesys.Reader) {
+					if err := r.Close(); err != nil {
+						t.Error("close -", err)
+					}
+				}(r)
+				if !r.ZipEnabled() {
+					t.Fatal("ZIP is not enabled")
+				}
+				testZipOpen(t, r)
+				testZipFiles(t, r)
+				comment, err := r.ZipComment()
+				if err != nil {
+					t.Error("zip comment -", err)
+				} else if comment != testFSZipComment {
+					t.Errorf("got comment %q; want %q", comment, testFSZipComment)
+				}
+			})
+		}
+	}
+}
+
+func TestReadSplitZipFromFS_SinglePart(t *testing.T) {
+	name := testFSZipFilenames[0]
+	if name == testFSZipOffsetName {
+		name = testFSZipFilenames[1]
+	}
+	fsys := fstest.MapFS{"single.zip": testFS[name]}
+	r, err := filesys.ReadSplitZipFromFS(fsys, "single.zip", nil)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	defer func(r filesys.Reader) {
+		if err := r.Close(); err != nil {
+			t.Error("close -", err)
+		}
+	}(r)
+	testZipFiles(t, r)
+}
+
+func TestReadSplitZipFromFS_Invalid(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.z01": &fstest.MapFile{Data: []byte("not a ZIP archive")},
+		"a.zip": &fstest.MapFile{Data: []byte("still not a ZIP archive")},
+		"b.tar": &fstest.MapFile{Data: []byte("not a ZIP archive")},
+	}
+	for _, name := range []string{"a.zip", "b.tar", "c.zip"} {
+		t.Run(fmt.Sprintf("name=%+q", name), func(t *testing.T) {
+			r, err := filesys.ReadSplitZipFromFS(fsys, name, nil)
+			if err == nil {
+				_ = r.Close() // ignore error
+				t.Error("got nil error")
+			}
+		})
+	}
+}
+
+// splitZipPKWARE splits the specified ZIP archive into three parts
+// in the PKWARE split format, where the offsets in the archive are
+// relative to the beginning of each part.
+//
+// The archive must not have a ZIP64 end of central directory record.
+func splitZipPKWARE(t *testing.T, data []byte) [][]byte {
+	t.Helper()
+	eocdOff := -1
+	for i := len(data) - 22; i >= 0; i-- {
+		if binary.LittleEndian.Uint32(data[i:]) == 0x06054b50 {
+			eocdOff = i
+			break
+		}
+	}
+	if eocdOff < 0 {
+		t.Fatal("end of central directory record not found")
+	}
+	cdOff := int(binary.LittleEndian.Uint32(data[eocdOff+16:]))
+	starts := []int{0, min(len(data)/3, cdOff), min(len(data)*2/3, cdOff)}
+	locate := func(off int) (disk, rel int) {
+		for i := len(starts) - 1; i >= 0; i-- {
+			if starts[i] <= off {
+				return i, off - starts[i]
+			}
+		}
+		t.Fatalf("cannot locate offset %d", off)
+		return
+	}
+
+	tail := append([]byte(nil), data[starts[2]:]...)
+	for p := cdOff - starts[2]; binary.LittleEndian.Uint32(tail[p:]) == 0x02014b50; {
+		disk, rel := locate(int(binary.LittleEndian.Uint32(tail[p+42:])))
+		binary.LittleEndian.PutUint16(tail[p+34:], uint16(disk))
+		binary.LittleEndian.PutUint32(tail[p+42:], uint32(rel))
+		p += 46 + int(binary.LittleEndian.Uint16(tail[p+28:])) +
+			int(binary.LittleEndian.Uint16(tail[p+30:])) +
+			int(binary.LittleEndian.Uint16(tail[p+32:]))
+	}
+	eocd := tail[eocdOff-starts[2]:]
+	cdDisk, cdRel := locate(cdOff)
+	binary.LittleEndian.PutUint16(eocd[4:], 2)
+	binary.LittleEndian.PutUint16(eocd[6:], uint16(cdDisk))
+	binary.LittleEndian.PutUint16(eocd[8:], 0)
+	binary.LittleEndian.PutUint32(eocd[16:], uint32(cdRel))
+	return [][]byte{data[:starts[1]], data[starts[1]:starts[2]], tail}
+}
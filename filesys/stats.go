@@ -0,0 +1,77 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys
+
+import "time"
+
+// Stats are the statistics of the data flowing through a Reader or Writer.
+type Stats struct {
+	// RawBytes is the number of bytes read from or written to the file,
+	// the same as the method BytesRead of Reader
+	// or BytesWritten of Writer returns.
+	//
+	// For compressed files and archives, it counts the compressed bytes.
+	RawBytes int64
+
+	// LogicalBytes is the number of bytes of the data
+	// after decompression (for Reader) or before compression (for Writer).
+	//
+	// For Reader, it counts the data read into the buffer
+	// (including the data buffered but not yet consumed by the client),
+	// excluding the data read through the methods
+	// TarFS, TarOpen, ZipOpen, and ZipFS.
+	//
+	// For Writer, it counts the data written by the client
+	// through the methods such as Write, WriteString, and Printf
+	// (including the data buffered but not yet written to the file),
+	// excluding the data written by the methods TarAddFS,
+	// TarAddFSWithOptions, TarCopy, ZipAddFS, ZipCopy, and ZipCreateRaw.
+	LogicalBytes int64
+
+	// Elapsed is the time elapsed since the Reader or Writer was created,
+	// until it was closed (or until now if it is not closed).
+	Elapsed time.Duration
+}
+
+// stopwatch measures the elapsed time of a Reader or Writer.
+type stopwatch struct {
+	start   time.Time
+	elapsed time.Duration // the elapsed time when stopped
+	stopped bool
+}
+
+// startStopwatch returns a stopwatch started now.
+func startStopwatch() stopwatch {
+	return stopwatch{start: time.Now()}
+}
+
+// stop stops the stopwatch if not stopped.
+func (sw *stopwatch) stop() {
+	if !sw.stopped {
+		sw.elapsed, sw.stopped = time.Since(sw.start), true
+	}
+}
+
+// get returns the elapsed time.
+func (sw *stopwatch) get() time.Duration {
+	if sw.stopped {
+		return sw.elapsed
+	}
+	return time.Since(sw.start)
+}
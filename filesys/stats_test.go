@@ -0,0 +1,177 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/donyori/gogo/filesys"
+)
+
+func TestReader_Stats(t *testing.T) {
+	data := []byte(strings.Repeat("Hello, world!\n", 1000))
+	var gzBuf bytes.Buffer
+	gw := gzip.NewWriter(&gzBuf)
+	_, err := gw.Write(data)
+	if err != nil {
+		t.Fatal("create gzip data -", err)
+	} else if err = gw.Close(); err != nil {
+		t.Fatal("close gzip writer -", err)
+	}
+	mapFS := fstest.MapFS{
+		"plain.txt":   {Data: data},
+		"text.txt.gz": {Data: gzBuf.Bytes()},
+	}
+	for name, file := range mapFS {
+		t.Run("file="+name, func(t *testing.T) {
+			r, err := filesys.ReadFromFS(mapFS, name, nil)
+			if err != nil {
+				t.Fatal("create -", err)
+			}
+			if stats := r.Stats(); stats.LogicalBytes != 0 {
+				t.Errorf("before reading, got LogicalBytes %d; want 0",
+					stats.LogicalBytes)
+			}
+			if _, err = io.Copy(io.Discard, r); err != nil {
+				_ = r.Close() // ignore error
+				t.Fatal("read -", err)
+			}
+			stats := r.Stats()
+			if stats.RawBytes != int64(len(file.Data)) {
+				t.Errorf("got RawBytes %d; want %d",
+					stats.RawBytes, len(file.Data))
+			}
+			if stats.RawBytes != r.BytesRead() {
+				t.Errorf("got RawBytes %d; BytesRead %d",
+					stats.RawBytes, r.BytesRead())
+			}
+			if stats.LogicalBytes != int64(len(data)) {
+				t.Errorf("got LogicalBytes %d; want %d",
+					stats.LogicalBytes, len(data))
+			}
+			if err = r.Close(); err != nil {
+				t.Error("close -", err)
+			}
+			testStatsElapsedStopped(t, r.Stats)
+		})
+	}
+}
+
+func TestReader_Stats_Tar(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	bodies := []string{"first entry", "second"}
+	for i, body := range bodies {
+		err := tw.WriteHeader(&tar.Header{
+			Name: string(rune('a'+i)) + ".txt",
+			Mode: 0600,
+			Size: int64(len(body)),
+		})
+		if err != nil {
+			t.Fatal("write tar header -", err)
+		} else if _, err = tw.Write([]byte(body)); err != nil {
+			t.Fatal("write tar body -", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal("close tar writer -", err)
+	}
+	mapFS := fstest.MapFS{"test.tar": {Data: buf.Bytes()}}
+	r, err := filesys.ReadFromFS(mapFS, "test.tar", nil)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	defer func(r filesys.Reader) {
+		if err := r.Close(); err != nil {
+			t.Error("close -", err)
+		}
+	}(r)
+	var want int64
+	for _, body := range bodies {
+		if _, err = r.TarNext(); err != nil {
+			t.Fatal("tar next -", err)
+		} else if _, err = io.Copy(io.Discard, r); err != nil {
+			t.Fatal("read -", err)
+		}
+		want += int64(len(body))
+		if got := r.Stats().LogicalBytes; got != want {
+			t.Errorf("got LogicalBytes %d; want %d", got, want)
+		}
+	}
+}
+
+func TestWriter_Stats(t *testing.T) {
+	const S = "Hello, world!\n"
+	const N int = 1000
+	for _, name := range []string{"test.txt", "test.txt.gz"} {
+		t.Run("file="+name, func(t *testing.T) {
+			file := &WritableFileImpl{Name: name}
+			w, err := filesys.Write(file, nil, true)
+			if err != nil {
+				t.Fatal("create -", err)
+			}
+			for range N {
+				if _, err = w.WriteString(S); err != nil {
+					_ = w.Close() // ignore error
+					t.Fatal("write -", err)
+				}
+			}
+			want := int64(len(S) * N)
+			stats := w.Stats()
+			if stats.LogicalBytes != want {
+				t.Errorf("before close, got LogicalBytes %d; want %d",
+					stats.LogicalBytes, want)
+			}
+			if err = w.Close(); err != nil {
+				t.Fatal("close -", err)
+			}
+			stats = w.Stats()
+			if stats.LogicalBytes != want {
+				t.Errorf("after close, got LogicalBytes %d; want %d",
+					stats.LogicalBytes, want)
+			}
+			if stats.RawBytes != int64(len(file.Data)) {
+				t.Errorf("got RawBytes %d; want %d",
+					stats.RawBytes, len(file.Data))
+			}
+			testStatsElapsedStopped(t, w.Stats)
+		})
+	}
+}
+
+// testStatsElapsedStopped tests that the elapsed time
+// reported by the closed reader or writer is positive and no longer grows.
+func testStatsElapsedStopped(t *testing.T, stats func() filesys.Stats) {
+	t.Helper()
+	elapsed := stats().Elapsed
+	if elapsed <= 0 {
+		t.Errorf("got Elapsed %v; want positive", elapsed)
+	}
+	time.Sleep(time.Millisecond)
+	if got := stats().Elapsed; got != elapsed {
+		t.Errorf("Elapsed changed after close: %v -> %v", elapsed, got)
+	}
+}
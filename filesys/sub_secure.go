@@ -0,0 +1,202 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// maxSymlinkHops is the maximum number of symbolic links
+// followed when resolving a path in the filesystem returned by SubSecure.
+const maxSymlinkHops = 40
+
+// SubSecure returns a filesystem (an io/fs.FS) corresponding to
+// the subtree rooted at dir in fsys,
+// which rejects the paths escaping from dir
+// (see below for the cases of symbolic links).
+// The returned filesystem also implements io/fs.ReadDirFS
+// and io/fs.StatFS.
+//
+// Like io/fs.Sub, the names passed to the returned filesystem
+// must be valid paths (see io/fs.ValidPath),
+// so absolute paths and paths containing ".." are rejected.
+// In addition, names containing backslashes are rejected,
+// as they may be interpreted as path separators on Windows.
+// These names cause an error wrapping io/fs.ErrInvalid.
+//
+// If fsys supports reading symbolic links
+// (i.e., it has the methods ReadLink and Lstat like io/fs.ReadLinkFS),
+// the symbolic links in the names are resolved within dir,
+// and any symbolic link pointing outside dir
+// (an absolute path or a relative path going beyond dir)
+// causes an error wrapping ErrPathEscape.
+// (To test whether err is ErrPathEscape, use function errors.Is.)
+// Otherwise, the symbolic links are NOT checked,
+// and they may lead to paths outside dir.
+//
+// In particular, the filesystem returned by os.DirFS
+// supports reading symbolic links only since Go 1.25.
+// For a local directory, use function
+// github.com/donyori/gogo/filesys/local.SubSecure instead,
+// which always checks the symbolic links.
+//
+// Note that the check is made before opening the file,
+// so it cannot prevent the symbolic links changed concurrently
+// by others from escaping.
+//
+// SubSecure reports an error wrapping io/fs.ErrInvalid
+// if dir is not a valid path.
+//
+// SubSecure panics if fsys is nil.
+func SubSecure(fsys fs.FS, dir string) (fs.FS, error) {
+	if fsys == nil {
+		panic(errors.AutoMsg("fsys is nil"))
+	} else if !fs.ValidPath(dir) {
+		return nil, errors.AutoWrap(&fs.PathError{
+			Op:   "sub",
+			Path: dir,
+			Err:  fs.ErrInvalid,
+		})
+	}
+	return &secureFS{fsys: fsys, dir: dir}, nil
+}
+
+// readLinkFS is a filesystem supporting reading symbolic links.
+//
+// It has the same method set as io/fs.ReadLinkFS.
+type readLinkFS interface {
+	fs.FS
+
+	ReadLink(name string) (string, error)
+
+	Lstat(name string) (fs.FileInfo, error)
+}
+
+// secureFS is the filesystem returned by SubSecure.
+type secureFS struct {
+	fsys fs.FS
+	dir  string
+}
+
+var (
+	_ fs.ReadDirFS = (*secureFS)(nil)
+	_ fs.StatFS    = (*secureFS)(nil)
+)
+
+func (s *secureFS) Open(name string) (fs.File, error) {
+	full, err := s.resolve("open", name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := s.fsys.Open(full)
+	return f, s.fixErr(err, name)
+}
+
+func (s *secureFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	full, err := s.resolve("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := fs.ReadDir(s.fsys, full)
+	return entries, s.fixErr(err, name)
+}
+
+func (s *secureFS) Stat(name string) (fs.FileInfo, error) {
+	full, err := s.resolve("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := fs.Stat(s.fsys, full)
+	return info, s.fixErr(err, name)
+}
+
+// resolve checks name and resolves the symbolic links in it (if supported),
+// and returns the corresponding path in s.fsys.
+//
+// op is the operation name used in the returned *io/fs.PathError.
+func (s *secureFS) resolve(op, name string) (string, error) {
+	if !fs.ValidPath(name) || strings.ContainsRune(name, '\\') {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	rl, ok := s.fsys.(readLinkFS)
+	if !ok || name == "." {
+		return path.Join(s.dir, name), nil
+	}
+	pending := strings.Split(name, "/")
+	resolved := make([]string, 0, len(pending))
+	var hops int
+	for len(pending) > 0 {
+		elem := pending[0]
+		pending = pending[1:]
+		switch elem {
+		case "", ".":
+			continue
+		case "..":
+			if len(resolved) == 0 {
+				return "", &fs.PathError{Op: op, Path: name, Err: ErrPathEscape}
+			}
+			resolved = resolved[:len(resolved)-1]
+			continue
+		}
+		resolved = append(resolved, elem)
+		p := path.Join(s.dir, path.Join(resolved...))
+		info, err := rl.Lstat(p)
+		if err != nil {
+			return "", s.fixErr(err, name)
+		} else if info.Mode()&fs.ModeSymlink == 0 {
+			continue
+		}
+		hops++
+		if hops > maxSymlinkHops {
+			return "", &fs.PathError{
+				Op:   op,
+				Path: name,
+				Err:  errors.New("too many levels of symbolic links"),
+			}
+		}
+		target, err := rl.ReadLink(p)
+		if err != nil {
+			return "", s.fixErr(err, name)
+		}
+		target = filepath.ToSlash(target)
+		if path.IsAbs(target) || filepath.IsAbs(target) ||
+			filepath.VolumeName(target) != "" {
+			return "", &fs.PathError{Op: op, Path: name, Err: ErrPathEscape}
+		}
+		// The target is relative to the directory containing the link.
+		resolved = resolved[:len(resolved)-1]
+		pending = append(strings.Split(target, "/"), pending...)
+	}
+	return path.Join(s.dir, path.Join(resolved...)), nil
+}
+
+// fixErr replaces the path in err (if it is an *io/fs.PathError)
+// with name, so as not to expose the paths outside the subtree.
+func (s *secureFS) fixErr(err error, name string) error {
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		return &fs.PathError{Op: pe.Op, Path: name, Err: pe.Err}
+	}
+	return err
+}
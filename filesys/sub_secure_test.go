@@ -0,0 +1,172 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/donyori/gogo/filesys"
+)
+
+func TestSubSecure_InvalidName(t *testing.T) {
+	fsys, err := filesys.SubSecure(testFS, ".")
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	names := []string{"", "/file1.txt", "../file1.txt", "a/../file1.txt", `a\file1.txt`, "./file1.txt"}
+	for _, name := range names {
+		t.Run("name="+name, func(t *testing.T) {
+			_, err := fsys.Open(name)
+			if !errors.Is(err, fs.ErrInvalid) {
+				t.Errorf("got %v; want %v", err, fs.ErrInvalid)
+			}
+		})
+	}
+}
+
+func TestSubSecure_InvalidDir(t *testing.T) {
+	for _, dir := range []string{"", "/", "..", "a/../b"} {
+		t.Run("dir="+dir, func(t *testing.T) {
+			_, err := filesys.SubSecure(testFS, dir)
+			if !errors.Is(err, fs.ErrInvalid) {
+				t.Errorf("got %v; want %v", err, fs.ErrInvalid)
+			}
+		})
+	}
+}
+
+func TestSubSecure_MapFS(t *testing.T) {
+	mapFS := fstest.MapFS{
+		"root/a.txt":     {Data: []byte("a")},
+		"root/dir/b.txt": {Data: []byte("b")},
+		"secret.txt":     {Data: []byte("secret")},
+	}
+	fsys, err := filesys.SubSecure(mapFS, "root")
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	err = fstest.TestFS(fsys, "a.txt", "dir/b.txt")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSubSecure_Symlink(t *testing.T) {
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "root")
+	for _, dir := range []string{
+		filepath.Join(root, "dir", "sub"),
+		filepath.Join(tmpDir, "outside"),
+	} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, data := range map[string]string{
+		filepath.Join(root, "dir", "sub", "file.txt"):  "inside",
+		filepath.Join(tmpDir, "outside", "secret.txt"): "secret",
+	} {
+		if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for link, target := range map[string]string{
+		"link-in":        "dir/sub/file.txt",
+		"link-dir":       "dir/sub",
+		"dir/link-up":    "../dir/sub/file.txt",
+		"link-out":       "../outside/secret.txt",
+		"link-out-dir":   "../outside",
+		"link-abs":       filepath.Join(tmpDir, "outside", "secret.txt"),
+		"dir/link-out":   "link-out-2",
+		"dir/link-out-2": "../../outside",
+		"loop":           "loop",
+	} {
+		err := os.Symlink(target, filepath.Join(root, filepath.FromSlash(link)))
+		if err != nil {
+			t.Skip("cannot create symbolic links -", err)
+		}
+	}
+
+	dirFS := os.DirFS(tmpDir)
+	if _, ok := dirFS.(interface {
+		ReadLink(name string) (string, error)
+	}); !ok {
+		t.Skip("os.DirFS does not support reading symbolic links")
+	}
+	fsys, err := filesys.SubSecure(dirFS, "root")
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	okNames := []string{
+		"dir/sub/file.txt",
+		"link-in",
+		"link-dir/file.txt",
+		"dir/link-up",
+	}
+	for _, name := range okNames {
+		t.Run("name="+name, func(t *testing.T) {
+			data, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				t.Fatal(err)
+			} else if string(data) != "inside" {
+				t.Errorf("got %q; want %q", data, "inside")
+			}
+		})
+	}
+	escapeNames := []string{
+		"link-out",
+		"link-out-dir/secret.txt",
+		"link-abs",
+		"dir/link-out/secret.txt",
+	}
+	for _, name := range escapeNames {
+		t.Run("name="+name, func(t *testing.T) {
+			_, err := fs.ReadFile(fsys, name)
+			if !errors.Is(err, filesys.ErrPathEscape) {
+				t.Errorf("got %v; want %v", err, filesys.ErrPathEscape)
+			}
+			_, err = fs.Stat(fsys, name)
+			if !errors.Is(err, filesys.ErrPathEscape) {
+				t.Errorf("stat - got %v; want %v", err, filesys.ErrPathEscape)
+			}
+		})
+	}
+	t.Run("name=loop", func(t *testing.T) {
+		if _, err := fsys.Open("loop"); err == nil {
+			t.Error("got nil error")
+		}
+	})
+	t.Run("ReadDir", func(t *testing.T) {
+		entries, err := fs.ReadDir(fsys, "link-dir")
+		if err != nil {
+			t.Fatal(err)
+		} else if len(entries) != 1 || entries[0].Name() != "file.txt" {
+			t.Errorf("got %v; want [file.txt]", entries)
+		}
+		_, err = fs.ReadDir(fsys, "link-out-dir")
+		if !errors.Is(err, filesys.ErrPathEscape) {
+			t.Errorf("got %v; want %v", err, filesys.ErrPathEscape)
+		}
+	})
+}
@@ -0,0 +1,583 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"sync"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// SyncReader returns a Reader that wraps r and serializes its method calls
+// with a mutex, so that it can be used by multiple goroutines concurrently.
+//
+// Each method call is atomic, but a sequence of calls is not.
+// To perform a sequence of calls atomically
+// (e.g., TarNext followed by reading the entry),
+// use function SyncReaderDo.
+//
+// The data returned by the methods ReadLine and Peek are
+// only valid until the next read operation,
+// which may be made by another goroutine.
+// The files and filesystems returned by the methods
+// TarFS, TarOpen, ZipOpen, and ZipFS are not protected by the mutex.
+// The functions passed to the methods ConsumeByteFunc and ConsumeRuneFunc
+// are called with the mutex held, so they must not call
+// the methods of the returned reader.
+//
+// If r is already returned by SyncReader, SyncReader returns r itself.
+//
+// SyncReader panics if r is nil.
+func SyncReader(r Reader) Reader {
+	if r == nil {
+		panic(errors.AutoMsg("Reader is nil"))
+	} else if sr, ok := r.(*syncReader); ok {
+		return sr
+	}
+	return &syncReader{r: r}
+}
+
+// SyncReaderDo calls f with the reader underlying r,
+// holding the mutex of r during the call,
+// so that the calls made by f are not interleaved with
+// the calls from other goroutines.
+//
+// r must be returned by function SyncReader.
+// f must not call the methods of r; instead, it uses its argument.
+//
+// SyncReaderDo returns the error returned by f.
+//
+// SyncReaderDo panics if r is not returned by SyncReader or f is nil.
+func SyncReaderDo(r Reader, f func(r Reader) error) error {
+	sr, ok := r.(*syncReader)
+	if !ok {
+		panic(errors.AutoMsg("Reader is not returned by SyncReader"))
+	} else if f == nil {
+		panic(errors.AutoMsg("f is nil"))
+	}
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return f(sr.r)
+}
+
+// SyncWriter returns a Writer that wraps w and serializes its method calls
+// with a mutex, so that it can be used by multiple goroutines concurrently,
+// for example, by the workers of package
+// github.com/donyori/gogo/concurrency/framework/jobsched.
+//
+// Each method call is atomic, but a sequence of calls is not.
+// To add an entry to an archive
+// (e.g., TarWriteHeader or ZipCreate followed by writing the file body)
+// without being interleaved with other goroutines,
+// use function SyncWriterDo.
+//
+// If w is already returned by SyncWriter, SyncWriter returns w itself.
+//
+// SyncWriter panics if w is nil.
+func SyncWriter(w Writer) Writer {
+	if w == nil {
+		panic(errors.AutoMsg("Writer is nil"))
+	} else if sw, ok := w.(*syncWriter); ok {
+		return sw
+	}
+	return &syncWriter{w: w}
+}
+
+// SyncWriterDo calls f with the writer underlying w,
+// holding the mutex of w during the call,
+// so that the calls made by f are not interleaved with
+// the calls from other goroutines.
+//
+// w must be returned by function SyncWriter.
+// f must not call the methods of w; instead, it uses its argument.
+//
+// SyncWriterDo returns the error returned by f.
+//
+// SyncWriterDo panics if w is not returned by SyncWriter or f is nil.
+func SyncWriterDo(w Writer, f func(w Writer) error) error {
+	sw, ok := w.(*syncWriter)
+	if !ok {
+		panic(errors.AutoMsg("Writer is not returned by SyncWriter"))
+	} else if f == nil {
+		panic(errors.AutoMsg("f is nil"))
+	}
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return f(sw.w)
+}
+
+// syncReader is an implementation of interface Reader
+// that serializes the method calls of the underlying reader with a mutex.
+type syncReader struct {
+	mu sync.Mutex
+	r  Reader
+}
+
+func (sr *syncReader) Close() error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.Close()
+}
+
+func (sr *syncReader) Closed() bool {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.Closed()
+}
+
+func (sr *syncReader) Read(p []byte) (n int, err error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.Read(p)
+}
+
+func (sr *syncReader) ReadByte() (byte, error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.ReadByte()
+}
+
+func (sr *syncReader) UnreadByte() error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.UnreadByte()
+}
+
+func (sr *syncReader) ConsumeByte(target byte, n int64) (consumed int64, err error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.ConsumeByte(target, n)
+}
+
+func (sr *syncReader) ConsumeByteFunc(f func(c byte) bool, n int64) (consumed int64, err error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.ConsumeByteFunc(f, n)
+}
+
+func (sr *syncReader) ReadRune() (r rune, size int, err error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.ReadRune()
+}
+
+func (sr *syncReader) UnreadRune() error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.UnreadRune()
+}
+
+func (sr *syncReader) ConsumeRune(target rune, n int64) (consumed int64, err error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.ConsumeRune(target, n)
+}
+
+func (sr *syncReader) ConsumeRuneFunc(f func(r rune, size int) bool, n int64) (consumed int64, err error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.ConsumeRuneFunc(f, n)
+}
+
+func (sr *syncReader) WriteTo(w io.Writer) (n int64, err error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.WriteTo(w)
+}
+
+func (sr *syncReader) ReadLine() (line []byte, more bool, err error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.ReadLine()
+}
+
+func (sr *syncReader) ReadEntireLine() (line []byte, err error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.ReadEntireLine()
+}
+
+func (sr *syncReader) WriteLineTo(w io.Writer) (n int64, err error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.WriteLineTo(w)
+}
+
+func (sr *syncReader) Size() int {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.Size()
+}
+
+func (sr *syncReader) Buffered() int {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.Buffered()
+}
+
+func (sr *syncReader) Peek(n int) (data []byte, err error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.Peek(n)
+}
+
+func (sr *syncReader) Discard(n int) (discarded int, err error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.Discard(n)
+}
+
+func (sr *syncReader) GzipHeader() (hdr *gzip.Header, err error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.GzipHeader()
+}
+
+func (sr *syncReader) TarEnabled() bool {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.TarEnabled()
+}
+
+func (sr *syncReader) TarNext() (hdr *tar.Header, err error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.TarNext()
+}
+
+func (sr *syncReader) TarFS() (fsys fs.FS, err error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.TarFS()
+}
+
+func (sr *syncReader) TarOpen(name string) (file fs.File, err error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.TarOpen(name)
+}
+
+func (sr *syncReader) ZipEnabled() bool {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.ZipEnabled()
+}
+
+func (sr *syncReader) ZipOpen(name string) (file fs.File, err error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.ZipOpen(name)
+}
+
+func (sr *syncReader) ZipFS() (fsys fs.FS, err error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.ZipFS()
+}
+
+func (sr *syncReader) ZipFiles() (files []*zip.File, err error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.ZipFiles()
+}
+
+func (sr *syncReader) ZipComment() (comment string, err error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.ZipComment()
+}
+
+func (sr *syncReader) ContentType() (contentType string, err error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.ContentType()
+}
+
+func (sr *syncReader) BytesRead() int64 {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.BytesRead()
+}
+
+func (sr *syncReader) Stats() Stats {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.Stats()
+}
+
+func (sr *syncReader) Options() *ReadOptions {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.Options()
+}
+
+func (sr *syncReader) FileStat() (info fs.FileInfo, err error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.r.FileStat()
+}
+
+// syncWriter is an implementation of interface Writer
+// that serializes the method calls of the underlying writer with a mutex.
+type syncWriter struct {
+	mu sync.Mutex
+	w  Writer
+}
+
+func (sw *syncWriter) Close() error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.Close()
+}
+
+func (sw *syncWriter) Closed() bool {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.Closed()
+}
+
+func (sw *syncWriter) Write(p []byte) (n int, err error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.Write(p)
+}
+
+func (sw *syncWriter) MustWrite(p []byte) (n int) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.MustWrite(p)
+}
+
+func (sw *syncWriter) WriteByte(c byte) error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.WriteByte(c)
+}
+
+func (sw *syncWriter) MustWriteByte(c byte) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	sw.w.MustWriteByte(c)
+}
+
+func (sw *syncWriter) WriteRune(r rune) (size int, err error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.WriteRune(r)
+}
+
+func (sw *syncWriter) MustWriteRune(r rune) (size int) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.MustWriteRune(r)
+}
+
+func (sw *syncWriter) WriteString(s string) (n int, err error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.WriteString(s)
+}
+
+func (sw *syncWriter) MustWriteString(s string) (n int) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.MustWriteString(s)
+}
+
+func (sw *syncWriter) ReadFrom(r io.Reader) (n int64, err error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.ReadFrom(r)
+}
+
+func (sw *syncWriter) Printf(format string, args ...any) (n int, err error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.Printf(format, args...)
+}
+
+func (sw *syncWriter) MustPrintf(format string, args ...any) (n int) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.MustPrintf(format, args...)
+}
+
+func (sw *syncWriter) Print(args ...any) (n int, err error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.Print(args...)
+}
+
+func (sw *syncWriter) MustPrint(args ...any) (n int) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.MustPrint(args...)
+}
+
+func (sw *syncWriter) Println(args ...any) (n int, err error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.Println(args...)
+}
+
+func (sw *syncWriter) MustPrintln(args ...any) (n int) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.MustPrintln(args...)
+}
+
+func (sw *syncWriter) Flush() error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.Flush()
+}
+
+func (sw *syncWriter) Size() int {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.Size()
+}
+
+func (sw *syncWriter) Buffered() int {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.Buffered()
+}
+
+func (sw *syncWriter) Available() int {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.Available()
+}
+
+func (sw *syncWriter) TarEnabled() bool {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.TarEnabled()
+}
+
+func (sw *syncWriter) TarWriteHeader(hdr *tar.Header) error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.TarWriteHeader(hdr)
+}
+
+func (sw *syncWriter) TarCopy(hdr *tar.Header, r io.Reader) error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.TarCopy(hdr, r)
+}
+
+func (sw *syncWriter) TarWriteSparse(hdr *tar.Header, r io.ReadSeeker) error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.TarWriteSparse(hdr, r)
+}
+
+func (sw *syncWriter) TarAddFS(fsys fs.FS) error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.TarAddFS(fsys)
+}
+
+func (sw *syncWriter) TarAddFSWithOptions(
+	fsys fs.FS,
+	opts *TarAddFSOptions,
+) error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.TarAddFSWithOptions(fsys, opts)
+}
+
+func (sw *syncWriter) ZipEnabled() bool {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.ZipEnabled()
+}
+
+func (sw *syncWriter) ZipCreate(name string) error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.ZipCreate(name)
+}
+
+func (sw *syncWriter) ZipCreateHeader(fh *zip.FileHeader) error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.ZipCreateHeader(fh)
+}
+
+func (sw *syncWriter) ZipCreateRaw(fh *zip.FileHeader) error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.ZipCreateRaw(fh)
+}
+
+func (sw *syncWriter) ZipCopy(f *zip.File) error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.ZipCopy(f)
+}
+
+func (sw *syncWriter) ZipAddFS(fsys fs.FS) error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.ZipAddFS(fsys)
+}
+
+func (sw *syncWriter) Checksums(upper bool) (uncompressed, compressed []string) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.Checksums(upper)
+}
+
+func (sw *syncWriter) BytesWritten() int64 {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.BytesWritten()
+}
+
+func (sw *syncWriter) Stats() Stats {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.Stats()
+}
+
+func (sw *syncWriter) Sync() error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.Sync()
+}
+
+func (sw *syncWriter) Options() *WriteOptions {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.Options()
+}
+
+func (sw *syncWriter) FileStat() (info fs.FileInfo, err error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.FileStat()
+}
@@ -0,0 +1,172 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/donyori/gogo/filesys"
+)
+
+func TestSyncReader(t *testing.T) {
+	const NumLine, NumGoroutine = 1000, 8
+	var b strings.Builder
+	for i := range NumLine {
+		_, _ = fmt.Fprintf(&b, "line %d\n", i) // ignore error
+	}
+	fsys := fstest.MapFS{"lines.txt": &fstest.MapFile{Data: []byte(b.String())}}
+	r, err := filesys.ReadFromFS(fsys, "lines.txt", nil)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	sr := filesys.SyncReader(r)
+	if sr2 := filesys.SyncReader(sr); sr2 != sr {
+		t.Error("SyncReader on a synchronized reader returned a new reader")
+	}
+	defer func(r filesys.Reader) {
+		if err := r.Close(); err != nil {
+			t.Error("close -", err)
+		}
+	}(sr)
+
+	seen := make([]bool, NumLine)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	wg.Add(NumGoroutine)
+	for range NumGoroutine {
+		go func() {
+			defer wg.Done()
+			for {
+				line, err := sr.ReadEntireLine()
+				if err == io.EOF {
+					return
+				} else if err != nil {
+					t.Error("read -", err)
+					return
+				}
+				var i int
+				_, err = fmt.Sscanf(string(line), "line %d", &i)
+				mu.Lock()
+				if err != nil || i < 0 || i >= NumLine || seen[i] {
+					t.Errorf("unexpected line %q", line)
+				} else {
+					seen[i] = true
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	for i := range seen {
+		if !seen[i] {
+			t.Errorf("line %d not read", i)
+		}
+	}
+}
+
+func TestSyncWriter_Zip(t *testing.T) {
+	const NumFile = 64
+	file := &WritableFileImpl{Name: "sync.zip"}
+	w, err := filesys.Write(file, nil, true)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	sw := filesys.SyncWriter(w)
+	if sw2 := filesys.SyncWriter(sw); sw2 != sw {
+		t.Error("SyncWriter on a synchronized writer returned a new writer")
+	}
+	var wg sync.WaitGroup
+	wg.Add(NumFile)
+	for i := range NumFile {
+		go func(i int) {
+			defer wg.Done()
+			err := filesys.SyncWriterDo(sw, func(w filesys.Writer) error {
+				err := w.ZipCreate(fmt.Sprintf("file%02d.txt", i))
+				if err != nil {
+					return err
+				}
+				_, err = w.Printf("This is file %d.\n", i)
+				return err
+			})
+			if err != nil {
+				t.Errorf("file %d - %v", i, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if err = sw.Close(); err != nil {
+		t.Fatal("close -", err)
+	}
+	if !sw.Closed() {
+		t.Error("got Closed false after closing")
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(file.Data), int64(len(file.Data)))
+	if err != nil {
+		t.Fatal("open zip -", err)
+	} else if len(zr.File) != NumFile {
+		t.Errorf("got %d zip files; want %d", len(zr.File), NumFile)
+	}
+	for _, f := range zr.File {
+		var i int
+		_, err = fmt.Sscanf(f.Name, "file%02d.txt", &i)
+		if err != nil {
+			t.Errorf("unexpected zip file %q", f.Name)
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Errorf("open %q - %v", f.Name, err)
+			continue
+		}
+		data, err := io.ReadAll(rc)
+		_ = rc.Close() // ignore error
+		if want := fmt.Sprintf("This is file %d.\n", i); err != nil ||
+			string(data) != want {
+			t.Errorf("%q - got (%q, %v); want (%q, nil)",
+				f.Name, data, err, want)
+		}
+	}
+}
+
+func TestSyncReaderDo_NotSyncReader(t *testing.T) {
+	fsys := fstest.MapFS{"a.txt": &fstest.MapFile{Data: []byte("a")}}
+	r, err := filesys.ReadFromFS(fsys, "a.txt", nil)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	defer func(r filesys.Reader) {
+		if err := r.Close(); err != nil {
+			t.Error("close -", err)
+		}
+	}(r)
+	defer func() {
+		if recover() == nil {
+			t.Error("want panic but not")
+		}
+	}()
+	_ = filesys.SyncReaderDo(r, func(r filesys.Reader) error { return nil })
+}
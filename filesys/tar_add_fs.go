@@ -0,0 +1,227 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"hash"
+	"io"
+	"io/fs"
+	"strconv"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// TarAddFSOptions are options for the method TarAddFSWithOptions of Writer.
+//
+// A nil *TarAddFSOptions is equivalent to a zero-value TarAddFSOptions.
+type TarAddFSOptions struct {
+	// SameFile reports whether two files are the same file,
+	// like function os.SameFile
+	// (which is suitable for the filesystem returned by os.DirFS).
+	//
+	// If SameFile is not nil, the regular files that are the same as
+	// a file already added (e.g., hard links to the same inode)
+	// are added as hard-link entries to that file,
+	// instead of duplicating the contents.
+	// Only the files of the same size are compared.
+	SameFile func(fi1, fi2 fs.FileInfo) bool
+
+	// DedupContent indicates whether to add the regular files
+	// with the same contents as a file already added
+	// as hard-link entries to that file, instead of duplicating the contents.
+	//
+	// The contents are compared by their sizes and hash checksums.
+	// Empty files are not deduplicated.
+	DedupContent bool
+
+	// NewHash is the function to create a new hash function
+	// used to compare the file contents when DedupContent is true.
+	//
+	// If NewHash is nil, crypto/sha256.New is used.
+	NewHash func() hash.Hash
+}
+
+func (fw *writer) TarAddFSWithOptions(
+	fsys fs.FS,
+	opts *TarAddFSOptions,
+) error {
+	err := fw.tarCheckAndFlush()
+	if err != nil {
+		return errors.AutoWrap(err)
+	} else if fsys == nil {
+		return nil
+	} else if opts == nil || opts.SameFile == nil && !opts.DedupContent {
+		return errors.AutoWrap(fw.tw.AddFS(fsys))
+	}
+	ta := &tarAdder{
+		tw:      fw.tw,
+		fsys:    fsys,
+		opts:    *opts,
+		bySize:  make(map[int64][]tarAddedFile),
+		byHash:  make(map[string]string),
+		hashBuf: make([]byte, 32<<10),
+	}
+	if ta.opts.NewHash == nil {
+		ta.opts.NewHash = sha256.New
+	}
+	return errors.AutoWrap(fs.WalkDir(fsys, ".", ta.add))
+}
+
+// tarAddedFile consists of the name and file information
+// of a regular file added to the tar archive.
+type tarAddedFile struct {
+	name string
+	info fs.FileInfo
+}
+
+// tarAdder holds the states of the method TarAddFSWithOptions of Writer.
+type tarAdder struct {
+	tw      *tar.Writer
+	fsys    fs.FS
+	opts    TarAddFSOptions
+	bySize  map[int64][]tarAddedFile // regular files added, grouped by size
+	byHash  map[string]string        // checksum (prefixed with size) to the name of the file added
+	hashBuf []byte
+}
+
+// add is the callback function of io/fs.WalkDir
+// to add the file name to the tar archive.
+//
+// It follows the method AddFS of archive/tar.Writer,
+// except for the deduplication of regular files.
+func (ta *tarAdder) add(name string, d fs.DirEntry, err error) error {
+	if err != nil {
+		return err
+	} else if name == "." {
+		return nil
+	}
+	info, err := d.Info()
+	if err != nil {
+		return err
+	}
+	var linkTarget string
+	if typ := d.Type(); typ == fs.ModeSymlink {
+		rl, ok := ta.fsys.(readLinkFS)
+		if !ok {
+			return errors.New("cannot read symbolic link " + name)
+		}
+		linkTarget, err = rl.ReadLink(name)
+		if err != nil {
+			return err
+		}
+	} else if !typ.IsRegular() && typ != fs.ModeDir {
+		return errors.New("cannot add non-regular file " + name)
+	}
+	hdr, err := tar.FileInfoHeader(info, linkTarget)
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	if d.IsDir() {
+		hdr.Name += "/"
+	}
+	if !d.Type().IsRegular() {
+		return ta.tw.WriteHeader(hdr)
+	}
+	key, target, err := ta.findSame(name, info)
+	if err != nil {
+		return err
+	} else if target != "" {
+		hdr.Typeflag, hdr.Linkname, hdr.Size = tar.TypeLink, target, 0
+		return ta.tw.WriteHeader(hdr)
+	}
+	err = ta.tw.WriteHeader(hdr)
+	if err != nil {
+		return err
+	}
+	f, err := ta.fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer func(f fs.File) {
+		_ = f.Close() // ignore error
+	}(f)
+	var w io.Writer = ta.tw
+	var h hash.Hash
+	if ta.opts.DedupContent && key == "" && info.Size() > 0 {
+		h = ta.opts.NewHash()
+		w = io.MultiWriter(ta.tw, h)
+	}
+	_, err = io.CopyBuffer(w, f, ta.hashBuf)
+	if err != nil {
+		return err
+	}
+	if h != nil {
+		key = hashKey(info.Size(), h)
+	}
+	if key != "" {
+		ta.byHash[key] = name
+	}
+	ta.bySize[info.Size()] = append(
+		ta.bySize[info.Size()], tarAddedFile{name: name, info: info})
+	return nil
+}
+
+// findSame finds the regular file added before that is the same as
+// (according to the option SameFile) or has the same contents as
+// (if the option DedupContent is true) the file name.
+//
+// It returns the name of the file found as target,
+// or an empty target if not found.
+// If the contents of the file are hashed, it also returns the key
+// of the file in ta.byHash; otherwise, key is empty.
+func (ta *tarAdder) findSame(name string, info fs.FileInfo) (
+	key, target string, err error) {
+	size := info.Size()
+	if len(ta.bySize[size]) == 0 {
+		return
+	}
+	if ta.opts.SameFile != nil {
+		for _, added := range ta.bySize[size] {
+			if ta.opts.SameFile(added.info, info) {
+				return "", added.name, nil
+			}
+		}
+	}
+	if !ta.opts.DedupContent || size == 0 {
+		return
+	}
+	f, err := ta.fsys.Open(name)
+	if err != nil {
+		return
+	}
+	defer func(f fs.File) {
+		_ = f.Close() // ignore error
+	}(f)
+	h := ta.opts.NewHash()
+	_, err = io.CopyBuffer(h, f, ta.hashBuf)
+	if err != nil {
+		return
+	}
+	key = hashKey(size, h)
+	return key, ta.byHash[key], nil
+}
+
+// hashKey returns the key of ta.byHash for a file with specified size
+// and hash function h that has consumed the file contents.
+func hashKey(size int64, h hash.Hash) string {
+	return strconv.FormatInt(size, 10) + ":" + string(h.Sum(nil))
+}
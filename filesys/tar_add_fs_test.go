@@ -0,0 +1,128 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/donyori/gogo/filesys"
+)
+
+func TestWriter_TarAddFSWithOptions(t *testing.T) {
+	const Content = "duplicate content"
+	mapFS := fstest.MapFS{
+		"a.txt":       {Data: []byte(Content), Mode: 0644, Sys: 1},
+		"b.txt":       {Data: []byte(Content), Mode: 0644, Sys: 2},
+		"dir/c.txt":   {Data: []byte(Content), Mode: 0644, Sys: 1},
+		"dir/d.txt":   {Data: []byte("unique content!!!"), Mode: 0644, Sys: 3},
+		"empty1":      {Mode: 0644, Sys: 4},
+		"empty2":      {Mode: 0644, Sys: 5},
+		"other/e.txt": {Data: []byte("other"), Mode: 0644, Sys: 6},
+	}
+	sameFile := func(fi1, fi2 fs.FileInfo) bool {
+		return fi1.Sys() == fi2.Sys()
+	}
+	testCases := []struct {
+		name  string
+		opts  *filesys.TarAddFSOptions
+		links map[string]string // hard-link name to target
+	}{
+		{"nil", nil, nil},
+		{"sameFile", &filesys.TarAddFSOptions{SameFile: sameFile},
+			map[string]string{"dir/c.txt": "a.txt"}},
+		{"dedupContent", &filesys.TarAddFSOptions{DedupContent: true},
+			map[string]string{"b.txt": "a.txt", "dir/c.txt": "a.txt"}},
+		{"both", &filesys.TarAddFSOptions{SameFile: sameFile, DedupContent: true},
+			map[string]string{"b.txt": "a.txt", "dir/c.txt": "a.txt"}},
+	}
+	for _, tc := range testCases {
+		t.Run("opts="+tc.name, func(t *testing.T) {
+			file := &WritableFileImpl{Name: "test.tar"}
+			w, err := filesys.Write(file, nil, true)
+			if err != nil {
+				t.Fatal("create -", err)
+			}
+			err = w.TarAddFSWithOptions(mapFS, tc.opts)
+			if closeErr := w.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				t.Fatal("add -", err)
+			}
+			tr := tar.NewReader(bytes.NewReader(file.Data))
+			gotLinks := make(map[string]string)
+			var numReg int
+			for {
+				hdr, err := tr.Next()
+				if errors.Is(err, io.EOF) {
+					break
+				} else if err != nil {
+					t.Fatal("read tar -", err)
+				}
+				switch hdr.Typeflag {
+				case tar.TypeLink:
+					gotLinks[hdr.Name] = hdr.Linkname
+				case tar.TypeReg:
+					numReg++
+					data, err := io.ReadAll(tr)
+					if err != nil {
+						t.Fatalf("read %q - %v", hdr.Name, err)
+					} else if string(data) != string(mapFS[hdr.Name].Data) {
+						t.Errorf("%s - got %q; want %q",
+							hdr.Name, data, mapFS[hdr.Name].Data)
+					}
+				}
+			}
+			if len(gotLinks) != len(tc.links) {
+				t.Errorf("got links %v; want %v", gotLinks, tc.links)
+			}
+			for name, target := range tc.links {
+				if gotLinks[name] != target {
+					t.Errorf("%s - got link target %q; want %q",
+						name, gotLinks[name], target)
+				}
+			}
+			if want := len(mapFS) - len(tc.links); numReg != want {
+				t.Errorf("got %d regular files; want %d", numReg, want)
+			}
+		})
+	}
+}
+
+func TestWriter_TarAddFSWithOptions_NotTar(t *testing.T) {
+	file := &WritableFileImpl{Name: "test.txt"}
+	w, err := filesys.Write(file, nil, true)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	defer func(w filesys.Writer) {
+		_ = w.Close() // ignore error
+	}(w)
+	err = w.TarAddFSWithOptions(
+		fstest.MapFS{}, &filesys.TarAddFSOptions{DedupContent: true})
+	if !errors.Is(err, filesys.ErrNotTar) {
+		t.Errorf("got %v; want %v", err, filesys.ErrNotTar)
+	}
+}
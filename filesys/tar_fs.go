@@ -0,0 +1,414 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// tarIndex is an index of the entries in a tar archive.
+//
+// It implements io/fs.FS, io/fs.ReadDirFS, and io/fs.StatFS.
+type tarIndex struct {
+	ra      *io.SectionReader    // the tar archive for reading file contents, or nil if the contents are buffered
+	entries map[string]*tarEntry // cleaned name -> entry
+}
+
+var (
+	_ fs.ReadDirFS = (*tarIndex)(nil)
+	_ fs.StatFS    = (*tarIndex)(nil)
+)
+
+// tarEntry is an entry in tarIndex.
+type tarEntry struct {
+	hdr      *tar.Header // nil for synthesized directories
+	info     fs.FileInfo
+	offset   int64    // offset of the file contents in tarIndex.ra
+	data     []byte   // buffered file contents, used if tarIndex.ra is nil or the entry is sparse
+	buffered bool     // true if the file contents are in data
+	children []string // sorted base names of the children, for directories
+}
+
+// tarBuildIndex builds fr.tarIdx if it has not been built.
+func (fr *reader) tarBuildIndex() error {
+	if fr.tarIdx != nil {
+		return nil
+	}
+	idx := &tarIndex{entries: make(map[string]*tarEntry)}
+	if fr.tarRA != nil {
+		idx.ra = fr.tarRA
+		sr := io.NewSectionReader(fr.tarRA, 0, fr.tarRA.Size())
+		// sr implements io.Seeker, so tr skips file contents by seeking.
+		tr := tar.NewReader(sr)
+		for {
+			hdr, err := tr.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				return err
+			}
+			e := &tarEntry{hdr: hdr}
+			if isTarHeaderSparse(hdr) {
+				// The contents of a sparse file are not stored contiguously.
+				e.data, err = io.ReadAll(tr)
+				if err != nil {
+					return err
+				}
+				e.buffered = true
+			} else {
+				e.offset, err = sr.Seek(0, io.SeekCurrent)
+				if err != nil {
+					return err
+				}
+			}
+			idx.add(e)
+		}
+	} else {
+		for {
+			hdr, err := fr.tr.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				return err
+			}
+			data, err := io.ReadAll(fr.tr)
+			if err != nil {
+				return err
+			}
+			idx.add(&tarEntry{hdr: hdr, data: data, buffered: true})
+		}
+		fr.ur, fr.err = fr.tr, nil
+		fr.resetBuffer()
+	}
+	idx.finish()
+	fr.tarIdx = idx
+	return nil
+}
+
+// isTarHeaderSparse reports whether hdr is the header of a sparse file.
+func isTarHeaderSparse(hdr *tar.Header) bool {
+	if hdr.Typeflag == tar.TypeGNUSparse {
+		return true
+	}
+	for key := range hdr.PAXRecords {
+		if strings.HasPrefix(key, "GNU.sparse.") {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanTarName cleans the name of a tar entry.
+//
+// It returns false if the cleaned name is not valid for io/fs.FS.
+func cleanTarName(name string) (cleaned string, ok bool) {
+	cleaned = path.Clean("/" + name)[1:]
+	if cleaned == "" {
+		return ".", true
+	}
+	return cleaned, fs.ValidPath(cleaned)
+}
+
+// add adds the entry e to the index.
+func (idx *tarIndex) add(e *tarEntry) {
+	name, ok := cleanTarName(e.hdr.Name)
+	if !ok || name == "." {
+		return
+	}
+	idx.entries[name] = e
+}
+
+// finish resolves hard links, synthesizes parent directories,
+// and collects the children of directories.
+func (idx *tarIndex) finish() {
+	for _, e := range idx.entries {
+		if e.hdr.Typeflag != tar.TypeLink {
+			continue
+		}
+		targetName, ok := cleanTarName(e.hdr.Linkname)
+		if !ok {
+			continue
+		}
+		target := idx.entries[targetName]
+		if target == nil || target.hdr == nil ||
+			target.hdr.Typeflag != tar.TypeReg &&
+				target.hdr.Typeflag != tar.TypeGNUSparse {
+			continue
+		}
+		hdr := *e.hdr
+		hdr.Typeflag, hdr.Size = tar.TypeReg, target.hdr.Size
+		e.hdr = &hdr
+		e.offset, e.data, e.buffered = target.offset, target.data, target.buffered
+	}
+	names := make([]string, 0, len(idx.entries))
+	for name, e := range idx.entries {
+		e.info = e.hdr.FileInfo()
+		names = append(names, name)
+	}
+	for _, name := range names {
+		for dir := path.Dir(name); ; dir = path.Dir(dir) {
+			if _, ok := idx.entries[dir]; ok {
+				break
+			}
+			idx.entries[dir] = &tarEntry{info: &tarDirInfo{name: path.Base(dir)}}
+			if dir == "." {
+				break
+			}
+		}
+	}
+	if root := idx.entries["."]; root == nil {
+		idx.entries["."] = &tarEntry{info: &tarDirInfo{name: "."}}
+	}
+	for name := range idx.entries {
+		if name == "." {
+			continue
+		}
+		parent := idx.entries[path.Dir(name)]
+		parent.children = append(parent.children, path.Base(name))
+	}
+	for _, e := range idx.entries {
+		slices.Sort(e.children)
+	}
+}
+
+func (idx *tarIndex) Open(name string) (fs.File, error) {
+	e, err := idx.lookup("open", name)
+	if err != nil {
+		return nil, err
+	} else if e.info.IsDir() {
+		return &tarDirFile{idx: idx, name: name, e: e}, nil
+	}
+	return &tarFile{e: e, r: idx.contentReader(e)}, nil
+}
+
+func (idx *tarIndex) ReadDir(name string) ([]fs.DirEntry, error) {
+	e, err := idx.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	} else if !e.info.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	return idx.dirEntries(name, e.children), nil
+}
+
+func (idx *tarIndex) Stat(name string) (fs.FileInfo, error) {
+	e, err := idx.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return e.info, nil
+}
+
+// lookup returns the entry with the specified name.
+//
+// op is the operation name used in the returned *io/fs.PathError.
+func (idx *tarIndex) lookup(op, name string) (*tarEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	e := idx.entries[name]
+	if e == nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return e, nil
+}
+
+// contentReader returns a reader for the contents of the entry e.
+func (idx *tarIndex) contentReader(e *tarEntry) io.ReadSeeker {
+	if e.buffered {
+		return bytes.NewReader(e.data)
+	} else if e.hdr == nil || idx.ra == nil ||
+		e.hdr.Typeflag != tar.TypeReg && e.hdr.Typeflag != tar.TypeChar {
+		return bytes.NewReader(nil) // no contents
+	}
+	return io.NewSectionReader(idx.ra, e.offset, e.hdr.Size)
+}
+
+// dirEntries returns the directory entries of the children
+// with the specified base names in the directory dir.
+func (idx *tarIndex) dirEntries(dir string, children []string) []fs.DirEntry {
+	list := make([]fs.DirEntry, len(children))
+	for i, child := range children {
+		name := child
+		if dir != "." {
+			name = dir + "/" + child
+		}
+		list[i] = fs.FileInfoToDirEntry(idx.entries[name].info)
+	}
+	return list
+}
+
+// tarFile is a non-directory file opened from tarIndex.
+type tarFile struct {
+	e      *tarEntry
+	r      io.ReadSeeker
+	closed bool
+}
+
+func (f *tarFile) Stat() (fs.FileInfo, error) {
+	if f.closed {
+		return nil, f.errClosed("stat")
+	}
+	return f.e.info, nil
+}
+
+func (f *tarFile) Read(p []byte) (n int, err error) {
+	if f.closed {
+		return 0, f.errClosed("read")
+	}
+	return f.r.Read(p)
+}
+
+func (f *tarFile) Seek(offset int64, whence int) (int64, error) {
+	if f.closed {
+		return 0, f.errClosed("seek")
+	}
+	return f.r.Seek(offset, whence)
+}
+
+func (f *tarFile) ReadAt(p []byte, off int64) (n int, err error) {
+	if f.closed {
+		return 0, f.errClosed("read")
+	}
+	return f.r.(io.ReaderAt).ReadAt(p, off)
+}
+
+func (f *tarFile) Close() error {
+	if f.closed {
+		return f.errClosed("close")
+	}
+	f.closed = true
+	return nil
+}
+
+// errClosed returns an *io/fs.PathError indicating that the file is closed.
+func (f *tarFile) errClosed(op string) error {
+	return &fs.PathError{Op: op, Path: f.e.info.Name(), Err: fs.ErrClosed}
+}
+
+// tarDirFile is a directory opened from tarIndex.
+type tarDirFile struct {
+	idx    *tarIndex
+	name   string
+	e      *tarEntry
+	offset int // the number of entries already read by ReadDir
+	closed bool
+}
+
+func (d *tarDirFile) Stat() (fs.FileInfo, error) {
+	if d.closed {
+		return nil, &fs.PathError{Op: "stat", Path: d.name, Err: fs.ErrClosed}
+	}
+	return d.e.info, nil
+}
+
+func (d *tarDirFile) Read([]byte) (n int, err error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: ErrIsDir}
+}
+
+func (d *tarDirFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if d.closed {
+		return nil, &fs.PathError{Op: "readdir", Path: d.name, Err: fs.ErrClosed}
+	}
+	rest := d.e.children[d.offset:]
+	if n > 0 {
+		if len(rest) == 0 {
+			return nil, io.EOF
+		}
+		rest = rest[:min(n, len(rest))]
+	}
+	d.offset += len(rest)
+	return d.idx.dirEntries(d.name, rest), nil
+}
+
+func (d *tarDirFile) Close() error {
+	if d.closed {
+		return &fs.PathError{Op: "close", Path: d.name, Err: fs.ErrClosed}
+	}
+	d.closed = true
+	return nil
+}
+
+// tarDirInfo is the io/fs.FileInfo of a synthesized directory.
+type tarDirInfo struct {
+	name string
+}
+
+func (di *tarDirInfo) Name() string {
+	return di.name
+}
+
+func (di *tarDirInfo) Size() int64 {
+	return 0
+}
+
+func (di *tarDirInfo) Mode() fs.FileMode {
+	return fs.ModeDir | 0555
+}
+
+func (di *tarDirInfo) ModTime() time.Time {
+	return time.Time{}
+}
+
+func (di *tarDirInfo) IsDir() bool {
+	return true
+}
+
+func (di *tarDirInfo) Sys() any {
+	return nil
+}
+
+// tarStreamFile is a file opened by the method TarOpen of reader
+// that reads the contents from the tar stream.
+type tarStreamFile struct {
+	fr     *reader
+	info   fs.FileInfo
+	closed bool
+}
+
+func (f *tarStreamFile) Stat() (fs.FileInfo, error) {
+	if f.closed {
+		return nil, &fs.PathError{Op: "stat", Path: f.info.Name(), Err: fs.ErrClosed}
+	}
+	return f.info, nil
+}
+
+func (f *tarStreamFile) Read(p []byte) (n int, err error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: "read", Path: f.info.Name(), Err: fs.ErrClosed}
+	}
+	return f.fr.Read(p)
+}
+
+func (f *tarStreamFile) Close() error {
+	if f.closed {
+		return &fs.PathError{Op: "close", Path: f.info.Name(), Err: fs.ErrClosed}
+	}
+	f.closed = true
+	return nil
+}
@@ -0,0 +1,233 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/donyori/gogo/filesys"
+)
+
+func TestReader_TarFS(t *testing.T) {
+	for _, name := range append(testFSTarFilenames, testFSTgzFilenames...) {
+		t.Run(fmt.Sprintf("file=%+q", name), func(t *testing.T) {
+			r, err := filesys.ReadFromFS(testFS, name, nil)
+			if err != nil {
+				t.Fatal("create -", err)
+			}
+			defer func(r filesys.Reader) {
+				if err := r.Close(); err != nil {
+					t.Error("close -", err)
+				}
+			}(r)
+			fsys, err := r.TarFS()
+			if err != nil {
+				t.Fatal("TarFS -", err)
+			}
+			var expected []string
+			for _, file := range testFSTarFiles {
+				expected = append(expected, strings.TrimSuffix(file.name, "/"))
+			}
+			if err = fstest.TestFS(fsys, expected...); err != nil {
+				t.Error("test FS -", err)
+			}
+			for _, file := range testFSTarFiles {
+				if strings.HasSuffix(file.name, "/") {
+					continue
+				}
+				data, err := fs.ReadFile(fsys, file.name)
+				if err != nil {
+					t.Errorf("read %q - %v", file.name, err)
+				} else if string(data) != file.body {
+					t.Errorf("file %q - content unequal", file.name)
+				}
+			}
+
+			// TarNext should still work on an uncompressed tar archive,
+			// and should report io.EOF on a compressed one.
+			hdr, err := r.TarNext()
+			if strings.HasSuffix(name, ".tar") {
+				if err != nil {
+					t.Error("TarNext -", err)
+				} else if hdr.Name != testFSTarFiles[0].name {
+					t.Errorf("TarNext - got %q; want %q",
+						hdr.Name, testFSTarFiles[0].name)
+				}
+			} else if !errors.Is(err, io.EOF) {
+				t.Errorf("TarNext - got %v; want io.EOF", err)
+			}
+		})
+	}
+}
+
+func TestReader_TarFS_Special(t *testing.T) {
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	entries := []struct {
+		hdr  tar.Header
+		body string
+	}{
+		{tar.Header{Name: "./a/b/c.txt", Mode: 0644}, "file c"},
+		{tar.Header{Name: "d.txt", Mode: 0644}, "old d"},
+		{tar.Header{Name: "d.txt", Mode: 0644}, "new d"},
+		{tar.Header{
+			Name:     "link.txt",
+			Typeflag: tar.TypeLink,
+			Linkname: "a/b/c.txt",
+			Mode:     0644,
+		}, ""},
+		{tar.Header{Name: "../escape.txt", Mode: 0644}, "escape"},
+	}
+	for _, entry := range entries {
+		hdr := entry.hdr
+		hdr.Size = int64(len(entry.body))
+		if err := tw.WriteHeader(&hdr); err != nil {
+			t.Fatalf("write header %q - %v", hdr.Name, err)
+		} else if _, err = tw.Write([]byte(entry.body)); err != nil {
+			t.Fatalf("write body %q - %v", hdr.Name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal("close tar writer -", err)
+	}
+	fsys := fstest.MapFS{"special.tar": {Data: buf.Bytes()}}
+	r, err := filesys.ReadFromFS(fsys, "special.tar", nil)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	defer func(r filesys.Reader) {
+		_ = r.Close() // ignore error
+	}(r)
+	tarFS, err := r.TarFS()
+	if err != nil {
+		t.Fatal("TarFS -", err)
+	}
+	err = fstest.TestFS(tarFS, "a", "a/b", "a/b/c.txt", "d.txt", "link.txt")
+	if err != nil {
+		t.Error("test FS -", err)
+	}
+	want := map[string]string{
+		"a/b/c.txt":  "file c",
+		"d.txt":      "new d",
+		"link.txt":   "file c",
+		"escape.txt": "escape",
+	}
+	for name, body := range want {
+		data, err := fs.ReadFile(tarFS, name)
+		if err != nil {
+			t.Errorf("read %q - %v", name, err)
+		} else if string(data) != body {
+			t.Errorf("file %q - got %q; want %q", name, data, body)
+		}
+	}
+	if _, err = tarFS.Open("nonexistent"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("open nonexistent - got %v; want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestReader_TarFS_NotTar(t *testing.T) {
+	r, err := filesys.ReadFromFS(testFS, "file1.txt", nil)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	defer func(r filesys.Reader) {
+		_ = r.Close() // ignore error
+	}(r)
+	if _, err = r.TarFS(); !errors.Is(err, filesys.ErrNotTar) {
+		t.Errorf("got %v; want %v", err, filesys.ErrNotTar)
+	}
+}
+
+func TestReader_TarOpen(t *testing.T) {
+	bodyMap := make(map[string]string, len(testFSTarFiles))
+	for _, file := range testFSTarFiles {
+		bodyMap[file.name] = file.body
+	}
+	// Open in reverse order to test random access.
+	names := []string{"13KB.dat", "tardir/tar file2.txt", "tardir/tar file1.txt"}
+
+	for _, name := range append(testFSTarFilenames, testFSTgzFilenames...) {
+		for _, buildFS := range []bool{false, true} {
+			t.Run(fmt.Sprintf("file=%+q&TarFS=%t", name, buildFS), func(t *testing.T) {
+				r, err := filesys.ReadFromFS(testFS, name, nil)
+				if err != nil {
+					t.Fatal("create -", err)
+				}
+				defer func(r filesys.Reader) {
+					if err := r.Close(); err != nil {
+						t.Error("close -", err)
+					}
+				}(r)
+				if buildFS {
+					if _, err = r.TarFS(); err != nil {
+						t.Fatal("TarFS -", err)
+					}
+				}
+				// Without an index, the tar stream can only go forward.
+				indexed := buildFS || strings.HasSuffix(name, ".tar")
+				for i, entryName := range names {
+					f, err := r.TarOpen(entryName)
+					if !indexed && i > 0 {
+						if !errors.Is(err, fs.ErrNotExist) {
+							t.Errorf("open %q - got %v; want %v",
+								entryName, err, fs.ErrNotExist)
+						}
+						continue
+					} else if err != nil {
+						t.Errorf("open %q - %v", entryName, err)
+						continue
+					}
+					data, err := io.ReadAll(f)
+					if err != nil {
+						t.Errorf("read %q - %v", entryName, err)
+					} else if string(data) != bodyMap[entryName] {
+						t.Errorf("file %q - content unequal", entryName)
+					}
+					if err = f.Close(); err != nil {
+						t.Errorf("close %q - %v", entryName, err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestReader_TarOpen_Invalid(t *testing.T) {
+	r, err := filesys.ReadFromFS(testFS, testFSTarFilenames[0], nil)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	defer func(r filesys.Reader) {
+		_ = r.Close() // ignore error
+	}(r)
+	for _, name := range []string{"/13KB.dat", "./13KB.dat", "nonexistent"} {
+		_, err = r.TarOpen(name)
+		if err == nil {
+			t.Errorf("open %q - got nil error", name)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// tarSparseEntry is a data fragment of a sparse file.
+type tarSparseEntry struct {
+	off, n int64
+}
+
+// end returns the offset right after the fragment.
+func (e tarSparseEntry) end() int64 {
+	return e.off + e.n
+}
+
+func (fw *writer) TarWriteSparse(hdr *tar.Header, r io.ReadSeeker) error {
+	err := fw.tarCheckAndFlush()
+	if err != nil {
+		return errors.AutoWrap(err)
+	} else if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != '\x00' {
+		return errors.AutoNew("hdr is not a header of a regular file")
+	} else if r == nil {
+		return errors.AutoNew("r is nil")
+	}
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	data, err := tarSparseData(r, size)
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	if len(data) == 1 && data[0].off == 0 && data[0].n == size {
+		// No holes. Write it as a regular file.
+		_, err = r.Seek(0, io.SeekStart)
+		if err != nil {
+			return errors.AutoWrap(err)
+		}
+		h := *hdr
+		h.Typeflag, h.Size = tar.TypeReg, size
+		return errors.AutoWrap(fw.TarCopy(&h, r))
+	}
+
+	// Encode the sparse map in the GNU sparse format 1.0.
+	if len(data) == 0 || data[len(data)-1].end() < size {
+		data = append(data, tarSparseEntry{off: size})
+	}
+	spb := strconv.AppendInt(nil, int64(len(data)), 10)
+	spb = append(spb, '\n')
+	var encodedSize int64
+	for _, e := range data {
+		spb = strconv.AppendInt(spb, e.off, 10)
+		spb = append(spb, '\n')
+		spb = strconv.AppendInt(spb, e.n, 10)
+		spb = append(spb, '\n')
+		encodedSize += e.n
+	}
+	spb = append(spb, make([]byte, tarBlockPadding(int64(len(spb))))...)
+	encodedSize += int64(len(spb))
+
+	h := *hdr
+	realName := h.Name
+	dir, file := path.Split(realName)
+	h.Name = path.Join(dir, "GNUSparseFile.0", file)
+	h.Typeflag, h.Size = tar.TypeReg, encodedSize
+	records := make(map[string]string, len(h.PAXRecords)+4)
+	for k, v := range h.PAXRecords {
+		if !strings.HasPrefix(k, "GNU.sparse.") {
+			records[k] = v
+		}
+	}
+	records["GNU.sparse.major"] = "1"
+	records["GNU.sparse.minor"] = "0"
+	records["GNU.sparse.name"] = realName
+	records["GNU.sparse.realsize"] = strconv.FormatInt(size, 10)
+	h.PAXRecords = nil
+	// The header must be encoded without a PAX header of its own,
+	// which would replace the PAX header written here.
+	for _, format := range []tar.Format{tar.FormatUSTAR, tar.FormatGNU} {
+		h.Format = format
+		err = tar.NewWriter(io.Discard).WriteHeader(&h)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return errors.AutoWrap(fmt.Errorf(
+			"cannot encode the header of sparse file %q without PAX: %w",
+			realName, err))
+	}
+
+	err = fw.tw.Flush() // write the padding of the previous entry
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	_, err = fw.tarUW.Write(tarPAXHeader(dir, file, hdr, records))
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	err = fw.tw.WriteHeader(&h)
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	fw.uw, fw.err = fw.tw, nil
+	fw.resetBuffer()
+	_, err = fw.tw.Write(spb)
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	dst := fw.limited(fw.tw)
+	for _, e := range data {
+		if e.n == 0 {
+			continue
+		}
+		_, err = r.Seek(e.off, io.SeekStart)
+		if err != nil {
+			return errors.AutoWrap(err)
+		}
+		n, err := io.CopyN(dst, r, e.n)
+		if errors.Is(err, io.EOF) {
+			err = fmt.Errorf("%w; copied %d of %d bytes at offset %d",
+				io.ErrUnexpectedEOF, n, e.n, e.off)
+		}
+		if err != nil {
+			return errors.AutoWrap(err)
+		}
+	}
+	return nil
+}
+
+// tarBlockPadding returns the number of bytes needed to pad n bytes
+// to a multiple of tarBlockSize.
+func tarBlockPadding(n int64) int64 {
+	return -n & (tarBlockSize - 1)
+}
+
+// tarSparseData returns the data fragments of the file r of size bytes,
+// in ascending order of offset.
+//
+// It uses SEEK_DATA and SEEK_HOLE if available.
+// Otherwise, it scans the file for blocks of zeros.
+func tarSparseData(r io.ReadSeeker, size int64) ([]tarSparseEntry, error) {
+	if data, ok := tarSparseDataSeek(r, size); ok {
+		return data, nil
+	}
+	_, err := r.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	var data []tarSparseEntry
+	br := bufio.NewReaderSize(r, 64<<10)
+	block := make([]byte, tarBlockSize)
+	for off := int64(0); off < size; off += tarBlockSize {
+		n, err := io.ReadFull(br, block[:min(tarBlockSize, size-off)])
+		if err != nil {
+			return nil, err
+		} else if isZeros(block[:n]) {
+			continue
+		}
+		if k := len(data) - 1; k >= 0 && data[k].end() == off {
+			data[k].n += int64(n)
+		} else {
+			data = append(data, tarSparseEntry{off: off, n: int64(n)})
+		}
+	}
+	return data, nil
+}
+
+// tarSparseDataSeek is like tarSparseData,
+// but finds the data fragments through SEEK_DATA and SEEK_HOLE.
+//
+// It only works on files with a method Fd (such as *os.File)
+// on the operating systems supporting SEEK_DATA and SEEK_HOLE.
+// ok is false if it does not work.
+func tarSparseDataSeek(r io.ReadSeeker, size int64) (
+	data []tarSparseEntry, ok bool) {
+	if _, hasFd := r.(interface{ Fd() uintptr }); !hasFd {
+		return nil, false
+	}
+	var seekData, seekHole int
+	switch runtime.GOOS {
+	case "linux", "android", "freebsd", "solaris", "illumos":
+		seekData, seekHole = 3, 4
+	case "darwin", "ios":
+		seekData, seekHole = 4, 3
+	default:
+		return nil, false
+	}
+	// Test whether SEEK_HOLE is supported.
+	if _, err := r.Seek(0, seekHole); err != nil {
+		return nil, false
+	}
+	for off := int64(0); off < size; {
+		start, err := r.Seek(off, seekData)
+		if err != nil || start >= size {
+			break // no more data (ENXIO)
+		}
+		end, err := r.Seek(start, seekHole)
+		if err != nil {
+			return nil, false
+		}
+		end = min(end, size)
+		data = append(data, tarSparseEntry{off: start, n: end - start})
+		off = end
+	}
+	return data, true
+}
+
+// isZeros reports whether p consists of zeros only.
+func isZeros(p []byte) bool {
+	for len(p) >= len(zeroBlock) {
+		if !bytes.Equal(p[:len(zeroBlock)], zeroBlock[:]) {
+			return false
+		}
+		p = p[len(zeroBlock):]
+	}
+	return bytes.Equal(p, zeroBlock[:len(p)])
+}
+
+// zeroBlock is a block of zeros.
+var zeroBlock [tarBlockSize]byte
+
+// tarPAXHeader returns the PAX extended header (including its content)
+// of the file with specified directory dir, base name file, and header hdr,
+// consisting of the specified records.
+func tarPAXHeader(
+	dir string,
+	file string,
+	hdr *tar.Header,
+	records map[string]string,
+) []byte {
+	keys := make([]string, 0, len(records))
+	for k := range records {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	var content []byte
+	for _, k := range keys {
+		content = append(content, formatPAXRecord(k, records[k])...)
+	}
+
+	block := make([]byte, tarBlockSize)
+	copy(block[:100], path.Join(dir, "PaxHeaders.0", file))
+	copy(block[100:], "0000644\x00")
+	copy(block[108:], "0000000\x00")
+	copy(block[116:], "0000000\x00")
+	copy(block[124:], fmt.Sprintf("%011o\x00", len(content)))
+	var mtime int64
+	if unix := hdr.ModTime.Unix(); unix > 0 && unix < 1<<33 {
+		mtime = unix
+	}
+	copy(block[136:], fmt.Sprintf("%011o\x00", mtime))
+	copy(block[148:156], "        ") // checksum placeholder
+	block[156] = tar.TypeXHeader
+	copy(block[257:], "ustar\x0000")
+	var chksum int64
+	for _, c := range block {
+		chksum += int64(c)
+	}
+	copy(block[148:], fmt.Sprintf("%06o\x00 ", chksum))
+
+	b := make([]byte, 0, len(block)+len(content)+tarBlockSize)
+	b = append(b, block...)
+	b = append(b, content...)
+	return append(b, make([]byte, tarBlockPadding(int64(len(content))))...)
+}
+
+// formatPAXRecord formats a PAX record,
+// prefixing it with its length (including the length itself).
+func formatPAXRecord(k, v string) string {
+	const padding = 3 // extra padding for ' ', '=', and '\n'
+	size := len(k) + len(v) + padding
+	size += len(strconv.Itoa(size))
+	record := strconv.Itoa(size) + " " + k + "=" + v + "\n"
+	if len(record) != size { // the length of size has changed
+		size = len(record)
+		record = strconv.Itoa(size) + " " + k + "=" + v + "\n"
+	}
+	return record
+}
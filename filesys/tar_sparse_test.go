@@ -0,0 +1,184 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/donyori/gogo/filesys"
+)
+
+// testTarSparseContent returns the contents of a sparse file for testing,
+// consisting of several data fragments separated by large holes.
+func testTarSparseContent() []byte {
+	content := make([]byte, 3<<20)
+	copy(content, "data at the beginning\n")
+	copy(content[1<<20+100:], "data in the middle\n")
+	copy(content[2<<20:], bytes.Repeat([]byte("block "), 200))
+	return content // ends with a hole
+}
+
+func TestWrite_TarWriteSparse(t *testing.T) {
+	content := testTarSparseContent()
+	testCases := []struct {
+		name    string
+		newFile func(t *testing.T) io.ReadSeeker
+	}{
+		{"bytes.Reader", func(t *testing.T) io.ReadSeeker {
+			return bytes.NewReader(content)
+		}},
+		{"os.File", func(t *testing.T) io.ReadSeeker {
+			name := filepath.Join(t.TempDir(), "sparse.dat")
+			f, err := os.Create(name)
+			if err != nil {
+				t.Fatal("create sparse file -", err)
+			}
+			t.Cleanup(func() {
+				_ = f.Close() // ignore error
+			})
+			err = f.Truncate(int64(len(content)))
+			if err != nil {
+				t.Fatal("truncate sparse file -", err)
+			}
+			for _, off := range []int64{0, 1<<20 + 100, 2 << 20} {
+				end := off + 1200
+				if _, err = f.WriteAt(content[off:end], off); err != nil {
+					t.Fatal("write sparse file -", err)
+				}
+			}
+			return f
+		}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			file := &WritableFileImpl{Name: "sparse.tar"}
+			w, err := filesys.Write(file, nil, true)
+			if err != nil {
+				t.Fatal("create -", err)
+			}
+			hdr := &tar.Header{
+				Typeflag: tar.TypeReg,
+				Name:     "dir/sparse.dat",
+				Mode:     0644,
+				ModTime:  time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC),
+			}
+			err = w.TarWriteSparse(hdr, tc.newFile(t))
+			if err == nil {
+				err = w.TarWriteHeader(&tar.Header{
+					Typeflag: tar.TypeReg,
+					Name:     "after.txt",
+					Mode:     0644,
+					Size:     6,
+				})
+			}
+			if err == nil {
+				_, err = w.WriteString("after\n")
+			}
+			if err != nil {
+				_ = w.Close() // ignore error
+				t.Fatal("write -", err)
+			}
+			if err = w.Close(); err != nil {
+				t.Fatal("close -", err)
+			}
+			if len(file.Data) > 64<<10 {
+				t.Errorf("got archive size %d; want at most %d",
+					len(file.Data), 64<<10)
+			}
+
+			r, err := filesys.ReadFromFS(
+				fstest.MapFS{file.Name: {Data: file.Data}}, file.Name, nil)
+			if err != nil {
+				t.Fatal("create reader -", err)
+			}
+			defer func(r filesys.Reader) {
+				if err := r.Close(); err != nil {
+					t.Error("close reader -", err)
+				}
+			}(r)
+			want := []struct {
+				name string
+				data []byte
+			}{
+				{hdr.Name, content},
+				{"after.txt", []byte("after\n")},
+			}
+			for i := 0; ; i++ {
+				h, err := r.TarNext()
+				if errors.Is(err, io.EOF) {
+					if i != len(want) {
+						t.Errorf("got %d entries; want %d", i, len(want))
+					}
+					break
+				} else if err != nil {
+					t.Fatal("TarNext -", err)
+				} else if i >= len(want) {
+					t.Fatalf("unexpected entry %q", h.Name)
+				}
+				data, err := io.ReadAll(r)
+				if err != nil {
+					t.Fatalf("read %q - %v", h.Name, err)
+				}
+				if h.Name != want[i].name || h.Size != int64(len(want[i].data)) ||
+					!bytes.Equal(data, want[i].data) {
+					t.Errorf("entry %d - got %q (size: %d, len: %d); want %q (size: %d)",
+						i, h.Name, h.Size, len(data), want[i].name, len(want[i].data))
+				}
+				if i == 0 && !h.ModTime.Equal(hdr.ModTime) {
+					t.Errorf("got ModTime %v; want %v", h.ModTime, hdr.ModTime)
+				}
+			}
+		})
+	}
+}
+
+func TestWrite_TarWriteSparse_NoHoles(t *testing.T) {
+	content := []byte("no holes in this file\n")
+	file := &WritableFileImpl{Name: "sparse.tar"}
+	w, err := filesys.Write(file, nil, true)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	hdr := &tar.Header{Typeflag: tar.TypeReg, Name: "a.txt", Mode: 0644}
+	err = w.TarWriteSparse(hdr, bytes.NewReader(content))
+	if err != nil {
+		_ = w.Close() // ignore error
+		t.Fatal("TarWriteSparse -", err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal("close -", err)
+	}
+	tr := tar.NewReader(bytes.NewReader(file.Data))
+	h, err := tr.Next()
+	if err != nil {
+		t.Fatal("next -", err)
+	} else if h.Name != hdr.Name || h.Size != int64(len(content)) ||
+		len(h.PAXRecords) != 0 {
+		t.Errorf("got header %+v; want a regular header of %q with size %d",
+			h, hdr.Name, len(content))
+	}
+}
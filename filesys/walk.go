@@ -0,0 +1,165 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys
+
+import (
+	"io/fs"
+	"iter"
+	"path"
+	"strings"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// WalkOptions are options for function Walk.
+//
+// A nil *WalkOptions is equivalent to a zero-value WalkOptions.
+type WalkOptions struct {
+	// Include is a list of patterns (in the syntax of path.Match)
+	// selecting the entries to be yielded.
+	//
+	// A pattern containing a slash is matched against
+	// the path of the entry relative to root
+	// (slash-separated, "." for root itself).
+	// Otherwise, it is matched against the base name of the entry.
+	//
+	// If Include is empty, all entries are selected.
+	// Directories not matching Include are still walked into.
+	Include []string
+
+	// Exclude is a list of patterns (in the same syntax as Include)
+	// selecting the entries not to be yielded.
+	//
+	// Exclude takes precedence over Include.
+	// Excluded directories are not walked into.
+	Exclude []string
+
+	// MaxDepth is the maximum depth to walk into.
+	// The depth of root is 0, and the depth of its direct children is 1.
+	//
+	// If MaxDepth is nonpositive, there is no limit on depth.
+	MaxDepth int
+}
+
+// Walk returns an iterator over the file tree rooted at root in fsys,
+// yielding the path and directory entry of each file or directory
+// (including root itself), filtered by options opts.
+//
+// The paths are in the same form as those passed to
+// the callback function of io/fs.WalkDir.
+// The entries are yielded in lexical order (in pre-order for directories),
+// so the output is deterministic.
+//
+// The iteration stops at the first error encountered
+// (e.g., an invalid pattern in opts, or an error reading a directory).
+// After the iteration ends, the error can be retrieved through
+// the returned pointer errPtr, which is never nil.
+// *errPtr is reset to nil at the start of each iteration.
+//
+// Walk panics if fsys is nil.
+func Walk(
+	fsys fs.FS,
+	root string,
+	opts *WalkOptions,
+) (seq iter.Seq2[string, fs.DirEntry], errPtr *error) {
+	if fsys == nil {
+		panic(errors.AutoMsg("fsys is nil"))
+	}
+	var o WalkOptions
+	if opts != nil {
+		o = *opts
+	}
+	errPtr = new(error)
+	seq = func(yield func(string, fs.DirEntry) bool) {
+		*errPtr = nil
+		for _, patterns := range [...][]string{o.Include, o.Exclude} {
+			for _, pattern := range patterns {
+				_, err := path.Match(pattern, "")
+				if err != nil {
+					*errPtr = errors.AutoWrap(err)
+					return
+				}
+			}
+		}
+		err := fs.WalkDir(fsys, root, func(
+			p string,
+			d fs.DirEntry,
+			err error,
+		) error {
+			if err != nil {
+				return err
+			}
+			rel := walkRelPath(root, p)
+			if walkMatchAny(o.Exclude, rel, d.Name()) {
+				if d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if (len(o.Include) == 0 ||
+				walkMatchAny(o.Include, rel, d.Name())) && !yield(p, d) {
+				return fs.SkipAll
+			}
+			if d.IsDir() && o.MaxDepth > 0 && walkDepth(rel) >= o.MaxDepth {
+				return fs.SkipDir
+			}
+			return nil
+		})
+		if err != nil {
+			*errPtr = errors.AutoWrap(err)
+		}
+	}
+	return
+}
+
+// walkRelPath returns the path of p relative to root,
+// where p is a path passed to the callback of io/fs.WalkDir.
+func walkRelPath(root, p string) string {
+	if p == root {
+		return "."
+	} else if root == "." {
+		return p
+	}
+	return strings.TrimPrefix(p[len(root):], "/")
+}
+
+// walkDepth returns the depth of the relative path rel.
+func walkDepth(rel string) int {
+	if rel == "." {
+		return 0
+	}
+	return strings.Count(rel, "/") + 1
+}
+
+// walkMatchAny reports whether any of patterns matches the entry
+// with the relative path rel and the base name name.
+//
+// The patterns must have been validated.
+func walkMatchAny(patterns []string, rel, name string) bool {
+	for _, pattern := range patterns {
+		s := name
+		if strings.Contains(pattern, "/") {
+			s = rel
+		}
+		if matched, _ := path.Match(pattern, s); matched {
+			return true
+		}
+	}
+	return false
+}
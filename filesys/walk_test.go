@@ -0,0 +1,154 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys_test
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/donyori/gogo/filesys"
+)
+
+var testWalkFS = fstest.MapFS{
+	"a.txt":               {Data: []byte("a")},
+	"b.go":                {Data: []byte("package b")},
+	"dir/c.txt":           {Data: []byte("c")},
+	"dir/d.go":            {Data: []byte("package d")},
+	"dir/sub/e.txt":       {Data: []byte("e")},
+	"vendor/f.go":         {Data: []byte("package f")},
+	"vendor/sub/g.txt":    {Data: []byte("g")},
+	"dir/sub/deep/h.json": {Data: []byte("{}")},
+}
+
+func TestWalk(t *testing.T) {
+	testCases := []struct {
+		root string
+		opts *filesys.WalkOptions
+		want []string
+	}{
+		{".", nil, []string{
+			".", "a.txt", "b.go", "dir", "dir/c.txt", "dir/d.go", "dir/sub",
+			"dir/sub/deep", "dir/sub/deep/h.json", "dir/sub/e.txt",
+			"vendor", "vendor/f.go", "vendor/sub", "vendor/sub/g.txt",
+		}},
+		{"dir", nil, []string{
+			"dir", "dir/c.txt", "dir/d.go", "dir/sub",
+			"dir/sub/deep", "dir/sub/deep/h.json", "dir/sub/e.txt",
+		}},
+		{".", &filesys.WalkOptions{Include: []string{"*.txt"}}, []string{
+			"a.txt", "dir/c.txt", "dir/sub/e.txt", "vendor/sub/g.txt",
+		}},
+		{".", &filesys.WalkOptions{
+			Include: []string{"*.txt", "*.go"},
+			Exclude: []string{"vendor"},
+		}, []string{
+			"a.txt", "b.go", "dir/c.txt", "dir/d.go", "dir/sub/e.txt",
+		}},
+		{".", &filesys.WalkOptions{Include: []string{"dir/*"}}, []string{
+			"dir/c.txt", "dir/d.go", "dir/sub",
+		}},
+		{"dir", &filesys.WalkOptions{Include: []string{"sub/*"}}, []string{
+			"dir/sub/deep", "dir/sub/e.txt",
+		}},
+		{".", &filesys.WalkOptions{MaxDepth: 1}, []string{
+			".", "a.txt", "b.go", "dir", "vendor",
+		}},
+		{"dir", &filesys.WalkOptions{
+			Exclude:  []string{"*.go"},
+			MaxDepth: 2,
+		}, []string{
+			"dir", "dir/c.txt", "dir/sub", "dir/sub/deep", "dir/sub/e.txt",
+		}},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("case %d?root=%+q", i, tc.root), func(t *testing.T) {
+			seq, errPtr := filesys.Walk(testWalkFS, tc.root, tc.opts)
+			var got []string
+			for p, d := range seq {
+				if d == nil {
+					t.Errorf("nil DirEntry for %q", p)
+				} else if d.Name() != path.Base(p) {
+					t.Errorf("got name %q for %q", d.Name(), p)
+				}
+				got = append(got, p)
+			}
+			if *errPtr != nil {
+				t.Error("walk -", *errPtr)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("got %q; want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWalk_Break(t *testing.T) {
+	seq, errPtr := filesys.Walk(testWalkFS, ".", nil)
+	var got []string
+	for p := range seq {
+		got = append(got, p)
+		if len(got) == 3 {
+			break
+		}
+	}
+	if *errPtr != nil {
+		t.Error("walk -", *errPtr)
+	}
+	if want := []string{".", "a.txt", "b.go"}; !slices.Equal(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestWalk_Error(t *testing.T) {
+	t.Run("bad pattern", func(t *testing.T) {
+		seq, errPtr := filesys.Walk(
+			testWalkFS,
+			".",
+			&filesys.WalkOptions{Include: []string{"["}},
+		)
+		for p := range seq {
+			t.Error("yielded", p)
+		}
+		if !errors.Is(*errPtr, path.ErrBadPattern) {
+			t.Errorf("got %v; want %v", *errPtr, path.ErrBadPattern)
+		}
+	})
+	t.Run("not exist", func(t *testing.T) {
+		seq, errPtr := filesys.Walk(testWalkFS, "nonexistent", nil)
+		for p := range seq {
+			t.Error("yielded", p)
+		}
+		if !errors.Is(*errPtr, fs.ErrNotExist) {
+			t.Errorf("got %v; want %v", *errPtr, fs.ErrNotExist)
+		}
+		// The iterator should be reusable and report the error again.
+		*errPtr = nil
+		for range seq {
+		}
+		if *errPtr == nil {
+			t.Error("got nil error on the second iteration")
+		}
+	})
+}
@@ -24,12 +24,18 @@ import (
 	"compress/flate"
 	"compress/gzip"
 	"fmt"
+	"hash"
 	"io"
 	"io/fs"
 	"maps"
 	"path"
+	"slices"
 	"strings"
+	"unicode/utf8"
 
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/donyori/gogo/encoding/hex"
 	"github.com/donyori/gogo/errors"
 	"github.com/donyori/gogo/inout"
 )
@@ -52,8 +58,9 @@ type WriteOptions struct {
 	// Nonpositive values for using default value.
 	BufSize int
 
-	// True if not to compress the file with gzip and not to archive the file
-	// with tar (i.e., tape archive) according to the file extension.
+	// True if not to compress the file with gzip or Zstandard (zstd)
+	// and not to archive the file with tar (i.e., tape archive)
+	// according to the file extension.
 	Raw bool
 
 	// The compression level of DEFLATE.
@@ -68,6 +75,76 @@ type WriteOptions struct {
 	// or ".zip" and the ZIP archive uses DEFLATE compression.
 	DeflateLv int
 
+	// The header of the gzip stream, including the fields
+	// Name, Comment, ModTime, Extra, and OS.
+	// Nil for using the default header (with all fields empty,
+	// except that OS is 255 (unknown)).
+	//
+	// The fields Name and Comment must be encoded in ISO 8859-1 (Latin-1),
+	// as required by the gzip format (RFC 1952).
+	// Otherwise, an error is reported when writing the file.
+	//
+	// This option only takes effect when Raw is false,
+	// and the file extension is ".gz" or ".tgz".
+	GzipHeader *gzip.Header
+
+	// The number of goroutines to compress the gzip stream in parallel.
+	//
+	// If it is greater than 1, the data is split into blocks of 1 MiB,
+	// which are compressed by up to ParallelCompression goroutines
+	// simultaneously (each block uses the end of the previous block
+	// as its preset dictionary).
+	// The output is still a single standard gzip stream,
+	// with a slightly lower compression ratio.
+	// If it is negative, runtime.GOMAXPROCS(0) goroutines are used.
+	// 0 and 1 for compressing in the current goroutine.
+	//
+	// This option only takes effect when Raw is false,
+	// and the file extension is ".gz" or ".tgz".
+	ParallelCompression int
+
+	// The compression level of Zstandard (zstd),
+	// in the same scale as the zstd command-line tool (i.e., 1 to 22).
+	// The level is mapped to the closest encoder level
+	// supported by package github.com/klauspost/compress/zstd.
+	// Nonpositive values for using default value (i.e., 3).
+	//
+	// This option only takes effect when Raw is false,
+	// and the file extension is ".zst" or ".tzst".
+	ZstdLv int
+
+	// The window size of Zstandard (zstd) compression, in bytes.
+	// If positive, it must be a power of two
+	// in the range [1024 (1 KiB), 536870912 (512 MiB)].
+	// Nonpositive values for using default value
+	// (depending on the compression level).
+	//
+	// A larger window may improve the compression ratio,
+	// but requires more memory for both compression and decompression.
+	//
+	// This option only takes effect when Raw is false,
+	// and the file extension is ".zst" or ".tzst".
+	ZstdWindowSize int
+
+	// True if to append new entries to the existing tar archive
+	// instead of writing a new archive.
+	//
+	// The writer looks for the end of the last entry in the archive,
+	// seeks there (overwriting the trailing zero blocks),
+	// and continues writing headers and bodies.
+	// The existing entries are neither read into memory nor rewritten.
+	//
+	// It requires the file to implement io.ReadSeeker,
+	// and only supports uncompressed tar archives
+	// (i.e., the file extension is ".tar").
+	// An empty file is treated as an empty archive.
+	// If the file also has a method Truncate(size int64) error
+	// (e.g., *os.File), the data after the last entry is truncated first.
+	//
+	// This option only takes effect when Raw is false,
+	// and the file is archived by tar.
+	TarAppend bool
+
 	// The offset of the beginning of the ZIP data within the underlying writer.
 	// It should be used when the ZIP data is appended to an existing file,
 	// such as a binary executable.
@@ -80,6 +157,43 @@ type WriteOptions struct {
 	// If the comment is too long, an error is reported.
 	ZipComment string
 
+	// The password for encrypting the entries of the ZIP archive.
+	//
+	// If ZipPassword is non-empty and ZipEncryption is ZipNoEncryption,
+	// ZipAES256 is used.
+	ZipPassword string
+
+	// The encryption method for the entries of the ZIP archive.
+	//
+	// If it is not ZipNoEncryption, ZipPassword must be non-empty.
+	//
+	// Encryption applies to the entries created by the methods
+	// ZipCreate and ZipCreateHeader of Writer (excluding directories),
+	// and is combined with the compression method of the entry,
+	// using the compressors registered through the option ZipComp
+	// (or the built-in ones for archive/zip.Store and archive/zip.Deflate).
+	// The entries created by ZipCreateRaw, ZipCopy, and ZipAddFS
+	// are written as is.
+	//
+	// The encrypted entries are written with data descriptors
+	// (i.e., bit 3 of the general purpose flag is set),
+	// as their sizes and CRC-32 checksums are unknown
+	// when writing their local file headers.
+	ZipEncryption ZipEncryption
+
+	// Functions that create new hash functions
+	// (e.g., crypto/sha256.New, crypto.SHA256.New)
+	// to calculate the checksums of the data written,
+	// which can be retrieved by the method Checksums of Writer.
+	// (Nil functions and functions returning nil are ignored,
+	// and the corresponding checksums are empty strings.)
+	//
+	// The checksums are calculated along with writing,
+	// without a second pass over the data.
+	// If TarAppend takes effect, only the data written by the writer
+	// (excluding the existing entries) is covered.
+	Hashes []func() hash.Hash
+
 	// A method-compressor map for writing the ZIP archive.
 	// These compressors are registered to the archive/zip.Writer.
 	// (Nil compressors are ignored.)
@@ -90,6 +204,81 @@ type WriteOptions struct {
 	// For more details, see the documentation of the method RegisterCompressor
 	// of archive/zip.Writer and the function archive/zip.RegisterCompressor.
 	ZipComp map[uint16]zip.Compressor
+
+	// A callback function to report the writing progress.
+	//
+	// It is called each time data is written to the file,
+	// with n being the number of bytes written to the file so far
+	// (the same as the method BytesWritten of Writer returns).
+	// total is always -1 as the final size is unknown during writing.
+	// Since the data is written to the file through buffers and compressors,
+	// it is called once per buffer flush rather than once per method call.
+	//
+	// Nil for no callback.
+	Progress func(n, total int64)
+
+	// The maximum number of bytes that can be written to the writer,
+	// counted before compression and archiving
+	// (i.e., the sum of the sizes of the data passed to the write methods,
+	// including the contents copied by the method TarCopy
+	// and the data fragments written by the method TarWriteSparse).
+	// The data written by the methods TarAddFS, TarAddFSWithOptions,
+	// ZipCopy, and ZipAddFS are not counted.
+	//
+	// The bytes beyond the limit are discarded,
+	// and the write method call that exceeds the limit
+	// (and every later one) reports ErrWriteLimitExceeded,
+	// after writing the bytes up to the limit.
+	// (To test whether the error is ErrWriteLimitExceeded,
+	// use function errors.Is.)
+	//
+	// It protects against decompression bombs when piping
+	// an extracted stream into the writer.
+	//
+	// Nonpositive values for no limit.
+	MaxBytes int64
+
+	// The mode of line-ending normalization
+	// for the data written to the writer.
+	//
+	// If it is not NewlineAsIs, the line endings ("\n" and "\r\n")
+	// in the data passed to the write methods
+	// (e.g., Write, WriteString, Println, and ReadFrom)
+	// are converted to "\n" (NewlineLF), "\r\n" (NewlineCRLF),
+	// or the native line ending of the current platform (NewlinePlatform)
+	// before compression and hashing.
+	// The option MaxBytes counts the data before the conversion.
+	// A lone "\r" (not followed by "\n") is written as is.
+	//
+	// It does not take effect when the file is archived by tar or ZIP
+	// (and is not opened in raw mode),
+	// as it would change the size of the archived files.
+	NewlineMode NewlineMode
+
+	// True if to commit the file contents to stable storage
+	// when closing the writer, after all data are flushed
+	// and before the file is closed (if closeFile is true),
+	// so that the data survive a system crash once Close returns.
+	//
+	// It only takes effect when the file has the method Sync
+	// with the signature "Sync() error", such as *os.File.
+	SyncOnClose bool
+
+	// The number of bytes written to the file after which
+	// the file contents are committed to stable storage.
+	// The file is synced each time the number of bytes written to
+	// the file since the last sync reaches SyncEvery.
+	//
+	// The bytes are counted after compression and archiving,
+	// and the data in the buffer and the compressors are not flushed.
+	// Use the method Sync of Writer to flush and sync
+	// at a specific point.
+	//
+	// It only takes effect when the file has the method Sync
+	// with the signature "Sync() error", such as *os.File.
+	//
+	// Nonpositive values for no periodic sync.
+	SyncEvery int64
 }
 
 // defaultWriteOptions are default options for Write functions.
@@ -124,6 +313,58 @@ type Writer interface {
 	// (To test whether the error is ErrNotTar, use function errors.Is.)
 	TarWriteHeader(hdr *tar.Header) error
 
+	// TarCopy writes hdr and copies the content of the file from r
+	// into the tape archive, bypassing the buffer of the writer.
+	//
+	// It reads exactly hdr.Size bytes from r.
+	// If r provides fewer bytes, it reports an error
+	// that satisfies errors.Is(err, io.ErrUnexpectedEOF) is true.
+	// r is not used (and can be nil) if hdr.Size is nonpositive
+	// or hdr represents a directory.
+	//
+	// To copy an entry from another tape archive,
+	// use the header returned by the method TarNext of a Reader
+	// and that Reader as r, for example:
+	//
+	//	hdr, err := src.TarNext()
+	//	// Handle err and filter entries here.
+	//	err = dst.TarCopy(hdr, src)
+	//
+	// Since tar does not compress each entry individually,
+	// the content of the file is copied as is.
+	//
+	// If the file is not archived by tar or is opened in raw mode,
+	// it does nothing and reports ErrNotTar.
+	// (To test whether the error is ErrNotTar, use function errors.Is.)
+	TarCopy(hdr *tar.Header, r io.Reader) error
+
+	// TarWriteSparse writes hdr and the content of the file from r
+	// into the tape archive as a sparse file,
+	// so that the holes (regions of zeros) in the file
+	// do not take space in the archive.
+	//
+	// hdr must be the header of a regular file.
+	// hdr.Size is ignored; the size of the file is determined by r.
+	//
+	// If r has a method Fd (such as *os.File) and
+	// the operating system supports SEEK_DATA and SEEK_HOLE,
+	// the holes are detected through them.
+	// Otherwise, the file is scanned for blocks of zeros
+	// (each of 512 bytes), which requires reading the file twice.
+	// If there are no holes, the file is written as a regular file.
+	//
+	// The sparse file is written in the GNU sparse format 1.0 (using PAX),
+	// which can be read by GNU tar, bsdtar, and archive/tar.Reader
+	// (and therefore the method TarNext of Reader).
+	// The header (except for its name and size)
+	// must be encodable in the USTAR or GNU format,
+	// while its PAX records are kept in the PAX header.
+	//
+	// If the file is not archived by tar or is opened in raw mode,
+	// it does nothing and reports ErrNotTar.
+	// (To test whether the error is ErrNotTar, use function errors.Is.)
+	TarWriteSparse(hdr *tar.Header, r io.ReadSeeker) error
+
 	// TarAddFS adds the files from the specified filesystem
 	// to the tape archive.
 	// It walks the directory tree starting at the root of the filesystem
@@ -135,6 +376,21 @@ type Writer interface {
 	// (To test whether the error is ErrNotTar, use function errors.Is.)
 	TarAddFS(fsys fs.FS) error
 
+	// TarAddFSWithOptions is like TarAddFS,
+	// but can add the duplicate regular files as hard-link entries
+	// according to the options opts (see TarAddFSOptions for details),
+	// which can shrink the archive significantly.
+	//
+	// The hard-link entries refer to the files added earlier
+	// in the same call.
+	// If opts are nil or enable no deduplication,
+	// it is equivalent to TarAddFS.
+	//
+	// If the file is not archived by tar or is opened in raw mode,
+	// it does nothing and reports ErrNotTar.
+	// (To test whether the error is ErrNotTar, use function errors.Is.)
+	TarAddFSWithOptions(fsys fs.FS, opts *TarAddFSOptions) error
+
 	// ZipEnabled returns true if the file is archived by ZIP
 	// and is not opened in raw mode.
 	ZipEnabled() bool
@@ -198,6 +454,61 @@ type Writer interface {
 	// (To test whether the error is ErrNotZip, use function errors.Is.)
 	ZipAddFS(fsys fs.FS) error
 
+	// Checksums returns the checksums of the data written,
+	// calculated by the hash functions specified by the option Hashes,
+	// in hexadecimal representation.
+	//
+	// upper indicates whether to use uppercase in hexadecimal representation.
+	//
+	// compressed are the checksums of the data written to the file,
+	// after compression and archiving.
+	// uncompressed are the checksums of the data before compression
+	// by gzip or Zstandard (zstd); for example, the checksums of
+	// the tar archive inside a ".tar.gz" file.
+	// If the file is not compressed by gzip or Zstandard
+	// (including the case that the writer is in raw mode),
+	// uncompressed are the same as compressed.
+	//
+	// The length of both uncompressed and compressed is
+	// the same as that of the option Hashes.
+	// The hash result of Hashes[i] is uncompressed[i] and compressed[i].
+	// In particular, if Hashes[i] is nil or returns nil,
+	// uncompressed[i] and compressed[i] are empty strings.
+	// If the option Hashes is empty, uncompressed and compressed are nil.
+	//
+	// The checksums are complete only after the writer is closed,
+	// because the buffered and compressed data is not written to the file
+	// until then.
+	Checksums(upper bool) (uncompressed, compressed []string)
+
+	// BytesWritten returns the number of bytes written to the file so far.
+	//
+	// For compressed files and archives, it counts the bytes
+	// written to the file rather than the bytes before compression.
+	// The data buffered but not yet written to the file is not counted.
+	BytesWritten() int64
+
+	// Sync flushes the buffered data and the pending compressed data
+	// (of gzip and Zstandard, but not the parallel gzip compressor)
+	// to the file, and then commits the file contents to stable storage
+	// if the file has the method Sync with the signature "Sync() error"
+	// (such as *os.File).
+	//
+	// Flushing the compressors frequently may degrade the compression ratio.
+	// For tar and ZIP archives, the archive is not finalized,
+	// so the file may not be a valid archive until the writer is closed.
+	//
+	// If the writer is closed, it does nothing and
+	// reports ErrFileWriterClosed.
+	// (To test whether the error is ErrFileWriterClosed,
+	// use function errors.Is.)
+	Sync() error
+
+	// Stats returns the statistics of the data written so far,
+	// including the raw (compressed) bytes, the logical (uncompressed) bytes,
+	// and the elapsed time (see Stats for details).
+	Stats() Stats
+
 	// Options returns a copy of options used by this writer.
 	Options() *WriteOptions
 
@@ -217,6 +528,21 @@ type writer struct {
 	f    WritableFile
 	tw   *tar.Writer
 	zw   *zip.Writer
+
+	tarUW io.Writer // the writer underlying tw, or nil
+
+	zipEnc *zipEncryptedEntry // the pending encrypted ZIP entry, or nil
+
+	flushers []flusher // the compressors that can be flushed, from inner to outer
+
+	compressed bool        // true if the file is compressed by gzip or zstd
+	preHs      []hash.Hash // hash functions for data before compression, or nil if not compressed
+	postHs     []hash.Hash // hash functions for data written to the file
+
+	pc progressCounter // the counter for the bytes written to the file
+	ln int64           // the number of bytes counted for the option MaxBytes
+	cw countWriter     // the writer underlying the buffer, counting the logical bytes
+	sw stopwatch
 }
 
 // Write creates a writer on the specified file with options opts.
@@ -229,9 +555,22 @@ type writer struct {
 //   - BufSize: 0
 //   - Raw: false
 //   - DeflateLv: compress/flate.BestCompression
+//   - GzipHeader: nil
+//   - ParallelCompression: 0
+//   - ZstdLv: 0
+//   - ZstdWindowSize: 0
+//   - TarAppend: false
 //   - ZipOffset: 0
 //   - ZipComment: ""
+//   - ZipPassword: ""
+//   - ZipEncryption: ZipNoEncryption
+//   - Hashes: nil
 //   - ZipComp: nil
+//   - Progress: nil
+//   - MaxBytes: 0
+//   - NewlineMode: NewlineAsIs
+//   - SyncOnClose: false
+//   - SyncEvery: 0
 //
 // To ensure that this function and the returned writer can work as expected,
 // the specified file must not be operated by anyone else
@@ -275,6 +614,22 @@ func Write(file WritableFile, opts *WriteOptions, closeFile bool) (
 			maxUint16,
 		))
 	}
+	if opts.ZipEncryption > maxZipEncryption {
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"option ZipEncryption (%v) is unknown",
+			opts.ZipEncryption,
+		))
+	} else if opts.ZipEncryption != ZipNoEncryption && opts.ZipPassword == "" {
+		return nil, errors.AutoNew(
+			"option ZipPassword is empty but option ZipEncryption is " +
+				opts.ZipEncryption.String())
+	}
+	if opts.NewlineMode > maxNewlineMode {
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"option NewlineMode (%v) is unknown",
+			opts.NewlineMode,
+		))
+	}
 
 	el := errors.NewErrorList(true)
 	defer func() {
@@ -283,10 +638,16 @@ func Write(file WritableFile, opts *WriteOptions, closeFile bool) (
 		}
 	}()
 
-	closers := make([]io.Closer, 0, 3)
+	closers := make([]io.Closer, 0, 4)
 	if closeFile {
 		closers = append(closers, file)
 	}
+	if s, ok := file.(fileSyncer); ok && opts.SyncOnClose {
+		// The closers are closed in reverse order,
+		// so the file is synced after closing the compressors
+		// and archivers, and before closing the file.
+		closers = append(closers, &syncCloser{s: s})
+	}
 	defer func() {
 		if el.Erroneous() {
 			for i := len(closers) - 1; i >= 0; i-- {
@@ -298,14 +659,28 @@ func Write(file WritableFile, opts *WriteOptions, closeFile bool) (
 	fw := &writer{
 		uw: file,
 		opts: WriteOptions{
-			BufSize:    opts.BufSize,
-			Raw:        opts.Raw,
-			DeflateLv:  opts.DeflateLv,
-			ZipOffset:  opts.ZipOffset,
-			ZipComment: opts.ZipComment,
-			ZipComp:    maps.Clone(opts.ZipComp),
+			BufSize:             opts.BufSize,
+			Raw:                 opts.Raw,
+			DeflateLv:           opts.DeflateLv,
+			GzipHeader:          cloneGzipHeader(opts.GzipHeader),
+			ParallelCompression: opts.ParallelCompression,
+			ZstdLv:              opts.ZstdLv,
+			ZstdWindowSize:      opts.ZstdWindowSize,
+			TarAppend:           opts.TarAppend,
+			ZipOffset:           opts.ZipOffset,
+			ZipComment:          opts.ZipComment,
+			ZipPassword:         opts.ZipPassword,
+			ZipEncryption:       opts.ZipEncryption,
+			Hashes:              slices.Clone(opts.Hashes),
+			ZipComp:             maps.Clone(opts.ZipComp),
+			Progress:            opts.Progress,
+			MaxBytes:            opts.MaxBytes,
+			NewlineMode:         opts.NewlineMode,
+			SyncOnClose:         opts.SyncOnClose,
+			SyncEvery:           opts.SyncEvery,
 		},
-		f: file,
+		f:  file,
+		sw: startStopwatch(),
 	}
 	maps.DeleteFunc(
 		fw.opts.ZipComp,
@@ -322,10 +697,21 @@ func Write(file WritableFile, opts *WriteOptions, closeFile bool) (
 //
 // It may update closers.
 func (fw *writer) init(info fs.FileInfo, pClosers *[]io.Closer) error {
+	if s, ok := fw.f.(fileSyncer); ok && fw.opts.SyncEvery > 0 {
+		fw.uw = &syncEveryWriter{w: fw.uw, s: s, every: fw.opts.SyncEvery}
+	}
+	fw.pc.total, fw.pc.fn = -1, fw.opts.Progress
+	fw.uw = &progressWriter{progressCounter: &fw.pc, w: fw.uw}
+	fw.postHs = fw.hashWrap()
 	err := fw.initRaw(info, pClosers)
 	if err != nil {
 		return err
 	}
+	if fw.opts.NewlineMode != NewlineAsIs && fw.tw == nil && fw.zw == nil {
+		nw := &newlineWriter{w: fw.uw, crlf: fw.opts.NewlineMode.crlf()}
+		*pClosers = append(*pClosers, nw)
+		fw.uw = nw
+	}
 	fw.initCloserAndBuffer(*pClosers)
 	return nil
 }
@@ -347,21 +733,73 @@ func (fw *writer) initRaw(info fs.FileInfo, pClosers *[]io.Closer) error {
 		case ".tgz":
 			name = name[:len(name)-len(ext)] + ".tar.gz"
 			ext = ""
+		case ".tzst":
+			name = name[:len(name)-len(ext)] + ".tar.zst"
+			ext = ""
 		case ".gz":
+			if fw.opts.ParallelCompression > 1 ||
+				fw.opts.ParallelCompression < 0 {
+				pw, err := newParallelGzipWriter(
+					fw.uw,
+					fw.opts.DeflateLv,
+					fw.opts.GzipHeader,
+					fw.opts.ParallelCompression,
+				)
+				if err != nil {
+					return err
+				}
+				*pClosers = append(*pClosers, pw)
+				fw.uw, fw.compressed = pw, true
+				break
+			}
 			gw, err := gzip.NewWriterLevel(fw.uw, fw.opts.DeflateLv)
 			if err != nil {
 				return err
 			}
+			if fw.opts.GzipHeader != nil {
+				gw.Header = *fw.opts.GzipHeader
+			}
 			*pClosers = append(*pClosers, gw)
-			fw.uw = gw
+			fw.flushers = append(fw.flushers, gw)
+			fw.uw, fw.compressed = gw, true
+		case ".zst":
+			eOpts := make([]zstd.EOption, 1, 2)
+			eOpts[0] = zstd.WithEncoderLevel(zstd.SpeedDefault)
+			if fw.opts.ZstdLv > 0 {
+				eOpts[0] = zstd.WithEncoderLevel(
+					zstd.EncoderLevelFromZstd(fw.opts.ZstdLv))
+			}
+			if fw.opts.ZstdWindowSize > 0 {
+				eOpts = append(
+					eOpts, zstd.WithWindowSize(fw.opts.ZstdWindowSize))
+			}
+			zw, err := zstd.NewWriter(fw.uw, eOpts...)
+			if err != nil {
+				return err
+			}
+			*pClosers = append(*pClosers, zw)
+			fw.flushers = append(fw.flushers, zw)
+			fw.uw, fw.compressed = zw, true
 		case ".tar":
-			fw.tw = tar.NewWriter(fw.uw)
+			if fw.opts.TarAppend {
+				err := fw.tarSeekEnd()
+				if err != nil {
+					return err
+				}
+			}
+			fw.initPreHashes()
+			fw.tw, fw.tarUW = tar.NewWriter(fw.uw), fw.uw
 			*pClosers = append(*pClosers, fw.tw)
 			fw.uw = fw.tw
 			loop = false
 		case ".zip":
+			fw.initPreHashes()
 			fw.zw = zip.NewWriter(fw.uw)
-			*pClosers = append(*pClosers, fw.zw)
+			if fw.zipEncryption() != ZipNoEncryption {
+				*pClosers = append(*pClosers, &zipWriterCloser{fw: fw})
+			} else {
+				*pClosers = append(*pClosers, fw.zw)
+			}
 			if fw.opts.ZipOffset > 0 {
 				fw.zw.SetOffset(fw.opts.ZipOffset)
 			}
@@ -390,12 +828,166 @@ func (fw *writer) initRaw(info fs.FileInfo, pClosers *[]io.Closer) error {
 			fw.err = ErrZipWriteBeforeCreate
 			loop = false
 		default:
+			fw.initPreHashes()
 			loop = false
 		}
 	}
 	return nil
 }
 
+// hashWrap creates the hash functions specified by the option Hashes
+// and makes fw.uw also write to them.
+//
+// It returns the hash functions, with nil for the ignored ones,
+// or nil if no hash function is specified.
+func (fw *writer) hashWrap() []hash.Hash {
+	if len(fw.opts.Hashes) == 0 {
+		return nil
+	}
+	hs := make([]hash.Hash, len(fw.opts.Hashes))
+	ws := make([]io.Writer, 1, len(fw.opts.Hashes)+1)
+	ws[0] = fw.uw
+	for i, newHash := range fw.opts.Hashes {
+		if newHash != nil {
+			hs[i] = newHash()
+			if hs[i] != nil {
+				ws = append(ws, hs[i])
+			}
+		}
+	}
+	if len(ws) > 1 {
+		fw.uw = io.MultiWriter(ws...)
+	}
+	return hs
+}
+
+// initPreHashes sets fw.preHs if the file is compressed.
+//
+// It must be called after setting up all compressors
+// and before setting up the archive writer.
+func (fw *writer) initPreHashes() {
+	if fw.compressed {
+		fw.preHs = fw.hashWrap()
+	}
+}
+
+// tarSeekEnd seeks the file to the end of the last entry
+// in the existing tar archive, for the option TarAppend.
+func (fw *writer) tarSeekEnd() error {
+	if fw.compressed {
+		return errors.New("option TarAppend does not support compressed tar archives")
+	}
+	rs, ok := fw.f.(io.ReadSeeker)
+	if !ok {
+		return errors.New("option TarAppend requires the file to implement io.ReadSeeker")
+	}
+	_, err := rs.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	// Pass rs to the tar reader directly,
+	// so that it skips the entry bodies by seeking instead of reading.
+	tr := tar.NewReader(rs)
+	var end int64
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+		var size int64
+		if tarIsSparse(hdr) {
+			// The size of the data of a sparse file in the archive
+			// is unknown until the data is read.
+			_, err = io.Copy(io.Discard, tr)
+			if err != nil {
+				return err
+			}
+		} else if !tarIsHeaderOnly(hdr.Typeflag) {
+			size = hdr.Size
+		}
+		pos, err := rs.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		// The entry ends at the end of its last block.
+		end = (pos + size + tarBlockSize - 1) / tarBlockSize * tarBlockSize
+	}
+	if t, ok := fw.f.(interface{ Truncate(size int64) error }); ok {
+		err = t.Truncate(end)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = rs.Seek(end, io.SeekStart)
+	return err
+}
+
+// tarIsSparse reports whether hdr is the header of a sparse file,
+// whose field Size is not the size of its data in the archive.
+func tarIsSparse(hdr *tar.Header) bool {
+	if hdr.Typeflag == tar.TypeGNUSparse {
+		return true
+	}
+	for k := range hdr.PAXRecords {
+		if strings.HasPrefix(k, "GNU.sparse.") {
+			return true
+		}
+	}
+	return false
+}
+
+// tarIsHeaderOnly reports whether the tar entries of the type flag
+// have no data in the archive, regardless of their field Size.
+func tarIsHeaderOnly(flag byte) bool {
+	switch flag {
+	case tar.TypeLink, tar.TypeSymlink, tar.TypeChar,
+		tar.TypeBlock, tar.TypeDir, tar.TypeFifo:
+		return true
+	}
+	return false
+}
+
+// countReader is an io.Reader that counts the bytes read.
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countReader) Read(p []byte) (n int, err error) {
+	n, err = cr.r.Read(p)
+	cr.n += int64(n)
+	return
+}
+
+// countWriter is an io.Writer that counts the bytes written.
+//
+// Its underlying writer w can be replaced without resetting the count.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countWriter) Write(p []byte) (n int, err error) {
+	n, err = cw.w.Write(p)
+	cw.n += int64(n)
+	return
+}
+
+// ReadFrom uses the method ReadFrom of the underlying writer if any,
+// so as to keep the special behavior of the underlying writer
+// (e.g., that of errorWriter).
+func (cw *countWriter) ReadFrom(r io.Reader) (n int64, err error) {
+	if rf, ok := cw.w.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(r)
+	} else {
+		n, err = io.Copy(struct{ io.Writer }{cw.w}, r)
+	}
+	cw.n += n
+	return
+}
+
 // initCloserAndBuffer sets fw.c and creates a buffer.
 func (fw *writer) initCloserAndBuffer(closers []io.Closer) {
 	switch len(closers) {
@@ -406,7 +998,85 @@ func (fw *writer) initCloserAndBuffer(closers []io.Closer) {
 	default:
 		fw.c = inout.NewMultiCloser(true, true, closers...)
 	}
-	fw.bw = inout.NewBufferedWriterSize(fw.uw, fw.opts.BufSize)
+	fw.cw.w = fw.uw
+	fw.bw = inout.NewBufferedWriterSize(&fw.cw, fw.opts.BufSize)
+}
+
+// resetBuffer discards the buffered data and
+// resets the buffer to write to fw.uw.
+func (fw *writer) resetBuffer() {
+	fw.cw.w = fw.uw
+	fw.bw.Reset(&fw.cw)
+}
+
+// limited returns w wrapped to enforce the option MaxBytes,
+// sharing the count with the write methods.
+//
+// It returns w itself if MaxBytes is nonpositive.
+func (fw *writer) limited(w io.Writer) io.Writer {
+	if fw.opts.MaxBytes <= 0 {
+		return w
+	}
+	return &limitWriter{w: w, limit: fw.opts.MaxBytes, pn: &fw.ln}
+}
+
+// limitRemain returns the number of bytes that can still be passed to
+// the write methods under the option MaxBytes,
+// or -1 if MaxBytes is nonpositive.
+func (fw *writer) limitRemain() int64 {
+	if fw.opts.MaxBytes <= 0 {
+		return -1
+	}
+	return max(fw.opts.MaxBytes-fw.ln, 0)
+}
+
+// writeLimited writes s through the function write,
+// enforcing the option MaxBytes.
+//
+// If s exceeds the limit, it writes the bytes up to the limit
+// and reports ErrWriteLimitExceeded.
+func writeLimited[S []byte | string](
+	fw *writer,
+	s S,
+	write func(s S) (int, error),
+) (n int, err error) {
+	var exceeded bool
+	if remain := fw.limitRemain(); remain >= 0 && int64(len(s)) > remain {
+		s, exceeded = s[:remain], true
+	}
+	if len(s) > 0 {
+		n, err = write(s)
+		fw.ln += int64(n)
+	}
+	if err == nil && exceeded {
+		err = ErrWriteLimitExceeded
+	}
+	return
+}
+
+// limitWriter wraps an io.Writer to limit the number of bytes written.
+//
+// It reports ErrWriteLimitExceeded when the limit is exceeded.
+type limitWriter struct {
+	w     io.Writer
+	limit int64
+	pn    *int64 // the pointer to the shared number of bytes written
+}
+
+func (lw *limitWriter) Write(p []byte) (n int, err error) {
+	if remain := lw.limit - *lw.pn; int64(len(p)) > remain {
+		if remain > 0 {
+			n, err = lw.w.Write(p[:remain])
+			*lw.pn += int64(n)
+			if err != nil {
+				return
+			}
+		}
+		return n, ErrWriteLimitExceeded
+	}
+	n, err = lw.w.Write(p)
+	*lw.pn += int64(n)
+	return
 }
 
 func (fw *writer) Close() error {
@@ -416,8 +1086,9 @@ func (fw *writer) Close() error {
 	flushErr := fw.bw.Flush()
 	closeErr := fw.c.Close()
 	if fw.c.Closed() {
+		fw.sw.stop()
 		fw.uw, fw.err = closedErrorWriter, ErrFileWriterClosed
-		fw.bw.Reset(fw.uw)
+		fw.resetBuffer()
 	}
 	return errors.AutoWrap(errors.Combine(flushErr, closeErr))
 }
@@ -430,7 +1101,7 @@ func (fw *writer) Write(p []byte) (n int, err error) {
 	if fw.err != nil {
 		return 0, errors.AutoWrap(fw.err)
 	}
-	n, err = fw.bw.Write(p)
+	n, err = writeLimited(fw, p, fw.bw.Write)
 	return n, errors.AutoWrap(err)
 }
 
@@ -446,7 +1117,14 @@ func (fw *writer) WriteByte(c byte) error {
 	if fw.err != nil {
 		return errors.AutoWrap(fw.err)
 	}
-	return errors.AutoWrap(fw.bw.WriteByte(c))
+	if fw.limitRemain() == 0 {
+		return errors.AutoWrap(ErrWriteLimitExceeded)
+	}
+	err := fw.bw.WriteByte(c)
+	if err == nil {
+		fw.ln++
+	}
+	return errors.AutoWrap(err)
 }
 
 func (fw *writer) MustWriteByte(c byte) {
@@ -460,7 +1138,11 @@ func (fw *writer) WriteRune(r rune) (size int, err error) {
 	if fw.err != nil {
 		return 0, errors.AutoWrap(fw.err)
 	}
-	size, err = fw.bw.WriteRune(r)
+	if fw.limitRemain() >= 0 {
+		size, err = writeLimited(fw, utf8.AppendRune(nil, r), fw.bw.Write)
+	} else {
+		size, err = fw.bw.WriteRune(r)
+	}
 	return size, errors.AutoWrap(err)
 }
 
@@ -476,7 +1158,7 @@ func (fw *writer) WriteString(s string) (n int, err error) {
 	if fw.err != nil {
 		return 0, errors.AutoWrap(fw.err)
 	}
-	n, err = fw.bw.WriteString(s)
+	n, err = writeLimited(fw, s, fw.bw.WriteString)
 	return n, errors.AutoWrap(err)
 }
 
@@ -492,7 +1174,23 @@ func (fw *writer) ReadFrom(r io.Reader) (n int64, err error) {
 	if fw.err != nil {
 		return 0, errors.AutoWrap(fw.err)
 	}
-	n, err = fw.bw.ReadFrom(r)
+	remain := fw.limitRemain()
+	if remain < 0 {
+		n, err = fw.bw.ReadFrom(r)
+		return n, errors.AutoWrap(err)
+	}
+	n, err = fw.bw.ReadFrom(io.LimitReader(r, remain))
+	fw.ln += n
+	if err == nil && n == remain {
+		// Check whether r has more data beyond the limit.
+		var b [1]byte
+		k, readErr := io.ReadFull(r, b[:])
+		if k > 0 {
+			err = ErrWriteLimitExceeded
+		} else if !errors.Is(readErr, io.EOF) {
+			err = readErr
+		}
+	}
 	return n, errors.AutoWrap(err)
 }
 
@@ -500,7 +1198,11 @@ func (fw *writer) Printf(format string, args ...any) (n int, err error) {
 	if fw.err != nil {
 		return 0, errors.AutoWrap(fw.err)
 	}
-	n, err = fw.bw.Printf(format, args...)
+	if fw.limitRemain() >= 0 {
+		n, err = writeLimited(fw, fmt.Appendf(nil, format, args...), fw.bw.Write)
+	} else {
+		n, err = fw.bw.Printf(format, args...)
+	}
 	return n, errors.AutoWrap(err)
 }
 
@@ -516,7 +1218,11 @@ func (fw *writer) Print(args ...any) (n int, err error) {
 	if fw.err != nil {
 		return 0, errors.AutoWrap(fw.err)
 	}
-	n, err = fw.bw.Print(args...)
+	if fw.limitRemain() >= 0 {
+		n, err = writeLimited(fw, fmt.Append(nil, args...), fw.bw.Write)
+	} else {
+		n, err = fw.bw.Print(args...)
+	}
 	return n, errors.AutoWrap(err)
 }
 
@@ -532,7 +1238,11 @@ func (fw *writer) Println(args ...any) (n int, err error) {
 	if fw.err != nil {
 		return 0, errors.AutoWrap(fw.err)
 	}
-	n, err = fw.bw.Println(args...)
+	if fw.limitRemain() >= 0 {
+		n, err = writeLimited(fw, fmt.Appendln(nil, args...), fw.bw.Write)
+	} else {
+		n, err = fw.bw.Println(args...)
+	}
 	return n, errors.AutoWrap(err)
 }
 
@@ -585,10 +1295,27 @@ func (fw *writer) TarWriteHeader(hdr *tar.Header) error {
 	default:
 		fw.uw, fw.err = fw.tw, nil
 	}
-	fw.bw.Reset(fw.uw)
+	fw.resetBuffer()
 	return nil
 }
 
+func (fw *writer) TarCopy(hdr *tar.Header, r io.Reader) error {
+	err := fw.TarWriteHeader(hdr)
+	if err != nil {
+		return errors.AutoWrap(err)
+	} else if hdr.Size <= 0 || tarHeaderIsDir(hdr) {
+		return nil
+	} else if r == nil {
+		return errors.AutoNew("r is nil but hdr.Size is positive")
+	}
+	n, err := io.CopyN(fw.limited(fw.tw), r, hdr.Size)
+	if errors.Is(err, io.EOF) {
+		err = fmt.Errorf("%w; copied %d of %d bytes",
+			io.ErrUnexpectedEOF, n, hdr.Size)
+	}
+	return errors.AutoWrap(err)
+}
+
 func (fw *writer) TarAddFS(fsys fs.FS) error {
 	err := fw.tarCheckAndFlush()
 	if err != nil {
@@ -608,6 +1335,13 @@ func (fw *writer) ZipCreate(name string) error {
 		nil,
 		name,
 		func() (io.Writer, error) {
+			if fw.zipEncryption() != ZipNoEncryption &&
+				!strings.HasSuffix(name, "/") {
+				return fw.zipCreateEncrypted(&zip.FileHeader{
+					Name:   name,
+					Method: zip.Deflate,
+				})
+			}
 			return fw.zw.Create(name)
 		},
 	))
@@ -618,6 +1352,10 @@ func (fw *writer) ZipCreateHeader(fh *zip.FileHeader) error {
 		fh,
 		"",
 		func() (io.Writer, error) {
+			if fw.zipEncryption() != ZipNoEncryption &&
+				!strings.HasSuffix(fh.Name, "/") {
+				return fw.zipCreateEncrypted(fh)
+			}
 			return fw.zw.CreateHeader(fh)
 		},
 	))
@@ -639,7 +1377,7 @@ func (fw *writer) ZipCopy(f *zip.File) error {
 		return errors.AutoWrap(err)
 	}
 	fw.uw, fw.err = zipWriteBeforeCreateErrorWriter, ErrZipWriteBeforeCreate
-	fw.bw.Reset(fw.uw)
+	fw.resetBuffer()
 	return errors.AutoWrap(fw.zw.Copy(f))
 }
 
@@ -655,18 +1393,76 @@ func (fw *writer) ZipAddFS(fsys fs.FS) error {
 		return errors.AutoWrap(err)
 	}
 	fw.uw, fw.err = zipWriteBeforeCreateErrorWriter, ErrZipWriteBeforeCreate
-	fw.bw.Reset(fw.uw)
+	fw.resetBuffer()
+	return nil
+}
+
+func (fw *writer) Checksums(upper bool) (uncompressed, compressed []string) {
+	if len(fw.postHs) == 0 {
+		return
+	}
+	compressed = hashesToHex(fw.postHs, upper)
+	if fw.preHs != nil {
+		uncompressed = hashesToHex(fw.preHs, upper)
+	} else {
+		uncompressed = slices.Clone(compressed)
+	}
+	return
+}
+
+func (fw *writer) BytesWritten() int64 {
+	return fw.pc.n.Load()
+}
+
+func (fw *writer) Stats() Stats {
+	return Stats{
+		RawBytes:     fw.pc.n.Load(),
+		LogicalBytes: fw.cw.n + int64(fw.bw.Buffered()),
+		Elapsed:      fw.sw.get(),
+	}
+}
+
+func (fw *writer) Sync() error {
+	if fw.c.Closed() {
+		return errors.AutoWrap(ErrFileWriterClosed)
+	}
+	err := fw.Flush()
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	for i := len(fw.flushers) - 1; i >= 0; i-- {
+		err = fw.flushers[i].Flush()
+		if err != nil {
+			return errors.AutoWrap(err)
+		}
+	}
+	if s, ok := fw.f.(fileSyncer); ok {
+		return errors.AutoWrap(s.Sync())
+	}
 	return nil
 }
 
 func (fw *writer) Options() *WriteOptions {
 	opts := &WriteOptions{
-		BufSize:    fw.opts.BufSize,
-		Raw:        fw.opts.Raw,
-		DeflateLv:  fw.opts.DeflateLv,
-		ZipOffset:  fw.opts.ZipOffset,
-		ZipComment: fw.opts.ZipComment,
-		ZipComp:    maps.Clone(fw.opts.ZipComp),
+		BufSize:             fw.opts.BufSize,
+		Raw:                 fw.opts.Raw,
+		DeflateLv:           fw.opts.DeflateLv,
+		GzipHeader:          cloneGzipHeader(fw.opts.GzipHeader),
+		ParallelCompression: fw.opts.ParallelCompression,
+		ZstdLv:              fw.opts.ZstdLv,
+		ZstdWindowSize:      fw.opts.ZstdWindowSize,
+		TarAppend:           fw.opts.TarAppend,
+		ZipOffset:           fw.opts.ZipOffset,
+		ZipComment:          fw.opts.ZipComment,
+		ZipPassword:         fw.opts.ZipPassword,
+		ZipEncryption:       fw.opts.ZipEncryption,
+		Hashes:              slices.Clone(fw.opts.Hashes),
+		ZipComp:             maps.Clone(fw.opts.ZipComp),
+		Progress:            fw.opts.Progress,
+		MaxBytes:            fw.opts.MaxBytes,
+		NewlineMode:         fw.opts.NewlineMode,
+		SyncOnClose:         fw.opts.SyncOnClose,
+		SyncEvery:           fw.opts.SyncEvery,
 	}
 	return opts
 }
@@ -675,6 +1471,31 @@ func (fw *writer) FileStat() (info fs.FileInfo, err error) {
 	return fw.f.Stat()
 }
 
+// cloneGzipHeader returns a deep copy of hdr.
+//
+// It returns nil if hdr is nil.
+func cloneGzipHeader(hdr *gzip.Header) *gzip.Header {
+	if hdr == nil {
+		return nil
+	}
+	c := *hdr
+	c.Extra = slices.Clone(hdr.Extra)
+	return &c
+}
+
+// hashesToHex returns the hash results of hs in hexadecimal representation.
+//
+// For nil hash functions in hs, the results are empty strings.
+func hashesToHex(hs []hash.Hash, upper bool) []string {
+	checksums := make([]string, len(hs))
+	for i := range hs {
+		if hs[i] != nil {
+			checksums[i] = hex.EncodeToString(hs[i].Sum(nil), upper)
+		}
+	}
+	return checksums
+}
+
 // tarCheckAndFlush checks whether the writer is in tar mode and not closed.
 // If so, it flushes the buffer and returns any error encountered.
 // If not, it reports the corresponding error.
@@ -688,7 +1509,8 @@ func (fw *writer) tarCheckAndFlush() error {
 }
 
 // zipCheckAndFlush checks whether the writer is in ZIP mode and not closed.
-// If so, it flushes the buffer and returns any error encountered.
+// If so, it flushes the buffer, finishes the pending encrypted entry (if any),
+// and returns any error encountered.
 // If not, it reports the corresponding error.
 func (fw *writer) zipCheckAndFlush() error {
 	if fw.zw == nil {
@@ -696,7 +1518,11 @@ func (fw *writer) zipCheckAndFlush() error {
 	} else if fw.c.Closed() {
 		return errors.AutoWrap(ErrFileWriterClosed)
 	}
-	return errors.AutoWrap(fw.bw.Flush())
+	err := fw.bw.Flush()
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	return errors.AutoWrap(fw.zipFinishEncrypted())
 }
 
 // zipCreateFunc is a framework for ZipCreate, ZipCreateHeader,
@@ -733,7 +1559,7 @@ func (fw *writer) zipCreateFunc(
 		fw.uw = zipWriteBeforeCreateErrorWriter
 		fw.err = ErrZipWriteBeforeCreate
 	}
-	fw.bw.Reset(fw.uw)
+	fw.resetBuffer()
 	return errors.AutoWrap(err)
 }
 
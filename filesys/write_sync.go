@@ -0,0 +1,66 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys
+
+import "io"
+
+// fileSyncer is implemented by files that can commit their contents
+// to stable storage, such as *os.File.
+type fileSyncer interface {
+	Sync() error
+}
+
+// flusher is implemented by compressors that can flush
+// the pending compressed data to the underlying writer,
+// such as *compress/gzip.Writer.
+type flusher interface {
+	Flush() error
+}
+
+// syncEveryWriter wraps an io.Writer to the file
+// to sync the file after every specified number of bytes written,
+// for the option SyncEvery of WriteOptions.
+type syncEveryWriter struct {
+	w     io.Writer
+	s     fileSyncer
+	every int64
+	n     int64 // the number of bytes written since the last sync
+}
+
+func (sw *syncEveryWriter) Write(p []byte) (n int, err error) {
+	n, err = sw.w.Write(p)
+	sw.n += int64(n)
+	if err == nil && sw.n >= sw.every {
+		sw.n = 0
+		err = sw.s.Sync()
+	}
+	return
+}
+
+// syncCloser is an io.Closer that syncs the file when closed,
+// for the option SyncOnClose of WriteOptions.
+//
+// It does not close the file.
+type syncCloser struct {
+	s fileSyncer
+}
+
+func (sc *syncCloser) Close() error {
+	return sc.s.Sync()
+}
@@ -0,0 +1,150 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/donyori/gogo/filesys"
+)
+
+// syncWritableFile is a WritableFileImpl with the method Sync,
+// recording the size of the data at each sync.
+type syncWritableFile struct {
+	WritableFileImpl
+	syncSizes []int
+	closed    bool
+}
+
+func (f *syncWritableFile) Sync() error {
+	if f.closed {
+		return errors.New("sync after close")
+	}
+	f.syncSizes = append(f.syncSizes, len(f.Data))
+	return nil
+}
+
+func (f *syncWritableFile) Close() error {
+	f.closed = true
+	return f.WritableFileImpl.Close()
+}
+
+func TestWrite_SyncOnClose(t *testing.T) {
+	for _, name := range []string{"test.txt", "test.txt.gz", "test.tar"} {
+		t.Run("file="+name, func(t *testing.T) {
+			file := &syncWritableFile{WritableFileImpl: WritableFileImpl{Name: name}}
+			w, err := filesys.Write(
+				file, &filesys.WriteOptions{SyncOnClose: true}, true)
+			if err != nil {
+				t.Fatal("create -", err)
+			}
+			if !w.TarEnabled() {
+				_, err = w.WriteString("Hello, world!")
+			}
+			if err != nil {
+				_ = w.Close()
+				t.Fatal("write -", err)
+			}
+			if len(file.syncSizes) != 0 {
+				t.Errorf("synced before close: %v", file.syncSizes)
+			}
+			if err = w.Close(); err != nil {
+				t.Fatal("close -", err)
+			}
+			if len(file.syncSizes) != 1 || file.syncSizes[0] != len(file.Data) {
+				t.Errorf("got sync sizes %v; want [%d]",
+					file.syncSizes, len(file.Data))
+			}
+			if !file.closed {
+				t.Error("file not closed")
+			}
+		})
+	}
+}
+
+func TestWrite_SyncEvery(t *testing.T) {
+	file := &syncWritableFile{WritableFileImpl: WritableFileImpl{Name: "test.txt"}}
+	w, err := filesys.Write(file, &filesys.WriteOptions{
+		BufSize:   16,
+		SyncEvery: 100,
+	}, true)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	_, err = w.WriteString(strings.Repeat("0123456789", 35))
+	if err != nil {
+		_ = w.Close()
+		t.Fatal("write -", err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal("close -", err)
+	}
+	if len(file.syncSizes) != 3 {
+		t.Errorf("got sync sizes %v; want 3 syncs", file.syncSizes)
+	}
+	for i, size := range file.syncSizes {
+		if size < (i+1)*100 {
+			t.Errorf("sync %d at size %d; want >= %d", i, size, (i+1)*100)
+		}
+	}
+}
+
+func TestWriter_Sync(t *testing.T) {
+	const data = "Hello, world!"
+	file := &syncWritableFile{WritableFileImpl: WritableFileImpl{Name: "test.txt.gz"}}
+	w, err := filesys.Write(file, nil, true)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	_, err = w.WriteString(data)
+	if err == nil {
+		err = w.Sync()
+	}
+	if err != nil {
+		_ = w.Close()
+		t.Fatal("write and sync -", err)
+	}
+	if len(file.syncSizes) != 1 || file.syncSizes[0] != len(file.Data) {
+		t.Errorf("got sync sizes %v; want [%d]", file.syncSizes, len(file.Data))
+	}
+	// The data written before Sync can be decompressed
+	// even though the gzip stream is not finished.
+	gr, err := gzip.NewReader(bytes.NewReader(file.Data))
+	if err != nil {
+		t.Fatal("gzip reader -", err)
+	}
+	got := make([]byte, len(data))
+	if _, err = io.ReadFull(gr, got); err != nil {
+		t.Error("read synced data -", err)
+	} else if string(got) != data {
+		t.Errorf("got %q; want %q", got, data)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal("close -", err)
+	}
+	if err = w.Sync(); !errors.Is(err, filesys.ErrFileWriterClosed) {
+		t.Errorf("sync after close - got %v; want %v",
+			err, filesys.ErrFileWriterClosed)
+	}
+}
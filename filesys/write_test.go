@@ -24,14 +24,21 @@ import (
 	"bytes"
 	"compress/flate"
 	"compress/gzip"
+	"crypto/md5"
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"io/fs"
 	"path"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/klauspost/compress/zstd"
+
 	"github.com/donyori/gogo/filesys"
 	"github.com/donyori/gogo/inout"
 )
@@ -103,6 +110,426 @@ func TestWrite_Gz(t *testing.T) {
 	}
 }
 
+func TestWrite_GzipHeader(t *testing.T) {
+	hdr := &gzip.Header{
+		Comment: "a comment",
+		Extra:   []byte("extra data"),
+		ModTime: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Name:    "original.txt",
+		OS:      3, // Unix
+	}
+	for _, name := range []string{"test-header.txt.gz", "test-header.tgz"} {
+		t.Run(fmt.Sprintf("file=%+q", name), func(t *testing.T) {
+			file := &WritableFileImpl{Name: name}
+			opts := &filesys.WriteOptions{
+				DeflateLv:  gzip.BestCompression,
+				GzipHeader: hdr,
+			}
+			w, err := filesys.Write(file, opts, true)
+			if err != nil {
+				t.Fatal("create -", err)
+			}
+			if got := w.Options().GzipHeader; got == hdr {
+				t.Error("Options().GzipHeader is not a copy")
+			}
+			if err = w.Close(); err != nil {
+				t.Fatal("close -", err)
+			}
+			gr, err := gzip.NewReader(bytes.NewReader(file.Data))
+			if err != nil {
+				t.Fatal("create gzip reader -", err)
+			}
+			defer func(gr *gzip.Reader) {
+				_ = gr.Close() // ignore error
+			}(gr)
+			if gr.Name != hdr.Name || gr.Comment != hdr.Comment ||
+				!gr.ModTime.Equal(hdr.ModTime) || gr.OS != hdr.OS ||
+				!bytes.Equal(gr.Extra, hdr.Extra) {
+				t.Errorf("got header %+v; want %+v", gr.Header, *hdr)
+			}
+		})
+	}
+}
+
+func TestWrite_Progress(t *testing.T) {
+	for _, name := range []string{"test-progress.txt", "test-progress.txt.gz"} {
+		t.Run(fmt.Sprintf("file=%+q", name), func(t *testing.T) {
+			file := &WritableFileImpl{Name: name}
+			var calls int
+			var lastN, lastTotal int64
+			opts := &filesys.WriteOptions{
+				BufSize: 16,
+				Progress: func(n, total int64) {
+					if n <= lastN {
+						t.Errorf("call %d - got n %d; want > %d", calls, n, lastN)
+					}
+					calls++
+					lastN, lastTotal = n, total
+				},
+			}
+			w, err := filesys.Write(file, opts, true)
+			if err != nil {
+				t.Fatal("create -", err)
+			}
+			for i := range 100 {
+				_, err = w.Printf("This is line %d.\n", i)
+				if err != nil {
+					_ = w.Close() // ignore error
+					t.Fatal("write -", err)
+				}
+			}
+			if err = w.Close(); err != nil {
+				t.Fatal("close -", err)
+			}
+			if n := w.BytesWritten(); n != int64(len(file.Data)) {
+				t.Errorf("got BytesWritten %d; want %d", n, len(file.Data))
+			}
+			if calls == 0 {
+				t.Fatal("Progress not called")
+			} else if lastN != int64(len(file.Data)) || lastTotal != -1 {
+				t.Errorf("got last (%d, %d); want (%d, -1)",
+					lastN, lastTotal, len(file.Data))
+			}
+		})
+	}
+}
+
+func TestWrite_MaxBytes(t *testing.T) {
+	const MaxBytes = 100
+	data := bytes.Repeat([]byte("0123456789"), 6)
+	for _, name := range []string{"test-limit.txt", "test-limit.txt.gz"} {
+		t.Run(fmt.Sprintf("file=%+q", name), func(t *testing.T) {
+			file := &WritableFileImpl{Name: name}
+			w, err := filesys.Write(
+				file, &filesys.WriteOptions{MaxBytes: MaxBytes}, true)
+			if err != nil {
+				t.Fatal("create -", err)
+			}
+			var errs []error
+			for range 2 {
+				_, err = w.Write(data)
+				errs = append(errs, err)
+			}
+			errs = append(errs, w.Flush(), w.Close())
+			if err = errors.Join(errs...); !errors.Is(
+				err, filesys.ErrWriteLimitExceeded) {
+				t.Errorf("got %v; want %v", err, filesys.ErrWriteLimitExceeded)
+			}
+			got := file.Data
+			if path.Ext(name) == ".gz" {
+				gr, err := gzip.NewReader(bytes.NewReader(file.Data))
+				if err != nil {
+					t.Fatal("create gzip reader -", err)
+				}
+				got, err = io.ReadAll(gr)
+				if err != nil {
+					t.Fatal("read gzip data -", err)
+				}
+			}
+			want := append(data, data...)[:MaxBytes]
+			if !bytes.Equal(got, want) {
+				t.Errorf("got %q; want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestWrite_MaxBytes_CrossingCall(t *testing.T) {
+	const MaxBytes = 10
+	first := []byte("01234567")
+	testCases := []struct {
+		name  string
+		write func(w filesys.Writer) (n int64, err error)
+		want  int64 // the number of bytes written by the crossing call
+	}{
+		{"Write", func(w filesys.Writer) (int64, error) {
+			n, err := w.Write([]byte("89abc"))
+			return int64(n), err
+		}, 2},
+		{"WriteString", func(w filesys.Writer) (int64, error) {
+			n, err := w.WriteString("89abc")
+			return int64(n), err
+		}, 2},
+		{"ReadFrom", func(w filesys.Writer) (int64, error) {
+			return w.ReadFrom(strings.NewReader("89abc"))
+		}, 2},
+		{"Printf", func(w filesys.Writer) (int64, error) {
+			n, err := w.Printf("%d", 89012)
+			return int64(n), err
+		}, 2},
+		{"WriteRune", func(w filesys.Writer) (int64, error) {
+			n, err := w.WriteRune('\u4e16') // 3 bytes in UTF-8
+			return int64(n), err
+		}, 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			file := &WritableFileImpl{Name: "test-limit.txt"}
+			w, err := filesys.Write(
+				file, &filesys.WriteOptions{MaxBytes: MaxBytes}, true)
+			if err != nil {
+				t.Fatal("create -", err)
+			}
+			if _, err = w.Write(first); err != nil {
+				t.Error("first write -", err)
+			}
+			n, err := tc.write(w)
+			if !errors.Is(err, filesys.ErrWriteLimitExceeded) {
+				t.Errorf("crossing call - got error %v; want %v",
+					err, filesys.ErrWriteLimitExceeded)
+			}
+			if n != tc.want {
+				t.Errorf("crossing call - got n %d; want %d", n, tc.want)
+			}
+			err = w.WriteByte('x')
+			if !errors.Is(err, filesys.ErrWriteLimitExceeded) {
+				t.Errorf("later call - got error %v; want %v",
+					err, filesys.ErrWriteLimitExceeded)
+			}
+			if err = w.Close(); err != nil {
+				t.Error("close -", err)
+			}
+			if len(file.Data) != MaxBytes {
+				t.Errorf("got %d bytes written; want %d",
+					len(file.Data), MaxBytes)
+			}
+		})
+	}
+}
+
+func TestWrite_MaxBytes_ReadFromExact(t *testing.T) {
+	const MaxBytes = 10
+	file := &WritableFileImpl{Name: "test-limit.txt"}
+	w, err := filesys.Write(
+		file, &filesys.WriteOptions{MaxBytes: MaxBytes}, true)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	n, err := w.ReadFrom(strings.NewReader("0123456789"))
+	if err != nil || n != MaxBytes {
+		t.Errorf("got %d, %v; want %d, <nil>", n, err, MaxBytes)
+	}
+	if err = w.Close(); err != nil {
+		t.Error("close -", err)
+	}
+	if string(file.Data) != "0123456789" {
+		t.Errorf("got %q; want %q", file.Data, "0123456789")
+	}
+}
+
+func TestWrite_MaxBytes_TarCopy(t *testing.T) {
+	file := &WritableFileImpl{Name: "test-limit.tar"}
+	w, err := filesys.Write(file, &filesys.WriteOptions{MaxBytes: 10}, true)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	defer func(w filesys.Writer) {
+		_ = w.Close() // ignore error
+	}(w)
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     "a.txt",
+		Size:     16,
+		Mode:     0600,
+	}
+	err = w.TarCopy(hdr, strings.NewReader("0123456789abcdef"))
+	if !errors.Is(err, filesys.ErrWriteLimitExceeded) {
+		t.Errorf("got %v; want %v", err, filesys.ErrWriteLimitExceeded)
+	}
+}
+
+func TestWrite_ParallelCompression(t *testing.T) {
+	// Generate about 3.5 MiB of compressible data
+	// so that the data is split into multiple blocks.
+	var b bytes.Buffer
+	for i := 0; b.Len() < 7<<19; i++ {
+		_, _ = fmt.Fprintf(&b, "line %d: %s\n", i, strings.Repeat("ab", i%50))
+	}
+	data := b.Bytes()
+	hdr := &gzip.Header{Name: "data.txt", Comment: "parallel"}
+
+	for _, workers := range []int{-1, 2, 4} {
+		t.Run(fmt.Sprintf("workers=%d", workers), func(t *testing.T) {
+			file := &WritableFileImpl{Name: "parallel.txt.gz"}
+			w, err := filesys.Write(file, &filesys.WriteOptions{
+				DeflateLv:           gzip.DefaultCompression,
+				GzipHeader:          hdr,
+				ParallelCompression: workers,
+				Hashes:              []func() hash.Hash{md5.New},
+			}, true)
+			if err != nil {
+				t.Fatal("create -", err)
+			}
+			// Write in small pieces to cross the block boundaries.
+			for p := data; len(p) > 0; {
+				k := min(len(p), 100_000)
+				if _, err = w.Write(p[:k]); err != nil {
+					t.Fatal("write -", err)
+				}
+				p = p[k:]
+			}
+			if err = w.Close(); err != nil {
+				t.Fatal("close -", err)
+			}
+			br := bytes.NewReader(file.Data)
+			gr, err := gzip.NewReader(br)
+			if err != nil {
+				t.Fatal("create gzip reader -", err)
+			}
+			gr.Multistream(false)
+			got, err := io.ReadAll(gr)
+			if err != nil {
+				t.Fatal("decompress gzip -", err)
+			} else if !bytes.Equal(got, data) {
+				t.Errorf("got (len: %d); want (len: %d)", len(got), len(data))
+			}
+			if gr.Name != hdr.Name || gr.Comment != hdr.Comment {
+				t.Errorf("got header %+v; want %+v", gr.Header, *hdr)
+			}
+			// There should be exactly one gzip member.
+			if br.Len() != 0 {
+				t.Errorf("got %d bytes after the first gzip member", br.Len())
+			}
+			uncompressed, _ := w.Checksums(false)
+			want := fmt.Sprintf("%x", md5.Sum(data))
+			if len(uncompressed) != 1 || uncompressed[0] != want {
+				t.Errorf("got uncompressed checksums %q; want [%q]",
+					uncompressed, want)
+			}
+		})
+	}
+}
+
+func TestWrite_Zst(t *testing.T) {
+	const Name = "13KB.dat.zst"
+	data := testFS["13KB.dat"].Data
+	testCases := []struct {
+		optsName string
+		opts     *filesys.WriteOptions
+	}{
+		{"<nil>", nil},
+		{"ZstdLv=1", &filesys.WriteOptions{ZstdLv: 1}},
+		{"ZstdLv=19&ZstdWindowSize=65536", &filesys.WriteOptions{
+			ZstdLv:         19,
+			ZstdWindowSize: 1 << 16,
+		}},
+	}
+	for _, tc := range testCases {
+		t.Run("opts="+tc.optsName, func(t *testing.T) {
+			file := &WritableFileImpl{Name: Name}
+			writeFile(t, file, data, tc.opts)
+			if t.Failed() {
+				return
+			}
+			zr, err := zstd.NewReader(bytes.NewReader(file.Data))
+			if err != nil {
+				t.Fatal("create zstd reader -", err)
+			}
+			defer zr.Close()
+			got, err := io.ReadAll(zr)
+			if err != nil {
+				t.Fatal("decompress zstd -", err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("got (len: %d); want (len: %d)", len(got), len(data))
+			}
+		})
+	}
+}
+
+func TestWrite_Zst_InvalidWindowSize(t *testing.T) {
+	file := &WritableFileImpl{Name: "test.zst"}
+	w, err := filesys.Write(
+		file, &filesys.WriteOptions{ZstdWindowSize: 1000}, true)
+	if err == nil {
+		_ = w.Close()
+		t.Error("create - no error but window size is not a power of two")
+	}
+}
+
+func TestWrite_Checksums(t *testing.T) {
+	data := testFS["13KB.dat"].Data
+	testCases := []struct {
+		name string
+		raw  bool
+	}{
+		{"13KB.dat", false},
+		{"13KB.dat.gz", false},
+		{"13KB.dat.zst", false},
+		{"13KB.dat.gz", true},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("file=%+q&raw=%t", tc.name, tc.raw), func(t *testing.T) {
+			file := &WritableFileImpl{Name: tc.name}
+			w, err := filesys.Write(file, &filesys.WriteOptions{
+				Raw:       tc.raw,
+				DeflateLv: flate.DefaultCompression,
+				Hashes:    []func() hash.Hash{sha256.New, nil, md5.New},
+			}, true)
+			if err != nil {
+				t.Fatal("create -", err)
+			}
+			_, err = w.Write(data)
+			if err != nil {
+				t.Error("write -", err)
+			}
+			err = w.Close()
+			if err != nil {
+				t.Fatal("close -", err)
+			}
+			uncompressed, compressed := w.Checksums(false)
+			wantCompressed := []string{
+				fmt.Sprintf("%x", sha256.Sum256(file.Data)),
+				"",
+				fmt.Sprintf("%x", md5.Sum(file.Data)),
+			}
+			wantUncompressed := []string{
+				fmt.Sprintf("%x", sha256.Sum256(data)),
+				"",
+				fmt.Sprintf("%x", md5.Sum(data)),
+			}
+			if !slices.Equal(compressed, wantCompressed) {
+				t.Errorf("compressed - got %q; want %q",
+					compressed, wantCompressed)
+			}
+			if !slices.Equal(uncompressed, wantUncompressed) {
+				t.Errorf("uncompressed - got %q; want %q",
+					uncompressed, wantUncompressed)
+			}
+		})
+	}
+}
+
+func TestWrite_Checksums_NoHashes(t *testing.T) {
+	file := &WritableFileImpl{Name: "test.txt.gz"}
+	w, err := filesys.Write(file, nil, true)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	defer func(w filesys.Writer) {
+		if err := w.Close(); err != nil {
+			t.Error("close -", err)
+		}
+	}(w)
+	uncompressed, compressed := w.Checksums(false)
+	if uncompressed != nil || compressed != nil {
+		t.Errorf("got (%q, %q); want (<nil>, <nil>)", uncompressed, compressed)
+	}
+}
+
+func TestWrite_TarZst(t *testing.T) {
+	for _, name := range []string{"tar file.tar.zst", "tar file.tzst"} {
+		t.Run(fmt.Sprintf("file=%+q", name), func(t *testing.T) {
+			file := &WritableFileImpl{Name: name}
+			writeTarFiles(t, file)
+			if !t.Failed() {
+				testTarTgzFile(t, file, testFSTarFiles)
+			}
+		})
+	}
+}
+
 func TestWrite_TarTgz(t *testing.T) {
 	for _, name := range append(testFSTarFilenames, testFSTgzFilenames...) {
 		t.Run(fmt.Sprintf("file=%+q", name), func(t *testing.T) {
@@ -127,6 +554,66 @@ func TestWrite_TarAddFS(t *testing.T) {
 	}
 }
 
+func TestWrite_TarCopy(t *testing.T) {
+	for _, srcName := range append(testFSTarFilenames, testFSTgzFilenames...) {
+		t.Run(fmt.Sprintf("src=%+q", srcName), func(t *testing.T) {
+			src, err := filesys.ReadFromFS(testFS, srcName, nil)
+			if err != nil {
+				t.Fatal("create reader -", err)
+			}
+			defer func(r filesys.Reader) {
+				if err := r.Close(); err != nil {
+					t.Error("close reader -", err)
+				}
+			}(src)
+			file := &WritableFileImpl{Name: "copy.tar"}
+			w, err := filesys.Write(file, nil, true)
+			if err != nil {
+				t.Fatal("create writer -", err)
+			}
+			for {
+				hdr, err := src.TarNext()
+				if errors.Is(err, io.EOF) {
+					break
+				} else if err != nil {
+					_ = w.Close() // ignore error
+					t.Fatal("TarNext -", err)
+				}
+				err = w.TarCopy(hdr, src)
+				if err != nil {
+					_ = w.Close() // ignore error
+					t.Fatalf("TarCopy %q - %v", hdr.Name, err)
+				}
+			}
+			if err = w.Close(); err != nil {
+				t.Fatal("close writer -", err)
+			}
+			testTarTgzFile(t, file, testFSTarFiles)
+		})
+	}
+}
+
+func TestWrite_TarCopy_ShortReader(t *testing.T) {
+	file := &WritableFileImpl{Name: "short.tar"}
+	w, err := filesys.Write(file, nil, true)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	defer func(w filesys.Writer) {
+		_ = w.Close() // ignore error
+	}(w)
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     "short.txt",
+		Size:     10,
+		Mode:     0600,
+	}
+	err = w.TarCopy(hdr, strings.NewReader("short"))
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("got %v; want %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
 func TestWrite_Zip(t *testing.T) {
 	testCases := []struct {
 		name string
@@ -712,7 +1199,7 @@ func writeTarFS(t *testing.T, file *WritableFileImpl) {
 // function writeTarFiles or writeTarFS.
 //
 // Caller should guarantee that file.Name has extension
-// ".tar", ".tar.gz", or ".tgz".
+// ".tar", ".tar.gz", ".tgz", ".tar.zst", or ".tzst".
 func testTarTgzFile(
 	t *testing.T,
 	file *WritableFileImpl,
@@ -732,6 +1219,14 @@ func testTarTgzFile(
 			}
 		}(gr)
 		r = gr
+	} else if ext == ".zst" || ext == ".tzst" {
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			t.Error("create zstd reader -", err)
+			return
+		}
+		defer zr.Close()
+		r = zr
 	}
 	tr := tar.NewReader(r)
 	for i := 0; ; i++ {
@@ -0,0 +1,537 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"math"
+	"unicode/utf8"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// ZipEncryption is the encryption method for the entries of a ZIP archive.
+type ZipEncryption uint8
+
+const (
+	// ZipNoEncryption indicates that the entries are not encrypted.
+	ZipNoEncryption ZipEncryption = iota
+
+	// ZipCrypto is the traditional PKWARE encryption.
+	//
+	// It is supported by almost all ZIP tools, but is known to be weak.
+	// Use it only for compatibility.
+	ZipCrypto
+
+	// ZipAES128 is the WinZip AES encryption with a 128-bit key.
+	ZipAES128
+
+	// ZipAES192 is the WinZip AES encryption with a 192-bit key.
+	ZipAES192
+
+	// ZipAES256 is the WinZip AES encryption with a 256-bit key.
+	ZipAES256
+
+	maxZipEncryption = ZipAES256
+)
+
+// String returns the name of the encryption method.
+func (e ZipEncryption) String() string {
+	switch e {
+	case ZipNoEncryption:
+		return "None"
+	case ZipCrypto:
+		return "ZipCrypto"
+	case ZipAES128:
+		return "AES-128"
+	case ZipAES192:
+		return "AES-192"
+	case ZipAES256:
+		return "AES-256"
+	}
+	return fmt.Sprintf("ZipEncryption(%d)", uint8(e))
+}
+
+const (
+	// zipAESMethod is the compression method ID
+	// indicating WinZip AES encryption.
+	zipAESMethod uint16 = 99
+
+	// zipAESExtraID is the ID of the WinZip AES extra field.
+	zipAESExtraID uint16 = 0x9901
+
+	// zipExtTimeExtraID is the ID of the extended timestamp extra field.
+	zipExtTimeExtraID uint16 = 0x5455
+
+	// zipAESIterations is the number of PBKDF2 iterations
+	// for WinZip AES key derivation.
+	zipAESIterations = 1000
+
+	// zipAESMACSize is the size of the authentication code
+	// of WinZip AES encryption.
+	zipAESMACSize = 10
+
+	// zipCryptoHeaderSize is the size of the encryption header
+	// of the traditional PKWARE encryption.
+	zipCryptoHeaderSize = 12
+
+	zipVersion20 uint16 = 20 // 2.0, for DEFLATE and ZipCrypto
+	zipVersion45 uint16 = 45 // 4.5, for ZIP64
+	zipVersion51 uint16 = 51 // 5.1, for WinZip AES
+)
+
+// zipEncryptedEntry is the writer of a ZIP entry to be encrypted.
+//
+// The data is compressed and encrypted while being written,
+// and the encrypted data is written to the ZIP archive directly
+// through archive/zip.Writer.CreateRaw, without buffering the whole entry.
+// As the sizes and the CRC-32 checksum are unknown
+// when writing the local file header,
+// the entry is written with a data descriptor
+// (i.e., bit 3 of the general purpose flag is set).
+// They are set in the file header on finish.
+// The file header is retained by the archive/zip.Writer
+// and used to write the data descriptor and the central directory.
+type zipEncryptedEntry struct {
+	fh      *zip.FileHeader
+	cw      countWriter    // counts the encrypted bytes written to the archive
+	enc     io.WriteCloser // encrypter, writing to cw
+	comp    io.WriteCloser // compressor, writing to enc
+	crc     hash.Hash32
+	rawSize uint64
+}
+
+// newZipEncryptedEntry creates a new zipEncryptedEntry in zw with
+// the file header fh (which is copied), encryption method enc,
+// password pwd, and compressor for the method fh.Method.
+func newZipEncryptedEntry(
+	zw *zip.Writer,
+	fh *zip.FileHeader,
+	enc ZipEncryption,
+	pwd string,
+	comp zip.Compressor,
+) (e *zipEncryptedEntry, err error) {
+	fhCopy := *fh
+	fh = &fhCopy
+	fh.Flags |= 0x1 | 0x8 // encrypted, with data descriptor
+	zipPrepareRawHeader(fh)
+	var strength byte
+	if enc == ZipCrypto {
+		fh.ReaderVersion = max(fh.ReaderVersion, zipVersion20)
+	} else {
+		fh.ReaderVersion = zipVersion51
+		strength = byte(enc - ZipAES128 + 1)
+		var extra [11]byte
+		binary.LittleEndian.PutUint16(extra[0:], zipAESExtraID)
+		binary.LittleEndian.PutUint16(extra[2:], 7)
+		binary.LittleEndian.PutUint16(extra[4:], 2) // AE-2
+		extra[6], extra[7] = 'A', 'E'
+		extra[8] = strength
+		binary.LittleEndian.PutUint16(extra[9:], fh.Method)
+		fh.Extra = append(fh.Extra, extra[:]...)
+		fh.Method = zipAESMethod
+	}
+	fh.CreatorVersion = fh.CreatorVersion&0xff00 | fh.ReaderVersion
+	w, err := zw.CreateRaw(fh)
+	if err != nil {
+		return nil, err
+	}
+	e = &zipEncryptedEntry{
+		fh:  fh,
+		cw:  countWriter{w: w},
+		crc: crc32.NewIEEE(),
+	}
+	if enc == ZipCrypto {
+		// The CRC-32 checksum is unknown when writing the encryption header.
+		// In this case (with the data descriptor),
+		// the last byte of the encryption header is
+		// the high-order byte of the MS-DOS modification time instead.
+		e.enc, err = newZipCryptoWriter(
+			&e.cw, []byte(pwd), byte(fh.ModifiedTime>>8))
+	} else {
+		e.enc, err = newZipAESWriter(&e.cw, []byte(pwd), strength)
+	}
+	if err != nil {
+		return nil, err
+	}
+	e.comp, err = comp(e.enc)
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (e *zipEncryptedEntry) Write(p []byte) (n int, err error) {
+	n, err = e.comp.Write(p)
+	_, _ = e.crc.Write(p[:n]) // never returns an error
+	e.rawSize += uint64(n)
+	return
+}
+
+// finish flushes the compressor and the encrypter,
+// and sets the sizes and the CRC-32 checksum in the file header.
+//
+// It must be called before creating the next entry
+// or closing the archive/zip.Writer.
+func (e *zipEncryptedEntry) finish() error {
+	err := e.comp.Close()
+	if err != nil {
+		return err
+	}
+	err = e.enc.Close()
+	if err != nil {
+		return err
+	}
+	fh := e.fh
+	if fh.Method != zipAESMethod {
+		// AE-2 does not store the CRC-32 checksum.
+		// The integrity is protected by the authentication code instead.
+		fh.CRC32 = e.crc.Sum32()
+	}
+	fh.CompressedSize64 = uint64(e.cw.n)
+	fh.UncompressedSize64 = e.rawSize
+	if fh.CompressedSize64 > math.MaxUint32 ||
+		fh.UncompressedSize64 > math.MaxUint32 {
+		fh.CompressedSize = math.MaxUint32
+		fh.UncompressedSize = math.MaxUint32
+		fh.ReaderVersion = max(fh.ReaderVersion, zipVersion45)
+	} else {
+		fh.CompressedSize = uint32(fh.CompressedSize64)
+		fh.UncompressedSize = uint32(fh.UncompressedSize64)
+	}
+	return nil
+}
+
+// zipPrepareRawHeader sets the fields of fh that archive/zip.Writer.CreateHeader
+// would set but archive/zip.Writer.CreateRaw would not,
+// namely, the UTF-8 flag and the modification time.
+func zipPrepareRawHeader(fh *zip.FileHeader) {
+	if !fh.NonUTF8 && (!isASCII(fh.Name) || !isASCII(fh.Comment)) &&
+		utf8.ValidString(fh.Name) && utf8.ValidString(fh.Comment) {
+		fh.Flags |= 0x800
+	}
+	if fh.Modified.IsZero() {
+		return
+	}
+	// Encode the MS-DOS time in the time zone of fh.Modified,
+	// the same as archive/zip.Writer.CreateHeader.
+	t := fh.Modified
+	if t.Year() >= 1980 {
+		fh.ModifiedDate = uint16(t.Day() + int(t.Month())<<5 + (t.Year()-1980)<<9)
+		fh.ModifiedTime = uint16(t.Second()/2 + t.Minute()<<5 + t.Hour()<<11)
+	}
+	var extra [9]byte
+	binary.LittleEndian.PutUint16(extra[0:], zipExtTimeExtraID)
+	binary.LittleEndian.PutUint16(extra[2:], 5)
+	extra[4] = 1 // flags: modification time
+	binary.LittleEndian.PutUint32(extra[5:], uint32(fh.Modified.Unix()))
+	fh.Extra = append(fh.Extra, extra[:]...)
+}
+
+// isASCII reports whether s consists of ASCII characters only.
+func isASCII(s string) bool {
+	for i := range len(s) {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// zipCryptoKeys are the keys of the traditional PKWARE encryption.
+type zipCryptoKeys [3]uint32
+
+// newZipCryptoKeys initializes the keys with password pwd.
+func newZipCryptoKeys(pwd []byte) *zipCryptoKeys {
+	k := &zipCryptoKeys{0x12345678, 0x23456789, 0x34567890}
+	for _, c := range pwd {
+		k.update(c)
+	}
+	return k
+}
+
+// update updates the keys with the plaintext byte c.
+func (k *zipCryptoKeys) update(c byte) {
+	k[0] = crc32.IEEETable[byte(k[0])^c] ^ k[0]>>8
+	k[1] = (k[1]+k[0]&0xff)*134775813 + 1
+	k[2] = crc32.IEEETable[byte(k[2])^byte(k[1]>>24)] ^ k[2]>>8
+}
+
+// streamByte returns the next byte of the key stream.
+func (k *zipCryptoKeys) streamByte() byte {
+	t := k[2] | 2
+	return byte(t * (t ^ 1) >> 8)
+}
+
+// encrypt encrypts p in place.
+func (k *zipCryptoKeys) encrypt(p []byte) {
+	for i, c := range p {
+		p[i] = c ^ k.streamByte()
+		k.update(c)
+	}
+}
+
+// zipCryptoWriter encrypts data with the traditional PKWARE encryption
+// and writes the results to the underlying writer.
+type zipCryptoWriter struct {
+	w    io.Writer
+	keys *zipCryptoKeys
+	buf  []byte
+}
+
+// newZipCryptoWriter creates a new zipCryptoWriter
+// writing to w with password pwd,
+// and writes the encryption header to w.
+//
+// check is the last byte of the encryption header.
+func newZipCryptoWriter(w io.Writer, pwd []byte, check byte) (
+	zcw *zipCryptoWriter, err error) {
+	header := make([]byte, zipCryptoHeaderSize)
+	_, err = rand.Read(header[:zipCryptoHeaderSize-1])
+	if err != nil {
+		return nil, err
+	}
+	header[zipCryptoHeaderSize-1] = check
+	zcw = &zipCryptoWriter{w: w, keys: newZipCryptoKeys(pwd)}
+	zcw.keys.encrypt(header)
+	_, err = w.Write(header)
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (zcw *zipCryptoWriter) Write(p []byte) (n int, err error) {
+	// Encrypt a copy of p, as io.Writer must not modify p.
+	zcw.buf = append(zcw.buf[:0], p...)
+	zcw.keys.encrypt(zcw.buf)
+	n, err = zcw.w.Write(zcw.buf)
+	if err == nil && n < len(p) {
+		err = io.ErrShortWrite
+	}
+	return
+}
+
+// Close does nothing and returns nil.
+// It does not close the underlying writer.
+func (zcw *zipCryptoWriter) Close() error {
+	return nil
+}
+
+// zipAESWriter encrypts data with the WinZip AES encryption
+// and writes the results to the underlying writer.
+//
+// The salt and password verification value are written on creation,
+// and the authentication code is written on Close.
+type zipAESWriter struct {
+	w      io.Writer
+	block  cipher.Block
+	mac    hash.Hash
+	ctr    [aes.BlockSize]byte
+	stream [aes.BlockSize]byte
+	used   int // number of bytes of stream used
+	buf    []byte
+}
+
+// newZipAESWriter creates a new zipAESWriter writing to w
+// with password pwd and the specified strength
+// (1 for AES-128, 2 for AES-192, 3 for AES-256),
+// and writes the salt and password verification value to w.
+func newZipAESWriter(w io.Writer, pwd []byte, strength byte) (
+	zaw *zipAESWriter, err error) {
+	keyLen := 8 + 8*int(strength) // 16, 24, or 32
+	salt := make([]byte, keyLen/2)
+	_, err = rand.Read(salt)
+	if err != nil {
+		return nil, err
+	}
+	dk := pbkdf2SHA1(pwd, salt, zipAESIterations, 2*keyLen+2)
+	block, err := aes.NewCipher(dk[:keyLen])
+	if err != nil {
+		return nil, err
+	}
+	zaw = &zipAESWriter{
+		w:     w,
+		block: block,
+		mac:   hmac.New(sha1.New, dk[keyLen:2*keyLen]),
+		used:  aes.BlockSize,
+	}
+	_, err = w.Write(append(salt, dk[2*keyLen:]...))
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (zaw *zipAESWriter) Write(p []byte) (n int, err error) {
+	if cap(zaw.buf) < len(p) {
+		zaw.buf = make([]byte, len(p))
+	}
+	ct := zaw.buf[:len(p)]
+	zaw.xorKeyStream(ct, p)
+	_, _ = zaw.mac.Write(ct) // never returns an error
+	n, err = zaw.w.Write(ct)
+	if err == nil && n < len(p) {
+		err = io.ErrShortWrite
+	}
+	return
+}
+
+// Close writes the authentication code to the underlying writer.
+// It does not close the underlying writer.
+func (zaw *zipAESWriter) Close() error {
+	_, err := zaw.w.Write(zaw.mac.Sum(nil)[:zipAESMACSize])
+	return err
+}
+
+// xorKeyStream XORs src with the key stream of AES in CTR mode
+// as used by WinZip AES (a little-endian counter starting at 1),
+// and writes the result to dst.
+// The key stream continues from the previous call.
+//
+// dst and src must have the same length.
+func (zaw *zipAESWriter) xorKeyStream(dst, src []byte) {
+	for i := range src {
+		if zaw.used == aes.BlockSize {
+			for j := range zaw.ctr {
+				zaw.ctr[j]++
+				if zaw.ctr[j] != 0 {
+					break
+				}
+			}
+			zaw.block.Encrypt(zaw.stream[:], zaw.ctr[:])
+			zaw.used = 0
+		}
+		dst[i] = src[i] ^ zaw.stream[zaw.used]
+		zaw.used++
+	}
+}
+
+// pbkdf2SHA1 derives a key of length keyLen from password pwd and salt
+// using PBKDF2 with HMAC-SHA1 and the specified number of iterations,
+// as defined in RFC 8018.
+func pbkdf2SHA1(pwd, salt []byte, iter, keyLen int) []byte {
+	prf := hmac.New(sha1.New, pwd)
+	dk := make([]byte, 0, (keyLen+sha1.Size-1)/sha1.Size*sha1.Size)
+	var u, t []byte
+	for block := uint32(1); len(dk) < keyLen; block++ {
+		prf.Reset()
+		_, _ = prf.Write(salt) // never returns an error
+		_, _ = prf.Write(binary.BigEndian.AppendUint32(nil, block))
+		u = prf.Sum(u[:0])
+		t = append(t[:0], u...)
+		for range iter - 1 {
+			prf.Reset()
+			_, _ = prf.Write(u)
+			u = prf.Sum(u[:0])
+			for i := range t {
+				t[i] ^= u[i]
+			}
+		}
+		dk = append(dk, t...)
+	}
+	return dk[:keyLen]
+}
+
+// zipCompressor returns the compressor for the specified method,
+// according to the option ZipComp and DeflateLv.
+func (fw *writer) zipCompressor(method uint16) (zip.Compressor, error) {
+	if comp := fw.opts.ZipComp[method]; comp != nil {
+		return comp, nil
+	}
+	switch method {
+	case zip.Store:
+		return func(w io.Writer) (io.WriteCloser, error) {
+			return &nopWriteCloser{w: w}, nil
+		}, nil
+	case zip.Deflate:
+		return func(w io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(w, fw.opts.DeflateLv)
+		}, nil
+	}
+	return nil, zip.ErrAlgorithm
+}
+
+// zipEncryption returns the effective encryption method
+// according to the options ZipEncryption and ZipPassword.
+func (fw *writer) zipEncryption() ZipEncryption {
+	if fw.opts.ZipEncryption == ZipNoEncryption && fw.opts.ZipPassword != "" {
+		return ZipAES256
+	}
+	return fw.opts.ZipEncryption
+}
+
+// zipCreateEncrypted starts an encrypted entry with the file header fh.
+func (fw *writer) zipCreateEncrypted(fh *zip.FileHeader) (io.Writer, error) {
+	comp, err := fw.zipCompressor(fh.Method)
+	if err != nil {
+		return nil, err
+	}
+	e, err := newZipEncryptedEntry(
+		fw.zw, fh, fw.zipEncryption(), fw.opts.ZipPassword, comp)
+	if err != nil {
+		return nil, err
+	}
+	fw.zipEnc = e
+	return e, nil
+}
+
+// zipFinishEncrypted finishes the pending encrypted entry, if any.
+func (fw *writer) zipFinishEncrypted() error {
+	e := fw.zipEnc
+	if e == nil {
+		return nil
+	}
+	fw.zipEnc = nil
+	return e.finish()
+}
+
+// zipWriterCloser closes the ZIP writer of a writer
+// after finishing its pending encrypted entry.
+type zipWriterCloser struct {
+	fw *writer
+}
+
+func (zc *zipWriterCloser) Close() error {
+	return errors.Combine(zc.fw.zipFinishEncrypted(), zc.fw.zw.Close())
+}
+
+// nopWriteCloser wraps an io.Writer with a no-op method Close.
+type nopWriteCloser struct {
+	w io.Writer
+}
+
+func (nwc *nopWriteCloser) Write(p []byte) (n int, err error) {
+	return nwc.w.Write(p)
+}
+
+func (nwc *nopWriteCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,373 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package filesys_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/filesys"
+)
+
+func TestWrite_ZipEncryption(t *testing.T) {
+	const Password = "密码 password"
+	encs := []filesys.ZipEncryption{
+		filesys.ZipCrypto,
+		filesys.ZipAES128,
+		filesys.ZipAES192,
+		filesys.ZipAES256,
+	}
+	methods := []uint16{zip.Store, zip.Deflate}
+	modTime := time.Date(2024, 5, 17, 13, 42, 36, 0, time.UTC)
+	for _, enc := range encs {
+		for _, method := range methods {
+			t.Run(fmt.Sprintf("enc=%v&method=%d", enc, method), func(t *testing.T) {
+				file := &WritableFileImpl{Name: "encrypted.zip"}
+				w, err := filesys.Write(file, &filesys.WriteOptions{
+					ZipPassword:   Password,
+					ZipEncryption: enc,
+				}, true)
+				if err != nil {
+					t.Fatal("create -", err)
+				}
+				for name, body := range testFSZipFileNameBodyMap {
+					err = w.ZipCreateHeader(&zip.FileHeader{
+						Name:     name,
+						Method:   method,
+						Modified: modTime,
+					})
+					if err != nil {
+						t.Fatalf("create %q - %v", name, err)
+					}
+					_, _ = w.WriteString(body) // directories report ErrIsDir
+				}
+				if err = w.Close(); err != nil {
+					t.Fatal("close -", err)
+				}
+				testEncryptedZipFile(
+					t, file.Data, testFSZipFileNameBodyMap, Password, enc)
+			})
+		}
+	}
+}
+
+func TestWrite_ZipEncryption_DefaultAES256(t *testing.T) {
+	const Password = "password"
+	file := &WritableFileImpl{Name: "encrypted.zip"}
+	w, err := filesys.Write(
+		file,
+		&filesys.WriteOptions{ZipPassword: Password},
+		true,
+	)
+	if err != nil {
+		t.Fatal("create -", err)
+	}
+	for name, body := range testFSZipFileNameBodyMap {
+		if err = w.ZipCreate(name); err != nil {
+			t.Fatalf("create %q - %v", name, err)
+		}
+		_, _ = w.WriteString(body) // directories report ErrIsDir
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal("close -", err)
+	}
+	testEncryptedZipFile(
+		t, file.Data, testFSZipFileNameBodyMap, Password, filesys.ZipAES256)
+}
+
+func TestWrite_ZipEncryption_Streaming(t *testing.T) {
+	const Password, Name, ChunkSize = "password", "large.bin", 1000
+	data := make([]byte, 1<<18)
+	for i := range data {
+		data[i] = byte(i*7 + i>>8)
+	}
+	nameBodyMap := map[string]string{Name: string(data)}
+	encs := []filesys.ZipEncryption{filesys.ZipCrypto, filesys.ZipAES256}
+	for _, enc := range encs {
+		t.Run("enc="+enc.String(), func(t *testing.T) {
+			file := &WritableFileImpl{Name: "encrypted.zip"}
+			w, err := filesys.Write(file, &filesys.WriteOptions{
+				ZipPassword:   Password,
+				ZipEncryption: enc,
+			}, true)
+			if err != nil {
+				t.Fatal("create -", err)
+			}
+			err = w.ZipCreateHeader(&zip.FileHeader{
+				Name:   Name,
+				Method: zip.Store,
+			})
+			if err != nil {
+				t.Fatalf("create %q - %v", Name, err)
+			}
+			// Write in chunks not aligned with the AES block size.
+			for i := 0; i < len(data); i += ChunkSize {
+				_, err = w.Write(data[i:min(i+ChunkSize, len(data))])
+				if err != nil {
+					t.Fatal("write -", err)
+				}
+			}
+			// The encrypted data should have been written to the file
+			// before finishing the entry, except for the buffered part.
+			if n := len(file.Data); n < len(data)/2 {
+				t.Errorf("got %d bytes written before close; want at least %d",
+					n, len(data)/2)
+			}
+			if err = w.Close(); err != nil {
+				t.Fatal("close -", err)
+			}
+			testEncryptedZipFile(t, file.Data, nameBodyMap, Password, enc)
+		})
+	}
+}
+
+func TestWrite_ZipEncryption_NoPassword(t *testing.T) {
+	file := &WritableFileImpl{Name: "encrypted.zip"}
+	w, err := filesys.Write(
+		file,
+		&filesys.WriteOptions{ZipEncryption: filesys.ZipAES128},
+		true,
+	)
+	if err == nil {
+		_ = w.Close()
+		t.Error("got nil error")
+	}
+}
+
+func TestPBKDF2SHA1(t *testing.T) {
+	// Test vectors from RFC 6070.
+	testCases := []struct {
+		pwd, salt string
+		iter      int
+		keyLen    int
+		want      string
+	}{
+		{"password", "salt", 1, 20,
+			"0c60c80f961f0e71f3a9b524af6012062fe037a6"},
+		{"password", "salt", 2, 20,
+			"ea6c014dc72d6f8ccd1ed92ace1d41f0d8de8957"},
+		{"password", "salt", 4096, 20,
+			"4b007901b765489abead49d926f721d065a429c1"},
+		{"passwordPASSWORDpassword",
+			"saltSALTsaltSALTsaltSALTsaltSALTsalt", 4096, 25,
+			"3d2eec4fe41c849b80c8d83662c0e44a8b291a964cf2f07038"},
+		{"pass\x00word", "sa\x00lt", 4096, 16,
+			"56fa6aa75548099dcc37d7f03425e0c3"},
+	}
+	for _, tc := range testCases {
+		t.Run(
+			fmt.Sprintf("pwd=%+q&salt=%+q&iter=%d", tc.pwd, tc.salt, tc.iter),
+			func(t *testing.T) {
+				got := hex.EncodeToString(filesys.PBKDF2SHA1(
+					[]byte(tc.pwd), []byte(tc.salt), tc.iter, tc.keyLen))
+				if got != tc.want {
+					t.Errorf("got %s; want %s", got, tc.want)
+				}
+			},
+		)
+	}
+}
+
+// testEncryptedZipFile checks whether the ZIP archive data
+// contains the files in nameBodyMap,
+// encrypted with password pwd and encryption method enc.
+func testEncryptedZipFile(
+	t *testing.T,
+	data []byte,
+	nameBodyMap map[string]string,
+	pwd string,
+	enc filesys.ZipEncryption,
+) {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal("create zip reader -", err)
+	}
+	if len(zr.File) != len(nameBodyMap) {
+		t.Errorf("got %d files; want %d", len(zr.File), len(nameBodyMap))
+	}
+	for _, f := range zr.File {
+		body, ok := nameBodyMap[f.Name]
+		if !ok {
+			t.Errorf("unexpected file %q", f.Name)
+			continue
+		} else if f.FileInfo().IsDir() {
+			if f.Flags&0x1 != 0 {
+				t.Errorf("directory %q is encrypted", f.Name)
+			}
+			continue
+		} else if f.Flags&0x1 == 0 {
+			t.Errorf("file %q is not encrypted", f.Name)
+			continue
+		} else if f.Flags&0x8 == 0 {
+			t.Errorf("file %q has no data descriptor", f.Name)
+		}
+		raw, err := f.OpenRaw()
+		if err != nil {
+			t.Errorf("open raw %q - %v", f.Name, err)
+			continue
+		}
+		ct, err := io.ReadAll(raw)
+		if err != nil {
+			t.Errorf("read raw %q - %v", f.Name, err)
+			continue
+		}
+		method := f.Method
+		var compressed []byte
+		if enc == filesys.ZipCrypto {
+			// With the data descriptor, the check byte is
+			// the high-order byte of the MS-DOS modification time
+			// instead of that of the CRC-32 checksum.
+			check := byte(f.CRC32 >> 24)
+			if f.Flags&0x8 != 0 {
+				check = byte(f.ModifiedTime >> 8)
+			}
+			compressed, err = zipCryptoDecrypt(ct, []byte(pwd), check)
+		} else {
+			method, compressed, err = zipAESDecrypt(f, ct, []byte(pwd), enc)
+		}
+		if err != nil {
+			t.Errorf("decrypt %q - %v", f.Name, err)
+			continue
+		}
+		var plain []byte
+		switch method {
+		case zip.Store:
+			plain = compressed
+		case zip.Deflate:
+			plain, err = io.ReadAll(flate.NewReader(bytes.NewReader(compressed)))
+		default:
+			err = fmt.Errorf("unknown method %d", method)
+		}
+		if err != nil {
+			t.Errorf("decompress %q - %v", f.Name, err)
+		} else if string(plain) != body {
+			t.Errorf("file %q - got %q; want %q", f.Name, plain, body)
+		} else if enc == filesys.ZipCrypto &&
+			crc32.ChecksumIEEE(plain) != f.CRC32 {
+			t.Errorf("file %q - CRC-32 mismatch", f.Name)
+		}
+	}
+}
+
+// zipCryptoDecrypt decrypts the data encrypted by
+// the traditional PKWARE encryption and checks the header.
+func zipCryptoDecrypt(ct, pwd []byte, check byte) ([]byte, error) {
+	if len(ct) < 12 {
+		return nil, fmt.Errorf("data too short (%d bytes)", len(ct))
+	}
+	keys := [3]uint32{0x12345678, 0x23456789, 0x34567890}
+	update := func(c byte) {
+		keys[0] = crc32.Update(keys[0]^0xffffffff, crc32.IEEETable,
+			[]byte{c}) ^ 0xffffffff
+		keys[1] = (keys[1]+keys[0]&0xff)*134775813 + 1
+		keys[2] = crc32.Update(keys[2]^0xffffffff, crc32.IEEETable,
+			[]byte{byte(keys[1] >> 24)}) ^ 0xffffffff
+	}
+	for _, c := range pwd {
+		update(c)
+	}
+	plain := make([]byte, len(ct))
+	for i, c := range ct {
+		k := keys[2] | 2
+		plain[i] = c ^ byte(k*(k^1)>>8)
+		update(plain[i])
+	}
+	if plain[11] != check {
+		return nil, fmt.Errorf("wrong check byte %#x; want %#x", plain[11], check)
+	}
+	return plain[12:], nil
+}
+
+// zipAESDecrypt decrypts the data encrypted by WinZip AES,
+// checks the extra field, password verification value,
+// and authentication code,
+// and returns the actual compression method and the decrypted data.
+func zipAESDecrypt(
+	f *zip.File,
+	ct, pwd []byte,
+	enc filesys.ZipEncryption,
+) (method uint16, plain []byte, err error) {
+	if f.Method != 99 {
+		return 0, nil, fmt.Errorf("got method %d; want 99", f.Method)
+	}
+	var strength byte
+	extra := f.Extra
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		if len(extra) < 4+size {
+			break
+		} else if id == 0x9901 && size == 7 &&
+			string(extra[6:8]) == "AE" {
+			strength = extra[8]
+			method = binary.LittleEndian.Uint16(extra[9:])
+		}
+		extra = extra[4+size:]
+	}
+	wantStrength := map[filesys.ZipEncryption]byte{
+		filesys.ZipAES128: 1,
+		filesys.ZipAES192: 2,
+		filesys.ZipAES256: 3,
+	}[enc]
+	if strength != wantStrength {
+		return 0, nil, fmt.Errorf("got AES strength %d; want %d",
+			strength, wantStrength)
+	}
+	keyLen := 8 + 8*int(strength)
+	saltLen := keyLen / 2
+	if len(ct) < saltLen+2+10 {
+		return 0, nil, fmt.Errorf("data too short (%d bytes)", len(ct))
+	}
+	dk := filesys.PBKDF2SHA1(pwd, ct[:saltLen], 1000, 2*keyLen+2)
+	if !bytes.Equal(dk[2*keyLen:], ct[saltLen:saltLen+2]) {
+		return 0, nil, fmt.Errorf("password verification value mismatch")
+	}
+	data := ct[saltLen+2 : len(ct)-10]
+	mac := hmac.New(sha1.New, dk[keyLen:2*keyLen])
+	mac.Write(data)
+	if !hmac.Equal(mac.Sum(nil)[:10], ct[len(ct)-10:]) {
+		return 0, nil, fmt.Errorf("authentication code mismatch")
+	}
+	block, err := aes.NewCipher(dk[:keyLen])
+	if err != nil {
+		return 0, nil, err
+	}
+	plain = make([]byte, len(data))
+	var ctr, stream [aes.BlockSize]byte
+	for i := range data {
+		if i%aes.BlockSize == 0 {
+			binary.LittleEndian.PutUint64(ctr[:], uint64(i/aes.BlockSize+1))
+			block.Encrypt(stream[:], ctr[:])
+		}
+		plain[i] = data[i] ^ stream[i%aes.BlockSize]
+	}
+	return
+}
@@ -0,0 +1,208 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package memo
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/donyori/gogo/container/cache"
+	"github.com/donyori/gogo/errors"
+)
+
+// Options are options for Memoize and New.
+//
+// A nil *Options is equivalent to a zero-value Options,
+// which memoizes all successful results forever.
+type Options struct {
+	// Capacity is the maximum number of results kept in the cache.
+	// When the cache is full, the least recently used result is evicted.
+	//
+	// Nonpositive values indicate that the capacity is unlimited.
+	Capacity int
+
+	// TTL is the time to live of each result.
+	// A result older than TTL is regarded as absent
+	// and is recomputed on the next call.
+	//
+	// Nonpositive values indicate that results never expire.
+	TTL time.Duration
+}
+
+// Memo is a memoized function with cache management methods.
+//
+// Its methods are safe for concurrent use by multiple goroutines.
+type Memo[K comparable, V any] interface {
+	// Get returns the result of the memoized function for key.
+	//
+	// If the result is cached and not expired, Get returns it directly.
+	// Otherwise, Get calls the memoized function.
+	// If other goroutines call Get with the same key meanwhile,
+	// they wait for that call and share its result
+	// instead of calling the function again.
+	//
+	// Only successful results (i.e., those with a nil error) are cached.
+	//
+	// If the memoized function panics, the panic is propagated
+	// to all callers waiting for that result, and nothing is cached.
+	Get(key K) (V, error)
+
+	// Forget removes the result for key from the cache.
+	//
+	// If a call for key is in progress,
+	// its result is still delivered to its callers but is not cached.
+	Forget(key K)
+
+	// Len returns the number of results in the cache,
+	// including expired ones that have not been removed yet.
+	Len() int
+
+	// Clear removes all results from the cache.
+	//
+	// Results of the calls in progress are not cached.
+	Clear()
+}
+
+// Memoize returns a memoized version of f.
+//
+// It is equivalent to New(f, opts).Get.
+// See Memo for details.
+//
+// Memoize panics if f is nil.
+func Memoize[K comparable, V any](
+	f func(key K) (V, error),
+	opts *Options,
+) func(key K) (V, error) {
+	if f == nil {
+		panic(errors.AutoMsg("f is nil"))
+	}
+	return New(f, opts).Get
+}
+
+// New creates a new Memo that memoizes f.
+//
+// New panics if f is nil.
+func New[K comparable, V any](
+	f func(key K) (V, error),
+	opts *Options,
+) Memo[K, V] {
+	if f == nil {
+		panic(errors.AutoMsg("f is nil"))
+	}
+	if opts == nil {
+		opts = new(Options)
+	}
+	capacity := opts.Capacity
+	if capacity <= 0 {
+		capacity = math.MaxInt
+	}
+	return &memo[K, V]{
+		f:     f,
+		lru:   cache.NewLRU(capacity, &cache.LRUOptions[K, V]{TTL: opts.TTL}),
+		calls: make(map[K]*call[V]),
+	}
+}
+
+// call represents a call to the memoized function in progress.
+type call[V any] struct {
+	done     chan struct{} // closed when the call finishes
+	value    V
+	err      error
+	panicked bool
+	pv       any // panic value
+}
+
+// memo is an implementation of interface Memo.
+type memo[K comparable, V any] struct {
+	f func(key K) (V, error)
+
+	mu    sync.Mutex
+	lru   cache.LRU[K, V] // cached results, protected by mu
+	calls map[K]*call[V]  // calls in progress
+}
+
+func (mm *memo[K, V]) Get(key K) (V, error) {
+	mm.mu.Lock()
+	if value, ok := mm.lru.Get(key); ok {
+		mm.mu.Unlock()
+		return value, nil
+	}
+	c := mm.calls[key]
+	if c != nil {
+		mm.mu.Unlock()
+		<-c.done
+		if c.panicked {
+			panic(c.pv)
+		}
+		return c.value, c.err
+	}
+	c = &call[V]{done: make(chan struct{})}
+	mm.calls[key] = c
+	mm.mu.Unlock()
+
+	mm.doCall(key, c)
+	if c.panicked {
+		panic(c.pv)
+	}
+	return c.value, c.err
+}
+
+func (mm *memo[K, V]) Forget(key K) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.lru.Remove(key)
+	delete(mm.calls, key)
+}
+
+func (mm *memo[K, V]) Len() int {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	return mm.lru.Len()
+}
+
+func (mm *memo[K, V]) Clear() {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.lru.Clear()
+	clear(mm.calls)
+}
+
+// doCall calls the memoized function with key,
+// records the result in c, and caches it if appropriate.
+//
+// It recovers the panic raised by the memoized function
+// and records it in c.
+func (mm *memo[K, V]) doCall(key K, c *call[V]) {
+	defer func() {
+		if e := recover(); e != nil {
+			c.panicked, c.pv = true, e
+		}
+		mm.mu.Lock()
+		if mm.calls[key] == c {
+			delete(mm.calls, key)
+			if !c.panicked && c.err == nil {
+				mm.lru.Set(key, c.value)
+			}
+		}
+		mm.mu.Unlock()
+		close(c.done)
+	}()
+	c.value, c.err = mm.f(key)
+}
@@ -0,0 +1,168 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package memo_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/function/memo"
+)
+
+var errTest = errors.New("test error")
+
+// newCounter returns a function that doubles its argument
+// (or returns errTest for negative arguments),
+// and a counter of the number of its calls.
+func newCounter() (f func(x int) (int, error), n *atomic.Int64) {
+	n = new(atomic.Int64)
+	f = func(x int) (int, error) {
+		n.Add(1)
+		if x < 0 {
+			return 0, errTest
+		}
+		return x * 2, nil
+	}
+	return
+}
+
+func TestMemoize(t *testing.T) {
+	f, n := newCounter()
+	mf := memo.Memoize(f, nil)
+	for range 3 {
+		if v, err := mf(2); v != 4 || err != nil {
+			t.Errorf("got (%d, %v); want (4, <nil>)", v, err)
+		}
+	}
+	if got := n.Load(); got != 1 {
+		t.Errorf("got %d call(s); want 1", got)
+	}
+	for range 2 {
+		if _, err := mf(-1); !errors.Is(err, errTest) {
+			t.Errorf("got error %v; want %v", err, errTest)
+		}
+	}
+	if got := n.Load(); got != 3 {
+		t.Errorf("got %d call(s) after errors; want 3", got)
+	}
+}
+
+func TestMemo_Capacity(t *testing.T) {
+	f, n := newCounter()
+	m := memo.New(f, &memo.Options{Capacity: 2})
+	for _, x := range []int{1, 2, 1, 3} { // 2 is evicted when adding 3
+		_, _ = m.Get(x)
+	}
+	if got := m.Len(); got != 2 {
+		t.Errorf("got Len %d; want 2", got)
+	}
+	n.Store(0)
+	_, _ = m.Get(1)
+	_, _ = m.Get(3)
+	if got := n.Load(); got != 0 {
+		t.Errorf("got %d call(s) for cached keys; want 0", got)
+	}
+	_, _ = m.Get(2)
+	if got := n.Load(); got != 1 {
+		t.Errorf("got %d call(s) for evicted key; want 1", got)
+	}
+}
+
+func TestMemo_TTL(t *testing.T) {
+	f, n := newCounter()
+	m := memo.New(f, &memo.Options{TTL: 20 * time.Millisecond})
+	_, _ = m.Get(1)
+	_, _ = m.Get(1)
+	if got := n.Load(); got != 1 {
+		t.Errorf("got %d call(s) before expiry; want 1", got)
+	}
+	time.Sleep(30 * time.Millisecond)
+	_, _ = m.Get(1)
+	if got := n.Load(); got != 2 {
+		t.Errorf("got %d call(s) after expiry; want 2", got)
+	}
+}
+
+func TestMemo_ForgetAndClear(t *testing.T) {
+	f, n := newCounter()
+	m := memo.New(f, nil)
+	_, _ = m.Get(1)
+	_, _ = m.Get(2)
+	m.Forget(1)
+	if got := m.Len(); got != 1 {
+		t.Errorf("got Len %d after Forget; want 1", got)
+	}
+	_, _ = m.Get(1)
+	if got := n.Load(); got != 3 {
+		t.Errorf("got %d call(s); want 3", got)
+	}
+	m.Clear()
+	if got := m.Len(); got != 0 {
+		t.Errorf("got Len %d after Clear; want 0", got)
+	}
+}
+
+func TestMemo_Deduplication(t *testing.T) {
+	const NumGoroutine = 8
+	var n atomic.Int64
+	release := make(chan struct{})
+	m := memo.New(func(x int) (int, error) {
+		n.Add(1)
+		<-release
+		return x + 1, nil
+	}, nil)
+	var wg sync.WaitGroup
+	wg.Add(NumGoroutine)
+	results := make([]int, NumGoroutine)
+	for i := range NumGoroutine {
+		go func() {
+			defer wg.Done()
+			results[i], _ = m.Get(10)
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if got := n.Load(); got != 1 {
+		t.Errorf("got %d call(s); want 1", got)
+	}
+	for i, r := range results {
+		if r != 11 {
+			t.Errorf("result %d - got %d; want 11", i, r)
+		}
+	}
+}
+
+func TestMemo_Panic(t *testing.T) {
+	m := memo.New(func(int) (int, error) {
+		panic("test panic")
+	}, nil)
+	defer func() {
+		if e := recover(); e != "test panic" {
+			t.Errorf("got panic %v; want test panic", e)
+		}
+		if got := m.Len(); got != 0 {
+			t.Errorf("got Len %d; want 0", got)
+		}
+	}()
+	_, _ = m.Get(1)
+}
@@ -0,0 +1,95 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package genid
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// Counter is a monotonic counter that generates sequential IDs
+// starting from 1.
+//
+// The zero value is ready to use.
+// A Counter must not be copied after first use.
+type Counter struct {
+	n atomic.Uint64
+}
+
+// NewCounter creates a new Counter whose first ID is start+1.
+func NewCounter(start uint64) *Counter {
+	c := new(Counter)
+	c.n.Store(start)
+	return c
+}
+
+// Next returns the next ID.
+//
+// The IDs returned by successive calls are strictly increasing
+// (until wrapping around after 1<<64-1).
+func (c *Counter) Next() uint64 {
+	return c.n.Add(1)
+}
+
+// Last returns the last ID returned by Next,
+// or the start value if Next has never been called.
+func (c *Counter) Last() uint64 {
+	return c.n.Load()
+}
+
+// PrefixCounter maintains an independent Counter for each prefix.
+//
+// The zero value is ready to use.
+// A PrefixCounter must not be copied after first use.
+type PrefixCounter struct {
+	m sync.Map // map from prefixes to *Counter
+}
+
+// Next returns the next ID of the sequence for prefix.
+//
+// The first ID of each prefix is 1.
+func (pc *PrefixCounter) Next(prefix string) uint64 {
+	return pc.counter(prefix).Next()
+}
+
+// NextString returns prefix followed by the decimal representation
+// of the next ID of the sequence for prefix,
+// such as "job-1", "job-2", and so on for prefix "job-".
+func (pc *PrefixCounter) NextString(prefix string) string {
+	return prefix + strconv.FormatUint(pc.Next(prefix), 10)
+}
+
+// Last returns the last ID of the sequence for prefix,
+// or 0 if no ID has been generated for prefix.
+func (pc *PrefixCounter) Last(prefix string) uint64 {
+	if v, ok := pc.m.Load(prefix); ok {
+		return v.(*Counter).Last()
+	}
+	return 0
+}
+
+// counter returns the Counter for prefix, creating it if necessary.
+func (pc *PrefixCounter) counter(prefix string) *Counter {
+	v, ok := pc.m.Load(prefix)
+	if !ok {
+		v, _ = pc.m.LoadOrStore(prefix, new(Counter))
+	}
+	return v.(*Counter)
+}
@@ -0,0 +1,147 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package genid_test
+
+import (
+	"math/rand/v2"
+	"sync"
+	"testing"
+
+	"github.com/donyori/gogo/genid"
+)
+
+func TestCounter(t *testing.T) {
+	var c genid.Counter
+	for i := uint64(1); i <= 3; i++ {
+		if got := c.Next(); got != i {
+			t.Errorf("got %d; want %d", got, i)
+		}
+	}
+	if got := c.Last(); got != 3 {
+		t.Errorf("Last - got %d; want 3", got)
+	}
+	if got := genid.NewCounter(10).Next(); got != 11 {
+		t.Errorf("NewCounter(10).Next - got %d; want 11", got)
+	}
+}
+
+func TestCounter_Concurrent(t *testing.T) {
+	const NumGoroutine, NumID = 8, 1000
+	var c genid.Counter
+	ids := make([][]uint64, NumGoroutine)
+	var wg sync.WaitGroup
+	wg.Add(NumGoroutine)
+	for i := range NumGoroutine {
+		go func() {
+			defer wg.Done()
+			for range NumID {
+				ids[i] = append(ids[i], c.Next())
+			}
+		}()
+	}
+	wg.Wait()
+	seen := make(map[uint64]bool, NumGoroutine*NumID)
+	for _, list := range ids {
+		for _, id := range list {
+			if seen[id] {
+				t.Fatalf("duplicate ID %d", id)
+			}
+			seen[id] = true
+		}
+	}
+	if got := c.Last(); got != NumGoroutine*NumID {
+		t.Errorf("Last - got %d; want %d", got, NumGoroutine*NumID)
+	}
+}
+
+func TestPrefixCounter(t *testing.T) {
+	var pc genid.PrefixCounter
+	want := []string{"job-1", "task-1", "job-2", "job-3", "task-2"}
+	prefixes := []string{"job-", "task-", "job-", "job-", "task-"}
+	for i, p := range prefixes {
+		if got := pc.NextString(p); got != want[i] {
+			t.Errorf("call %d - got %q; want %q", i, got, want[i])
+		}
+	}
+	if got := pc.Last("job-"); got != 3 {
+		t.Errorf("Last(job-) - got %d; want 3", got)
+	}
+	if got := pc.Last("none"); got != 0 {
+		t.Errorf("Last(none) - got %d; want 0", got)
+	}
+}
+
+func TestSnowflake(t *testing.T) {
+	sf := genid.NewSnowflake(&genid.SnowflakeOptions{Node: 5})
+	var last int64
+	for i := range 10000 {
+		id := sf.Next()
+		if id <= last {
+			t.Fatalf("ID %d - got %d; want greater than %d", i, id, last)
+		}
+		last = id
+		if _, node, _ := sf.Decompose(id); node != 5 {
+			t.Fatalf("ID %d - got node %d; want 5", i, node)
+		}
+	}
+}
+
+func TestSnowflake_Deterministic(t *testing.T) {
+	gen := func() []int64 {
+		sf := genid.NewSnowflake(&genid.SnowflakeOptions{
+			RandomNode: true,
+			Source:     rand.NewPCG(1, 2),
+		})
+		ids := make([]int64, 5000)
+		for i := range ids {
+			ids[i] = sf.Next()
+		}
+		return ids
+	}
+	a, b := gen(), gen()
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("ID %d - got %d and %d; want same", i, a[i], b[i])
+		}
+		if i > 0 && a[i] <= a[i-1] {
+			t.Fatalf("ID %d - got %d; want greater than %d", i, a[i], a[i-1])
+		}
+	}
+	sf := genid.NewSnowflake(&genid.SnowflakeOptions{Source: rand.NewPCG(1, 2)})
+	t0, _, seq0 := sf.Decompose(sf.Next())
+	for range 1<<genid.SnowflakeSequenceBits - 1 {
+		sf.Next()
+	}
+	t1, _, seq1 := sf.Decompose(sf.Next())
+	if seq0 != 0 || seq1 != 0 {
+		t.Errorf("got sequence numbers %d and %d; want 0 and 0", seq0, seq1)
+	}
+	if d := t1.Sub(t0).Milliseconds(); d != 1 {
+		t.Errorf("got logical time difference %dms; want 1ms", d)
+	}
+}
+
+func TestNewSnowflake_InvalidNode(t *testing.T) {
+	defer func() {
+		if e := recover(); e == nil {
+			t.Error("want panic but not")
+		}
+	}()
+	genid.NewSnowflake(&genid.SnowflakeOptions{Node: genid.MaxSnowflakeNode + 1})
+}
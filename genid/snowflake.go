@@ -0,0 +1,216 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package genid
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/randbytes"
+)
+
+// Bit widths of the fields of a Snowflake ID.
+//
+// From the most significant bit, a Snowflake ID consists of
+// one zero bit (the sign bit), a timestamp of SnowflakeTimeBits bits
+// (in milliseconds since the epoch), a node ID of SnowflakeNodeBits bits,
+// and a sequence number of SnowflakeSequenceBits bits.
+const (
+	SnowflakeTimeBits     = 41
+	SnowflakeNodeBits     = 10
+	SnowflakeSequenceBits = 12
+)
+
+// MaxSnowflakeNode is the maximum node ID of a Snowflake generator.
+const MaxSnowflakeNode = 1<<SnowflakeNodeBits - 1
+
+const (
+	maxSnowflakeTime     = 1<<SnowflakeTimeBits - 1
+	maxSnowflakeSequence = 1<<SnowflakeSequenceBits - 1
+)
+
+// DefaultSnowflakeEpoch is the epoch used
+// when SnowflakeOptions.Epoch is zero: 2020-01-01T00:00:00Z.
+var DefaultSnowflakeEpoch = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// SnowflakeOptions are options for NewSnowflake.
+//
+// A nil *SnowflakeOptions is equivalent to a zero-value SnowflakeOptions.
+type SnowflakeOptions struct {
+	// Epoch is the start time of the timestamps in the IDs.
+	//
+	// Zero value means DefaultSnowflakeEpoch.
+	Epoch time.Time
+
+	// Node is the node ID embedded in the IDs,
+	// to distinguish the IDs generated by different generators.
+	// It must be in the range [0, MaxSnowflakeNode].
+	Node int
+
+	// RandomNode indicates to choose the node ID pseudorandomly
+	// instead of using Node.
+	//
+	// The node ID is drawn from Source if Source is not nil,
+	// and from the global random source of math/rand/v2 otherwise.
+	RandomNode bool
+
+	// Source, if not nil, enables the deterministic mode,
+	// which is useful for testing.
+	//
+	// In the deterministic mode, the generator does not read the clock.
+	// Instead, it uses a logical timestamp that starts from
+	// a pseudorandom offset (no more than one day) drawn from Source
+	// via package randbytes, and advances by one millisecond
+	// each time the sequence numbers of the current timestamp run out.
+	// Thus, the generated IDs depend only on Source and other options.
+	//
+	// Source is used only in NewSnowflake.
+	Source rand.Source
+}
+
+// Snowflake is a generator of Snowflake-style 64-bit IDs,
+// which are positive, unique to a generator, and roughly time-ordered.
+//
+// The IDs generated by the same generator are strictly increasing.
+// Up to 1<<SnowflakeSequenceBits IDs can be generated per millisecond;
+// Next waits for the next millisecond if they run out.
+//
+// It is safe for concurrent use by multiple goroutines.
+type Snowflake struct {
+	epoch   time.Time
+	node    int64
+	logical bool
+
+	mu   sync.Mutex
+	last int64 // timestamp of the last ID
+	seq  int64 // sequence number of the last ID
+}
+
+// NewSnowflake creates a new Snowflake generator with the specified options.
+//
+// NewSnowflake panics if the node ID is out of range,
+// or if the epoch is later than the current time.
+func NewSnowflake(opts *SnowflakeOptions) *Snowflake {
+	if opts == nil {
+		opts = new(SnowflakeOptions)
+	}
+	sf := &Snowflake{
+		epoch:   opts.Epoch,
+		node:    int64(opts.Node),
+		logical: opts.Source != nil,
+		last:    -1,
+	}
+	if sf.epoch.IsZero() {
+		sf.epoch = DefaultSnowflakeEpoch
+	}
+	if !sf.logical && sf.epoch.After(time.Now()) {
+		panic(errors.AutoMsg("epoch is later than the current time"))
+	}
+	var r randbytes.Reader
+	if opts.Source != nil {
+		r = randbytes.NewReader(opts.Source)
+	}
+	if opts.RandomNode {
+		if r != nil {
+			sf.node = int64(readUint64(r, 2) & MaxSnowflakeNode)
+		} else {
+			sf.node = rand.Int64N(MaxSnowflakeNode + 1)
+		}
+	} else if sf.node < 0 || sf.node > MaxSnowflakeNode {
+		panic(errors.AutoMsg("node ID is out of range"))
+	}
+	if r != nil {
+		sf.last = int64(readUint64(r, 4) % uint64(24*time.Hour/time.Millisecond))
+		sf.seq = maxSnowflakeSequence // so that the first ID advances the timestamp
+	}
+	return sf
+}
+
+// Node returns the node ID of the generator.
+func (sf *Snowflake) Node() int {
+	return int(sf.node)
+}
+
+// Next returns the next ID.
+//
+// It panics if the timestamp overflows,
+// i.e., about 69 years after the epoch.
+func (sf *Snowflake) Next() int64 {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	var now int64
+	if !sf.logical {
+		now = sf.now()
+	}
+	if now > sf.last {
+		sf.last, sf.seq = now, 0
+	} else if sf.seq < maxSnowflakeSequence {
+		// The clock has not advanced or has moved backward;
+		// stay at the last timestamp to keep the IDs increasing.
+		sf.seq++
+	} else {
+		// The sequence numbers run out.
+		if sf.logical {
+			now = sf.last + 1
+		} else {
+			for now <= sf.last {
+				time.Sleep(time.Duration(sf.last-now+1) * time.Millisecond)
+				now = sf.now()
+			}
+		}
+		sf.last, sf.seq = now, 0
+	}
+	if sf.last > maxSnowflakeTime {
+		panic(errors.AutoMsg("timestamp overflows"))
+	}
+	return sf.last<<(SnowflakeNodeBits+SnowflakeSequenceBits) |
+		sf.node<<SnowflakeSequenceBits |
+		sf.seq
+}
+
+// Decompose splits id into its timestamp, node ID, and sequence number.
+//
+// The timestamp is interpreted relative to the epoch of the generator.
+// In the deterministic mode, it is a logical time rather than
+// the actual generation time.
+func (sf *Snowflake) Decompose(id int64) (t time.Time, node int, seq int) {
+	ms := id >> (SnowflakeNodeBits + SnowflakeSequenceBits)
+	t = sf.epoch.Add(time.Duration(ms) * time.Millisecond)
+	node = int(id >> SnowflakeSequenceBits & MaxSnowflakeNode)
+	seq = int(id & maxSnowflakeSequence)
+	return
+}
+
+// now returns the current time in milliseconds since the epoch.
+func (sf *Snowflake) now() int64 {
+	return time.Since(sf.epoch).Milliseconds()
+}
+
+// readUint64 reads n bytes from r and
+// returns them as a little-endian unsigned integer.
+func readUint64(r randbytes.Reader, n int) uint64 {
+	var x uint64
+	for i := range n {
+		c, _ := r.ReadByte() // the error is always nil
+		x |= uint64(c) << (8 * i)
+	}
+	return x
+}
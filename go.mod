@@ -1,3 +1,5 @@
 module github.com/donyori/gogo
 
-go 1.22.5
+go 1.23
+
+require github.com/klauspost/compress v1.18.2
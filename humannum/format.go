@@ -0,0 +1,176 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package humannum
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/donyori/gogo/constraints"
+)
+
+// FormatInt formats the integer x in decimal,
+// with sep inserted between every three digits
+// of the integer part, counting from the right.
+//
+// For example, FormatInt(-1234567, ",") returns "-1,234,567".
+//
+// If sep is empty, it is equivalent to formatting x by strconv.
+func FormatInt[T constraints.Integer](x T, sep string) string {
+	var s string
+	var zero T
+	if x < zero {
+		s = strconv.FormatInt(int64(x), 10)
+	} else {
+		s = strconv.FormatUint(uint64(x), 10)
+	}
+	return groupDigits(s, sep)
+}
+
+// FormatFloat formats the floating-point number x
+// in the form "-ddd.ddd" without an exponent,
+// with sep inserted between every three digits
+// of the integer part, counting from the right.
+//
+// prec is the number of digits after the decimal point.
+// The special precision -1 uses the smallest number of digits
+// necessary to represent x uniquely (see strconv.FormatFloat).
+//
+// For example, FormatFloat(1234567.891, 2, ",") returns "1,234,567.89".
+//
+// Infinities and NaN are formatted as "+Inf", "-Inf", and "NaN".
+func FormatFloat[T constraints.Float](x T, prec int, sep string) string {
+	bitSize := int(unsafe.Sizeof(x)) * 8
+	return groupDigits(strconv.FormatFloat(float64(x), 'f', prec, bitSize), sep)
+}
+
+// siPrefixes are the SI prefixes from 1e-12 to 1e18,
+// with an empty string for 1e0.
+var siPrefixes = [...]string{"p", "n", "µ", "m", "", "k", "M", "G", "T", "P", "E"}
+
+// siZeroIndex is the index of the empty prefix in siPrefixes.
+const siZeroIndex = 4
+
+// FormatSI formats the real number x with an SI suffix,
+// such that the absolute value of the number before the suffix
+// is in the range [1, 1000), if possible.
+// For example, FormatSI(1234, 3) returns "1.23k",
+// and FormatSI(0.0005, 2) returns "500µ".
+//
+// The supported suffixes are
+// "p", "n", "µ" (U+00B5 MICRO SIGN), "m",
+// "k", "M", "G", "T", "P", and "E".
+// The numbers out of the range of these suffixes
+// use the smallest or largest one.
+//
+// sig is the number of significant digits.
+// Trailing zeros after the decimal point are removed.
+// If sig is nonpositive, the smallest number of digits
+// necessary to represent the value uniquely is used.
+//
+// The result can be parsed back by Parse (up to rounding).
+//
+// Zero is formatted as "0".
+// Infinities and NaN are formatted as "+Inf", "-Inf", and "NaN".
+func FormatSI[T constraints.Real](x T, sig int) string {
+	f := float64(x)
+	switch {
+	case f == 0:
+		return "0"
+	case math.IsInf(f, 0), math.IsNaN(f):
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	idx := siZeroIndex + int(math.Floor(math.Log10(math.Abs(f))/3))
+	idx = min(max(idx, 0), len(siPrefixes)-1)
+	m := f / math.Pow10((idx-siZeroIndex)*3)
+	if sig <= 0 {
+		return strconv.FormatFloat(m, 'f', -1, 64) + siPrefixes[idx]
+	}
+	s := formatSig(m, sig)
+	if idx < len(siPrefixes)-1 {
+		// Rounding may carry the mantissa to 1000, e.g., 999.96 -> 1000.
+		if v, err := strconv.ParseFloat(s, 64); err == nil && math.Abs(v) >= 1000 {
+			idx++
+			s = formatSig(m/1000, sig)
+		}
+	}
+	return s + siPrefixes[idx]
+}
+
+// formatSig formats m with sig significant digits,
+// without an exponent and without trailing zeros after the decimal point.
+func formatSig(m float64, sig int) string {
+	// intDigits is the position of the most significant digit,
+	// which is nonpositive if the absolute value of m is less than 0.1.
+	intDigits := 1
+	if a := math.Abs(m); a > 0 {
+		intDigits = int(math.Floor(math.Log10(a))) + 1
+	}
+	prec := max(sig-intDigits, 0)
+	if intDigits > sig {
+		// Round to sig significant digits in the integer part.
+		p := math.Pow10(intDigits - sig)
+		m = math.Round(m/p) * p
+	}
+	s := strconv.FormatFloat(m, 'f', prec, 64)
+	if strings.IndexByte(s, '.') >= 0 {
+		s = strings.TrimRight(s, "0")
+		s = strings.TrimSuffix(s, ".")
+	}
+	if s == "-0" {
+		s = "0"
+	}
+	return s
+}
+
+// groupDigits inserts sep between every three digits
+// of the integer part of the decimal number string s.
+func groupDigits(s, sep string) string {
+	if sep == "" {
+		return s
+	}
+	start := 0
+	if len(s) > 0 && (s[0] == '-' || s[0] == '+') {
+		start = 1
+	}
+	end := start
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	n := end - start
+	if n <= 3 {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s) + (n-1)/3*len(sep))
+	b.WriteString(s[:start])
+	first := n % 3
+	if first == 0 {
+		first = 3
+	}
+	b.WriteString(s[start : start+first])
+	for i := start + first; i < end; i += 3 {
+		b.WriteString(sep)
+		b.WriteString(s[i : i+3])
+	}
+	b.WriteString(s[end:])
+	return b.String()
+}
@@ -0,0 +1,210 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package humannum_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/humannum"
+)
+
+func TestParse_Int64(t *testing.T) {
+	testCases := []struct {
+		s       string
+		want    int64
+		wantErr error
+	}{
+		{"0", 0, nil},
+		{"1_000_000", 1000000, nil},
+		{"-42", -42, nil},
+		{"+7", 7, nil},
+		{"1.2k", 1200, nil},
+		{"1.2K", 1200, nil},
+		{"3M", 3000000, nil},
+		{" 2 G ", 2000000000, nil},
+		{"1.5e3", 1500, nil},
+		{"9E", 9000000000000000000, nil},
+		{"10E", 0, humannum.ErrOutOfRange},
+		{"1.5", 0, humannum.ErrNotInteger},
+		{"1500m", 0, humannum.ErrNotInteger},
+		{"2000m", 2, nil},
+		{"", 0, humannum.ErrInvalidNumber},
+		{"k", 0, humannum.ErrInvalidNumber},
+		{"1__0", 0, humannum.ErrInvalidNumber},
+		{"_10", 0, humannum.ErrInvalidNumber},
+		{"10_", 0, humannum.ErrInvalidNumber},
+		{"1x", 0, humannum.ErrInvalidNumber},
+		{"1/2", 0, humannum.ErrInvalidNumber},
+		{"0x10", 0, humannum.ErrInvalidNumber},
+		{"1e", 0, humannum.ErrInvalidNumber},
+	}
+	for _, tc := range testCases {
+		t.Run("s="+tc.s, func(t *testing.T) {
+			got, err := humannum.Parse[int64](tc.s)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("got error %v; want %v", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("got %d; want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParse_SmallTypes(t *testing.T) {
+	if got, err := humannum.Parse[uint8]("255"); got != 255 || err != nil {
+		t.Errorf("uint8 255 - got (%d, %v); want (255, <nil>)", got, err)
+	}
+	if _, err := humannum.Parse[uint8]("256"); !errors.Is(err, humannum.ErrOutOfRange) {
+		t.Errorf("uint8 256 - got error %v; want %v", err, humannum.ErrOutOfRange)
+	}
+	if _, err := humannum.Parse[uint]("-1"); !errors.Is(err, humannum.ErrOutOfRange) {
+		t.Errorf("uint -1 - got error %v; want %v", err, humannum.ErrOutOfRange)
+	}
+	if got, err := humannum.Parse[int8]("-128"); got != -128 || err != nil {
+		t.Errorf("int8 -128 - got (%d, %v); want (-128, <nil>)", got, err)
+	}
+	if _, err := humannum.Parse[float32]("1e39"); !errors.Is(err, humannum.ErrOutOfRange) {
+		t.Errorf("float32 1e39 - got error %v; want %v", err, humannum.ErrOutOfRange)
+	}
+}
+
+func TestParse_Float64(t *testing.T) {
+	testCases := []struct {
+		s    string
+		want float64
+	}{
+		{"1.25", 1.25},
+		{".5k", 500},
+		{"2.5m", 0.0025},
+		{"3u", 3e-6},
+		{"3µ", 3e-6},
+		{"3μ", 3e-6},
+		{"4n", 4e-9},
+		{"5 p", 5e-12},
+		{"-1_000.5", -1000.5},
+	}
+	for _, tc := range testCases {
+		t.Run("s="+tc.s, func(t *testing.T) {
+			got, err := humannum.Parse[float64](tc.s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("got %v; want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFormatInt(t *testing.T) {
+	testCases := []struct {
+		x    int64
+		sep  string
+		want string
+	}{
+		{0, ",", "0"},
+		{999, ",", "999"},
+		{1000, ",", "1,000"},
+		{-1234567, ",", "-1,234,567"},
+		{123456, "_", "123_456"},
+		{1234567, "", "1234567"},
+		{math.MinInt64, ",", "-9,223,372,036,854,775,808"},
+	}
+	for _, tc := range testCases {
+		if got := humannum.FormatInt(tc.x, tc.sep); got != tc.want {
+			t.Errorf("x=%d, sep=%q - got %q; want %q", tc.x, tc.sep, got, tc.want)
+		}
+	}
+	if got := humannum.FormatInt(uint64(math.MaxUint64), ","); got != "18,446,744,073,709,551,615" {
+		t.Errorf("MaxUint64 - got %q", got)
+	}
+}
+
+func TestFormatFloat(t *testing.T) {
+	testCases := []struct {
+		x    float64
+		prec int
+		want string
+	}{
+		{1234567.891, 2, "1,234,567.89"},
+		{-1234.5, -1, "-1,234.5"},
+		{0.125, 3, "0.125"},
+		{999.9, 0, "1,000"},
+		{math.Inf(1), 2, "+Inf"},
+	}
+	for _, tc := range testCases {
+		if got := humannum.FormatFloat(tc.x, tc.prec, ","); got != tc.want {
+			t.Errorf("x=%v, prec=%d - got %q; want %q", tc.x, tc.prec, got, tc.want)
+		}
+	}
+	if got := humannum.FormatFloat(float32(0.1), -1, ","); got != "0.1" {
+		t.Errorf("float32 0.1 - got %q; want %q", got, "0.1")
+	}
+}
+
+func TestFormatSI(t *testing.T) {
+	testCases := []struct {
+		x    float64
+		sig  int
+		want string
+	}{
+		{0, 3, "0"},
+		{1, 3, "1"},
+		{1234, 3, "1.23k"},
+		{1200, 3, "1.2k"},
+		{-3e6, 2, "-3M"},
+		{0.0005, 2, "500µ"},
+		{999.96, 4, "1k"},
+		{999960, 3, "1M"},
+		{123456, 2, "120k"},
+		{1.5e-15, 2, "0.0015p"},
+		{2e21, 1, "2000E"},
+		{1234.5678, 0, "1.2345678k"},
+		{math.NaN(), 3, "NaN"},
+	}
+	for _, tc := range testCases {
+		if got := humannum.FormatSI(tc.x, tc.sig); got != tc.want {
+			t.Errorf("x=%v, sig=%d - got %q; want %q", tc.x, tc.sig, got, tc.want)
+		}
+	}
+	if got := humannum.FormatSI(2500, 2); got != "2.5k" {
+		t.Errorf("int 2500 - got %q; want %q", got, "2.5k")
+	}
+}
+
+func TestFormatSI_ParseRoundTrip(t *testing.T) {
+	for _, x := range []float64{1.5e-9, 0.25, 42, 7.5e4, 3.25e12} {
+		s := humannum.FormatSI(x, 0)
+		got, err := humannum.Parse[float64](s)
+		if err != nil {
+			t.Errorf("x=%v, s=%q - %v", x, s, err)
+		} else if math.Abs(got-x) > math.Abs(x)*1e-12 {
+			t.Errorf("x=%v, s=%q - got %v", x, s, got)
+		}
+	}
+}
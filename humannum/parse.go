@@ -0,0 +1,191 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package humannum
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/donyori/gogo/constraints"
+	"github.com/donyori/gogo/errors"
+)
+
+// ErrInvalidNumber is an error indicating that
+// the number string is malformed.
+//
+// The client should use errors.Is to test whether
+// an error is ErrInvalidNumber.
+var ErrInvalidNumber = errors.AutoNewCustom(
+	"invalid number",
+	errors.PrependFullPkgName,
+	0,
+)
+
+// ErrOutOfRange is an error indicating that
+// the number is out of the range of the target type.
+//
+// The client should use errors.Is to test whether
+// an error is ErrOutOfRange.
+var ErrOutOfRange = errors.AutoNewCustom(
+	"number out of range",
+	errors.PrependFullPkgName,
+	0,
+)
+
+// ErrNotInteger is an error indicating that
+// the number is not an integer but the target type is an integer type.
+//
+// The client should use errors.Is to test whether
+// an error is ErrNotInteger.
+var ErrNotInteger = errors.AutoNewCustom(
+	"number is not an integer",
+	errors.PrependFullPkgName,
+	0,
+)
+
+// suffixExp maps the SI suffixes to their decimal exponents.
+var suffixExp = map[string]int{
+	"":  0,
+	"p": -12,
+	"n": -9,
+	"u": -6,
+	"µ": -6, // U+00B5 MICRO SIGN
+	"μ": -6, // U+03BC GREEK SMALL LETTER MU
+	"m": -3,
+	"k": 3,
+	"K": 3,
+	"M": 6,
+	"G": 9,
+	"T": 12,
+	"P": 15,
+	"E": 18,
+}
+
+// numberRegexp matches a decimal number with optional sign, fractional part,
+// exponent, and underscores between digits.
+var numberRegexp = regexp.MustCompile(
+	`^[+-]?(?:\d(?:_?\d)*(?:\.(?:\d(?:_?\d)*)?)?|\.\d(?:_?\d)*)(?:[eE][+-]?\d(?:_?\d)*)?$`,
+)
+
+// Parse parses a human-friendly number string,
+// such as "1_000_000", "1.2k", "3M", "-2.5e3", and "10 µ".
+//
+// The string consists of a decimal number (possibly with a sign,
+// a fractional part, an exponent, and underscores between digits),
+// optionally followed by white space and an SI suffix.
+// The suffixes are case-sensitive:
+//   - "p", "n", "u" (or "µ", "μ"), "m": 1e-12, 1e-9, 1e-6, 1e-3.
+//   - "k" (or "K"), "M", "G", "T", "P", "E": 1e3, 1e6, 1e9, 1e12, 1e15, 1e18.
+//
+// The number is computed exactly before being converted to T.
+// If T is an integer type, the number must be an integer
+// within the range of T.
+// If T is a floating-point type, the number is rounded to the nearest
+// representable value, and it must not overflow T.
+//
+// If s is malformed, Parse reports ErrInvalidNumber.
+// If T is an integer type and the number is not an integer,
+// Parse reports ErrNotInteger.
+// If the number is out of the range of T, Parse reports ErrOutOfRange.
+// (To test whether err is ErrInvalidNumber, ErrNotInteger,
+// or ErrOutOfRange, use function errors.Is.)
+func Parse[T constraints.Real](s string) (T, error) {
+	r, err := parseRat(s)
+	if err != nil {
+		return 0, errors.AutoWrap(err)
+	}
+	switch kind := reflect.TypeFor[T]().Kind(); kind {
+	case reflect.Float32, reflect.Float64:
+		f, _ := r.Float64()
+		if kind == reflect.Float32 {
+			f32, _ := r.Float32()
+			f = float64(f32)
+		}
+		if math.IsInf(f, 0) {
+			return 0, errors.AutoWrap(fmt.Errorf("%w: %q", ErrOutOfRange, s))
+		}
+		return T(f), nil
+	}
+	if !r.IsInt() {
+		return 0, errors.AutoWrap(fmt.Errorf("%w: %q", ErrNotInteger, s))
+	}
+	n := r.Num()
+	var zero T
+	if zero-1 < zero { // signed
+		if n.IsInt64() {
+			i := n.Int64()
+			if v := T(i); int64(v) == i {
+				return v, nil
+			}
+		}
+	} else if n.IsUint64() {
+		u := n.Uint64()
+		if v := T(u); uint64(v) == u {
+			return v, nil
+		}
+	}
+	return 0, errors.AutoWrap(fmt.Errorf("%w: %q", ErrOutOfRange, s))
+}
+
+// MustParse is like Parse but panics if s cannot be parsed.
+func MustParse[T constraints.Real](s string) T {
+	x, err := Parse[T](s)
+	if err != nil {
+		panic(errors.AutoWrap(err))
+	}
+	return x
+}
+
+// parseRat parses s into an exact rational number.
+func parseRat(s string) (*big.Rat, error) {
+	t := strings.TrimSpace(s)
+	i := strings.LastIndexFunc(t, func(r rune) bool {
+		return r >= '0' && r <= '9' || r == '.'
+	})
+	num, suffix := t[:i+1], strings.TrimSpace(t[i+1:])
+	exp, ok := suffixExp[suffix]
+	if !ok || !numberRegexp.MatchString(num) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidNumber, s)
+	}
+	r, ok := new(big.Rat).SetString(strings.ReplaceAll(num, "_", ""))
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidNumber, s)
+	}
+	if exp != 0 {
+		p := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(exp))), nil)
+		if exp > 0 {
+			r.Mul(r, new(big.Rat).SetInt(p))
+		} else {
+			r.Quo(r, new(big.Rat).SetInt(p))
+		}
+	}
+	return r, nil
+}
+
+// abs returns the absolute value of x.
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
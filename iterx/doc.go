@@ -0,0 +1,30 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package iterx provides combinators for iterators
+// defined in the standard package iter,
+// such as Map, Filter, Reduce, Take, Drop, Zip, Chain, and Enumerate.
+//
+// The functions returning iterators are lazy:
+// they do not consume their input iterators until the results are iterated.
+// Unless otherwise specified, the returned iterators
+// can be iterated multiple times if their input iterators can.
+//
+// For better performance, all functions in this package are unsafe
+// for concurrency unless otherwise specified.
+package iterx
@@ -0,0 +1,378 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package iterx
+
+import (
+	"iter"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// Map returns an iterator over the results of applying f
+// to the values of seq.
+//
+// Map panics if seq or f is nil.
+func Map[V, R any](seq iter.Seq[V], f func(v V) R) iter.Seq[R] {
+	if seq == nil {
+		panic(errors.AutoMsg("seq is nil"))
+	} else if f == nil {
+		panic(errors.AutoMsg("f is nil"))
+	}
+	return func(yield func(R) bool) {
+		for v := range seq {
+			if !yield(f(v)) {
+				return
+			}
+		}
+	}
+}
+
+// Map2 returns an iterator over the results of applying f
+// to the pairs of seq.
+//
+// Map2 panics if seq or f is nil.
+func Map2[K, V, RK, RV any](
+	seq iter.Seq2[K, V],
+	f func(k K, v V) (RK, RV),
+) iter.Seq2[RK, RV] {
+	if seq == nil {
+		panic(errors.AutoMsg("seq is nil"))
+	} else if f == nil {
+		panic(errors.AutoMsg("f is nil"))
+	}
+	return func(yield func(RK, RV) bool) {
+		for k, v := range seq {
+			if !yield(f(k, v)) {
+				return
+			}
+		}
+	}
+}
+
+// Filter returns an iterator over the values of seq satisfying keep.
+//
+// Filter panics if seq or keep is nil.
+func Filter[V any](seq iter.Seq[V], keep func(v V) bool) iter.Seq[V] {
+	if seq == nil {
+		panic(errors.AutoMsg("seq is nil"))
+	} else if keep == nil {
+		panic(errors.AutoMsg("keep is nil"))
+	}
+	return func(yield func(V) bool) {
+		for v := range seq {
+			if keep(v) && !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Filter2 returns an iterator over the pairs of seq satisfying keep.
+//
+// Filter2 panics if seq or keep is nil.
+func Filter2[K, V any](
+	seq iter.Seq2[K, V],
+	keep func(k K, v V) bool,
+) iter.Seq2[K, V] {
+	if seq == nil {
+		panic(errors.AutoMsg("seq is nil"))
+	} else if keep == nil {
+		panic(errors.AutoMsg("keep is nil"))
+	}
+	return func(yield func(K, V) bool) {
+		for k, v := range seq {
+			if keep(k, v) && !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// Reduce applies f cumulatively to the values of seq,
+// from left to right, starting with init, and returns the final result.
+//
+// If seq yields no values, Reduce returns init.
+//
+// Reduce panics if seq or f is nil.
+func Reduce[V, R any](seq iter.Seq[V], init R, f func(acc R, v V) R) R {
+	if seq == nil {
+		panic(errors.AutoMsg("seq is nil"))
+	} else if f == nil {
+		panic(errors.AutoMsg("f is nil"))
+	}
+	acc := init
+	for v := range seq {
+		acc = f(acc, v)
+	}
+	return acc
+}
+
+// Take returns an iterator over the first n values of seq.
+//
+// If n is nonpositive, the returned iterator yields nothing
+// and never calls seq.
+//
+// Take panics if seq is nil.
+func Take[V any](seq iter.Seq[V], n int) iter.Seq[V] {
+	if seq == nil {
+		panic(errors.AutoMsg("seq is nil"))
+	}
+	return func(yield func(V) bool) {
+		if n <= 0 {
+			return
+		}
+		i := 0
+		for v := range seq {
+			i++
+			if !yield(v) || i >= n {
+				return
+			}
+		}
+	}
+}
+
+// Take2 returns an iterator over the first n pairs of seq.
+//
+// If n is nonpositive, the returned iterator yields nothing
+// and never calls seq.
+//
+// Take2 panics if seq is nil.
+func Take2[K, V any](seq iter.Seq2[K, V], n int) iter.Seq2[K, V] {
+	if seq == nil {
+		panic(errors.AutoMsg("seq is nil"))
+	}
+	return func(yield func(K, V) bool) {
+		if n <= 0 {
+			return
+		}
+		i := 0
+		for k, v := range seq {
+			i++
+			if !yield(k, v) || i >= n {
+				return
+			}
+		}
+	}
+}
+
+// Drop returns an iterator over the values of seq except the first n values.
+//
+// If n is nonpositive, the returned iterator yields all values of seq.
+//
+// Drop panics if seq is nil.
+func Drop[V any](seq iter.Seq[V], n int) iter.Seq[V] {
+	if seq == nil {
+		panic(errors.AutoMsg("seq is nil"))
+	}
+	return func(yield func(V) bool) {
+		i := 0
+		for v := range seq {
+			if i < n {
+				i++
+				continue
+			} else if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Drop2 returns an iterator over the pairs of seq except the first n pairs.
+//
+// If n is nonpositive, the returned iterator yields all pairs of seq.
+//
+// Drop2 panics if seq is nil.
+func Drop2[K, V any](seq iter.Seq2[K, V], n int) iter.Seq2[K, V] {
+	if seq == nil {
+		panic(errors.AutoMsg("seq is nil"))
+	}
+	return func(yield func(K, V) bool) {
+		i := 0
+		for k, v := range seq {
+			if i < n {
+				i++
+				continue
+			} else if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// Zip returns an iterator over the pairs of values from a and b
+// at the same position.
+//
+// The returned iterator stops when either a or b is exhausted.
+//
+// Zip panics if a or b is nil.
+func Zip[V1, V2 any](a iter.Seq[V1], b iter.Seq[V2]) iter.Seq2[V1, V2] {
+	if a == nil {
+		panic(errors.AutoMsg("a is nil"))
+	} else if b == nil {
+		panic(errors.AutoMsg("b is nil"))
+	}
+	return func(yield func(V1, V2) bool) {
+		next, stop := iter.Pull(b)
+		defer stop()
+		for v1 := range a {
+			v2, ok := next()
+			if !ok || !yield(v1, v2) {
+				return
+			}
+		}
+	}
+}
+
+// Chain returns an iterator that yields the values of seqs in order.
+//
+// Nil items in seqs are ignored.
+func Chain[V any](seqs ...iter.Seq[V]) iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, seq := range seqs {
+			if seq == nil {
+				continue
+			}
+			for v := range seq {
+				if !yield(v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Chain2 returns an iterator that yields the pairs of seqs in order.
+//
+// Nil items in seqs are ignored.
+func Chain2[K, V any](seqs ...iter.Seq2[K, V]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for _, seq := range seqs {
+			if seq == nil {
+				continue
+			}
+			for k, v := range seq {
+				if !yield(k, v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Enumerate returns an iterator over the values of seq
+// paired with their index, starting from 0.
+//
+// Enumerate panics if seq is nil.
+func Enumerate[V any](seq iter.Seq[V]) iter.Seq2[int, V] {
+	if seq == nil {
+		panic(errors.AutoMsg("seq is nil"))
+	}
+	return func(yield func(int, V) bool) {
+		i := 0
+		for v := range seq {
+			if !yield(i, v) {
+				return
+			}
+			i++
+		}
+	}
+}
+
+// Keys returns an iterator over the first elements of the pairs of seq.
+//
+// Keys panics if seq is nil.
+func Keys[K, V any](seq iter.Seq2[K, V]) iter.Seq[K] {
+	if seq == nil {
+		panic(errors.AutoMsg("seq is nil"))
+	}
+	return func(yield func(K) bool) {
+		for k := range seq {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the second elements of the pairs of seq.
+//
+// Values panics if seq is nil.
+func Values[K, V any](seq iter.Seq2[K, V]) iter.Seq[V] {
+	if seq == nil {
+		panic(errors.AutoMsg("seq is nil"))
+	}
+	return func(yield func(V) bool) {
+		for _, v := range seq {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Collect collects the values of seq into a new slice.
+//
+// Collect returns nil if seq yields no values.
+//
+// Collect panics if seq is nil.
+func Collect[V any](seq iter.Seq[V]) []V {
+	if seq == nil {
+		panic(errors.AutoMsg("seq is nil"))
+	}
+	var s []V
+	for v := range seq {
+		s = append(s, v)
+	}
+	return s
+}
+
+// Collect2 collects the pairs of seq into two new slices,
+// one for the first elements and the other for the second elements.
+//
+// Collect2 returns (nil, nil) if seq yields no pairs.
+//
+// Collect2 panics if seq is nil.
+func Collect2[K, V any](seq iter.Seq2[K, V]) (ks []K, vs []V) {
+	if seq == nil {
+		panic(errors.AutoMsg("seq is nil"))
+	}
+	for k, v := range seq {
+		ks, vs = append(ks, k), append(vs, v)
+	}
+	return
+}
+
+// CollectMap collects the pairs of seq into a new map.
+//
+// If seq yields two or more pairs with the same key,
+// the value of the last one is kept.
+//
+// CollectMap returns a non-nil empty map if seq yields no pairs.
+//
+// CollectMap panics if seq is nil.
+func CollectMap[K comparable, V any](seq iter.Seq2[K, V]) map[K]V {
+	if seq == nil {
+		panic(errors.AutoMsg("seq is nil"))
+	}
+	m := make(map[K]V)
+	for k, v := range seq {
+		m[k] = v
+	}
+	return m
+}
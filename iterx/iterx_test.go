@@ -0,0 +1,133 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package iterx_test
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/donyori/gogo/iterx"
+)
+
+func TestMapFilterReduce(t *testing.T) {
+	seq := slices.Values([]int{1, 2, 3, 4, 5, 6})
+	even := iterx.Filter(seq, func(v int) bool { return v%2 == 0 })
+	strs := iterx.Map(even, strconv.Itoa)
+	if got := iterx.Collect(strs); !reflect.DeepEqual(got, []string{"2", "4", "6"}) {
+		t.Errorf("got %v; want [2 4 6]", got)
+	}
+	sum := iterx.Reduce(even, 0, func(acc, v int) int { return acc + v })
+	if sum != 12 {
+		t.Errorf("got sum %d; want 12", sum)
+	}
+}
+
+func TestMap2Filter2(t *testing.T) {
+	seq := slices.All([]string{"a", "b", "c"})
+	m := iterx.Map2(seq, func(i int, s string) (string, int) { return s, i * 10 })
+	f := iterx.Filter2(m, func(_ string, v int) bool { return v > 0 })
+	got := iterx.CollectMap(f)
+	if want := map[string]int{"b": 10, "c": 20}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func TestTakeDrop(t *testing.T) {
+	s := []int{0, 1, 2, 3, 4}
+	testCases := []struct {
+		n                  int
+		wantTake, wantDrop []int
+	}{
+		{-1, nil, s},
+		{0, nil, s},
+		{2, []int{0, 1}, []int{2, 3, 4}},
+		{5, s, nil},
+		{7, s, nil},
+	}
+	for _, tc := range testCases {
+		t.Run("n="+strconv.Itoa(tc.n), func(t *testing.T) {
+			var pulled int
+			seq := func(yield func(int) bool) {
+				for _, v := range s {
+					pulled++
+					if !yield(v) {
+						return
+					}
+				}
+			}
+			if got := iterx.Collect(iterx.Take(seq, tc.n)); !reflect.DeepEqual(got, tc.wantTake) {
+				t.Errorf("Take - got %v; want %v", got, tc.wantTake)
+			}
+			if pulled > max(tc.n, 0) {
+				t.Errorf("Take pulled %d values; want at most %d", pulled, max(tc.n, 0))
+			}
+			if got := iterx.Collect(iterx.Drop(seq, tc.n)); !reflect.DeepEqual(got, tc.wantDrop) {
+				t.Errorf("Drop - got %v; want %v", got, tc.wantDrop)
+			}
+			ks, _ := iterx.Collect2(iterx.Take2(slices.All(s), tc.n))
+			if !reflect.DeepEqual(ks, tc.wantTake) {
+				t.Errorf("Take2 - got %v; want %v", ks, tc.wantTake)
+			}
+			_, vs := iterx.Collect2(iterx.Drop2(slices.All(s), tc.n))
+			if !reflect.DeepEqual(vs, tc.wantDrop) {
+				t.Errorf("Drop2 - got %v; want %v", vs, tc.wantDrop)
+			}
+		})
+	}
+}
+
+func TestZip(t *testing.T) {
+	a := slices.Values([]string{"a", "b", "c"})
+	b := slices.Values([]int{1, 2})
+	ks, vs := iterx.Collect2(iterx.Zip(a, b))
+	if !reflect.DeepEqual(ks, []string{"a", "b"}) || !reflect.DeepEqual(vs, []int{1, 2}) {
+		t.Errorf("got %v, %v; want [a b], [1 2]", ks, vs)
+	}
+	// Break early.
+	for k := range iterx.Zip(a, b) {
+		if k != "a" {
+			t.Errorf("got %q; want %q", k, "a")
+		}
+		break
+	}
+}
+
+func TestChainEnumerate(t *testing.T) {
+	seq := iterx.Chain(
+		slices.Values([]string{"x", "y"}),
+		nil,
+		slices.Values([]string{"z"}),
+	)
+	var got []string
+	for i, v := range iterx.Enumerate(seq) {
+		got = append(got, strconv.Itoa(i)+v)
+	}
+	if want := []string{"0x", "1y", "2z"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+	chained := iterx.Chain2(maps.All(map[int]int{1: 1}), maps.All(map[int]int{2: 4}))
+	keys := slices.Sorted(iterx.Keys(chained))
+	values := slices.Sorted(iterx.Values(chained))
+	if !reflect.DeepEqual(keys, []int{1, 2}) || !reflect.DeepEqual(values, []int{1, 4}) {
+		t.Errorf("got keys %v, values %v; want [1 2], [1 4]", keys, values)
+	}
+}
@@ -0,0 +1,28 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package logging provides a leveled logging facade with structured fields.
+//
+// The logger expands errors into fields,
+// understanding the errors from package github.com/donyori/gogo/errors
+// and the panic records from package
+// github.com/donyori/gogo/concurrency/framework.
+//
+// The logger writes records to a Sink.
+// This package provides sinks based on log/slog and on a plain io.Writer.
+package logging
@@ -0,0 +1,79 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package logging
+
+import (
+	"github.com/donyori/gogo/concurrency/framework"
+	"github.com/donyori/gogo/errors"
+)
+
+// ExpandError expands err into fields and retrieves its stack trace.
+//
+// The fields are as follows:
+//   - "error": the error message of err.
+//   - "error_funcs": the function names recorded in err,
+//     if err is an github.com/donyori/gogo/errors.AutoWrappedError.
+//   - "error_count": the number of errors,
+//     if err is (or wraps) an github.com/donyori/gogo/errors.ErrorList.
+//   - "error_code": the error code, if err is (or wraps) a CodeError.
+//   - "panic_goroutine" and "panic": the name of the goroutine
+//     and the panic content, if err is (or wraps) a
+//     github.com/donyori/gogo/concurrency/framework.PanicRecord.
+//   - The fields carried by err, if err is (or wraps) a FieldsError.
+//
+// The stack trace is that of the first StackError in
+// the Unwrap error tree of err.
+// If there is no such error, stack is nil.
+//
+// If err is nil, ExpandError returns (nil, nil).
+func ExpandError(err error) (fields []Field, stack []byte) {
+	if err == nil {
+		return
+	}
+	fields = append(fields, Field{Key: "error", Value: err.Error()})
+	if names, _ := errors.ListFunctionNamesInAutoWrappedErrors(
+		err); len(names) > 0 {
+		fields = append(fields, Field{Key: "error_funcs", Value: names})
+	}
+	var el errors.ErrorList
+	if errors.As(err, &el) {
+		fields = append(fields, Field{Key: "error_count", Value: el.Len()})
+	}
+	var ce CodeError
+	if errors.As(err, &ce) {
+		fields = append(fields, Field{Key: "error_code", Value: ce.Code()})
+	}
+	var pr framework.PanicRecord
+	if errors.As(err, &pr) {
+		fields = append(
+			fields,
+			Field{Key: "panic_goroutine", Value: pr.Name},
+			Field{Key: "panic", Value: pr.Content},
+		)
+	}
+	var fe FieldsError
+	if errors.As(err, &fe) {
+		fields = append(fields, fe.Fields()...)
+	}
+	var se StackError
+	if errors.As(err, &se) {
+		stack = se.Stack()
+	}
+	return
+}
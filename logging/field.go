@@ -0,0 +1,72 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package logging
+
+// Field is a key-value pair attached to a log record.
+type Field struct {
+	Key   string
+	Value any
+}
+
+// Any creates a Field with the specified key and value.
+func Any(key string, value any) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Err creates a Field with the key "error" and the error err.
+//
+// Unlike the parameter err of the method Error of Logger,
+// the error is not expanded.
+func Err(err error) Field {
+	return Field{Key: "error", Value: err}
+}
+
+// FieldsError is an error that carries fields.
+//
+// The logger appends its fields to the record
+// when expanding an error whose Unwrap error tree contains a FieldsError.
+type FieldsError interface {
+	error
+
+	// Fields returns the fields carried by the error.
+	Fields() []Field
+}
+
+// CodeError is an error that carries an error code.
+//
+// The logger adds a field with the key "error_code"
+// when expanding an error whose Unwrap error tree contains a CodeError.
+type CodeError interface {
+	error
+
+	// Code returns the error code.
+	Code() string
+}
+
+// StackError is an error that carries a stack trace.
+//
+// The logger attaches the stack trace to the record
+// when expanding an error whose Unwrap error tree contains a StackError.
+type StackError interface {
+	error
+
+	// Stack returns the formatted stack trace.
+	// It returns nil if the stack trace is unavailable.
+	Stack() []byte
+}
@@ -0,0 +1,68 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package logging
+
+import (
+	"log/slog"
+	"strconv"
+)
+
+// Level is the importance or severity of a log record.
+//
+// Its values are consistent with those of log/slog.Level.
+// The higher the level, the more important or severe the record.
+type Level int
+
+// Predefined levels.
+const (
+	LevelDebug Level = -4
+	LevelInfo  Level = 0
+	LevelWarn  Level = 4
+	LevelError Level = 8
+)
+
+// String returns the name of the level.
+//
+// The names of the predefined levels are
+// "DEBUG", "INFO", "WARN", and "ERROR".
+// For other levels, the name is that of the nearest predefined level
+// below it, followed by the offset (e.g., "INFO+2").
+func (lv Level) String() string {
+	name := func(base string, offset Level) string {
+		if offset == 0 {
+			return base
+		}
+		return base + "+" + strconv.Itoa(int(offset))
+	}
+	switch {
+	case lv < LevelInfo:
+		return name("DEBUG", lv-LevelDebug)
+	case lv < LevelWarn:
+		return name("INFO", lv-LevelInfo)
+	case lv < LevelError:
+		return name("WARN", lv-LevelWarn)
+	default:
+		return name("ERROR", lv-LevelError)
+	}
+}
+
+// SlogLevel returns the corresponding log/slog.Level.
+func (lv Level) SlogLevel() slog.Level {
+	return slog.Level(lv)
+}
@@ -0,0 +1,172 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package logging
+
+import (
+	"time"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// Record is a log record passed to a Sink.
+type Record struct {
+	// The time when the record is created.
+	Time time.Time
+
+	// The level of the record.
+	Level Level
+
+	// The log message.
+	Message string
+
+	// The fields attached to the record,
+	// including those attached to the logger.
+	Fields []Field
+
+	// The formatted stack trace, or nil if unavailable.
+	Stack []byte
+}
+
+// Sink is the destination of log records.
+//
+// Its methods must be safe for concurrent use by multiple goroutines.
+type Sink interface {
+	// Enabled reports whether the sink accepts the records
+	// at the specified level.
+	Enabled(level Level) bool
+
+	// Write writes the record.
+	//
+	// The sink must not retain r or its fields after Write returns.
+	Write(r *Record) error
+}
+
+// Logger is a leveled logger with structured fields.
+//
+// It is safe for concurrent use by multiple goroutines.
+type Logger interface {
+	// Enabled reports whether the logger emits the records
+	// at the specified level.
+	Enabled(level Level) bool
+
+	// Log emits a record with the specified level, message, and fields.
+	Log(level Level, msg string, fields ...Field)
+
+	// LogError is like Log, but also expands err into fields.
+	//
+	// See function ExpandError for details on the expansion.
+	// If err is nil, LogError is equivalent to Log.
+	LogError(level Level, msg string, err error, fields ...Field)
+
+	// Debug emits a record at LevelDebug.
+	Debug(msg string, fields ...Field)
+
+	// Info emits a record at LevelInfo.
+	Info(msg string, fields ...Field)
+
+	// Warn emits a record at LevelWarn.
+	Warn(msg string, fields ...Field)
+
+	// Error emits a record at LevelError, with err expanded into fields.
+	//
+	// See function ExpandError for details on the expansion.
+	Error(msg string, err error, fields ...Field)
+
+	// With returns a logger that attaches the specified fields
+	// to all its records, in addition to the fields of this logger.
+	With(fields ...Field) Logger
+}
+
+// New creates a new Logger that writes records to sink.
+//
+// errorHandler is called when sink reports an error on writing a record.
+// If errorHandler is nil, such errors are discarded.
+//
+// New panics if sink is nil.
+func New(sink Sink, errorHandler func(err error)) Logger {
+	if sink == nil {
+		panic(errors.AutoMsg("sink is nil"))
+	}
+	return &logger{sink: sink, eh: errorHandler}
+}
+
+// logger is an implementation of interface Logger.
+type logger struct {
+	sink   Sink
+	eh     func(err error)
+	fields []Field
+}
+
+func (lg *logger) Enabled(level Level) bool {
+	return lg.sink.Enabled(level)
+}
+
+func (lg *logger) Log(level Level, msg string, fields ...Field) {
+	lg.log(level, msg, nil, fields)
+}
+
+func (lg *logger) LogError(level Level, msg string, err error, fields ...Field) {
+	lg.log(level, msg, err, fields)
+}
+
+func (lg *logger) Debug(msg string, fields ...Field) {
+	lg.log(LevelDebug, msg, nil, fields)
+}
+
+func (lg *logger) Info(msg string, fields ...Field) {
+	lg.log(LevelInfo, msg, nil, fields)
+}
+
+func (lg *logger) Warn(msg string, fields ...Field) {
+	lg.log(LevelWarn, msg, nil, fields)
+}
+
+func (lg *logger) Error(msg string, err error, fields ...Field) {
+	lg.log(LevelError, msg, err, fields)
+}
+
+func (lg *logger) With(fields ...Field) Logger {
+	if len(fields) == 0 {
+		return lg
+	}
+	newFields := make([]Field, len(lg.fields)+len(fields))
+	copy(newFields[copy(newFields, lg.fields):], fields)
+	return &logger{sink: lg.sink, eh: lg.eh, fields: newFields}
+}
+
+// log is the common process of the methods that emit records.
+func (lg *logger) log(level Level, msg string, err error, fields []Field) {
+	if !lg.sink.Enabled(level) {
+		return
+	}
+	errFields, stack := ExpandError(err)
+	r := &Record{
+		Time:    time.Now(),
+		Level:   level,
+		Message: msg,
+		Fields:  make([]Field, 0, len(lg.fields)+len(errFields)+len(fields)),
+		Stack:   stack,
+	}
+	r.Fields = append(r.Fields, lg.fields...)
+	r.Fields = append(r.Fields, errFields...)
+	r.Fields = append(r.Fields, fields...)
+	if e := lg.sink.Write(r); e != nil && lg.eh != nil {
+		lg.eh(e)
+	}
+}
@@ -0,0 +1,177 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package logging_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/donyori/gogo/concurrency/framework"
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/logging"
+)
+
+type testStackError struct {
+	msg   string
+	stack []byte
+}
+
+func (e *testStackError) Error() string {
+	return e.msg
+}
+
+func (e *testStackError) Stack() []byte {
+	return e.stack
+}
+
+func (e *testStackError) Code() string {
+	return "E42"
+}
+
+func (e *testStackError) Fields() []logging.Field {
+	return []logging.Field{logging.Any("user", "alice")}
+}
+
+func TestLevel_String(t *testing.T) {
+	testCases := []struct {
+		level logging.Level
+		want  string
+	}{
+		{logging.LevelDebug, "DEBUG"},
+		{logging.LevelDebug + 1, "DEBUG+1"},
+		{logging.LevelInfo, "INFO"},
+		{logging.LevelInfo + 2, "INFO+2"},
+		{logging.LevelWarn, "WARN"},
+		{logging.LevelError, "ERROR"},
+		{logging.LevelError + 4, "ERROR+4"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.want, func(t *testing.T) {
+			if got := tc.level.String(); got != tc.want {
+				t.Errorf("got %q; want %q", got, tc.want)
+			}
+			if got := tc.level.SlogLevel().String(); got != tc.want {
+				t.Errorf("slog level %q; want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	lg := logging.New(logging.NewWriterSink(
+		&buf,
+		&logging.WriterSinkOptions{OmitTime: true},
+	), nil)
+	lg.Debug("not written")
+	lg.Info("hello", logging.Any("n", 1))
+	lg.With(logging.Any("component", "db")).
+		Warn("slow query", logging.Any("sql", "SELECT 1"))
+	lg.Error("failed", &testStackError{
+		msg:   "boom",
+		stack: []byte("main.f()\n\tmain.go:1\n"),
+	})
+	want := `INFO hello n=1
+WARN "slow query" component=db sql="SELECT 1"
+ERROR failed error=boom error_code=E42 user=alice
+	main.f()
+		main.go:1
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSlogSink(t *testing.T) {
+	var buf bytes.Buffer
+	h := slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelWarn,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	lg := logging.New(logging.NewSlogSink(h), nil)
+	if lg.Enabled(logging.LevelInfo) {
+		t.Error("LevelInfo is enabled")
+	}
+	lg.Info("not written")
+	lg.Error("panicked", framework.PanicRecord{Name: "worker 1", Content: "oops"})
+	want := `level=ERROR msg=panicked error="panic on goroutine worker 1: oops" ` +
+		`panic_goroutine="worker 1" panic=oops` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestExpandError(t *testing.T) {
+	err := errors.AutoWrap(errors.Combine(
+		errors.New("a"),
+		errors.New("b"),
+	))
+	fields, stack := logging.ExpandError(err)
+	if stack != nil {
+		t.Errorf("got stack %q; want nil", stack)
+	}
+	keys := make([]string, len(fields))
+	for i := range fields {
+		keys[i] = fields[i].Key
+	}
+	if got, want := strings.Join(keys, ","),
+		"error,error_funcs,error_count"; got != want {
+		t.Fatalf("got keys %s; want %s", got, want)
+	}
+	if names := fields[1].Value.([]string); len(names) != 1 ||
+		!strings.HasSuffix(names[0], "TestExpandError") {
+		t.Errorf("got error_funcs %q", names)
+	}
+	if n := fields[2].Value; n != 2 {
+		t.Errorf("got error_count %v; want 2", n)
+	}
+
+	fields, stack = logging.ExpandError(nil)
+	if fields != nil || stack != nil {
+		t.Errorf("got %v, %q; want nil, nil", fields, stack)
+	}
+}
+
+func TestLogger_ErrorHandler(t *testing.T) {
+	wantErr := errors.New("write failed")
+	var gotErr error
+	lg := logging.New(
+		logging.NewWriterSink(failingWriter{err: wantErr}, nil),
+		func(err error) { gotErr = err },
+	)
+	lg.Info("message")
+	if !errors.Is(gotErr, wantErr) {
+		t.Errorf("got %v; want %v", gotErr, wantErr)
+	}
+}
+
+type failingWriter struct {
+	err error
+}
+
+func (fw failingWriter) Write([]byte) (int, error) {
+	return 0, fw.err
+}
@@ -0,0 +1,182 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// NewSlogSink creates a Sink that writes records to the log/slog.Handler h.
+//
+// The fields are converted to attributes by log/slog.Any.
+// The stack trace, if any, is added as an attribute with the key "stack".
+//
+// NewSlogSink panics if h is nil.
+func NewSlogSink(h slog.Handler) Sink {
+	if h == nil {
+		panic(errors.AutoMsg("slog handler is nil"))
+	}
+	return &slogSink{h: h}
+}
+
+// slogSink is an implementation of interface Sink based on log/slog.Handler.
+type slogSink struct {
+	h slog.Handler
+}
+
+func (s *slogSink) Enabled(level Level) bool {
+	return s.h.Enabled(context.Background(), level.SlogLevel())
+}
+
+func (s *slogSink) Write(r *Record) error {
+	sr := slog.NewRecord(r.Time, r.Level.SlogLevel(), r.Message, 0)
+	for _, f := range r.Fields {
+		sr.AddAttrs(slog.Any(f.Key, f.Value))
+	}
+	if r.Stack != nil {
+		sr.AddAttrs(slog.String("stack", string(r.Stack)))
+	}
+	return errors.AutoWrap(s.h.Handle(context.Background(), sr))
+}
+
+// WriterSinkOptions are options for function NewWriterSink.
+type WriterSinkOptions struct {
+	// The minimum level of the records to write.
+	// The zero value is LevelInfo.
+	Level Level
+
+	// The layout to format the record time, used by time.Time.Format.
+	// Empty for using "2006-01-02T15:04:05.000Z07:00".
+	TimeLayout string
+
+	// True if not to write the record time.
+	OmitTime bool
+}
+
+// defaultTimeLayout is the default value of WriterSinkOptions.TimeLayout.
+const defaultTimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// NewWriterSink creates a Sink that writes records to w in plain text.
+//
+// Each record is written as a line in the following form:
+//
+//	<time> <level> <message> <key1>=<value1> <key2>=<value2> ...
+//
+// The message and values are quoted (in Go string literal)
+// if they are empty or contain white space,
+// quotation marks, equal signs, or non-printable characters.
+// The stack trace, if any, follows the line,
+// with each line of the stack trace indented by a tab.
+//
+// Each record is written by a single call to the method Write of w,
+// and the calls are serialized.
+//
+// If opts are nil, a zero-value WriterSinkOptions is used.
+//
+// NewWriterSink panics if w is nil.
+func NewWriterSink(w io.Writer, opts *WriterSinkOptions) Sink {
+	if w == nil {
+		panic(errors.AutoMsg("writer is nil"))
+	}
+	s := &writerSink{w: w}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.TimeLayout == "" {
+		s.opts.TimeLayout = defaultTimeLayout
+	}
+	return s
+}
+
+// writerSink is an implementation of interface Sink based on io.Writer.
+type writerSink struct {
+	m    sync.Mutex
+	w    io.Writer
+	opts WriterSinkOptions
+	buf  bytes.Buffer
+}
+
+func (s *writerSink) Enabled(level Level) bool {
+	return level >= s.opts.Level
+}
+
+func (s *writerSink) Write(r *Record) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.buf.Reset()
+	if !s.opts.OmitTime {
+		s.buf.WriteString(r.Time.Format(s.opts.TimeLayout))
+		s.buf.WriteByte(' ')
+	}
+	s.buf.WriteString(r.Level.String())
+	s.buf.WriteByte(' ')
+	s.buf.WriteString(quoteIfNeeded(r.Message))
+	for _, f := range r.Fields {
+		s.buf.WriteByte(' ')
+		s.buf.WriteString(quoteIfNeeded(f.Key))
+		s.buf.WriteByte('=')
+		s.buf.WriteString(quoteIfNeeded(formatValue(f.Value)))
+	}
+	s.buf.WriteByte('\n')
+	if len(r.Stack) > 0 {
+		for _, line := range strings.Split(
+			strings.TrimRight(string(r.Stack), "\n"), "\n") {
+			s.buf.WriteByte('\t')
+			s.buf.WriteString(line)
+			s.buf.WriteByte('\n')
+		}
+	}
+	_, err := s.w.Write(s.buf.Bytes())
+	return errors.AutoWrap(err)
+}
+
+// formatValue formats the field value v as a string.
+func formatValue(v any) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case error:
+		return x.Error()
+	case fmt.Stringer:
+		return x.String()
+	}
+	return fmt.Sprint(v)
+}
+
+// quoteIfNeeded quotes s in Go string literal if s is empty or
+// contains white space, quotation marks, equal signs,
+// or non-printable characters.
+func quoteIfNeeded(s string) string {
+	if s == "" || strings.IndexFunc(s, func(r rune) bool {
+		return r == '"' || r == '=' || unicode.IsSpace(r) || !unicode.IsPrint(r)
+	}) >= 0 {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,246 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package mapsx
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/donyori/gogo/container/mapping"
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/function/compare"
+)
+
+// ErrInvertConflict is an error indicating that
+// two or more keys are bound to the same value
+// so that the map cannot be inverted.
+//
+// The client should use errors.Is to test whether
+// an error is ErrInvertConflict.
+var ErrInvertConflict = errors.AutoNewCustom(
+	"multiple keys are bound to the same value",
+	errors.PrependFullPkgName,
+	0,
+)
+
+// Invert returns a new map from the values of m to their keys.
+//
+// If two or more keys are bound to the same value,
+// Invert reports ErrInvertConflict and returns a nil map.
+// (To test whether err is ErrInvertConflict, use function errors.Is.)
+// To resolve such conflicts, use InvertFunc or InvertMulti instead.
+//
+// Invert returns (nil, nil) if m is nil.
+func Invert[M ~map[K]V, K, V comparable](m M) (inv map[V]K, err error) {
+	if m == nil {
+		return
+	}
+	inv = make(map[V]K, len(m))
+	for k, v := range m {
+		if _, ok := inv[v]; ok {
+			return nil, errors.AutoWrap(fmt.Errorf(
+				"%w: %v", ErrInvertConflict, v))
+		}
+		inv[v] = k
+	}
+	return
+}
+
+// InvertFunc returns a new map from the values of m to their keys.
+//
+// If two or more keys are bound to the same value,
+// the key in the result is determined by resolve.
+// Its parameters are the value, the key currently in the result,
+// and another key bound to that value.
+// It returns the key to keep.
+// Note that the keys are visited in unspecified order,
+// so resolve should be independent of the order of its last two arguments
+// to get a deterministic result (e.g., keeping the minimum key).
+//
+// InvertFunc returns nil if m is nil.
+// It panics if resolve is nil.
+func InvertFunc[M ~map[K]V, K, V comparable](
+	m M,
+	resolve func(value V, current, another K) K,
+) map[V]K {
+	if resolve == nil {
+		panic(errors.AutoMsg("resolve is nil"))
+	} else if m == nil {
+		return nil
+	}
+	inv := make(map[V]K, len(m))
+	for k, v := range m {
+		if cur, ok := inv[v]; ok {
+			inv[v] = resolve(v, cur, k)
+		} else {
+			inv[v] = k
+		}
+	}
+	return inv
+}
+
+// InvertMulti returns a new map from the values of m to all their keys.
+//
+// If keyCmp is not nil, the keys bound to each value
+// are sorted in ascending order according to keyCmp.
+// Otherwise, the keys are in unspecified order.
+//
+// InvertMulti returns nil if m is nil.
+func InvertMulti[M ~map[K]V, K, V comparable](
+	m M,
+	keyCmp compare.CompareFunc[K],
+) map[V][]K {
+	if m == nil {
+		return nil
+	}
+	inv := make(map[V][]K, len(m))
+	for k, v := range m {
+		inv[v] = append(inv[v], k)
+	}
+	if keyCmp != nil {
+		for _, keys := range inv {
+			slices.SortFunc(keys, keyCmp)
+		}
+	}
+	return inv
+}
+
+// MergeWith returns a new map containing the entries of all maps in ms.
+//
+// If a key is present in two or more maps,
+// its value in the result is determined by resolve.
+// The maps are merged in order, and resolve is called with the key,
+// the value merged so far, and the value in the later map.
+// It returns the value to keep.
+// If resolve is nil, the value in the later map is kept.
+//
+// MergeWith returns nil if ms contains no non-nil maps.
+func MergeWith[M ~map[K]V, K comparable, V any](
+	resolve func(key K, current, later V) V,
+	ms ...M,
+) M {
+	var n int
+	var hasNonNil bool
+	for _, m := range ms {
+		n, hasNonNil = max(n, len(m)), hasNonNil || m != nil
+	}
+	if !hasNonNil {
+		return nil
+	}
+	r := make(M, n)
+	for _, m := range ms {
+		for k, v := range m {
+			if cur, ok := r[k]; ok && resolve != nil {
+				r[k] = resolve(k, cur, v)
+			} else {
+				r[k] = v
+			}
+		}
+	}
+	return r
+}
+
+// FilterKeys returns a new map containing the entries of m
+// whose key satisfies keep.
+//
+// FilterKeys returns nil if m is nil.
+// It panics if keep is nil.
+func FilterKeys[M ~map[K]V, K comparable, V any](
+	m M,
+	keep func(key K) bool,
+) M {
+	if keep == nil {
+		panic(errors.AutoMsg("keep is nil"))
+	}
+	return filter(m, func(k K, _ V) bool { return keep(k) })
+}
+
+// FilterValues returns a new map containing the entries of m
+// whose value satisfies keep.
+//
+// FilterValues returns nil if m is nil.
+// It panics if keep is nil.
+func FilterValues[M ~map[K]V, K comparable, V any](
+	m M,
+	keep func(value V) bool,
+) M {
+	if keep == nil {
+		panic(errors.AutoMsg("keep is nil"))
+	}
+	return filter(m, func(_ K, v V) bool { return keep(v) })
+}
+
+// filter is the implementation of FilterKeys and FilterValues.
+func filter[M ~map[K]V, K comparable, V any](
+	m M,
+	keep func(key K, value V) bool,
+) M {
+	if m == nil {
+		return nil
+	}
+	r := make(M)
+	for k, v := range m {
+		if keep(k, v) {
+			r[k] = v
+		}
+	}
+	return r
+}
+
+// ToEntries returns the entries of m as key-value pairs.
+//
+// If keyCmp is not nil, the entries are sorted in ascending order
+// of their keys according to keyCmp.
+// Otherwise, the entries are in unspecified order.
+//
+// ToEntries returns nil if m is empty.
+func ToEntries[M ~map[K]V, K comparable, V any](
+	m M,
+	keyCmp compare.CompareFunc[K],
+) []mapping.Entry[K, V] {
+	if len(m) == 0 {
+		return nil
+	}
+	entries := make([]mapping.Entry[K, V], 0, len(m))
+	for k, v := range m {
+		entries = append(entries, mapping.Entry[K, V]{Key: k, Value: v})
+	}
+	if keyCmp != nil {
+		slices.SortFunc(entries, func(a, b mapping.Entry[K, V]) int {
+			return keyCmp(a.Key, b.Key)
+		})
+	}
+	return entries
+}
+
+// FromEntries returns a new map containing the specified entries.
+//
+// If two or more entries have the same key,
+// the value of the last one is kept.
+//
+// FromEntries returns a non-nil empty map if entries are empty.
+func FromEntries[K comparable, V any](
+	entries []mapping.Entry[K, V],
+) map[K]V {
+	m := make(map[K]V, len(entries))
+	for _, entry := range entries {
+		m[entry.Key] = entry.Value
+	}
+	return m
+}
@@ -0,0 +1,115 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package mapsx_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/donyori/gogo/container/mapping"
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/function/compare"
+	"github.com/donyori/gogo/mapsx"
+)
+
+func TestInvert(t *testing.T) {
+	inv, err := mapsx.Invert(map[string]int{"a": 1, "b": 2})
+	if err != nil {
+		t.Fatal(err)
+	} else if want := map[int]string{1: "a", 2: "b"}; !reflect.DeepEqual(inv, want) {
+		t.Errorf("got %v; want %v", inv, want)
+	}
+	inv, err = mapsx.Invert(map[string]int{"a": 1, "b": 1})
+	if !errors.Is(err, mapsx.ErrInvertConflict) {
+		t.Errorf("got error %v; want ErrInvertConflict", err)
+	} else if inv != nil {
+		t.Errorf("got %v; want nil", inv)
+	}
+}
+
+func TestInvertFunc(t *testing.T) {
+	m := map[string]int{"c": 1, "a": 1, "b": 1, "d": 2}
+	got := mapsx.InvertFunc(m, func(_ int, cur, another string) string {
+		return min(cur, another)
+	})
+	if want := map[int]string{1: "a", 2: "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func TestInvertMulti(t *testing.T) {
+	m := map[string]int{"c": 1, "a": 1, "b": 1, "d": 2}
+	got := mapsx.InvertMulti(m, compare.OrderedCompare[string])
+	want := map[int][]string{1: {"a", "b", "c"}, 2: {"d"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func TestMergeWith(t *testing.T) {
+	m1 := map[string]int{"a": 1, "b": 2}
+	m2 := map[string]int{"b": 3, "c": 4}
+	m3 := map[string]int{"b": 5}
+	got := mapsx.MergeWith(func(_ string, cur, later int) int {
+		return cur + later
+	}, m1, nil, m2, m3)
+	if want := map[string]int{"a": 1, "b": 10, "c": 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+	got = mapsx.MergeWith(nil, m1, m2)
+	if want := map[string]int{"a": 1, "b": 3, "c": 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("nil resolve - got %v; want %v", got, want)
+	}
+	if got = mapsx.MergeWith[map[string]int](nil, nil, nil); got != nil {
+		t.Errorf("got %v; want nil", got)
+	}
+}
+
+func TestFilterKeysValues(t *testing.T) {
+	m := map[string]int{"apple": 1, "banana": 2, "avocado": 3}
+	got := mapsx.FilterKeys(m, func(k string) bool {
+		return strings.HasPrefix(k, "a")
+	})
+	if want := map[string]int{"apple": 1, "avocado": 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FilterKeys - got %v; want %v", got, want)
+	}
+	got = mapsx.FilterValues(m, func(v int) bool { return v%2 == 0 })
+	if want := map[string]int{"banana": 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FilterValues - got %v; want %v", got, want)
+	}
+}
+
+func TestToEntriesFromEntries(t *testing.T) {
+	m := map[string]int{"b": 2, "c": 3, "a": 1}
+	got := mapsx.ToEntries(m, compare.OrderedCompare[string])
+	want := []mapping.Entry[string, int]{
+		{Key: "a", Value: 1}, {Key: "b", Value: 2}, {Key: "c", Value: 3},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ToEntries - got %v; want %v", got, want)
+	}
+	got = mapsx.ToEntries(m, compare.CompareFunc[string](compare.OrderedCompare[string]).Reverse())
+	if got[0].Key != "c" || got[2].Key != "a" {
+		t.Errorf("ToEntries reverse - got %v", got)
+	}
+	if back := mapsx.FromEntries(got); !reflect.DeepEqual(back, m) {
+		t.Errorf("FromEntries - got %v; want %v", back, m)
+	}
+}
@@ -0,0 +1,205 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package optional
+
+import (
+	"fmt"
+	"iter"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// Optional is a value of type T that may or may not be present.
+//
+// The zero value of Optional is an Optional without a value (i.e., None).
+//
+// Optional is comparable if T is comparable.
+type Optional[T any] struct {
+	v  T
+	ok bool
+}
+
+// Some returns an Optional containing v.
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{v: v, ok: true}
+}
+
+// None returns an Optional without a value.
+func None[T any]() Optional[T] {
+	return Optional[T]{}
+}
+
+// Of returns Some(v) if ok is true, and None otherwise.
+//
+// It is useful to convert the results of the comma-ok idiom,
+// such as a map lookup, to an Optional.
+func Of[T any](v T, ok bool) Optional[T] {
+	if ok {
+		return Some(v)
+	}
+	return Optional[T]{}
+}
+
+// FromPtr returns Some(*p) if p is non-nil, and None otherwise.
+func FromPtr[T any](p *T) Optional[T] {
+	if p != nil {
+		return Some(*p)
+	}
+	return Optional[T]{}
+}
+
+// First returns an Optional containing the first value
+// yielded by the iterator seq, or None if seq yields nothing or is nil.
+func First[T any](seq iter.Seq[T]) Optional[T] {
+	if seq != nil {
+		for v := range seq {
+			return Some(v)
+		}
+	}
+	return Optional[T]{}
+}
+
+// IsSome reports whether the Optional contains a value.
+func (o Optional[T]) IsSome() bool {
+	return o.ok
+}
+
+// IsNone reports whether the Optional contains no value.
+func (o Optional[T]) IsNone() bool {
+	return !o.ok
+}
+
+// Get returns the value and true if the Optional contains a value.
+// Otherwise, it returns the zero value of T and false.
+func (o Optional[T]) Get() (v T, ok bool) {
+	return o.v, o.ok
+}
+
+// MustGet returns the value contained in the Optional.
+//
+// It panics if the Optional contains no value.
+func (o Optional[T]) MustGet() T {
+	if !o.ok {
+		panic(errors.AutoMsg("optional value is not present"))
+	}
+	return o.v
+}
+
+// OrElse returns the value if the Optional contains a value,
+// and v otherwise.
+func (o Optional[T]) OrElse(v T) T {
+	if o.ok {
+		return o.v
+	}
+	return v
+}
+
+// OrElseGet returns the value if the Optional contains a value.
+// Otherwise, it calls f and returns its result.
+//
+// f is not called if the Optional contains a value.
+// OrElseGet panics if f is nil and the Optional contains no value.
+func (o Optional[T]) OrElseGet(f func() T) T {
+	if o.ok {
+		return o.v
+	} else if f == nil {
+		panic(errors.AutoMsg("f is nil"))
+	}
+	return f()
+}
+
+// Or returns the Optional itself if it contains a value,
+// and other otherwise.
+func (o Optional[T]) Or(other Optional[T]) Optional[T] {
+	if o.ok {
+		return o
+	}
+	return other
+}
+
+// Filter returns the Optional itself if it contains a value
+// that satisfies the predicate, and None otherwise.
+//
+// Filter panics if predicate is nil and the Optional contains a value.
+func (o Optional[T]) Filter(predicate func(v T) bool) Optional[T] {
+	if !o.ok {
+		return o
+	} else if predicate == nil {
+		panic(errors.AutoMsg("predicate is nil"))
+	} else if predicate(o.v) {
+		return o
+	}
+	return Optional[T]{}
+}
+
+// Ptr returns a pointer to a copy of the value
+// if the Optional contains a value, and nil otherwise.
+func (o Optional[T]) Ptr() *T {
+	if !o.ok {
+		return nil
+	}
+	v := o.v
+	return &v
+}
+
+// All returns an iterator that yields the value
+// if the Optional contains a value, and yields nothing otherwise.
+func (o Optional[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		if o.ok {
+			yield(o.v)
+		}
+	}
+}
+
+// String returns "Some(<value>)" if the Optional contains a value,
+// where <value> is formatted by fmt.Sprint,
+// and "None" otherwise.
+func (o Optional[T]) String() string {
+	if o.ok {
+		return fmt.Sprintf("Some(%v)", o.v)
+	}
+	return "None"
+}
+
+// Map returns Some(f(v)) if o contains a value v, and None otherwise.
+//
+// f is not called if o contains no value.
+// Map panics if f is nil and o contains a value.
+func Map[T, U any](o Optional[T], f func(v T) U) Optional[U] {
+	if !o.ok {
+		return Optional[U]{}
+	} else if f == nil {
+		panic(errors.AutoMsg("f is nil"))
+	}
+	return Some(f(o.v))
+}
+
+// FlatMap returns f(v) if o contains a value v, and None otherwise.
+//
+// f is not called if o contains no value.
+// FlatMap panics if f is nil and o contains a value.
+func FlatMap[T, U any](o Optional[T], f func(v T) Optional[U]) Optional[U] {
+	if !o.ok {
+		return Optional[U]{}
+	} else if f == nil {
+		panic(errors.AutoMsg("f is nil"))
+	}
+	return f(o.v)
+}
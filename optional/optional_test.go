@@ -0,0 +1,148 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package optional_test
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/donyori/gogo/optional"
+)
+
+func TestOptional_Basic(t *testing.T) {
+	some, none := optional.Some(3), optional.None[int]()
+	if !some.IsSome() || some.IsNone() {
+		t.Error("Some(3) - got None; want Some")
+	}
+	if none.IsSome() || !none.IsNone() {
+		t.Error("None - got Some; want None")
+	}
+	var zero optional.Optional[int]
+	if zero != none {
+		t.Errorf("zero value - got %v; want None", zero)
+	}
+	if v, ok := some.Get(); v != 3 || !ok {
+		t.Errorf("Some(3).Get - got (%d, %t); want (3, true)", v, ok)
+	}
+	if v, ok := none.Get(); v != 0 || ok {
+		t.Errorf("None.Get - got (%d, %t); want (0, false)", v, ok)
+	}
+	if got := some.OrElse(7); got != 3 {
+		t.Errorf("Some(3).OrElse(7) - got %d; want 3", got)
+	}
+	if got := none.OrElse(7); got != 7 {
+		t.Errorf("None.OrElse(7) - got %d; want 7", got)
+	}
+	if got := none.OrElseGet(func() int { return 8 }); got != 8 {
+		t.Errorf("None.OrElseGet - got %d; want 8", got)
+	}
+	if got := none.Or(some); got != some {
+		t.Errorf("None.Or(Some(3)) - got %v; want Some(3)", got)
+	}
+	if got := some.Filter(func(v int) bool { return v > 5 }); got != none {
+		t.Errorf("Some(3).Filter(>5) - got %v; want None", got)
+	}
+	if p := some.Ptr(); p == nil || *p != 3 {
+		t.Errorf("Some(3).Ptr - got %v; want pointer to 3", p)
+	}
+	if p := none.Ptr(); p != nil {
+		t.Errorf("None.Ptr - got %v; want <nil>", p)
+	}
+	if got := some.String(); got != "Some(3)" {
+		t.Errorf("Some(3).String - got %q; want %q", got, "Some(3)")
+	}
+	if got := none.String(); got != "None" {
+		t.Errorf("None.String - got %q; want %q", got, "None")
+	}
+}
+
+func TestOptional_MustGet_Panic(t *testing.T) {
+	defer func() {
+		if e := recover(); e == nil {
+			t.Error("want panic but not")
+		}
+	}()
+	optional.None[int]().MustGet()
+}
+
+func TestOf(t *testing.T) {
+	m := map[string]int{"a": 1}
+	v, ok := m["a"]
+	if got := optional.Of(v, ok); got != optional.Some(1) {
+		t.Errorf("key a - got %v; want Some(1)", got)
+	}
+	v, ok = m["b"]
+	if got := optional.Of(v, ok); got != optional.None[int]() {
+		t.Errorf("key b - got %v; want None", got)
+	}
+}
+
+func TestFromPtr(t *testing.T) {
+	x := 5
+	if got := optional.FromPtr(&x); got != optional.Some(5) {
+		t.Errorf("got %v; want Some(5)", got)
+	}
+	if got := optional.FromPtr[int](nil); got != optional.None[int]() {
+		t.Errorf("got %v; want None", got)
+	}
+}
+
+func TestOptional_All(t *testing.T) {
+	if got := slices.Collect(optional.Some(3).All()); !slices.Equal(got, []int{3}) {
+		t.Errorf("Some(3) - got %v; want [3]", got)
+	}
+	if got := slices.Collect(optional.None[int]().All()); len(got) != 0 {
+		t.Errorf("None - got %v; want []", got)
+	}
+}
+
+func TestFirst(t *testing.T) {
+	if got := optional.First(slices.Values([]int{4, 5})); got != optional.Some(4) {
+		t.Errorf("got %v; want Some(4)", got)
+	}
+	if got := optional.First(maps.Keys(map[int]bool{})); got != optional.None[int]() {
+		t.Errorf("got %v; want None", got)
+	}
+}
+
+func TestMap(t *testing.T) {
+	got := optional.Map(optional.Some(3), strconv.Itoa)
+	if got != optional.Some("3") {
+		t.Errorf("Some(3) - got %v; want Some(3)", got)
+	}
+	got = optional.Map(optional.None[int](), strconv.Itoa)
+	if got != optional.None[string]() {
+		t.Errorf("None - got %v; want None", got)
+	}
+}
+
+func TestFlatMap(t *testing.T) {
+	parse := func(s string) optional.Optional[int] {
+		i, err := strconv.Atoi(s)
+		return optional.Of(i, err == nil)
+	}
+	if got := optional.FlatMap(optional.Some("12"), parse); got != optional.Some(12) {
+		t.Errorf("Some(12) - got %v; want Some(12)", got)
+	}
+	if got := optional.FlatMap(optional.Some("x"), parse); got != optional.None[int]() {
+		t.Errorf("Some(x) - got %v; want None", got)
+	}
+}
@@ -0,0 +1,157 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package regexpcache
+
+import (
+	"regexp"
+	"sync"
+
+	"github.com/donyori/gogo/container/cache"
+	"github.com/donyori/gogo/errors"
+)
+
+// DefaultCapacity is the capacity of the default cache
+// used by the package-level functions Get and MustGet.
+const DefaultCapacity = 256
+
+// Cache is a cache of compiled regular expressions
+// with least-recently-used (LRU) eviction.
+//
+// Its methods are safe for concurrent use by multiple goroutines.
+type Cache interface {
+	// Get returns the compiled regular expression of pattern.
+	//
+	// If pattern is not in the cache, Get compiles it by regexp.Compile
+	// and adds the result to the cache,
+	// evicting the least recently used one if the cache is full.
+	//
+	// If pattern cannot be compiled, Get returns nil and the error
+	// reported by regexp.Compile, and nothing is added to the cache.
+	Get(pattern string) (re *regexp.Regexp, err error)
+
+	// MustGet is like Get but panics if pattern cannot be compiled.
+	MustGet(pattern string) *regexp.Regexp
+
+	// Len returns the number of regular expressions in the cache.
+	Len() int
+
+	// Cap returns the capacity of the cache.
+	Cap() int
+
+	// Clear removes all regular expressions in the cache.
+	Clear()
+}
+
+// New creates a new Cache with the specified capacity.
+//
+// If posix is true, the cache compiles patterns by regexp.CompilePOSIX
+// instead of regexp.Compile.
+//
+// New panics if capacity is nonpositive.
+func New(capacity int, posix bool) Cache {
+	if capacity <= 0 {
+		panic(errors.AutoMsg("capacity is nonpositive"))
+	}
+	c := &reCache{
+		compile: regexp.Compile,
+		lru:     cache.NewLRU[string, *regexp.Regexp](capacity, nil),
+	}
+	if posix {
+		c.compile = regexp.CompilePOSIX
+	}
+	return c
+}
+
+// reCache is an implementation of interface Cache.
+type reCache struct {
+	compile func(expr string) (*regexp.Regexp, error)
+
+	mu  sync.Mutex
+	lru cache.LRU[string, *regexp.Regexp] // protected by mu
+}
+
+func (c *reCache) Get(pattern string) (re *regexp.Regexp, err error) {
+	c.mu.Lock()
+	re, ok := c.lru.Get(pattern)
+	c.mu.Unlock()
+	if ok {
+		return
+	}
+
+	// Compile without holding the lock
+	// so that other patterns can be retrieved meanwhile.
+	re, err = c.compile(pattern)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if added, ok := c.lru.Get(pattern); ok {
+		// Another goroutine has added the pattern.
+		return added, nil
+	}
+	c.lru.Set(pattern, re)
+	return
+}
+
+func (c *reCache) MustGet(pattern string) *regexp.Regexp {
+	re, err := c.Get(pattern)
+	if err != nil {
+		panic(errors.AutoWrap(err))
+	}
+	return re
+}
+
+func (c *reCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+func (c *reCache) Cap() int {
+	return c.lru.Cap()
+}
+
+func (c *reCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lru.Clear()
+}
+
+// defaultCache is the cache used by the package-level functions.
+var defaultCache = New(DefaultCapacity, false)
+
+// Get returns the compiled regular expression of pattern
+// from a package-level cache with capacity DefaultCapacity.
+//
+// See the method Get of Cache for details.
+func Get(pattern string) (re *regexp.Regexp, err error) {
+	re, err = defaultCache.Get(pattern)
+	return re, errors.AutoWrap(err)
+}
+
+// MustGet is like Get but panics if pattern cannot be compiled.
+func MustGet(pattern string) *regexp.Regexp {
+	re, err := defaultCache.Get(pattern)
+	if err != nil {
+		panic(errors.AutoWrap(err))
+	}
+	return re
+}
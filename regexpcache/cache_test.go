@@ -0,0 +1,104 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package regexpcache_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/donyori/gogo/regexpcache"
+)
+
+func TestCache_Get(t *testing.T) {
+	c := regexpcache.New(2, false)
+	re1 := c.MustGet(`a+b`)
+	if !re1.MatchString("aaab") {
+		t.Error("compiled pattern does not match")
+	}
+	if re := c.MustGet(`a+b`); re != re1 {
+		t.Error("pattern is compiled again")
+	}
+	c.MustGet(`c+`)
+	c.MustGet(`a+b`) // make "a+b" the most recently used
+	c.MustGet(`d+`)  // evict "c+"
+	if n := c.Len(); n != 2 {
+		t.Errorf("got Len %d; want 2", n)
+	}
+	if re := c.MustGet(`a+b`); re != re1 {
+		t.Error("the most recently used pattern is evicted")
+	}
+	c.Clear()
+	if n := c.Len(); n != 0 {
+		t.Errorf("got Len %d after Clear; want 0", n)
+	}
+	if re := c.MustGet(`a+b`); re == re1 {
+		t.Error("pattern is not compiled again after Clear")
+	}
+}
+
+func TestCache_Get_Error(t *testing.T) {
+	c := regexpcache.New(2, false)
+	if re, err := c.Get(`a(`); err == nil {
+		t.Errorf("got %v, nil error", re)
+	}
+	if n := c.Len(); n != 0 {
+		t.Errorf("got Len %d; want 0", n)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("MustGet does not panic")
+		}
+	}()
+	c.MustGet(`a(`)
+}
+
+func TestCache_POSIX(t *testing.T) {
+	c := regexpcache.New(1, true)
+	if got := c.MustGet(`a+|a+b`).FindString("aab"); got != "aab" {
+		t.Errorf("got %q; want leftmost-longest match %q", got, "aab")
+	}
+}
+
+func TestCache_Concurrent(t *testing.T) {
+	c := regexpcache.New(8, false)
+	var wg sync.WaitGroup
+	for i := range 16 {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := range 100 {
+				p := "x" + strconv.Itoa((i+j)%12)
+				if !c.MustGet(p).MatchString(p) {
+					t.Errorf("pattern %q does not match itself", p)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if n := c.Len(); n > c.Cap() {
+		t.Errorf("got Len %d; want at most %d", n, c.Cap())
+	}
+}
+
+func TestMustGet(t *testing.T) {
+	if re := regexpcache.MustGet(`^\d+$`); re != regexpcache.MustGet(`^\d+$`) {
+		t.Error("pattern is compiled again")
+	}
+}
@@ -0,0 +1,177 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package result
+
+import (
+	"fmt"
+
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/optional"
+)
+
+// Result is either a value of type T or an error.
+//
+// The zero value of Result is a successful Result
+// with the zero value of T.
+type Result[T any] struct {
+	v   T
+	err error
+}
+
+// Ok returns a successful Result containing v.
+func Ok[T any](v T) Result[T] {
+	return Result[T]{v: v}
+}
+
+// Err returns a failed Result containing err.
+//
+// Err panics if err is nil.
+func Err[T any](err error) Result[T] {
+	if err == nil {
+		panic(errors.AutoMsg("err is nil"))
+	}
+	return Result[T]{err: err}
+}
+
+// Of returns a Result from the return values of a function call
+// in the form of (T, error).
+//
+// If err is non-nil, the returned Result is failed and v is discarded.
+// Otherwise, the returned Result is successful and contains v.
+func Of[T any](v T, err error) Result[T] {
+	if err != nil {
+		return Result[T]{err: err}
+	}
+	return Result[T]{v: v}
+}
+
+// IsOk reports whether the Result is successful.
+func (r Result[T]) IsOk() bool {
+	return r.err == nil
+}
+
+// IsErr reports whether the Result is failed.
+func (r Result[T]) IsErr() bool {
+	return r.err != nil
+}
+
+// Get returns the value and error of the Result.
+//
+// If the Result is failed, the returned value is the zero value of T.
+func (r Result[T]) Get() (v T, err error) {
+	return r.v, r.err
+}
+
+// Value returns the value of the Result.
+//
+// If the Result is failed, it returns the zero value of T.
+func (r Result[T]) Value() T {
+	return r.v
+}
+
+// Err returns the error of the Result, or nil if the Result is successful.
+func (r Result[T]) Err() error {
+	return r.err
+}
+
+// Unwrap returns the value of the Result.
+//
+// It panics if the Result is failed.
+// The panic value is the error of the Result,
+// wrapped with the function name of the caller of Unwrap
+// (see errors.AutoWrapSkip for details).
+func (r Result[T]) Unwrap() T {
+	if r.err != nil {
+		panic(errors.AutoWrapSkip(r.err, 1))
+	}
+	return r.v
+}
+
+// UnwrapOr returns the value of the Result if it is successful,
+// and v otherwise.
+func (r Result[T]) UnwrapOr(v T) T {
+	if r.err != nil {
+		return v
+	}
+	return r.v
+}
+
+// UnwrapOrElse returns the value of the Result if it is successful.
+// Otherwise, it calls f with the error and returns its result.
+//
+// f is not called if the Result is successful.
+// UnwrapOrElse panics if f is nil and the Result is failed.
+func (r Result[T]) UnwrapOrElse(f func(err error) T) T {
+	if r.err == nil {
+		return r.v
+	} else if f == nil {
+		panic(errors.AutoMsg("f is nil"))
+	}
+	return f(r.err)
+}
+
+// Ok returns an Optional containing the value if the Result is successful,
+// and None otherwise.
+func (r Result[T]) Ok() optional.Optional[T] {
+	return optional.Of(r.v, r.err == nil)
+}
+
+// Is reports whether the Result is failed
+// and its error matches target according to errors.Is.
+func (r Result[T]) Is(target error) bool {
+	return r.err != nil && errors.Is(r.err, target)
+}
+
+// String returns "Ok(<value>)" if the Result is successful,
+// where <value> is formatted by fmt.Sprint,
+// and "Err(<error message>)" otherwise.
+func (r Result[T]) String() string {
+	if r.err != nil {
+		return fmt.Sprintf("Err(%v)", r.err)
+	}
+	return fmt.Sprintf("Ok(%v)", r.v)
+}
+
+// Map returns Ok(f(v)) if r is successful with value v.
+// Otherwise, it returns a failed Result with the error of r.
+//
+// f is not called if r is failed.
+// Map panics if f is nil and r is successful.
+func Map[T, U any](r Result[T], f func(v T) U) Result[U] {
+	if r.err != nil {
+		return Result[U]{err: r.err}
+	} else if f == nil {
+		panic(errors.AutoMsg("f is nil"))
+	}
+	return Result[U]{v: f(r.v)}
+}
+
+// AndThen returns Of(f(v)) if r is successful with value v.
+// Otherwise, it returns a failed Result with the error of r.
+//
+// f is not called if r is failed.
+// AndThen panics if f is nil and r is successful.
+func AndThen[T, U any](r Result[T], f func(v T) (U, error)) Result[U] {
+	if r.err != nil {
+		return Result[U]{err: r.err}
+	} else if f == nil {
+		panic(errors.AutoMsg("f is nil"))
+	}
+	return Of(f(r.v))
+}
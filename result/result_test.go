@@ -0,0 +1,128 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package result_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/optional"
+	"github.com/donyori/gogo/result"
+)
+
+var errTest = errors.New("test error")
+
+func TestResult_Basic(t *testing.T) {
+	ok, bad := result.Ok(3), result.Err[int](errTest)
+	if !ok.IsOk() || ok.IsErr() {
+		t.Error("Ok(3) - got failed; want successful")
+	}
+	if bad.IsOk() || !bad.IsErr() {
+		t.Error("Err - got successful; want failed")
+	}
+	if v, err := ok.Get(); v != 3 || err != nil {
+		t.Errorf("Ok(3).Get - got (%d, %v); want (3, <nil>)", v, err)
+	}
+	if v, err := bad.Get(); v != 0 || !errors.Is(err, errTest) {
+		t.Errorf("Err.Get - got (%d, %v); want (0, %v)", v, err, errTest)
+	}
+	if got := bad.Value(); got != 0 {
+		t.Errorf("Err.Value - got %d; want 0", got)
+	}
+	if got := ok.UnwrapOr(7); got != 3 {
+		t.Errorf("Ok(3).UnwrapOr(7) - got %d; want 3", got)
+	}
+	if got := bad.UnwrapOr(7); got != 7 {
+		t.Errorf("Err.UnwrapOr(7) - got %d; want 7", got)
+	}
+	if got := bad.UnwrapOrElse(func(error) int { return 8 }); got != 8 {
+		t.Errorf("Err.UnwrapOrElse - got %d; want 8", got)
+	}
+	if got := ok.Ok(); got != optional.Some(3) {
+		t.Errorf("Ok(3).Ok - got %v; want Some(3)", got)
+	}
+	if got := bad.Ok(); got != optional.None[int]() {
+		t.Errorf("Err.Ok - got %v; want None", got)
+	}
+	if !bad.Is(errTest) || ok.Is(errTest) {
+		t.Error("Is - got wrong result")
+	}
+	if got := ok.String(); got != "Ok(3)" {
+		t.Errorf("Ok(3).String - got %q; want %q", got, "Ok(3)")
+	}
+	if got := bad.String(); got != "Err(test error)" {
+		t.Errorf("Err.String - got %q; want %q", got, "Err(test error)")
+	}
+}
+
+func TestErr_NilPanic(t *testing.T) {
+	defer func() {
+		if e := recover(); e == nil {
+			t.Error("want panic but not")
+		}
+	}()
+	result.Err[int](nil)
+}
+
+func TestResult_Unwrap(t *testing.T) {
+	if got := result.Ok(3).Unwrap(); got != 3 {
+		t.Errorf("got %d; want 3", got)
+	}
+	defer func() {
+		e := recover()
+		err, ok := e.(error)
+		if !ok || !errors.Is(err, errTest) {
+			t.Errorf("got panic %v; want an error wrapping %v", e, errTest)
+		}
+	}()
+	result.Err[int](errTest).Unwrap()
+}
+
+func TestOf(t *testing.T) {
+	if got := result.Of(strconv.Atoi("12")); got != result.Ok(12) {
+		t.Errorf("got %v; want Ok(12)", got)
+	}
+	if got := result.Of(strconv.Atoi("x")); got.IsOk() {
+		t.Errorf("got %v; want failed", got)
+	}
+}
+
+func TestMap(t *testing.T) {
+	if got := result.Map(result.Ok(3), strconv.Itoa); got != result.Ok("3") {
+		t.Errorf("Ok(3) - got %v; want Ok(3)", got)
+	}
+	got := result.Map(result.Err[int](errTest), strconv.Itoa)
+	if !got.Is(errTest) {
+		t.Errorf("Err - got %v; want Err(%v)", got, errTest)
+	}
+}
+
+func TestAndThen(t *testing.T) {
+	if got := result.AndThen(result.Ok("12"), strconv.Atoi); got != result.Ok(12) {
+		t.Errorf("Ok(12) - got %v; want Ok(12)", got)
+	}
+	if got := result.AndThen(result.Ok("x"), strconv.Atoi); got.IsOk() {
+		t.Errorf("Ok(x) - got %v; want failed", got)
+	}
+	got := result.AndThen(result.Err[string](errTest), strconv.Atoi)
+	if !got.Is(errTest) {
+		t.Errorf("Err - got %v; want Err(%v)", got, errTest)
+	}
+}
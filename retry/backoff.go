@@ -0,0 +1,99 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package retry
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// Backoff is a function that returns the delay
+// before the next attempt, after the attempt-th attempt failed.
+//
+// attempt starts from 1.
+type Backoff func(attempt int) time.Duration
+
+// ConstantBackoff returns a Backoff that always returns d.
+//
+// It panics if d is negative.
+func ConstantBackoff(d time.Duration) Backoff {
+	if d < 0 {
+		panic(errors.AutoMsg("d is negative"))
+	}
+	return func(int) time.Duration {
+		return d
+	}
+}
+
+// ExponentialBackoff returns a Backoff whose delay starts from initial
+// and is multiplied by factor after each failed attempt,
+// that is, initial * factor^(attempt-1).
+//
+// If maxDelay is positive, the delay is capped by maxDelay.
+//
+// It panics if initial is negative or factor is less than 1.
+func ExponentialBackoff(initial time.Duration, factor float64,
+	maxDelay time.Duration) Backoff {
+	if initial < 0 {
+		panic(errors.AutoMsg("initial is negative"))
+	} else if factor < 1 || math.IsNaN(factor) {
+		panic(errors.AutoMsg("factor is less than 1"))
+	}
+	return func(attempt int) time.Duration {
+		if attempt < 1 {
+			attempt = 1
+		}
+		d := float64(initial) * math.Pow(factor, float64(attempt-1))
+		if maxDelay > 0 && d >= float64(maxDelay) {
+			return maxDelay
+		} else if d >= math.MaxInt64 {
+			return math.MaxInt64
+		}
+		return time.Duration(d)
+	}
+}
+
+// WithJitter returns a Backoff that randomizes the delay of b
+// uniformly in the range [d*(1-fraction), d*(1+fraction)],
+// where d is the delay returned by b.
+//
+// fraction is clipped to [0, 1].
+//
+// It panics if b is nil.
+func (b Backoff) WithJitter(fraction float64) Backoff {
+	if b == nil {
+		panic(errors.AutoMsg("b is nil"))
+	}
+	if fraction <= 0 || math.IsNaN(fraction) {
+		return b
+	} else if fraction > 1 {
+		fraction = 1
+	}
+	return func(attempt int) time.Duration {
+		d := float64(b(attempt))
+		d *= 1 - fraction + 2*fraction*rand.Float64()
+		if d >= math.MaxInt64 {
+			return math.MaxInt64
+		}
+		return time.Duration(d)
+	}
+}
@@ -0,0 +1,190 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package retry
+
+import (
+	"context"
+	"time"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// DefaultMaxAttempts is the maximum number of attempts
+// used when Policy.MaxAttempts is zero.
+const DefaultMaxAttempts = 3
+
+// Policy specifies how to retry a function.
+//
+// A nil *Policy is equivalent to a zero-value Policy,
+// which makes at most DefaultMaxAttempts attempts without delay,
+// and retries on any error.
+type Policy struct {
+	// MaxAttempts is the maximum number of attempts,
+	// including the first one.
+	//
+	// Zero means DefaultMaxAttempts.
+	// Negative values indicate that the number of attempts is unlimited,
+	// in which case the retry loop is bounded only by MaxElapsedTime,
+	// the context, and the retryable-error predicate.
+	MaxAttempts int
+
+	// Backoff returns the delay before each retry.
+	//
+	// A nil Backoff indicates no delay.
+	Backoff Backoff
+
+	// MaxElapsedTime is the maximum time elapsed since the first attempt
+	// within which a retry may start.
+	// If the next retry would start later than that, no more retries.
+	//
+	// Nonpositive values indicate that the elapsed time is unlimited.
+	MaxElapsedTime time.Duration
+
+	// Retryable reports whether an error returned by the function
+	// is worth a retry.
+	//
+	// A nil Retryable indicates that all errors are retryable,
+	// except for the errors marked by Permanent.
+	Retryable func(err error) bool
+
+	// OnRetry, if not nil, is called before waiting for each retry,
+	// with the number of the failed attempt (starting from 1),
+	// the error it returned, and the delay before the next attempt.
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+// permanentError is an error marked by Permanent.
+type permanentError struct {
+	err error
+}
+
+func (pe *permanentError) Error() string {
+	return pe.err.Error()
+}
+
+func (pe *permanentError) Unwrap() error {
+	return pe.err
+}
+
+// Permanent marks err as non-retryable.
+//
+// When the function called by Do or DoValue returns
+// an error marked by Permanent, the retry stops immediately,
+// and the original err is returned.
+//
+// Permanent returns nil if err is nil.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Do calls fn until it returns nil, according to policy.
+//
+// It returns nil if an attempt succeeds.
+// Otherwise, it returns the error of the last attempt.
+// If ctx is done while waiting for a retry,
+// it returns an error combining ctx.Err() and the error of the last attempt,
+// so that the client can use errors.Is to test both of them.
+//
+// Do panics if ctx or fn is nil.
+func Do(ctx context.Context, policy *Policy,
+	fn func(ctx context.Context) error) error {
+	if fn == nil {
+		panic(errors.AutoMsg("fn is nil"))
+	}
+	_, err := DoValue(ctx, policy, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+	return errors.AutoWrap(err)
+}
+
+// DoValue is like Do but for functions that return a value.
+//
+// It returns the value of the successful attempt.
+// If no attempt succeeds, it returns the zero value of T
+// along with the error as described in Do.
+//
+// DoValue panics if ctx or fn is nil.
+func DoValue[T any](ctx context.Context, policy *Policy,
+	fn func(ctx context.Context) (T, error)) (T, error) {
+	if ctx == nil {
+		panic(errors.AutoMsg("ctx is nil"))
+	} else if fn == nil {
+		panic(errors.AutoMsg("fn is nil"))
+	}
+	if policy == nil {
+		policy = new(Policy)
+	}
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	var zero T
+	var err error
+	start := time.Now()
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	for attempt := 1; ; attempt++ {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return zero, errors.AutoWrap(errors.Combine(ctxErr, err))
+		}
+		var value T
+		value, err = fn(ctx)
+		if err == nil {
+			return value, nil
+		}
+		var pe *permanentError
+		if errors.As(err, &pe) {
+			return zero, errors.AutoWrap(pe.err)
+		} else if maxAttempts > 0 && attempt >= maxAttempts ||
+			policy.Retryable != nil && !policy.Retryable(err) {
+			return zero, errors.AutoWrap(err)
+		}
+		var delay time.Duration
+		if policy.Backoff != nil {
+			delay = max(policy.Backoff(attempt), 0)
+		}
+		if policy.MaxElapsedTime > 0 &&
+			time.Since(start)+delay > policy.MaxElapsedTime {
+			return zero, errors.AutoWrap(err)
+		}
+		if policy.OnRetry != nil {
+			policy.OnRetry(attempt, err, delay)
+		}
+		if delay <= 0 {
+			continue
+		}
+		if timer == nil {
+			timer = time.NewTimer(delay)
+		} else {
+			timer.Reset(delay)
+		}
+		select {
+		case <-ctx.Done():
+			return zero, errors.AutoWrap(errors.Combine(ctx.Err(), err))
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,211 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package retry_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/retry"
+)
+
+var errTest = errors.New("test error")
+
+// failN returns a function that fails n times and then succeeds,
+// and a pointer to its call counter.
+func failN(n int) (fn func(ctx context.Context) error, calls *int) {
+	calls = new(int)
+	fn = func(context.Context) error {
+		*calls++
+		if *calls <= n {
+			return errTest
+		}
+		return nil
+	}
+	return
+}
+
+func TestDo_Success(t *testing.T) {
+	fn, calls := failN(2)
+	var retries []int
+	err := retry.Do(context.Background(), &retry.Policy{
+		OnRetry: func(attempt int, err error, _ time.Duration) {
+			if !errors.Is(err, errTest) {
+				t.Errorf("OnRetry got error %v; want %v", err, errTest)
+			}
+			retries = append(retries, attempt)
+		},
+	}, fn)
+	if err != nil {
+		t.Errorf("got error %v; want <nil>", err)
+	}
+	if *calls != 3 {
+		t.Errorf("got %d call(s); want 3", *calls)
+	}
+	if len(retries) != 2 || retries[0] != 1 || retries[1] != 2 {
+		t.Errorf("got retries %v; want [1 2]", retries)
+	}
+}
+
+func TestDo_MaxAttempts(t *testing.T) {
+	testCases := []struct {
+		maxAttempts int
+		wantCalls   int
+	}{
+		{0, retry.DefaultMaxAttempts},
+		{1, 1},
+		{5, 5},
+	}
+	for _, tc := range testCases {
+		fn, calls := failN(10)
+		err := retry.Do(context.Background(),
+			&retry.Policy{MaxAttempts: tc.maxAttempts}, fn)
+		if !errors.Is(err, errTest) {
+			t.Errorf("maxAttempts=%d - got error %v; want %v",
+				tc.maxAttempts, err, errTest)
+		}
+		if *calls != tc.wantCalls {
+			t.Errorf("maxAttempts=%d - got %d call(s); want %d",
+				tc.maxAttempts, *calls, tc.wantCalls)
+		}
+	}
+}
+
+func TestDo_Retryable(t *testing.T) {
+	errOther := errors.New("other error")
+	var calls int
+	err := retry.Do(context.Background(), &retry.Policy{
+		MaxAttempts: -1,
+		Retryable: func(err error) bool {
+			return errors.Is(err, errTest)
+		},
+	}, func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errTest
+		}
+		return errOther
+	})
+	if !errors.Is(err, errOther) {
+		t.Errorf("got error %v; want %v", err, errOther)
+	}
+	if calls != 3 {
+		t.Errorf("got %d call(s); want 3", calls)
+	}
+}
+
+func TestDo_Permanent(t *testing.T) {
+	var calls int
+	err := retry.Do(context.Background(), nil, func(context.Context) error {
+		calls++
+		return retry.Permanent(errTest)
+	})
+	if !errors.Is(err, errTest) {
+		t.Errorf("got error %v; want %v", err, errTest)
+	}
+	if calls != 1 {
+		t.Errorf("got %d call(s); want 1", calls)
+	}
+}
+
+func TestDo_MaxElapsedTime(t *testing.T) {
+	fn, calls := failN(1000)
+	err := retry.Do(context.Background(), &retry.Policy{
+		MaxAttempts:    -1,
+		Backoff:        retry.ConstantBackoff(10 * time.Millisecond),
+		MaxElapsedTime: 35 * time.Millisecond,
+	}, fn)
+	if !errors.Is(err, errTest) {
+		t.Errorf("got error %v; want %v", err, errTest)
+	}
+	if *calls < 2 || *calls > 4 {
+		t.Errorf("got %d call(s); want 2 to 4", *calls)
+	}
+}
+
+func TestDo_ContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fn, calls := failN(1000)
+	time.AfterFunc(20*time.Millisecond, cancel)
+	err := retry.Do(ctx, &retry.Policy{
+		MaxAttempts: -1,
+		Backoff:     retry.ConstantBackoff(time.Hour),
+	}, fn)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v; want %v", err, context.Canceled)
+	}
+	if !errors.Is(err, errTest) {
+		t.Errorf("got error %v; want also %v", err, errTest)
+	}
+	if *calls != 1 {
+		t.Errorf("got %d call(s); want 1", *calls)
+	}
+}
+
+func TestDoValue(t *testing.T) {
+	var calls int
+	v, err := retry.DoValue(context.Background(), nil,
+		func(context.Context) (int, error) {
+			calls++
+			if calls < 2 {
+				return -1, errTest
+			}
+			return 42, nil
+		})
+	if v != 42 || err != nil {
+		t.Errorf("got (%d, %v); want (42, <nil>)", v, err)
+	}
+	v, err = retry.DoValue(context.Background(), &retry.Policy{MaxAttempts: 1},
+		func(context.Context) (int, error) {
+			return -1, errTest
+		})
+	if v != 0 || !errors.Is(err, errTest) {
+		t.Errorf("got (%d, %v); want (0, %v)", v, err, errTest)
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	b := retry.ExponentialBackoff(time.Millisecond, 2, 10*time.Millisecond)
+	want := []time.Duration{
+		time.Millisecond,
+		2 * time.Millisecond,
+		4 * time.Millisecond,
+		8 * time.Millisecond,
+		10 * time.Millisecond,
+		10 * time.Millisecond,
+	}
+	for i, w := range want {
+		if got := b(i + 1); got != w {
+			t.Errorf("attempt %d - got %v; want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestBackoff_WithJitter(t *testing.T) {
+	b := retry.ConstantBackoff(100 * time.Millisecond).WithJitter(0.5)
+	for range 100 {
+		d := b(1)
+		if d < 50*time.Millisecond || d > 150*time.Millisecond {
+			t.Fatalf("got %v; want in [50ms, 150ms]", d)
+		}
+	}
+}
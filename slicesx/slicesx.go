@@ -0,0 +1,214 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package slicesx
+
+import "github.com/donyori/gogo/errors"
+
+// Group is a group of elements with the same key,
+// returned by GroupByOrdered.
+type Group[K comparable, S any] struct {
+	Key   K
+	Items S
+}
+
+// GroupBy groups the elements of s by their key computed by keyFn.
+//
+// The elements in each group are in the same order as in s.
+// The groups are newly allocated slices.
+//
+// GroupBy returns nil if s is empty.
+// It panics if keyFn is nil.
+func GroupBy[S ~[]E, E any, K comparable](s S, keyFn func(x E) K) map[K]S {
+	if keyFn == nil {
+		panic(errors.AutoMsg("keyFn is nil"))
+	} else if len(s) == 0 {
+		return nil
+	}
+	m := make(map[K]S)
+	for _, x := range s {
+		k := keyFn(x)
+		m[k] = append(m[k], x)
+	}
+	return m
+}
+
+// GroupByOrdered is like GroupBy,
+// but returns the groups in a slice, ordered by the first occurrence
+// of their key in s.
+//
+// GroupByOrdered returns nil if s is empty.
+// It panics if keyFn is nil.
+func GroupByOrdered[S ~[]E, E any, K comparable](
+	s S,
+	keyFn func(x E) K,
+) []Group[K, S] {
+	if keyFn == nil {
+		panic(errors.AutoMsg("keyFn is nil"))
+	} else if len(s) == 0 {
+		return nil
+	}
+	var groups []Group[K, S]
+	idxMap := make(map[K]int)
+	for _, x := range s {
+		k := keyFn(x)
+		i, ok := idxMap[k]
+		if !ok {
+			i = len(groups)
+			idxMap[k] = i
+			groups = append(groups, Group[K, S]{Key: k})
+		}
+		groups[i].Items = append(groups[i].Items, x)
+	}
+	return groups
+}
+
+// Chunk splits s into consecutive subslices of n elements.
+// The last subslice may have fewer than n elements.
+//
+// The subslices share the underlying array with s.
+//
+// Chunk returns nil if s is empty.
+// It panics if n is nonpositive.
+func Chunk[S ~[]E, E any](s S, n int) []S {
+	if n <= 0 {
+		panic(errors.AutoMsg("n is nonpositive"))
+	} else if len(s) == 0 {
+		return nil
+	}
+	chunks := make([]S, 0, (len(s)+n-1)/n)
+	for i := 0; i < len(s); i += n {
+		end := min(i+n, len(s))
+		chunks = append(chunks, s[i:end:end])
+	}
+	return chunks
+}
+
+// Windows returns all the sliding windows of size elements in s,
+// from left to right with step 1.
+//
+// The windows share the underlying array with s.
+//
+// Windows returns nil if len(s) < size.
+// It panics if size is nonpositive.
+func Windows[S ~[]E, E any](s S, size int) []S {
+	if size <= 0 {
+		panic(errors.AutoMsg("size is nonpositive"))
+	} else if len(s) < size {
+		return nil
+	}
+	windows := make([]S, len(s)-size+1)
+	for i := range windows {
+		windows[i] = s[i : i+size : i+size]
+	}
+	return windows
+}
+
+// Zip combines the elements of a and b at the same index by f.
+//
+// The length of the result is the minimum of len(a) and len(b).
+// The extra elements in the longer slice are ignored.
+//
+// Zip returns nil if a or b is empty.
+// It panics if f is nil.
+func Zip[S1 ~[]E1, S2 ~[]E2, E1, E2, R any](
+	a S1,
+	b S2,
+	f func(x E1, y E2) R,
+) []R {
+	if f == nil {
+		panic(errors.AutoMsg("f is nil"))
+	}
+	n := min(len(a), len(b))
+	if n == 0 {
+		return nil
+	}
+	r := make([]R, n)
+	for i := range r {
+		r[i] = f(a[i], b[i])
+	}
+	return r
+}
+
+// Unzip splits each element of s into two parts by f,
+// and returns the slices of the first and second parts.
+//
+// Unzip returns (nil, nil) if s is empty.
+// It panics if f is nil.
+func Unzip[S ~[]E, E, R1, R2 any](s S, f func(x E) (R1, R2)) ([]R1, []R2) {
+	if f == nil {
+		panic(errors.AutoMsg("f is nil"))
+	} else if len(s) == 0 {
+		return nil, nil
+	}
+	a, b := make([]R1, len(s)), make([]R2, len(s))
+	for i := range s {
+		a[i], b[i] = f(s[i])
+	}
+	return a, b
+}
+
+// Partition splits s into the elements satisfying pred (matched)
+// and the others (unmatched), keeping their relative order.
+//
+// The results share a single newly allocated array,
+// rather than the underlying array of s.
+//
+// Partition returns (nil, nil) if s is empty.
+// It panics if pred is nil.
+func Partition[S ~[]E, E any](s S, pred func(x E) bool) (matched, unmatched S) {
+	if pred == nil {
+		panic(errors.AutoMsg("pred is nil"))
+	} else if len(s) == 0 {
+		return
+	}
+	// Fill the matched elements from the front
+	// and the unmatched elements from the back,
+	// then reverse the unmatched elements.
+	buf := make(S, len(s))
+	i, j := 0, len(buf)
+	for _, x := range s {
+		if pred(x) {
+			buf[i] = x
+			i++
+		} else {
+			j--
+			buf[j] = x
+		}
+	}
+	matched, unmatched = buf[:i:i], buf[i:]
+	for l, r := 0, len(unmatched)-1; l < r; l, r = l+1, r-1 {
+		unmatched[l], unmatched[r] = unmatched[r], unmatched[l]
+	}
+	return
+}
+
+// FlatMap applies f to each element of s and concatenates the results.
+//
+// FlatMap returns nil if the result is empty.
+// It panics if f is nil.
+func FlatMap[S ~[]E, E, R any](s S, f func(x E) []R) []R {
+	if f == nil {
+		panic(errors.AutoMsg("f is nil"))
+	}
+	var r []R
+	for _, x := range s {
+		r = append(r, f(x)...)
+	}
+	return r
+}
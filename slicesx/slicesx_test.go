@@ -0,0 +1,139 @@
+// gogo.  A Go (Golang) toolbox.
+// Copyright (C) 2019-2024  Yuan Gao
+//
+// This file is part of gogo.
+//
+// gogo is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package slicesx_test
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/donyori/gogo/slicesx"
+)
+
+func TestGroupBy(t *testing.T) {
+	s := []int{1, 2, 3, 4, 5, 6, 7}
+	got := slicesx.GroupBy(s, func(x int) int { return x % 3 })
+	want := map[int][]int{0: {3, 6}, 1: {1, 4, 7}, 2: {2, 5}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+	if got := slicesx.GroupBy([]int(nil), strconv.Itoa); got != nil {
+		t.Errorf("got %v; want nil", got)
+	}
+}
+
+func TestGroupByOrdered(t *testing.T) {
+	s := []string{"banana", "apple", "blueberry", "cherry", "avocado"}
+	got := slicesx.GroupByOrdered(s, func(x string) byte { return x[0] })
+	want := []slicesx.Group[byte, []string]{
+		{Key: 'b', Items: []string{"banana", "blueberry"}},
+		{Key: 'a', Items: []string{"apple", "avocado"}},
+		{Key: 'c', Items: []string{"cherry"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func TestChunk(t *testing.T) {
+	testCases := []struct {
+		s    []int
+		n    int
+		want [][]int
+	}{
+		{nil, 2, nil},
+		{[]int{1}, 2, [][]int{{1}}},
+		{[]int{1, 2, 3, 4}, 2, [][]int{{1, 2}, {3, 4}}},
+		{[]int{1, 2, 3, 4, 5}, 2, [][]int{{1, 2}, {3, 4}, {5}}},
+		{[]int{1, 2, 3}, 5, [][]int{{1, 2, 3}}},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("s=%v&n=%d", tc.s, tc.n), func(t *testing.T) {
+			got := slicesx.Chunk(tc.s, tc.n)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v; want %v", got, tc.want)
+			}
+			for i, c := range got {
+				if len(c) != cap(c) {
+					t.Errorf("chunk %d: len %d != cap %d", i, len(c), cap(c))
+				}
+			}
+		})
+	}
+}
+
+func TestWindows(t *testing.T) {
+	got := slicesx.Windows([]int{1, 2, 3, 4}, 3)
+	want := [][]int{{1, 2, 3}, {2, 3, 4}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+	if got := slicesx.Windows([]int{1, 2}, 3); got != nil {
+		t.Errorf("got %v; want nil", got)
+	}
+}
+
+func TestZipUnzip(t *testing.T) {
+	type kv struct {
+		k string
+		v int
+	}
+	got := slicesx.Zip(
+		[]string{"a", "b", "c"},
+		[]int{1, 2},
+		func(k string, v int) kv { return kv{k, v} },
+	)
+	want := []kv{{"a", 1}, {"b", 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+	ks, vs := slicesx.Unzip(got, func(x kv) (string, int) { return x.k, x.v })
+	if !reflect.DeepEqual(ks, []string{"a", "b"}) ||
+		!reflect.DeepEqual(vs, []int{1, 2}) {
+		t.Errorf("got %v, %v; want [a b], [1 2]", ks, vs)
+	}
+}
+
+func TestPartition(t *testing.T) {
+	s := []int{1, 2, 3, 4, 5, 6, 7}
+	even, odd := slicesx.Partition(s, func(x int) bool { return x%2 == 0 })
+	if !reflect.DeepEqual(even, []int{2, 4, 6}) ||
+		!reflect.DeepEqual(odd, []int{1, 3, 5, 7}) {
+		t.Errorf("got %v, %v; want [2 4 6], [1 3 5 7]", even, odd)
+	}
+	even = append(even, 100)
+	if odd[0] != 1 {
+		t.Error("appending to matched overwrites unmatched")
+	}
+}
+
+func TestFlatMap(t *testing.T) {
+	got := slicesx.FlatMap([]int{0, 1, 2, 3}, func(x int) []string {
+		r := make([]string, x)
+		for i := range r {
+			r[i] = strconv.Itoa(x)
+		}
+		return r
+	})
+	want := []string{"1", "2", "2", "3", "3", "3"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}